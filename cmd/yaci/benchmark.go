@@ -0,0 +1,148 @@
+package yaci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/manifest-network/yaci/internal/benchmark"
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/utils"
+)
+
+var BenchmarkCmd = &cobra.Command{
+	Use:   "benchmark [address] [flags]",
+	Args:  cobra.ExactArgs(1),
+	Short: "Measure the latency and the throughput of a node to tune the extraction",
+	Long: `Send requests to the block, transaction and optionally block results endpoints of a gRPC server at several
+concurrency levels, from the latest height down, and report their latency percentiles, error rate and throughput,
+along with the --max-concurrency and --max-retries settings recommended for extracting from that node.
+
+The requests are sent once, without retries, so that the errors of the node are measured.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := cmd.Flags()
+		insecure, _ := flags.GetBool("insecure")
+		maxRecvMsgSize, _ := flags.GetInt("max-recv-msg-size")
+		levels, _ := flags.GetUintSlice("concurrency")
+		requests, _ := flags.GetInt("requests")
+		withResults, _ := flags.GetBool("block-results")
+		asJSON, _ := flags.GetBool("json")
+		if requests <= 0 {
+			return fmt.Errorf("requests must be positive")
+		}
+		for _, level := range levels {
+			if level == 0 {
+				return fmt.Errorf("concurrency levels must be positive")
+			}
+		}
+		if len(levels) == 0 {
+			return fmt.Errorf("missing --concurrency")
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		handleInterrupt(cancel)
+
+		gRPCClient, err := client.NewGRPCClient(ctx, args[0], insecure, maxRecvMsgSize)
+		if err != nil {
+			return fmt.Errorf("failed to initialize gRPC: %w", err)
+		}
+		defer gRPCClient.Close()
+
+		latest, err := utils.GetLatestBlockHeightWithRetry(gRPCClient, 3)
+		if err != nil {
+			return fmt.Errorf("failed to get the latest block height of the node: %w", err)
+		}
+		earliest, err := utils.GetEarliestBlockHeightWithRetry(gRPCClient, 3)
+		if err != nil {
+			return fmt.Errorf("failed to get the earliest block height of the node: %w", err)
+		}
+		if earliest > latest {
+			return fmt.Errorf("the node reports no available block, earliest height %d above latest height %d", earliest, latest)
+		}
+		// The requests cycle over the latest heights available on the node
+		span := min(latest-earliest+1, uint64(requests))
+		heightOf := func(i int) uint64 {
+			return latest - uint64(i)%span
+		}
+
+		var mu sync.Mutex
+		seen := make(map[string]bool)
+		var hashes []string
+		blockCall := func(_ context.Context, i int) error {
+			data, err := utils.GetGRPCResponse(gRPCClient, gRPCClient.Methods.Block, 1, []byte(fmt.Sprintf(`{"height": %d}`, heightOf(i))))
+			if err != nil {
+				return err
+			}
+			// The transactions of the blocks are the ones requested from the transaction endpoint
+			blockHashes, err := benchmark.BlockTxHashes(data)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, hash := range blockHashes {
+				if !seen[hash] {
+					seen[hash] = true
+					hashes = append(hashes, hash)
+				}
+			}
+			return nil
+		}
+		txCall := func(_ context.Context, i int) error {
+			_, err := utils.GetGRPCResponse(gRPCClient, gRPCClient.Methods.Tx, 1, []byte(fmt.Sprintf(`{"hash": "%s"}`, hashes[i%len(hashes)])))
+			return err
+		}
+		blockResultsCall := func(_ context.Context, i int) error {
+			_, err := utils.GetGRPCResponse(gRPCClient, gRPCClient.Methods.BlockResults, 1, []byte(fmt.Sprintf(`{"height": %d}`, heightOf(i))))
+			return err
+		}
+
+		var results []benchmark.Result
+		run := func(endpoint string, call benchmark.Call) {
+			for _, level := range levels {
+				if ctx.Err() != nil {
+					return
+				}
+				slog.Info("Benchmarking endpoint", "endpoint", endpoint, "concurrency", level, "requests", requests)
+				results = append(results, benchmark.Run(ctx, endpoint, level, requests, call))
+			}
+		}
+		run(benchmark.EndpointBlock, blockCall)
+		if len(hashes) > 0 {
+			run(benchmark.EndpointTx, txCall)
+		} else {
+			slog.Warn("No transaction in the benchmarked blocks, the transaction endpoint is not measured")
+		}
+		if withResults {
+			run(benchmark.EndpointBlockResults, blockResultsCall)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		report := benchmark.NewReport(results)
+		if asJSON {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode the benchmark: %w", err)
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			return err
+		}
+		return report.Print(cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	BenchmarkCmd.Flags().BoolP("insecure", "k", false, "Disable TLS and use an insecure plaintext connection")
+	BenchmarkCmd.Flags().IntP("max-recv-msg-size", "m", 4194304, "Maximum gRPC message size in bytes (advanced)")
+	BenchmarkCmd.Flags().UintSlice("concurrency", []uint{1, 5, 10, 25, 50, 100}, "Concurrency levels to measure, comma-separated")
+	BenchmarkCmd.Flags().Int("requests", 100, "Number of requests per endpoint and concurrency level")
+	BenchmarkCmd.Flags().Bool("block-results", false, "Also measure the block results endpoint")
+	BenchmarkCmd.Flags().Bool("json", false, "Print the report as JSON")
+}
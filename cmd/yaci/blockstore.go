@@ -0,0 +1,63 @@
+package yaci
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/manifest-network/yaci/internal/blockstore"
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/extractor"
+	"github.com/manifest-network/yaci/internal/output/postgresql"
+)
+
+var BlockstoreCmd = &cobra.Command{
+	Use:   "blockstore [data-dir] [flags]",
+	Args:  cobra.ExactArgs(1),
+	Short: "Extract chain data from the data directory of a stopped node to a PostgreSQL database",
+	Long: `Extract the blocks and the transaction results directly from the blockstore.db and tx_index.db goleveldb
+databases of the data directory of a stopped CometBFT node, for air-gapped archive processing where no endpoint is
+exposed. The transactions are not decoded, since the protobuf descriptors of the chain are only available from a
+running node.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The flags are not bound to the configuration, to not shadow the flags of the extract command
+		postgresConfig := config.PostgresConfig{ConnString: viper.GetString("postgres-conn")}
+		if connString, _ := cmd.Flags().GetString("postgres-conn"); connString != "" {
+			postgresConfig.ConnString = connString
+		}
+		if err := postgresConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid PostgreSQL configuration: %w", err)
+		}
+		start, _ := cmd.Flags().GetUint64("start")
+		stop, _ := cmd.Flags().GetUint64("stop")
+		if start > 0 && stop > 0 && start > stop {
+			return fmt.Errorf("start block is greater than stop block")
+		}
+
+		store, err := blockstore.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open the block store: %w", err)
+		}
+		defer store.Close()
+
+		outputHandler, err := postgresql.NewPostgresOutputHandler(postgresConfig.ConnString)
+		if err != nil {
+			return fmt.Errorf("failed to create PostgreSQL output handler: %w", err)
+		}
+		defer outputHandler.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		handleInterrupt(cancel)
+
+		return extractor.ExtractBlockstore(ctx, store, outputHandler, start, stop)
+	},
+}
+
+func init() {
+	BlockstoreCmd.Flags().StringP("postgres-conn", "p", "", "PostgreSQL connection string, or the YACI_POSTGRES_CONN environment variable")
+	BlockstoreCmd.Flags().Uint64P("start", "s", 0, "Start block height, by default the block after the latest stored block")
+	BlockstoreCmd.Flags().Uint64P("stop", "e", 0, "Stop block height, by default the highest height of the block store")
+}
@@ -0,0 +1,154 @@
+package yaci
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/extractor"
+	"github.com/manifest-network/yaci/internal/jsondiff"
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+// nodeBlock is a block fetched from a single node.
+type nodeBlock struct {
+	block        *models.Block
+	transactions []*models.Transaction
+	blockResults *models.BlockResults
+}
+
+var DiffCmd = &cobra.Command{
+	Use:   "diff [address] [address]",
+	Args:  cobra.ExactArgs(2),
+	Short: "Compare a block fetched from two gRPC servers",
+	Long: `Fetch the same block, its transactions and optionally its block results from two gRPC servers and print the differences between the decoded outputs.
+Useful to highlight non-determinism or version skew between nodes. Exits with an error when differences are found.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if parent := cmd.Parent(); parent != nil && parent.PreRunE != nil {
+			if err := parent.PreRunE(parent, args); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := cmd.Flags()
+		height, _ := flags.GetUint64("height")
+		insecure, _ := flags.GetBool("insecure")
+		withResults, _ := flags.GetBool("block-results")
+		maxRetries, _ := flags.GetUint("max-retries")
+		maxRecvMsgSize, _ := flags.GetInt("max-recv-msg-size")
+
+		if height == 0 {
+			return fmt.Errorf("missing --height")
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		handleInterrupt(cancel)
+
+		// Fetch the block from both nodes concurrently, as descriptor resolution can take a while.
+		blocks := make([]nodeBlock, len(args))
+		eg, egCtx := errgroup.WithContext(ctx)
+		for i, address := range args {
+			eg.Go(func() error {
+				slog.Info("Fetching block", "address", address, "height", height)
+				gRPCClient, err := client.NewGRPCClient(egCtx, address, insecure, maxRecvMsgSize)
+				if err != nil {
+					return fmt.Errorf("failed to initialize gRPC for %s: %w", address, err)
+				}
+				defer gRPCClient.Close()
+
+				block, transactions, blockResults, err := extractor.FetchBlock(gRPCClient, height, maxRetries, withResults)
+				if err != nil {
+					return fmt.Errorf("failed to fetch block %d from %s: %w", height, address, err)
+				}
+				blocks[i] = nodeBlock{block: block, transactions: transactions, blockResults: blockResults}
+				return nil
+			})
+		}
+		if err := eg.Wait(); err != nil {
+			return err
+		}
+
+		count, err := printBlockDiff(cmd, blocks[0], blocks[1])
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			return fmt.Errorf("%d differences found at height %d", count, height)
+		}
+		cmd.Printf("No differences found at height %d\n", height)
+		return nil
+	},
+}
+
+// printBlockDiff prints the differences between two fetched blocks and returns their number.
+func printBlockDiff(cmd *cobra.Command, left, right nodeBlock) (int, error) {
+	count := 0
+	section := func(name string, l, r []byte) error {
+		if l == nil || r == nil {
+			if l != nil || r != nil {
+				cmd.Printf("%s: present on one node only\n", name)
+				count++
+			}
+			return nil
+		}
+		diffs, err := jsondiff.Compare(l, r)
+		if err != nil {
+			return fmt.Errorf("failed to compare %s: %w", name, err)
+		}
+		for _, d := range diffs {
+			cmd.Printf("%s: %s\n", name, d)
+		}
+		count += len(diffs)
+		return nil
+	}
+
+	if err := section("block", left.block.Data, right.block.Data); err != nil {
+		return 0, err
+	}
+
+	rightTxs := make(map[string][]byte, len(right.transactions))
+	for _, tx := range right.transactions {
+		rightTxs[tx.Hash] = tx.Data
+	}
+	for _, tx := range left.transactions {
+		if err := section(fmt.Sprintf("tx %s", tx.Hash), tx.Data, rightTxs[tx.Hash]); err != nil {
+			return 0, err
+		}
+		delete(rightTxs, tx.Hash)
+	}
+	for _, tx := range right.transactions {
+		if _, ok := rightTxs[tx.Hash]; ok {
+			cmd.Printf("tx %s: present on one node only\n", tx.Hash)
+			count++
+		}
+	}
+
+	var leftResults, rightResults []byte
+	if left.blockResults != nil {
+		leftResults = left.blockResults.Data
+	}
+	if right.blockResults != nil {
+		rightResults = right.blockResults.Data
+	}
+	if err := section("block results", leftResults, rightResults); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func init() {
+	DiffCmd.Flags().Uint64("height", 0, "Block height to compare")
+	DiffCmd.Flags().BoolP("insecure", "k", false, "Disable TLS and use an insecure plaintext connection")
+	DiffCmd.Flags().Bool("block-results", false, "Also compare block results (finalize_block_events) fetched via gRPC")
+	DiffCmd.Flags().UintP("max-retries", "r", 3, "Maximum number of retries for failed requests")
+	DiffCmd.Flags().IntP("max-recv-msg-size", "m", 4194304, "Maximum gRPC message size in bytes (advanced)")
+}
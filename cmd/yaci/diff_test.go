@@ -0,0 +1,21 @@
+package yaci_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/manifest-network/yaci/cmd/yaci"
+)
+
+func TestDiffCmd(t *testing.T) {
+	// Two addresses are required
+	_, err := executeCommand(yaci.RootCmd, "diff", "foobar")
+	assert.Error(t, err)
+	assert.ErrorContains(t, err, "accepts 2 arg(s)")
+
+	// The height is required
+	_, err = executeCommand(yaci.RootCmd, "diff", "foo", "bar")
+	assert.Error(t, err)
+	assert.ErrorContains(t, err, "missing --height")
+}
@@ -0,0 +1,70 @@
+package yaci
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/manifest-network/yaci/internal/archive"
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/output/postgresql"
+)
+
+var ExportCmd = &cobra.Command{
+	Use:   "export [output-dir] [flags]",
+	Args:  cobra.ExactArgs(1),
+	Short: "Export a height range of a PostgreSQL database to files",
+	Long: `Export the blocks of a height range stored in a PostgreSQL database, their transactions and their results to
+one file per dataset, blocks, transactions and block_results, in JSON Lines, CSV or Parquet, e.g., to hand datasets
+over without database access.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The flag is not bound to the configuration, to not shadow the flag of the postgres subcommand
+		postgresConfig := config.PostgresConfig{ConnString: viper.GetString("postgres-conn")}
+		if connString, _ := cmd.Flags().GetString("postgres-conn"); connString != "" {
+			postgresConfig.ConnString = connString
+		}
+		if err := postgresConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid PostgreSQL configuration: %w", err)
+		}
+		start, _ := cmd.Flags().GetUint64("start")
+		stop, _ := cmd.Flags().GetUint64("stop")
+		format, _ := cmd.Flags().GetString("format")
+		batchSize, _ := cmd.Flags().GetInt("batch-size")
+		if start > 0 && stop > 0 && start > stop {
+			return fmt.Errorf("start block is greater than stop block")
+		}
+		if err := archive.ValidateFormat(format); err != nil {
+			return err
+		}
+		if batchSize <= 0 {
+			return fmt.Errorf("batch size must be positive")
+		}
+
+		outputHandler, err := postgresql.NewPostgresOutputHandler(postgresConfig.ConnString)
+		if err != nil {
+			return fmt.Errorf("failed to create PostgreSQL output handler: %w", err)
+		}
+		defer outputHandler.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		handleInterrupt(cancel)
+
+		summary, err := archive.Export(ctx, outputHandler, args[0], format, start, stop, batchSize)
+		if err != nil {
+			return err
+		}
+		cmd.Printf("Exported %d blocks, %d transactions and %d block results to %s\n", summary.Blocks, summary.Transactions, summary.BlockResults, args[0])
+		return nil
+	},
+}
+
+func init() {
+	ExportCmd.Flags().StringP("postgres-conn", "p", "", "PostgreSQL connection string, or the YACI_POSTGRES_CONN environment variable")
+	ExportCmd.Flags().Uint64P("start", "s", 0, "Start block height, by default the lowest stored block")
+	ExportCmd.Flags().Uint64P("stop", "e", 0, "Stop block height, by default the highest stored block")
+	ExportCmd.Flags().StringP("format", "f", archive.FormatJSONL, "Format of the files, jsonl, csv or parquet")
+	ExportCmd.Flags().Int("batch-size", 1000, "Number of blocks read at once")
+}
@@ -7,11 +7,16 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/manifest-network/yaci/internal/client"
 	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/preset"
+	"github.com/manifest-network/yaci/internal/reflection"
+	"github.com/manifest-network/yaci/internal/utils"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 var (
@@ -42,11 +47,28 @@ var ExtractCmd = &cobra.Command{
 		ctx, cancel := context.WithCancel(context.Background())
 		handleInterrupt(cancel)
 
-		var err error
-		gRPCClient, err = client.NewGRPCClient(ctx, args[0], extractConfig.Insecure, extractConfig.MaxRecvMsgSize)
+		descriptors, err := loadDescriptors(ctx, extractConfig)
+		if err != nil {
+			return err
+		}
+
+		gRPCClient, err = client.NewGRPCClientWithDescriptors(ctx, args[0], extractConfig.Insecure, extractConfig.MaxRecvMsgSize, descriptors)
 		if err != nil {
 			return fmt.Errorf("failed to initialize gRPC: %w", err)
 		}
+		gRPCClient.SetMethods(methodOverrides())
+
+		if extractConfig.ArchiveEndpoint != "" {
+			if err := setArchiveEndpoint(ctx, descriptors); err != nil {
+				return err
+			}
+		}
+
+		if name := viper.GetString("preset"); name != "" {
+			if err := applyPreset(name); err != nil {
+				return err
+			}
+		}
 
 		return nil
 	},
@@ -54,17 +76,67 @@ var ExtractCmd = &cobra.Command{
 
 func init() {
 	ExtractCmd.PersistentFlags().BoolP("insecure", "k", false, "Disable TLS and use an insecure plaintext connection")
+	ExtractCmd.PersistentFlags().String("descriptors", "", "Path of a protobuf FileDescriptorSet, e.g., built with buf build -o chain.pb, whose descriptors replace the ones of the same files fetched via server reflection")
+	ExtractCmd.PersistentFlags().StringArray("proto-dir", nil, "Directory of .proto files compiled at startup, for custom modules unknown to server reflection; the files import each other by their path relative to the directory (repeatable)")
+	ExtractCmd.PersistentFlags().Bool("descriptors-only", false, "Only use the descriptors of --descriptors and --proto-dir, without server reflection, for nodes with reflection disabled")
+	ExtractCmd.PersistentFlags().StringArray("height-descriptors", nil, "Protobuf FileDescriptorSet decoding a range of heights, as RANGE=PATH, e.g., 1-1499999=v1.pb for the heights before an upgrade; its descriptors replace the ones of the same files for those heights (repeatable)")
+	ExtractCmd.PersistentFlags().String("archive-endpoint", "", "gRPC endpoint of an archive node serving the heights below --archive-threshold, the other endpoints serving the recent heights")
+	ExtractCmd.PersistentFlags().Uint64("archive-threshold", 0, "Height below which the requests are routed to --archive-endpoint (0 detects the earliest height available on the other endpoints)")
+	ExtractCmd.PersistentFlags().String("block-method", "", "Full name of the GetBlockWithTxs gRPC method, for the forks that renamed it (empty uses cosmos.tx.v1beta1.Service.GetBlockWithTxs)")
+	ExtractCmd.PersistentFlags().String("tx-method", "", "Full name of the GetTx gRPC method, for the forks that renamed it (empty uses cosmos.tx.v1beta1.Service.GetTx)")
+	ExtractCmd.PersistentFlags().String("block-results-method", "", "Full name of the GetBlockResults gRPC method, for the forks that renamed it (empty uses the method of the detected block service)")
+	ExtractCmd.PersistentFlags().String("block-by-height-method", "", "Full name of the GetBlockByHeight gRPC method, for the forks that renamed it (empty uses the method of the detected block service)")
+	ExtractCmd.PersistentFlags().String("latest-block-method", "", "Full name of the GetLatestBlock gRPC method, for the forks that renamed it (empty uses the method of the detected block service)")
+	ExtractCmd.PersistentFlags().String("status-method", "", "Full name of the node Status gRPC method, for the forks that renamed it (empty uses cosmos.base.node.v1beta1.Service.Status)")
 	ExtractCmd.PersistentFlags().Bool("live", false, "Enable live monitoring")
+	ExtractCmd.PersistentFlags().Bool("continuous", false, "Backfill from the earliest stored or available height up to the chain tip, then switch to live monitoring")
+	ExtractCmd.PersistentFlags().String("schedule", "", "Extract from the latest stored block up to the chain tip at startup, then at the times of this cron expression, e.g., \"0 * * * *\"")
+	ExtractCmd.PersistentFlags().Bool("leader-election", false, "Only extract while elected leader among the instances sharing the database, the others waiting as standby")
+	ExtractCmd.PersistentFlags().String("leader-election-name", "yaci", "Name of the leadership shared by the instances with --leader-election")
+	ExtractCmd.PersistentFlags().Uint64("confirmation-depth", 0, "Only process blocks at least N heights behind the chain tip")
 	ExtractCmd.PersistentFlags().Bool("reindex", false, "Reindex the database from block 1 to the latest block (advanced)")
+	ExtractCmd.PersistentFlags().Bool("newest-first", false, "Process the range from the highest height downward, so recent blocks are available first")
 	ExtractCmd.PersistentFlags().Uint64P("start", "s", 0, "Start block height")
+	ExtractCmd.PersistentFlags().String("ranges", "", "Comma-separated heights and height ranges to extract in a single run, e.g., 1-1000,50000-60000")
+	ExtractCmd.PersistentFlags().String("force-heights", "", "Comma-separated heights and height ranges to delete and extract again, even if already stored, e.g., 1200-1300,1500")
+	ExtractCmd.PersistentFlags().Uint64("sample-every", 0, "Only extract the heights multiple of N, for quick statistical passes (0 disables)")
+	ExtractCmd.PersistentFlags().String("sample-include", "", "Comma-separated heights and height ranges always extracted with --sample-every")
+	ExtractCmd.PersistentFlags().String("skip-ranges", "", "Comma-separated heights and height ranges to skip, e.g., 100000-100050,2000000")
+	ExtractCmd.PersistentFlags().Bool("resume", true, "Without --start, resume from the latest stored block; when disabled, start from the earliest block available on the node")
 	ExtractCmd.PersistentFlags().Uint64P("stop", "e", 0, "Stop block height")
-	ExtractCmd.PersistentFlags().UintP("block-time", "t", 2, "Block time in seconds")
+	ExtractCmd.PersistentFlags().String("start-time", "", "Start from the first block at or after this RFC 3339 time")
+	ExtractCmd.PersistentFlags().String("end-time", "", "Stop at the last block before this RFC 3339 time")
+	ExtractCmd.PersistentFlags().Uint64("max-blocks", 0, "Stop after processing N blocks (0 disables)")
+	ExtractCmd.PersistentFlags().Duration("deadline", 0, "Stop after running for this duration, e.g., 2h (0 disables)")
+	ExtractCmd.PersistentFlags().String("stop-block-time", "", "Stop after processing a block whose time is at or after this RFC 3339 time")
+	ExtractCmd.PersistentFlags().UintP("block-time", "t", 2, "Initial estimate of the block time in seconds, refined from the observed block times in live mode")
 	ExtractCmd.PersistentFlags().UintP("max-retries", "r", 3, "Maximum number of retries for failed block processing")
 	ExtractCmd.PersistentFlags().UintP("max-concurrency", "c", 100, "Maximum block retrieval concurrency (advanced)")
 	ExtractCmd.PersistentFlags().IntP("max-recv-msg-size", "m", 4194304, "Maximum gRPC message size in bytes (advanced)")
 	ExtractCmd.PersistentFlags().Bool("enable-prometheus", false, "Enable Prometheus metrics server")
 	ExtractCmd.PersistentFlags().String("prometheus-addr", "0.0.0.0:2112", "Address and port of the Prometheus metrics server")
 	ExtractCmd.PersistentFlags().Bool("enable-block-results", false, "Fetch block results (finalize_block_events) via gRPC - requires republicd with GetBlockResults support")
+	ExtractCmd.PersistentFlags().String("cometbft-rpc", "", "CometBFT RPC URL, e.g., http://localhost:26657, the block results are fetched from when the node lacks the GetBlockResults gRPC endpoint")
+	ExtractCmd.PersistentFlags().StringArray("redact-memo", nil, "Regular expression of the transaction memos to redact before storing them, e.g., \"@\" (repeatable)")
+	ExtractCmd.PersistentFlags().String("memo-redaction", "hash", "Redaction of the memos matching --redact-memo: hash replaces them with their SHA-256 hash, truncate keeps their first 16 characters")
+	ExtractCmd.PersistentFlags().String("vote-extension-decoder", "", "Decode the vote extensions the proposers inject into their blocks, as json or proto:MESSAGE_NAME, e.g., proto:slinky.abci.v2.OracleVoteExtension (empty disables)")
+	ExtractCmd.PersistentFlags().StringArray("prune-json", nil, "JSON path of the blocks, transactions and block results to drop before storing them, PATH, or whose strings to truncate to N characters, PATH=N, e.g., tx.body.messages.*.wasmByteCode (repeatable)")
+	ExtractCmd.PersistentFlags().Bool("canonical-json", false, "Store the JSON payloads in canonical form, with sorted keys and fixed number formatting, so that re-extractions are byte-comparable")
+	ExtractCmd.PersistentFlags().Bool("store-raw-protobuf", false, "Store the protobuf bytes of the block, transaction and block results responses as sent by the node, along with their JSON")
+	ExtractCmd.PersistentFlags().Bool("header-only", false, "Only fetch and store block headers, without transactions or block results")
+	ExtractCmd.PersistentFlags().String("tx-events-query", "", "Only extract the transactions matching this event query with GetTxsEvent, e.g., \"message.module='bank'\", restricted to --start/--stop if set")
+	ExtractCmd.PersistentFlags().Uint64("gov-proposals-interval", 0, "Query governance proposals, deposits and tallies every N blocks (0 disables)")
+	ExtractCmd.PersistentFlags().Uint64("ibc-state-interval", 0, "Query the IBC clients, connections and channels every N blocks (0 disables)")
+	ExtractCmd.PersistentFlags().Uint64("validators-interval", 0, "Query the validators and their consensus addresses every N blocks, to resolve the block proposers (0 disables)")
+	ExtractCmd.PersistentFlags().StringArray("extra-query", nil, "User-defined gRPC query run at every --extra-queries-interval blocks, as NAME=METHOD[;PARAMS] where {height} in the JSON parameters is replaced by the block height (repeatable)")
+	ExtractCmd.PersistentFlags().Uint64("extra-queries-interval", 1, "Run the extra queries every N blocks (0 disables)")
+	ExtractCmd.PersistentFlags().Uint64("supply-interval", 0, "Record the total supply of every denom and the denom metadata every N blocks (0 disables)")
+	ExtractCmd.PersistentFlags().Uint64("balance-snapshot-interval", 0, "Snapshot the bank balances of all holders at every height multiple of N (0 disables)")
+	ExtractCmd.PersistentFlags().Uint64("delegation-snapshot-interval", 0, "Snapshot staking delegations and unbonding delegations at every height multiple of N (0 disables)")
+	ExtractCmd.PersistentFlags().String("preset", "", "Apply the settings of an embedded preset (auto, sdk, wasm, evm, ics-consumer); explicit settings take precedence")
+	ExtractCmd.PersistentFlags().String("debug-capture", "", "Dump the raw gRPC requests and responses of failing heights into this directory")
+	ExtractCmd.PersistentFlags().Uint64("shard-size", 0, "Share the --start/--stop range with other instances using the same database, in shards of N blocks (0 disables)")
+	ExtractCmd.PersistentFlags().Duration("shard-lease", 10*time.Minute, "Duration of the lease on a claimed shard, renewed while the shard is processed")
 
 	if err := viper.BindPFlags(ExtractCmd.PersistentFlags()); err != nil {
 		slog.Error("Failed to bind ExtractCmd flags", "error", err)
@@ -73,6 +145,103 @@ func init() {
 	ExtractCmd.AddCommand(PostgresCmd)
 }
 
+// applyPreset applies the settings of the named preset, or of the preset detected from the node when the name is "auto",
+// and reloads the extract configuration.
+func applyPreset(name string) error {
+	var p *preset.Preset
+	var err error
+	if name == preset.Auto {
+		p, err = preset.Detect(gRPCClient.Resolver.HasService)
+	} else {
+		p, err = preset.Get(name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to select preset: %w", err)
+	}
+
+	slog.Info("Applying preset", "preset", p.Name, "description", p.Description)
+	p.Apply(viper.GetViper())
+
+	extractConfig = config.LoadExtractConfigFromCLI()
+	if err := extractConfig.Validate(); err != nil {
+		return fmt.Errorf("invalid Extract configuration after applying preset %s: %w", p.Name, err)
+	}
+	return nil
+}
+
+// loadDescriptors loads the descriptors supplied with the configuration, or returns nil if there are none.
+// The descriptors compiled from the .proto files replace the ones of the same files of the descriptor set.
+func loadDescriptors(ctx context.Context, cfg config.ExtractConfig) (*client.Descriptors, error) {
+	if cfg.Descriptors == "" && len(cfg.ProtoDirs) == 0 && len(cfg.HeightDescriptors) == 0 {
+		return nil, nil
+	}
+
+	var files []*descriptorpb.FileDescriptorProto
+	if len(cfg.ProtoDirs) > 0 {
+		compiled, err := reflection.CompileProtoDirs(ctx, cfg.ProtoDirs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load .proto files: %w", err)
+		}
+		slog.Info("Compiled .proto files", "dirs", cfg.ProtoDirs, "files", len(compiled))
+		files = compiled
+	}
+
+	if cfg.Descriptors != "" {
+		loaded, err := reflection.LoadFileDescriptorSet(cfg.Descriptors)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load descriptors: %w", err)
+		}
+		slog.Info("Loaded protocol buffer descriptors", "path", cfg.Descriptors, "files", len(loaded))
+		files = reflection.MergeDescriptors(files, loaded)
+	}
+
+	// The height descriptors were validated with the configuration
+	heightDescriptors, _ := config.ParseHeightDescriptors(cfg.HeightDescriptors)
+	var heights []*client.HeightDescriptors
+	for _, h := range heightDescriptors {
+		loaded, err := reflection.LoadFileDescriptorSet(h.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load descriptors of heights [%d, %d]: %w", h.Range.Start, h.Range.Stop, err)
+		}
+		slog.Info("Loaded protocol buffer descriptors of height range", "path", h.Path, "start", h.Range.Start, "stop", h.Range.Stop, "files", len(loaded))
+		heights = append(heights, &client.HeightDescriptors{Start: h.Range.Start, Stop: h.Range.Stop, Files: loaded})
+	}
+
+	return &client.Descriptors{Files: files, Only: cfg.DescriptorsOnly, Heights: heights}, nil
+}
+
+// methodOverrides returns the methods of the configuration replacing the methods detected from the node.
+func methodOverrides() client.Methods {
+	return client.Methods{
+		Block:         extractConfig.BlockMethod,
+		Tx:            extractConfig.TxMethod,
+		BlockResults:  extractConfig.BlockResultsMethod,
+		BlockByHeight: extractConfig.BlockByHeightMethod,
+		LatestBlock:   extractConfig.LatestBlockMethod,
+		Status:        extractConfig.StatusMethod,
+	}
+}
+
+// setArchiveEndpoint routes the requests for the heights below the archive threshold to the archive endpoint. Without
+// a threshold, the earliest height available on the endpoints is used, below which they are pruned.
+func setArchiveEndpoint(ctx context.Context, descriptors *client.Descriptors) error {
+	archive, err := client.NewGRPCClientWithDescriptors(ctx, extractConfig.ArchiveEndpoint, extractConfig.Insecure, extractConfig.MaxRecvMsgSize, descriptors)
+	if err != nil {
+		return fmt.Errorf("failed to initialize archive gRPC: %w", err)
+	}
+
+	threshold := extractConfig.ArchiveThreshold
+	if threshold == 0 {
+		if threshold, err = utils.GetEarliestBlockHeightWithRetry(gRPCClient, extractConfig.MaxRetries); err != nil {
+			return fmt.Errorf("failed to detect the archive threshold: %w", err)
+		}
+	}
+
+	gRPCClient.SetArchive(archive, threshold)
+	slog.Info("Routing the heights below the threshold to the archive endpoint", "archive", extractConfig.ArchiveEndpoint, "threshold", threshold)
+	return nil
+}
+
 // handleInterrupt handles interrupt signals for graceful shutdown.
 func handleInterrupt(cancel context.CancelFunc) {
 	// Handle interrupt signals for graceful shutdown
@@ -0,0 +1,122 @@
+package yaci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/extractor"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output/postgresql"
+	"github.com/manifest-network/yaci/internal/status"
+)
+
+var GapsCmd = &cobra.Command{
+	Use:   "gaps [flags]",
+	Args:  cobra.NoArgs,
+	Short: "List the height ranges missing from a PostgreSQL database",
+	Long: `List the height ranges missing from a PostgreSQL database, between the processed, skipped and pruned heights,
+with their number of blocks, as text or JSON. With --backfill, the missing ranges are then extracted from the gRPC
+server, and only them.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The flag is not bound to the configuration, to not shadow the flag of the postgres subcommand
+		postgresConfig := config.PostgresConfig{ConnString: viper.GetString("postgres-conn")}
+		if connString, _ := cmd.Flags().GetString("postgres-conn"); connString != "" {
+			postgresConfig.ConnString = connString
+		}
+		if err := postgresConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid PostgreSQL configuration: %w", err)
+		}
+		asJSON, _ := cmd.Flags().GetBool("json")
+		backfill, _ := cmd.Flags().GetString("backfill")
+
+		outputHandler, err := postgresql.NewPostgresOutputHandler(postgresConfig.ConnString)
+		if err != nil {
+			return fmt.Errorf("failed to create PostgreSQL output handler: %w", err)
+		}
+		defer outputHandler.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		handleInterrupt(cancel)
+
+		ranges, err := outputHandler.GetMissingRanges(ctx)
+		if err != nil {
+			return err
+		}
+
+		report := status.NewGaps(ranges)
+		if asJSON {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode the gaps: %w", err)
+			}
+			if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(data)); err != nil {
+				return err
+			}
+		} else if err := report.Print(cmd.OutOrStdout()); err != nil {
+			return err
+		}
+
+		if backfill == "" || len(ranges) == 0 {
+			return nil
+		}
+		return backfillGaps(ctx, cmd, backfill, outputHandler, ranges)
+	},
+}
+
+// backfillGaps extracts the missing ranges from the gRPC server at address, with the extraction settings of the
+// configuration file and the environment, and the connection flags of the command.
+func backfillGaps(ctx context.Context, cmd *cobra.Command, address string, outputHandler *postgresql.PostgresOutputHandler, ranges []models.HeightRange) error {
+	extractConfig = config.LoadExtractConfigFromCLI()
+	if cmd.Flags().Changed("insecure") {
+		extractConfig.Insecure, _ = cmd.Flags().GetBool("insecure")
+	}
+	if cmd.Flags().Changed("max-concurrency") {
+		extractConfig.MaxConcurrency, _ = cmd.Flags().GetUint("max-concurrency")
+	}
+	if cmd.Flags().Changed("max-retries") {
+		extractConfig.MaxRetries, _ = cmd.Flags().GetUint("max-retries")
+	}
+	// The gaps are the only heights extracted, once
+	extractConfig.Ranges = config.FormatHeightRanges(ranges)
+	extractConfig.ForceHeights = ""
+	extractConfig.BlockStart, extractConfig.BlockStop = 0, 0
+	extractConfig.StartTime, extractConfig.EndTime = "", ""
+	extractConfig.LiveMonitoring, extractConfig.Continuous, extractConfig.ReIndex = false, false, false
+	extractConfig.Schedule, extractConfig.TxEventsQuery = "", ""
+	extractConfig.ShardSize = 0
+	if err := extractConfig.Validate(); err != nil {
+		return fmt.Errorf("invalid Extract configuration: %w", err)
+	}
+
+	descriptors, err := loadDescriptors(ctx, extractConfig)
+	if err != nil {
+		return err
+	}
+	gRPCClient, err = client.NewGRPCClientWithDescriptors(ctx, address, extractConfig.Insecure, extractConfig.MaxRecvMsgSize, descriptors)
+	if err != nil {
+		return fmt.Errorf("failed to initialize gRPC: %w", err)
+	}
+	defer gRPCClient.Close()
+	gRPCClient.SetMethods(methodOverrides())
+
+	slog.Info("Backfilling the missing ranges", "ranges", len(ranges), "address", address)
+	defer reportUnresolvedTypes()
+	return extractor.Extract(gRPCClient, outputHandler, extractConfig)
+}
+
+func init() {
+	GapsCmd.Flags().StringP("postgres-conn", "p", "", "PostgreSQL connection string, or the YACI_POSTGRES_CONN environment variable")
+	GapsCmd.Flags().Bool("json", false, "Print the gaps as JSON")
+	GapsCmd.Flags().String("backfill", "", "Address of a gRPC server to extract the missing ranges from after listing them")
+	GapsCmd.Flags().BoolP("insecure", "k", false, "Disable TLS and use an insecure plaintext connection to the backfill server")
+	GapsCmd.Flags().UintP("max-concurrency", "c", 100, "Maximum block retrieval concurrency of the backfill")
+	GapsCmd.Flags().UintP("max-retries", "r", 3, "Maximum number of retries for failed block processing of the backfill")
+}
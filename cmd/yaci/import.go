@@ -0,0 +1,60 @@
+package yaci
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/extractor"
+	"github.com/manifest-network/yaci/internal/output/postgresql"
+)
+
+var ImportCmd = &cobra.Command{
+	Use:   "import [input-dir] [flags]",
+	Args:  cobra.ExactArgs(1),
+	Short: "Import files exported by yaci to a PostgreSQL database",
+	Long: `Import the blocks, the transactions and the block results of the files written by the export command, in JSON
+Lines, CSV or Parquet, optionally gzip compressed, to a PostgreSQL database without any endpoint, e.g., to seed a new
+environment from an archive.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The flag is not bound to the configuration, to not shadow the flag of the postgres subcommand
+		postgresConfig := config.PostgresConfig{ConnString: viper.GetString("postgres-conn")}
+		if connString, _ := cmd.Flags().GetString("postgres-conn"); connString != "" {
+			postgresConfig.ConnString = connString
+		}
+		if err := postgresConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid PostgreSQL configuration: %w", err)
+		}
+		start, _ := cmd.Flags().GetUint64("start")
+		stop, _ := cmd.Flags().GetUint64("stop")
+		if start > 0 && stop > 0 && start > stop {
+			return fmt.Errorf("start block is greater than stop block")
+		}
+
+		outputHandler, err := postgresql.NewPostgresOutputHandler(postgresConfig.ConnString)
+		if err != nil {
+			return fmt.Errorf("failed to create PostgreSQL output handler: %w", err)
+		}
+		defer outputHandler.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		handleInterrupt(cancel)
+
+		summary, err := extractor.ImportArchive(ctx, args[0], outputHandler, start, stop)
+		if err != nil {
+			return err
+		}
+		cmd.Printf("Imported %d blocks, %d transactions and %d block results from %s\n", summary.Blocks, summary.Transactions, summary.BlockResults, args[0])
+		return nil
+	},
+}
+
+func init() {
+	ImportCmd.Flags().StringP("postgres-conn", "p", "", "PostgreSQL connection string, or the YACI_POSTGRES_CONN environment variable")
+	ImportCmd.Flags().Uint64P("start", "s", 0, "Start block height, by default the lowest block of the files")
+	ImportCmd.Flags().Uint64P("stop", "e", 0, "Stop block height, by default the highest block of the files")
+}
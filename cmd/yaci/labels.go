@@ -0,0 +1,117 @@
+package yaci
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/labels"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output/postgresql"
+)
+
+// configLabelsSource is the source of the labels of the configuration file.
+const configLabelsSource = "config"
+
+var LabelsCmd = &cobra.Command{
+	Use:   "labels [csv-file...] [flags]",
+	Short: "Import the labels of known addresses to a PostgreSQL database",
+	Long: `Import human-readable labels of known addresses, e.g., exchanges, module accounts and team wallets, into the
+api.address_labels table, from CSV files of address,label[,category] records and from the labels list of the
+configuration file. The labels are joined to the addresses of the labeled views.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The flag is not bound to the configuration, to not shadow the flag of the postgres subcommand
+		postgresConfig := config.PostgresConfig{ConnString: viper.GetString("postgres-conn")}
+		if connString, _ := cmd.Flags().GetString("postgres-conn"); connString != "" {
+			postgresConfig.ConnString = connString
+		}
+		if err := postgresConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid PostgreSQL configuration: %w", err)
+		}
+		bech32Prefix, _ := cmd.Flags().GetString("module-accounts")
+		replace, _ := cmd.Flags().GetBool("replace")
+
+		// The module accounts are labeled first, so that the imported labels take precedence
+		var sets [][]*models.AddressLabel
+		if bech32Prefix != "" {
+			modules, err := labels.ModuleAccounts(bech32Prefix)
+			if err != nil {
+				return fmt.Errorf("failed to label the module accounts: %w", err)
+			}
+			sets = append(sets, modules)
+		}
+
+		configLabels, err := loadConfigLabels()
+		if err != nil {
+			return err
+		}
+		sets = append(sets, configLabels)
+
+		for _, path := range args {
+			fileLabels, err := loadCSVLabels(path)
+			if err != nil {
+				return err
+			}
+			sets = append(sets, fileLabels)
+		}
+
+		merged := labels.Merge(sets...)
+		if len(merged) == 0 {
+			return fmt.Errorf("no labels to import, give CSV files, a labels list in the configuration file or --module-accounts")
+		}
+
+		outputHandler, err := postgresql.NewPostgresOutputHandler(postgresConfig.ConnString)
+		if err != nil {
+			return fmt.Errorf("failed to create PostgreSQL output handler: %w", err)
+		}
+		defer outputHandler.Close()
+
+		if err := outputHandler.WriteAddressLabels(context.Background(), merged, replace); err != nil {
+			return err
+		}
+		slog.Info("Imported address labels", "count", len(merged))
+		return nil
+	},
+}
+
+// loadConfigLabels returns the labels of the labels list of the configuration file.
+func loadConfigLabels() ([]*models.AddressLabel, error) {
+	var configLabels []*models.AddressLabel
+	if err := viper.UnmarshalKey("labels", &configLabels); err != nil {
+		return nil, fmt.Errorf("invalid labels in the configuration file: %w", err)
+	}
+	for _, l := range configLabels {
+		l.Source = configLabelsSource
+		if err := labels.Validate(l); err != nil {
+			return nil, fmt.Errorf("invalid labels in the configuration file: %w", err)
+		}
+	}
+	return configLabels, nil
+}
+
+// loadCSVLabels returns the labels of a CSV file, whose source is the name of the file.
+func loadCSVLabels(path string) ([]*models.AddressLabel, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open labels file: %w", err)
+	}
+	defer f.Close()
+
+	fileLabels, err := labels.ParseCSV(f, filepath.Base(path))
+	if err != nil {
+		return nil, fmt.Errorf("invalid labels file %s: %w", path, err)
+	}
+	return fileLabels, nil
+}
+
+func init() {
+	LabelsCmd.Flags().StringP("postgres-conn", "p", "", "PostgreSQL connection string, or the YACI_POSTGRES_CONN environment variable")
+	LabelsCmd.Flags().String("module-accounts", "", "Also label the module accounts of the Cosmos SDK, with this bech32 prefix, e.g., manifest")
+	LabelsCmd.Flags().Bool("replace", false, "Delete the labels previously imported from the same sources, e.g., the same CSV file, before importing")
+}
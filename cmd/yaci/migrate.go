@@ -0,0 +1,212 @@
+package yaci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/output/postgresql"
+)
+
+var MigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage the versioned schema migrations of a PostgreSQL database",
+	Long: `Apply, revert and list the versioned schema migrations embedded in yaci. The commands writing to a PostgreSQL
+database require its schema to be up to date, so that upgrades are applied explicitly with migrate up, or at startup
+with the --auto-migrate flag of extract postgres.`,
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up [flags]",
+	Args:  cobra.NoArgs,
+	Short: "Apply the pending migrations",
+	Long: `Apply the pending migrations, or only the next --steps ones. The migrations adding derived tables only create
+them and their triggers, which fill them from the rows written afterwards; the rows indexed before are derived by
+migrate backfill.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		connString, err := migrateConnString(cmd)
+		if err != nil {
+			return err
+		}
+		steps, _ := cmd.Flags().GetInt("steps")
+		if steps < 0 {
+			return fmt.Errorf("steps cannot be negative")
+		}
+		return migrateUp(connString, steps)
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down [flags]",
+	Args:  cobra.NoArgs,
+	Short: "Revert the last migrations",
+	Long: `Revert the last --steps migrations, or all of them with --all, dropping the tables and the data of the
+reverted migrations.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		connString, err := migrateConnString(cmd)
+		if err != nil {
+			return err
+		}
+		steps, _ := cmd.Flags().GetInt("steps")
+		all, _ := cmd.Flags().GetBool("all")
+		if (steps > 0) == all || steps < 0 {
+			return fmt.Errorf("exactly one of a positive steps and all must be set")
+		}
+
+		migrator, err := postgresql.NewMigrator(connString)
+		if err != nil {
+			return err
+		}
+		defer migrator.Close()
+		return migrator.Down(steps)
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status [flags]",
+	Args:  cobra.NoArgs,
+	Short: "List the migrations and the schema version of the database",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		connString, err := migrateConnString(cmd)
+		if err != nil {
+			return err
+		}
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		migrator, err := postgresql.NewMigrator(connString)
+		if err != nil {
+			return err
+		}
+		defer migrator.Close()
+
+		status, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			data, err := json.MarshalIndent(status, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode the migration status: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			return nil
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+		for _, m := range status.Migrations {
+			state := "pending"
+			if m.Applied {
+				state = "applied"
+			} else if m.Version == status.Version && status.Dirty {
+				state = "dirty"
+			}
+			fmt.Fprintf(w, "%03d\t%s\t%s\n", m.Version, m.Name, state)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		cmd.Printf("Schema version %d of %d, %d pending\n", status.Version, status.Latest, status.Pending())
+		return nil
+	},
+}
+
+var migrateForceCmd = &cobra.Command{
+	Use:   "force [version] [flags]",
+	Args:  cobra.ExactArgs(1),
+	Short: "Set the schema version without running any migration",
+	Long: `Set the schema version and clear the dirty flag left by a failed migration, once the database was fixed by
+hand, without running any migration.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		connString, err := migrateConnString(cmd)
+		if err != nil {
+			return err
+		}
+		version, err := strconv.Atoi(args[0])
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[0])
+		}
+
+		migrator, err := postgresql.NewMigrator(connString)
+		if err != nil {
+			return err
+		}
+		defer migrator.Close()
+		return migrator.Force(version)
+	},
+}
+
+var migrateBackfillCmd = &cobra.Command{
+	Use:   "backfill [flags]",
+	Args:  cobra.NoArgs,
+	Short: "Fill the derived tables from the rows indexed before their migrations",
+	Long: `Fill the derived tables of the applied migrations, e.g., the messages, the events or the transfers, from the
+blocks, the transactions and the block results indexed before the migrations that added them, in batches of
+--batch-size heights from the lowest one, one database transaction per batch. The backfills replace the derived rows
+of the heights they read, so that an interrupted backfill is resumed by running it again, and the short transactions
+do not hold up the extraction writing to the database meanwhile.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		connString, err := migrateConnString(cmd)
+		if err != nil {
+			return err
+		}
+		only, _ := cmd.Flags().GetStringSlice("only")
+		batchSize, _ := cmd.Flags().GetUint64("batch-size")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		handleInterrupt(cancel)
+
+		migrator, err := postgresql.NewMigrator(connString)
+		if err != nil {
+			return err
+		}
+		defer migrator.Close()
+		return migrator.Backfill(ctx, only, batchSize)
+	},
+}
+
+// migrateConnString returns the validated connection string of the migrate commands.
+func migrateConnString(cmd *cobra.Command) (string, error) {
+	// The flag is not bound to the configuration, to not shadow the flag of the postgres subcommand
+	postgresConfig := config.PostgresConfig{ConnString: viper.GetString("postgres-conn")}
+	if connString, _ := cmd.Flags().GetString("postgres-conn"); connString != "" {
+		postgresConfig.ConnString = connString
+	}
+	if err := postgresConfig.Validate(); err != nil {
+		return "", fmt.Errorf("invalid PostgreSQL configuration: %w", err)
+	}
+	return postgresConfig.ConnString, nil
+}
+
+// migrateUp applies the next steps pending migrations, or all of them if steps is 0.
+func migrateUp(connString string, steps int) error {
+	migrator, err := postgresql.NewMigrator(connString)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+	return migrator.Up(steps)
+}
+
+func init() {
+	MigrateCmd.PersistentFlags().StringP("postgres-conn", "p", "", "PostgreSQL connection string, or the YACI_POSTGRES_CONN environment variable")
+	migrateUpCmd.Flags().Int("steps", 0, "Number of migrations to apply (0 applies all the pending migrations)")
+	migrateDownCmd.Flags().Int("steps", 0, "Number of migrations to revert")
+	migrateDownCmd.Flags().Bool("all", false, "Revert all the migrations")
+	migrateStatusCmd.Flags().Bool("json", false, "Print the status as JSON")
+	migrateBackfillCmd.Flags().StringSlice("only", nil, "Only run these backfills, comma-separated, e.g., messages,tx_events (default: all)")
+	migrateBackfillCmd.Flags().Uint64("batch-size", 10000, "Number of heights backfilled per database transaction")
+
+	MigrateCmd.AddCommand(migrateUpCmd)
+	MigrateCmd.AddCommand(migrateDownCmd)
+	MigrateCmd.AddCommand(migrateStatusCmd)
+	MigrateCmd.AddCommand(migrateForceCmd)
+	MigrateCmd.AddCommand(migrateBackfillCmd)
+}
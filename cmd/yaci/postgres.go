@@ -1,13 +1,17 @@
 package yaci
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"sort"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/manifest-network/yaci/internal/metrics"
 	"github.com/manifest-network/yaci/internal/output/postgresql"
+	"github.com/manifest-network/yaci/internal/reflection"
 	"github.com/manifest-network/yaci/internal/utils"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -29,12 +33,26 @@ var PostgresRunE = func(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to parse PostgreSQL connection string: %w", err)
 	}
 
+	if postgresConfig.AutoMigrate {
+		if err := migrateUp(postgresConfig.ConnString, 0); err != nil {
+			return err
+		}
+	}
+
 	outputHandler, err := postgresql.NewPostgresOutputHandler(postgresConfig.ConnString)
 	if err != nil {
 		return fmt.Errorf("failed to create PostgreSQL output handler: %w", err)
 	}
 	defer outputHandler.Close()
 
+	outputHandler.StartCompaction(postgresConfig.CompactionInterval)
+
+	if postgresConfig.EnableTextSearch {
+		if err := outputHandler.EnableTextSearch(context.Background()); err != nil {
+			return err
+		}
+	}
+
 	if extractConfig.EnablePrometheus {
 		slog.Info("Starting Prometheus metrics server...")
 
@@ -53,9 +71,51 @@ var PostgresRunE = func(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// The snapshot is not needed by the extraction, which starts even if the node cannot be queried yet. The descriptors
+	// fetched while decoding are added to the snapshot once the extraction stops
+	if err := writeDescriptorSnapshot(outputHandler); err != nil {
+		slog.Warn("Failed to store the descriptor snapshot", "error", err)
+	}
+	defer func() {
+		if err := writeDescriptorSnapshot(outputHandler); err != nil {
+			slog.Warn("Failed to store the descriptor snapshot", "error", err)
+		}
+	}()
+
+	defer reportUnresolvedTypes()
 	return extractor.Extract(gRPCClient, outputHandler, extractConfig)
 }
 
+// writeDescriptorSnapshot stores the descriptors the chain data is decoded with, at the latest height of the node, for
+// the gRPC server reflection of the serve command. Nothing is stored if they did not change.
+func writeDescriptorSnapshot(outputHandler *postgresql.PostgresOutputHandler) error {
+	height, err := utils.GetLatestBlockHeightWithRetry(gRPCClient, extractConfig.MaxRetries)
+	if err != nil {
+		return fmt.Errorf("failed to get the latest height of the descriptor snapshot: %w", err)
+	}
+	return outputHandler.WriteDescriptorSnapshot(context.Background(), height, gRPCClient.Resolver.Descriptors())
+}
+
+// reportUnresolvedTypes logs a summary of the message types that could not be resolved during the run, whose raw
+// values were stored instead of their decoded values.
+func reportUnresolvedTypes() {
+	counts := reflection.UnresolvedTypeURLs()
+	if len(counts) == 0 {
+		return
+	}
+
+	typeURLs := make([]string, 0, len(counts))
+	for typeURL := range counts {
+		typeURLs = append(typeURLs, typeURL)
+	}
+	sort.Strings(typeURLs)
+
+	slog.Warn("Some message types could not be resolved, their raw values are in api.unknown_messages", "type_urls", len(typeURLs))
+	for _, typeURL := range typeURLs {
+		slog.Warn("Unresolved message type", "type_url", typeURL, "count", counts[typeURL])
+	}
+}
+
 var PostgresCmd = &cobra.Command{
 	Use:   "postgres [flags]",
 	Short: "Extract chain data to a PostgreSQL database",
@@ -73,6 +133,9 @@ var PostgresCmd = &cobra.Command{
 
 func init() {
 	PostgresCmd.Flags().StringP("postgres-conn", "p", "", "PosftgreSQL connection string")
+	PostgresCmd.Flags().Duration("compaction-interval", time.Hour, "Interval between compactions of the processed heights tracking table (0 disables)")
+	PostgresCmd.Flags().Bool("auto-migrate", false, "Apply the pending database schema migrations at startup, instead of running yaci migrate up beforehand")
+	PostgresCmd.Flags().Bool("enable-text-search", false, "Add the full-text search columns and indexes over the memos and the event attribute values")
 	if err := viper.BindPFlags(PostgresCmd.Flags()); err != nil {
 		slog.Error("Failed to bind postgresCmd flags", "error", err)
 	}
@@ -0,0 +1,136 @@
+package yaci
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/output/postgresql"
+)
+
+var PruneCmd = &cobra.Command{
+	Use:   "prune [flags]",
+	Args:  cobra.NoArgs,
+	Short: "Delete the old blocks stored in a PostgreSQL database",
+	Long: `Delete the stored blocks below a height, along with their transactions, their block results and the rows
+derived from them, in batches from the lowest height. The blocks to delete are selected with exactly one of
+--keep-last, --before-height and --older-than; the latest stored block is always kept, so that extraction resumes
+after it.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The flag is not bound to the configuration, to not shadow the flag of the postgres subcommand
+		postgresConfig := config.PostgresConfig{ConnString: viper.GetString("postgres-conn")}
+		if connString, _ := cmd.Flags().GetString("postgres-conn"); connString != "" {
+			postgresConfig.ConnString = connString
+		}
+		if err := postgresConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid PostgreSQL configuration: %w", err)
+		}
+
+		var pruneConfig config.PruneConfig
+		pruneConfig.KeepLast, _ = cmd.Flags().GetUint64("keep-last")
+		pruneConfig.BeforeHeight, _ = cmd.Flags().GetUint64("before-height")
+		pruneConfig.BatchSize, _ = cmd.Flags().GetInt("batch-size")
+		pruneConfig.DryRun, _ = cmd.Flags().GetBool("dry-run")
+		if olderThan, _ := cmd.Flags().GetString("older-than"); olderThan != "" {
+			age, err := config.ParseAge(olderThan)
+			if err != nil {
+				return err
+			}
+			pruneConfig.OlderThan = age
+		}
+		if err := pruneConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid prune configuration: %w", err)
+		}
+
+		outputHandler, err := postgresql.NewPostgresOutputHandler(postgresConfig.ConnString)
+		if err != nil {
+			return fmt.Errorf("failed to create PostgreSQL output handler: %w", err)
+		}
+		defer outputHandler.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		handleInterrupt(cancel)
+
+		latest, err := outputHandler.GetLatestBlock(ctx)
+		if err != nil {
+			return err
+		}
+		if latest == nil {
+			cmd.Println("No stored blocks to prune")
+			return nil
+		}
+
+		var before uint64
+		switch {
+		case pruneConfig.KeepLast > 0:
+			if latest.ID > pruneConfig.KeepLast {
+				before = latest.ID - pruneConfig.KeepLast + 1
+			}
+		case pruneConfig.BeforeHeight > 0:
+			before = pruneConfig.BeforeHeight
+		default:
+			height, found, err := outputHandler.GetFirstBlockSince(ctx, time.Now().Add(-pruneConfig.OlderThan))
+			if err != nil {
+				return err
+			}
+			before = latest.ID
+			if found {
+				before = height
+			}
+		}
+		before = min(before, latest.ID)
+
+		blocks, transactions, err := outputHandler.CountBlocksBefore(ctx, before)
+		if err != nil {
+			return err
+		}
+		if blocks == 0 {
+			cmd.Printf("No stored blocks below height %d to prune\n", before)
+			return nil
+		}
+		if pruneConfig.DryRun {
+			cmd.Printf("Would delete %d blocks and %d transactions below height %d\n", blocks, transactions, before)
+			return nil
+		}
+
+		slog.Info("Pruning blocks", "before", before, "blocks", blocks, "transactions", transactions)
+		var deletedBlocks, deletedTransactions uint64
+		for {
+			if ctx.Err() != nil {
+				slog.Info("Processing cancelled by user")
+				cmd.Printf("Deleted %d blocks and %d transactions before cancellation\n", deletedBlocks, deletedTransactions)
+				return ctx.Err()
+			}
+
+			batchBlocks, batchTransactions, last, err := outputHandler.PruneBlocks(ctx, before, pruneConfig.BatchSize)
+			if err != nil {
+				return err
+			}
+			if batchBlocks == 0 {
+				break
+			}
+			deletedBlocks += batchBlocks
+			deletedTransactions += batchTransactions
+			slog.Info("Pruning blocks", "height", last, "blocks", deletedBlocks, "remaining", blocks-min(deletedBlocks, blocks))
+		}
+
+		cmd.Printf("Deleted %d blocks and %d transactions below height %d\n", deletedBlocks, deletedTransactions, before)
+		return nil
+	},
+}
+
+func init() {
+	PruneCmd.Flags().StringP("postgres-conn", "p", "", "PostgreSQL connection string, or the YACI_POSTGRES_CONN environment variable")
+	PruneCmd.Flags().Uint64("keep-last", 0, "Keep the N highest stored blocks and delete the others")
+	PruneCmd.Flags().Uint64("before-height", 0, "Delete the blocks below this height")
+	PruneCmd.Flags().String("older-than", "", "Delete the blocks older than this age, in days, e.g., 90d, or as a duration, e.g., 36h")
+	PruneCmd.Flags().Int("batch-size", 1000, "Number of blocks deleted per database transaction")
+	PruneCmd.Flags().Bool("dry-run", false, "Only report the number of blocks and transactions that would be deleted")
+	PruneCmd.MarkFlagsMutuallyExclusive("keep-last", "before-height", "older-than")
+}
@@ -0,0 +1,173 @@
+package yaci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/output/postgresql"
+	"github.com/manifest-network/yaci/internal/query"
+)
+
+var QueryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Run canned queries against a PostgreSQL database",
+	Long: `Run canned parametrized queries against a PostgreSQL database, to pull transactions, blocks and events from
+the command line without writing SQL. The results are printed as a table, or as JSON with --json.`,
+}
+
+var queryTxCmd = &cobra.Command{
+	Use:   "tx [hash] [flags]",
+	Args:  cobra.ExactArgs(1),
+	Short: "Print the stored JSON of a transaction",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runQuery(cmd, func(ctx context.Context, h *postgresql.PostgresOutputHandler) error {
+			data, err := h.QueryTransaction(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			if data == nil {
+				return fmt.Errorf("transaction %s not found", args[0])
+			}
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, data, "", "  "); err != nil {
+				return fmt.Errorf("failed to format the transaction: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), buf.String())
+			return nil
+		})
+	},
+}
+
+var queryTxsCmd = &cobra.Command{
+	Use:   "txs [address] [flags]",
+	Args:  cobra.ExactArgs(1),
+	Short: "List the last transactions involving an address",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runQuery(cmd, func(ctx context.Context, h *postgresql.PostgresOutputHandler) error {
+			limit, _ := cmd.Flags().GetInt("limit")
+			result, err := h.QueryAddressTransactions(ctx, args[0], nil, limit)
+			if err != nil {
+				return err
+			}
+			return printResult(cmd, result)
+		})
+	},
+}
+
+var queryBlocksCmd = &cobra.Command{
+	Use:   "blocks [date] [flags]",
+	Args:  cobra.ExactArgs(1),
+	Short: "List the blocks of a UTC date, e.g., 2024-03-01",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		date, err := time.Parse(time.DateOnly, args[0])
+		if err != nil {
+			return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", args[0])
+		}
+		return runQuery(cmd, func(ctx context.Context, h *postgresql.PostgresOutputHandler) error {
+			limit, _ := cmd.Flags().GetInt("limit")
+			result, err := h.QueryBlocksByDate(ctx, date, limit)
+			if err != nil {
+				return err
+			}
+			return printResult(cmd, result)
+		})
+	},
+}
+
+var queryEventsCmd = &cobra.Command{
+	Use:   "events [type] [flags]",
+	Args:  cobra.ExactArgs(1),
+	Short: "List the last event attributes of a type, e.g., transfer",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runQuery(cmd, func(ctx context.Context, h *postgresql.PostgresOutputHandler) error {
+			limit, _ := cmd.Flags().GetInt("limit")
+			key, _ := cmd.Flags().GetString("key")
+			value, _ := cmd.Flags().GetString("value")
+			result, err := h.QueryEvents(ctx, args[0], key, value, nil, limit)
+			if err != nil {
+				return err
+			}
+			return printResult(cmd, result)
+		})
+	},
+}
+
+var querySearchCmd = &cobra.Command{
+	Use:   "search [text] [flags]",
+	Args:  cobra.ExactArgs(1),
+	Short: "Search the memos and the event attribute values for a text",
+	Long: `Search the memos and the event attribute values for a text, with the web search syntax, e.g.,
+'"exchange deposit" -refund', the most relevant matches first. The text search must be enabled, see
+--enable-text-search of the postgres subcommand.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runQuery(cmd, func(ctx context.Context, h *postgresql.PostgresOutputHandler) error {
+			limit, _ := cmd.Flags().GetInt("limit")
+			result, err := h.SearchText(ctx, args[0], limit)
+			if err != nil {
+				return err
+			}
+			return printResult(cmd, result)
+		})
+	},
+}
+
+// runQuery runs a canned query with a handler connected to the database of the flags.
+func runQuery(cmd *cobra.Command, run func(context.Context, *postgresql.PostgresOutputHandler) error) error {
+	// The flag is not bound to the configuration, to not shadow the flag of the postgres subcommand
+	postgresConfig := config.PostgresConfig{ConnString: viper.GetString("postgres-conn")}
+	if connString, _ := cmd.Flags().GetString("postgres-conn"); connString != "" {
+		postgresConfig.ConnString = connString
+	}
+	if err := postgresConfig.Validate(); err != nil {
+		return fmt.Errorf("invalid PostgreSQL configuration: %w", err)
+	}
+	if limit, _ := cmd.Flags().GetInt("limit"); limit <= 0 {
+		return fmt.Errorf("limit must be positive")
+	}
+
+	outputHandler, err := postgresql.NewPostgresOutputHandler(postgresConfig.ConnString)
+	if err != nil {
+		return fmt.Errorf("failed to create PostgreSQL output handler: %w", err)
+	}
+	defer outputHandler.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handleInterrupt(cancel)
+
+	return run(ctx, outputHandler)
+}
+
+// printResult prints the result of a query as a table, or as JSON with the json flag.
+func printResult(cmd *cobra.Command, result *query.Result) error {
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode the results: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	}
+	return result.Print(cmd.OutOrStdout())
+}
+
+func init() {
+	QueryCmd.PersistentFlags().StringP("postgres-conn", "p", "", "PostgreSQL connection string, or the YACI_POSTGRES_CONN environment variable")
+	QueryCmd.PersistentFlags().Int("limit", 100, "Maximum number of results")
+	QueryCmd.PersistentFlags().Bool("json", false, "Print the results as JSON")
+	queryEventsCmd.Flags().String("key", "", "Only list the attributes with this key, e.g., recipient")
+	queryEventsCmd.Flags().String("value", "", "Only list the attributes with this value")
+
+	QueryCmd.AddCommand(queryTxCmd)
+	QueryCmd.AddCommand(queryTxsCmd)
+	QueryCmd.AddCommand(queryBlocksCmd)
+	QueryCmd.AddCommand(queryEventsCmd)
+	QueryCmd.AddCommand(querySearchCmd)
+}
@@ -0,0 +1,104 @@
+package yaci
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/extractor"
+	"github.com/manifest-network/yaci/internal/output/postgresql"
+	"github.com/manifest-network/yaci/internal/reflection"
+)
+
+var ReplayCmd = &cobra.Command{
+	Use:   "replay [flags]",
+	Args:  cobra.NoArgs,
+	Short: "Decode the blocks stored in a PostgreSQL database again",
+	Long: `Run the blocks, the transactions and the block results stored in a PostgreSQL database through the decoding of
+the extraction again and rewrite them, without any endpoint, so that the columns and the tables derived from them are
+refreshed, e.g., after a decoder fix or a migration adding a derived table, instead of extracting the chain again.
+
+The interchain accounts packets and the protobuf vote extensions are decoded with the descriptors of --descriptors and
+--proto-dir, and left as they are without them.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The flag is not bound to the configuration, to not shadow the flag of the postgres subcommand
+		postgresConfig := config.PostgresConfig{ConnString: viper.GetString("postgres-conn")}
+		if connString, _ := cmd.Flags().GetString("postgres-conn"); connString != "" {
+			postgresConfig.ConnString = connString
+		}
+		if err := postgresConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid PostgreSQL configuration: %w", err)
+		}
+
+		var replayConfig config.ReplayConfig
+		replayConfig.BlockStart, _ = cmd.Flags().GetUint64("start")
+		replayConfig.BlockStop, _ = cmd.Flags().GetUint64("stop")
+		replayConfig.BatchSize, _ = cmd.Flags().GetInt("batch-size")
+		replayConfig.Descriptors, _ = cmd.Flags().GetString("descriptors")
+		replayConfig.ProtoDirs, _ = cmd.Flags().GetStringArray("proto-dir")
+		replayConfig.RedactMemoPatterns, _ = cmd.Flags().GetStringArray("redact-memo")
+		replayConfig.MemoRedaction, _ = cmd.Flags().GetString("memo-redaction")
+		replayConfig.VoteExtensionDecoder, _ = cmd.Flags().GetString("vote-extension-decoder")
+		replayConfig.CanonicalJSON, _ = cmd.Flags().GetBool("canonical-json")
+		replayConfig.PruneJSON, _ = cmd.Flags().GetStringArray("prune-json")
+		if err := replayConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid replay configuration: %w", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		handleInterrupt(cancel)
+
+		resolver, err := replayResolver(ctx, replayConfig)
+		if err != nil {
+			return err
+		}
+
+		outputHandler, err := postgresql.NewPostgresOutputHandler(postgresConfig.ConnString)
+		if err != nil {
+			return fmt.Errorf("failed to create PostgreSQL output handler: %w", err)
+		}
+		defer outputHandler.Close()
+
+		summary, err := extractor.ReplayBlocks(ctx, outputHandler, outputHandler, resolver, replayConfig)
+		if err != nil {
+			return err
+		}
+		cmd.Printf("Replayed %d blocks, %d transactions and %d block results\n", summary.Blocks, summary.Transactions, summary.BlockResults)
+		return nil
+	},
+}
+
+// replayResolver returns the resolver of the descriptors supplied with the configuration, which resolves nothing if
+// there are none.
+func replayResolver(ctx context.Context, cfg config.ReplayConfig) (*reflection.CustomResolver, error) {
+	descriptors, err := loadDescriptors(ctx, cfg.ExtractConfig())
+	if err != nil {
+		return nil, err
+	}
+	files := &protoregistry.Files{}
+	if descriptors != nil {
+		if files, err = reflection.BuildFileDescriptorSet(descriptors.Files); err != nil {
+			return nil, fmt.Errorf("failed to build descriptor set: %w", err)
+		}
+	}
+	return reflection.NewCustomResolver(ctx, files, nil, 0), nil
+}
+
+func init() {
+	ReplayCmd.Flags().StringP("postgres-conn", "p", "", "PostgreSQL connection string, or the YACI_POSTGRES_CONN environment variable")
+	ReplayCmd.Flags().Uint64P("start", "s", 0, "Start block height, by default the lowest stored block")
+	ReplayCmd.Flags().Uint64P("stop", "e", 0, "Stop block height, by default the highest stored block")
+	ReplayCmd.Flags().Int("batch-size", 1000, "Number of blocks read at once")
+	ReplayCmd.Flags().String("descriptors", "", "Path of a protobuf FileDescriptorSet, e.g., built with buf build -o chain.pb, decoding the interchain accounts packets and the protobuf vote extensions")
+	ReplayCmd.Flags().StringArray("proto-dir", nil, "Directory of .proto files compiled at startup, merged like --descriptors (repeatable)")
+	ReplayCmd.Flags().StringArray("redact-memo", nil, "Regular expression of the transaction memos to redact, e.g., \"@\" (repeatable)")
+	ReplayCmd.Flags().String("memo-redaction", "hash", "Redaction of the memos matching --redact-memo: hash replaces them with their SHA-256 hash, truncate keeps their first 16 characters")
+	ReplayCmd.Flags().String("vote-extension-decoder", "", "Decode the vote extensions the proposers inject into their blocks, as json or proto:MESSAGE_NAME (empty disables)")
+	ReplayCmd.Flags().StringArray("prune-json", nil, "JSON path of the blocks, transactions and block results to drop, PATH, or whose strings to truncate to N characters, PATH=N (repeatable)")
+	ReplayCmd.Flags().Bool("canonical-json", false, "Rewrite the JSON payloads in canonical form, with sorted keys and fixed number formatting")
+}
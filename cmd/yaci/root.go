@@ -2,6 +2,7 @@ package yaci
 
 import (
 	"fmt"
+	"io"
 	"log/slog"
 	"maps"
 	"os"
@@ -20,6 +21,9 @@ var (
 		"error": slog.LevelError,
 	}
 	validLogLevelsStr = strings.Join(slices.Sorted(maps.Keys(validLogLevels)), "|")
+
+	// logOutput is where the logs are written to
+	logOutput io.Writer = os.Stdout
 )
 
 var RootCmd = &cobra.Command{
@@ -43,7 +47,7 @@ func setLogLevel(logLevel string) error {
 		return fmt.Errorf("invalid log level: %s. Valid log levels are: %s", logLevel, validLogLevelsStr)
 	}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	logger := slog.New(slog.NewJSONHandler(logOutput, &slog.HandlerOptions{
 		Level: level,
 	}))
 	slog.SetDefault(logger)
@@ -70,6 +74,21 @@ func init() {
 	viper.AutomaticEnv()
 
 	RootCmd.AddCommand(ExtractCmd)
+	RootCmd.AddCommand(DiffCmd)
+	RootCmd.AddCommand(ExportCmd)
+	RootCmd.AddCommand(GapsCmd)
+	RootCmd.AddCommand(ImportCmd)
+	RootCmd.AddCommand(StreamCmd)
+	RootCmd.AddCommand(BenchmarkCmd)
+	RootCmd.AddCommand(BlockstoreCmd)
+	RootCmd.AddCommand(LabelsCmd)
+	RootCmd.AddCommand(MigrateCmd)
+	RootCmd.AddCommand(PruneCmd)
+	RootCmd.AddCommand(QueryCmd)
+	RootCmd.AddCommand(ReplayCmd)
+	RootCmd.AddCommand(ServeCmd)
+	RootCmd.AddCommand(StatusCmd)
+	RootCmd.AddCommand(VerifyCmd)
 	RootCmd.AddCommand(versionCmd)
 }
 
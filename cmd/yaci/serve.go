@@ -0,0 +1,148 @@
+package yaci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/gql"
+	"github.com/manifest-network/yaci/internal/output/postgresql"
+	"github.com/manifest-network/yaci/internal/reflection"
+	"github.com/manifest-network/yaci/internal/rest"
+)
+
+var ServeCmd = &cobra.Command{
+	Use:   "serve [flags]",
+	Args:  cobra.NoArgs,
+	Short: "Serve a paginated REST API and a GraphQL endpoint over a PostgreSQL database",
+	Long: `Serve a read-only, paginated REST API over the blocks, the transactions and the events stored in a PostgreSQL
+database, and a GraphQL endpoint over the blocks, the transactions, the messages and the events at /graphql, so that
+lightweight frontends consume the index without database credentials. The balances, the delegations and the validator
+sets are served as of the at_height parameter, from the snapshots and the derived tables. With --grpc-addr, the gRPC
+server reflection requests are answered with the latest descriptor snapshot stored by the extraction, so that the
+types of the chain can be introspected even when its nodes are unreachable.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The flag is not bound to the configuration, to not shadow the flag of the postgres subcommand
+		postgresConfig := config.PostgresConfig{ConnString: viper.GetString("postgres-conn")}
+		if connString, _ := cmd.Flags().GetString("postgres-conn"); connString != "" {
+			postgresConfig.ConnString = connString
+		}
+		if err := postgresConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid PostgreSQL configuration: %w", err)
+		}
+		addr, _ := cmd.Flags().GetString("addr")
+		corsOrigin, _ := cmd.Flags().GetString("cors-origin")
+		enableGraphQL, _ := cmd.Flags().GetBool("graphql")
+		grpcAddr, _ := cmd.Flags().GetString("grpc-addr")
+
+		outputHandler, err := postgresql.NewPostgresOutputHandler(postgresConfig.ConnString)
+		if err != nil {
+			return fmt.Errorf("failed to create PostgreSQL output handler: %w", err)
+		}
+		defer outputHandler.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		handleInterrupt(cancel)
+
+		mux := http.NewServeMux()
+		mux.Handle("/v1/", rest.NewHandler(outputHandler))
+		if enableGraphQL {
+			schema, err := gql.NewSchema(outputHandler)
+			if err != nil {
+				return fmt.Errorf("failed to create the GraphQL schema: %w", err)
+			}
+			mux.Handle("/graphql", gql.NewHandler(schema))
+		}
+
+		var grpcServer *grpc.Server
+		var grpcErrChan chan error
+		if grpcAddr != "" {
+			if grpcServer, err = newReflectionServer(ctx, outputHandler); err != nil {
+				return err
+			}
+			defer grpcServer.Stop()
+			listener, err := net.Listen("tcp", grpcAddr)
+			if err != nil {
+				return fmt.Errorf("failed to listen for gRPC server reflection: %w", err)
+			}
+			grpcErrChan = make(chan error, 1)
+			go func() {
+				slog.Info("Serving gRPC server reflection", "addr", grpcAddr)
+				grpcErrChan <- grpcServer.Serve(listener)
+			}()
+		}
+
+		server := rest.NewServer(addr, rest.WithCORS(mux, corsOrigin))
+		errChan := make(chan error, 1)
+		go func() {
+			slog.Info("Serving the REST API", "addr", addr)
+			errChan <- server.ListenAndServe()
+		}()
+
+		select {
+		case err := <-errChan:
+			return fmt.Errorf("failed to serve the REST API: %w", err)
+		case err := <-grpcErrChan:
+			return fmt.Errorf("failed to serve gRPC server reflection: %w", err)
+		case <-ctx.Done():
+		}
+
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer shutdownCancel()
+		if grpcServer != nil {
+			stopped := make(chan struct{})
+			go func() {
+				grpcServer.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-shutdownCtx.Done():
+				grpcServer.Stop()
+			}
+		}
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("failed to shut down the REST API: %w", err)
+		}
+		if err := <-errChan; !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("failed to serve the REST API: %w", err)
+		}
+		return nil
+	},
+}
+
+// newReflectionServer returns the gRPC server answering the server reflection requests with the latest descriptor
+// snapshot.
+func newReflectionServer(ctx context.Context, outputHandler *postgresql.PostgresOutputHandler) (*grpc.Server, error) {
+	descriptors, height, err := outputHandler.GetLatestDescriptorSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if descriptors == nil {
+		return nil, fmt.Errorf("no descriptor snapshot stored, run the extraction with yaci extract postgres first")
+	}
+	server, err := reflection.NewServer(descriptors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the gRPC server reflection of the descriptor snapshot of height %d: %w", height, err)
+	}
+	slog.Info("Loaded descriptor snapshot", "height", height, "files", len(descriptors))
+	return server, nil
+}
+
+func init() {
+	ServeCmd.Flags().StringP("postgres-conn", "p", "", "PostgreSQL connection string, or the YACI_POSTGRES_CONN environment variable")
+	ServeCmd.Flags().String("addr", "0.0.0.0:8080", "Address and port of the REST API server")
+	ServeCmd.Flags().Bool("graphql", true, "Serve the GraphQL endpoint at /graphql")
+	ServeCmd.Flags().String("grpc-addr", "", "Address and port of the gRPC server reflection endpoint serving the latest stored descriptor snapshot, e.g., 0.0.0.0:9091 (empty disables)")
+	ServeCmd.Flags().String("cors-origin", "", "Origin allowed to call the API from a browser, e.g., https://explorer.example.com, or * for any")
+}
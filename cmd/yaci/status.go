@@ -0,0 +1,124 @@
+package yaci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/extractor"
+	"github.com/manifest-network/yaci/internal/output/postgresql"
+	"github.com/manifest-network/yaci/internal/status"
+	"github.com/manifest-network/yaci/internal/utils"
+)
+
+var StatusCmd = &cobra.Command{
+	Use:   "status [address] [flags]",
+	Args:  cobra.ExactArgs(1),
+	Short: "Report the progress of a PostgreSQL output relative to a node",
+	Long: `Report the earliest and latest heights stored in a PostgreSQL database, the number of missing blocks, the tip of
+the chain reported by the node, the lag of the output in blocks and in time, and the estimated time to catch up from
+the recent indexing rate, in human-readable form or in JSON.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := cmd.Flags()
+		// The flag is not bound to the configuration, to not shadow the flag of the postgres subcommand
+		postgresConfig := config.PostgresConfig{ConnString: viper.GetString("postgres-conn")}
+		if connString, _ := flags.GetString("postgres-conn"); connString != "" {
+			postgresConfig.ConnString = connString
+		}
+		if err := postgresConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid PostgreSQL configuration: %w", err)
+		}
+		insecure, _ := flags.GetBool("insecure")
+		maxRetries, _ := flags.GetUint("max-retries")
+		maxRecvMsgSize, _ := flags.GetInt("max-recv-msg-size")
+		rateWindow, _ := flags.GetDuration("rate-window")
+		asJSON, _ := flags.GetBool("json")
+		if rateWindow <= 0 {
+			return fmt.Errorf("rate window must be positive")
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		handleInterrupt(cancel)
+
+		outputHandler, err := postgresql.NewPostgresOutputHandler(postgresConfig.ConnString)
+		if err != nil {
+			return fmt.Errorf("failed to create PostgreSQL output handler: %w", err)
+		}
+		defer outputHandler.Close()
+
+		output, err := outputStatus(ctx, outputHandler, rateWindow)
+		if err != nil {
+			return err
+		}
+
+		gRPCClient, err := client.NewGRPCClient(ctx, args[0], insecure, maxRecvMsgSize)
+		if err != nil {
+			return fmt.Errorf("failed to initialize gRPC: %w", err)
+		}
+		defer gRPCClient.Close()
+
+		var node status.Node
+		if node.Latest, err = utils.GetLatestBlockHeightWithRetry(gRPCClient, maxRetries); err != nil {
+			return fmt.Errorf("failed to get the latest block height of the node: %w", err)
+		}
+		if node.LatestTime, err = extractor.GetBlockTime(gRPCClient, node.Latest, maxRetries); err != nil {
+			return fmt.Errorf("failed to get the latest block time of the node: %w", err)
+		}
+
+		report := status.NewReport(output, node)
+		if asJSON {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode the status: %w", err)
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), string(data))
+			return err
+		}
+		return report.Print(cmd.OutOrStdout())
+	},
+}
+
+// outputStatus returns the state of the output, with the number of blocks committed during the last rate window.
+func outputStatus(ctx context.Context, outputHandler *postgresql.PostgresOutputHandler, rateWindow time.Duration) (status.Output, error) {
+	output := status.Output{RateWindow: rateWindow}
+
+	earliest, err := outputHandler.GetEarliestBlock(ctx)
+	if err != nil {
+		return output, err
+	}
+	latest, err := outputHandler.GetLatestBlock(ctx)
+	if err != nil {
+		return output, err
+	}
+	if earliest == nil || latest == nil {
+		return output, nil
+	}
+	output.Earliest, output.Latest = earliest.ID, latest.ID
+
+	if output.LatestTime, err = outputHandler.GetBlockTime(ctx, latest.ID); err != nil {
+		return output, err
+	}
+	if output.Missing, err = outputHandler.CountMissingBlocks(ctx); err != nil {
+		return output, err
+	}
+	if output.IndexedRecently, err = outputHandler.CountBlocksIndexedSince(ctx, time.Now().Add(-rateWindow)); err != nil {
+		return output, err
+	}
+	return output, nil
+}
+
+func init() {
+	StatusCmd.Flags().StringP("postgres-conn", "p", "", "PostgreSQL connection string, or the YACI_POSTGRES_CONN environment variable")
+	StatusCmd.Flags().BoolP("insecure", "k", false, "Disable TLS and use an insecure plaintext connection")
+	StatusCmd.Flags().UintP("max-retries", "r", 3, "Maximum number of retries for failed requests")
+	StatusCmd.Flags().IntP("max-recv-msg-size", "m", 4194304, "Maximum gRPC message size in bytes (advanced)")
+	StatusCmd.Flags().Duration("rate-window", 10*time.Minute, "Window of the recent blocks the indexing rate is measured over")
+	StatusCmd.Flags().Bool("json", false, "Print the status in JSON")
+}
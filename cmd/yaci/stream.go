@@ -0,0 +1,65 @@
+package yaci
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/output/postgresql"
+	"github.com/manifest-network/yaci/internal/streaming"
+)
+
+var StreamCmd = &cobra.Command{
+	Use:   "stream [flags]",
+	Args:  cobra.NoArgs,
+	Short: "Ingest the state changes streamed by a node as an ABCI listener plugin",
+	Long: `Run as an ADR-038 ABCI listener plugin launched by a node, and write the FinalizeBlock and Commit data and the
+state changes of every block to a PostgreSQL database, instead of polling the query endpoints of the node.
+The plugin is launched by the node without arguments, so it is usually configured with environment variables.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// The standard output is reserved to the plugin handshake
+		logOutput = os.Stderr
+
+		if parent := cmd.Parent(); parent != nil && parent.PreRunE != nil {
+			if err := parent.PreRunE(parent, args); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The flag is not bound to the configuration, to not shadow the flag of the postgres subcommand
+		postgresConfig := config.PostgresConfig{ConnString: viper.GetString("postgres-conn")}
+		if connString, _ := cmd.Flags().GetString("postgres-conn"); connString != "" {
+			postgresConfig.ConnString = connString
+		}
+		if err := postgresConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid PostgreSQL configuration: %w", err)
+		}
+
+		outputHandler, err := postgresql.NewPostgresOutputHandler(postgresConfig.ConnString)
+		if err != nil {
+			return fmt.Errorf("failed to create PostgreSQL output handler: %w", err)
+		}
+		defer outputHandler.Close()
+
+		listener, err := streaming.NewListener(outputHandler)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		handleInterrupt(cancel)
+
+		return streaming.Serve(ctx, listener, os.Stdout)
+	},
+}
+
+func init() {
+	StreamCmd.Flags().StringP("postgres-conn", "p", "", "PostgreSQL connection string, or the YACI_POSTGRES_CONN environment variable")
+}
@@ -0,0 +1,80 @@
+package yaci
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/manifest-network/yaci/internal/extractor"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output/postgresql"
+)
+
+var TxCmd = &cobra.Command{
+	Use:   "tx [address] [hash]",
+	Args:  cobra.ExactArgs(2),
+	Short: "Fetch a single transaction by hash",
+	Long: `Fetch and decode a single transaction by hash and print it as JSON.
+When a PostgreSQL connection string is given, the transaction is also written to the database.
+Useful to investigate a specific transaction without running a range extraction.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if b, err := hex.DecodeString(args[1]); err != nil || len(b) != 32 {
+			return fmt.Errorf("invalid transaction hash %q, expected 64 hexadecimal characters", args[1])
+		}
+
+		if parent := cmd.Parent(); parent != nil && parent.PreRunE != nil {
+			if err := parent.PreRunE(parent, args); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		defer gRPCClient.Close()
+
+		hash := strings.ToLower(args[1])
+		transaction, err := extractor.FetchTransaction(gRPCClient, hash, extractConfig.MaxRetries)
+		if err != nil {
+			return err
+		}
+		if err := extractor.RedactMemos([]*models.Transaction{transaction}, extractConfig); err != nil {
+			return err
+		}
+		if err := extractor.PruneTransactions([]*models.Transaction{transaction}, extractConfig); err != nil {
+			return err
+		}
+
+		var out bytes.Buffer
+		if err := json.Indent(&out, transaction.Data, "", "  "); err != nil {
+			return fmt.Errorf("failed to format transaction: %w", err)
+		}
+		cmd.Println(out.String())
+
+		// The flag is not bound to the configuration, to not shadow the flag of the postgres subcommand
+		connString, _ := cmd.Flags().GetString("postgres-conn")
+		if connString == "" {
+			return nil
+		}
+
+		outputHandler, err := postgresql.NewPostgresOutputHandler(connString)
+		if err != nil {
+			return fmt.Errorf("failed to create PostgreSQL output handler: %w", err)
+		}
+		defer outputHandler.Close()
+
+		if err := outputHandler.WriteTransactions(gRPCClient.Ctx, []*models.Transaction{transaction}); err != nil {
+			return err
+		}
+		cmd.PrintErrf("Transaction %s written to the database\n", hash)
+		return nil
+	},
+}
+
+func init() {
+	TxCmd.Flags().StringP("postgres-conn", "p", "", "PostgreSQL connection string to also write the transaction to")
+	ExtractCmd.AddCommand(TxCmd)
+}
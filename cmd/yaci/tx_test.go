@@ -0,0 +1,18 @@
+package yaci_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/manifest-network/yaci/cmd/yaci"
+)
+
+func TestTxCmd(t *testing.T) {
+	// The hash is validated before connecting to the gRPC server
+	_, err := executeCommand(yaci.RootCmd, "extract", "tx", "foobar", "not-a-hash")
+	assert.ErrorContains(t, err, "invalid transaction hash")
+
+	_, err = executeCommand(yaci.RootCmd, "extract", "tx", "foobar")
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package yaci
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/output/postgresql"
+	"github.com/manifest-network/yaci/internal/verify"
+)
+
+var VerifyCmd = &cobra.Command{
+	Use:   "verify [flags]",
+	Args:  cobra.NoArgs,
+	Short: "Verify the integrity of the blocks and transactions stored in a PostgreSQL database",
+	Long: `Walk the stored blocks in order of height and report the corruptions: heights missing between stored blocks,
+except the skipped and pruned ranges, header heights and hash columns inconsistent with the block data, previous
+block hashes not matching the hash of the stored previous block, and transactions of the blocks, whose hashes are
+recomputed from their bytes, that are not stored or whose response reports another hash.
+Exits with an error when issues are found.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The flag is not bound to the configuration, to not shadow the flag of the postgres subcommand
+		postgresConfig := config.PostgresConfig{ConnString: viper.GetString("postgres-conn")}
+		if connString, _ := cmd.Flags().GetString("postgres-conn"); connString != "" {
+			postgresConfig.ConnString = connString
+		}
+		if err := postgresConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid PostgreSQL configuration: %w", err)
+		}
+		start, _ := cmd.Flags().GetUint64("start")
+		stop, _ := cmd.Flags().GetUint64("stop")
+		batchSize, _ := cmd.Flags().GetInt("batch-size")
+		if start > 0 && stop > 0 && start > stop {
+			return fmt.Errorf("start block is greater than stop block")
+		}
+		if batchSize <= 0 {
+			return fmt.Errorf("batch size must be positive")
+		}
+
+		outputHandler, err := postgresql.NewPostgresOutputHandler(postgresConfig.ConnString)
+		if err != nil {
+			return fmt.Errorf("failed to create PostgreSQL output handler: %w", err)
+		}
+		defer outputHandler.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		handleInterrupt(cancel)
+
+		summary, err := verify.Verify(ctx, outputHandler, start, stop, batchSize, func(issue verify.Issue) {
+			cmd.Println(issue.String())
+		})
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf("Verified %d blocks and %d transactions\n", summary.Blocks, summary.Transactions)
+		if summary.Issues > 0 {
+			return fmt.Errorf("%d issues found", summary.Issues)
+		}
+		return nil
+	},
+}
+
+func init() {
+	VerifyCmd.Flags().StringP("postgres-conn", "p", "", "PostgreSQL connection string, or the YACI_POSTGRES_CONN environment variable")
+	VerifyCmd.Flags().Uint64P("start", "s", 0, "Start block height, by default the lowest stored block")
+	VerifyCmd.Flags().Uint64P("stop", "e", 0, "Stop block height, by default the highest stored block")
+	VerifyCmd.Flags().Int("batch-size", 1000, "Number of blocks read at once")
+}
@@ -0,0 +1,127 @@
+// Package addresses finds the bech32 addresses referenced by the JSON documents of the chain.
+package addresses
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// candidatePattern matches the strings shaped like bech32 addresses: a lowercase human-readable part, the separator
+// and at least 38 characters of data, i.e., a 20-byte address and its checksum.
+var candidatePattern = regexp.MustCompile(`\b[a-z]{1,83}1[` + charset + `]{38,}\b`)
+
+// Find returns the sorted distinct bech32 addresses found in data, whose checksum is valid.
+func Find(data []byte) []string {
+	var found []string
+	for _, match := range candidatePattern.FindAll(data, -1) {
+		address := string(match)
+		if Valid(address) && !slices.Contains(found, address) {
+			found = append(found, address)
+		}
+	}
+	slices.Sort(found)
+	return found
+}
+
+// Valid returns true if s is a lowercase bech32 string with a valid checksum.
+func Valid(s string) bool {
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+7 > len(s) {
+		return false
+	}
+
+	hrp, data := s[:sep], s[sep+1:]
+	values := make([]byte, 0, 2*len(hrp)+1+len(data))
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return false
+		}
+		values = append(values, hrp[i]>>5)
+	}
+	values = append(values, 0)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+	for i := 0; i < len(data); i++ {
+		v := strings.IndexByte(charset, data[i])
+		if v < 0 {
+			return false
+		}
+		values = append(values, byte(v))
+	}
+
+	return polymod(values) == 1
+}
+
+// Encode returns the bech32 address of data with the human-readable part hrp.
+func Encode(hrp string, data []byte) (string, error) {
+	if hrp == "" || strings.ToLower(hrp) != hrp {
+		return "", fmt.Errorf("invalid human-readable part %q", hrp)
+	}
+
+	// Regroup the 8-bit bytes into 5-bit values, padding the last one with zeros
+	var values []byte
+	acc, bits := 0, 0
+	for _, b := range data {
+		acc = acc<<8 | int(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			values = append(values, byte(acc>>bits&31))
+		}
+	}
+	if bits > 0 {
+		values = append(values, byte(acc<<(5-bits)&31))
+	}
+
+	expanded := make([]byte, 0, 2*len(hrp)+1+len(values)+6)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	expanded = append(expanded, values...)
+	expanded = append(expanded, 0, 0, 0, 0, 0, 0)
+	checksum := polymod(expanded) ^ 1
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range values {
+		sb.WriteByte(charset[v])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(charset[checksum>>(5*(5-i))&31])
+	}
+	return sb.String(), nil
+}
+
+// ModuleAddress returns the bech32 address of the account of a module, the first 20 bytes of the SHA-256 hash of its
+// name, e.g., fee_collector.
+func ModuleAddress(hrp, module string) (string, error) {
+	hash := sha256.Sum256([]byte(module))
+	return Encode(hrp, hash[:20])
+}
+
+// polymod computes the bech32 checksum of the values, as specified by BIP-173.
+func polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
@@ -0,0 +1,77 @@
+package addresses_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/addresses"
+)
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		address string
+		valid   bool
+	}{
+		{"cosmos1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc5lzv7xu", true},
+		{"cosmosvaloper1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc5a3kdc5", false},
+		{"cosmos1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc5lzv7xv", false},
+		{"a12uel5l", true},
+		{"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", true},
+		{"1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc5lzv7xu", false},
+		{"cosmos1b", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			assert.Equal(t, tt.valid, addresses.Valid(tt.address))
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	data := []byte(`{
+		"body": {"messages": [{"@type": "/cosmos.bank.v1beta1.MsgSend", "fromAddress": "cosmos1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc5lzv7xu", "toAddress": "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw"}]},
+		"events": [{"type": "transfer", "attributes": [{"key": "sender", "value": "cosmos1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc5lzv7xu"}]}],
+		"invalid": "cosmos1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc5lzv7xv",
+		"txhash": "A1B2C3D4E5F60718293A4B5C6D7E8F90A1B2C3D4E5F60718293A4B5C6D7E8F90"
+	}`)
+
+	assert.Equal(t, []string{
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"cosmos1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc5lzv7xu",
+	}, addresses.Find(data))
+	assert.Empty(t, addresses.Find([]byte(`{"memo": "hello"}`)))
+}
+
+func TestEncode(t *testing.T) {
+	address, err := addresses.Encode("cosmos", []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20})
+	require.NoError(t, err)
+	assert.True(t, addresses.Valid(address))
+
+	_, err = addresses.Encode("", []byte{1})
+	require.Error(t, err)
+	_, err = addresses.Encode("Cosmos", []byte{1})
+	require.Error(t, err)
+}
+
+func TestModuleAddress(t *testing.T) {
+	tests := []struct {
+		module   string
+		expected string
+	}{
+		{"fee_collector", "cosmos17xpfvakm2amg962yls6f84z3kell8c5lserqta"},
+		{"distribution", "cosmos1jv65s3grqf6v6jl3dp4t6c9t9rk99cd88lyufl"},
+		{"bonded_tokens_pool", "cosmos1fl48vsnmsdzcv85q5d2q4z5ajdha8yu34mf0eh"},
+		{"gov", "cosmos10d07y265gmmuvt4z0w9aw880jnsr700j6zn9kn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.module, func(t *testing.T) {
+			address, err := addresses.ModuleAddress("cosmos", tt.module)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, address)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// Package archive writes and reads the files of the datasets exported from an output, one file per dataset in JSON
+// Lines, CSV or Parquet, so that a height range can be handed over without database access or loaded into another
+// output.
+package archive
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Formats of the files.
+const (
+	FormatJSONL   = "jsonl"
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+)
+
+// Names of the datasets, which are also the base names of their files.
+const (
+	DatasetBlocks       = "blocks"
+	DatasetTransactions = "transactions"
+	DatasetBlockResults = "block_results"
+)
+
+// Formats are the supported formats.
+var Formats = []string{FormatJSONL, FormatCSV, FormatParquet}
+
+// ValidateFormat returns an error if the format is not supported.
+func ValidateFormat(format string) error {
+	for _, f := range Formats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid format %q, expected one of %v", format, Formats)
+}
+
+// FileName returns the name of the file of a dataset in a format, e.g., blocks.jsonl.
+func FileName(dataset, format string) string {
+	return dataset + "." + format
+}
+
+// Block is a stored block. Time is its RFC 3339 on-chain time, empty if unknown.
+type Block struct {
+	Height int64           `json:"height" parquet:"height"`
+	Hash   string          `json:"hash,omitempty" parquet:"hash,optional"`
+	Time   string          `json:"time,omitempty" parquet:"time,optional"`
+	Data   json.RawMessage `json:"data" parquet:"data,json"`
+}
+
+// Transaction is a stored transaction, with the height of its block.
+type Transaction struct {
+	Hash   string          `json:"hash" parquet:"hash"`
+	Height int64           `json:"height" parquet:"height"`
+	Data   json.RawMessage `json:"data" parquet:"data,json"`
+}
+
+// BlockResults are the stored results of a block.
+type BlockResults struct {
+	Height int64           `json:"height" parquet:"height"`
+	Data   json.RawMessage `json:"data" parquet:"data,json"`
+}
+
+// Record is a record of a dataset.
+type Record interface {
+	Block | Transaction | BlockResults
+}
+
+func (b Block) csvRecord() []string {
+	return []string{strconv.FormatInt(b.Height, 10), b.Hash, b.Time, string(b.Data)}
+}
+
+func (t Transaction) csvRecord() []string {
+	return []string{t.Hash, strconv.FormatInt(t.Height, 10), string(t.Data)}
+}
+
+func (r BlockResults) csvRecord() []string {
+	return []string{strconv.FormatInt(r.Height, 10), string(r.Data)}
+}
+
+// csvHeader returns the header of the CSV files of the records of type T.
+func csvHeader[T Record]() []string {
+	var record T
+	switch any(record).(type) {
+	case Block:
+		return []string{"height", "hash", "time", "data"}
+	case Transaction:
+		return []string{"hash", "height", "data"}
+	default:
+		return []string{"height", "data"}
+	}
+}
+
+// Writer writes the records of a dataset to a file.
+type Writer[T Record] struct {
+	jsonl   *json.Encoder
+	csv     *csv.Writer
+	parquet *parquet.GenericWriter[T]
+}
+
+// NewWriter returns a writer of records of type T in the given format.
+func NewWriter[T Record](w io.Writer, format string) (*Writer[T], error) {
+	switch format {
+	case FormatJSONL:
+		return &Writer[T]{jsonl: json.NewEncoder(w)}, nil
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader[T]()); err != nil {
+			return nil, fmt.Errorf("failed to write CSV header: %w", err)
+		}
+		return &Writer[T]{csv: cw}, nil
+	case FormatParquet:
+		return &Writer[T]{parquet: parquet.NewGenericWriter[T](w)}, nil
+	default:
+		return nil, ValidateFormat(format)
+	}
+}
+
+// Write writes the records.
+func (w *Writer[T]) Write(records []T) error {
+	switch {
+	case w.jsonl != nil:
+		for _, r := range records {
+			if err := w.jsonl.Encode(r); err != nil {
+				return fmt.Errorf("failed to write record: %w", err)
+			}
+		}
+	case w.csv != nil:
+		for _, r := range records {
+			if err := w.csv.Write(any(r).(interface{ csvRecord() []string }).csvRecord()); err != nil {
+				return fmt.Errorf("failed to write record: %w", err)
+			}
+		}
+	default:
+		if _, err := w.parquet.Write(records); err != nil {
+			return fmt.Errorf("failed to write records: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close flushes the records written. It does not close the underlying writer.
+func (w *Writer[T]) Close() error {
+	switch {
+	case w.csv != nil:
+		w.csv.Flush()
+		return w.csv.Error()
+	case w.parquet != nil:
+		return w.parquet.Close()
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package archive_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/archive"
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+// fakeSource is an in-memory output.
+type fakeSource struct {
+	blocks       []*models.Block
+	transactions map[uint64][]*models.Transaction
+	blockResults []*models.BlockResults
+}
+
+func (s *fakeSource) GetBlocks(_ context.Context, after, stop uint64, limit int) ([]*models.Block, error) {
+	var blocks []*models.Block
+	for _, b := range s.blocks {
+		if b.ID > after && (stop == 0 || b.ID <= stop) && len(blocks) < limit {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks, nil
+}
+
+func (s *fakeSource) GetBlockTransactions(_ context.Context, start, stop uint64) (map[uint64][]*models.Transaction, error) {
+	txs := make(map[uint64][]*models.Transaction)
+	for height, t := range s.transactions {
+		if height >= start && height <= stop {
+			txs[height] = t
+		}
+	}
+	return txs, nil
+}
+
+func (s *fakeSource) GetBlockResults(_ context.Context, start, stop uint64) ([]*models.BlockResults, error) {
+	var results []*models.BlockResults
+	for _, r := range s.blockResults {
+		if r.Height >= start && r.Height <= stop {
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{
+		blocks: []*models.Block{
+			{ID: 1, Hash: "HASH1", Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Data: []byte(`{"block":{"header":{"height":"1"}}}`)},
+			{ID: 2, Hash: "HASH2", Data: []byte(`{"block":{"header":{"height":"2"}}}`)},
+			{ID: 3, Hash: "HASH3", Data: []byte(`{"block":{"header":{"height":"3"}}}`)},
+		},
+		transactions: map[uint64][]*models.Transaction{
+			2: {{Hash: "aa", Data: []byte(`{"tx":{"body":{"memo":"a, \"quoted\" memo"}}}`)}, {Hash: "bb", Data: []byte(`{"tx":{}}`)}},
+		},
+		blockResults: []*models.BlockResults{{Height: 2, Data: []byte(`{"height":"2"}`)}},
+	}
+}
+
+func readLines(t *testing.T, path string) []string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestExportJSONL(t *testing.T) {
+	dir := t.TempDir()
+	summary, err := archive.Export(context.Background(), newFakeSource(), dir, archive.FormatJSONL, 0, 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, archive.Summary{Blocks: 3, Transactions: 2, BlockResults: 1}, summary)
+
+	blocks := readLines(t, filepath.Join(dir, "blocks.jsonl"))
+	require.Len(t, blocks, 3)
+	assert.JSONEq(t, `{"height":1,"hash":"HASH1","time":"2026-01-01T00:00:00Z","data":{"block":{"header":{"height":"1"}}}}`, blocks[0])
+
+	txs := readLines(t, filepath.Join(dir, "transactions.jsonl"))
+	require.Len(t, txs, 2)
+	var tx archive.Transaction
+	require.NoError(t, json.Unmarshal([]byte(txs[0]), &tx))
+	assert.Equal(t, "aa", tx.Hash)
+	assert.Equal(t, int64(2), tx.Height)
+
+	assert.Len(t, readLines(t, filepath.Join(dir, "block_results.jsonl")), 1)
+}
+
+func TestExportRange(t *testing.T) {
+	dir := t.TempDir()
+	summary, err := archive.Export(context.Background(), newFakeSource(), dir, archive.FormatCSV, 2, 2, 10)
+	require.NoError(t, err)
+	assert.Equal(t, archive.Summary{Blocks: 1, Transactions: 2, BlockResults: 1}, summary)
+
+	blocks := readLines(t, filepath.Join(dir, "blocks.csv"))
+	assert.Equal(t, []string{"height,hash,time,data", `2,HASH2,,"{""block"":{""header"":{""height"":""2""}}}"`}, blocks)
+}
+
+func TestExportParquet(t *testing.T) {
+	dir := t.TempDir()
+	_, err := archive.Export(context.Background(), newFakeSource(), dir, archive.FormatParquet, 0, 0, 2)
+	require.NoError(t, err)
+
+	for _, name := range []string{"blocks.parquet", "transactions.parquet", "block_results.parquet"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.Equal(t, "PAR1", string(data[:4]), name)
+	}
+}
+
+func TestExportInvalidFormat(t *testing.T) {
+	_, err := archive.Export(context.Background(), newFakeSource(), t.TempDir(), "xml", 0, 0, 0)
+	require.Error(t, err)
+}
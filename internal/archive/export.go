@@ -0,0 +1,160 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+// defaultBatchSize is the number of blocks exported at once by default.
+const defaultBatchSize = 1000
+
+// Source reads the stored blocks, transactions and block results, e.g., a PostgreSQL output.
+type Source interface {
+	// GetBlocks returns up to limit blocks above height after and up to stop, ordered by height.
+	GetBlocks(ctx context.Context, after, stop uint64, limit int) ([]*models.Block, error)
+	// GetBlockTransactions returns the stored transactions of the blocks from start to stop, by height.
+	GetBlockTransactions(ctx context.Context, start, stop uint64) (map[uint64][]*models.Transaction, error)
+	// GetBlockResults returns the stored results of the blocks from start to stop, ordered by height.
+	GetBlockResults(ctx context.Context, start, stop uint64) ([]*models.BlockResults, error)
+}
+
+// Summary counts the exported or imported records.
+type Summary struct {
+	Blocks       uint64
+	Transactions uint64
+	BlockResults uint64
+}
+
+// datasetFile is the file of a dataset being written.
+type datasetFile[T Record] struct {
+	file   *os.File
+	writer *Writer[T]
+}
+
+func createDatasetFile[T Record](dir, dataset, format string) (*datasetFile[T], error) {
+	f, err := os.Create(filepath.Join(dir, FileName(dataset, format)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s file: %w", dataset, err)
+	}
+	w, err := NewWriter[T](f, format)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &datasetFile[T]{file: f, writer: w}, nil
+}
+
+func (d *datasetFile[T]) close() error {
+	if err := d.writer.Close(); err != nil {
+		d.file.Close()
+		return err
+	}
+	return d.file.Close()
+}
+
+// Export writes the blocks from start to stop, 0 for the latest, their transactions and their results to one file per
+// dataset in dir, reading batchSize blocks at once, 0 for the default.
+func Export(ctx context.Context, src Source, dir, format string, start, stop uint64, batchSize int) (Summary, error) {
+	if err := ValidateFormat(format); err != nil {
+		return Summary{}, err
+	}
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Summary{}, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	blocks, err := createDatasetFile[Block](dir, DatasetBlocks, format)
+	if err != nil {
+		return Summary{}, err
+	}
+	defer blocks.file.Close()
+	transactions, err := createDatasetFile[Transaction](dir, DatasetTransactions, format)
+	if err != nil {
+		return Summary{}, err
+	}
+	defer transactions.file.Close()
+	blockResults, err := createDatasetFile[BlockResults](dir, DatasetBlockResults, format)
+	if err != nil {
+		return Summary{}, err
+	}
+	defer blockResults.file.Close()
+
+	var summary Summary
+	after := start
+	if after > 0 {
+		after--
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+		batch, err := src.GetBlocks(ctx, after, stop, batchSize)
+		if err != nil {
+			return summary, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		first, last := batch[0].ID, batch[len(batch)-1].ID
+
+		txs, err := src.GetBlockTransactions(ctx, first, last)
+		if err != nil {
+			return summary, err
+		}
+		results, err := src.GetBlockResults(ctx, first, last)
+		if err != nil {
+			return summary, err
+		}
+
+		blockRecords := make([]Block, 0, len(batch))
+		var txRecords []Transaction
+		for _, b := range batch {
+			record := Block{Height: int64(b.ID), Hash: b.Hash, Data: b.Data}
+			if !b.Time.IsZero() {
+				record.Time = b.Time.UTC().Format(time.RFC3339Nano)
+			}
+			blockRecords = append(blockRecords, record)
+			for _, t := range txs[b.ID] {
+				txRecords = append(txRecords, Transaction{Hash: t.Hash, Height: int64(b.ID), Data: t.Data})
+			}
+		}
+		resultRecords := make([]BlockResults, 0, len(results))
+		for _, r := range results {
+			resultRecords = append(resultRecords, BlockResults{Height: int64(r.Height), Data: r.Data})
+		}
+
+		if err := blocks.writer.Write(blockRecords); err != nil {
+			return summary, err
+		}
+		if err := transactions.writer.Write(txRecords); err != nil {
+			return summary, err
+		}
+		if err := blockResults.writer.Write(resultRecords); err != nil {
+			return summary, err
+		}
+		summary.Blocks += uint64(len(blockRecords))
+		summary.Transactions += uint64(len(txRecords))
+		summary.BlockResults += uint64(len(resultRecords))
+		slog.Info("Exported blocks", "from", first, "to", last, "blocks", summary.Blocks, "transactions", summary.Transactions)
+
+		after = last
+		if stop > 0 && after >= stop {
+			break
+		}
+	}
+
+	for _, closeFile := range []func() error{blocks.close, transactions.close, blockResults.close} {
+		if err := closeFile(); err != nil {
+			return summary, fmt.Errorf("failed to close file: %w", err)
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,190 @@
+package archive
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// gzipSuffix is the suffix of the compressed JSON Lines and CSV files, e.g., blocks.jsonl.gz.
+const gzipSuffix = ".gz"
+
+// Reader reads the records of a dataset from a file.
+type Reader[T Record] struct {
+	jsonl   *json.Decoder
+	csv     *csv.Reader
+	parquet *parquet.GenericReader[T]
+	closers []io.Closer
+}
+
+// NewReader returns a reader of records of type T in the given format. The Parquet files are read from an
+// io.ReaderAt of the given size.
+func NewReader[T Record](r io.Reader, size int64, format string) (*Reader[T], error) {
+	switch format {
+	case FormatJSONL:
+		return &Reader[T]{jsonl: json.NewDecoder(r)}, nil
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.ReuseRecord = true
+		if _, err := cr.Read(); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		}
+		return &Reader[T]{csv: cr}, nil
+	case FormatParquet:
+		ra, ok := r.(io.ReaderAt)
+		if !ok {
+			return nil, fmt.Errorf("parquet files must be read from an io.ReaderAt")
+		}
+		f, err := parquet.OpenFile(ra, size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open parquet file: %w", err)
+		}
+		return &Reader[T]{parquet: parquet.NewGenericReader[T](f)}, nil
+	default:
+		return nil, ValidateFormat(format)
+	}
+}
+
+// Read returns the next record, or io.EOF when all the records were read.
+func (r *Reader[T]) Read() (T, error) {
+	var record T
+	switch {
+	case r.jsonl != nil:
+		if err := r.jsonl.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				return record, io.EOF
+			}
+			return record, fmt.Errorf("failed to read record: %w", err)
+		}
+	case r.csv != nil:
+		fields, err := r.csv.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return record, io.EOF
+			}
+			return record, fmt.Errorf("failed to read record: %w", err)
+		}
+		return parseCSVRecord[T](fields)
+	default:
+		rows := make([]T, 1)
+		n, err := r.parquet.Read(rows)
+		if n == 1 {
+			return rows[0], nil
+		}
+		if err == nil || errors.Is(err, io.EOF) {
+			return record, io.EOF
+		}
+		return record, fmt.Errorf("failed to read record: %w", err)
+	}
+	return record, nil
+}
+
+// Close closes the reader and the file it was opened from, if any.
+func (r *Reader[T]) Close() error {
+	var errs []error
+	if r.parquet != nil {
+		errs = append(errs, r.parquet.Close())
+	}
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		errs = append(errs, r.closers[i].Close())
+	}
+	return errors.Join(errs...)
+}
+
+// parseCSVRecord parses the fields of a CSV record of type T.
+func parseCSVRecord[T Record](fields []string) (T, error) {
+	var record T
+	parseHeight := func(s string) (int64, error) {
+		height, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid height %q", s)
+		}
+		return height, nil
+	}
+
+	var err error
+	switch r := any(&record).(type) {
+	case *Block:
+		if len(fields) != 4 {
+			return record, fmt.Errorf("invalid block record, expected 4 fields, got %d", len(fields))
+		}
+		r.Height, err = parseHeight(fields[0])
+		r.Hash, r.Time, r.Data = fields[1], fields[2], json.RawMessage(fields[3])
+	case *Transaction:
+		if len(fields) != 3 {
+			return record, fmt.Errorf("invalid transaction record, expected 3 fields, got %d", len(fields))
+		}
+		r.Hash = fields[0]
+		r.Height, err = parseHeight(fields[1])
+		r.Data = json.RawMessage(fields[2])
+	case *BlockResults:
+		if len(fields) != 2 {
+			return record, fmt.Errorf("invalid block results record, expected 2 fields, got %d", len(fields))
+		}
+		r.Height, err = parseHeight(fields[0])
+		r.Data = json.RawMessage(fields[1])
+	}
+	return record, err
+}
+
+// OpenDataset opens the file of a dataset in dir, in the format of the first file found among the formats, and
+// compressed with gzip if its name ends with .gz. It returns nil if the dataset has no file.
+func OpenDataset[T Record](dir, dataset string) (*Reader[T], error) {
+	for _, format := range Formats {
+		for _, suffix := range []string{"", gzipSuffix} {
+			if format == FormatParquet && suffix != "" {
+				continue
+			}
+			path := filepath.Join(dir, FileName(dataset, format)+suffix)
+			f, err := os.Open(path)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to open %s: %w", path, err)
+			}
+			return newFileReader[T](f, format, suffix == gzipSuffix)
+		}
+	}
+	return nil, nil
+}
+
+func newFileReader[T Record](f *os.File, format string, compressed bool) (*Reader[T], error) {
+	closers := []io.Closer{f}
+	var r io.Reader = f
+	var size int64
+	if compressed {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to open %s: %w", f.Name(), err)
+		}
+		closers = append(closers, gz)
+		r = gz
+	} else {
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to open %s: %w", f.Name(), err)
+		}
+		size = info.Size()
+	}
+
+	reader, err := NewReader[T](r, size, format)
+	if err != nil {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i].Close()
+		}
+		return nil, fmt.Errorf("failed to open %s: %w", f.Name(), err)
+	}
+	reader.closers = closers
+	return reader, nil
+}
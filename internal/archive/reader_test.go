@@ -0,0 +1,87 @@
+package archive_test
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/archive"
+)
+
+func readAll[T archive.Record](t *testing.T, dir, dataset string) []T {
+	reader, err := archive.OpenDataset[T](dir, dataset)
+	require.NoError(t, err)
+	require.NotNil(t, reader)
+	defer reader.Close()
+
+	var records []T
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+		require.NoError(t, err)
+		records = append(records, record)
+	}
+}
+
+func TestReaderRoundTrip(t *testing.T) {
+	for _, format := range archive.Formats {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			_, err := archive.Export(context.Background(), newFakeSource(), dir, format, 0, 0, 2)
+			require.NoError(t, err)
+
+			blocks := readAll[archive.Block](t, dir, archive.DatasetBlocks)
+			require.Len(t, blocks, 3)
+			assert.Equal(t, int64(1), blocks[0].Height)
+			assert.Equal(t, "HASH1", blocks[0].Hash)
+			assert.Equal(t, "2026-01-01T00:00:00Z", blocks[0].Time)
+			assert.JSONEq(t, `{"block":{"header":{"height":"1"}}}`, string(blocks[0].Data))
+
+			txs := readAll[archive.Transaction](t, dir, archive.DatasetTransactions)
+			require.Len(t, txs, 2)
+			assert.Equal(t, "aa", txs[0].Hash)
+			assert.Equal(t, int64(2), txs[0].Height)
+			assert.JSONEq(t, `{"tx":{"body":{"memo":"a, \"quoted\" memo"}}}`, string(txs[0].Data))
+
+			results := readAll[archive.BlockResults](t, dir, archive.DatasetBlockResults)
+			require.Len(t, results, 1)
+			assert.Equal(t, int64(2), results[0].Height)
+		})
+	}
+}
+
+func TestOpenDatasetGzip(t *testing.T) {
+	dir := t.TempDir()
+	_, err := archive.Export(context.Background(), newFakeSource(), dir, archive.FormatJSONL, 0, 0, 2)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, archive.FileName(archive.DatasetBlocks, archive.FormatJSONL))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	f, err := os.Create(path + ".gz")
+	require.NoError(t, err)
+	w := gzip.NewWriter(f)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+	require.NoError(t, os.Remove(path))
+
+	blocks := readAll[archive.Block](t, dir, archive.DatasetBlocks)
+	assert.Len(t, blocks, 3)
+}
+
+func TestOpenDatasetMissing(t *testing.T) {
+	reader, err := archive.OpenDataset[archive.Block](t.TempDir(), archive.DatasetBlocks)
+	require.NoError(t, err)
+	assert.Nil(t, reader)
+}
@@ -0,0 +1,262 @@
+// Package benchmark measures the latency and the throughput of the endpoints of a node at several concurrency levels,
+// and recommends the extraction settings for the node from the measures.
+package benchmark
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// The benchmarked endpoints.
+const (
+	EndpointBlock        = "block"
+	EndpointTx           = "tx"
+	EndpointBlockResults = "block_results"
+)
+
+const (
+	// maxErrorRate is the highest error rate of a concurrency level to be recommended.
+	maxErrorRate = 0.01
+	// throughputRatio is the share of the best throughput the recommended concurrency level must reach, so that the
+	// lowest level near the plateau is recommended rather than the level loading the node the most.
+	throughputRatio = 0.9
+	// defaultMaxRetries is the default of the --max-retries flag of the extraction.
+	defaultMaxRetries = 3
+)
+
+// Call is a request to an endpoint, the i-th of its concurrency level.
+type Call func(ctx context.Context, i int) error
+
+// Result is the measure of the requests to an endpoint at a concurrency level. The latencies are those of the
+// successful requests, and Throughput is in successful requests per second.
+type Result struct {
+	Endpoint    string        `json:"endpoint"`
+	Concurrency uint          `json:"concurrency"`
+	Requests    int           `json:"requests"`
+	Errors      int           `json:"errors"`
+	Mean        time.Duration `json:"-"`
+	P50         time.Duration `json:"-"`
+	P95         time.Duration `json:"-"`
+	P99         time.Duration `json:"-"`
+	Throughput  float64       `json:"throughput"`
+}
+
+// MarshalJSON encodes the latencies of the result in milliseconds.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+	milliseconds := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	return json.Marshal(struct {
+		result
+		MeanMs float64 `json:"mean_ms"`
+		P50Ms  float64 `json:"p50_ms"`
+		P95Ms  float64 `json:"p95_ms"`
+		P99Ms  float64 `json:"p99_ms"`
+	}{
+		result: result(r),
+		MeanMs: milliseconds(r.Mean),
+		P50Ms:  milliseconds(r.P50),
+		P95Ms:  milliseconds(r.P95),
+		P99Ms:  milliseconds(r.P99),
+	})
+}
+
+// ErrorRate returns the share of the requests that failed.
+func (r Result) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(r.Requests)
+}
+
+// Run sends the requests to the endpoint with concurrency workers, and measures them. The requests not sent before
+// the context is cancelled are not counted.
+func Run(ctx context.Context, endpoint string, concurrency uint, requests int, call Call) Result {
+	result := Result{Endpoint: endpoint, Concurrency: concurrency}
+
+	jobs := make(chan int)
+	var mu sync.Mutex
+	var latencies []time.Duration
+	var wg sync.WaitGroup
+	start := time.Now()
+	for range max(concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				callStart := time.Now()
+				err := call(ctx, i)
+				latency := time.Since(callStart)
+
+				mu.Lock()
+				result.Requests++
+				if err != nil {
+					result.Errors++
+				} else {
+					latencies = append(latencies, latency)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+dispatch:
+	for i := range requests {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	if len(latencies) == 0 {
+		return result
+	}
+	slices.Sort(latencies)
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+	result.Mean = total / time.Duration(len(latencies))
+	result.P50 = percentile(latencies, 50)
+	result.P95 = percentile(latencies, 95)
+	result.P99 = percentile(latencies, 99)
+	if elapsed > 0 {
+		result.Throughput = float64(len(latencies)) / elapsed.Seconds()
+	}
+	return result
+}
+
+// percentile returns the nearest-rank percentile p of the sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// Recommendation is the extraction settings recommended for the node.
+type Recommendation struct {
+	MaxConcurrency uint   `json:"max_concurrency"`
+	MaxRetries     uint   `json:"max_retries"`
+	Reason         string `json:"reason"`
+}
+
+// Recommend returns the settings for the measured node. The concurrency is the lowest level of the block endpoint,
+// which bounds the extraction, reaching 90% of its best throughput among the levels with less than 1% of errors; the
+// retries are raised above the default with the error rate of the endpoints at that level.
+func Recommend(results []Result) Recommendation {
+	var blocks []Result
+	for _, r := range results {
+		if r.Endpoint == EndpointBlock {
+			blocks = append(blocks, r)
+		}
+	}
+	if len(blocks) == 0 {
+		return Recommendation{MaxRetries: defaultMaxRetries, Reason: "no block request was measured"}
+	}
+	slices.SortFunc(blocks, func(a, b Result) int {
+		return int(a.Concurrency) - int(b.Concurrency)
+	})
+
+	var best float64
+	for _, r := range blocks {
+		if r.ErrorRate() < maxErrorRate {
+			best = max(best, r.Throughput)
+		}
+	}
+	rec := Recommendation{MaxRetries: defaultMaxRetries}
+	if best == 0 {
+		rec.MaxConcurrency = blocks[0].Concurrency
+		rec.Reason = fmt.Sprintf("every concurrency level failed at least %.0f%% of the block requests", maxErrorRate*100)
+	} else {
+		for _, r := range blocks {
+			if r.ErrorRate() < maxErrorRate && r.Throughput >= throughputRatio*best {
+				rec.MaxConcurrency = r.Concurrency
+				rec.Reason = fmt.Sprintf("%.1f blocks/s, %.0f%% of the best throughput of %.1f blocks/s", r.Throughput, r.Throughput/best*100, best)
+				break
+			}
+		}
+	}
+
+	var errorRate float64
+	for _, r := range results {
+		if r.Concurrency == rec.MaxConcurrency {
+			errorRate = max(errorRate, r.ErrorRate())
+		}
+	}
+	switch {
+	case errorRate >= 0.05:
+		rec.MaxRetries = 10
+	case errorRate > 0:
+		rec.MaxRetries = 5
+	}
+	return rec
+}
+
+// Report is the measures of a benchmark and the settings recommended from them.
+type Report struct {
+	Results        []Result       `json:"results"`
+	Recommendation Recommendation `json:"recommendation"`
+}
+
+// NewReport returns the report of the results.
+func NewReport(results []Result) Report {
+	return Report{Results: results, Recommendation: Recommend(results)}
+}
+
+// Print writes the report in human-readable form.
+func (r Report) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENDPOINT\tCONCURRENCY\tREQUESTS\tERRORS\tMEAN\tP50\tP95\tP99\tTHROUGHPUT")
+	for _, res := range r.Results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\t%s\t%s\t%s\t%.1f/s\n", res.Endpoint, res.Concurrency, res.Requests, res.Errors,
+			res.Mean.Round(time.Millisecond), res.P50.Round(time.Millisecond), res.P95.Round(time.Millisecond), res.P99.Round(time.Millisecond), res.Throughput)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	rec := r.Recommendation
+	if rec.MaxConcurrency == 0 {
+		_, err := fmt.Fprintf(w, "\nNo recommendation: %s\n", rec.Reason)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\nRecommended: --max-concurrency %d --max-retries %d (%s)\n", rec.MaxConcurrency, rec.MaxRetries, rec.Reason)
+	return err
+}
+
+// BlockTxHashes returns the hashes of the transactions of a block in the JSON of a GetBlockWithTxs response, to
+// request them from the transaction endpoint.
+func BlockTxHashes(data []byte) ([]string, error) {
+	var block struct {
+		Block struct {
+			Data struct {
+				Txs []string `json:"txs"`
+			} `json:"data"`
+		} `json:"block"`
+	}
+	if err := json.Unmarshal(data, &block); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal block: %w", err)
+	}
+
+	hashes := make([]string, 0, len(block.Block.Data.Txs))
+	for _, tx := range block.Block.Data.Txs {
+		raw, err := base64.StdEncoding.DecodeString(tx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode tx: %w", err)
+		}
+		hash := sha256.Sum256(raw)
+		hashes = append(hashes, hex.EncodeToString(hash[:]))
+	}
+	return hashes, nil
+}
@@ -0,0 +1,147 @@
+package benchmark_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/benchmark"
+)
+
+func TestRun(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	result := benchmark.Run(context.Background(), benchmark.EndpointBlock, 4, 20, func(_ context.Context, i int) error {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		if i%10 == 0 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+
+	assert.Equal(t, benchmark.EndpointBlock, result.Endpoint)
+	assert.Equal(t, uint(4), result.Concurrency)
+	assert.Equal(t, 20, result.Requests)
+	assert.Equal(t, 2, result.Errors)
+	assert.InDelta(t, 0.1, result.ErrorRate(), 1e-9)
+	assert.LessOrEqual(t, peak.Load(), int32(4))
+	assert.GreaterOrEqual(t, result.P50, 5*time.Millisecond)
+	assert.LessOrEqual(t, result.P50, result.P95)
+	assert.LessOrEqual(t, result.P95, result.P99)
+	assert.Positive(t, result.Throughput)
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result := benchmark.Run(ctx, benchmark.EndpointTx, 2, 100, func(context.Context, int) error { return nil })
+	assert.Less(t, result.Requests, 100)
+}
+
+func TestRecommend(t *testing.T) {
+	block := func(concurrency uint, errs int, throughput float64) benchmark.Result {
+		return benchmark.Result{Endpoint: benchmark.EndpointBlock, Concurrency: concurrency, Requests: 100, Errors: errs, Throughput: throughput}
+	}
+
+	tests := []struct {
+		name        string
+		results     []benchmark.Result
+		concurrency uint
+		retries     uint
+	}{
+		{
+			name:        "plateau",
+			results:     []benchmark.Result{block(1, 0, 10), block(10, 0, 80), block(25, 0, 95), block(50, 0, 100)},
+			concurrency: 25,
+			retries:     3,
+		},
+		{
+			name:        "errors at high concurrency",
+			results:     []benchmark.Result{block(1, 0, 10), block(10, 0, 80), block(50, 20, 150)},
+			concurrency: 10,
+			retries:     3,
+		},
+		{
+			name: "errors of another endpoint",
+			results: []benchmark.Result{
+				block(10, 0, 80),
+				{Endpoint: benchmark.EndpointTx, Concurrency: 10, Requests: 100, Errors: 2, Throughput: 300},
+			},
+			concurrency: 10,
+			retries:     5,
+		},
+		{
+			name:        "errors at every level",
+			results:     []benchmark.Result{block(5, 10, 40), block(1, 5, 10)},
+			concurrency: 1,
+			retries:     10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := benchmark.Recommend(tt.results)
+			assert.Equal(t, tt.concurrency, rec.MaxConcurrency)
+			assert.Equal(t, tt.retries, rec.MaxRetries)
+			assert.NotEmpty(t, rec.Reason)
+		})
+	}
+
+	assert.Zero(t, benchmark.Recommend(nil).MaxConcurrency)
+}
+
+func TestReport(t *testing.T) {
+	r := benchmark.NewReport([]benchmark.Result{
+		{Endpoint: benchmark.EndpointBlock, Concurrency: 10, Requests: 100, Mean: 12 * time.Millisecond, P50: 10 * time.Millisecond, P95: 30 * time.Millisecond, P99: 45 * time.Millisecond, Throughput: 80},
+	})
+
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"results": [{
+			"endpoint": "block",
+			"concurrency": 10,
+			"requests": 100,
+			"errors": 0,
+			"throughput": 80,
+			"mean_ms": 12,
+			"p50_ms": 10,
+			"p95_ms": 30,
+			"p99_ms": 45
+		}],
+		"recommendation": {"max_concurrency": 10, "max_retries": 3, "reason": "80.0 blocks/s, 100% of the best throughput of 80.0 blocks/s"}
+	}`, string(data))
+
+	var out bytes.Buffer
+	require.NoError(t, r.Print(&out))
+	assert.Contains(t, out.String(), "Recommended: --max-concurrency 10 --max-retries 3")
+	assert.Regexp(t, `block\s+10\s+100\s+0\s+12ms\s+10ms\s+30ms\s+45ms\s+80.0/s`, out.String())
+}
+
+func TestBlockTxHashes(t *testing.T) {
+	hashes, err := benchmark.BlockTxHashes([]byte(`{"block":{"data":{"txs":["dHgx"]}}}`))
+	require.NoError(t, err)
+	// SHA-256 of "tx1"
+	assert.Equal(t, []string{"709b55bd3da0f5a838125bd0ee20c5bfdd7caba173912d4281cae816b79a201b"}, hashes)
+
+	hashes, err = benchmark.BlockTxHashes([]byte(`{"block":{"data":{}}}`))
+	require.NoError(t, err)
+	assert.Empty(t, hashes)
+
+	_, err = benchmark.BlockTxHashes([]byte(`{"block":{"data":{"txs":["!"]}}}`))
+	assert.Error(t, err)
+}
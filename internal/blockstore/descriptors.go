@@ -0,0 +1,192 @@
+package blockstore
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb" // Registers google/protobuf/timestamp.proto
+)
+
+// The CometBFT messages stored in the block store and in the transaction index are declared without depending on the
+// CometBFT protos. The evidences and the part proofs are declared as bytes, which is wire compatible with embedded
+// messages, since they are not needed to rebuild the blocks.
+const typesPackage = "tendermint.types"
+
+// messages holds the descriptors of the stored CometBFT messages.
+type messages struct {
+	blockStoreState protoreflect.MessageDescriptor
+	blockMeta       protoreflect.MessageDescriptor
+	part            protoreflect.MessageDescriptor
+	blockID         protoreflect.MessageDescriptor
+	block           protoreflect.MessageDescriptor
+	txResult        protoreflect.MessageDescriptor
+}
+
+func field(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Type:   typ.Enum(),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+	return f
+}
+
+func repeated(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+	f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	return f
+}
+
+func message(name string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	return &descriptorpb.DescriptorProto{Name: proto.String(name), Field: fields}
+}
+
+// buildMessages builds the descriptors of the stored CometBFT messages.
+func buildMessages() (*messages, error) {
+	const (
+		typeBytes   = descriptorpb.FieldDescriptorProto_TYPE_BYTES
+		typeInt64   = descriptorpb.FieldDescriptorProto_TYPE_INT64
+		typeInt32   = descriptorpb.FieldDescriptorProto_TYPE_INT32
+		typeUint32  = descriptorpb.FieldDescriptorProto_TYPE_UINT32
+		typeUint64  = descriptorpb.FieldDescriptorProto_TYPE_UINT64
+		typeString  = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		typeBool    = descriptorpb.FieldDescriptorProto_TYPE_BOOL
+		typeEnum    = descriptorpb.FieldDescriptorProto_TYPE_ENUM
+		typeMessage = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+	ref := func(name string) string { return "." + typesPackage + "." + name }
+	const timestamp = ".google.protobuf.Timestamp"
+
+	blockIDFlags := []string{"BLOCK_ID_FLAG_UNKNOWN", "BLOCK_ID_FLAG_ABSENT", "BLOCK_ID_FLAG_COMMIT", "BLOCK_ID_FLAG_NIL"}
+	blockIDFlag := &descriptorpb.EnumDescriptorProto{Name: proto.String("BlockIDFlag")}
+	for i, name := range blockIDFlags {
+		blockIDFlag.Value = append(blockIDFlag.Value, &descriptorpb.EnumValueDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(int32(i)),
+		})
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("yaci/blockstore/types.proto"),
+		Package:    proto.String(typesPackage),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		EnumType:   []*descriptorpb.EnumDescriptorProto{blockIDFlag},
+		MessageType: []*descriptorpb.DescriptorProto{
+			message("BlockStoreState",
+				field("base", 1, typeInt64, ""),
+				field("height", 2, typeInt64, ""),
+			),
+			message("BlockMeta",
+				field("block_id", 1, typeMessage, ref("BlockID")),
+				field("block_size", 2, typeInt64, ""),
+				field("header", 3, typeMessage, ref("Header")),
+				field("num_txs", 4, typeInt64, ""),
+			),
+			message("Part",
+				field("index", 1, typeUint32, ""),
+				field("bytes", 2, typeBytes, ""),
+				field("proof", 3, typeBytes, ""),
+			),
+			message("PartSetHeader",
+				field("total", 1, typeUint32, ""),
+				field("hash", 2, typeBytes, ""),
+			),
+			message("BlockID",
+				field("hash", 1, typeBytes, ""),
+				field("part_set_header", 2, typeMessage, ref("PartSetHeader")),
+			),
+			message("Consensus",
+				field("block", 1, typeUint64, ""),
+				field("app", 2, typeUint64, ""),
+			),
+			message("Header",
+				field("version", 1, typeMessage, ref("Consensus")),
+				field("chain_id", 2, typeString, ""),
+				field("height", 3, typeInt64, ""),
+				field("time", 4, typeMessage, timestamp),
+				field("last_block_id", 5, typeMessage, ref("BlockID")),
+				field("last_commit_hash", 6, typeBytes, ""),
+				field("data_hash", 7, typeBytes, ""),
+				field("validators_hash", 8, typeBytes, ""),
+				field("next_validators_hash", 9, typeBytes, ""),
+				field("consensus_hash", 10, typeBytes, ""),
+				field("app_hash", 11, typeBytes, ""),
+				field("last_results_hash", 12, typeBytes, ""),
+				field("evidence_hash", 13, typeBytes, ""),
+				field("proposer_address", 14, typeBytes, ""),
+			),
+			message("Data",
+				repeated(field("txs", 1, typeBytes, "")),
+			),
+			message("EvidenceList",
+				repeated(field("evidence", 1, typeBytes, "")),
+			),
+			message("CommitSig",
+				field("block_id_flag", 1, typeEnum, ref("BlockIDFlag")),
+				field("validator_address", 2, typeBytes, ""),
+				field("timestamp", 3, typeMessage, timestamp),
+				field("signature", 4, typeBytes, ""),
+			),
+			message("Commit",
+				field("height", 1, typeInt64, ""),
+				field("round", 2, typeInt32, ""),
+				field("block_id", 3, typeMessage, ref("BlockID")),
+				repeated(field("signatures", 4, typeMessage, ref("CommitSig"))),
+			),
+			message("Block",
+				field("header", 1, typeMessage, ref("Header")),
+				field("data", 2, typeMessage, ref("Data")),
+				field("evidence", 3, typeMessage, ref("EvidenceList")),
+				field("last_commit", 4, typeMessage, ref("Commit")),
+			),
+			message("EventAttribute",
+				field("key", 1, typeString, ""),
+				field("value", 2, typeString, ""),
+				field("index", 3, typeBool, ""),
+			),
+			message("Event",
+				field("type", 1, typeString, ""),
+				repeated(field("attributes", 2, typeMessage, ref("EventAttribute"))),
+			),
+			message("ExecTxResult",
+				field("code", 1, typeUint32, ""),
+				field("data", 2, typeBytes, ""),
+				field("log", 3, typeString, ""),
+				field("info", 4, typeString, ""),
+				field("gas_wanted", 5, typeInt64, ""),
+				field("gas_used", 6, typeInt64, ""),
+				repeated(field("events", 7, typeMessage, ref("Event"))),
+				field("codespace", 8, typeString, ""),
+			),
+			message("TxResult",
+				field("height", 1, typeInt64, ""),
+				field("index", 2, typeUint32, ""),
+				field("tx", 3, typeBytes, ""),
+				field("result", 4, typeMessage, ref("ExecTxResult")),
+			),
+		},
+	}
+
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build block store descriptors: %w", err)
+	}
+
+	msgs := fd.Messages()
+	return &messages{
+		blockStoreState: msgs.ByName("BlockStoreState"),
+		blockMeta:       msgs.ByName("BlockMeta"),
+		part:            msgs.ByName("Part"),
+		blockID:         msgs.ByName("BlockID"),
+		block:           msgs.ByName("Block"),
+		txResult:        msgs.ByName("TxResult"),
+	}, nil
+}
@@ -0,0 +1,190 @@
+// Package blockstore reads the blocks and the transaction results directly from the data directory of a stopped
+// CometBFT node, for offline extractions where no endpoint of the node is exposed.
+package blockstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	blockStoreDB = "blockstore.db"
+	txIndexDB    = "tx_index.db"
+)
+
+// blockStoreStateKey is the key of the base and height of the block store.
+var blockStoreStateKey = []byte("blockStore")
+
+// Store reads the block store and, when present, the transaction index of a CometBFT data directory.
+// Only the default goleveldb backend and the default key layout of the block store are supported.
+type Store struct {
+	blocks  *leveldb.DB
+	txIndex *leveldb.DB
+	msgs    *messages
+}
+
+// Open opens the block store and the transaction index of the data directory in read-only mode.
+// The node must be stopped, since the databases are locked while it runs.
+func Open(dataDir string) (*Store, error) {
+	msgs, err := buildMessages()
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := openReadOnly(filepath.Join(dataDir, blockStoreDB))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{blocks: blocks, msgs: msgs}
+	txIndexPath := filepath.Join(dataDir, txIndexDB)
+	if _, err := os.Stat(txIndexPath); err == nil {
+		if s.txIndex, err = openReadOnly(txIndexPath); err != nil {
+			blocks.Close()
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func openReadOnly(path string) (*leveldb.DB, error) {
+	db, err := leveldb.OpenFile(path, &opt.Options{ReadOnly: true, ErrorIfMissing: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// Close closes the databases.
+func (s *Store) Close() error {
+	err := s.blocks.Close()
+	if s.txIndex != nil {
+		err = errors.Join(err, s.txIndex.Close())
+	}
+	return err
+}
+
+// HasTxIndex returns true if the data directory has a transaction index.
+func (s *Store) HasTxIndex() bool {
+	return s.txIndex != nil
+}
+
+// Range returns the lowest and the highest heights of the blocks available in the block store.
+func (s *Store) Range() (uint64, uint64, error) {
+	state, err := s.get(s.blocks, blockStoreStateKey, s.msgs.blockStoreState)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read the block store state: %w", err)
+	}
+	if state == nil {
+		return 0, 0, fmt.Errorf("the block store is empty")
+	}
+
+	fields := state.Descriptor().Fields()
+	base := state.Get(fields.ByName("base")).Int()
+	height := state.Get(fields.ByName("height")).Int()
+	if base == 0 && height > 0 {
+		base = 1
+	}
+	return uint64(base), uint64(height), nil
+}
+
+// Block returns the block at the given height as JSON, in the shape of the GetBlockByHeight responses of the
+// cosmos.base.tendermint.v1beta1.Service, and its raw transactions.
+func (s *Store) Block(height uint64) ([]byte, [][]byte, error) {
+	meta, err := s.get(s.blocks, []byte(fmt.Sprintf("H:%d", height)), s.msgs.blockMeta)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the metadata of block %d: %w", height, err)
+	}
+	if meta == nil {
+		return nil, nil, fmt.Errorf("block %d is not in the block store", height)
+	}
+
+	blockID := meta.Get(meta.Descriptor().Fields().ByName("block_id")).Message()
+	partSetHeader := blockID.Get(s.msgs.blockID.Fields().ByName("part_set_header")).Message()
+	total := partSetHeader.Get(partSetHeader.Descriptor().Fields().ByName("total")).Uint()
+
+	var raw bytes.Buffer
+	for i := uint64(0); i < total; i++ {
+		part, err := s.get(s.blocks, []byte(fmt.Sprintf("P:%d:%d", height, i)), s.msgs.part)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read part %d of block %d: %w", i, height, err)
+		}
+		if part == nil {
+			return nil, nil, fmt.Errorf("part %d of block %d is not in the block store", i, height)
+		}
+		raw.Write(part.Get(s.msgs.part.Fields().ByName("bytes")).Bytes())
+	}
+
+	block := dynamicpb.NewMessage(s.msgs.block)
+	if err := proto.Unmarshal(raw.Bytes(), block); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode block %d: %w", height, err)
+	}
+
+	blockIDJSON, err := protojson.Marshal(blockID.Interface())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal the ID of block %d: %w", height, err)
+	}
+	blockJSON, err := protojson.Marshal(block)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal block %d: %w", height, err)
+	}
+	data, err := json.Marshal(map[string]json.RawMessage{
+		"blockId": blockIDJSON,
+		"block":   blockJSON,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal block %d: %w", height, err)
+	}
+
+	var txs [][]byte
+	blockData := block.Get(s.msgs.block.Fields().ByName("data")).Message()
+	list := blockData.Get(blockData.Descriptor().Fields().ByName("txs")).List()
+	for i := 0; i < list.Len(); i++ {
+		txs = append(txs, list.Get(i).Bytes())
+	}
+
+	return data, txs, nil
+}
+
+// TxResult returns the indexed result of the transaction with the given hash as JSON, or nil if the data directory
+// has no transaction index or the transaction is not indexed.
+func (s *Store) TxResult(hash []byte) ([]byte, error) {
+	if s.txIndex == nil {
+		return nil, nil
+	}
+
+	result, err := s.get(s.txIndex, hash, s.msgs.txResult)
+	if err != nil || result == nil {
+		return nil, err
+	}
+	return protojson.Marshal(result.Interface())
+}
+
+// get reads and decodes the message stored at key, or returns nil if the key does not exist.
+func (s *Store) get(db *leveldb.DB, key []byte, desc protoreflect.MessageDescriptor) (protoreflect.Message, error) {
+	value, err := db.Get(key, nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	msg := dynamicpb.NewMessage(desc)
+	if err := proto.Unmarshal(value, msg); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", desc.Name(), err)
+	}
+	return msg, nil
+}
@@ -0,0 +1,122 @@
+package blockstore_test
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/manifest-network/yaci/internal/blockstore"
+)
+
+func message(fields ...[]byte) []byte {
+	var b []byte
+	for _, f := range fields {
+		b = append(b, f...)
+	}
+	return b
+}
+
+func bytesField(num protowire.Number, v []byte) []byte {
+	b := protowire.AppendTag(nil, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func varintField(num protowire.Number, v uint64) []byte {
+	b := protowire.AppendTag(nil, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// writeDataDir writes a block store with the blocks 1 to height, each with a single transaction, and a transaction
+// index with the results of the transactions.
+func writeDataDir(t *testing.T, height uint64) string {
+	dir := t.TempDir()
+
+	blocks, err := leveldb.OpenFile(filepath.Join(dir, "blockstore.db"), nil)
+	require.NoError(t, err)
+	txIndex, err := leveldb.OpenFile(filepath.Join(dir, "tx_index.db"), nil)
+	require.NoError(t, err)
+
+	for h := uint64(1); h <= height; h++ {
+		tx := []byte(fmt.Sprintf("tx-%d", h))
+		header := message(
+			bytesField(2, []byte("test-chain")),
+			varintField(3, h),
+			bytesField(4, varintField(1, 1700000000+h)),
+		)
+		block := message(bytesField(1, header), bytesField(2, bytesField(1, tx)))
+		blockID := message(bytesField(1, []byte{byte(h)}), bytesField(2, varintField(1, 2)))
+
+		// The block is split in two parts
+		require.NoError(t, blocks.Put([]byte(fmt.Sprintf("H:%d", h)), bytesField(1, blockID), nil))
+		require.NoError(t, blocks.Put([]byte(fmt.Sprintf("P:%d:0", h)), bytesField(2, block[:3]), nil))
+		require.NoError(t, blocks.Put([]byte(fmt.Sprintf("P:%d:1", h)), message(varintField(1, 1), bytesField(2, block[3:])), nil))
+
+		hash := sha256.Sum256(tx)
+		result := message(varintField(1, 0), bytesField(3, []byte("ok")), varintField(6, 1000))
+		require.NoError(t, txIndex.Put(hash[:], message(varintField(1, h), bytesField(3, tx), bytesField(4, result)), nil))
+	}
+	require.NoError(t, blocks.Put([]byte("blockStore"), message(varintField(1, 1), varintField(2, height)), nil))
+
+	require.NoError(t, blocks.Close())
+	require.NoError(t, txIndex.Close())
+	return dir
+}
+
+func TestStore(t *testing.T) {
+	store, err := blockstore.Open(writeDataDir(t, 3))
+	require.NoError(t, err)
+	defer store.Close()
+
+	base, height, err := store.Range()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), base)
+	assert.Equal(t, uint64(3), height)
+	assert.True(t, store.HasTxIndex())
+
+	data, txs, err := store.Block(2)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("tx-2")}, txs)
+
+	var block struct {
+		BlockID struct {
+			Hash string `json:"hash"`
+		} `json:"blockId"`
+		Block struct {
+			Header struct {
+				ChainID string `json:"chainId"`
+				Height  string `json:"height"`
+				Time    string `json:"time"`
+			} `json:"header"`
+		} `json:"block"`
+	}
+	require.NoError(t, json.Unmarshal(data, &block))
+	assert.Equal(t, "Ag==", block.BlockID.Hash)
+	assert.Equal(t, "test-chain", block.Block.Header.ChainID)
+	assert.Equal(t, "2", block.Block.Header.Height)
+	assert.Equal(t, "2023-11-14T22:13:22Z", block.Block.Header.Time)
+
+	hash := sha256.Sum256(txs[0])
+	result, err := store.TxResult(hash[:])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"height":"2","tx":"dHgtMg==","result":{"log":"ok","gasUsed":"1000"}}`, string(result))
+
+	missing := sha256.Sum256([]byte("missing"))
+	result, err = store.TxResult(missing[:])
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	_, _, err = store.Block(4)
+	assert.ErrorContains(t, err, "not in the block store")
+}
+
+func TestOpenMissingBlockstore(t *testing.T) {
+	_, err := blockstore.Open(t.TempDir())
+	assert.Error(t, err)
+}
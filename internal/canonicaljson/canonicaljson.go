@@ -0,0 +1,124 @@
+// Package canonicaljson rewrites JSON documents into a canonical form, so that the documents of equal values are equal
+// byte for byte.
+package canonicaljson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Canonicalize returns the canonical form of a JSON document: the keys of the objects are sorted, the whitespace is
+// removed, the integers are written without exponent nor sign of zero, and the other numbers are written in their
+// shortest form, with an exponent only below 1e-6 or from 1e21, as in JavaScript.
+func Canonicalize(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("failed to decode JSON: unexpected data after the document")
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(data))
+	if err := write(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func write(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		n, err := formatNumber(v)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(n)
+	case string:
+		writeString(buf, v)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := write(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeString(buf, k)
+			buf.WriteByte(':')
+			if err := write(buf, v[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unexpected JSON value of type %T", v)
+	}
+	return nil
+}
+
+// writeString writes a string without escaping the HTML characters, unlike json.Marshal.
+func writeString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	// Encoding a string cannot fail
+	_ = enc.Encode(s)
+	// Remove the newline added by the encoder
+	buf.Truncate(buf.Len() - 1)
+}
+
+// formatNumber formats an integer exactly, whatever its size, and the other numbers as float64.
+func formatNumber(n json.Number) (string, error) {
+	s := n.String()
+	if !strings.ContainsAny(s, ".eE") {
+		i, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return "", fmt.Errorf("invalid number %s", s)
+		}
+		return i.String(), nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid number %s: %w", s, err)
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	if abs := math.Abs(f); abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+
+	// Remove the leading zeros of the exponent, e.g., 1e-07 becomes 1e-7
+	mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	sign, digits := exponent[:1], strings.TrimLeft(exponent[1:], "0")
+	return mantissa + "e" + sign + digits, nil
+}
@@ -0,0 +1,47 @@
+package canonicaljson_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/canonicaljson"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{name: "sorted keys", data: `{"b": 1, "a": {"d": [3, 2], "c": null}}`, expected: `{"a":{"c":null,"d":[3,2]},"b":1}`},
+		{name: "whitespace", data: "\n[ true ,\tfalse ]\n", expected: `[true,false]`},
+		{name: "large integer", data: `123456789012345678901234567890`, expected: `123456789012345678901234567890`},
+		{name: "negative zero", data: `[-0, -0.0, 0e10]`, expected: `[0,0,0]`},
+		{name: "decimal", data: `[1.50, 1.0, 2.5e3, -0.000001]`, expected: `[1.5,1,2500,-0.000001]`},
+		{name: "exponent", data: `[1e21, 1.5E-7, 123e-20]`, expected: `[1e+21,1.5e-7,1.23e-18]`},
+		{name: "strings", data: `{"<html>": "a&b", "unicode": "é\n"}`, expected: `{"<html>":"a&b","unicode":"é\n"}`},
+		{name: "object keys sorted by bytes", data: `{"é": 1, "z": 2, "Z": 3}`, expected: `{"Z":3,"z":2,"é":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := canonicaljson.Canonicalize([]byte(tt.data))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(data))
+
+			// The canonical form is stable
+			again, err := canonicaljson.Canonicalize(data)
+			require.NoError(t, err)
+			assert.Equal(t, data, again)
+		})
+	}
+}
+
+func TestCanonicalizeInvalid(t *testing.T) {
+	for _, data := range []string{``, `{"a":`, `{} {}`, `[1,]`} {
+		_, err := canonicaljson.Canonicalize([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
@@ -0,0 +1,161 @@
+// Package capture records the raw gRPC requests and responses made while processing a block height,
+// so that they can be dumped to disk when the height fails and decode bugs can be reproduced offline.
+package capture
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// MaxPayloadSize is the maximum number of bytes kept for each request or response payload.
+const MaxPayloadSize = 1 << 20
+
+const redacted = "[REDACTED]"
+
+// safeMetadataKeys are the outgoing metadata keys whose values are kept as is. Every other value is redacted
+// as it may carry credentials, e.g., API keys or authorization tokens.
+var safeMetadataKeys = map[string]bool{
+	"x-cosmos-block-height": true,
+}
+
+// Call is a single recorded gRPC call. Payloads are the protobuf wire encoding of the messages.
+type Call struct {
+	Method       string              `json:"method"`
+	Metadata     map[string][]string `json:"metadata,omitempty"`
+	Request      []byte              `json:"request,omitempty"`
+	RequestSize  int                 `json:"request_size"`
+	Response     []byte              `json:"response,omitempty"`
+	ResponseSize int                 `json:"response_size"`
+	Truncated    bool                `json:"truncated,omitempty"`
+	Error        string              `json:"error,omitempty"`
+}
+
+// Recorder records the gRPC calls made for a block height. It is safe for concurrent use.
+type Recorder struct {
+	height uint64
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+func NewRecorder(height uint64) *Recorder {
+	return &Recorder{height: height}
+}
+
+type recorderKey struct{}
+
+// NewContext returns a copy of ctx whose gRPC calls are recorded by r.
+func NewContext(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// FromContext returns the recorder of ctx, or nil if its calls are not recorded.
+func FromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
+
+// Calls returns a copy of the recorded calls.
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// WriteFile dumps the recorded calls as JSON into dir and returns the path of the written file.
+func (r *Recorder) WriteFile(dir string) (string, error) {
+	dump := struct {
+		Height uint64 `json:"height"`
+		Calls  []Call `json:"calls"`
+	}{
+		Height: r.height,
+		Calls:  r.Calls(),
+	}
+
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal captured calls: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create debug capture directory: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("height-%d.json", r.height))
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write debug capture: %w", err)
+	}
+	return path, nil
+}
+
+func (r *Recorder) record(call Call) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+// UnaryClientInterceptor records the unary calls made with a context carrying a recorder.
+// Calls made with any other context are passed through untouched.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		r := FromContext(ctx)
+		if r == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		call := Call{Method: method}
+		if md, ok := metadata.FromOutgoingContext(ctx); ok {
+			call.Metadata = redactMetadata(md)
+		}
+		call.Request, call.RequestSize = marshalPayload(req, &call.Truncated)
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err != nil {
+			call.Error = err.Error()
+		} else {
+			call.Response, call.ResponseSize = marshalPayload(reply, &call.Truncated)
+		}
+
+		r.record(call)
+		return err
+	}
+}
+
+// marshalPayload returns the wire encoding of msg, limited to MaxPayloadSize bytes, and its full size.
+func marshalPayload(msg any, truncated *bool) ([]byte, int) {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return nil, 0
+	}
+
+	data, err := proto.Marshal(m)
+	if err != nil {
+		return nil, 0
+	}
+
+	if len(data) > MaxPayloadSize {
+		*truncated = true
+		return data[:MaxPayloadSize], len(data)
+	}
+	return data, len(data)
+}
+
+func redactMetadata(md metadata.MD) map[string][]string {
+	redactedMD := make(map[string][]string, len(md))
+	for key, values := range md {
+		if safeMetadataKeys[key] {
+			redactedMD[key] = append([]string(nil), values...)
+			continue
+		}
+		redactedMD[key] = []string{redacted}
+	}
+	return redactedMD
+}
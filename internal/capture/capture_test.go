@@ -0,0 +1,96 @@
+package capture_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/manifest-network/yaci/internal/capture"
+)
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	interceptor := capture.UnaryClientInterceptor()
+	respond := func(value string, err error) grpc.UnaryInvoker {
+		return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			reply.(*wrapperspb.StringValue).Value = value
+			return err
+		}
+	}
+
+	t.Run("without recorder", func(t *testing.T) {
+		err := interceptor(context.Background(), "/svc/Method", wrapperspb.String("req"), &wrapperspb.StringValue{}, nil, respond("resp", nil))
+		require.NoError(t, err)
+	})
+
+	t.Run("records calls", func(t *testing.T) {
+		recorder := capture.NewRecorder(42)
+		ctx := capture.NewContext(context.Background(), recorder)
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-cosmos-block-height", "42", "authorization", "Bearer secret")
+
+		err := interceptor(ctx, "/svc/Ok", wrapperspb.String("req"), &wrapperspb.StringValue{}, nil, respond("resp", nil))
+		require.NoError(t, err)
+		err = interceptor(ctx, "/svc/Fail", wrapperspb.String("req"), &wrapperspb.StringValue{}, nil, respond("", errors.New("boom")))
+		require.Error(t, err)
+
+		calls := recorder.Calls()
+		require.Len(t, calls, 2)
+		assert.Equal(t, "/svc/Ok", calls[0].Method)
+		assert.NotEmpty(t, calls[0].Request)
+		assert.NotEmpty(t, calls[0].Response)
+		assert.Equal(t, []string{"42"}, calls[0].Metadata["x-cosmos-block-height"])
+		assert.Equal(t, []string{"[REDACTED]"}, calls[0].Metadata["authorization"])
+		assert.Equal(t, "boom", calls[1].Error)
+		assert.Empty(t, calls[1].Response)
+	})
+
+	t.Run("truncates large payloads", func(t *testing.T) {
+		recorder := capture.NewRecorder(1)
+		ctx := capture.NewContext(context.Background(), recorder)
+
+		large := strings.Repeat("a", capture.MaxPayloadSize+10)
+		err := interceptor(ctx, "/svc/Large", wrapperspb.String("req"), &wrapperspb.StringValue{}, nil, respond(large, nil))
+		require.NoError(t, err)
+
+		calls := recorder.Calls()
+		require.Len(t, calls, 1)
+		assert.True(t, calls[0].Truncated)
+		assert.Len(t, calls[0].Response, capture.MaxPayloadSize)
+		assert.Greater(t, calls[0].ResponseSize, capture.MaxPayloadSize)
+	})
+}
+
+func TestRecorderWriteFile(t *testing.T) {
+	recorder := capture.NewRecorder(7)
+	ctx := capture.NewContext(context.Background(), recorder)
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return nil
+	}
+	require.NoError(t, capture.UnaryClientInterceptor()(ctx, "/svc/Method", wrapperspb.String("req"), &wrapperspb.StringValue{}, nil, invoker))
+
+	dir := filepath.Join(t.TempDir(), "captures")
+	path, err := recorder.WriteFile(dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "height-7.json"), path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var dump struct {
+		Height uint64         `json:"height"`
+		Calls  []capture.Call `json:"calls"`
+	}
+	require.NoError(t, json.Unmarshal(data, &dump))
+	assert.Equal(t, uint64(7), dump.Height)
+	require.Len(t, dump.Calls, 1)
+	assert.Equal(t, "/svc/Method", dump.Calls[0].Method)
+}
@@ -4,15 +4,26 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/manifest-network/yaci/internal/capture"
 	"github.com/manifest-network/yaci/internal/reflection"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
+// blockHeightHeader is the gRPC metadata key used by Cosmos SDK nodes to answer queries at a past height.
+const blockHeightHeader = "x-cosmos-block-height"
+
 var keepaliveParams = keepalive.ClientParameters{
 	Time:                60 * time.Second,
 	Timeout:             30 * time.Second,
@@ -21,39 +32,342 @@ var keepaliveParams = keepalive.ClientParameters{
 
 type GRPCClient struct {
 	Ctx      context.Context
-	Conn     *grpc.ClientConn
+	Conn     *grpc.ClientConn // Connection to the endpoint the descriptors were fetched from
 	Resolver *reflection.CustomResolver
+	Methods  Methods // Methods exposed by the node, detected from its descriptors
+
+	// Endpoints the calls made with Invoke and by the resolvers are routed to, shared by the copies of the client and
+	// kept when reconnecting
+	endpoints *endpointSet
+
+	// Client of the archive endpoint the requests for the heights below archiveThreshold are routed to, nil if none
+	archive          *GRPCClient
+	archiveThreshold uint64
+
+	// Resolvers of the heights decoded with their own descriptors, e.g., before an upgrade
+	heightResolvers []heightResolver
+
+	// Methods configured to replace the detected ones, kept to be applied again when reconnecting
+	methodOverrides Methods
+
+	// Dial parameters, kept to be able to reconnect
+	addresses          []string
+	insecure           bool
+	maxCallRecvMsgSize int
+	descriptors        *Descriptors
+}
+
+// Descriptors are protocol buffer descriptors supplied offline, merged with the descriptors fetched via server
+// reflection, or used instead of them.
+type Descriptors struct {
+	Files []*descriptorpb.FileDescriptorProto
+	// Only disables server reflection, for the nodes with reflection disabled
+	Only bool
+	// Heights are the descriptors of height ranges, replacing the descriptors of the same files for those heights
+	Heights []*HeightDescriptors
+}
+
+// HeightDescriptors are the descriptors the messages of an inclusive range of heights are decoded with, e.g., the
+// descriptors of the version of the chain before an upgrade.
+type HeightDescriptors struct {
+	Start uint64
+	Stop  uint64
+	Files []*descriptorpb.FileDescriptorProto
 }
 
+// heightResolver is the resolver of an inclusive range of heights.
+type heightResolver struct {
+	start    uint64
+	stop     uint64
+	resolver *reflection.CustomResolver
+}
+
+// NewGRPCClient connects to the gRPC server at address. The address can be a comma-separated list of endpoints
+// serving the same chain, in order of preference: calls fail over to the next healthy endpoint when the active one
+// becomes unreachable, and fail back once a preferred endpoint is healthy again.
 func NewGRPCClient(ctx context.Context, address string, insecure bool, maxCallRecvMsgSize int) (*GRPCClient, error) {
-	slog.Info("Initializing gRPC client pool...")
-	conn := dial(ctx, address, insecure, maxCallRecvMsgSize)
+	return NewGRPCClientWithDescriptors(ctx, address, insecure, maxCallRecvMsgSize, nil)
+}
 
-	slog.Info("Fetching protocol buffer descriptors from gRPC server... This may take a while.")
-	descriptors, err := reflection.FetchAllDescriptors(ctx, conn, 3)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch descriptors: %w", err)
+// NewGRPCClientWithDescriptors connects to the gRPC server at address like NewGRPCClient, and decodes the messages with
+// the supplied descriptors, if not nil. The supplied descriptors replace the descriptors of the same files fetched via
+// server reflection, so that decoding does not depend on the version of the node.
+func NewGRPCClientWithDescriptors(ctx context.Context, address string, insecure bool, maxCallRecvMsgSize int, descriptors *Descriptors) (*GRPCClient, error) {
+	addresses := ParseAddresses(address)
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no gRPC endpoint given")
 	}
 
-	slog.Info("Building protocol buffer descriptor set...")
-	files, err := reflection.BuildFileDescriptorSet(descriptors)
+	slog.Info("Initializing gRPC client pool...", "endpoints", len(addresses))
+	endpoints := &endpointSet{}
+	resolver, heightResolvers, err := connect(ctx, endpoints, addresses, insecure, maxCallRecvMsgSize, descriptors)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build descriptor set: %w", err)
+		return nil, err
 	}
 
-	resolver := reflection.NewCustomResolver(ctx, files, conn, 3)
-
+	_, conn := endpoints.current()
 	return &GRPCClient{
-		Ctx:      ctx,
-		Conn:     conn,
-		Resolver: resolver,
+		Ctx:                ctx,
+		Conn:               conn,
+		Resolver:           resolver,
+		Methods:            DetectMethods(resolver),
+		heightResolvers:    heightResolvers,
+		endpoints:          endpoints,
+		addresses:          addresses,
+		insecure:           insecure,
+		maxCallRecvMsgSize: maxCallRecvMsgSize,
+		descriptors:        descriptors,
 	}, nil
 }
 
-func dial(ctx context.Context, address string, insecure bool, maxCallRecvMsgSize int) *grpc.ClientConn {
+// ParseAddresses splits a comma-separated list of gRPC endpoints, ignoring empty entries.
+func ParseAddresses(address string) []string {
+	var addresses []string
+	for _, a := range strings.Split(address, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			addresses = append(addresses, a)
+		}
+	}
+	return addresses
+}
+
+// Reconnect re-dials the gRPC server and re-resolves the protocol buffer descriptors, in case the server was upgraded.
+// The previous connection is closed once the new one is ready, and the copies of the client route their calls to the
+// new one.
+// Reconnect must not be called while requests using the client are in flight.
+func (c *GRPCClient) Reconnect() error {
+	slog.Info("Reconnecting to gRPC server...", "addresses", c.addresses)
+	resolver, heightResolvers, err := connect(c.Ctx, c.endpoints, c.addresses, c.insecure, c.maxCallRecvMsgSize, c.descriptors)
+	if err != nil {
+		return err
+	}
+
+	_, c.Conn = c.endpoints.current()
+	c.Resolver = resolver
+	c.Methods = DetectMethods(resolver).Override(c.methodOverrides)
+	c.endpoints.setHealthCheckMethod(c.Methods.Status)
+	c.heightResolvers = heightResolvers
+
+	if c.archive != nil {
+		if err := c.archive.Reconnect(); err != nil {
+			return fmt.Errorf("failed to reconnect to the archive endpoint: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close closes the connections to all the endpoints.
+func (c *GRPCClient) Close() error {
+	if c.archive != nil {
+		if err := c.archive.Close(); err != nil {
+			slog.Debug("Failed to close archive gRPC connection", "error", err)
+		}
+	}
+	return c.endpoints.close()
+}
+
+// SetMethods replaces the detected methods of the client, and of its archive client, with the non-empty methods of
+// overrides, including after reconnecting. The health checks of the endpoints probe the Status method.
+func (c *GRPCClient) SetMethods(overrides Methods) {
+	c.methodOverrides = overrides
+	c.Methods = c.Methods.Override(overrides)
+	if c.endpoints != nil {
+		c.endpoints.setHealthCheckMethod(c.Methods.Status)
+	}
+	if c.archive != nil {
+		c.archive.SetMethods(overrides)
+	}
+}
+
+// SetArchive routes the requests for the heights below threshold to the archive client, e.g., when the endpoints of
+// the client are pruned nodes that are faster than the archive node for the recent heights.
+// The methods set on the client are also set on the archive client.
+func (c *GRPCClient) SetArchive(archive *GRPCClient, threshold uint64) {
+	archive.SetMethods(c.methodOverrides)
+	c.archive = archive
+	c.archiveThreshold = threshold
+}
+
+// ForHeight returns a copy of the client routing its calls to the endpoint serving the given height: the archive
+// endpoint for the heights below the archive threshold, and the endpoints of the client otherwise. The messages are
+// decoded with the descriptors supplied for the height, if any.
+func (c *GRPCClient) ForHeight(height uint64) *GRPCClient {
+	routed := c
+	if c.archive != nil && height < c.archiveThreshold {
+		routed = c.archive.WithContext(c.Ctx)
+	}
+
+	for _, r := range routed.heightResolvers {
+		if height >= r.start && height <= r.stop {
+			clone := *routed
+			clone.Resolver = r.resolver
+			return &clone
+		}
+	}
+	return routed
+}
+
+// Invoke performs a unary call on the active endpoint.
+// Calls failing because the endpoint is unreachable count towards failing over to the next healthy endpoint.
+func (c *GRPCClient) Invoke(method string, args, reply any, opts ...grpc.CallOption) error {
+	if c.endpoints == nil {
+		return c.Conn.Invoke(c.Ctx, method, args, reply, opts...)
+	}
+
+	return c.endpoints.Invoke(c.Ctx, method, args, reply, opts...)
+}
+
+// ActiveEndpoint returns the address of the endpoint calls are currently routed to.
+func (c *GRPCClient) ActiveEndpoint() string {
+	return c.endpoints.activeAddress()
+}
+
+// WithContext returns a copy of the client whose calls use the given context.
+func (c *GRPCClient) WithContext(ctx context.Context) *GRPCClient {
+	clone := *c
+	clone.Ctx = ctx
+	return &clone
+}
+
+// AtHeight returns a copy of the client whose queries are answered with the state at the given block height.
+// The node must still have the state of that height, i.e., it must not have been pruned; heights below the archive
+// threshold are routed to the archive endpoint.
+func (c *GRPCClient) AtHeight(height uint64) *GRPCClient {
+	return c.ForHeight(height).WithContext(metadata.AppendToOutgoingContext(c.Ctx, blockHeightHeader, strconv.FormatUint(height, 10)))
+}
+
+// IsConnectionError returns true if the error was caused by the gRPC server being unreachable.
+func IsConnectionError(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
+
+// IsPrunedStateError returns true if the query failed because the node no longer has the state of the requested
+// height, i.e., it was pruned.
+func IsPrunedStateError(err error) bool {
+	msg := status.Convert(err).Message()
+	return strings.Contains(msg, "version does not exist") || strings.Contains(msg, "failed to load state at height")
+}
+
+// lowestHeightPattern matches the error returned by CometBFT for the blocks below the lowest height of its block store.
+var lowestHeightPattern = regexp.MustCompile(`lowest height is (\d+)`)
+
+// LowestAvailableHeight returns the lowest height available on the node if the request failed because the requested
+// block was pruned, and false otherwise.
+func LowestAvailableHeight(err error) (uint64, bool) {
+	if err == nil {
+		return 0, false
+	}
+	match := lowestHeightPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0, false
+	}
+	height, parseErr := strconv.ParseUint(match[1], 10, 64)
+	if parseErr != nil {
+		return 0, false
+	}
+	return height, true
+}
+
+// IsConnected returns false if the connection to the active endpoint is failing or has been shut down.
+func (c *GRPCClient) IsConnected() bool {
+	_, conn := c.endpoints.current()
+	state := conn.GetState()
+	return state != connectivity.TransientFailure && state != connectivity.Shutdown
+}
+
+// connect dials all the endpoints and builds a resolver from the descriptors fetched via server reflection
+// from the first endpoint that answers, which becomes the active endpoint.
+// The supplied descriptors, if any, are merged with the fetched ones, or used alone when reflection is disabled.
+// A resolver is also built for each range of heights with supplied descriptors, which replace the others of the same
+// files.
+// The dialed endpoints replace those of shared once ready, and the resolvers route their calls through shared.
+func connect(ctx context.Context, shared *endpointSet, addresses []string, insecure bool, maxCallRecvMsgSize int, supplied *Descriptors) (*reflection.CustomResolver, []heightResolver, error) {
+	endpoints := &endpointSet{}
+	for _, address := range addresses {
+		conn, err := dial(ctx, address, insecure, maxCallRecvMsgSize)
+		if err != nil {
+			endpoints.close()
+			return nil, nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+		}
+		endpoints.endpoints = append(endpoints.endpoints, &endpoint{address: address, conn: conn, healthy: true})
+	}
+
+	if supplied != nil && supplied.Only {
+		slog.Info("Building protocol buffer descriptor set from the supplied descriptors, without server reflection...")
+		files, err := reflection.BuildFileDescriptorSet(supplied.Files)
+		if err != nil {
+			endpoints.close()
+			return nil, nil, fmt.Errorf("failed to build descriptor set: %w", err)
+		}
+		heightResolvers, err := buildHeightResolvers(ctx, supplied, supplied.Files, nil)
+		if err != nil {
+			endpoints.close()
+			return nil, nil, err
+		}
+		shared.replace(ctx, endpoints)
+		return reflection.NewCustomResolver(ctx, files, nil, 3), heightResolvers, nil
+	}
+
+	var lastErr error
+	for i, e := range endpoints.endpoints {
+		slog.Info("Fetching protocol buffer descriptors from gRPC server... This may take a while.", "address", e.address)
+		descriptors, err := reflection.FetchAllDescriptors(ctx, e.conn, 3)
+		if err != nil {
+			slog.Warn("Failed to fetch descriptors from gRPC endpoint", "address", e.address, "error", err)
+			e.healthy = false
+			lastErr = err
+			continue
+		}
+
+		if supplied != nil {
+			descriptors = reflection.MergeDescriptors(supplied.Files, descriptors)
+		}
+
+		slog.Info("Building protocol buffer descriptor set...")
+		files, err := reflection.BuildFileDescriptorSet(descriptors)
+		if err != nil {
+			endpoints.close()
+			return nil, nil, fmt.Errorf("failed to build descriptor set: %w", err)
+		}
+		heightResolvers, err := buildHeightResolvers(ctx, supplied, descriptors, shared)
+		if err != nil {
+			endpoints.close()
+			return nil, nil, err
+		}
+
+		endpoints.active = i
+		shared.replace(ctx, endpoints)
+		return reflection.NewCustomResolver(ctx, files, shared, 3), heightResolvers, nil
+	}
+
+	endpoints.close()
+	return nil, nil, fmt.Errorf("failed to fetch descriptors: %w", lastErr)
+}
+
+// buildHeightResolvers builds the resolvers of the ranges of heights with supplied descriptors, which replace the
+// base descriptors of the same files.
+func buildHeightResolvers(ctx context.Context, supplied *Descriptors, base []*descriptorpb.FileDescriptorProto, conn grpc.ClientConnInterface) ([]heightResolver, error) {
+	if supplied == nil {
+		return nil, nil
+	}
+
+	resolvers := make([]heightResolver, 0, len(supplied.Heights))
+	for _, h := range supplied.Heights {
+		slog.Info("Building protocol buffer descriptor set of height range...", "start", h.Start, "stop", h.Stop)
+		files, err := reflection.BuildFileDescriptorSet(reflection.MergeDescriptors(h.Files, base))
+		if err != nil {
+			return nil, fmt.Errorf("failed to build descriptor set of heights [%d, %d]: %w", h.Start, h.Stop, err)
+		}
+		resolvers = append(resolvers, heightResolver{start: h.Start, stop: h.Stop, resolver: reflection.NewCustomResolver(ctx, files, conn, 3)})
+	}
+	return resolvers, nil
+}
+
+func dial(ctx context.Context, address string, insecure bool, maxCallRecvMsgSize int) (*grpc.ClientConn, error) {
 	var opts []grpc.DialOption
 	opts = append(opts, grpc.WithKeepaliveParams(keepaliveParams))
 	opts = append(opts, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxCallRecvMsgSize)))
+	opts = append(opts, grpc.WithChainUnaryInterceptor(capture.UnaryClientInterceptor()))
 	if insecure {
 		opts = append(opts, grpc.WithInsecure())
 	} else {
@@ -61,11 +375,5 @@ func dial(ctx context.Context, address string, insecure bool, maxCallRecvMsgSize
 		opts = append(opts, grpc.WithTransportCredentials(creds))
 	}
 
-	conn, err := grpc.DialContext(ctx, address, opts...)
-	if err != nil {
-		slog.Error("Failed to connect", "error", err)
-		os.Exit(1)
-	}
-
-	return conn
+	return grpc.DialContext(ctx, address, opts...)
 }
@@ -0,0 +1,37 @@
+package client_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/manifest-network/yaci/internal/client"
+)
+
+func TestIsConnectionError(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	cases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "nil", err: nil, expected: false},
+		{name: "unavailable", err: unavailable, expected: true},
+		{name: "wrapped unavailable", err: fmt.Errorf("failed to get block data: %w", unavailable), expected: true},
+		{name: "retried unavailable", err: pkgerrors.WithMessage(unavailable, "Failed after 3 retries"), expected: true},
+		{name: "not found", err: status.Error(codes.NotFound, "block not found"), expected: false},
+		{name: "plain error", err: errors.New("boom"), expected: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, client.IsConnectionError(tc.err))
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	healthCheckInterval = 10 * time.Second
+	healthCheckTimeout  = 5 * time.Second
+
+	// maxConsecutiveFailures is the number of consecutive failed calls after which an endpoint is considered unhealthy.
+	maxConsecutiveFailures = 3
+
+	// defaultHealthCheckMethod is a cheap method exposed by Cosmos SDK nodes, probed until the Status method of the
+	// client is set. Any answer from the server, including an error other than a transport error, means that the
+	// endpoint is reachable.
+	defaultHealthCheckMethod = "/cosmos.base.node.v1beta1.Service/Status"
+)
+
+type endpoint struct {
+	address             string
+	conn                *grpc.ClientConn
+	healthy             bool
+	consecutiveFailures int
+}
+
+// endpointSet routes calls to the first healthy endpoint, in the order in which they were given.
+// An endpoint becomes unhealthy after maxConsecutiveFailures failed calls or a failed health check, in which case
+// calls fail over to the next healthy endpoint. Calls fail back to a preferred endpoint once its health check passes.
+// It is a grpc.ClientConnInterface, so that the calls of the resolvers, e.g., the server reflection requests of the
+// symbols missing from the descriptors, fail over as well.
+type endpointSet struct {
+	mu        sync.RWMutex
+	endpoints []*endpoint
+	active    int
+	stop      context.CancelFunc
+	// Path of the method probed by the health checks, the Status method of the node
+	healthCheckMethod string
+}
+
+// setHealthCheckMethod sets the method probed by the health checks from its full name, e.g.,
+// cosmos.base.node.v1beta1.Service.Status. An empty name keeps the current method.
+func (s *endpointSet) setHealthCheckMethod(fullName string) {
+	i := strings.LastIndex(fullName, ".")
+	if i < 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.healthCheckMethod = "/" + fullName[:i] + "/" + fullName[i+1:]
+}
+
+// current returns the index and the connection of the active endpoint.
+func (s *endpointSet) current() (int, *grpc.ClientConn) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.active, s.endpoints[s.active].conn
+}
+
+// activeAddress returns the address of the active endpoint.
+func (s *endpointSet) activeAddress() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.endpoints[s.active].address
+}
+
+// Invoke performs a unary call on the active endpoint.
+// Calls failing because the endpoint is unreachable count towards failing over to the next healthy endpoint.
+func (s *endpointSet) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	index, conn := s.current()
+	err := conn.Invoke(ctx, method, args, reply, opts...)
+	s.report(index, err)
+	return err
+}
+
+// NewStream opens a stream on the active endpoint, e.g., a server reflection stream.
+// Streams failing to open because the endpoint is unreachable count towards failing over to the next healthy endpoint.
+func (s *endpointSet) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	index, conn := s.current()
+	stream, err := conn.NewStream(ctx, desc, method, opts...)
+	s.report(index, err)
+	return stream, err
+}
+
+// report records the outcome of a call made on the endpoint at index.
+func (s *endpointSet) report(index int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// There is no other endpoint to fail over to, or the endpoints were replaced since the call
+	if len(s.endpoints) == 1 || index >= len(s.endpoints) {
+		return
+	}
+
+	e := s.endpoints[index]
+	if !isEndpointFailure(err) {
+		e.consecutiveFailures = 0
+		return
+	}
+
+	e.consecutiveFailures++
+	if e.consecutiveFailures >= maxConsecutiveFailures && e.healthy {
+		slog.Warn("gRPC endpoint is unhealthy", "address", e.address, "consecutive_failures", e.consecutiveFailures, "error", err)
+		e.healthy = false
+		s.selectLocked()
+	}
+}
+
+// selectLocked makes the first healthy endpoint active. The active endpoint is kept if none is healthy.
+func (s *endpointSet) selectLocked() {
+	for i, e := range s.endpoints {
+		if !e.healthy {
+			continue
+		}
+		if i != s.active {
+			slog.Warn("Switching gRPC endpoint", "from", s.endpoints[s.active].address, "to", e.address)
+			s.active = i
+		}
+		return
+	}
+}
+
+// checkHealth probes every endpoint and fails over or back according to the results.
+func (s *endpointSet) checkHealth(ctx context.Context) {
+	s.mu.RLock()
+	method := s.healthCheckMethod
+	endpoints := s.endpoints
+	s.mu.RUnlock()
+	if method == "" {
+		method = defaultHealthCheckMethod
+	}
+
+	results := make([]bool, len(endpoints))
+	var wg sync.WaitGroup
+	for i, e := range endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = probe(ctx, e.conn, method)
+		}()
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ctx.Err() != nil {
+		// The endpoints were replaced during the checks
+		return
+	}
+	for i, e := range s.endpoints {
+		if results[i] != e.healthy {
+			slog.Info("gRPC endpoint health changed", "address", e.address, "healthy", results[i])
+		}
+		e.healthy = results[i]
+		if e.healthy {
+			e.consecutiveFailures = 0
+		}
+	}
+	s.selectLocked()
+}
+
+// run checks the health of the endpoints periodically until ctx is done.
+func (s *endpointSet) run(ctx context.Context) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkHealth(ctx)
+		}
+	}
+}
+
+// startHealthChecks starts checking the health of the endpoints in the background, if there is more than one.
+func (s *endpointSet) startHealthChecks(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.endpoints) < 2 {
+		return
+	}
+	ctx, s.stop = context.WithCancel(ctx)
+	go s.run(ctx)
+}
+
+// replace routes the calls to the endpoints of other, e.g., once reconnected, so that the clients and the resolvers
+// sharing the set use them. The previous connections are closed and their health checks stopped.
+func (s *endpointSet) replace(ctx context.Context, other *endpointSet) {
+	s.mu.Lock()
+	previous := &endpointSet{endpoints: s.endpoints, stop: s.stop}
+	s.endpoints, s.active, s.stop = other.endpoints, other.active, nil
+	s.mu.Unlock()
+
+	if err := previous.close(); err != nil {
+		slog.Debug("Failed to close previous gRPC connection", "error", err)
+	}
+	s.startHealthChecks(ctx)
+}
+
+// close stops the health checks and closes all the connections.
+func (s *endpointSet) close() error {
+	s.mu.RLock()
+	stop, endpoints := s.stop, s.endpoints
+	s.mu.RUnlock()
+	if stop != nil {
+		stop()
+	}
+
+	var firstErr error
+	for _, e := range endpoints {
+		if err := e.conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// probe returns true if the server behind conn answers a call to method.
+func probe(ctx context.Context, conn *grpc.ClientConn, method string) bool {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	err := conn.Invoke(ctx, method, &emptypb.Empty{}, &emptypb.Empty{})
+	return !isEndpointFailure(err)
+}
+
+// isEndpointFailure returns true if the error is caused by the endpoint rather than by the request.
+func isEndpointFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,151 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"github.com/manifest-network/yaci/internal/client"
+)
+
+// startServer starts a gRPC server exposing the health service and server reflection.
+func startServer(t *testing.T) (string, *grpc.Server) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	reflection.Register(s)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	return lis.Addr().String(), s
+}
+
+func TestParseAddresses(t *testing.T) {
+	assert.Equal(t, []string{"a:9090"}, client.ParseAddresses("a:9090"))
+	assert.Equal(t, []string{"a:9090", "b:9090"}, client.ParseAddresses(" a:9090, ,b:9090,"))
+	assert.Empty(t, client.ParseAddresses(""))
+}
+
+func TestFailover(t *testing.T) {
+	primary, primaryServer := startServer(t)
+	secondary, _ := startServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, err := client.NewGRPCClient(ctx, primary+","+secondary, true, 4194304)
+	require.NoError(t, err)
+	defer c.Close()
+	assert.Equal(t, primary, c.ActiveEndpoint())
+
+	check := func() error {
+		return c.Invoke("/grpc.health.v1.Health/Check", &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{})
+	}
+	require.NoError(t, check())
+
+	primaryServer.Stop()
+
+	// Calls fail until the primary endpoint is considered unhealthy
+	for i := 0; i < 3; i++ {
+		assert.Error(t, check())
+	}
+	assert.Equal(t, secondary, c.ActiveEndpoint())
+	assert.NoError(t, check())
+}
+
+func TestResolverFailover(t *testing.T) {
+	primary, primaryServer := startServer(t)
+	secondary, _ := startServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, err := client.NewGRPCClient(ctx, primary+","+secondary, true, 4194304)
+	require.NoError(t, err)
+	defer c.Close()
+
+	primaryServer.Stop()
+	for i := 0; i < 3; i++ {
+		assert.Error(t, c.Invoke("/grpc.health.v1.Health/Check", &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{}))
+	}
+	require.Equal(t, secondary, c.ActiveEndpoint())
+
+	// A message missing from the descriptors of the services is fetched via the server reflection of the endpoint
+	// calls fail over to
+	_, err = c.Resolver.FindMessageByName("google.protobuf.Duration")
+	assert.NoError(t, err)
+}
+
+func TestReconnectSharesEndpoints(t *testing.T) {
+	address, _ := startServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, err := client.NewGRPCClient(ctx, address, true, 4194304)
+	require.NoError(t, err)
+	defer c.Close()
+
+	// The copies of the client route their calls to the connections of the reconnected client
+	clone := c.WithContext(ctx)
+	require.NoError(t, c.Reconnect())
+	assert.NoError(t, clone.Invoke("/grpc.health.v1.Health/Check", &healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{}))
+}
+
+func TestIsPrunedStateError(t *testing.T) {
+	pruned := status.Error(codes.InvalidArgument, "failed to load state at height 10; version does not exist (latest height: 2000)")
+	assert.True(t, client.IsPrunedStateError(pruned))
+	assert.True(t, client.IsPrunedStateError(fmt.Errorf("failed to query: %w", pruned)))
+	assert.False(t, client.IsPrunedStateError(status.Error(codes.Unavailable, "connection refused")))
+	assert.False(t, client.IsPrunedStateError(nil))
+}
+
+func TestForHeight(t *testing.T) {
+	recent, _ := startServer(t)
+	archive, _ := startServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, err := client.NewGRPCClient(ctx, recent, true, 4194304)
+	require.NoError(t, err)
+	defer c.Close()
+
+	// Without archive, all the heights are served by the client
+	assert.Equal(t, recent, c.ForHeight(1).ActiveEndpoint())
+
+	archiveClient, err := client.NewGRPCClient(ctx, archive, true, 4194304)
+	require.NoError(t, err)
+	c.SetArchive(archiveClient, 100)
+
+	assert.Equal(t, archive, c.ForHeight(99).ActiveEndpoint())
+	assert.Equal(t, recent, c.ForHeight(100).ActiveEndpoint())
+	assert.Equal(t, archive, c.AtHeight(1).ActiveEndpoint())
+	assert.Equal(t, recent, c.AtHeight(1000).ActiveEndpoint())
+}
+
+func TestLowestAvailableHeight(t *testing.T) {
+	pruned := status.Error(codes.InvalidArgument, "height 5 is not available, lowest height is 1200")
+	height, ok := client.LowestAvailableHeight(fmt.Errorf("failed to get block data: %w", pruned))
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1200), height)
+
+	_, ok = client.LowestAvailableHeight(status.Error(codes.Unavailable, "connection refused"))
+	assert.False(t, ok)
+	_, ok = client.LowestAvailableHeight(nil)
+	assert.False(t, ok)
+}
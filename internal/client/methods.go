@@ -0,0 +1,105 @@
+package client
+
+import (
+	"log/slog"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Methods are the full names of the gRPC methods the blocks and transactions are extracted with, which differ between
+// the versions and the forks of the Cosmos SDK.
+type Methods struct {
+	Block         string // GetBlockWithTxs
+	Tx            string // GetTx
+	BlockResults  string // GetBlockResults
+	BlockByHeight string // GetBlockByHeight
+	LatestBlock   string // GetLatestBlock
+	Status        string // Status of the node
+	// Field of the GetBlockByHeight and GetLatestBlock responses holding the block, sdk_block or block
+	BlockField string
+}
+
+// DefaultMethods are the methods of the Cosmos SDK, used when no other variant is exposed by the node.
+var DefaultMethods = Methods{
+	Block:         "cosmos.tx.v1beta1.Service.GetBlockWithTxs",
+	Tx:            "cosmos.tx.v1beta1.Service.GetTx",
+	BlockResults:  "cosmos.base.tendermint.v1beta1.Service.GetBlockResults",
+	BlockByHeight: "cosmos.base.tendermint.v1beta1.Service.GetBlockByHeight",
+	LatestBlock:   "cosmos.base.tendermint.v1beta1.Service.GetLatestBlock",
+	Status:        "cosmos.base.node.v1beta1.Service.Status",
+	BlockField:    "sdk_block",
+}
+
+// blockServices are the names of the service of the block queries, in order of preference: the Tendermint name of the
+// Cosmos SDK, and the CometBFT name of the forks that renamed it.
+var blockServices = []string{
+	"cosmos.base.tendermint.v1beta1.Service",
+	"cosmos.base.cometbft.v1beta1.Service",
+}
+
+// MethodFinder finds the descriptors of the gRPC methods, e.g., a reflection.CustomResolver.
+type MethodFinder interface {
+	FindMethodDescriptor(serviceName, methodName string) (protoreflect.MethodDescriptor, error)
+}
+
+// DetectMethods returns the methods exposed by the node, among the known variants of the block service, and the field
+// of their responses holding the block: sdk_block since the Cosmos SDK 0.47, and block before. The default method is
+// kept when the node exposes no variant of it, so that calling it fails with a clear error.
+func DetectMethods(finder MethodFinder) Methods {
+	methods := DefaultMethods
+	methods.BlockByHeight = detectBlockMethod(finder, "GetBlockByHeight", methods.BlockByHeight)
+	methods.LatestBlock = detectBlockMethod(finder, "GetLatestBlock", methods.LatestBlock)
+	methods.BlockResults = detectBlockMethod(finder, "GetBlockResults", methods.BlockResults)
+
+	i := strings.LastIndex(methods.LatestBlock, ".")
+	if md, err := finder.FindMethodDescriptor(methods.LatestBlock[:i], methods.LatestBlock[i+1:]); err == nil {
+		if md.Output().Fields().ByName("sdk_block") == nil && md.Output().Fields().ByName("block") != nil {
+			methods.BlockField = "block"
+		}
+	}
+
+	if methods != DefaultMethods {
+		slog.Info("Detected block service variant", "block_by_height", methods.BlockByHeight, "block_results", methods.BlockResults, "block_field", methods.BlockField)
+	}
+	return methods
+}
+
+// detectBlockMethod returns the full name of the method of the first block service exposing it, or fallback.
+func detectBlockMethod(finder MethodFinder, methodName, fallback string) string {
+	for _, serviceName := range blockServices {
+		if _, err := finder.FindMethodDescriptor(serviceName, methodName); err == nil {
+			return serviceName + "." + methodName
+		}
+	}
+	return fallback
+}
+
+// Override returns the methods with the non-empty methods of overrides, e.g., for the forks that renamed a service.
+// The block field is kept.
+func (m Methods) Override(overrides Methods) Methods {
+	for _, f := range []struct{ field, override *string }{
+		{&m.Block, &overrides.Block},
+		{&m.Tx, &overrides.Tx},
+		{&m.BlockResults, &overrides.BlockResults},
+		{&m.BlockByHeight, &overrides.BlockByHeight},
+		{&m.LatestBlock, &overrides.LatestBlock},
+		{&m.Status, &overrides.Status},
+	} {
+		if *f.override != "" {
+			*f.field = *f.override
+		}
+	}
+	return m
+}
+
+// BlockJSONField returns the name of BlockField in the JSON of the responses, e.g., sdkBlock.
+func (m Methods) BlockJSONField() string {
+	parts := strings.Split(m.BlockField, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
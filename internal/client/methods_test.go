@@ -0,0 +1,96 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/reflection"
+)
+
+// blockService returns the descriptor of a block service whose GetLatestBlock response holds the block in blockField.
+func blockService(pkg, blockField string) *descriptorpb.FileDescriptorProto {
+	message := func(name string, fields ...string) *descriptorpb.DescriptorProto {
+		m := &descriptorpb.DescriptorProto{Name: proto.String(name)}
+		for i, f := range fields {
+			m.Field = append(m.Field, &descriptorpb.FieldDescriptorProto{
+				Name:   proto.String(f),
+				Number: proto.Int32(int32(i + 1)),
+				Type:   descriptorpb.FieldDescriptorProto_TYPE_BYTES.Enum(),
+				Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			})
+		}
+		return m
+	}
+	method := func(name string) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(name),
+			InputType:  proto.String("." + pkg + ".Request"),
+			OutputType: proto.String("." + pkg + ".Response"),
+		}
+	}
+	return &descriptorpb.FileDescriptorProto{
+		Name:        proto.String(pkg + "/query.proto"),
+		Package:     proto.String(pkg),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{message("Request", "height"), message("Response", blockField)},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name:   proto.String("Service"),
+			Method: []*descriptorpb.MethodDescriptorProto{method("GetBlockByHeight"), method("GetLatestBlock")},
+		}},
+	}
+}
+
+func TestDetectMethods(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []*descriptorpb.FileDescriptorProto
+		expected client.Methods
+	}{
+		{name: "no block service", expected: client.DefaultMethods},
+		{
+			name:     "tendermint service",
+			files:    []*descriptorpb.FileDescriptorProto{blockService("cosmos.base.tendermint.v1beta1", "sdk_block")},
+			expected: client.DefaultMethods,
+		},
+		{
+			name:  "cometbft service with block field",
+			files: []*descriptorpb.FileDescriptorProto{blockService("cosmos.base.cometbft.v1beta1", "block")},
+			expected: func() client.Methods {
+				m := client.DefaultMethods
+				m.BlockByHeight = "cosmos.base.cometbft.v1beta1.Service.GetBlockByHeight"
+				m.LatestBlock = "cosmos.base.cometbft.v1beta1.Service.GetLatestBlock"
+				m.BlockField = "block"
+				return m
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := reflection.BuildFileDescriptorSet(tt.files)
+			require.NoError(t, err)
+			methods := client.DetectMethods(reflection.NewCustomResolver(context.Background(), files, nil, 1))
+			assert.Equal(t, tt.expected, methods)
+		})
+	}
+}
+
+func TestBlockJSONField(t *testing.T) {
+	assert.Equal(t, "sdkBlock", client.DefaultMethods.BlockJSONField())
+	assert.Equal(t, "block", client.Methods{BlockField: "block"}.BlockJSONField())
+}
+
+func TestMethodsOverride(t *testing.T) {
+	methods := client.DefaultMethods.Override(client.Methods{BlockResults: "republic.block.v1.Service.GetBlockResults"})
+
+	expected := client.DefaultMethods
+	expected.BlockResults = "republic.block.v1.Service.GetBlockResults"
+	assert.Equal(t, expected, methods)
+	assert.Equal(t, client.DefaultMethods, client.DefaultMethods.Override(client.Methods{}))
+}
@@ -3,24 +3,81 @@ package config
 import (
 	"fmt"
 	"net"
+	"net/url"
 	"strconv"
+	"time"
 
+	"github.com/robfig/cron/v3"
 	"github.com/spf13/viper"
+
+	"github.com/manifest-network/yaci/internal/prune"
+	"github.com/manifest-network/yaci/internal/redact"
+	"github.com/manifest-network/yaci/internal/utils"
+	"github.com/manifest-network/yaci/internal/voteext"
 )
 
 type ExtractConfig struct {
-	MaxConcurrency       uint
-	MaxRetries           uint
-	BlockTime            uint
-	BlockStart           uint64
-	BlockStop            uint64
-	LiveMonitoring       bool
-	Insecure             bool
-	ReIndex              bool
-	MaxRecvMsgSize       int
-	EnablePrometheus     bool
-	PrometheusListenAddr string
-	EnableBlockResults   bool // Fetch block results (finalize_block_events) via gRPC
+	MaxConcurrency             uint
+	MaxRetries                 uint
+	BlockTime                  uint
+	BlockStart                 uint64
+	BlockStop                  uint64
+	StartTime                  string // Start from the first block at or after this RFC 3339 time, empty disables
+	EndTime                    string // Stop at the last block before this RFC 3339 time, empty disables
+	SkipRanges                 string // Comma-separated heights and height ranges to skip, e.g., "100-150,2000"
+	Ranges                     string // Comma-separated heights and height ranges to extract instead of a single range
+	ForceHeights               string // Comma-separated heights and height ranges to delete and extract again
+	SampleEvery                uint64 // Only extract the heights multiple of N, 0 or 1 disables
+	SampleInclude              string // Comma-separated heights and height ranges always extracted when sampling
+	LiveMonitoring             bool
+	Continuous                 bool   // Backfill up to the chain tip, then follow the chain as in live mode
+	Schedule                   string // Extract up to the chain tip at the times of this cron expression, empty disables
+	LeaderElection             bool   // Only extract while elected leader among the instances sharing the output
+	LeaderElectionName         string // Name of the leadership shared by the instances
+	ConfirmationDepth          uint64 // Only process blocks at least N heights behind the chain tip
+	Insecure                   bool
+	Descriptors                string   // Path of a FileDescriptorSet merged with the descriptors of server reflection, empty disables
+	ProtoDirs                  []string // Directories of .proto files compiled at startup, merged like Descriptors
+	DescriptorsOnly            bool     // Only use the descriptors of Descriptors and ProtoDirs, without server reflection
+	HeightDescriptors          []string // Descriptor sets of height ranges, RANGE=PATH, replacing the others for those heights
+	ArchiveEndpoint            string   // gRPC endpoint serving the heights below ArchiveThreshold, empty disables
+	ArchiveThreshold           uint64   // Height below which requests go to ArchiveEndpoint, 0 detects it
+	BlockMethod                string   // Full name of the GetBlockWithTxs method, empty uses the detected one
+	TxMethod                   string   // Full name of the GetTx method, empty uses the detected one
+	BlockResultsMethod         string   // Full name of the GetBlockResults method, empty uses the detected one
+	BlockByHeightMethod        string   // Full name of the GetBlockByHeight method, empty uses the detected one
+	LatestBlockMethod          string   // Full name of the GetLatestBlock method, empty uses the detected one
+	StatusMethod               string   // Full name of the node Status method, empty uses the detected one
+	ReIndex                    bool
+	NewestFirst                bool // Process ranges from the highest height downward
+	Resume                     bool // Without --start, resume from the latest stored block
+	MaxRecvMsgSize             int
+	EnablePrometheus           bool
+	PrometheusListenAddr       string
+	EnableBlockResults         bool          // Fetch block results (finalize_block_events) via gRPC
+	CometBFTRPC                string        // CometBFT RPC URL the block results are fetched from without the gRPC endpoint
+	HeaderOnly                 bool          // Only fetch and store block headers, without transactions
+	TxEventsQuery              string        // Only extract the transactions matching this event query, without their blocks
+	GovProposalsInterval       uint64        // Query governance proposals every N blocks, 0 disables
+	DelegationSnapshotInterval uint64        // Snapshot staking delegations every N blocks, 0 disables
+	BalanceSnapshotInterval    uint64        // Snapshot bank balances every N blocks, 0 disables
+	SupplyInterval             uint64        // Record the total supply every N blocks, 0 disables
+	IBCStateInterval           uint64        // Query the IBC clients, connections and channels every N blocks, 0 disables
+	ValidatorsInterval         uint64        // Query the validators and their consensus addresses every N blocks, 0 disables
+	ExtraQueries               []string      // User-defined gRPC queries of the form NAME=METHOD[;PARAMS]
+	ExtraQueriesInterval       uint64        // Run the extra queries every N blocks, 0 disables
+	DebugCaptureDir            string        // Dump the gRPC payloads of failing heights into this directory, empty disables
+	ShardSize                  uint64        // Share the range with other instances in shards of N blocks, 0 disables
+	ShardLease                 time.Duration // Duration of the lease on a claimed shard
+	MaxBlocks                  uint64        // Stop after processing N blocks, 0 disables
+	Deadline                   time.Duration // Stop after running for this duration, 0 disables
+	StopBlockTime              string        // Stop after processing a block at or after this RFC 3339 time, empty disables
+	RedactMemoPatterns         []string      // Regular expressions of the transaction memos to redact before storing them
+	MemoRedaction              string        // Redaction of the matching memos, hash or truncate
+	VoteExtensionDecoder       string        // Decoder of the vote extensions injected into the blocks, NAME[:ARG], empty disables
+	CanonicalJSON              bool          // Store the JSON payloads in canonical form, with sorted keys and fixed number formatting
+	StoreRawProtobuf           bool          // Store the protobuf bytes of the block, transaction and block results responses along with their JSON
+	PruneJSON                  []string      // JSON paths of the blocks, transactions and block results to drop, PATH, or truncate, PATH=N
 }
 
 func (c ExtractConfig) Validate() error {
@@ -28,6 +85,186 @@ func (c ExtractConfig) Validate() error {
 		return fmt.Errorf("cannot set --live and --stop flags together")
 	}
 
+	if c.Continuous {
+		if c.LiveMonitoring {
+			return fmt.Errorf("cannot set --continuous and --live flags together, --continuous implies live extraction")
+		}
+		if c.BlockStop != 0 {
+			return fmt.Errorf("cannot set --continuous and --stop flags together")
+		}
+		if c.ShardSize > 0 {
+			return fmt.Errorf("cannot set --continuous and --shard-size flags together")
+		}
+	}
+
+	if c.Schedule != "" {
+		if _, err := cron.ParseStandard(c.Schedule); err != nil {
+			return fmt.Errorf("invalid schedule, expected a cron expression: %w", err)
+		}
+		if c.LiveMonitoring || c.Continuous || c.ReIndex || c.ShardSize > 0 {
+			return fmt.Errorf("cannot set --schedule with --live, --continuous, --reindex or --shard-size")
+		}
+		if c.BlockStart != 0 || c.BlockStop != 0 || c.StartTime != "" || c.EndTime != "" {
+			return fmt.Errorf("cannot set --schedule with --start, --stop, --start-time or --end-time, scheduled extractions resume from the latest stored block")
+		}
+		if c.Ranges != "" || c.ForceHeights != "" || c.TxEventsQuery != "" {
+			return fmt.Errorf("cannot set --schedule with --ranges, --force-heights or --tx-events-query")
+		}
+		if !c.Resume {
+			return fmt.Errorf("cannot set --schedule with --resume=false, scheduled extractions resume from the latest stored block")
+		}
+	}
+
+	if _, err := ParseHeightDescriptors(c.HeightDescriptors); err != nil {
+		return fmt.Errorf("invalid height-descriptors: %w", err)
+	}
+
+	for _, m := range []struct{ flag, method string }{
+		{"block-method", c.BlockMethod},
+		{"tx-method", c.TxMethod},
+		{"block-results-method", c.BlockResultsMethod},
+		{"block-by-height-method", c.BlockByHeightMethod},
+		{"latest-block-method", c.LatestBlockMethod},
+		{"status-method", c.StatusMethod},
+	} {
+		if m.method == "" {
+			continue
+		}
+		if _, _, err := utils.ParseMethodFullName(m.method); err != nil {
+			return fmt.Errorf("invalid %s %q, expected a full name such as cosmos.tx.v1beta1.Service.GetTx: %w", m.flag, m.method, err)
+		}
+	}
+
+	if c.ArchiveThreshold > 0 && c.ArchiveEndpoint == "" {
+		return fmt.Errorf("--archive-threshold requires --archive-endpoint")
+	}
+
+	if c.LeaderElection {
+		if c.LeaderElectionName == "" {
+			return fmt.Errorf("leader election name cannot be empty")
+		}
+		if c.ShardSize > 0 {
+			return fmt.Errorf("cannot set --leader-election and --shard-size flags together, shards are already shared between instances")
+		}
+	}
+
+	if c.ShardSize > 0 {
+		if c.LiveMonitoring {
+			return fmt.Errorf("cannot set --live and --shard-size flags together")
+		}
+		if c.BlockStart == 0 || c.BlockStop == 0 {
+			return fmt.Errorf("--shard-size requires --start and --stop, identical on all instances")
+		}
+		if c.ShardLease <= 0 {
+			return fmt.Errorf("shard lease must be positive")
+		}
+	}
+
+	if _, err := ParseHeightRanges(c.SkipRanges); err != nil {
+		return fmt.Errorf("invalid skip-ranges: %w", err)
+	}
+
+	if c.Ranges != "" && c.ForceHeights != "" {
+		return fmt.Errorf("cannot set --ranges and --force-heights flags together")
+	}
+
+	for flag, ranges := range map[string]string{"ranges": c.Ranges, "force-heights": c.ForceHeights} {
+		if ranges == "" {
+			continue
+		}
+		if _, err := ParseHeightRanges(ranges); err != nil {
+			return fmt.Errorf("invalid %s: %w", flag, err)
+		}
+		if c.BlockStart != 0 || c.BlockStop != 0 || c.StartTime != "" || c.EndTime != "" {
+			return fmt.Errorf("cannot set --%s with --start, --stop, --start-time or --end-time", flag)
+		}
+		if c.LiveMonitoring || c.Continuous || c.ReIndex || c.ShardSize > 0 {
+			return fmt.Errorf("cannot set --%s with --live, --continuous, --reindex or --shard-size", flag)
+		}
+	}
+
+	if _, err := ParseExtraQueries(c.ExtraQueries); err != nil {
+		return err
+	}
+
+	if c.TxEventsQuery != "" {
+		if c.LiveMonitoring || c.Continuous || c.ReIndex || c.ShardSize > 0 || c.HeaderOnly {
+			return fmt.Errorf("cannot set --tx-events-query with --live, --continuous, --reindex, --shard-size or --header-only")
+		}
+		if c.Ranges != "" || c.ForceHeights != "" {
+			return fmt.Errorf("cannot set --tx-events-query with --ranges or --force-heights")
+		}
+	}
+
+	if c.CometBFTRPC != "" {
+		if !c.EnableBlockResults {
+			return fmt.Errorf("--cometbft-rpc requires --enable-block-results")
+		}
+		u, err := url.Parse(c.CometBFTRPC)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid cometbft-rpc, expected an http(s) URL: %s", c.CometBFTRPC)
+		}
+	}
+
+	if c.DescriptorsOnly && c.Descriptors == "" && len(c.ProtoDirs) == 0 {
+		return fmt.Errorf("--descriptors-only requires --descriptors or --proto-dir")
+	}
+
+	if len(c.RedactMemoPatterns) > 0 {
+		if _, err := redact.NewMemoRedactor(c.RedactMemoPatterns, c.MemoRedaction); err != nil {
+			return err
+		}
+	}
+
+	if _, err := prune.NewPruner(c.PruneJSON); err != nil {
+		return fmt.Errorf("invalid prune-json: %w", err)
+	}
+
+	if _, err := voteext.NewDecoder(c.VoteExtensionDecoder); err != nil {
+		return fmt.Errorf("invalid vote-extension-decoder: %w", err)
+	}
+
+	if c.HeaderOnly && c.EnableBlockResults {
+		return fmt.Errorf("cannot set --header-only and --enable-block-results flags together")
+	}
+
+	if c.StoreRawProtobuf {
+		if c.HeaderOnly {
+			return fmt.Errorf("cannot set --store-raw-protobuf and --header-only flags together")
+		}
+		// The raw responses would keep the memos the redaction removes from the JSON
+		if len(c.RedactMemoPatterns) > 0 {
+			return fmt.Errorf("cannot set --store-raw-protobuf and --redact-memo flags together")
+		}
+	}
+
+	if c.SampleInclude != "" {
+		if _, err := ParseHeightRanges(c.SampleInclude); err != nil {
+			return fmt.Errorf("invalid sample-include: %w", err)
+		}
+		if c.SampleEvery <= 1 {
+			return fmt.Errorf("--sample-include requires --sample-every")
+		}
+	}
+
+	if err := c.validateTimeRange(); err != nil {
+		return err
+	}
+
+	if c.StopBlockTime != "" {
+		if _, err := time.Parse(time.RFC3339, c.StopBlockTime); err != nil {
+			return fmt.Errorf("invalid stop-block-time, expected an RFC 3339 time: %w", err)
+		}
+	}
+
+	if c.Deadline < 0 {
+		return fmt.Errorf("deadline cannot be negative")
+	}
+
+	if c.ShardSize > 0 && (c.MaxBlocks > 0 || c.Deadline > 0 || c.StopBlockTime != "") {
+		return fmt.Errorf("cannot set --shard-size with --max-blocks, --deadline or --stop-block-time, shards must be completed")
+	}
+
 	if c.EnablePrometheus {
 		host, port, err := net.SplitHostPort(c.PrometheusListenAddr)
 		if err != nil {
@@ -45,19 +282,98 @@ func (c ExtractConfig) Validate() error {
 	return nil
 }
 
+func (c ExtractConfig) validateTimeRange() error {
+	var startTime, endTime time.Time
+	var err error
+	if c.StartTime != "" {
+		if c.BlockStart != 0 {
+			return fmt.Errorf("cannot set --start and --start-time flags together")
+		}
+		if startTime, err = time.Parse(time.RFC3339, c.StartTime); err != nil {
+			return fmt.Errorf("invalid start-time, expected an RFC 3339 time: %w", err)
+		}
+	}
+
+	if c.EndTime != "" {
+		if c.BlockStop != 0 {
+			return fmt.Errorf("cannot set --stop and --end-time flags together")
+		}
+		if c.LiveMonitoring || c.Continuous {
+			return fmt.Errorf("cannot set --end-time with --live or --continuous")
+		}
+		if endTime, err = time.Parse(time.RFC3339, c.EndTime); err != nil {
+			return fmt.Errorf("invalid end-time, expected an RFC 3339 time: %w", err)
+		}
+	}
+
+	if !startTime.IsZero() && !endTime.IsZero() && !startTime.Before(endTime) {
+		return fmt.Errorf("start-time must be before end-time")
+	}
+	return nil
+}
+
 func LoadExtractConfigFromCLI() ExtractConfig {
 	return ExtractConfig{
-		MaxConcurrency:       viper.GetUint("max-concurrency"),
-		MaxRetries:           viper.GetUint("max-retries"),
-		BlockTime:            viper.GetUint("block-time"),
-		BlockStart:           viper.GetUint64("start"),
-		BlockStop:            viper.GetUint64("stop"),
-		LiveMonitoring:       viper.GetBool("live"),
-		Insecure:             viper.GetBool("insecure"),
-		ReIndex:              viper.GetBool("reindex"),
-		MaxRecvMsgSize:       viper.GetInt("max-recv-msg-size"),
-		EnablePrometheus:     viper.GetBool("enable-prometheus"),
-		PrometheusListenAddr: viper.GetString("prometheus-addr"),
-		EnableBlockResults:   viper.GetBool("enable-block-results"),
+		MaxConcurrency:             viper.GetUint("max-concurrency"),
+		MaxRetries:                 viper.GetUint("max-retries"),
+		BlockTime:                  viper.GetUint("block-time"),
+		BlockStart:                 viper.GetUint64("start"),
+		BlockStop:                  viper.GetUint64("stop"),
+		StartTime:                  viper.GetString("start-time"),
+		EndTime:                    viper.GetString("end-time"),
+		SkipRanges:                 viper.GetString("skip-ranges"),
+		Ranges:                     viper.GetString("ranges"),
+		ForceHeights:               viper.GetString("force-heights"),
+		SampleEvery:                viper.GetUint64("sample-every"),
+		SampleInclude:              viper.GetString("sample-include"),
+		LiveMonitoring:             viper.GetBool("live"),
+		Continuous:                 viper.GetBool("continuous"),
+		Schedule:                   viper.GetString("schedule"),
+		LeaderElection:             viper.GetBool("leader-election"),
+		LeaderElectionName:         viper.GetString("leader-election-name"),
+		ConfirmationDepth:          viper.GetUint64("confirmation-depth"),
+		Insecure:                   viper.GetBool("insecure"),
+		Descriptors:                viper.GetString("descriptors"),
+		ProtoDirs:                  viper.GetStringSlice("proto-dir"),
+		DescriptorsOnly:            viper.GetBool("descriptors-only"),
+		HeightDescriptors:          viper.GetStringSlice("height-descriptors"),
+		BlockMethod:                viper.GetString("block-method"),
+		TxMethod:                   viper.GetString("tx-method"),
+		BlockResultsMethod:         viper.GetString("block-results-method"),
+		BlockByHeightMethod:        viper.GetString("block-by-height-method"),
+		LatestBlockMethod:          viper.GetString("latest-block-method"),
+		StatusMethod:               viper.GetString("status-method"),
+		ArchiveEndpoint:            viper.GetString("archive-endpoint"),
+		ArchiveThreshold:           viper.GetUint64("archive-threshold"),
+		ReIndex:                    viper.GetBool("reindex"),
+		NewestFirst:                viper.GetBool("newest-first"),
+		Resume:                     viper.GetBool("resume"),
+		MaxRecvMsgSize:             viper.GetInt("max-recv-msg-size"),
+		EnablePrometheus:           viper.GetBool("enable-prometheus"),
+		PrometheusListenAddr:       viper.GetString("prometheus-addr"),
+		EnableBlockResults:         viper.GetBool("enable-block-results"),
+		CometBFTRPC:                viper.GetString("cometbft-rpc"),
+		HeaderOnly:                 viper.GetBool("header-only"),
+		TxEventsQuery:              viper.GetString("tx-events-query"),
+		GovProposalsInterval:       viper.GetUint64("gov-proposals-interval"),
+		DelegationSnapshotInterval: viper.GetUint64("delegation-snapshot-interval"),
+		BalanceSnapshotInterval:    viper.GetUint64("balance-snapshot-interval"),
+		SupplyInterval:             viper.GetUint64("supply-interval"),
+		IBCStateInterval:           viper.GetUint64("ibc-state-interval"),
+		ValidatorsInterval:         viper.GetUint64("validators-interval"),
+		ExtraQueries:               viper.GetStringSlice("extra-query"),
+		ExtraQueriesInterval:       viper.GetUint64("extra-queries-interval"),
+		DebugCaptureDir:            viper.GetString("debug-capture"),
+		ShardSize:                  viper.GetUint64("shard-size"),
+		ShardLease:                 viper.GetDuration("shard-lease"),
+		MaxBlocks:                  viper.GetUint64("max-blocks"),
+		Deadline:                   viper.GetDuration("deadline"),
+		StopBlockTime:              viper.GetString("stop-block-time"),
+		RedactMemoPatterns:         viper.GetStringSlice("redact-memo"),
+		MemoRedaction:              viper.GetString("memo-redaction"),
+		VoteExtensionDecoder:       viper.GetString("vote-extension-decoder"),
+		CanonicalJSON:              viper.GetBool("canonical-json"),
+		StoreRawProtobuf:           viper.GetBool("store-raw-protobuf"),
+		PruneJSON:                  viper.GetStringSlice("prune-json"),
 	}
 }
@@ -0,0 +1,154 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/manifest-network/yaci/internal/config"
+)
+
+func TestValidateSchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.ExtractConfig
+		wantErr string
+	}{
+		{name: "hourly", cfg: config.ExtractConfig{Schedule: "0 * * * *", Resume: true}},
+		{name: "descriptor", cfg: config.ExtractConfig{Schedule: "@daily", Resume: true}},
+		{name: "invalid", cfg: config.ExtractConfig{Schedule: "every hour", Resume: true}, wantErr: "invalid schedule"},
+		{name: "live", cfg: config.ExtractConfig{Schedule: "0 * * * *", Resume: true, LiveMonitoring: true}, wantErr: "cannot set --schedule with --live"},
+		{name: "start", cfg: config.ExtractConfig{Schedule: "0 * * * *", Resume: true, BlockStart: 10}, wantErr: "cannot set --schedule with --start"},
+		{name: "no resume", cfg: config.ExtractConfig{Schedule: "0 * * * *"}, wantErr: "--resume=false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateLeaderElection(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.ExtractConfig
+		wantErr string
+	}{
+		{name: "enabled", cfg: config.ExtractConfig{LeaderElection: true, LeaderElectionName: "yaci", LiveMonitoring: true}},
+		{name: "empty name", cfg: config.ExtractConfig{LeaderElection: true}, wantErr: "name cannot be empty"},
+		{name: "shards", cfg: config.ExtractConfig{LeaderElection: true, LeaderElectionName: "yaci", ShardSize: 100, BlockStart: 1, BlockStop: 1000, ShardLease: 1}, wantErr: "--shard-size"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateCometBFTRPC(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.ExtractConfig
+		wantErr string
+	}{
+		{name: "valid", cfg: config.ExtractConfig{CometBFTRPC: "http://localhost:26657", EnableBlockResults: true}},
+		{name: "without block results", cfg: config.ExtractConfig{CometBFTRPC: "http://localhost:26657"}, wantErr: "requires --enable-block-results"},
+		{name: "invalid URL", cfg: config.ExtractConfig{CometBFTRPC: "localhost:26657", EnableBlockResults: true}, wantErr: "invalid cometbft-rpc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateMemoRedaction(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.ExtractConfig
+		wantErr string
+	}{
+		{name: "hash", cfg: config.ExtractConfig{RedactMemoPatterns: []string{"@"}, MemoRedaction: "hash"}},
+		{name: "truncate", cfg: config.ExtractConfig{RedactMemoPatterns: []string{"^[0-9]+$"}, MemoRedaction: "truncate"}},
+		{name: "no patterns", cfg: config.ExtractConfig{MemoRedaction: "drop"}},
+		{name: "invalid pattern", cfg: config.ExtractConfig{RedactMemoPatterns: []string{"("}, MemoRedaction: "hash"}, wantErr: "invalid memo pattern"},
+		{name: "invalid mode", cfg: config.ExtractConfig{RedactMemoPatterns: []string{"@"}, MemoRedaction: "drop"}, wantErr: "invalid memo redaction mode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateStoreRawProtobuf(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.ExtractConfig
+		wantErr string
+	}{
+		{name: "valid", cfg: config.ExtractConfig{StoreRawProtobuf: true, EnableBlockResults: true}},
+		{name: "header only", cfg: config.ExtractConfig{StoreRawProtobuf: true, HeaderOnly: true}, wantErr: "cannot set --store-raw-protobuf and --header-only"},
+		{name: "memo redaction", cfg: config.ExtractConfig{StoreRawProtobuf: true, RedactMemoPatterns: []string{"@"}, MemoRedaction: "hash"}, wantErr: "cannot set --store-raw-protobuf and --redact-memo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateMethods(t *testing.T) {
+	assert.NoError(t, config.ExtractConfig{BlockResultsMethod: "republic.block.v1.Service.GetBlockResults"}.Validate())
+	assert.ErrorContains(t, config.ExtractConfig{TxMethod: "GetTx"}.Validate(), `invalid tx-method "GetTx"`)
+	assert.ErrorContains(t, config.ExtractConfig{StatusMethod: "cosmos.base.node.v1beta1.Service."}.Validate(), "invalid status-method")
+}
+
+func TestValidatePruneJSON(t *testing.T) {
+	assert.NoError(t, config.ExtractConfig{PruneJSON: []string{"tx.body.messages.*.wasmByteCode", "txs.*.body.memo=64"}}.Validate())
+	assert.ErrorContains(t, config.ExtractConfig{PruneJSON: []string{"tx..body"}}.Validate(), "invalid prune-json")
+	assert.ErrorContains(t, config.ExtractConfig{PruneJSON: []string{"tx.body=-1"}}.Validate(), "invalid prune-json")
+}
+
+func TestValidateVoteExtensionDecoder(t *testing.T) {
+	assert.NoError(t, config.ExtractConfig{VoteExtensionDecoder: "json"}.Validate())
+	assert.NoError(t, config.ExtractConfig{VoteExtensionDecoder: "proto:slinky.abci.v2.OracleVoteExtension"}.Validate())
+	assert.ErrorContains(t, config.ExtractConfig{VoteExtensionDecoder: "proto"}.Validate(), "invalid vote-extension-decoder")
+	assert.ErrorContains(t, config.ExtractConfig{VoteExtensionDecoder: "cbor"}.Validate(), "unknown vote extension decoder")
+}
+
+func TestValidateDescriptors(t *testing.T) {
+	assert.NoError(t, config.ExtractConfig{Descriptors: "chain.pb", DescriptorsOnly: true}.Validate())
+	assert.NoError(t, config.ExtractConfig{ProtoDirs: []string{"proto"}, DescriptorsOnly: true}.Validate())
+	assert.ErrorContains(t, config.ExtractConfig{DescriptorsOnly: true}.Validate(), "--descriptors-only requires --descriptors or --proto-dir")
+}
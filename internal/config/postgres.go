@@ -2,13 +2,17 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/spf13/viper"
 )
 
 type PostgresConfig struct {
-	ConnString string
+	ConnString         string
+	CompactionInterval time.Duration // Interval between processed ranges compactions, 0 disables
+	EnableTextSearch   bool          // Add the full-text search columns over the memos and the events
+	AutoMigrate        bool          // Apply the pending schema migrations at startup
 }
 
 func (c PostgresConfig) Validate() error {
@@ -21,11 +25,18 @@ func (c PostgresConfig) Validate() error {
 		return fmt.Errorf("failed to parse PostgreSQL connection string: %w", err)
 	}
 
+	if c.CompactionInterval < 0 {
+		return fmt.Errorf("compaction interval cannot be negative")
+	}
+
 	return nil
 }
 
 func LoadPostgresConfigFromCLI() PostgresConfig {
 	return PostgresConfig{
-		ConnString: viper.GetString("postgres-conn"),
+		ConnString:         viper.GetString("postgres-conn"),
+		CompactionInterval: viper.GetDuration("compaction-interval"),
+		EnableTextSearch:   viper.GetBool("enable-text-search"),
+		AutoMigrate:        viper.GetBool("auto-migrate"),
 	}
 }
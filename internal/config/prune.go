@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PruneConfig selects the old blocks to delete. Exactly one of KeepLast, BeforeHeight and OlderThan is set.
+type PruneConfig struct {
+	KeepLast     uint64        // Keep the N highest stored blocks
+	BeforeHeight uint64        // Delete the blocks below this height
+	OlderThan    time.Duration // Delete the blocks older than this age
+	BatchSize    int           // Number of blocks deleted per database transaction
+	DryRun       bool          // Only report what would be deleted
+}
+
+func (c PruneConfig) Validate() error {
+	set := 0
+	for _, isSet := range []bool{c.KeepLast > 0, c.BeforeHeight > 0, c.OlderThan > 0} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of keep-last, before-height and older-than must be set")
+	}
+
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("batch size must be positive")
+	}
+
+	return nil
+}
+
+// ParseAge parses a duration which can also be expressed in days, e.g., "90d", along with the units of
+// time.ParseDuration, e.g., "36h".
+func ParseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseUint(days, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	age, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	if age < 0 {
+		return 0, fmt.Errorf("age %q cannot be negative", s)
+	}
+	return age, nil
+}
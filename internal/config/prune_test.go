@@ -0,0 +1,64 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/config"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{name: "days", input: "90d", expected: 90 * 24 * time.Hour},
+		{name: "hours", input: "36h", expected: 36 * time.Hour},
+		{name: "negative", input: "-1h", wantErr: true},
+		{name: "fractional days", input: "1.5d", wantErr: true},
+		{name: "invalid", input: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			age, err := config.ParseAge(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, age)
+		})
+	}
+}
+
+func TestPruneConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  config.PruneConfig
+		wantErr bool
+	}{
+		{name: "keep last", config: config.PruneConfig{KeepLast: 1000, BatchSize: 100}},
+		{name: "before height", config: config.PruneConfig{BeforeHeight: 5000, BatchSize: 100}},
+		{name: "older than", config: config.PruneConfig{OlderThan: time.Hour, BatchSize: 100}},
+		{name: "no criterion", config: config.PruneConfig{BatchSize: 100}, wantErr: true},
+		{name: "several criteria", config: config.PruneConfig{KeepLast: 1000, BeforeHeight: 5000, BatchSize: 100}, wantErr: true},
+		{name: "invalid batch size", config: config.PruneConfig{KeepLast: 1000}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
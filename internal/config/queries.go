@@ -0,0 +1,66 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// heightPlaceholder is replaced by the block height in the parameters of extra queries.
+const heightPlaceholder = "{height}"
+
+// ExtraQuery is a user-defined gRPC query run at every block, whose response is stored under its name.
+type ExtraQuery struct {
+	Name   string
+	Method string // Full name of the gRPC method, e.g., cosmos.staking.v1beta1.Query.Pool
+	Params string // JSON parameters, where {height} is replaced by the block height
+}
+
+// ParseExtraQuery parses an extra query definition of the form NAME=METHOD or NAME=METHOD;PARAMS.
+func ParseExtraQuery(s string) (ExtraQuery, error) {
+	name, rest, ok := strings.Cut(s, "=")
+	if !ok {
+		return ExtraQuery{}, fmt.Errorf("invalid extra query %q, expected NAME=METHOD[;PARAMS]", s)
+	}
+	method, params, _ := strings.Cut(rest, ";")
+
+	q := ExtraQuery{
+		Name:   strings.TrimSpace(name),
+		Method: strings.TrimSpace(method),
+		Params: strings.TrimSpace(params),
+	}
+	if q.Name == "" || q.Method == "" {
+		return ExtraQuery{}, fmt.Errorf("invalid extra query %q, expected NAME=METHOD[;PARAMS]", s)
+	}
+	if q.Params != "" && !json.Valid(q.ParamsAt(1)) {
+		return ExtraQuery{}, fmt.Errorf("invalid parameters of extra query %q, expected JSON", q.Name)
+	}
+	return q, nil
+}
+
+// ParseExtraQueries parses extra query definitions, whose names must be unique.
+func ParseExtraQueries(definitions []string) ([]ExtraQuery, error) {
+	var queries []ExtraQuery
+	names := make(map[string]bool)
+	for _, d := range definitions {
+		q, err := ParseExtraQuery(d)
+		if err != nil {
+			return nil, err
+		}
+		if names[q.Name] {
+			return nil, fmt.Errorf("duplicate extra query name %q", q.Name)
+		}
+		names[q.Name] = true
+		queries = append(queries, q)
+	}
+	return queries, nil
+}
+
+// ParamsAt returns the JSON parameters of the query at height, or nil if the query has none.
+func (q ExtraQuery) ParamsAt(height uint64) []byte {
+	if q.Params == "" {
+		return nil
+	}
+	return []byte(strings.ReplaceAll(q.Params, heightPlaceholder, strconv.FormatUint(height, 10)))
+}
@@ -0,0 +1,63 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/config"
+)
+
+func TestParseExtraQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected config.ExtraQuery
+		wantErr  bool
+	}{
+		{
+			name:     "without parameters",
+			input:    "pool=cosmos.staking.v1beta1.Query.Pool",
+			expected: config.ExtraQuery{Name: "pool", Method: "cosmos.staking.v1beta1.Query.Pool"},
+		},
+		{
+			name:  "with parameters",
+			input: `info=cosmos.base.tendermint.v1beta1.Service.GetBlockByHeight;{"height": "{height}"}`,
+			expected: config.ExtraQuery{
+				Name:   "info",
+				Method: "cosmos.base.tendermint.v1beta1.Service.GetBlockByHeight",
+				Params: `{"height": "{height}"}`,
+			},
+		},
+		{name: "missing method", input: "pool=", wantErr: true},
+		{name: "missing name", input: "cosmos.staking.v1beta1.Query.Pool", wantErr: true},
+		{name: "invalid parameters", input: "pool=cosmos.staking.v1beta1.Query.Pool;{", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := config.ParseExtraQuery(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, q)
+		})
+	}
+}
+
+func TestParseExtraQueriesDuplicateName(t *testing.T) {
+	_, err := config.ParseExtraQueries([]string{
+		"pool=cosmos.staking.v1beta1.Query.Pool",
+		"pool=cosmos.staking.v1beta1.Query.Params",
+	})
+	require.Error(t, err)
+}
+
+func TestExtraQueryParamsAt(t *testing.T) {
+	q := config.ExtraQuery{Name: "block", Method: "m", Params: `{"height": "{height}"}`}
+	assert.Equal(t, `{"height": "42"}`, string(q.ParamsAt(42)))
+	assert.Nil(t, config.ExtraQuery{Name: "pool", Method: "m"}.ParamsAt(42))
+}
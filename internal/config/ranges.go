@@ -0,0 +1,110 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+// ParseHeightRanges parses a comma-separated list of heights and inclusive height ranges, e.g., "100-150,2000".
+// An empty string returns no range.
+func ParseHeightRanges(s string) ([]models.HeightRange, error) {
+	var ranges []models.HeightRange
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		startStr, stopStr, isRange := strings.Cut(part, "-")
+		start, err := strconv.ParseUint(strings.TrimSpace(startStr), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid height in %q: %w", part, err)
+		}
+		stop := start
+		if isRange {
+			if stop, err = strconv.ParseUint(strings.TrimSpace(stopStr), 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid height in %q: %w", part, err)
+			}
+		}
+
+		if start == 0 || start > stop {
+			return nil, fmt.Errorf("invalid height range %q", part)
+		}
+		ranges = append(ranges, models.HeightRange{Start: start, Stop: stop})
+	}
+	return ranges, nil
+}
+
+// FormatHeightRanges formats the ranges as parsed by ParseHeightRanges, e.g., "100-150,2000".
+func FormatHeightRanges(ranges []models.HeightRange) string {
+	parts := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		if r.Start == r.Stop {
+			parts = append(parts, strconv.FormatUint(r.Start, 10))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%d-%d", r.Start, r.Stop))
+	}
+	return strings.Join(parts, ",")
+}
+
+// MergeHeightRanges returns the ranges sorted by start height, with overlapping and adjacent ranges merged.
+func MergeHeightRanges(ranges []models.HeightRange) []models.HeightRange {
+	sorted := slices.Clone(ranges)
+	slices.SortFunc(sorted, func(a, b models.HeightRange) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+
+	var merged []models.HeightRange
+	for _, r := range sorted {
+		if last := len(merged) - 1; last >= 0 && r.Start <= merged[last].Stop+1 {
+			merged[last].Stop = max(merged[last].Stop, r.Stop)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// HeightDescriptors is the path of the protobuf descriptor set the messages of a range of heights are decoded with.
+type HeightDescriptors struct {
+	Range models.HeightRange
+	Path  string
+}
+
+// ParseHeightDescriptors parses descriptor sets of height ranges, each RANGE=PATH, e.g., "1-1499999=v1.pb".
+// The ranges must not overlap.
+func ParseHeightDescriptors(specs []string) ([]HeightDescriptors, error) {
+	var descriptors []HeightDescriptors
+	for _, spec := range specs {
+		rangeStr, path, ok := strings.Cut(spec, "=")
+		path = strings.TrimSpace(path)
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid height descriptors %q, expected RANGE=PATH", spec)
+		}
+		ranges, err := ParseHeightRanges(rangeStr)
+		if err != nil {
+			return nil, err
+		}
+		if len(ranges) != 1 {
+			return nil, fmt.Errorf("invalid height descriptors %q, expected a single height range", spec)
+		}
+		descriptors = append(descriptors, HeightDescriptors{Range: ranges[0], Path: path})
+	}
+
+	sorted := slices.Clone(descriptors)
+	slices.SortFunc(sorted, func(a, b HeightDescriptors) int {
+		return cmp.Compare(a.Range.Start, b.Range.Start)
+	})
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Range.Start <= sorted[i-1].Range.Stop {
+			return nil, fmt.Errorf("height descriptors of %s and %s overlap", sorted[i-1].Path, sorted[i].Path)
+		}
+	}
+	return descriptors, nil
+}
@@ -0,0 +1,105 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+func TestParseHeightRanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []models.HeightRange
+		wantErr  bool
+	}{
+		{name: "empty", input: ""},
+		{name: "single height", input: "2000000", expected: []models.HeightRange{{Start: 2000000, Stop: 2000000}}},
+		{
+			name:     "ranges and heights",
+			input:    "100000-100050, 2000000,",
+			expected: []models.HeightRange{{Start: 100000, Stop: 100050}, {Start: 2000000, Stop: 2000000}},
+		},
+		{name: "reversed range", input: "50-10", wantErr: true},
+		{name: "zero height", input: "0-10", wantErr: true},
+		{name: "invalid height", input: "10-abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranges, err := config.ParseHeightRanges(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ranges)
+		})
+	}
+}
+
+func TestFormatHeightRanges(t *testing.T) {
+	ranges := []models.HeightRange{{Start: 100, Stop: 150}, {Start: 2000, Stop: 2000}}
+	assert.Equal(t, "100-150,2000", config.FormatHeightRanges(ranges))
+	assert.Empty(t, config.FormatHeightRanges(nil))
+
+	parsed, err := config.ParseHeightRanges(config.FormatHeightRanges(ranges))
+	require.NoError(t, err)
+	assert.Equal(t, ranges, parsed)
+}
+
+func TestMergeHeightRanges(t *testing.T) {
+	ranges := []models.HeightRange{
+		{Start: 50000, Stop: 60000},
+		{Start: 1, Stop: 1000},
+		{Start: 500, Stop: 1500},
+		{Start: 1501, Stop: 1600},
+		{Start: 55000, Stop: 56000},
+	}
+
+	expected := []models.HeightRange{
+		{Start: 1, Stop: 1600},
+		{Start: 50000, Stop: 60000},
+	}
+	assert.Equal(t, expected, config.MergeHeightRanges(ranges))
+	assert.Empty(t, config.MergeHeightRanges(nil))
+}
+
+func TestParseHeightDescriptors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		expected []config.HeightDescriptors
+		wantErr  string
+	}{
+		{name: "empty"},
+		{
+			name:  "ranges",
+			input: []string{"1500000-2000000=v2.pb", "1-1499999 = v1.pb"},
+			expected: []config.HeightDescriptors{
+				{Range: models.HeightRange{Start: 1500000, Stop: 2000000}, Path: "v2.pb"},
+				{Range: models.HeightRange{Start: 1, Stop: 1499999}, Path: "v1.pb"},
+			},
+		},
+		{name: "missing path", input: []string{"1-100"}, wantErr: "expected RANGE=PATH"},
+		{name: "several ranges", input: []string{"1-100,200=v1.pb"}, wantErr: "expected a single height range"},
+		{name: "invalid range", input: []string{"100-1=v1.pb"}, wantErr: "invalid height range"},
+		{name: "overlapping ranges", input: []string{"1-100=v1.pb", "100-200=v2.pb"}, wantErr: "overlap"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			descriptors, err := config.ParseHeightDescriptors(tt.input)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, descriptors)
+		})
+	}
+}
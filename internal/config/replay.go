@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/manifest-network/yaci/internal/prune"
+	"github.com/manifest-network/yaci/internal/redact"
+	"github.com/manifest-network/yaci/internal/voteext"
+)
+
+// ReplayConfig configures the replay of the stored blocks through the decoding pipeline of the extraction.
+type ReplayConfig struct {
+	BlockStart           uint64   // Lowest height replayed, 0 for the lowest stored block
+	BlockStop            uint64   // Highest height replayed, 0 for the latest stored block
+	BatchSize            int      // Number of blocks read at once
+	Descriptors          string   // Path of a FileDescriptorSet decoding the protobuf payloads, e.g., the ICA packets
+	ProtoDirs            []string // Directories of .proto files compiled at startup, merged like Descriptors
+	RedactMemoPatterns   []string // Regular expressions of the transaction memos to redact
+	MemoRedaction        string   // Redaction of the matching memos, hash or truncate
+	VoteExtensionDecoder string   // Decoder of the vote extensions injected into the blocks, NAME[:ARG], empty disables
+	CanonicalJSON        bool     // Rewrite the JSON payloads in canonical form
+	PruneJSON            []string // JSON paths of the blocks, transactions and block results to drop, PATH, or truncate, PATH=N
+}
+
+func (c ReplayConfig) Validate() error {
+	if c.BlockStart > 0 && c.BlockStop > 0 && c.BlockStart > c.BlockStop {
+		return fmt.Errorf("start block is greater than stop block")
+	}
+
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("batch size must be positive")
+	}
+
+	if len(c.RedactMemoPatterns) > 0 {
+		if _, err := redact.NewMemoRedactor(c.RedactMemoPatterns, c.MemoRedaction); err != nil {
+			return err
+		}
+	}
+
+	if _, err := prune.NewPruner(c.PruneJSON); err != nil {
+		return fmt.Errorf("invalid prune-json: %w", err)
+	}
+
+	if _, err := voteext.NewDecoder(c.VoteExtensionDecoder); err != nil {
+		return fmt.Errorf("invalid vote-extension-decoder: %w", err)
+	}
+
+	return nil
+}
+
+// ExtractConfig returns the extraction configuration of the same descriptors and output transformations, which the
+// replay applies like an extraction.
+func (c ReplayConfig) ExtractConfig() ExtractConfig {
+	return ExtractConfig{
+		Descriptors:          c.Descriptors,
+		ProtoDirs:            c.ProtoDirs,
+		RedactMemoPatterns:   c.RedactMemoPatterns,
+		MemoRedaction:        c.MemoRedaction,
+		VoteExtensionDecoder: c.VoteExtensionDecoder,
+		CanonicalJSON:        c.CanonicalJSON,
+		PruneJSON:            c.PruneJSON,
+	}
+}
@@ -0,0 +1,44 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/config"
+)
+
+func TestReplayConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  config.ReplayConfig
+		wantErr bool
+	}{
+		{name: "defaults", config: config.ReplayConfig{BatchSize: 100}},
+		{name: "range", config: config.ReplayConfig{BlockStart: 10, BlockStop: 20, BatchSize: 100}},
+		{name: "output transformations", config: config.ReplayConfig{BatchSize: 100, PruneJSON: []string{"tx.body.memo"}, RedactMemoPatterns: []string{"@"}, MemoRedaction: "hash", VoteExtensionDecoder: "json"}},
+		{name: "start above stop", config: config.ReplayConfig{BlockStart: 20, BlockStop: 10, BatchSize: 100}, wantErr: true},
+		{name: "invalid batch size", config: config.ReplayConfig{}, wantErr: true},
+		{name: "invalid memo redaction", config: config.ReplayConfig{BatchSize: 100, RedactMemoPatterns: []string{"@"}, MemoRedaction: "drop"}, wantErr: true},
+		{name: "invalid vote extension decoder", config: config.ReplayConfig{BatchSize: 100, VoteExtensionDecoder: "xml"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestReplayConfigExtractConfig(t *testing.T) {
+	cfg := config.ReplayConfig{Descriptors: "chain.pb", CanonicalJSON: true, PruneJSON: []string{"tx.body.memo"}}.ExtractConfig()
+	assert.Equal(t, "chain.pb", cfg.Descriptors)
+	assert.True(t, cfg.CanonicalJSON)
+	assert.Equal(t, []string{"tx.body.memo"}, cfg.PruneJSON)
+}
@@ -0,0 +1,109 @@
+// Package events normalizes the events of the transactions and of the block results.
+package events
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// attributeKeyRegex matches the decoded attribute keys, which are identifiers such as "sender" or "_contract_address".
+var attributeKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.\-]*$`)
+
+// DecodeLegacyAttributes decodes the base64 event attribute keys and values of JSON data, as produced by the Cosmos
+// SDK before v0.46 (Tendermint 0.34), so that early chain history is stored in human-readable form like newer blocks.
+//
+// Every events array of the data, e.g., "events" or "finalizeBlockEvents", is decoded when all its attribute keys are valid base64 decoding to identifiers,
+// which does not happen with plain text keys. The data is returned as is when there is nothing to decode.
+func DecodeLegacyAttributes(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte(`"attributes"`)) {
+		return data, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	if !decodeEvents(v) {
+		return data, nil
+	}
+	return json.Marshal(v)
+}
+
+// decodeEvents decodes the legacy attributes of the events arrays found in v, and returns true if any was decoded.
+func decodeEvents(v interface{}) bool {
+	decoded := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if strings.HasSuffix(strings.ToLower(key), "events") {
+				if events, ok := value.([]interface{}); ok && decodeEventList(events) {
+					decoded = true
+					continue
+				}
+			}
+			if decodeEvents(value) {
+				decoded = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if decodeEvents(value) {
+				decoded = true
+			}
+		}
+	}
+	return decoded
+}
+
+// decodeEventList decodes the attributes of the events if all their keys are base64-encoded, and returns true if so.
+func decodeEventList(events []interface{}) bool {
+	var attributes []map[string]interface{}
+	for _, e := range events {
+		event, ok := e.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		attrs, _ := event["attributes"].([]interface{})
+		for _, a := range attrs {
+			attr, ok := a.(map[string]interface{})
+			if !ok {
+				return false
+			}
+			key, _ := attr["key"].(string)
+			decodedKey, ok := decodeBase64(key)
+			if !ok || !attributeKeyRegex.MatchString(decodedKey) {
+				return false
+			}
+			attributes = append(attributes, attr)
+		}
+	}
+	if len(attributes) == 0 {
+		return false
+	}
+
+	for _, attr := range attributes {
+		attr["key"], _ = decodeBase64(attr["key"].(string))
+		if value, ok := attr["value"].(string); ok {
+			if decodedValue, ok := decodeBase64(value); ok {
+				attr["value"] = decodedValue
+			}
+		}
+	}
+	return true
+}
+
+// decodeBase64 decodes a base64 string to UTF-8 text.
+func decodeBase64(s string) (string, bool) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || !utf8.Valid(b) {
+		return "", false
+	}
+	return string(b), true
+}
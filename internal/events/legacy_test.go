@@ -0,0 +1,52 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/events"
+)
+
+func TestDecodeLegacyAttributes(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{
+			name:     "legacy transaction",
+			data:     `{"txResponse":{"height":"10","events":[{"type":"transfer","attributes":[{"key":"cmVjaXBpZW50","value":"Y29zbW9zMXJlY2lwaWVudA==","index":true},{"key":"YW1vdW50","value":"MTAwdWF0b20="}]}]}}`,
+			expected: `{"txResponse":{"height":"10","events":[{"type":"transfer","attributes":[{"key":"recipient","value":"cosmos1recipient","index":true},{"key":"amount","value":"100uatom"}]}]}}`,
+		},
+		{
+			name:     "legacy block results",
+			data:     `{"txsResults":[{"events":[{"type":"message","attributes":[{"key":"bW9kdWxl","value":"YmFuaw=="}]}]}],"beginBlockEvents":[{"type":"mint","attributes":[{"key":"YW1vdW50","value":null}]}]}`,
+			expected: `{"txsResults":[{"events":[{"type":"message","attributes":[{"key":"module","value":"bank"}]}]}],"beginBlockEvents":[{"type":"mint","attributes":[{"key":"amount","value":null}]}]}`,
+		},
+		{
+			name:     "plain text",
+			data:     `{"txResponse":{"events":[{"type":"transfer","attributes":[{"key":"recipient","value":"cosmos1recipient"},{"key":"amount","value":"100uatom"}]}]}}`,
+			expected: `{"txResponse":{"events":[{"type":"transfer","attributes":[{"key":"recipient","value":"cosmos1recipient"},{"key":"amount","value":"100uatom"}]}]}}`,
+		},
+		{
+			name:     "plain text keys decoding to binary",
+			data:     `{"events":[{"type":"coin_received","attributes":[{"key":"receiver","value":"cosmos1receiver"}]}]}`,
+			expected: `{"events":[{"type":"coin_received","attributes":[{"key":"receiver","value":"cosmos1receiver"}]}]}`,
+		},
+		{
+			name:     "no events",
+			data:     `{"error":"transaction not indexed","hash":"abc"}`,
+			expected: `{"error":"transaction not indexed","hash":"abc"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := events.DecodeLegacyAttributes([]byte(tt.data))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(decoded))
+		})
+	}
+}
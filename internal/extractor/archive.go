@@ -0,0 +1,190 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/manifest-network/yaci/internal/archive"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+)
+
+// archiveProgressInterval is the number of blocks between two progress logs of an import.
+const archiveProgressInterval = 10000
+
+// peekReader reads the records of a dataset one ahead, to group them by height.
+type peekReader[T archive.Record] struct {
+	reader *archive.Reader[T]
+	next   *T
+	done   bool
+}
+
+// peek returns the next record without consuming it, or nil at the end of the dataset.
+func (p *peekReader[T]) peek() (*T, error) {
+	if p.next != nil || p.done || p.reader == nil {
+		return p.next, nil
+	}
+	record, err := p.reader.Read()
+	if errors.Is(err, io.EOF) {
+		p.done = true
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.next = &record
+	return p.next, nil
+}
+
+// ImportArchive writes the blocks of the start to stop range, 0 for no bound, of the files exported to dir, with their
+// transactions and their results, without any endpoint. The records of the files must be ordered by height, as they are
+// exported; the transactions and block results files are optional.
+func ImportArchive(ctx context.Context, dir string, outputHandler output.OutputHandler, start, stop uint64) (archive.Summary, error) {
+	var summary archive.Summary
+
+	blocksReader, err := archive.OpenDataset[archive.Block](dir, archive.DatasetBlocks)
+	if err != nil {
+		return summary, err
+	}
+	if blocksReader == nil {
+		return summary, fmt.Errorf("no %s file in %s", archive.DatasetBlocks, dir)
+	}
+	defer blocksReader.Close()
+
+	txsReader, err := archive.OpenDataset[archive.Transaction](dir, archive.DatasetTransactions)
+	if err != nil {
+		return summary, err
+	}
+	if txsReader != nil {
+		defer txsReader.Close()
+	}
+	resultsReader, err := archive.OpenDataset[archive.BlockResults](dir, archive.DatasetBlockResults)
+	if err != nil {
+		return summary, err
+	}
+	if resultsReader != nil {
+		defer resultsReader.Close()
+	}
+	txs := &peekReader[archive.Transaction]{reader: txsReader}
+	results := &peekReader[archive.BlockResults]{reader: resultsReader}
+
+	slog.Info("Importing blocks and transactions from the archive", "dir", dir)
+	for {
+		if ctx.Err() != nil {
+			slog.Info("Processing cancelled by user")
+			return summary, ctx.Err()
+		}
+
+		record, err := blocksReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return summary, fmt.Errorf("failed to read blocks: %w", err)
+		}
+		height := record.Height
+
+		transactions, err := takeArchiveRecords(txs, height, func(t archive.Transaction) int64 { return t.Height })
+		if err != nil {
+			return summary, fmt.Errorf("failed to read the transactions of block %d: %w", height, err)
+		}
+		blockResults, err := takeArchiveRecords(results, height, func(r archive.BlockResults) int64 { return r.Height })
+		if err != nil {
+			return summary, fmt.Errorf("failed to read the results of block %d: %w", height, err)
+		}
+		if height < 0 || uint64(height) < start || (stop > 0 && uint64(height) > stop) {
+			continue
+		}
+
+		block, err := archiveBlock(record)
+		if err != nil {
+			return summary, err
+		}
+		var modelTxs []*models.Transaction
+		for _, t := range transactions {
+			modelTxs = append(modelTxs, &models.Transaction{Hash: t.Hash, Data: t.Data})
+		}
+		var modelResults *models.BlockResults
+		if len(blockResults) > 0 {
+			modelResults = &models.BlockResults{Height: block.ID, Data: blockResults[0].Data}
+		}
+
+		if err := outputHandler.WriteBlockWithTransactions(ctx, block, modelTxs, modelResults); err != nil {
+			return summary, fmt.Errorf("failed to write block %d: %w", block.ID, err)
+		}
+		summary.Blocks++
+		summary.Transactions += uint64(len(modelTxs))
+		if modelResults != nil {
+			summary.BlockResults++
+		}
+
+		if summary.Blocks%archiveProgressInterval == 0 {
+			slog.Info("Importing blocks and transactions from the archive", "height", block.ID, "blocks", summary.Blocks)
+		}
+	}
+
+	// The remaining records have no block in the archive
+	for _, p := range []interface{ peekHeight() (int64, bool, error) }{txs, results} {
+		height, ok, err := p.peekHeight()
+		if err != nil {
+			return summary, err
+		}
+		if ok {
+			return summary, fmt.Errorf("records of height %d have no block in the archive, or the files are not ordered by height", height)
+		}
+	}
+
+	slog.Info("Import from the archive completed", "blocks", summary.Blocks, "transactions", summary.Transactions)
+	return summary, nil
+}
+
+// peekHeight returns the height of the next record, and false at the end of the dataset.
+func (p *peekReader[T]) peekHeight() (int64, bool, error) {
+	next, err := p.peek()
+	if err != nil || next == nil {
+		return 0, false, err
+	}
+	switch r := any(*next).(type) {
+	case archive.Transaction:
+		return r.Height, true, nil
+	case archive.BlockResults:
+		return r.Height, true, nil
+	default:
+		return 0, true, nil
+	}
+}
+
+// takeArchiveRecords consumes the records of the dataset at height. A record below height has no block in the archive.
+func takeArchiveRecords[T archive.Record](p *peekReader[T], height int64, heightOf func(T) int64) ([]T, error) {
+	var records []T
+	for {
+		next, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if next == nil || heightOf(*next) > height {
+			return records, nil
+		}
+		if heightOf(*next) < height {
+			return nil, fmt.Errorf("record of height %d has no block in the archive, or the files are not ordered by height", heightOf(*next))
+		}
+		records = append(records, *next)
+		p.next = nil
+	}
+}
+
+// archiveBlock returns the block of an archive record, whose hashes and time are read from its data.
+func archiveBlock(record archive.Block) (*models.Block, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(record.Data, &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal block %d JSON: %w", record.Height, err)
+	}
+	block := &models.Block{ID: uint64(record.Height), Data: record.Data}
+	block.Time = parseBlockTime(data)
+	block.Hash, block.ParentHash = parseBlockHashes(data)
+	return block, nil
+}
@@ -5,10 +5,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"iter"
 	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
 
+	"github.com/manifest-network/yaci/internal/capture"
 	"github.com/manifest-network/yaci/internal/client"
 	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/events"
+	"github.com/manifest-network/yaci/internal/metrics"
 	"github.com/manifest-network/yaci/internal/models"
 	"github.com/manifest-network/yaci/internal/output"
 	"github.com/manifest-network/yaci/internal/utils"
@@ -16,36 +23,40 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
-// extractBlocksAndTransactions extracts blocks and transactions from the gRPC server.
-func extractBlocksAndTransactions(gRPCClient *client.GRPCClient, start, stop uint64, outputHandler output.OutputHandler, cfg config.ExtractConfig) error {
-	displayProgress := start != stop
-	if displayProgress {
-		slog.Info("Extracting blocks and transactions", "range", fmt.Sprintf("[%d, %d]", start, stop))
+// extractBlocksAndTransactions extracts blocks and transactions from the gRPC server, until the range is processed
+// or a stop condition of limits is met.
+func extractBlocksAndTransactions(gRPCClient *client.GRPCClient, start, stop uint64, outputHandler output.OutputHandler, cfg config.ExtractConfig, limits *stopConditions) error {
+	return extractRanges(gRPCClient, []models.HeightRange{{Start: start, Stop: stop}}, outputHandler, cfg, limits)
+}
+
+// extractRanges extracts the blocks and transactions of the ranges from the gRPC server with a single worker pool,
+// until the ranges are processed or a stop condition of limits is met.
+func extractRanges(gRPCClient *client.GRPCClient, ranges []models.HeightRange, outputHandler output.OutputHandler, cfg config.ExtractConfig, limits *stopConditions) error {
+	var total uint64
+	for _, r := range ranges {
+		total += r.Stop - r.Start + 1
+	}
+
+	displayProgress := total > 1
+	if len(ranges) > 1 {
+		slog.Info("Extracting blocks and transactions", "ranges", len(ranges), "blocks", total)
+	} else if displayProgress {
+		slog.Info("Extracting blocks and transactions", "range", fmt.Sprintf("[%d, %d]", ranges[0].Start, ranges[0].Stop))
 	} else {
-		slog.Info("Extracting blocks and transactions", "height", start)
+		slog.Info("Extracting blocks and transactions", "height", ranges[0].Start)
 	}
 	var bar *progressbar.ProgressBar
 	if displayProgress {
-		bar = progressbar.NewOptions64(
-			int64(stop-start+1),
-			progressbar.OptionClearOnFinish(),
-			progressbar.OptionSetDescription("Processing blocks..."),
-			progressbar.OptionShowCount(),
-			progressbar.OptionShowIts(),
-			progressbar.OptionSetTheme(progressbar.Theme{
-				Saucer:        "=",
-				SaucerHead:    ">",
-				SaucerPadding: " ",
-				BarStart:      "[",
-				BarEnd:        "]",
-			}),
-		)
+		bar = newProgressBar(total, os.Stdout)
 		if err := bar.RenderBlank(); err != nil {
 			return fmt.Errorf("failed to render progress bar: %w", err)
 		}
 	}
 
-	if err := processBlocks(gRPCClient, start, stop, outputHandler, cfg, bar); err != nil {
+	progress := newProgressReporter(bar, progressReportInterval)
+	err := processBlocks(gRPCClient, ranges, outputHandler, cfg, progress, limits)
+	progress.Stop()
+	if err != nil {
 		return fmt.Errorf("failed to process blocks and transactions: %w", err)
 	}
 
@@ -67,12 +78,18 @@ func processMissingBlocks(gRPCClient *client.GRPCClient, outputHandler output.Ou
 
 	if len(missingBlockIds) > 0 {
 		slog.Warn("Missing blocks detected", "count", len(missingBlockIds))
+		var lowestAvailable atomic.Uint64
 		for _, blockID := range missingBlockIds {
-			var processErr error
-			if cfg.EnableBlockResults {
-				processErr = processSingleBlockWithResultsAndRetry(gRPCClient, blockID, outputHandler, cfg.MaxRetries)
-			} else {
-				processErr = processSingleBlockWithRetry(gRPCClient, blockID, outputHandler, cfg.MaxRetries)
+			if blockID < lowestAvailable.Load() {
+				if err := recordPrunedRange(gRPCClient.Ctx, outputHandler, models.HeightRange{Start: blockID, Stop: blockID}); err != nil {
+					return err
+				}
+				continue
+			}
+
+			_, processErr := processBlock(gRPCClient, blockID, outputHandler, cfg)
+			if lowest, ok := client.LowestAvailableHeight(processErr); ok && blockID < lowest {
+				processErr = recordPrunedRanges(gRPCClient.Ctx, outputHandler, []models.HeightRange{{Start: blockID, Stop: blockID}}, lowest, &lowestAvailable)
 			}
 			if processErr != nil {
 				return fmt.Errorf("failed to process missing block %d: %w", blockID, processErr)
@@ -82,38 +99,57 @@ func processMissingBlocks(gRPCClient *client.GRPCClient, outputHandler output.Ou
 	return nil
 }
 
-// processBlocks processes blocks in parallel using goroutines.
-func processBlocks(gRPCClient *client.GRPCClient, start, stop uint64, outputHandler output.OutputHandler, cfg config.ExtractConfig, bar *progressbar.ProgressBar) error {
+// processBlocks processes the blocks of the ranges in parallel using goroutines.
+// When cfg.ForceHeights is set, the stored blocks are deleted along with their transactions before being extracted again.
+// Blocks are scheduled from the lowest height upward, or from the highest height downward when cfg.NewestFirst is set;
+// in both cases, interrupted ranges are completed by the missing block check of the next run.
+// The heights pruned from the node are recorded as such instead of failing the extraction.
+// The ranges must be sorted and must not overlap.
+func processBlocks(gRPCClient *client.GRPCClient, ranges []models.HeightRange, outputHandler output.OutputHandler, cfg config.ExtractConfig, progress *progressReporter, limits *stopConditions) error {
 	eg, ctx := errgroup.WithContext(gRPCClient.Ctx)
 	sem := make(chan struct{}, cfg.MaxConcurrency)
 
-	for height := start; height <= stop; height++ {
+	// The client is shared by all workers, which only read it
+	clientWithCtx := gRPCClient.WithContext(ctx)
+	if cfg.ForceHeights != "" {
+		// The forced heights are fetched before their stored blocks are replaced, atomically
+		clientWithCtx = gRPCClient.WithContext(output.WithBlockReplacement(ctx))
+	}
+
+	// The ranges were validated with the configuration
+	skipRanges, _ := config.ParseHeightRanges(cfg.SkipRanges)
+	sampleInclude, _ := config.ParseHeightRanges(cfg.SampleInclude)
+
+	// Lowest height available on the node, once a pruned height was requested
+	var lowestAvailable atomic.Uint64
+
+	for blockHeight := range rangeHeights(ranges, cfg.NewestFirst) {
 		if ctx.Err() != nil {
 			slog.Info("Processing cancelled by user")
 			return ctx.Err()
 		}
 
-		blockHeight := height
-		sem <- struct{}{}
-
-		clientWithCtx := &client.GRPCClient{
-			Conn:     gRPCClient.Conn,
-			Ctx:      ctx,
-			Resolver: gRPCClient.Resolver,
+		pruned := blockHeight < lowestAvailable.Load()
+		if pruned || inRanges(skipRanges, blockHeight) || !isSampled(cfg.SampleEvery, sampleInclude, blockHeight) {
+			progress.Add(1)
+			continue
 		}
+		if !limits.Next() {
+			break
+		}
+		sem <- struct{}{}
 
 		eg.Go(func() error {
 			defer func() { <-sem }()
 
-			var err error
-			if cfg.EnableBlockResults {
-				// Fetch blocks, transactions, AND block results (finalize_block_events)
-				err = processSingleBlockWithResultsAndRetry(clientWithCtx, blockHeight, outputHandler, cfg.MaxRetries)
-			} else {
-				// Standard extraction: blocks and transactions only
-				err = processSingleBlockWithRetry(clientWithCtx, blockHeight, outputHandler, cfg.MaxRetries)
+			block, err := processBlock(clientWithCtx, blockHeight, outputHandler, cfg)
+			if lowest, ok := client.LowestAvailableHeight(err); ok && blockHeight < lowest {
+				if err := recordPrunedRanges(ctx, outputHandler, ranges, lowest, &lowestAvailable); err != nil {
+					return err
+				}
+				progress.Add(1)
+				return nil
 			}
-
 			if err != nil {
 				if !errors.Is(err, context.Canceled) {
 					slog.Error("Block processing error",
@@ -126,12 +162,11 @@ func processBlocks(gRPCClient *client.GRPCClient, start, stop uint64, outputHand
 				return fmt.Errorf("failed to process block %d: %w", blockHeight, err)
 			}
 
-			if bar != nil {
-				if err := bar.Add(1); err != nil {
-					slog.Warn("Failed to update progress bar", "error", err)
-				}
+			if cfg.LiveMonitoring {
+				metrics.ObserveBlockIndexingLatency(block.Time, block.IndexedAt)
 			}
-
+			limits.Observe(block)
+			progress.Add(1)
 			return nil
 		})
 	}
@@ -142,89 +177,325 @@ func processBlocks(gRPCClient *client.GRPCClient, start, stop uint64, outputHand
 	return nil
 }
 
+// recordPrunedRanges records the heights of the ranges below lowest, the lowest height available on the node, as
+// pruned, unless they were already recorded for a lowest height at least as high.
+func recordPrunedRanges(ctx context.Context, outputHandler output.OutputHandler, ranges []models.HeightRange, lowest uint64, lowestAvailable *atomic.Uint64) error {
+	for {
+		previous := lowestAvailable.Load()
+		if previous >= lowest {
+			return nil
+		}
+		if lowestAvailable.CompareAndSwap(previous, lowest) {
+			break
+		}
+	}
+
+	slog.Warn("Requested heights were pruned from the node, recording them as pruned", "lowest_available_height", lowest)
+	for _, r := range ranges {
+		if r.Start >= lowest {
+			continue
+		}
+		if err := recordPrunedRange(ctx, outputHandler, models.HeightRange{Start: r.Start, Stop: min(r.Stop, lowest-1)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordPrunedRange records the range as pruned, if the output records the pruned ranges. Otherwise, its heights are
+// reported as missing again.
+func recordPrunedRange(ctx context.Context, outputHandler output.OutputHandler, pruned models.HeightRange) error {
+	recorder, ok := output.As[output.RangeRecorder](outputHandler)
+	if !ok {
+		return nil
+	}
+	if err := recorder.WritePrunedRange(ctx, pruned); err != nil {
+		return fmt.Errorf("failed to record pruned range [%d, %d]: %w", pruned.Start, pruned.Stop, err)
+	}
+	return nil
+}
+
+// rangeHeights iterates over the heights of the ranges in ascending order, or in descending order if reverse is set.
+func rangeHeights(ranges []models.HeightRange, reverse bool) iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for i := range ranges {
+			r := ranges[i]
+			if reverse {
+				r = ranges[len(ranges)-1-i]
+			}
+			for j := uint64(0); r.Start+j <= r.Stop; j++ {
+				height := r.Start + j
+				if reverse {
+					height = r.Stop - j
+				}
+				if !yield(height) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// inRanges returns true if height is in any of the ranges.
+func inRanges(ranges []models.HeightRange, height uint64) bool {
+	for _, r := range ranges {
+		if r.Contains(height) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSampled returns true if height is extracted when sampling every n heights, i.e., if it is a multiple of n or in
+// any of the included ranges. All heights are sampled when n is 0 or 1.
+func isSampled(n uint64, include []models.HeightRange, height uint64) bool {
+	return n <= 1 || height%n == 0 || inRanges(include, height)
+}
+
+// processBlock fetches and writes a block, its transactions and, when enabled, its block results, and returns the written block.
+// When debug capture is enabled, the gRPC payloads of the height are dumped to disk if it fails.
+func processBlock(gRPCClient *client.GRPCClient, blockHeight uint64, outputHandler output.OutputHandler, cfg config.ExtractConfig) (*models.Block, error) {
+	gRPCClient = gRPCClient.ForHeight(blockHeight)
+
+	var recorder *capture.Recorder
+	if cfg.DebugCaptureDir != "" {
+		recorder = capture.NewRecorder(blockHeight)
+		gRPCClient = gRPCClient.WithContext(capture.NewContext(gRPCClient.Ctx, recorder))
+	}
+
+	var block *models.Block
+	var err error
+	if cfg.HeaderOnly {
+		// Lightweight extraction: block headers only
+		block, err = processSingleHeaderWithRetry(gRPCClient, blockHeight, outputHandler, cfg.MaxRetries)
+	} else if cfg.EnableBlockResults {
+		// Fetch blocks, transactions, AND block results (finalize_block_events)
+		block, err = processSingleBlockWithResultsAndRetry(gRPCClient, blockHeight, outputHandler, cfg.MaxRetries, cfg.CometBFTRPC, cfg.StoreRawProtobuf)
+	} else {
+		// Standard extraction: blocks and transactions only
+		block, err = processSingleBlockWithRetry(gRPCClient, blockHeight, outputHandler, cfg.MaxRetries, cfg.StoreRawProtobuf)
+	}
+
+	if err != nil && recorder != nil && !errors.Is(err, context.Canceled) {
+		if path, writeErr := recorder.WriteFile(cfg.DebugCaptureDir); writeErr != nil {
+			slog.Warn("Failed to write debug capture", "height", blockHeight, "error", writeErr)
+		} else {
+			slog.Info("Wrote debug capture of failing height", "height", blockHeight, "path", path)
+		}
+	}
+
+	return block, err
+}
+
 // processSingleBlockWithRetry fetches a block and its transactions from the gRPC server with retries.
-// It unmarshals the block data and writes it to the output handler.
-func processSingleBlockWithRetry(gRPCClient *client.GRPCClient, blockHeight uint64, outputHandler output.OutputHandler, maxRetries uint) error {
+// It unmarshals the block data and writes it to the output handler, along with the raw responses when keepRaw is set.
+func processSingleBlockWithRetry(gRPCClient *client.GRPCClient, blockHeight uint64, outputHandler output.OutputHandler, maxRetries uint, keepRaw bool) (*models.Block, error) {
+	block, transactions, err := fetchBlockWithTransactions(gRPCClient, blockHeight, maxRetries, keepRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write block with transactions to the output handler
+	err = outputHandler.WriteBlockWithTransactions(gRPCClient.Ctx, block, transactions, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write block with transactions: %w", err)
+	}
+
+	return block, nil
+}
+
+// processSingleHeaderWithRetry fetches the header of a block from the gRPC server with retries, and writes it to the
+// output handler as a block without transactions. The stored data only keeps the block ID and the header.
+func processSingleHeaderWithRetry(gRPCClient *client.GRPCClient, blockHeight uint64, outputHandler output.OutputHandler, maxRetries uint) (*models.Block, error) {
+	params := []byte(fmt.Sprintf(`{"height": %d}`, blockHeight))
+	resp, err := utils.GetGRPCResponse(gRPCClient, gRPCClient.Methods.BlockByHeight, maxRetries, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block header: %w", err)
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(resp, &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal block JSON: %w", err)
+	}
+	useBlockField(data, gRPCClient.Methods)
+
+	blockData, _ := data["block"].(map[string]interface{})
+	headerData := map[string]interface{}{
+		"blockId": data["blockId"],
+		"block":   map[string]interface{}{"header": blockData["header"]},
+	}
+	headerJsonBytes, err := json.Marshal(headerData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal block header: %w", err)
+	}
+
+	block := &models.Block{
+		ID:   blockHeight,
+		Data: headerJsonBytes,
+		Time: parseBlockTime(headerData),
+	}
+	block.Hash, block.ParentHash = parseBlockHashes(headerData)
+
+	if err := outputHandler.WriteBlockWithTransactions(gRPCClient.Ctx, block, nil, nil); err != nil {
+		return nil, fmt.Errorf("failed to write block header: %w", err)
+	}
+
+	return block, nil
+}
+
+// FetchBlock fetches a block, its transactions and, when withResults is set, its block results from the gRPC server.
+// Unlike the extraction, a failure to fetch the block results is returned as an error.
+func FetchBlock(gRPCClient *client.GRPCClient, blockHeight uint64, maxRetries uint, withResults bool) (*models.Block, []*models.Transaction, *models.BlockResults, error) {
+	gRPCClient = gRPCClient.ForHeight(blockHeight)
+	block, transactions, err := fetchBlockWithTransactions(gRPCClient, blockHeight, maxRetries, false)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if !withResults {
+		return block, transactions, nil, nil
+	}
+
+	blockResults, err := fetchBlockResults(gRPCClient, blockHeight, maxRetries, false)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return block, transactions, blockResults, nil
+}
+
+// fetchBlockWithTransactions fetches a block and its transactions from the gRPC server with retries, along with their
+// raw responses when keepRaw is set.
+func fetchBlockWithTransactions(gRPCClient *client.GRPCClient, blockHeight uint64, maxRetries uint, keepRaw bool) (*models.Block, []*models.Transaction, error) {
 	blockJsonParams := []byte(fmt.Sprintf(`{"height": %d}`, blockHeight))
 
 	// Get block data with retries
-	blockJsonBytes, err := utils.GetGRPCResponse(
+	blockJsonBytes, blockRaw, err := utils.GetGRPCResponseWithRaw(
 		gRPCClient,
-		blockMethodFullName,
+		gRPCClient.Methods.Block,
 		maxRetries,
 		blockJsonParams,
+		keepRaw,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to get block data: %w", err)
+		return nil, nil, fmt.Errorf("failed to get block data: %w", err)
 	}
 
 	// Create block model
 	block := &models.Block{
 		ID:   blockHeight,
 		Data: blockJsonBytes,
+		Raw:  blockRaw,
 	}
 
 	var data map[string]interface{}
 	if err := json.Unmarshal(blockJsonBytes, &data); err != nil {
-		return fmt.Errorf("failed to unmarshal block JSON: %w", err)
+		return nil, nil, fmt.Errorf("failed to unmarshal block JSON: %w", err)
 	}
 
-	transactions, err := extractTransactions(gRPCClient, data, maxRetries)
+	block.Time = parseBlockTime(data)
+	block.Hash, block.ParentHash = parseBlockHashes(data)
+
+	transactions, err := extractTransactions(gRPCClient, data, maxRetries, keepRaw)
 	if err != nil {
-		return fmt.Errorf("failed to extract transactions from block: %w", err)
+		return nil, nil, fmt.Errorf("failed to extract transactions from block: %w", err)
 	}
 
-	// Write block with transactions to the output handler
-	err = outputHandler.WriteBlockWithTransactions(gRPCClient.Ctx, block, transactions)
+	return block, transactions, nil
+}
+
+// useBlockField makes the block of a GetBlockByHeight response available in its block field, for the nodes that only
+// return it in the field of their variant, e.g., sdkBlock.
+func useBlockField(data map[string]interface{}, methods client.Methods) {
+	if _, ok := data["block"]; ok {
+		return
+	}
+	if block, ok := data[methods.BlockJSONField()]; ok {
+		data["block"] = block
+	}
+}
+
+// parseBlockTime returns the time of the block header, or the zero time if it is missing or invalid.
+func parseBlockTime(data map[string]interface{}) time.Time {
+	blockData, _ := data["block"].(map[string]interface{})
+	header, _ := blockData["header"].(map[string]interface{})
+	blockTime, _ := header["time"].(string)
+
+	t, err := time.Parse(time.RFC3339Nano, blockTime)
 	if err != nil {
-		return fmt.Errorf("failed to write block with transactions: %w", err)
+		return time.Time{}
 	}
+	return t
+}
 
-	return nil
+// parseBlockHashes returns the hash of the block and the hash of the previous block, or empty strings if missing.
+func parseBlockHashes(data map[string]interface{}) (string, string) {
+	blockID, _ := data["blockId"].(map[string]interface{})
+	hash, _ := blockID["hash"].(string)
+
+	blockData, _ := data["block"].(map[string]interface{})
+	header, _ := blockData["header"].(map[string]interface{})
+	lastBlockID, _ := header["lastBlockId"].(map[string]interface{})
+	parentHash, _ := lastBlockID["hash"].(string)
+
+	return hash, parentHash
 }
 
 // fetchBlockResults fetches block results (finalize_block_events) from the gRPC server.
 // This requires republicd with the GetBlockResults gRPC endpoint (cosmos-sdk feat/grpc-block-results-main).
 // Block results contain consensus-level events: slashing, jailing, validator updates.
-func fetchBlockResults(gRPCClient *client.GRPCClient, blockHeight uint64, maxRetries uint) (*models.BlockResults, error) {
+// The raw response is kept when keepRaw is set.
+func fetchBlockResults(gRPCClient *client.GRPCClient, blockHeight uint64, maxRetries uint, keepRaw bool) (*models.BlockResults, error) {
 	blockResultsParams := []byte(fmt.Sprintf(`{"height": %d}`, blockHeight))
 
-	blockResultsBytes, err := utils.GetGRPCResponse(
+	blockResultsBytes, blockResultsRaw, err := utils.GetGRPCResponseWithRaw(
 		gRPCClient,
-		blockResultsMethodFullName,
+		gRPCClient.Methods.BlockResults,
 		maxRetries,
 		blockResultsParams,
+		keepRaw,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get block results: %w", err)
 	}
+	blockResultsBytes, err = events.DecodeLegacyAttributes(blockResultsBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the events of the block results: %w", err)
+	}
 
 	return &models.BlockResults{
 		Height: blockHeight,
 		Data:   blockResultsBytes,
+		Raw:    blockResultsRaw,
 	}, nil
 }
 
 // processSingleBlockWithResultsAndRetry fetches a block, its transactions, and block results.
 // Block results are fetched via the GetBlockResults gRPC endpoint which provides
 // finalize_block_events (slashing, jailing, validator updates).
-func processSingleBlockWithResultsAndRetry(gRPCClient *client.GRPCClient, blockHeight uint64, outputHandler output.OutputHandler, maxRetries uint) error {
-	// First, process the block and transactions normally
-	if err := processSingleBlockWithRetry(gRPCClient, blockHeight, outputHandler, maxRetries); err != nil {
-		return err
+// When the node lacks this endpoint and rpcURL is set, they are fetched from the CometBFT RPC instead.
+// The block results are written together with the block so that they are never visible without it.
+// The raw responses are written along when keepRaw is set, except for the block results fetched from the CometBFT RPC.
+func processSingleBlockWithResultsAndRetry(gRPCClient *client.GRPCClient, blockHeight uint64, outputHandler output.OutputHandler, maxRetries uint, rpcURL string, keepRaw bool) (*models.Block, error) {
+	block, transactions, err := fetchBlockWithTransactions(gRPCClient, blockHeight, maxRetries, keepRaw)
+	if err != nil {
+		return nil, err
 	}
 
-	// Then fetch and write block results
-	blockResults, err := fetchBlockResults(gRPCClient, blockHeight, maxRetries)
+	blockResults, err := fetchBlockResults(gRPCClient, blockHeight, maxRetries, keepRaw)
+	if err != nil && rpcURL != "" {
+		slog.Debug("Failed to fetch block results via gRPC, falling back to the CometBFT RPC", "height", blockHeight, "error", err)
+		blockResults, err = fetchBlockResultsFromRPC(gRPCClient.Ctx, rpcURL, blockHeight, maxRetries)
+	}
 	if err != nil {
 		// Log warning but don't fail - node might not support GetBlockResults
 		slog.Warn("Failed to fetch block results (node may not support GetBlockResults)", "height", blockHeight, "error", err)
-		return nil
+		blockResults = nil
 	}
 
-	if err := outputHandler.WriteBlockResults(gRPCClient.Ctx, blockResults); err != nil {
-		return fmt.Errorf("failed to write block results: %w", err)
+	if err := outputHandler.WriteBlockWithTransactions(gRPCClient.Ctx, block, transactions, blockResults); err != nil {
+		return nil, fmt.Errorf("failed to write block with transactions and results: %w", err)
 	}
 
-	return nil
+	return block, nil
 }
@@ -0,0 +1,112 @@
+package extractor_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/manifest-network/yaci/internal/extractor"
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+func TestRangeHeights(t *testing.T) {
+	tests := []struct {
+		name     string
+		ranges   []models.HeightRange
+		reverse  bool
+		expected []uint64
+	}{
+		{
+			name:     "single range",
+			ranges:   []models.HeightRange{{Start: 5, Stop: 8}},
+			expected: []uint64{5, 6, 7, 8},
+		},
+		{
+			name:     "single range descending",
+			ranges:   []models.HeightRange{{Start: 5, Stop: 8}},
+			reverse:  true,
+			expected: []uint64{8, 7, 6, 5},
+		},
+		{
+			name:     "multiple ranges",
+			ranges:   []models.HeightRange{{Start: 1, Stop: 3}, {Start: 10, Stop: 11}, {Start: 20, Stop: 20}},
+			expected: []uint64{1, 2, 3, 10, 11, 20},
+		},
+		{
+			name:     "multiple ranges descending",
+			ranges:   []models.HeightRange{{Start: 1, Stop: 3}, {Start: 10, Stop: 11}, {Start: 20, Stop: 20}},
+			reverse:  true,
+			expected: []uint64{20, 11, 10, 3, 2, 1},
+		},
+		{
+			name:     "single height",
+			ranges:   []models.HeightRange{{Start: 7, Stop: 7}},
+			expected: []uint64{7},
+		},
+		{
+			name:     "single height descending",
+			ranges:   []models.HeightRange{{Start: 7, Stop: 7}},
+			reverse:  true,
+			expected: []uint64{7},
+		},
+		{
+			name:     "single height from genesis descending",
+			ranges:   []models.HeightRange{{Start: 0, Stop: 0}, {Start: 1, Stop: 2}},
+			reverse:  true,
+			expected: []uint64{2, 1, 0},
+		},
+		{
+			name: "no range",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, slices.Collect(extractor.RangeHeights(tt.ranges, tt.reverse)))
+		})
+	}
+}
+
+func TestRangeHeightsStop(t *testing.T) {
+	var heights []uint64
+	for height := range extractor.RangeHeights([]models.HeightRange{{Start: 1, Stop: 3}, {Start: 10, Stop: 12}}, true) {
+		heights = append(heights, height)
+		if height == 11 {
+			break
+		}
+	}
+	assert.Equal(t, []uint64{12, 11}, heights)
+}
+
+func TestIsSampled(t *testing.T) {
+	include := []models.HeightRange{{Start: 15, Stop: 17}, {Start: 23, Stop: 23}}
+
+	tests := []struct {
+		name     string
+		n        uint64
+		include  []models.HeightRange
+		height   uint64
+		expected bool
+	}{
+		{name: "disabled", n: 0, height: 7, expected: true},
+		{name: "every height", n: 1, height: 7, expected: true},
+		{name: "multiple", n: 10, height: 20, expected: true},
+		{name: "not a multiple", n: 10, height: 21, expected: false},
+		{name: "genesis", n: 10, height: 0, expected: true},
+		{name: "included", n: 10, include: include, height: 16, expected: true},
+		{name: "start of an included range", n: 10, include: include, height: 15, expected: true},
+		{name: "stop of an included range", n: 10, include: include, height: 17, expected: true},
+		{name: "before an included range", n: 10, include: include, height: 14, expected: false},
+		{name: "after an included range", n: 10, include: include, height: 18, expected: false},
+		{name: "included single height", n: 10, include: include, height: 23, expected: true},
+		{name: "multiple outside the included ranges", n: 10, include: include, height: 30, expected: true},
+		{name: "disabled outside the included ranges", n: 0, include: include, height: 31, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, extractor.IsSampled(tt.n, tt.include, tt.height))
+		})
+	}
+}
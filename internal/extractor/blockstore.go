@@ -0,0 +1,170 @@
+package extractor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/manifest-network/yaci/internal/blockstore"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+)
+
+// blockstoreProgressInterval is the number of blocks between two progress logs of an offline extraction.
+const blockstoreProgressInterval = 10000
+
+// ExtractBlockstore extracts the blocks and the transactions of the start to stop range from the block store of a
+// stopped node, without any endpoint. A zero start resumes from the latest stored block, or starts from the lowest
+// height of the block store; a zero stop extracts up to the highest height of the block store.
+//
+// The transactions are not decoded, since the protobuf descriptors of the chain are only available from a running
+// node: their results are read from the transaction index when the node indexed them, and they are stored with error
+// metadata otherwise, like the transactions that could not be fetched from a node.
+func ExtractBlockstore(ctx context.Context, store *blockstore.Store, outputHandler output.OutputHandler, start, stop uint64) error {
+	base, height, err := store.Range()
+	if err != nil {
+		return err
+	}
+
+	if start == 0 {
+		start = base
+		latestLocalBlock, err := outputHandler.GetLatestBlock(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get the latest block: %w", err)
+		}
+		if latestLocalBlock != nil {
+			start = max(base, latestLocalBlock.ID+1)
+		}
+	}
+	if stop == 0 {
+		stop = height
+	}
+	if start < base || stop > height {
+		return fmt.Errorf("range [%d, %d] is outside of the block store range [%d, %d]", start, stop, base, height)
+	}
+	if start > stop {
+		slog.Info("The output is up to date with the block store", "height", height)
+		return nil
+	}
+	if !store.HasTxIndex() {
+		slog.Warn("No transaction index in the data directory, the transaction results will not be extracted")
+	}
+
+	slog.Info("Extracting blocks and transactions from the block store", "range", fmt.Sprintf("[%d, %d]", start, stop))
+	for blockHeight := start; blockHeight <= stop; blockHeight++ {
+		if ctx.Err() != nil {
+			slog.Info("Processing cancelled by user")
+			return ctx.Err()
+		}
+
+		block, transactions, err := readBlockstoreBlock(store, blockHeight)
+		if err != nil {
+			return err
+		}
+		if err := outputHandler.WriteBlockWithTransactions(ctx, block, transactions, nil); err != nil {
+			return fmt.Errorf("failed to write block %d: %w", blockHeight, err)
+		}
+
+		if (blockHeight-start+1)%blockstoreProgressInterval == 0 {
+			slog.Info("Extracting blocks and transactions from the block store", "height", blockHeight, "stop", stop)
+		}
+	}
+	slog.Info("Extraction from the block store completed", "stop", stop)
+
+	return nil
+}
+
+// readBlockstoreBlock reads the block at the given height and its transactions from the block store.
+func readBlockstoreBlock(store *blockstore.Store, blockHeight uint64) (*models.Block, []*models.Transaction, error) {
+	data, txs, err := store.Block(blockHeight)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	block := &models.Block{
+		ID:   blockHeight,
+		Data: data,
+	}
+
+	var blockData map[string]interface{}
+	if err := json.Unmarshal(data, &blockData); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal block JSON: %w", err)
+	}
+	block.Time = parseBlockTime(blockData)
+	block.Hash, block.ParentHash = parseBlockHashes(blockData)
+
+	var transactions []*models.Transaction
+	for _, tx := range txs {
+		hash := sha256.Sum256(tx)
+		hashStr := hex.EncodeToString(hash[:])
+
+		result, err := store.TxResult(hash[:])
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read the result of transaction %s: %w", hashStr, err)
+		}
+
+		var txJSON []byte
+		if result == nil {
+			txJSON = []byte(fmt.Sprintf(`{"error": "transaction not indexed", "hash": "%s"}`, hashStr))
+		} else if txJSON, err = txResponseFromResult(hashStr, result, block); err != nil {
+			return nil, nil, err
+		}
+
+		transactions = append(transactions, &models.Transaction{
+			Hash: hashStr,
+			Data: txJSON,
+		})
+	}
+
+	return block, transactions, nil
+}
+
+// indexedTxResult is the JSON of an indexed CometBFT transaction result.
+type indexedTxResult struct {
+	Height string `json:"height"`
+	Result struct {
+		Code      uint32          `json:"code"`
+		Data      string          `json:"data"`
+		Log       string          `json:"log"`
+		Info      string          `json:"info"`
+		GasWanted string          `json:"gasWanted"`
+		GasUsed   string          `json:"gasUsed"`
+		Events    json.RawMessage `json:"events"`
+		Codespace string          `json:"codespace"`
+	} `json:"result"`
+}
+
+// txResponseFromResult converts an indexed transaction result to the txResponse of the GetTx responses, without the
+// decoded transaction.
+func txResponseFromResult(hash string, result []byte, block *models.Block) ([]byte, error) {
+	var r indexedTxResult
+	if err := json.Unmarshal(result, &r); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the result of transaction %s: %w", hash, err)
+	}
+
+	txResponse := map[string]interface{}{
+		"height":    r.Height,
+		"txhash":    strings.ToUpper(hash),
+		"codespace": r.Result.Codespace,
+		"code":      r.Result.Code,
+		"data":      r.Result.Data,
+		"rawLog":    r.Result.Log,
+		"info":      r.Result.Info,
+		"gasWanted": r.Result.GasWanted,
+		"gasUsed":   r.Result.GasUsed,
+		"events":    r.Result.Events,
+	}
+	if !block.Time.IsZero() {
+		txResponse["timestamp"] = block.Time.UTC().Format("2006-01-02T15:04:05Z")
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"txResponse": txResponse})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal transaction %s: %w", hash, err)
+	}
+	return data, nil
+}
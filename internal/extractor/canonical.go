@@ -0,0 +1,192 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/manifest-network/yaci/internal/canonicaljson"
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+)
+
+// canonicalJSONHandler rewrites the JSON payloads into their canonical form before they are written to the wrapped
+// output handler, so that the payloads of the re-extracted data are equal byte for byte. It implements the optional
+// interfaces of the payloads it rewrites, whose writes fail if the wrapped output handler does not support them.
+type canonicalJSONHandler struct {
+	output.OutputHandler
+}
+
+// A missing method would let the writes of its interface bypass the wrapper
+var (
+	_ output.GovWriter         = (*canonicalJSONHandler)(nil)
+	_ output.UpgradePlanWriter = (*canonicalJSONHandler)(nil)
+	_ output.SnapshotWriter    = (*canonicalJSONHandler)(nil)
+)
+
+func (h *canonicalJSONHandler) Unwrap() output.OutputHandler {
+	return h.OutputHandler
+}
+
+func (h *canonicalJSONHandler) WriteBlockWithTransactions(ctx context.Context, block *models.Block, transactions []*models.Transaction, blockResults *models.BlockResults) error {
+	if err := canonicalize(&block.Data, "block %d", block.ID); err != nil {
+		return err
+	}
+	if err := canonicalizeTransactions(transactions); err != nil {
+		return err
+	}
+	if blockResults != nil {
+		if err := canonicalize(&blockResults.Data, "results of block %d", blockResults.Height); err != nil {
+			return err
+		}
+	}
+	return h.OutputHandler.WriteBlockWithTransactions(ctx, block, transactions, blockResults)
+}
+
+func (h *canonicalJSONHandler) WriteTransactions(ctx context.Context, transactions []*models.Transaction) error {
+	if err := canonicalizeTransactions(transactions); err != nil {
+		return err
+	}
+	return h.OutputHandler.WriteTransactions(ctx, transactions)
+}
+
+func (h *canonicalJSONHandler) WriteGovProposals(ctx context.Context, proposals []*models.GovProposal) error {
+	for _, p := range proposals {
+		for _, data := range []*[]byte{&p.Data, &p.Tally, &p.Deposits} {
+			if err := canonicalize(data, "proposal %d", p.ID); err != nil {
+				return err
+			}
+		}
+	}
+	w, err := output.Require[output.GovWriter](h.OutputHandler, "governance proposals")
+	if err != nil {
+		return err
+	}
+	return w.WriteGovProposals(ctx, proposals)
+}
+
+func (h *canonicalJSONHandler) WriteSupplySnapshot(ctx context.Context, snapshot *models.SupplySnapshot) error {
+	for _, m := range snapshot.Metadata {
+		if err := canonicalize(&m.Data, "metadata of denom %s", m.Base); err != nil {
+			return err
+		}
+	}
+	w, err := h.snapshotWriter()
+	if err != nil {
+		return err
+	}
+	return w.WriteSupplySnapshot(ctx, snapshot)
+}
+
+func (h *canonicalJSONHandler) WriteExtraQueryResults(ctx context.Context, results []*models.ExtraQueryResult) error {
+	for _, r := range results {
+		if err := canonicalize(&r.Data, "result of query %s", r.Name); err != nil {
+			return err
+		}
+	}
+	w, err := h.snapshotWriter()
+	if err != nil {
+		return err
+	}
+	return w.WriteExtraQueryResults(ctx, results)
+}
+
+func (h *canonicalJSONHandler) WriteIBCState(ctx context.Context, state *models.IBCState) error {
+	for _, c := range state.Clients {
+		if err := canonicalize(&c.Data, "IBC client %s", c.ClientID); err != nil {
+			return err
+		}
+	}
+	for _, c := range state.Connections {
+		if err := canonicalize(&c.Data, "IBC connection %s", c.ConnectionID); err != nil {
+			return err
+		}
+	}
+	for _, c := range state.Channels {
+		if err := canonicalize(&c.Data, "IBC channel %s/%s", c.PortID, c.ChannelID); err != nil {
+			return err
+		}
+	}
+	w, err := h.snapshotWriter()
+	if err != nil {
+		return err
+	}
+	return w.WriteIBCState(ctx, state)
+}
+
+func (h *canonicalJSONHandler) WriteValidators(ctx context.Context, set *models.ValidatorSet) error {
+	for _, v := range set.Validators {
+		if err := canonicalize(&v.Data, "validator %s", v.OperatorAddress); err != nil {
+			return err
+		}
+	}
+	w, err := h.snapshotWriter()
+	if err != nil {
+		return err
+	}
+	return w.WriteValidators(ctx, set)
+}
+
+func (h *canonicalJSONHandler) WriteUpgradePlan(ctx context.Context, plan *models.UpgradePlan) error {
+	if err := canonicalize(&plan.Data, "upgrade plan %s", plan.Name); err != nil {
+		return err
+	}
+	w, err := output.Require[output.UpgradePlanWriter](h.OutputHandler, "upgrade plans")
+	if err != nil {
+		return err
+	}
+	return w.WriteUpgradePlan(ctx, plan)
+}
+
+// The delegation and balance snapshots have no JSON payload, they are only forwarded so that the wrapped snapshot
+// writer is reached through the wrapper.
+func (h *canonicalJSONHandler) WriteDelegationSnapshot(ctx context.Context, snapshot *models.DelegationSnapshot) error {
+	w, err := h.snapshotWriter()
+	if err != nil {
+		return err
+	}
+	return w.WriteDelegationSnapshot(ctx, snapshot)
+}
+
+func (h *canonicalJSONHandler) WriteBalanceSnapshot(ctx context.Context, snapshot *models.BalanceSnapshot) error {
+	w, err := h.snapshotWriter()
+	if err != nil {
+		return err
+	}
+	return w.WriteBalanceSnapshot(ctx, snapshot)
+}
+
+func (h *canonicalJSONHandler) snapshotWriter() (output.SnapshotWriter, error) {
+	return output.Require[output.SnapshotWriter](h.OutputHandler, "snapshots")
+}
+
+// withCanonicalJSON wraps the output handler to write the JSON payloads in their canonical form, if enabled by the
+// configuration.
+func withCanonicalJSON(outputHandler output.OutputHandler, cfg config.ExtractConfig) output.OutputHandler {
+	if !cfg.CanonicalJSON {
+		return outputHandler
+	}
+	return &canonicalJSONHandler{OutputHandler: outputHandler}
+}
+
+func canonicalizeTransactions(transactions []*models.Transaction) error {
+	for _, tx := range transactions {
+		if err := canonicalize(&tx.Data, "transaction %s", tx.Hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// canonicalize replaces the JSON payload with its canonical form. The payloads that were not queried are left nil.
+func canonicalize(data *[]byte, format string, args ...interface{}) error {
+	if *data == nil {
+		return nil
+	}
+	canonical, err := canonicaljson.Canonicalize(*data)
+	if err != nil {
+		return fmt.Errorf("failed to canonicalize the JSON of %s: %w", fmt.Sprintf(format, args...), err)
+	}
+	*data = canonical
+	return nil
+}
@@ -0,0 +1,48 @@
+package extractor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/extractor"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/redact"
+)
+
+// validatorRecorder records the validators written to it.
+type validatorRecorder struct {
+	output.OutputHandler
+	output.SnapshotWriter
+	set *models.ValidatorSet
+}
+
+func (r *validatorRecorder) WriteValidators(_ context.Context, set *models.ValidatorSet) error {
+	r.set = set
+	return nil
+}
+
+func TestCanonicalJSONOptionalWriters(t *testing.T) {
+	recorder := &validatorRecorder{}
+	cfg := config.ExtractConfig{CanonicalJSON: true, RedactMemoPatterns: []string{`@`}, MemoRedaction: redact.ModeHash}
+	handler := extractor.WithMemoRedaction(extractor.WithCanonicalJSON(recorder, cfg), cfg)
+
+	// The snapshot writer is reached through the canonical JSON wrapper, not around it
+	writer, ok := output.As[output.SnapshotWriter](handler)
+	require.True(t, ok)
+	set := &models.ValidatorSet{Validators: []*models.Validator{{OperatorAddress: "val", Data: []byte(`{"b": 1, "a": 2}`)}}}
+	require.NoError(t, writer.WriteValidators(context.Background(), set))
+	require.NotNil(t, recorder.set)
+	assert.Equal(t, `{"a":2,"b":1}`, string(recorder.set.Validators[0].Data))
+
+	// The wrapped output handler does not record the governance proposals
+	_, ok = output.As[output.GovWriter](extractor.WithMemoRedaction(recorder, cfg))
+	assert.False(t, ok)
+	gov, ok := output.As[output.GovWriter](handler)
+	require.True(t, ok)
+	assert.ErrorContains(t, gov.WriteGovProposals(context.Background(), nil), "the output does not support governance proposals")
+}
@@ -0,0 +1,18 @@
+package extractor
+
+// The unexported helpers of the package, exported to its external tests.
+var (
+	RangeHeights        = rangeHeights
+	IsSampled           = isSampled
+	NewAdaptivePoller   = newAdaptivePoller
+	NewStopConditions   = newStopConditions
+	NewProgressBar      = newProgressBar
+	NewProgressReporter = newProgressReporter
+	WithCanonicalJSON   = withCanonicalJSON
+	WithMemoRedaction   = withMemoRedaction
+)
+
+const (
+	MinPollInterval = minPollInterval
+	MaxPollInterval = maxPollInterval
+)
@@ -3,21 +3,44 @@ package extractor
 import (
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/manifest-network/yaci/internal/client"
 	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/models"
 	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/snapshot"
 	"github.com/manifest-network/yaci/internal/utils"
 )
 
-const (
-	blockMethodFullName        = "cosmos.tx.v1beta1.Service.GetBlockWithTxs"
-	txMethodFullName           = "cosmos.tx.v1beta1.Service.GetTx"
-	blockResultsMethodFullName = "cosmos.base.tendermint.v1beta1.Service.GetBlockResults"
-)
-
 // Extract extracts blocks and transactions from a gRPC server.
 func Extract(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, config config.ExtractConfig) error {
+	outputHandler = withCanonicalJSON(outputHandler, config)
+	outputHandler = withJSONPruning(outputHandler, config)
+	outputHandler = withMemoRedaction(outputHandler, config)
+	outputHandler = withVoteExtensionDecoding(gRPCClient, outputHandler, config)
+	return extract(gRPCClient, outputHandler, config)
+}
+
+// extract dispatches the extraction of the configuration. The leader election and the schedule run it again for each
+// term and each scheduled extraction, so that the output handler wrapped by Extract is only wrapped once.
+func extract(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, config config.ExtractConfig) error {
+	if config.LeaderElection {
+		return extractAsLeader(gRPCClient, outputHandler, config)
+	}
+
+	if config.Schedule != "" {
+		return extractOnSchedule(gRPCClient, outputHandler, config)
+	}
+
+	if config.TxEventsQuery != "" {
+		return extractTxsByEvent(gRPCClient, outputHandler, config)
+	}
+
+	if config.Ranges != "" || config.ForceHeights != "" {
+		return extractRangeList(gRPCClient, outputHandler, config)
+	}
+
 	// Check if the missing block check should be skipped before setting the block range
 	skipMissingBlockCheck := shouldSkipMissingBlockCheck(config)
 
@@ -25,6 +48,11 @@ func Extract(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler,
 		return err
 	}
 
+	// Record the skipped ranges before looking for missing blocks, so that they are not reported
+	if err := recordSkippedRanges(gRPCClient, outputHandler, config); err != nil {
+		return err
+	}
+
 	if !skipMissingBlockCheck {
 		if err := processMissingBlocks(gRPCClient, outputHandler, config); err != nil {
 			return err
@@ -35,28 +63,113 @@ func Extract(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler,
 		slog.Info("Block results fetching enabled (finalize_block_events)")
 	}
 
-	if config.LiveMonitoring {
+	scheduler := newScheduler(config)
+	limits := newStopConditions(config, time.Now())
+
+	if config.Continuous {
+		if config.BlockStart <= config.BlockStop {
+			slog.Info("Starting continuous extraction", "start", config.BlockStart, "tip", config.BlockStop)
+			err := extractBlocksAndTransactions(gRPCClient, config.BlockStart, config.BlockStop, outputHandler, config, limits)
+			if err != nil {
+				return fmt.Errorf("failed to backfill blocks and transactions: %w", err)
+			}
+			if limits.Reached() {
+				return nil
+			}
+			scheduler.RunRange(gRPCClient, outputHandler, config.BlockStart, config.BlockStop)
+		}
+
+		slog.Info("Backfill completed, switching to live extraction", "height", config.BlockStop, "block_time", config.BlockTime)
+		config.LiveMonitoring = true
+		err := extractLiveBlocksAndTransactions(gRPCClient, config.BlockStop+1, outputHandler, config, scheduler, limits)
+		if err != nil {
+			return fmt.Errorf("failed to process live blocks and transactions: %w", err)
+		}
+	} else if config.LiveMonitoring {
 		slog.Info("Starting live extraction", "block_time", config.BlockTime)
-		err := extractLiveBlocksAndTransactions(gRPCClient, config.BlockStart, outputHandler, config)
+		err := extractLiveBlocksAndTransactions(gRPCClient, config.BlockStart, outputHandler, config, scheduler, limits)
 		if err != nil {
 			return fmt.Errorf("failed to process live blocks and transactions: %w", err)
 		}
+	} else if config.ShardSize > 0 {
+		err := extractShards(gRPCClient, config.BlockStart, config.BlockStop, outputHandler, config, scheduler)
+		if err != nil {
+			return fmt.Errorf("failed to process shards: %w", err)
+		}
 	} else {
 		slog.Info("Starting extraction", "start", config.BlockStart, "stop", config.BlockStop)
-		err := extractBlocksAndTransactions(gRPCClient, config.BlockStart, config.BlockStop, outputHandler, config)
+		err := extractBlocksAndTransactions(gRPCClient, config.BlockStart, config.BlockStop, outputHandler, config, limits)
 		if err != nil {
 			return fmt.Errorf("failed to process blocks and transactions: %w", err)
 		}
+		if limits.Reached() {
+			return nil
+		}
+		scheduler.RunRange(gRPCClient, outputHandler, config.BlockStart, config.BlockStop)
+	}
+
+	return nil
+}
+
+// extractRangeList extracts the ranges of cfg.Ranges, or re-extracts the ranges of cfg.ForceHeights, with a single
+// worker pool.
+func extractRangeList(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, cfg config.ExtractConfig) error {
+	list := cfg.Ranges
+	if cfg.ForceHeights != "" {
+		list = cfg.ForceHeights
+		slog.Info("Forcing the re-extraction of heights", "heights", cfg.ForceHeights)
 	}
 
+	// The ranges were validated with the configuration
+	ranges, _ := config.ParseHeightRanges(list)
+	ranges = config.MergeHeightRanges(ranges)
+
+	if err := recordSkippedRanges(gRPCClient, outputHandler, cfg); err != nil {
+		return err
+	}
+
+	scheduler := newScheduler(cfg)
+	limits := newStopConditions(cfg, time.Now())
+	if err := extractRanges(gRPCClient, ranges, outputHandler, cfg, limits); err != nil {
+		return fmt.Errorf("failed to process blocks and transactions: %w", err)
+	}
+	if limits.Reached() {
+		return nil
+	}
+	for _, r := range ranges {
+		scheduler.RunRange(gRPCClient, outputHandler, r.Start, r.Stop)
+	}
 	return nil
 }
 
+// newScheduler creates the snapshot scheduler with the jobs enabled in the configuration.
+func newScheduler(cfg config.ExtractConfig) *snapshot.Scheduler {
+	scheduler := snapshot.NewScheduler()
+	scheduler.Add(snapshot.NewGovProposalsJob(cfg.MaxRetries), cfg.GovProposalsInterval, false)
+	scheduler.Add(snapshot.NewIBCStateJob(cfg.MaxRetries), cfg.IBCStateInterval, false)
+	scheduler.Add(snapshot.NewValidatorsJob(cfg.MaxRetries), cfg.ValidatorsInterval, false)
+	scheduler.Add(snapshot.NewSupplyJob(cfg.MaxRetries), cfg.SupplyInterval, false)
+	scheduler.Add(snapshot.NewDelegationSnapshotJob(cfg.MaxRetries), cfg.DelegationSnapshotInterval, true)
+	scheduler.Add(snapshot.NewBalanceSnapshotJob(cfg.MaxRetries), cfg.BalanceSnapshotInterval, true)
+	if len(cfg.ExtraQueries) > 0 {
+		// The queries were validated with the configuration
+		queries, _ := config.ParseExtraQueries(cfg.ExtraQueries)
+		scheduler.Add(snapshot.NewExtraQueriesJob(queries, cfg.MaxRetries), cfg.ExtraQueriesInterval, true)
+	}
+	return scheduler
+}
+
 // setBlockRange sets correct the block range based on the configuration.
-// If the start block is not set, it will be set to the latest block in the database.
+// The start and stop times, if set, are resolved to the start and stop blocks first.
+// If the start block is not set, it will be set to the block after the latest block in the database, unless resuming
+// is disabled, in which case it will be set to the earliest block available on the gRPC server.
 // If the stop block is not set, it will be set to the latest block in the gRPC server.
 // If the start block is greater than the stop block, an error will be returned.
 func setBlockRange(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, cfg *config.ExtractConfig) error {
+	if err := resolveTimeRange(gRPCClient, cfg); err != nil {
+		return fmt.Errorf("failed to resolve the time range: %w", err)
+	}
+
 	if cfg.ReIndex {
 		slog.Info("Reindexing entire database...")
 		// TODO: Get the earliest block from the gRPC server
@@ -76,31 +189,82 @@ func setBlockRange(gRPCClient *client.GRPCClient, outputHandler output.OutputHan
 		// TODO: Get the earliest block from the gRPC server
 		// See https://github.com/manifest-network/yaci/issues/28
 		cfg.BlockStart = 1
-		latestLocalBlock, err := outputHandler.GetLatestBlock(gRPCClient.Ctx)
-		if err != nil {
-			return fmt.Errorf("failed to get the latest block: %w", err)
+		var latestLocalBlock *models.Block
+		if cfg.Resume {
+			var err error
+			latestLocalBlock, err = outputHandler.GetLatestBlock(gRPCClient.Ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get the latest block: %w", err)
+			}
 		}
 		if latestLocalBlock != nil {
 			cfg.BlockStart = latestLocalBlock.ID + 1
+			slog.Info("Resuming from the latest stored block", "height", latestLocalBlock.ID)
+		} else if cfg.Continuous || !cfg.Resume {
+			// The earliest heights are served by the archive endpoint, if any
+			earliestRemoteBlock, err := utils.GetEarliestBlockHeightWithRetry(gRPCClient.ForHeight(0), cfg.MaxRetries)
+			if err != nil {
+				slog.Warn("Failed to get the earliest available block, starting from block 1", "error", err)
+			} else {
+				cfg.BlockStart = earliestRemoteBlock
+			}
 		}
 	}
 
 	if cfg.BlockStop == 0 {
-		latestRemoteBlock, err := utils.GetLatestBlockHeightWithRetry(gRPCClient, cfg.MaxRetries)
+		latestRemoteBlock, err := getLatestConfirmedHeight(gRPCClient, *cfg)
 		if err != nil {
 			return fmt.Errorf("failed to get the latest block: %w", err)
 		}
 		cfg.BlockStop = latestRemoteBlock
 	}
 
-	if cfg.BlockStart > cfg.BlockStop {
+	// A live or continuous extraction may start right after the chain tip, when the output is up to date
+	upToDate := (cfg.LiveMonitoring || cfg.Continuous) && cfg.BlockStart == cfg.BlockStop+1
+	if cfg.BlockStart > cfg.BlockStop && !upToDate {
 		return fmt.Errorf("start block is greater than stop block")
 	}
 
 	return nil
 }
 
+// getLatestConfirmedHeight returns the latest height of the chain that is at least cfg.ConfirmationDepth heights
+// behind the chain tip, or 0 if there is none yet.
+func getLatestConfirmedHeight(gRPCClient *client.GRPCClient, cfg config.ExtractConfig) (uint64, error) {
+	latestHeight, err := utils.GetLatestBlockHeightWithRetry(gRPCClient, cfg.MaxRetries)
+	if err != nil {
+		return 0, err
+	}
+
+	if latestHeight < cfg.ConfirmationDepth {
+		return 0, nil
+	}
+	return latestHeight - cfg.ConfirmationDepth, nil
+}
+
+// recordSkippedRanges records the ranges skipped with cfg.SkipRanges in the output.
+func recordSkippedRanges(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, cfg config.ExtractConfig) error {
+	// The ranges were validated with the configuration
+	skipRanges, _ := config.ParseHeightRanges(cfg.SkipRanges)
+	if len(skipRanges) == 0 {
+		return nil
+	}
+
+	recorder, err := output.Require[output.RangeRecorder](outputHandler, "skipped ranges")
+	if err != nil {
+		return err
+	}
+	if err := recorder.WriteSkippedRanges(gRPCClient.Ctx, skipRanges); err != nil {
+		return fmt.Errorf("failed to record skipped ranges: %w", err)
+	}
+	slog.Info("Skipping height ranges", "ranges", cfg.SkipRanges)
+	return nil
+}
+
 // shouldSkipMissingBlockCheck returns true if the missing block check should be skipped.
 func shouldSkipMissingBlockCheck(cfg config.ExtractConfig) bool {
-	return (cfg.BlockStart != 0 && cfg.BlockStop != 0) || cfg.ReIndex
+	hasStart := cfg.BlockStart != 0 || cfg.StartTime != ""
+	hasStop := cfg.BlockStop != 0 || cfg.EndTime != ""
+	// The heights left out by sampling would be reported as missing
+	return (hasStart && hasStop) || cfg.ReIndex || cfg.SampleEvery > 1
 }
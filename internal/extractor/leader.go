@@ -0,0 +1,95 @@
+package extractor
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/output"
+)
+
+// leadershipInterval is the interval at which a standby tries to become the leader, and at which the leader checks
+// that it still is, which bounds the time for a standby to take over.
+const leadershipInterval = 2 * time.Second
+
+// extractAsLeader waits until the instance is elected leader among the instances sharing the output, then extracts
+// while checking the leadership. When the leadership is lost, the extraction is cancelled and the instance becomes a
+// standby again.
+func extractAsLeader(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, cfg config.ExtractConfig) error {
+	elector, err := output.Require[output.LeaderElector](outputHandler, "leader elections")
+	if err != nil {
+		return err
+	}
+
+	run := cfg
+	run.LeaderElection = false
+	for {
+		if !waitForLeadership(gRPCClient.Ctx, elector, cfg.LeaderElectionName) {
+			return nil
+		}
+		slog.Info("Elected leader, starting extraction", "name", cfg.LeaderElectionName)
+
+		ctx, cancel := context.WithCancel(gRPCClient.Ctx)
+		lost := make(chan struct{})
+		go func() {
+			ticker := time.NewTicker(leadershipInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := elector.CheckLeadership(ctx); err != nil && ctx.Err() == nil {
+						slog.Error("Lost leadership, stopping extraction", "error", err)
+						close(lost)
+						cancel()
+						return
+					}
+				}
+			}
+		}()
+
+		err := extract(gRPCClient.WithContext(ctx), outputHandler, run)
+		cancel()
+		if releaseErr := elector.ReleaseLeadership(context.Background()); releaseErr != nil {
+			slog.Warn("Failed to release leadership", "error", releaseErr)
+		}
+
+		select {
+		case <-lost:
+			slog.Info("Back to standby", "name", cfg.LeaderElectionName)
+			continue
+		default:
+		}
+		if gRPCClient.Ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+}
+
+// waitForLeadership tries to become the leader until it succeeds, and returns false if the context is cancelled first.
+func waitForLeadership(ctx context.Context, elector output.LeaderElector, name string) bool {
+	standby := false
+	for {
+		acquired, err := elector.TryAcquireLeadership(ctx, name)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("Failed to try to acquire leadership", "error", err)
+		}
+		if acquired {
+			return true
+		}
+		if !standby {
+			slog.Info("Another instance is the leader, waiting as standby", "name", name)
+			standby = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(leadershipInterval):
+		}
+	}
+}
@@ -2,38 +2,155 @@ package extractor
 
 import (
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/manifest-network/yaci/internal/client"
 	"github.com/manifest-network/yaci/internal/config"
 	"github.com/manifest-network/yaci/internal/output"
-	"github.com/manifest-network/yaci/internal/utils"
+	"github.com/manifest-network/yaci/internal/snapshot"
+)
+
+const (
+	initialReconnectDelay = 1 * time.Second
+	maxReconnectDelay     = 60 * time.Second
 )
 
 // extractLiveBlocksAndTransactions monitors the chain and processes new blocks as they are produced.
-func extractLiveBlocksAndTransactions(gRPCClient *client.GRPCClient, start uint64, outputHandler output.OutputHandler, cfg config.ExtractConfig) error {
+// When the connection to the gRPC server is lost, it reconnects with exponential backoff and resumes
+// from the last processed height. Other failures, e.g., a node restarting or a temporary database outage, are retried
+// with exponential backoff as well instead of stopping the extraction.
+// When the node halts at the height of a scheduled upgrade, it waits for the upgraded node instead of failing.
+// The chain tip is polled at an interval adapted to the observed block times, starting from cfg.BlockTime.
+// New blocks are verified to link to the stored chain, and divergent stored blocks are replaced.
+// It returns once a stop condition of limits is met.
+func extractLiveBlocksAndTransactions(gRPCClient *client.GRPCClient, start uint64, outputHandler output.OutputHandler, cfg config.ExtractConfig, scheduler *snapshot.Scheduler, limits *stopConditions) error {
 	currentHeight := start - 1
+	upgrades := newUpgradeWatcher(gRPCClient, cfg.MaxRetries)
+	waitingForUpgrade := false
+	poller := newAdaptivePoller(time.Duration(cfg.BlockTime) * time.Second)
+	retryDelay := initialReconnectDelay
 	for {
 		select {
 		case <-gRPCClient.Ctx.Done():
 			return nil
 		default:
-			// Get the latest block height
-			latestHeight, err := utils.GetLatestBlockHeightWithRetry(gRPCClient, cfg.MaxRetries)
+			latestHeight, err := extractNewBlocks(gRPCClient, currentHeight, outputHandler, cfg, scheduler, limits)
 			if err != nil {
-				return fmt.Errorf("failed to get latest block height: %w", err)
-			}
+				if gRPCClient.Ctx.Err() != nil {
+					return nil
+				}
+				if plan := upgrades.HaltingPlan(currentHeight); plan != nil {
+					slog.Warn("Node halted for upgrade, waiting for the upgraded node", "upgrade", plan.Name, "upgrade_height", plan.Height, "resume_height", currentHeight+1, "error", err)
+					waitingForUpgrade = true
+					if err := waitForUpgradedNode(gRPCClient, err, cfg.BlockTime); err != nil {
+						return err
+					}
+					continue
+				}
+				if !client.IsConnectionError(err) && gRPCClient.IsConnected() {
+					slog.Warn("Live extraction failed, retrying", "resume_height", currentHeight+1, "retry_in", retryDelay, "error", err)
+					select {
+					case <-gRPCClient.Ctx.Done():
+						return nil
+					case <-time.After(retryDelay):
+					}
+					retryDelay = min(2*retryDelay, maxReconnectDelay)
+					continue
+				}
 
+				slog.Warn("Lost connection to the gRPC server", "resume_height", currentHeight+1, "error", err)
+				if err := reconnectWithBackoff(gRPCClient); err != nil {
+					return err
+				}
+				continue
+			}
+			retryDelay = initialReconnectDelay
 			if latestHeight > currentHeight {
-				err = extractBlocksAndTransactions(gRPCClient, currentHeight+1, latestHeight, outputHandler, cfg)
-				if err != nil {
-					return fmt.Errorf("failed to process blocks and transactions: %w", err)
+				if waitingForUpgrade {
+					slog.Info("Node resumed after upgrade", "height", latestHeight)
+					waitingForUpgrade = false
+				}
+				if err := upgrades.Check(gRPCClient, outputHandler, latestHeight); err != nil {
+					slog.Warn("Failed to check upgrade plan", "error", err)
 				}
-				currentHeight = latestHeight
+			} else if plan := upgrades.HaltingPlan(currentHeight); plan != nil && !waitingForUpgrade {
+				slog.Info("Waiting for the node to apply the upgrade", "upgrade", plan.Name, "upgrade_height", plan.Height)
+				waitingForUpgrade = true
 			}
+			currentHeight = latestHeight
+			if limits.Reached() {
+				return nil
+			}
+
+			// Sleep until the next block is expected
+			delay := poller.Next(latestHeight, time.Now())
+			slog.Debug("Waiting for the next block", "delay", delay, "block_interval", poller.Interval())
+			select {
+			case <-gRPCClient.Ctx.Done():
+				return nil
+			case <-time.After(delay):
+			}
+		}
+	}
+}
+
+// extractNewBlocks extracts the blocks produced after currentHeight and returns the new current height.
+func extractNewBlocks(gRPCClient *client.GRPCClient, currentHeight uint64, outputHandler output.OutputHandler, cfg config.ExtractConfig, scheduler *snapshot.Scheduler, limits *stopConditions) (uint64, error) {
+	// Get the latest block height
+	latestHeight, err := getLatestConfirmedHeight(gRPCClient, cfg)
+	if err != nil {
+		return currentHeight, fmt.Errorf("failed to get latest block height: %w", err)
+	}
 
-			// Sleep before checking again
-			time.Sleep(time.Duration(cfg.BlockTime) * time.Second)
+	if latestHeight <= currentHeight {
+		return currentHeight, nil
+	}
+
+	err = extractBlocksAndTransactions(gRPCClient, currentHeight+1, latestHeight, outputHandler, cfg, limits)
+	if err != nil {
+		return currentHeight, fmt.Errorf("failed to process blocks and transactions: %w", err)
+	}
+	if err := repairReorg(gRPCClient, currentHeight+1, latestHeight, outputHandler, cfg); err != nil {
+		return currentHeight, fmt.Errorf("failed to repair reorg: %w", err)
+	}
+	scheduler.RunRange(gRPCClient, outputHandler, currentHeight+1, latestHeight)
+
+	return latestHeight, nil
+}
+
+// waitForUpgradedNode waits for the node to come back after an upgrade halt. A lost connection is re-established,
+// which also refreshes the protocol buffer descriptors of the upgraded node; otherwise, the extractor retries after
+// the block time.
+func waitForUpgradedNode(gRPCClient *client.GRPCClient, err error, blockTime uint) error {
+	if client.IsConnectionError(err) || !gRPCClient.IsConnected() {
+		return reconnectWithBackoff(gRPCClient)
+	}
+
+	select {
+	case <-gRPCClient.Ctx.Done():
+		return gRPCClient.Ctx.Err()
+	case <-time.After(time.Duration(blockTime) * time.Second):
+		return nil
+	}
+}
+
+// reconnectWithBackoff reconnects to the gRPC server until it succeeds or the context is cancelled.
+func reconnectWithBackoff(gRPCClient *client.GRPCClient) error {
+	delay := initialReconnectDelay
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-gRPCClient.Ctx.Done():
+			return gRPCClient.Ctx.Err()
+		case <-time.After(delay):
+		}
+
+		err := gRPCClient.Reconnect()
+		if err == nil {
+			slog.Info("Reconnected to the gRPC server", "attempts", attempt)
+			return nil
 		}
+		delay = min(2*delay, maxReconnectDelay)
+		slog.Warn("Failed to reconnect to the gRPC server", "attempt", attempt, "retry_in", delay, "error", err)
 	}
 }
@@ -0,0 +1,73 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/redact"
+)
+
+// memoRedactingHandler redacts the memos of the transactions and of the blocks before they are written to the wrapped
+// output handler.
+type memoRedactingHandler struct {
+	output.OutputHandler
+	redactor *redact.MemoRedactor
+}
+
+func (h *memoRedactingHandler) Unwrap() output.OutputHandler {
+	return h.OutputHandler
+}
+
+func (h *memoRedactingHandler) WriteBlockWithTransactions(ctx context.Context, block *models.Block, transactions []*models.Transaction, blockResults *models.BlockResults) error {
+	data, err := h.redactor.Block(block.Data)
+	if err != nil {
+		return fmt.Errorf("failed to redact the memos of block %d: %w", block.ID, err)
+	}
+	block.Data = data
+
+	if err := redactMemos(h.redactor, transactions); err != nil {
+		return err
+	}
+	return h.OutputHandler.WriteBlockWithTransactions(ctx, block, transactions, blockResults)
+}
+
+func (h *memoRedactingHandler) WriteTransactions(ctx context.Context, transactions []*models.Transaction) error {
+	if err := redactMemos(h.redactor, transactions); err != nil {
+		return err
+	}
+	return h.OutputHandler.WriteTransactions(ctx, transactions)
+}
+
+// withMemoRedaction wraps the output handler to redact the memos matching the patterns of the configuration, if any.
+func withMemoRedaction(outputHandler output.OutputHandler, cfg config.ExtractConfig) output.OutputHandler {
+	if len(cfg.RedactMemoPatterns) == 0 {
+		return outputHandler
+	}
+	// The patterns and the mode were validated with the configuration
+	redactor, _ := redact.NewMemoRedactor(cfg.RedactMemoPatterns, cfg.MemoRedaction)
+	return &memoRedactingHandler{OutputHandler: outputHandler, redactor: redactor}
+}
+
+// RedactMemos redacts the memos of the transactions matching the patterns of the configuration, if any.
+func RedactMemos(transactions []*models.Transaction, cfg config.ExtractConfig) error {
+	if len(cfg.RedactMemoPatterns) == 0 {
+		return nil
+	}
+	// The patterns and the mode were validated with the configuration
+	redactor, _ := redact.NewMemoRedactor(cfg.RedactMemoPatterns, cfg.MemoRedaction)
+	return redactMemos(redactor, transactions)
+}
+
+func redactMemos(redactor *redact.MemoRedactor, transactions []*models.Transaction) error {
+	for _, tx := range transactions {
+		data, err := redactor.Transaction(tx.Data)
+		if err != nil {
+			return fmt.Errorf("failed to redact the memo of transaction %s: %w", tx.Hash, err)
+		}
+		tx.Data = data
+	}
+	return nil
+}
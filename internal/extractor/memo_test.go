@@ -0,0 +1,49 @@
+package extractor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/extractor"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/redact"
+)
+
+// blockRecorder records the blocks and the transactions written to it.
+type blockRecorder struct {
+	output.OutputHandler
+	block        *models.Block
+	transactions []*models.Transaction
+}
+
+func (r *blockRecorder) WriteBlockWithTransactions(_ context.Context, block *models.Block, transactions []*models.Transaction, _ *models.BlockResults) error {
+	r.block = block
+	r.transactions = transactions
+	return nil
+}
+
+func TestMemoRedaction(t *testing.T) {
+	recorder := &blockRecorder{}
+	handler := extractor.WithMemoRedaction(recorder, config.ExtractConfig{RedactMemoPatterns: []string{`@`}, MemoRedaction: redact.ModeHash})
+
+	block := &models.Block{
+		ID:   10,
+		Data: []byte(`{"txs":[{"body":{"memo":"user@example.com"}}],"block":{"header":{"height":"10"},"data":{"txs":["dXNlckBleGFtcGxlLmNvbQ=="]}}}`),
+	}
+	transactions := []*models.Transaction{{
+		Hash: "hash",
+		Data: []byte(`{"tx":{"body":{"memo":"user@example.com"}},"txResponse":{"height":"10"}}`),
+	}}
+	require.NoError(t, handler.WriteBlockWithTransactions(context.Background(), block, transactions, nil))
+
+	// Neither the decoded transactions nor the raw transactions of the stored block hold the memo
+	require.NotNil(t, recorder.block)
+	assert.JSONEq(t, `{"txs":[{"body":{"memo":"sha256:b4c9a289323b21a01c3e940f150eb9b8c542587f1abfd8f0e1cc1ffc5e475514"}}],"block":{"header":{"height":"10"},"data":{}}}`, string(recorder.block.Data))
+	require.Len(t, recorder.transactions, 1)
+	assert.NotContains(t, string(recorder.transactions[0].Data), "user@example.com")
+}
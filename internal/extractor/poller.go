@@ -0,0 +1,53 @@
+package extractor
+
+import (
+	"time"
+)
+
+const (
+	pollIntervalAlpha = 0.2 // Weight of the latest observed block interval in the moving average
+	minPollInterval   = 250 * time.Millisecond
+	maxPollInterval   = 60 * time.Second
+)
+
+// adaptivePoller derives the delay between two polls of the chain tip from an exponentially weighted moving
+// average of the observed block interval, so that fast chains are not polled more than needed and slow chains
+// are not lagged behind.
+type adaptivePoller struct {
+	interval    time.Duration // Moving average of the block interval
+	lastHeight  uint64
+	lastAdvance time.Time // Time at which lastHeight was observed
+}
+
+// newAdaptivePoller creates a poller whose block interval estimate starts at initial.
+func newAdaptivePoller(initial time.Duration) *adaptivePoller {
+	return &adaptivePoller{interval: clampPollInterval(initial)}
+}
+
+// Next records the chain tip height observed at now and returns the delay before the next poll, which targets
+// the expected time of the next block. When the next block is overdue, the chain is polled at a fraction of the
+// block interval.
+func (p *adaptivePoller) Next(height uint64, now time.Time) time.Duration {
+	if p.lastAdvance.IsZero() {
+		p.lastHeight, p.lastAdvance = height, now
+		return p.interval
+	}
+
+	if height > p.lastHeight {
+		sample := now.Sub(p.lastAdvance) / time.Duration(height-p.lastHeight)
+		p.interval = clampPollInterval(time.Duration(pollIntervalAlpha*float64(sample) + (1-pollIntervalAlpha)*float64(p.interval)))
+		p.lastHeight, p.lastAdvance = height, now
+	}
+
+	wait := p.lastAdvance.Add(p.interval).Sub(now)
+	return clampPollInterval(max(wait, p.interval/4))
+}
+
+// Interval returns the current estimate of the block interval.
+func (p *adaptivePoller) Interval() time.Duration {
+	return p.interval
+}
+
+func clampPollInterval(d time.Duration) time.Duration {
+	return min(max(d, minPollInterval), maxPollInterval)
+}
@@ -0,0 +1,129 @@
+package extractor_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/manifest-network/yaci/internal/extractor"
+)
+
+func TestAdaptivePoller(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type poll struct {
+		height uint64
+		at     time.Duration // Time of the poll since start
+	}
+	tests := []struct {
+		name     string
+		initial  time.Duration
+		polls    []poll
+		interval time.Duration // Block interval estimated after the polls
+		wait     time.Duration // Delay returned by the last poll
+	}{
+		{
+			name:     "first poll",
+			initial:  5 * time.Second,
+			polls:    []poll{{height: 10}},
+			interval: 5 * time.Second,
+			wait:     5 * time.Second,
+		},
+		{
+			name:     "grows with slower blocks",
+			initial:  time.Second,
+			polls:    []poll{{height: 10}, {height: 11, at: 6 * time.Second}},
+			interval: 2 * time.Second,
+			wait:     2 * time.Second,
+		},
+		{
+			name:     "shrinks with faster blocks",
+			initial:  6 * time.Second,
+			polls:    []poll{{height: 10}, {height: 11, at: time.Second}},
+			interval: 5 * time.Second,
+			wait:     5 * time.Second,
+		},
+		{
+			name:     "averages over the heights of a poll",
+			initial:  6 * time.Second,
+			polls:    []poll{{height: 10}, {height: 13, at: 3 * time.Second}},
+			interval: 5 * time.Second,
+			wait:     5 * time.Second,
+		},
+		{
+			name:     "targets the next block",
+			initial:  4 * time.Second,
+			polls:    []poll{{height: 10}, {height: 10, at: time.Second}},
+			interval: 4 * time.Second,
+			wait:     3 * time.Second,
+		},
+		{
+			name:     "polls overdue blocks at a fraction of the interval",
+			initial:  4 * time.Second,
+			polls:    []poll{{height: 10}, {height: 10, at: 10 * time.Second}},
+			interval: 4 * time.Second,
+			wait:     time.Second,
+		},
+		{
+			name:     "initial below the lower bound",
+			initial:  time.Millisecond,
+			polls:    []poll{{height: 10}},
+			interval: extractor.MinPollInterval,
+			wait:     extractor.MinPollInterval,
+		},
+		{
+			name:     "initial above the upper bound",
+			initial:  time.Hour,
+			polls:    []poll{{height: 10}},
+			interval: extractor.MaxPollInterval,
+			wait:     extractor.MaxPollInterval,
+		},
+		{
+			name:    "bounded by the lower bound",
+			initial: time.Second,
+			polls: []poll{
+				{height: 10},
+				{height: 1010, at: time.Second},
+				{height: 2010, at: 2 * time.Second},
+				{height: 3010, at: 3 * time.Second},
+				{height: 4010, at: 4 * time.Second},
+				{height: 5010, at: 5 * time.Second},
+				{height: 6010, at: 6 * time.Second},
+				{height: 7010, at: 7 * time.Second},
+			},
+			interval: extractor.MinPollInterval,
+			wait:     extractor.MinPollInterval,
+		},
+		{
+			name:    "bounded by the upper bound",
+			initial: 50 * time.Second,
+			polls: []poll{
+				{height: 10},
+				{height: 11, at: time.Hour},
+				{height: 12, at: 2 * time.Hour},
+			},
+			interval: extractor.MaxPollInterval,
+			wait:     extractor.MaxPollInterval,
+		},
+		{
+			name:     "overdue wait bounded by the lower bound",
+			initial:  500 * time.Millisecond,
+			polls:    []poll{{height: 10}, {height: 10, at: 10 * time.Second}},
+			interval: 500 * time.Millisecond,
+			wait:     extractor.MinPollInterval,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := extractor.NewAdaptivePoller(tt.initial)
+			var wait time.Duration
+			for _, poll := range tt.polls {
+				wait = p.Next(poll.height, start.Add(poll.at))
+			}
+			assert.Equal(t, tt.interval, p.Interval())
+			assert.Equal(t, tt.wait, wait)
+		})
+	}
+}
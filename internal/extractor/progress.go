@@ -0,0 +1,97 @@
+package extractor
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// progressReportInterval is the interval between two renders of the progress bar.
+const progressReportInterval = 200 * time.Millisecond
+
+// newProgressBar returns the progress bar of the extraction of total blocks written to w, showing the count of the
+// processed blocks, their rate and the estimated remaining time.
+func newProgressBar(total uint64, w io.Writer) *progressbar.ProgressBar {
+	return progressbar.NewOptions64(
+		int64(total),
+		progressbar.OptionSetWriter(w),
+		progressbar.OptionClearOnFinish(),
+		progressbar.OptionSetDescription("Processing blocks..."),
+		progressbar.OptionShowCount(),
+		progressbar.OptionShowIts(),
+		progressbar.OptionSetTheme(progressbar.Theme{
+			Saucer:        "=",
+			SaucerHead:    ">",
+			SaucerPadding: " ",
+			BarStart:      "[",
+			BarEnd:        "]",
+		}),
+	)
+}
+
+// progressReporter counts the processed blocks with an atomic counter and renders the progress bar from a single
+// goroutine, so that workers never contend on the progress bar lock.
+type progressReporter struct {
+	bar       *progressbar.ProgressBar
+	processed atomic.Int64
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// newProgressReporter starts rendering the progress of bar every interval until Stop is called.
+// A nil bar returns a nil reporter, on which all methods are no-ops.
+func newProgressReporter(bar *progressbar.ProgressBar, interval time.Duration) *progressReporter {
+	if bar == nil {
+		return nil
+	}
+
+	p := &progressReporter{
+		bar:  bar,
+		stop: make(chan struct{}),
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				p.render()
+				return
+			case <-ticker.C:
+				p.render()
+			}
+		}
+	}()
+
+	return p
+}
+
+// Add records n processed blocks.
+func (p *progressReporter) Add(n int64) {
+	if p == nil {
+		return
+	}
+	p.processed.Add(n)
+}
+
+// Stop renders the final progress and stops the reporter goroutine.
+func (p *progressReporter) Stop() {
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	p.wg.Wait()
+}
+
+func (p *progressReporter) render() {
+	if err := p.bar.Set64(p.processed.Load()); err != nil {
+		slog.Warn("Failed to update progress bar", "error", err)
+	}
+}
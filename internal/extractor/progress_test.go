@@ -0,0 +1,47 @@
+package extractor_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/manifest-network/yaci/internal/extractor"
+)
+
+func TestProgressReporter(t *testing.T) {
+	var out bytes.Buffer
+	bar := extractor.NewProgressBar(100, &out)
+	progress := extractor.NewProgressReporter(bar, time.Millisecond)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 4 {
+				progress.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	progress.Add(2)
+	progress.Stop()
+
+	assert.Equal(t, 0.42, bar.State().CurrentPercent)
+	assert.Contains(t, out.String(), "Processing blocks...")
+	assert.Contains(t, out.String(), "(42/100,")
+	// The elapsed and the estimated remaining time
+	assert.Regexp(t, `\[\d+s:\d+s\]`, out.String())
+}
+
+func TestProgressReporterWithoutBar(t *testing.T) {
+	progress := extractor.NewProgressReporter(nil, time.Millisecond)
+	assert.Nil(t, progress)
+	assert.NotPanics(t, func() {
+		progress.Add(1)
+		progress.Stop()
+	})
+}
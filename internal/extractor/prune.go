@@ -0,0 +1,81 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/prune"
+)
+
+// jsonPruningHandler drops or truncates the JSON fields matching the prune rules of the blocks, transactions and block
+// results before they are written to the wrapped output handler.
+type jsonPruningHandler struct {
+	output.OutputHandler
+	pruner *prune.Pruner
+}
+
+func (h *jsonPruningHandler) Unwrap() output.OutputHandler {
+	return h.OutputHandler
+}
+
+func (h *jsonPruningHandler) WriteBlockWithTransactions(ctx context.Context, block *models.Block, transactions []*models.Transaction, blockResults *models.BlockResults) error {
+	data, err := h.pruner.Prune(block.Data)
+	if err != nil {
+		return fmt.Errorf("failed to prune block %d: %w", block.ID, err)
+	}
+	block.Data = data
+
+	if err := pruneTransactions(h.pruner, transactions); err != nil {
+		return err
+	}
+
+	if blockResults != nil {
+		data, err := h.pruner.Prune(blockResults.Data)
+		if err != nil {
+			return fmt.Errorf("failed to prune results of block %d: %w", blockResults.Height, err)
+		}
+		blockResults.Data = data
+	}
+	return h.OutputHandler.WriteBlockWithTransactions(ctx, block, transactions, blockResults)
+}
+
+func (h *jsonPruningHandler) WriteTransactions(ctx context.Context, transactions []*models.Transaction) error {
+	if err := pruneTransactions(h.pruner, transactions); err != nil {
+		return err
+	}
+	return h.OutputHandler.WriteTransactions(ctx, transactions)
+}
+
+// withJSONPruning wraps the output handler to prune the JSON fields matching the rules of the configuration, if any.
+func withJSONPruning(outputHandler output.OutputHandler, cfg config.ExtractConfig) output.OutputHandler {
+	if len(cfg.PruneJSON) == 0 {
+		return outputHandler
+	}
+	// The rules were validated with the configuration
+	pruner, _ := prune.NewPruner(cfg.PruneJSON)
+	return &jsonPruningHandler{OutputHandler: outputHandler, pruner: pruner}
+}
+
+// PruneTransactions prunes the JSON fields of the transactions matching the rules of the configuration, if any.
+func PruneTransactions(transactions []*models.Transaction, cfg config.ExtractConfig) error {
+	if len(cfg.PruneJSON) == 0 {
+		return nil
+	}
+	// The rules were validated with the configuration
+	pruner, _ := prune.NewPruner(cfg.PruneJSON)
+	return pruneTransactions(pruner, transactions)
+}
+
+func pruneTransactions(pruner *prune.Pruner, transactions []*models.Transaction) error {
+	for _, tx := range transactions {
+		data, err := pruner.Prune(tx.Data)
+		if err != nil {
+			return fmt.Errorf("failed to prune transaction %s: %w", tx.Hash, err)
+		}
+		tx.Data = data
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package extractor
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+)
+
+// maxReorgDepth is the maximum number of stored blocks replaced when repairing a reorg. A deeper divergence
+// most likely means the node serves another chain than the one in the output.
+const maxReorgDepth = 100
+
+// repairReorg verifies that the stored blocks of [start, stop] link to their parent. When a block does not, e.g.,
+// after a node rollback, the stored blocks below it are re-fetched and replaced until the chain links again, and the
+// reorg is recorded. Each stored block is only replaced once its replacement was fetched. Nothing is verified if the
+// output does not record reorgs.
+func repairReorg(gRPCClient *client.GRPCClient, start, stop uint64, outputHandler output.OutputHandler, cfg config.ExtractConfig) error {
+	recorder, ok := output.As[output.ReorgRecorder](outputHandler)
+	if !ok {
+		return nil
+	}
+
+	height, found, err := recorder.FindChainMismatch(gRPCClient.Ctx, start, stop)
+	if err != nil || !found {
+		return err
+	}
+
+	reorg := &models.Reorg{DetectedHeight: height}
+	for found {
+		if height <= 1 {
+			return fmt.Errorf("block %d does not link to the stored chain", height)
+		}
+		if len(reorg.OldHashes) >= maxReorgDepth {
+			return fmt.Errorf("reorg at height %d is deeper than %d blocks", reorg.DetectedHeight, maxReorgDepth)
+		}
+
+		// The parent of the mismatching block diverges from the node, replace it
+		parent := height - 1
+		oldHash, err := recorder.GetBlockHash(gRPCClient.Ctx, parent)
+		if err != nil {
+			return err
+		}
+		block, err := processBlock(gRPCClient.WithContext(output.WithBlockReplacement(gRPCClient.Ctx)), parent, outputHandler, cfg)
+		if err != nil {
+			return fmt.Errorf("failed to replace block %d: %w", parent, err)
+		}
+		reorg.ForkHeight = parent
+		reorg.OldHashes = append(reorg.OldHashes, oldHash)
+		reorg.NewHashes = append(reorg.NewHashes, block.Hash)
+
+		height, found, err = recorder.FindChainMismatch(gRPCClient.Ctx, parent, stop)
+		if err != nil {
+			return err
+		}
+	}
+
+	slices.Reverse(reorg.OldHashes)
+	slices.Reverse(reorg.NewHashes)
+	slog.Warn("Reorg detected, replaced divergent blocks", "fork_height", reorg.ForkHeight, "detected_height", reorg.DetectedHeight, "depth", len(reorg.OldHashes))
+	return recorder.WriteReorg(gRPCClient.Ctx, reorg)
+}
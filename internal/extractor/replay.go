@@ -0,0 +1,134 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/manifest-network/yaci/internal/archive"
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/events"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/reflection"
+)
+
+// replayProgressInterval is the number of blocks between two progress logs of a replay.
+const replayProgressInterval = 10000
+
+// ReplayBlocks runs the stored blocks of the configured range, their transactions and their results through the
+// decoding of the extraction again, and rewrites them to the output handler, without any endpoint. The rewrite
+// refreshes the columns and the tables derived from the raw JSON, e.g., after a decoder fix or the addition of a
+// derived table.
+//
+// The JSON stored by the node responses is decoded again: the legacy event attributes, the interchain accounts packets
+// and the vote extensions, with the descriptors of resolver, along with the output transformations of the
+// configuration. The transactions that could not be fetched from a node are rewritten as they are.
+func ReplayBlocks(ctx context.Context, src archive.Source, outputHandler output.OutputHandler, resolver reflection.JSONResolver, cfg config.ReplayConfig) (archive.Summary, error) {
+	var summary archive.Summary
+
+	extractConfig := cfg.ExtractConfig()
+	outputHandler = withCanonicalJSON(outputHandler, extractConfig)
+	outputHandler = withJSONPruning(outputHandler, extractConfig)
+	outputHandler = withMemoRedaction(outputHandler, extractConfig)
+	outputHandler = withVoteExtensionResolver(func() reflection.JSONResolver { return resolver }, outputHandler, extractConfig)
+
+	after := cfg.BlockStart
+	if after > 0 {
+		after--
+	}
+	slog.Info("Replaying stored blocks and transactions", "start", cfg.BlockStart, "stop", cfg.BlockStop)
+	for {
+		if ctx.Err() != nil {
+			slog.Info("Processing cancelled by user")
+			return summary, ctx.Err()
+		}
+
+		blocks, err := src.GetBlocks(ctx, after, cfg.BlockStop, cfg.BatchSize)
+		if err != nil {
+			return summary, err
+		}
+		if len(blocks) == 0 {
+			break
+		}
+		first, last := blocks[0].ID, blocks[len(blocks)-1].ID
+
+		transactions, err := src.GetBlockTransactions(ctx, first, last)
+		if err != nil {
+			return summary, err
+		}
+		results, err := src.GetBlockResults(ctx, first, last)
+		if err != nil {
+			return summary, err
+		}
+		resultsByHeight := make(map[uint64]*models.BlockResults, len(results))
+		for _, r := range results {
+			resultsByHeight[r.Height] = r
+		}
+
+		for _, block := range blocks {
+			if ctx.Err() != nil {
+				slog.Info("Processing cancelled by user")
+				return summary, ctx.Err()
+			}
+
+			txs, blockResults, err := replayBlock(resolver, block, transactions[block.ID], resultsByHeight[block.ID])
+			if err != nil {
+				return summary, err
+			}
+			if err := outputHandler.WriteBlockWithTransactions(ctx, block, txs, blockResults); err != nil {
+				return summary, fmt.Errorf("failed to write block %d: %w", block.ID, err)
+			}
+			summary.Blocks++
+			summary.Transactions += uint64(len(txs))
+			if blockResults != nil {
+				summary.BlockResults++
+			}
+
+			if summary.Blocks%replayProgressInterval == 0 {
+				slog.Info("Replaying stored blocks and transactions", "height", block.ID, "blocks", summary.Blocks)
+			}
+		}
+		after = last
+	}
+
+	slog.Info("Replay completed", "blocks", summary.Blocks, "transactions", summary.Transactions)
+	return summary, nil
+}
+
+// replayBlock decodes the stored block, its transactions and its results again. The hashes and the time of the block
+// are read from its data, for the blocks stored before they had their own columns; the stored values are kept when the
+// data lacks them.
+func replayBlock(resolver reflection.JSONResolver, block *models.Block, transactions []*models.Transaction, blockResults *models.BlockResults) ([]*models.Transaction, *models.BlockResults, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(block.Data, &data); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal block %d JSON: %w", block.ID, err)
+	}
+	if t := parseBlockTime(data); !t.IsZero() {
+		block.Time = t
+	}
+	if hash, parentHash := parseBlockHashes(data); hash != "" {
+		block.Hash, block.ParentHash = hash, parentHash
+	}
+
+	for _, tx := range transactions {
+		txData, err := decodeTransaction(resolver, tx.Hash, tx.Data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if tx.Data, err = verifyTransactionHash(tx.Hash, txData); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if blockResults != nil {
+		data, err := events.DecodeLegacyAttributes(blockResults.Data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode the events of the results of block %d: %w", block.ID, err)
+		}
+		blockResults.Data = data
+	}
+
+	return transactions, blockResults, nil
+}
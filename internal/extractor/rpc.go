@@ -0,0 +1,141 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/manifest-network/yaci/internal/events"
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+// rpcTimeout is the timeout of the requests to the CometBFT RPC.
+const rpcTimeout = 30 * time.Second
+
+var rpcClient = &http.Client{Timeout: rpcTimeout}
+
+// rpcResponse is a CometBFT JSON-RPC response.
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    string `json:"data"`
+	} `json:"error"`
+}
+
+// fetchBlockResultsFromRPC fetches the block results from the /block_results endpoint of the CometBFT RPC, for the
+// nodes without the GetBlockResults gRPC endpoint. The keys of the result are converted to lowerCamelCase, so that the
+// stored block results have the same shape as the ones of the gRPC endpoint.
+func fetchBlockResultsFromRPC(ctx context.Context, rpcURL string, blockHeight uint64, maxRetries uint) (*models.BlockResults, error) {
+	endpoint := strings.TrimSuffix(rpcURL, "/") + "/block_results?" + url.Values{"height": {fmt.Sprint(blockHeight)}}.Encode()
+
+	var result json.RawMessage
+	var err error
+	for attempt := uint(1); attempt <= max(maxRetries, 1); attempt++ {
+		if result, err = getRPCResult(ctx, endpoint); err == nil {
+			break
+		}
+		slog.Debug("Retrying CometBFT RPC call", "endpoint", endpoint, "attempt", attempt, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Duration(2*attempt) * time.Second):
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block results from the CometBFT RPC after %d retries: %w", maxRetries, err)
+	}
+
+	data, err := camelCaseKeys(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert block results: %w", err)
+	}
+	if data, err = events.DecodeLegacyAttributes(data); err != nil {
+		return nil, fmt.Errorf("failed to decode the events of the block results: %w", err)
+	}
+
+	return &models.BlockResults{
+		Height: blockHeight,
+		Data:   data,
+	}, nil
+}
+
+// getRPCResult calls a CometBFT RPC endpoint and returns the result of the JSON-RPC response.
+func getRPCResult(ctx context.Context, endpoint string) (json.RawMessage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := rpcClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var rpcResp rpcResponse
+	if err := json.Unmarshal(body, &rpcResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response (status %d): %w", resp.StatusCode, err)
+	}
+	if rpcResp.Error != nil {
+		return nil, fmt.Errorf("RPC error %d: %s %s", rpcResp.Error.Code, rpcResp.Error.Message, rpcResp.Error.Data)
+	}
+	if len(rpcResp.Result) == 0 {
+		return nil, fmt.Errorf("empty result (status %d)", resp.StatusCode)
+	}
+	return rpcResp.Result, nil
+}
+
+// camelCaseKeys converts the snake_case keys of a JSON document to lowerCamelCase, keeping the numbers as is.
+func camelCaseKeys(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(convertKeys(v))
+}
+
+func convertKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			converted[snakeToLowerCamel(key)] = convertKeys(value)
+		}
+		return converted
+	case []interface{}:
+		for i, value := range v {
+			v[i] = convertKeys(value)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// snakeToLowerCamel converts a snake_case name to lowerCamelCase, e.g., txs_results to txsResults.
+func snakeToLowerCamel(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,69 @@
+package extractor
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/output"
+)
+
+// extractOnSchedule extracts from the latest stored block up to the chain tip at startup, then at the times of the
+// cfg.Schedule cron expression, sleeping in between, until the context is cancelled.
+// A run that fails to reach the node is retried at the next scheduled time.
+func extractOnSchedule(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, cfg config.ExtractConfig) error {
+	// The schedule was validated with the configuration
+	schedule, _ := cron.ParseStandard(cfg.Schedule)
+
+	run := cfg
+	run.Schedule = ""
+	for {
+		if err := extractToTip(gRPCClient, outputHandler, run); err != nil {
+			if gRPCClient.Ctx.Err() != nil {
+				return nil
+			}
+			if !client.IsConnectionError(err) && gRPCClient.IsConnected() {
+				return err
+			}
+			slog.Warn("Scheduled extraction failed, retrying at the next scheduled time", "error", err)
+		}
+
+		next := schedule.Next(time.Now())
+		slog.Info("Waiting for the next scheduled extraction", "next", next)
+		select {
+		case <-gRPCClient.Ctx.Done():
+			return nil
+		case <-time.After(time.Until(next)):
+		}
+
+		if !gRPCClient.IsConnected() {
+			if err := gRPCClient.Reconnect(); err != nil {
+				slog.Warn("Failed to reconnect to the gRPC server", "error", err)
+			}
+		}
+	}
+}
+
+// extractToTip extracts from the latest stored block up to the chain tip, if the output is not up to date.
+func extractToTip(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, cfg config.ExtractConfig) error {
+	latestLocalBlock, err := outputHandler.GetLatestBlock(gRPCClient.Ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the latest block: %w", err)
+	}
+	latestHeight, err := getLatestConfirmedHeight(gRPCClient, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to get latest block height: %w", err)
+	}
+
+	if latestLocalBlock != nil && latestLocalBlock.ID >= latestHeight {
+		slog.Info("The output is up to date with the chain tip", "height", latestLocalBlock.ID)
+		return nil
+	}
+
+	slog.Info("Starting scheduled extraction", "tip", latestHeight)
+	return extract(gRPCClient, outputHandler, cfg)
+}
@@ -0,0 +1,135 @@
+package extractor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/snapshot"
+)
+
+// maxShardWait is the maximum time to wait before looking for a shard again when all the remaining shards are leased
+// by other instances.
+const maxShardWait = 30 * time.Second
+
+// splitShards splits [start, stop] into shards aligned on multiples of size, so that all the instances sharing the
+// same range compute the same shards.
+func splitShards(start, stop, size uint64) []models.Shard {
+	var shards []models.Shard
+	for shardStart := start; shardStart <= stop; {
+		shardStop := min((shardStart/size+1)*size-1, stop)
+		shards = append(shards, models.Shard{Start: shardStart, Stop: shardStop})
+		if shardStop == stop {
+			break
+		}
+		shardStart = shardStop + 1
+	}
+	return shards
+}
+
+// extractShards processes [start, stop] cooperatively with other instances sharing the same output. Each instance
+// claims shards of the range under a lease, which it renews while processing them, so that the shards of a crashed
+// instance are claimed by another one once their lease expires. It returns once every shard has been completed.
+func extractShards(gRPCClient *client.GRPCClient, start, stop uint64, outputHandler output.OutputHandler, cfg config.ExtractConfig, scheduler *snapshot.Scheduler) error {
+	coordinator, err := output.Require[output.ShardCoordinator](outputHandler, "sharded extractions")
+	if err != nil {
+		return err
+	}
+
+	owner := shardOwner()
+	shards := splitShards(start, stop, cfg.ShardSize)
+	if err := coordinator.RegisterShards(gRPCClient.Ctx, shards); err != nil {
+		return fmt.Errorf("failed to register shards: %w", err)
+	}
+	slog.Info("Starting sharded extraction", "owner", owner, "shards", len(shards), "shard_size", cfg.ShardSize)
+
+	for {
+		if gRPCClient.Ctx.Err() != nil {
+			return gRPCClient.Ctx.Err()
+		}
+
+		shard, err := coordinator.ClaimShard(gRPCClient.Ctx, owner, start, stop, cfg.ShardLease)
+		if err != nil {
+			return fmt.Errorf("failed to claim shard: %w", err)
+		}
+
+		if shard == nil {
+			pending, err := coordinator.CountPendingShards(gRPCClient.Ctx, start, stop)
+			if err != nil {
+				return fmt.Errorf("failed to count pending shards: %w", err)
+			}
+			if pending == 0 {
+				slog.Info("All shards completed", "owner", owner)
+				return nil
+			}
+
+			wait := min(cfg.ShardLease/2, maxShardWait)
+			slog.Info("Remaining shards are leased by other instances, waiting", "pending", pending, "retry_in", wait)
+			select {
+			case <-gRPCClient.Ctx.Done():
+				return gRPCClient.Ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+
+		if err := processShard(gRPCClient, *shard, owner, outputHandler, coordinator, cfg); err != nil {
+			return err
+		}
+		scheduler.RunRange(gRPCClient, outputHandler, shard.Start, shard.Stop)
+	}
+}
+
+// processShard extracts the blocks of a claimed shard while renewing its lease, then marks it as completed.
+func processShard(gRPCClient *client.GRPCClient, shard models.Shard, owner string, outputHandler output.OutputHandler, coordinator output.ShardCoordinator, cfg config.ExtractConfig) error {
+	slog.Info("Claimed shard", "start", shard.Start, "stop", shard.Stop)
+
+	ctx, cancel := context.WithCancel(gRPCClient.Ctx)
+	defer cancel()
+
+	go func() {
+		ticker := time.NewTicker(cfg.ShardLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := coordinator.RenewShardLease(ctx, owner, shard, cfg.ShardLease); err != nil && ctx.Err() == nil {
+					// Stop processing the shard, another instance may claim it once the lease expires
+					slog.Error("Failed to renew shard lease", "start", shard.Start, "stop", shard.Stop, "error", err)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	if err := extractBlocksAndTransactions(gRPCClient.WithContext(ctx), shard.Start, shard.Stop, outputHandler, cfg, nil); err != nil {
+		return fmt.Errorf("failed to process shard [%d, %d]: %w", shard.Start, shard.Stop, err)
+	}
+
+	if err := coordinator.CompleteShard(gRPCClient.Ctx, owner, shard); err != nil {
+		return fmt.Errorf("failed to complete shard [%d, %d]: %w", shard.Start, shard.Stop, err)
+	}
+	return nil
+}
+
+// shardOwner returns an identifier of this instance, unique across hosts and restarts.
+func shardOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(suffix))
+}
@@ -0,0 +1,97 @@
+package extractor
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+// stopConditions stops the extraction once any of the configured conditions is met: a number of processed blocks,
+// a wall-clock deadline or a block time cutoff. Once a condition is met, no new block is scheduled, and the blocks
+// already in flight are completed.
+type stopConditions struct {
+	maxBlocks       uint64
+	deadline        time.Time
+	blockTimeCutoff time.Time
+
+	mu        sync.Mutex
+	scheduled uint64
+	reason    string
+}
+
+// newStopConditions creates the stop conditions of the configuration, the deadline starting at now.
+// It returns nil when no condition is configured, on which all methods are no-ops.
+func newStopConditions(cfg config.ExtractConfig, now time.Time) *stopConditions {
+	if cfg.MaxBlocks == 0 && cfg.Deadline == 0 && cfg.StopBlockTime == "" {
+		return nil
+	}
+
+	s := &stopConditions{maxBlocks: cfg.MaxBlocks}
+	if cfg.Deadline > 0 {
+		s.deadline = now.Add(cfg.Deadline)
+	}
+	if cfg.StopBlockTime != "" {
+		// The cutoff was validated with the configuration
+		s.blockTimeCutoff, _ = time.Parse(time.RFC3339, cfg.StopBlockTime)
+	}
+	return s
+}
+
+// Next reserves one more block to process, and returns false if a stop condition is met.
+func (s *stopConditions) Next() bool {
+	if s == nil {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkDeadlineLocked()
+	if s.reason == "" && s.maxBlocks > 0 && s.scheduled >= s.maxBlocks {
+		s.setReasonLocked("max blocks")
+	}
+	if s.reason != "" {
+		return false
+	}
+	s.scheduled++
+	return true
+}
+
+// Observe records a processed block, whose time may meet the block time cutoff.
+func (s *stopConditions) Observe(block *models.Block) {
+	if s == nil || s.blockTimeCutoff.IsZero() || block.Time.IsZero() || block.Time.Before(s.blockTimeCutoff) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reason == "" {
+		s.setReasonLocked("block time")
+	}
+}
+
+// Reached returns true if a stop condition is met, i.e., Next returned false, a block met the block time cutoff,
+// or the deadline has passed.
+func (s *stopConditions) Reached() bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkDeadlineLocked()
+	return s.reason != ""
+}
+
+func (s *stopConditions) checkDeadlineLocked() {
+	if s.reason == "" && !s.deadline.IsZero() && !time.Now().Before(s.deadline) {
+		s.setReasonLocked("deadline")
+	}
+}
+
+func (s *stopConditions) setReasonLocked(reason string) {
+	s.reason = reason
+	slog.Info("Stop condition reached, finishing the blocks in flight", "condition", reason, "scheduled_blocks", s.scheduled)
+}
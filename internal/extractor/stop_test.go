@@ -0,0 +1,116 @@
+package extractor_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/extractor"
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+func TestStopConditions(t *testing.T) {
+	const maxScheduled = 10
+	cutoff := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	before, after := cutoff.Add(-time.Hour), cutoff.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		cfg       config.ExtractConfig
+		started   time.Duration // Time elapsed since the conditions were created
+		blocks    []time.Time   // Times of the processed blocks, in scheduling order
+		scheduled int           // Number of blocks scheduled before stopping, at most maxScheduled
+		reached   bool
+	}{
+		{
+			name:      "no condition",
+			scheduled: maxScheduled,
+		},
+		{
+			name:      "max blocks",
+			cfg:       config.ExtractConfig{MaxBlocks: 3},
+			scheduled: 3,
+			reached:   true,
+		},
+		{
+			name:      "deadline ahead",
+			cfg:       config.ExtractConfig{Deadline: time.Hour},
+			scheduled: maxScheduled,
+		},
+		{
+			name:      "deadline passed",
+			cfg:       config.ExtractConfig{Deadline: time.Minute},
+			started:   time.Hour,
+			scheduled: 0,
+			reached:   true,
+		},
+		{
+			name:      "block time cutoff",
+			cfg:       config.ExtractConfig{StopBlockTime: cutoff.Format(time.RFC3339)},
+			blocks:    []time.Time{before, before, cutoff, before},
+			scheduled: 3,
+			reached:   true,
+		},
+		{
+			name:      "block time after the cutoff",
+			cfg:       config.ExtractConfig{StopBlockTime: cutoff.Format(time.RFC3339)},
+			blocks:    []time.Time{after},
+			scheduled: 1,
+			reached:   true,
+		},
+		{
+			name:      "blocks without time",
+			cfg:       config.ExtractConfig{StopBlockTime: cutoff.Format(time.RFC3339)},
+			blocks:    []time.Time{{}, {}, {}},
+			scheduled: maxScheduled,
+		},
+		{
+			name:      "max blocks before the block time cutoff",
+			cfg:       config.ExtractConfig{MaxBlocks: 2, StopBlockTime: cutoff.Format(time.RFC3339)},
+			blocks:    []time.Time{before, before, after},
+			scheduled: 2,
+			reached:   true,
+		},
+		{
+			name:      "block time cutoff before max blocks",
+			cfg:       config.ExtractConfig{MaxBlocks: 5, StopBlockTime: cutoff.Format(time.RFC3339)},
+			blocks:    []time.Time{before, after},
+			scheduled: 2,
+			reached:   true,
+		},
+		{
+			name:      "deadline before max blocks",
+			cfg:       config.ExtractConfig{MaxBlocks: 5, Deadline: time.Minute},
+			started:   time.Hour,
+			scheduled: 0,
+			reached:   true,
+		},
+		{
+			name:      "max blocks before the deadline",
+			cfg:       config.ExtractConfig{MaxBlocks: 5, Deadline: time.Hour},
+			scheduled: 5,
+			reached:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := extractor.NewStopConditions(tt.cfg, time.Now().Add(-tt.started))
+
+			scheduled := 0
+			for scheduled < maxScheduled && s.Next() {
+				if scheduled < len(tt.blocks) {
+					s.Observe(&models.Block{Time: tt.blocks[scheduled]})
+				}
+				scheduled++
+			}
+			assert.Equal(t, tt.scheduled, scheduled)
+			assert.Equal(t, tt.reached, s.Reached())
+			if tt.reached {
+				assert.False(t, s.Next(), "no block is scheduled once a condition is met")
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/utils"
+)
+
+// resolveTimeRange sets the start and stop heights of the configuration from its start and end times, by binary
+// searching the block times between the earliest available and the latest confirmed heights.
+// The start height is the first block at or after the start time, and the stop height is the last block before the
+// end time.
+func resolveTimeRange(gRPCClient *client.GRPCClient, cfg *config.ExtractConfig) error {
+	if cfg.StartTime == "" && cfg.EndTime == "" {
+		return nil
+	}
+
+	// The earliest heights are served by the archive endpoint, if any
+	earliest, err := utils.GetEarliestBlockHeightWithRetry(gRPCClient.ForHeight(0), cfg.MaxRetries)
+	if err != nil {
+		return fmt.Errorf("failed to get the earliest block: %w", err)
+	}
+	latest, err := getLatestConfirmedHeight(gRPCClient, *cfg)
+	if err != nil {
+		return fmt.Errorf("failed to get the latest block: %w", err)
+	}
+	if latest < earliest {
+		return fmt.Errorf("no block available on the node")
+	}
+
+	// The times were validated with the configuration
+	if cfg.StartTime != "" {
+		startTime, _ := time.Parse(time.RFC3339, cfg.StartTime)
+		height, err := findFirstBlockAtOrAfter(gRPCClient, startTime, earliest, latest, cfg.MaxRetries)
+		if err != nil {
+			return err
+		}
+		if height > latest {
+			return fmt.Errorf("no block at or after the start time %s", cfg.StartTime)
+		}
+		cfg.BlockStart = height
+	}
+
+	if cfg.EndTime != "" {
+		endTime, _ := time.Parse(time.RFC3339, cfg.EndTime)
+		height, err := findFirstBlockAtOrAfter(gRPCClient, endTime, earliest, latest, cfg.MaxRetries)
+		if err != nil {
+			return err
+		}
+		if height == earliest {
+			return fmt.Errorf("no block before the end time %s", cfg.EndTime)
+		}
+		cfg.BlockStop = height - 1
+	}
+
+	slog.Info("Resolved time range", "start_time", cfg.StartTime, "end_time", cfg.EndTime, "start", cfg.BlockStart, "stop", cfg.BlockStop)
+	return nil
+}
+
+// findFirstBlockAtOrAfter returns the first height of [low, high] whose block time is at or after t, or high+1 if
+// there is none. Block times are non-decreasing with the height.
+func findFirstBlockAtOrAfter(gRPCClient *client.GRPCClient, t time.Time, low, high uint64, maxRetries uint) (uint64, error) {
+	for low <= high {
+		mid := low + (high-low)/2
+		blockTime, err := GetBlockTime(gRPCClient, mid, maxRetries)
+		if err != nil {
+			return 0, err
+		}
+		if blockTime.Before(t) {
+			low = mid + 1
+		} else {
+			high = mid - 1
+		}
+	}
+	return low, nil
+}
+
+// GetBlockTime returns the time of the block at height, fetched from the node.
+func GetBlockTime(gRPCClient *client.GRPCClient, height uint64, maxRetries uint) (time.Time, error) {
+	params := []byte(fmt.Sprintf(`{"height": %d}`, height))
+	gRPCClient = gRPCClient.ForHeight(height)
+	resp, err := utils.GetGRPCResponse(gRPCClient, gRPCClient.Methods.BlockByHeight, maxRetries, params)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get block %d: %w", height, err)
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(resp, &data); err != nil {
+		return time.Time{}, fmt.Errorf("failed to unmarshal block JSON: %w", err)
+	}
+	useBlockField(data, gRPCClient.Methods)
+
+	blockTime := parseBlockTime(data)
+	if blockTime.IsZero() {
+		return time.Time{}, fmt.Errorf("block %d has no time", height)
+	}
+	return blockTime, nil
+}
@@ -1,18 +1,24 @@
 package extractor
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/events"
+	"github.com/manifest-network/yaci/internal/ica"
 	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/reflection"
 	"github.com/manifest-network/yaci/internal/utils"
 )
 
-func extractTransactions(gRPCClient *client.GRPCClient, data map[string]interface{}, maxRetries uint) ([]*models.Transaction, error) {
+func extractTransactions(gRPCClient *client.GRPCClient, data map[string]interface{}, maxRetries uint, keepRaw bool) ([]*models.Transaction, error) {
 	blockData, exists := data["block"].(map[string]interface{})
 	if !exists || blockData == nil {
 		return nil, nil
@@ -42,11 +48,12 @@ func extractTransactions(gRPCClient *client.GRPCClient, data map[string]interfac
 		hashStr := hex.EncodeToString(hash[:])
 
 		txJsonParams := []byte(fmt.Sprintf(`{"hash": "%s"}`, hashStr))
-		txJsonBytes, err := utils.GetGRPCResponse(
+		txJsonBytes, txRaw, err := utils.GetGRPCResponseWithRaw(
 			gRPCClient,
-			txMethodFullName,
+			gRPCClient.Methods.Tx,
 			maxRetries,
 			txJsonParams,
+			keepRaw,
 		)
 
 		// Handle transaction fetch failures gracefully
@@ -66,9 +73,19 @@ func extractTransactions(gRPCClient *client.GRPCClient, data map[string]interfac
 			continue
 		}
 
+		txJsonBytes, err = decodeTransaction(gRPCClient.Resolver, hashStr, txJsonBytes)
+		if err != nil {
+			return nil, err
+		}
+		txJsonBytes, err = verifyTransactionHash(hashStr, txJsonBytes)
+		if err != nil {
+			return nil, err
+		}
+
 		transaction := &models.Transaction{
 			Hash: hashStr,
 			Data: txJsonBytes,
+			Raw:  txRaw,
 		}
 
 		transactions = append(transactions, transaction)
@@ -76,3 +93,74 @@ func extractTransactions(gRPCClient *client.GRPCClient, data map[string]interfac
 
 	return transactions, nil
 }
+
+// FetchTransaction fetches a single transaction by its hexadecimal hash from the gRPC server.
+func FetchTransaction(gRPCClient *client.GRPCClient, hash string, maxRetries uint) (*models.Transaction, error) {
+	txJsonParams := []byte(fmt.Sprintf(`{"hash": "%s"}`, hash))
+	txJsonBytes, err := utils.GetGRPCResponse(gRPCClient, gRPCClient.Methods.Tx, maxRetries, txJsonParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction %s: %w", hash, err)
+	}
+	txJsonBytes, err = decodeTransaction(gRPCClient.Resolver, hash, txJsonBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Transaction{Hash: hash, Data: txJsonBytes}, nil
+}
+
+// decodeTransaction decodes the parts of a GetTx response that are not decoded by the node: the base64 event
+// attributes of the legacy nodes and the messages of the interchain accounts packets.
+func decodeTransaction(resolver reflection.JSONResolver, hash string, data []byte) ([]byte, error) {
+	data, err := events.DecodeLegacyAttributes(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the events of transaction %s: %w", hash, err)
+	}
+	if data, err = ica.DecodeTransaction(data, resolver); err != nil {
+		return nil, fmt.Errorf("failed to decode the interchain accounts packets of transaction %s: %w", hash, err)
+	}
+	return data, nil
+}
+
+// verifyTransactionHash compares the hash computed from the bytes of a transaction with the hash reported by the node
+// in its GetTx response, and logs a warning when they differ. The computed hash is added to the response when the node
+// omitted it.
+func verifyTransactionHash(hash string, data []byte) ([]byte, error) {
+	var reported struct {
+		TxResponse *struct {
+			TxHash string `json:"txhash"`
+		} `json:"txResponse"`
+	}
+	if err := json.Unmarshal(data, &reported); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transaction %s: %w", hash, err)
+	}
+	if reported.TxResponse == nil {
+		return data, nil
+	}
+	if reported.TxResponse.TxHash != "" {
+		if !strings.EqualFold(reported.TxResponse.TxHash, hash) {
+			slog.Warn("Transaction hash reported by the node does not match the hash of its bytes",
+				"hash", hash,
+				"reported", reported.TxResponse.TxHash)
+		}
+		return data, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var tx map[string]interface{}
+	if err := decoder.Decode(&tx); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transaction %s: %w", hash, err)
+	}
+	txResponse, ok := tx["txResponse"].(map[string]interface{})
+	if !ok {
+		return data, nil
+	}
+	txResponse["txhash"] = strings.ToUpper(hash)
+
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal transaction %s: %w", hash, err)
+	}
+	return data, nil
+}
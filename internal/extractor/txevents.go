@@ -0,0 +1,119 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/reflection"
+	"github.com/manifest-network/yaci/internal/utils"
+)
+
+const (
+	txsEventMethodFullName = "cosmos.tx.v1beta1.Service.GetTxsEvent"
+	txsEventPageSize       = 100 // Maximum page size accepted by the tx service
+)
+
+// extractTxsByEvent pages through the transactions matching cfg.TxEventsQuery with GetTxsEvent, and writes them
+// without their blocks. The query is restricted to the configured start and stop heights, if any.
+// This is much cheaper than scanning every block when only the transactions of a single module are needed.
+func extractTxsByEvent(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, cfg config.ExtractConfig) error {
+	if err := resolveTimeRange(gRPCClient, &cfg); err != nil {
+		return fmt.Errorf("failed to resolve the time range: %w", err)
+	}
+
+	query := cfg.TxEventsQuery
+	if cfg.BlockStart != 0 {
+		query += fmt.Sprintf(" AND tx.height>=%d", cfg.BlockStart)
+	}
+	if cfg.BlockStop != 0 {
+		query += fmt.Sprintf(" AND tx.height<=%d", cfg.BlockStop)
+	}
+	slog.Info("Starting transaction extraction by event", "query", query)
+
+	written := 0
+	for page := 1; ; page++ {
+		if gRPCClient.Ctx.Err() != nil {
+			return gRPCClient.Ctx.Err()
+		}
+
+		params, err := json.Marshal(map[string]interface{}{
+			"query":    query,
+			"page":     page,
+			"limit":    txsEventPageSize,
+			"order_by": "ORDER_BY_ASC",
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal input parameters: %w", err)
+		}
+
+		resp, err := utils.GetGRPCResponse(gRPCClient, txsEventMethodFullName, cfg.MaxRetries, params)
+		if err != nil {
+			return fmt.Errorf("failed to query transactions by event: %w", err)
+		}
+
+		transactions, total, err := parseTxsEventResponse(resp, gRPCClient.Resolver)
+		if err != nil {
+			return err
+		}
+		if len(transactions) == 0 {
+			break
+		}
+
+		if err := outputHandler.WriteTransactions(gRPCClient.Ctx, transactions); err != nil {
+			return fmt.Errorf("failed to write transactions: %w", err)
+		}
+		written += len(transactions)
+		slog.Info("Extracted transactions by event", "page", page, "written", written, "total", total)
+
+		if uint64(written) >= total {
+			break
+		}
+	}
+
+	slog.Info("Transaction extraction by event completed", "transactions", written)
+	return nil
+}
+
+// parseTxsEventResponse returns the transactions of a GetTxsEvent response, in the format of GetTx responses, along
+// with the total number of matching transactions.
+func parseTxsEventResponse(resp []byte, resolver reflection.JSONResolver) ([]*models.Transaction, uint64, error) {
+	var data struct {
+		Txs         []json.RawMessage `json:"txs"`
+		TxResponses []json.RawMessage `json:"txResponses"`
+		Total       uint64            `json:"total,string"`
+	}
+	if err := json.Unmarshal(resp, &data); err != nil {
+		return nil, 0, fmt.Errorf("failed to unmarshal transactions: %w", err)
+	}
+	if len(data.Txs) != len(data.TxResponses) {
+		return nil, 0, fmt.Errorf("mismatched number of transactions and responses: %d != %d", len(data.Txs), len(data.TxResponses))
+	}
+
+	transactions := make([]*models.Transaction, 0, len(data.Txs))
+	for i, txResponse := range data.TxResponses {
+		var hash struct {
+			TxHash string `json:"txhash"`
+		}
+		if err := json.Unmarshal(txResponse, &hash); err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal transaction response: %w", err)
+		}
+
+		txData, err := json.Marshal(map[string]json.RawMessage{"tx": data.Txs[i], "txResponse": txResponse})
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to marshal transaction: %w", err)
+		}
+		if txData, err = decodeTransaction(resolver, hash.TxHash, txData); err != nil {
+			return nil, 0, err
+		}
+		// Transactions extracted from blocks are identified by their lowercase hash
+		transactions = append(transactions, &models.Transaction{Hash: strings.ToLower(hash.TxHash), Data: txData})
+	}
+
+	return transactions, data.Total, nil
+}
@@ -0,0 +1,160 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/utils"
+)
+
+const (
+	upgradeServiceName               = "cosmos.upgrade.v1beta1.Query"
+	upgradeCurrentPlanMethodFullName = "cosmos.upgrade.v1beta1.Query.CurrentPlan"
+	upgradeAppliedPlanMethodFullName = "cosmos.upgrade.v1beta1.Query.AppliedPlan"
+)
+
+// upgradeWatcher tracks the upgrade plan scheduled by the x/upgrade module, records it in the output,
+// and tells the live extractor when the node is expected to be halted for the upgrade.
+type upgradeWatcher struct {
+	enabled    bool
+	maxRetries uint
+	plan       *models.UpgradePlan
+}
+
+func newUpgradeWatcher(gRPCClient *client.GRPCClient, maxRetries uint) *upgradeWatcher {
+	enabled := gRPCClient.Resolver.HasService(upgradeServiceName)
+	if !enabled {
+		slog.Info("The node does not expose the upgrade module, upgrade plans will not be tracked")
+	}
+	return &upgradeWatcher{enabled: enabled, maxRetries: maxRetries}
+}
+
+// Check queries the current upgrade plan after the blocks up to height have been processed.
+// A new plan is recorded in the output; once the height of the tracked plan has been processed,
+// the plan is recorded as applied and is no longer tracked.
+func (w *upgradeWatcher) Check(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, height uint64) error {
+	if !w.enabled {
+		return nil
+	}
+
+	if w.plan != nil && height >= w.plan.Height {
+		if err := w.recordApplied(gRPCClient, outputHandler); err != nil {
+			return err
+		}
+		slog.Info("Upgrade applied", "upgrade", w.plan.Name, "height", w.plan.Height)
+		w.plan = nil
+	}
+
+	plan, err := w.queryCurrentPlan(gRPCClient)
+	if err != nil {
+		return err
+	}
+	if plan == nil || (w.plan != nil && w.plan.Name == plan.Name && w.plan.Height == plan.Height) {
+		return nil
+	}
+
+	plan.FirstSeenHeight = height
+	if err := writeUpgradePlan(gRPCClient.Ctx, outputHandler, plan); err != nil {
+		return err
+	}
+	slog.Info("Upgrade scheduled", "upgrade", plan.Name, "height", plan.Height)
+	w.plan = plan
+	return nil
+}
+
+// HaltingPlan returns the tracked plan if the node is expected to halt before producing the block after height.
+func (w *upgradeWatcher) HaltingPlan(height uint64) *models.UpgradePlan {
+	if w.plan != nil && height+1 >= w.plan.Height {
+		return w.plan
+	}
+	return nil
+}
+
+func (w *upgradeWatcher) queryCurrentPlan(gRPCClient *client.GRPCClient) (*models.UpgradePlan, error) {
+	resp, err := utils.GetGRPCResponse(gRPCClient, upgradeCurrentPlanMethodFullName, w.maxRetries, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query current upgrade plan: %w", err)
+	}
+
+	var current struct {
+		Plan json.RawMessage `json:"plan"`
+	}
+	if err := json.Unmarshal(resp, &current); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal current upgrade plan: %w", err)
+	}
+	if len(current.Plan) == 0 {
+		return nil, nil
+	}
+
+	var plan struct {
+		Name   string `json:"name"`
+		Height string `json:"height"`
+		Info   string `json:"info"`
+	}
+	if err := json.Unmarshal(current.Plan, &plan); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal upgrade plan: %w", err)
+	}
+
+	planHeight, err := strconv.ParseUint(plan.Height, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upgrade plan height %q: %w", plan.Height, err)
+	}
+
+	return &models.UpgradePlan{
+		Name:   plan.Name,
+		Height: planHeight,
+		Info:   plan.Info,
+		Data:   current.Plan,
+	}, nil
+}
+
+// recordApplied records the height at which the tracked plan was applied, as reported by the upgrade module.
+func (w *upgradeWatcher) recordApplied(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler) error {
+	params, err := json.Marshal(map[string]string{"name": w.plan.Name})
+	if err != nil {
+		return fmt.Errorf("failed to marshal applied plan parameters: %w", err)
+	}
+
+	resp, err := utils.GetGRPCResponse(gRPCClient, upgradeAppliedPlanMethodFullName, w.maxRetries, params)
+	if err != nil {
+		return fmt.Errorf("failed to query applied upgrade plan: %w", err)
+	}
+
+	var applied struct {
+		Height string `json:"height"`
+	}
+	if err := json.Unmarshal(resp, &applied); err != nil {
+		return fmt.Errorf("failed to unmarshal applied upgrade plan: %w", err)
+	}
+	if applied.Height == "" {
+		// The plan was cancelled or replaced before its height
+		return nil
+	}
+
+	appliedHeight, err := strconv.ParseUint(applied.Height, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid applied upgrade height %q: %w", applied.Height, err)
+	}
+
+	w.plan.AppliedHeight = appliedHeight
+	return writeUpgradePlan(gRPCClient.Ctx, outputHandler, w.plan)
+}
+
+// writeUpgradePlan records the plan in the output, if the output records the upgrade plans. The plans are tracked
+// either way, to wait for the node during the upgrade halts.
+func writeUpgradePlan(ctx context.Context, outputHandler output.OutputHandler, plan *models.UpgradePlan) error {
+	writer, ok := output.As[output.UpgradePlanWriter](outputHandler)
+	if !ok {
+		return nil
+	}
+	if err := writer.WriteUpgradePlan(ctx, plan); err != nil {
+		return fmt.Errorf("failed to write upgrade plan: %w", err)
+	}
+	return nil
+}
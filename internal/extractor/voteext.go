@@ -0,0 +1,52 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/reflection"
+	"github.com/manifest-network/yaci/internal/voteext"
+)
+
+// voteExtensionDecodingHandler decodes the vote extensions injected into the blocks before they are written to the
+// wrapped output handler.
+type voteExtensionDecodingHandler struct {
+	output.OutputHandler
+	decoder voteext.Decoder
+	// The resolver of the client is refreshed when the node is upgraded, so it is read for every block
+	resolver func() reflection.JSONResolver
+}
+
+func (h *voteExtensionDecodingHandler) Unwrap() output.OutputHandler {
+	return h.OutputHandler
+}
+
+func (h *voteExtensionDecodingHandler) WriteBlockWithTransactions(ctx context.Context, block *models.Block, transactions []*models.Transaction, blockResults *models.BlockResults) error {
+	data, err := voteext.DecodeBlock(block.Data, h.decoder, h.resolver())
+	if err != nil {
+		return fmt.Errorf("failed to decode the vote extensions of block %d: %w", block.ID, err)
+	}
+	block.Data = data
+	return h.OutputHandler.WriteBlockWithTransactions(ctx, block, transactions, blockResults)
+}
+
+// withVoteExtensionDecoding wraps the output handler to decode the vote extensions of the blocks with the decoder of
+// the configuration, if any.
+func withVoteExtensionDecoding(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, cfg config.ExtractConfig) output.OutputHandler {
+	return withVoteExtensionResolver(func() reflection.JSONResolver { return gRPCClient.Resolver }, outputHandler, cfg)
+}
+
+// withVoteExtensionResolver wraps the output handler like withVoteExtensionDecoding, decoding the vote extensions with
+// the descriptors of resolver.
+func withVoteExtensionResolver(resolver func() reflection.JSONResolver, outputHandler output.OutputHandler, cfg config.ExtractConfig) output.OutputHandler {
+	if cfg.VoteExtensionDecoder == "" {
+		return outputHandler
+	}
+	// The decoder was validated with the configuration
+	decoder, _ := voteext.NewDecoder(cfg.VoteExtensionDecoder)
+	return &voteExtensionDecodingHandler{OutputHandler: outputHandler, decoder: decoder, resolver: resolver}
+}
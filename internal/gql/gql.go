@@ -0,0 +1,571 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/manifest-network/yaci/internal/query"
+)
+
+const (
+	// DefaultFirst is the number of nodes of a connection when the query sets no first argument.
+	DefaultFirst = 50
+	// MaxFirst is the highest first argument of a connection.
+	MaxFirst = 500
+	// maxRequestSize is the maximum size of a request body.
+	maxRequestSize = 1 << 20
+)
+
+// Store is the extracted data served by the GraphQL schema. The list methods return the rows after the cursor, nil for
+// the first page, ordered by decreasing height.
+type Store interface {
+	QueryBlock(ctx context.Context, height uint64) (*query.Result, error)
+	QueryBlocks(ctx context.Context, cursor *query.Cursor, limit int) (*query.Result, error)
+	GetBlockData(ctx context.Context, height uint64) (json.RawMessage, error)
+	QueryBlockTransactions(ctx context.Context, height uint64) (*query.Result, error)
+	QueryTransaction(ctx context.Context, hash string) (json.RawMessage, error)
+	QueryTransactionSummary(ctx context.Context, hash string) (*query.Result, error)
+	QueryAddressTransactions(ctx context.Context, address string, cursor *query.Cursor, limit int) (*query.Result, error)
+	QueryMessageTransactions(ctx context.Context, typeURL string, cursor *query.Cursor, limit int) (*query.Result, error)
+	QueryTransactionMessages(ctx context.Context, hash string) (*query.Result, error)
+	QueryTransactionEvents(ctx context.Context, hash string) (*query.Result, error)
+	QueryMessages(ctx context.Context, typeURL string, cursor *query.Cursor, limit int) (*query.Result, error)
+	QueryEvents(ctx context.Context, eventType, key, value string, cursor *query.Cursor, limit int) (*query.Result, error)
+	SearchText(ctx context.Context, text string, limit int) (*query.Result, error)
+	QueryBalances(ctx context.Context, address string, atHeight uint64) (*query.Result, error)
+	QueryDelegations(ctx context.Context, delegator, validator string, atHeight uint64) (*query.Result, error)
+	QueryValidators(ctx context.Context, atHeight uint64) (*query.Result, error)
+}
+
+// jsonScalar is a stored JSON document, served as is.
+var jsonScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "A JSON document, as stored",
+	Serialize:   func(value interface{}) interface{} { return value },
+})
+
+// NewSchema returns the GraphQL schema over the blocks, transactions, messages and events of the store.
+func NewSchema(store Store) (graphql.Schema, error) {
+	var blockType, transactionType *graphql.Object
+
+	// transactionField is the transaction of a message or an event, called once the types are declared
+	transactionField := func() *graphql.Field {
+		return &graphql.Field{
+			Type:        transactionType,
+			Description: "The transaction, null for the finalize block events",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				hash, ok := source(p)["txHash"].(string)
+				if !ok {
+					return nil, nil
+				}
+				return first(store.QueryTransactionSummary(p.Context, hash))
+			},
+		}
+	}
+
+	messageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Message",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"height":      {Type: graphql.Int},
+				"txHash":      {Type: graphql.String},
+				"msgIndex":    {Type: graphql.Int},
+				"typeUrl":     {Type: graphql.String},
+				"data":        {Type: jsonScalar, Description: "The decoded message"},
+				"transaction": transactionField(),
+			}
+		}),
+	})
+
+	eventType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Event",
+		Description: "An attribute of an event, or an event without attribute",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":             {Type: graphql.String},
+				"height":         {Type: graphql.Int},
+				"txHash":         {Type: graphql.String, Description: "The hash of the transaction, null for the finalize block events"},
+				"eventType":      {Type: graphql.String},
+				"attributeKey":   {Type: graphql.String},
+				"attributeValue": {Type: graphql.String},
+				"transaction":    transactionField(),
+			}
+		}),
+	})
+
+	searchMatchType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "SearchMatch",
+		Description: "A memo or an event attribute value matching a text search",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"source":       {Type: graphql.String, Description: "memo or event"},
+				"height":       {Type: graphql.Int},
+				"txHash":       {Type: graphql.String},
+				"eventType":    {Type: graphql.String, Description: "The type of the event, null for the memos"},
+				"attributeKey": {Type: graphql.String, Description: "The key of the attribute, null for the memos"},
+				"text":         {Type: graphql.String, Description: "The memo or the attribute value"},
+				"rank":         {Type: graphql.Float},
+				"transaction":  transactionField(),
+			}
+		}),
+	})
+
+	balanceType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Balance",
+		Fields: graphql.Fields{
+			"address": {Type: graphql.String},
+			"denom":   {Type: graphql.String},
+			"balance": {Type: graphql.String, Description: "The amount, as an integer string"},
+			"height":  {Type: graphql.Int, Description: "The height of the balance, of its last change for the latest balances"},
+		},
+	})
+
+	delegationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Delegation",
+		Fields: graphql.Fields{
+			"height":           {Type: graphql.Int, Description: "The height of the delegation snapshot"},
+			"delegatorAddress": {Type: graphql.String},
+			"validatorAddress": {Type: graphql.String},
+			"shares":           {Type: graphql.String, Description: "The delegator shares, as a decimal string"},
+			"amount":           {Type: graphql.String, Description: "The delegated amount, as an integer string"},
+			"denom":            {Type: graphql.String},
+		},
+	})
+
+	validatorType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Validator",
+		Description: "A validator of the validator set at a height",
+		Fields: graphql.Fields{
+			"height":           {Type: graphql.Int},
+			"consensusAddress": {Type: graphql.String, Description: "The uppercase hexadecimal consensus address"},
+			"operatorAddress":  {Type: graphql.String, Description: "The operator address, null if unknown"},
+			"moniker":          {Type: graphql.String, Description: "The current moniker, null if unknown"},
+			"signed":           {Type: graphql.Boolean, Description: "Whether the validator signed the commit of the height"},
+		},
+	})
+
+	transactionType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Transaction",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"height":       {Type: graphql.Int},
+				"hash":         {Type: graphql.String},
+				"time":         {Type: graphql.DateTime},
+				"code":         {Type: graphql.Int, Description: "The result code, 0 for success"},
+				"firstMessage": {Type: graphql.String, Description: "The type URL of the first message"},
+				"memo":         {Type: graphql.String},
+				"data": {
+					Type:        jsonScalar,
+					Description: "The stored GetTx response",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return document(store.QueryTransaction(p.Context, source(p)["hash"].(string)))
+					},
+				},
+				"messages": {
+					Type: graphql.NewList(messageType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return list(store.QueryTransactionMessages(p.Context, source(p)["hash"].(string)))
+					},
+				},
+				"events": {
+					Type: graphql.NewList(eventType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return list(store.QueryTransactionEvents(p.Context, source(p)["hash"].(string)))
+					},
+				},
+				"block": {
+					Type: blockType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						height, ok := source(p)["height"].(int64)
+						if !ok {
+							return nil, nil
+						}
+						return first(store.QueryBlock(p.Context, uint64(height)))
+					},
+				},
+			}
+		}),
+	})
+
+	blockType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Block",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"height":   {Type: graphql.Int},
+				"hash":     {Type: graphql.String},
+				"time":     {Type: graphql.DateTime},
+				"txs":      {Type: graphql.Int, Description: "The number of transactions"},
+				"proposer": {Type: graphql.String, Description: "The consensus address of the proposer"},
+				"data": {
+					Type:        jsonScalar,
+					Description: "The stored GetBlockWithTxs response",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return document(store.GetBlockData(p.Context, uint64(source(p)["height"].(int64))))
+					},
+				},
+				"transactions": {
+					Type: graphql.NewList(transactionType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return list(store.QueryBlockTransactions(p.Context, uint64(source(p)["height"].(int64))))
+					},
+				},
+			}
+		}),
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"block": {
+				Type: blockType,
+				Args: graphql.FieldConfigArgument{"height": {Type: graphql.NewNonNull(graphql.Int)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					height, err := heightArg(p)
+					if err != nil {
+						return nil, err
+					}
+					return first(store.QueryBlock(p.Context, height))
+				},
+			},
+			"blocks": {
+				Type: connectionType(blockType),
+				Args: pageArgs(nil),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return connection(p, func(cursor *query.Cursor, limit int) (*query.Result, error) {
+						return store.QueryBlocks(p.Context, cursor, limit)
+					}, "height")
+				},
+			},
+			"transaction": {
+				Type: transactionType,
+				Args: graphql.FieldConfigArgument{"hash": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return first(store.QueryTransactionSummary(p.Context, p.Args["hash"].(string)))
+				},
+			},
+			"transactions": {
+				Type:        connectionType(transactionType),
+				Description: "The transactions involving an address or containing a message of a type, exactly one of which must be set",
+				Args: pageArgs(graphql.FieldConfigArgument{
+					"address":     {Type: graphql.String},
+					"messageType": {Type: graphql.String, Description: "A type URL, e.g., /cosmos.bank.v1beta1.MsgSend"},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					address, _ := p.Args["address"].(string)
+					msgType, _ := p.Args["messageType"].(string)
+					if (address == "") == (msgType == "") {
+						return nil, fmt.Errorf("exactly one of address and messageType must be set")
+					}
+					return connection(p, func(cursor *query.Cursor, limit int) (*query.Result, error) {
+						if address != "" {
+							return store.QueryAddressTransactions(p.Context, address, cursor, limit)
+						}
+						return store.QueryMessageTransactions(p.Context, msgType, cursor, limit)
+					}, "height", "hash")
+				},
+			},
+			"messages": {
+				Type: connectionType(messageType),
+				Args: pageArgs(graphql.FieldConfigArgument{"type": {Type: graphql.NewNonNull(graphql.String)}}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return connection(p, func(cursor *query.Cursor, limit int) (*query.Result, error) {
+						return store.QueryMessages(p.Context, p.Args["type"].(string), cursor, limit)
+					}, "height", "tx_hash", "msg_index")
+				},
+			},
+			"events": {
+				Type: connectionType(eventType),
+				Args: pageArgs(graphql.FieldConfigArgument{
+					"type":  {Type: graphql.NewNonNull(graphql.String)},
+					"key":   {Type: graphql.String},
+					"value": {Type: graphql.String},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					key, _ := p.Args["key"].(string)
+					value, _ := p.Args["value"].(string)
+					return connection(p, func(cursor *query.Cursor, limit int) (*query.Result, error) {
+						return store.QueryEvents(p.Context, p.Args["type"].(string), key, value, cursor, limit)
+					}, "height", "id")
+				},
+			},
+			"search": {
+				Type:        graphql.NewList(searchMatchType),
+				Description: "The memos and the event attribute values matching a web search query, the most relevant first",
+				Args: graphql.FieldConfigArgument{
+					"text":  {Type: graphql.NewNonNull(graphql.String), Description: `A web search query, e.g., "exchange deposit" -refund`},
+					"first": {Type: graphql.Int, DefaultValue: DefaultFirst, Description: fmt.Sprintf("The number of matches, at most %d", MaxFirst)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					limit, _ := p.Args["first"].(int)
+					if limit <= 0 || limit > MaxFirst {
+						return nil, fmt.Errorf("first must be between 1 and %d", MaxFirst)
+					}
+					result, err := store.SearchText(p.Context, p.Args["text"].(string), limit)
+					if errors.Is(err, query.ErrTextSearchDisabled) {
+						return nil, err
+					}
+					return list(result, err)
+				},
+			},
+			"balances": {
+				Type:        graphql.NewList(balanceType),
+				Description: "The non-zero balances of an address, from the balance snapshots and the balance deltas",
+				Args: graphql.FieldConfigArgument{
+					"address":  {Type: graphql.NewNonNull(graphql.String)},
+					"atHeight": atHeightArgConfig,
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					height, err := atHeightArg(p)
+					if err != nil {
+						return nil, err
+					}
+					return list(store.QueryBalances(p.Context, p.Args["address"].(string), height))
+				},
+			},
+			"delegations": {
+				Type:        graphql.NewList(delegationType),
+				Description: "The delegations of a delegator or to a validator, exactly one of which must be set, of the latest delegation snapshot at or below the height",
+				Args: graphql.FieldConfigArgument{
+					"delegator": {Type: graphql.String},
+					"validator": {Type: graphql.String},
+					"atHeight":  atHeightArgConfig,
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					delegator, _ := p.Args["delegator"].(string)
+					validator, _ := p.Args["validator"].(string)
+					if (delegator == "") == (validator == "") {
+						return nil, fmt.Errorf("exactly one of delegator and validator must be set")
+					}
+					height, err := atHeightArg(p)
+					if err != nil {
+						return nil, err
+					}
+					return list(store.QueryDelegations(p.Context, delegator, validator, height))
+				},
+			},
+			"validators": {
+				Type:        graphql.NewList(validatorType),
+				Description: "The validator set, from the signatures of the commit of the height, in validator set order",
+				Args:        graphql.FieldConfigArgument{"atHeight": atHeightArgConfig},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					height, err := atHeightArg(p)
+					if err != nil {
+						return nil, err
+					}
+					return list(store.QueryValidators(p.Context, height))
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// connectionType returns the type of a page of nodes, with the cursor of the next page, null on the last page.
+func connectionType(nodeType *graphql.Object) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: nodeType.Name() + "Connection",
+		Fields: graphql.Fields{
+			"nodes": {Type: graphql.NewList(nodeType)},
+			"next":  {Type: graphql.String, Description: "The after argument of the next page"},
+		},
+	})
+}
+
+// pageArgs returns the arguments with the pagination arguments.
+func pageArgs(args graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	if args == nil {
+		args = graphql.FieldConfigArgument{}
+	}
+	args["first"] = &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: DefaultFirst, Description: fmt.Sprintf("The number of nodes, at most %d", MaxFirst)}
+	args["after"] = &graphql.ArgumentConfig{Type: graphql.String, Description: "The next cursor of the previous page"}
+	return args
+}
+
+// connection returns the page of the first and after arguments. The cursor of the next page is read from the
+// heightColumn and the keyColumns of the last row of a full page.
+func connection(p graphql.ResolveParams, page func(*query.Cursor, int) (*query.Result, error), heightColumn string, keyColumns ...string) (interface{}, error) {
+	limit, _ := p.Args["first"].(int)
+	if limit <= 0 || limit > MaxFirst {
+		return nil, fmt.Errorf("first must be between 1 and %d", MaxFirst)
+	}
+	var cursor *query.Cursor
+	if after, _ := p.Args["after"].(string); after != "" {
+		c, err := query.ParseCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		cursor = &c
+	}
+
+	result, err := page(cursor, limit)
+	if errors.Is(err, query.ErrInvalidCursor) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, internalError(err)
+	}
+	var next interface{}
+	if len(result.Rows) == limit {
+		c, err := result.LastCursor(heightColumn, keyColumns...)
+		if err != nil {
+			return nil, internalError(err)
+		}
+		next = c.String()
+	}
+	return map[string]interface{}{"nodes": nodes(result), "next": next}, nil
+}
+
+// nodes returns the rows of the result as objects whose fields are the columns in camel case.
+func nodes(result *query.Result) []map[string]interface{} {
+	fields := make([]string, len(result.Columns))
+	for i, column := range result.Columns {
+		parts := strings.Split(column, "_")
+		for j := 1; j < len(parts); j++ {
+			parts[j] = strings.ToUpper(parts[j][:1]) + parts[j][1:]
+		}
+		fields[i] = strings.Join(parts, "")
+	}
+
+	objects := make([]map[string]interface{}, len(result.Rows))
+	for i, row := range result.Rows {
+		object := make(map[string]interface{}, len(row))
+		for j, value := range row {
+			if fields[j] == "id" {
+				value = fmt.Sprint(value)
+			}
+			object[fields[j]] = value
+		}
+		objects[i] = object
+	}
+	return objects
+}
+
+// first returns the first row of the result, or nil without row.
+func first(result *query.Result, err error) (interface{}, error) {
+	if err != nil {
+		return nil, internalError(err)
+	}
+	if len(result.Rows) == 0 {
+		return nil, nil
+	}
+	return nodes(result)[0], nil
+}
+
+// list returns the rows of the result.
+func list(result *query.Result, err error) (interface{}, error) {
+	if err != nil {
+		return nil, internalError(err)
+	}
+	return nodes(result), nil
+}
+
+// document returns a stored JSON document, or nil if not found.
+func document(data json.RawMessage, err error) (interface{}, error) {
+	if err != nil {
+		return nil, internalError(err)
+	}
+	if data == nil {
+		return nil, nil
+	}
+	return data, nil
+}
+
+func source(p graphql.ResolveParams) map[string]interface{} {
+	return p.Source.(map[string]interface{})
+}
+
+func heightArg(p graphql.ResolveParams) (uint64, error) {
+	height, _ := p.Args["height"].(int)
+	if height < 0 {
+		return 0, fmt.Errorf("invalid height %d", height)
+	}
+	return uint64(height), nil
+}
+
+// atHeightArgConfig is the argument of the height of a state, the latest state if not set.
+var atHeightArgConfig = &graphql.ArgumentConfig{Type: graphql.Int, Description: "The height of the state, the latest state if not set"}
+
+// atHeightArg returns the atHeight argument, 0 if not set.
+func atHeightArg(p graphql.ResolveParams) (uint64, error) {
+	height, ok := p.Args["atHeight"].(int)
+	if !ok {
+		return 0, nil
+	}
+	if height <= 0 {
+		return 0, fmt.Errorf("invalid atHeight %d", height)
+	}
+	return uint64(height), nil
+}
+
+// internalError logs the error of the store and hides its details from the response.
+func internalError(err error) error {
+	slog.Error("Failed to resolve GraphQL query", "error", err)
+	return fmt.Errorf("internal error")
+}
+
+// request is a GraphQL request, as a POST JSON body or as GET parameters.
+type request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// NewHandler returns the HTTP handler of the GraphQL queries of the schema.
+func NewHandler(schema graphql.Schema) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		switch r.Method {
+		case http.MethodGet:
+			params := r.URL.Query()
+			req.Query, req.OperationName = params.Get("query"), params.Get("operationName")
+			if variables := params.Get("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+					writeJSON(w, http.StatusBadRequest, errorResponse("invalid variables"))
+					return
+				}
+			}
+		case http.MethodPost:
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, errorResponse("invalid request body"))
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+			return
+		}
+		if req.Query == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse("missing query"))
+			return
+		}
+
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  req.Query,
+			VariableValues: req.Variables,
+			OperationName:  req.OperationName,
+			Context:        r.Context(),
+		})
+		writeJSON(w, http.StatusOK, result)
+	})
+}
+
+func errorResponse(message string) map[string]interface{} {
+	return map[string]interface{}{"errors": []map[string]string{{"message": message}}}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to write GraphQL response", "error", err)
+	}
+}
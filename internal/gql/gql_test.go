@@ -0,0 +1,360 @@
+package gql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/gql"
+	"github.com/manifest-network/yaci/internal/query"
+)
+
+var (
+	txColumns    = []string{"height", "hash", "time", "code", "first_message", "memo"}
+	blockTime    = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	transactionA = []any{int64(3), "aa", blockTime, int32(0), "/cosmos.bank.v1beta1.MsgSend", nil}
+)
+
+// fakeStore serves blocks 1 to 5, whose block 3 has the transaction aa.
+type fakeStore struct {
+	lastCursor *query.Cursor
+	lastLimit  int
+	lastHeight uint64
+}
+
+func blockRow(height int64) []any {
+	return []any{height, "HASH", blockTime, int32(0), "PROPOSER"}
+}
+
+func (s *fakeStore) QueryBlock(_ context.Context, height uint64) (*query.Result, error) {
+	result := &query.Result{Columns: []string{"height", "hash", "time", "txs", "proposer"}}
+	if height >= 1 && height <= 5 {
+		result.Rows = append(result.Rows, blockRow(int64(height)))
+	}
+	return result, nil
+}
+
+func (s *fakeStore) QueryBlocks(_ context.Context, cursor *query.Cursor, limit int) (*query.Result, error) {
+	s.lastCursor, s.lastLimit = cursor, limit
+	result := &query.Result{Columns: []string{"height", "hash", "time", "txs", "proposer"}}
+	height := int64(5)
+	if cursor != nil {
+		height = int64(cursor.Height) - 1
+	}
+	for ; height > 0 && len(result.Rows) < limit; height-- {
+		result.Rows = append(result.Rows, blockRow(height))
+	}
+	return result, nil
+}
+
+func (s *fakeStore) GetBlockData(_ context.Context, _ uint64) (json.RawMessage, error) {
+	return json.RawMessage(`{"block":{"header":{"chainId":"test"}}}`), nil
+}
+
+func (s *fakeStore) QueryBlockTransactions(_ context.Context, height uint64) (*query.Result, error) {
+	result := &query.Result{Columns: txColumns}
+	if height == 3 {
+		result.Rows = append(result.Rows, transactionA)
+	}
+	return result, nil
+}
+
+func (s *fakeStore) QueryTransaction(_ context.Context, _ string) (json.RawMessage, error) {
+	return json.RawMessage(`{"tx":{}}`), nil
+}
+
+func (s *fakeStore) QueryTransactionSummary(_ context.Context, hash string) (*query.Result, error) {
+	if hash == "fail" {
+		return nil, errors.New("connection refused")
+	}
+	result := &query.Result{Columns: txColumns}
+	if hash == "aa" {
+		result.Rows = append(result.Rows, transactionA)
+	}
+	return result, nil
+}
+
+func (s *fakeStore) QueryAddressTransactions(_ context.Context, _ string, cursor *query.Cursor, _ int) (*query.Result, error) {
+	s.lastCursor = cursor
+	return &query.Result{Columns: txColumns, Rows: [][]any{transactionA}}, nil
+}
+
+func (s *fakeStore) QueryMessageTransactions(_ context.Context, _ string, cursor *query.Cursor, _ int) (*query.Result, error) {
+	s.lastCursor = cursor
+	return &query.Result{Columns: txColumns, Rows: [][]any{transactionA}}, nil
+}
+
+func (s *fakeStore) QueryTransactionMessages(_ context.Context, hash string) (*query.Result, error) {
+	return &query.Result{
+		Columns: []string{"height", "tx_hash", "msg_index", "type_url", "data"},
+		Rows:    [][]any{{int64(3), hash, int32(0), "/cosmos.bank.v1beta1.MsgSend", map[string]any{"amount": "1"}}},
+	}, nil
+}
+
+func (s *fakeStore) QueryTransactionEvents(_ context.Context, hash string) (*query.Result, error) {
+	return &query.Result{
+		Columns: []string{"id", "height", "tx_hash", "event_type", "attribute_key", "attribute_value"},
+		Rows:    [][]any{{int64(42), int64(3), hash, "transfer", "amount", "1umfx"}},
+	}, nil
+}
+
+func (s *fakeStore) QueryMessages(_ context.Context, typeURL string, cursor *query.Cursor, _ int) (*query.Result, error) {
+	s.lastCursor = cursor
+	return &query.Result{
+		Columns: []string{"height", "tx_hash", "msg_index", "type_url", "data"},
+		Rows:    [][]any{{int64(3), "aa", int32(1), typeURL, map[string]any{}}},
+	}, nil
+}
+
+func (s *fakeStore) QueryEvents(_ context.Context, eventType, _, _ string, cursor *query.Cursor, _ int) (*query.Result, error) {
+	s.lastCursor = cursor
+	return &query.Result{
+		Columns: []string{"id", "height", "tx_hash", "event_type", "attribute_key", "attribute_value"},
+		Rows:    [][]any{{int64(43), int64(4), nil, eventType, "validator", "val1"}},
+	}, nil
+}
+
+func (s *fakeStore) SearchText(_ context.Context, text string, limit int) (*query.Result, error) {
+	s.lastLimit = limit
+	if text == "disabled" {
+		return nil, query.ErrTextSearchDisabled
+	}
+	return &query.Result{
+		Columns: []string{"source", "height", "tx_hash", "event_type", "attribute_key", "text", "rank"},
+		Rows:    [][]any{{"memo", int64(3), "aa", nil, nil, text, float32(0.5)}},
+	}, nil
+}
+
+func (s *fakeStore) QueryBalances(_ context.Context, address string, atHeight uint64) (*query.Result, error) {
+	s.lastHeight = atHeight
+	return &query.Result{
+		Columns: []string{"address", "denom", "balance", "height"},
+		Rows:    [][]any{{address, "umfx", "100", int64(atHeight)}},
+	}, nil
+}
+
+func (s *fakeStore) QueryDelegations(_ context.Context, delegator, validator string, atHeight uint64) (*query.Result, error) {
+	s.lastHeight = atHeight
+	return &query.Result{
+		Columns: []string{"height", "delegator_address", "validator_address", "shares", "amount", "denom"},
+		Rows:    [][]any{{int64(atHeight), delegator + "1abc", validator + "valoper1abc", "10.5", "10", "umfx"}},
+	}, nil
+}
+
+func (s *fakeStore) QueryValidators(_ context.Context, atHeight uint64) (*query.Result, error) {
+	s.lastHeight = atHeight
+	if atHeight == 7 {
+		return nil, errors.New("connection refused")
+	}
+	return &query.Result{
+		Columns: []string{"height", "consensus_address", "operator_address", "moniker", "signed"},
+		Rows:    [][]any{{int64(atHeight), "AB12", nil, nil, false}},
+	}, nil
+}
+
+func newHandler(t *testing.T, store gql.Store) http.Handler {
+	schema, err := gql.NewSchema(store)
+	require.NoError(t, err)
+	return gql.NewHandler(schema)
+}
+
+// post runs the GraphQL query and returns its response.
+func post(t *testing.T, handler http.Handler, q string, variables map[string]any) map[string]any {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{"query": q, "variables": variables})
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var response map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	return response
+}
+
+func TestBlocks(t *testing.T) {
+	store := &fakeStore{}
+	handler := newHandler(t, store)
+
+	response := post(t, handler, `{ blocks(first: 2) { nodes { height hash time } next } }`, nil)
+	require.Nil(t, response["errors"])
+	assert.Equal(t, map[string]any{"blocks": map[string]any{
+		"nodes": []any{
+			map[string]any{"height": 5.0, "hash": "HASH", "time": "2026-01-01T00:00:00Z"},
+			map[string]any{"height": 4.0, "hash": "HASH", "time": "2026-01-01T00:00:00Z"},
+		},
+		"next": "4",
+	}}, response["data"])
+
+	response = post(t, handler, `query($after: String) { blocks(after: $after) { nodes { height } next } }`, map[string]any{"after": "2"})
+	require.Nil(t, response["errors"])
+	assert.Equal(t, &query.Cursor{Height: 2}, store.lastCursor)
+	assert.Equal(t, gql.DefaultFirst, store.lastLimit)
+	assert.Equal(t, map[string]any{"blocks": map[string]any{
+		"nodes": []any{map[string]any{"height": 1.0}},
+		"next":  nil,
+	}}, response["data"])
+}
+
+func TestNestedFields(t *testing.T) {
+	handler := newHandler(t, &fakeStore{})
+
+	response := post(t, handler, `{
+		block(height: 3) {
+			data
+			transactions {
+				hash
+				memo
+				messages { msgIndex typeUrl data transaction { hash } }
+				events { id eventType attributeValue }
+				block { height }
+			}
+		}
+	}`, nil)
+	require.Nil(t, response["errors"])
+	assert.Equal(t, map[string]any{"block": map[string]any{
+		"data": map[string]any{"block": map[string]any{"header": map[string]any{"chainId": "test"}}},
+		"transactions": []any{map[string]any{
+			"hash": "aa",
+			"memo": nil,
+			"messages": []any{map[string]any{
+				"msgIndex":    0.0,
+				"typeUrl":     "/cosmos.bank.v1beta1.MsgSend",
+				"data":        map[string]any{"amount": "1"},
+				"transaction": map[string]any{"hash": "aa"},
+			}},
+			"events": []any{map[string]any{"id": "42", "eventType": "transfer", "attributeValue": "1umfx"}},
+			"block":  map[string]any{"height": 3.0},
+		}},
+	}}, response["data"])
+
+	response = post(t, handler, `{ block(height: 9) { height } transaction(hash: "bb") { hash } }`, nil)
+	require.Nil(t, response["errors"])
+	assert.Equal(t, map[string]any{"block": nil, "transaction": nil}, response["data"])
+}
+
+func TestConnections(t *testing.T) {
+	store := &fakeStore{}
+	handler := newHandler(t, store)
+
+	response := post(t, handler, `{ transactions(address: "manifest1abc", first: 1, after: "10:bb") { nodes { hash } next } }`, nil)
+	require.Nil(t, response["errors"])
+	assert.Equal(t, &query.Cursor{Height: 10, Key: "bb"}, store.lastCursor)
+	assert.Equal(t, "3:aa", response["data"].(map[string]any)["transactions"].(map[string]any)["next"])
+
+	response = post(t, handler, `{ messages(type: "/cosmos.bank.v1beta1.MsgSend", first: 1) { nodes { typeUrl } next } }`, nil)
+	require.Nil(t, response["errors"])
+	assert.Equal(t, "3:aa:1", response["data"].(map[string]any)["messages"].(map[string]any)["next"])
+
+	response = post(t, handler, `{ events(type: "slash", first: 1) { nodes { txHash transaction { hash } } next } }`, nil)
+	require.Nil(t, response["errors"])
+	assert.Equal(t, map[string]any{"events": map[string]any{
+		"nodes": []any{map[string]any{"txHash": nil, "transaction": nil}},
+		"next":  "4:43",
+	}}, response["data"])
+}
+
+func TestSearch(t *testing.T) {
+	store := &fakeStore{}
+	handler := newHandler(t, store)
+
+	response := post(t, handler, `{ search(text: "deposit", first: 10) { source height eventType text rank transaction { hash } } }`, nil)
+	require.Nil(t, response["errors"])
+	assert.Equal(t, 10, store.lastLimit)
+	assert.Equal(t, map[string]any{"search": []any{map[string]any{
+		"source":      "memo",
+		"height":      3.0,
+		"eventType":   nil,
+		"text":        "deposit",
+		"rank":        0.5,
+		"transaction": map[string]any{"hash": "aa"},
+	}}}, response["data"])
+}
+
+func TestAtHeight(t *testing.T) {
+	store := &fakeStore{}
+	handler := newHandler(t, store)
+
+	response := post(t, handler, `{ balances(address: "manifest1abc", atHeight: 4) { address denom balance height } }`, nil)
+	require.Nil(t, response["errors"])
+	assert.Equal(t, uint64(4), store.lastHeight)
+	assert.Equal(t, map[string]any{"balances": []any{map[string]any{
+		"address": "manifest1abc", "denom": "umfx", "balance": "100", "height": 4.0,
+	}}}, response["data"])
+
+	response = post(t, handler, `{ delegations(validator: "manifest") { height delegatorAddress validatorAddress shares } }`, nil)
+	require.Nil(t, response["errors"])
+	assert.Equal(t, uint64(0), store.lastHeight)
+	assert.Equal(t, map[string]any{"delegations": []any{map[string]any{
+		"height": 0.0, "delegatorAddress": "1abc", "validatorAddress": "manifestvaloper1abc", "shares": "10.5",
+	}}}, response["data"])
+
+	response = post(t, handler, `query($height: Int) { validators(atHeight: $height) { height consensusAddress operatorAddress signed } }`, map[string]any{"height": 4})
+	require.Nil(t, response["errors"])
+	assert.Equal(t, uint64(4), store.lastHeight)
+	assert.Equal(t, map[string]any{"validators": []any{map[string]any{
+		"height": 4.0, "consensusAddress": "AB12", "operatorAddress": nil, "signed": false,
+	}}}, response["data"])
+}
+
+func TestErrors(t *testing.T) {
+	handler := newHandler(t, &fakeStore{})
+
+	tests := []struct {
+		name    string
+		query   string
+		message string
+	}{
+		{name: "no transaction filter", query: `{ transactions { next } }`, message: "exactly one of address and messageType"},
+		{name: "both transaction filters", query: `{ transactions(address: "a", messageType: "b") { next } }`, message: "exactly one of address and messageType"},
+		{name: "first too high", query: `{ blocks(first: 501) { next } }`, message: "first must be between"},
+		{name: "invalid cursor", query: `{ blocks(after: "abc") { next } }`, message: "invalid cursor"},
+		{name: "store failure", query: `{ transaction(hash: "fail") { hash } }`, message: "internal error"},
+		{name: "text search disabled", query: `{ search(text: "disabled") { text } }`, message: "text search is not enabled"},
+		{name: "no delegation filter", query: `{ delegations { height } }`, message: "exactly one of delegator and validator"},
+		{name: "invalid at height", query: `{ validators(atHeight: 0) { height } }`, message: "invalid atHeight"},
+		{name: "state store failure", query: `{ validators(atHeight: 7) { height } }`, message: "internal error"},
+		{name: "unknown field", query: `{ blocks { nodes { unknown } } }`, message: "Cannot query field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := post(t, handler, tt.query, nil)
+			errs, ok := response["errors"].([]any)
+			require.True(t, ok)
+			require.NotEmpty(t, errs)
+			assert.Contains(t, errs[0].(map[string]any)["message"], tt.message)
+			assert.NotContains(t, errs[0].(map[string]any)["message"], "connection refused")
+		})
+	}
+}
+
+func TestHandlerRequests(t *testing.T) {
+	handler := newHandler(t, &fakeStore{})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(`{ block(height: 1) { height } }`), nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"data":{"block":{"height":1}}}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader("not json")))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/graphql", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/graphql", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
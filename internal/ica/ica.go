@@ -0,0 +1,162 @@
+// Package ica decodes the messages executed on host chains through interchain accounts (ICS-27).
+package ica
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/manifest-network/yaci/internal/reflection"
+)
+
+const (
+	// HostPort is the port of the interchain accounts host module
+	HostPort = "icahost"
+
+	// MessagesField is the JSON field the decoded messages of the ICA packets are added to
+	MessagesField = "icaMessages"
+
+	cosmosTxFullName = "ibc.applications.interchain_accounts.v1.CosmosTx"
+	executeTxType    = "TYPE_EXECUTE_TX"
+	recvPacketType   = "/ibc.core.channel.v1.MsgRecvPacket"
+	sendTxType       = "/ibc.applications.interchain_accounts.controller.v1.MsgSendTx"
+	execType         = "/cosmos.authz.v1beta1.MsgExec"
+)
+
+// packetData is the JSON of an interchain accounts packet.
+type packetData struct {
+	Type string `json:"type"`
+	Data []byte `json:"data"`
+	Memo string `json:"memo"`
+}
+
+// DecodeTransaction decodes the messages of the interchain accounts packets of the transaction of a GetTx response:
+// the packets received by the host chain with MsgRecvPacket and the packets sent by the controller chain with
+// MsgSendTx, including those executed with MsgExec. The decoded messages are added to the packet messages in the
+// MessagesField field. The data is returned as is if it has no interchain accounts packet to decode.
+func DecodeTransaction(data []byte, resolver reflection.JSONResolver) ([]byte, error) {
+	if !bytes.Contains(data, []byte(`"`+recvPacketType+`"`)) && !bytes.Contains(data, []byte(`"`+sendTxType+`"`)) {
+		return data, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var tx map[string]interface{}
+	if err := decoder.Decode(&tx); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transaction: %w", err)
+	}
+
+	txData, _ := tx["tx"].(map[string]interface{})
+	body, _ := txData["body"].(map[string]interface{})
+	messages, _ := body["messages"].([]interface{})
+	if !decodeMessages(messages, resolver) {
+		return data, nil
+	}
+	return json.Marshal(tx)
+}
+
+// decodeMessages decodes the interchain accounts packets of the messages, and returns true if any was decoded.
+func decodeMessages(messages []interface{}, resolver reflection.JSONResolver) bool {
+	decoded := false
+	for _, m := range messages {
+		msg, ok := m.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		var packet *packetData
+		switch msg["@type"] {
+		case recvPacketType:
+			packet = receivedPacketData(msg)
+		case sendTxType:
+			packet = sentPacketData(msg)
+		case execType:
+			inner, _ := msg["msgs"].([]interface{})
+			decoded = decodeMessages(inner, resolver) || decoded
+			continue
+		}
+		if packet == nil || packet.Type != executeTxType {
+			continue
+		}
+
+		inner, err := decodeCosmosTx(packet.Data, resolver)
+		if err != nil {
+			continue
+		}
+		msg[MessagesField] = inner
+		decoded = true
+	}
+	return decoded
+}
+
+// receivedPacketData returns the interchain accounts packet data of a MsgRecvPacket, or nil if it is not sent to the
+// host port.
+func receivedPacketData(msg map[string]interface{}) *packetData {
+	packet, _ := msg["packet"].(map[string]interface{})
+	if packet["destinationPort"] != HostPort {
+		return nil
+	}
+	encoded, _ := packet["data"].(string)
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil
+	}
+
+	var data packetData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil
+	}
+	return &data
+}
+
+// sentPacketData returns the interchain accounts packet data of a MsgSendTx.
+func sentPacketData(msg map[string]interface{}) *packetData {
+	raw, err := json.Marshal(msg["packetData"])
+	if err != nil {
+		return nil
+	}
+
+	var data packetData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil
+	}
+	return &data
+}
+
+// decodeCosmosTx decodes the messages of a CosmosTx, encoded in protobuf or, on the channels using the proto3json
+// encoding, in JSON.
+func decodeCosmosTx(data []byte, resolver reflection.JSONResolver) ([]interface{}, error) {
+	var cosmosTx struct {
+		Messages []interface{} `json:"messages"`
+	}
+
+	if len(data) > 0 && data[0] == '{' {
+		if err := json.Unmarshal(data, &cosmosTx); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal CosmosTx: %w", err)
+		}
+		return cosmosTx.Messages, nil
+	}
+
+	mt, err := resolver.FindMessageByName(cosmosTxFullName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve CosmosTx: %w", err)
+	}
+	msg := mt.New()
+	if err := proto.Unmarshal(data, msg.Interface()); err != nil {
+		return nil, fmt.Errorf("failed to decode CosmosTx: %w", err)
+	}
+	decoded, err := reflection.MarshalJSON(msg.Interface(), resolver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal CosmosTx: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(decoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(&cosmosTx); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal CosmosTx: %w", err)
+	}
+	return cosmosTx.Messages, nil
+}
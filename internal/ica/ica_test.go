@@ -0,0 +1,122 @@
+package ica_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/typepb"
+
+	"github.com/manifest-network/yaci/internal/ica"
+)
+
+// cosmosTxTypes returns the types of the CosmosTx message of the interchain accounts and of the messages it contains.
+func cosmosTxTypes(t *testing.T) (*protoregistry.Types, protoreflect.MessageType) {
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("ibc/applications/interchain_accounts/v1/packet.proto"),
+		Package:    proto.String("ibc.applications.interchain_accounts.v1"),
+		Dependency: []string{"google/protobuf/any.proto"},
+		Syntax:     proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("CosmosTx"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("messages"),
+				JsonName: proto.String("messages"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+				TypeName: proto.String(".google.protobuf.Any"),
+			}},
+		}},
+	}, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+
+	cosmosTx := dynamicpb.NewMessageType(fd.Messages().ByName("CosmosTx"))
+	types := new(protoregistry.Types)
+	require.NoError(t, types.RegisterMessage(cosmosTx))
+	require.NoError(t, types.RegisterMessage((&typepb.Option{}).ProtoReflect().Type()))
+	return types, cosmosTx
+}
+
+func TestDecodeTransaction(t *testing.T) {
+	types, cosmosTxType := cosmosTxTypes(t)
+
+	inner, err := anypb.New(&typepb.Option{Name: "inner"})
+	require.NoError(t, err)
+	cosmosTx := cosmosTxType.New()
+	messages := cosmosTx.Mutable(cosmosTx.Descriptor().Fields().ByName("messages")).List()
+	messages.Append(protoreflect.ValueOfMessage(inner.ProtoReflect()))
+	cosmosTxBytes, err := proto.Marshal(cosmosTx.Interface())
+	require.NoError(t, err)
+	cosmosTxData := base64.StdEncoding.EncodeToString(cosmosTxBytes)
+
+	packet := func(cosmosTx string) string {
+		data, err := json.Marshal(map[string]string{"type": "TYPE_EXECUTE_TX", "data": cosmosTx, "memo": ""})
+		require.NoError(t, err)
+		return base64.StdEncoding.EncodeToString(data)
+	}
+	decoded := `[{"@type":"type.googleapis.com/google.protobuf.Option","name":"inner"}]`
+	tx := func(messages string) string {
+		return fmt.Sprintf(`{"tx":{"body":{"messages":[%s]}},"txResponse":{"height":"1"}}`, messages)
+	}
+
+	tests := []struct {
+		name     string
+		messages string
+		expected string
+	}{
+		{
+			name:     "no packet",
+			messages: `{"@type":"/cosmos.bank.v1beta1.MsgSend"}`,
+			expected: `{"@type":"/cosmos.bank.v1beta1.MsgSend"}`,
+		},
+		{
+			name:     "received packet",
+			messages: fmt.Sprintf(`{"@type":"/ibc.core.channel.v1.MsgRecvPacket","packet":{"destinationPort":"icahost","data":%q}}`, packet(cosmosTxData)),
+			expected: fmt.Sprintf(`{"@type":"/ibc.core.channel.v1.MsgRecvPacket","packet":{"destinationPort":"icahost","data":%q},"icaMessages":%s}`, packet(cosmosTxData), decoded),
+		},
+		{
+			name:     "received packet in proto3json",
+			messages: fmt.Sprintf(`{"@type":"/ibc.core.channel.v1.MsgRecvPacket","packet":{"destinationPort":"icahost","data":%q}}`, packet(base64.StdEncoding.EncodeToString([]byte(`{"messages":[{"@type":"/cosmos.bank.v1beta1.MsgSend"}]}`)))),
+			expected: fmt.Sprintf(`{"@type":"/ibc.core.channel.v1.MsgRecvPacket","packet":{"destinationPort":"icahost","data":%q},"icaMessages":[{"@type":"/cosmos.bank.v1beta1.MsgSend"}]}`, packet(base64.StdEncoding.EncodeToString([]byte(`{"messages":[{"@type":"/cosmos.bank.v1beta1.MsgSend"}]}`)))),
+		},
+		{
+			name:     "received transfer packet",
+			messages: fmt.Sprintf(`{"@type":"/ibc.core.channel.v1.MsgRecvPacket","packet":{"destinationPort":"transfer","data":%q}}`, packet(cosmosTxData)),
+			expected: fmt.Sprintf(`{"@type":"/ibc.core.channel.v1.MsgRecvPacket","packet":{"destinationPort":"transfer","data":%q}}`, packet(cosmosTxData)),
+		},
+		{
+			name:     "sent packet",
+			messages: fmt.Sprintf(`{"@type":"/ibc.applications.interchain_accounts.controller.v1.MsgSendTx","owner":"owner","connectionId":"connection-0","packetData":{"type":"TYPE_EXECUTE_TX","data":%q}}`, cosmosTxData),
+			expected: fmt.Sprintf(`{"@type":"/ibc.applications.interchain_accounts.controller.v1.MsgSendTx","owner":"owner","connectionId":"connection-0","packetData":{"type":"TYPE_EXECUTE_TX","data":%q},"icaMessages":%s}`, cosmosTxData, decoded),
+		},
+		{
+			name:     "sent packet in authz exec",
+			messages: fmt.Sprintf(`{"@type":"/cosmos.authz.v1beta1.MsgExec","msgs":[{"@type":"/ibc.applications.interchain_accounts.controller.v1.MsgSendTx","packetData":{"type":"TYPE_EXECUTE_TX","data":%q}}]}`, cosmosTxData),
+			expected: fmt.Sprintf(`{"@type":"/cosmos.authz.v1beta1.MsgExec","msgs":[{"@type":"/ibc.applications.interchain_accounts.controller.v1.MsgSendTx","packetData":{"type":"TYPE_EXECUTE_TX","data":%q},"icaMessages":%s}]}`, cosmosTxData, decoded),
+		},
+		{
+			name:     "undecodable packet",
+			messages: `{"@type":"/ibc.applications.interchain_accounts.controller.v1.MsgSendTx","packetData":{"type":"TYPE_EXECUTE_TX","data":"AQID"}}`,
+			expected: `{"@type":"/ibc.applications.interchain_accounts.controller.v1.MsgSendTx","packetData":{"type":"TYPE_EXECUTE_TX","data":"AQID"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := ica.DecodeTransaction([]byte(tx(tt.messages)), types)
+			require.NoError(t, err)
+			assert.JSONEq(t, tx(tt.expected), string(data))
+		})
+	}
+}
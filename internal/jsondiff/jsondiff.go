@@ -0,0 +1,136 @@
+// Package jsondiff computes the structural differences between two JSON documents.
+package jsondiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Missing is the value reported for a side of a difference where the path does not exist.
+const Missing = "<missing>"
+
+// Difference is a single difference between two JSON documents.
+type Difference struct {
+	Path  string
+	Left  interface{}
+	Right interface{}
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s: %s != %s", d.Path, format(d.Left), format(d.Right))
+}
+
+// Compare returns the differences between two JSON documents, ordered by path.
+// Objects are compared key by key and arrays element by element.
+func Compare(left, right []byte) ([]Difference, error) {
+	l, err := decode(left)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode left document: %w", err)
+	}
+	r, err := decode(right)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode right document: %w", err)
+	}
+
+	var diffs []Difference
+	compare("", l, r, &diffs)
+	return diffs, nil
+}
+
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func compare(path string, left, right interface{}, diffs *[]Difference) {
+	switch l := left.(type) {
+	case map[string]interface{}:
+		if r, ok := right.(map[string]interface{}); ok {
+			compareObjects(path, l, r, diffs)
+			return
+		}
+	case []interface{}:
+		if r, ok := right.([]interface{}); ok {
+			compareArrays(path, l, r, diffs)
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(left, right) {
+		*diffs = append(*diffs, Difference{Path: rootPath(path), Left: left, Right: right})
+	}
+}
+
+func compareObjects(path string, left, right map[string]interface{}, diffs *[]Difference) {
+	keys := make([]string, 0, len(left)+len(right))
+	for k := range left {
+		keys = append(keys, k)
+	}
+	for k := range right {
+		if _, ok := left[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		childPath := k
+		if path != "" {
+			childPath = path + "." + k
+		}
+
+		l, lok := left[k]
+		r, rok := right[k]
+		switch {
+		case !lok:
+			*diffs = append(*diffs, Difference{Path: childPath, Left: Missing, Right: r})
+		case !rok:
+			*diffs = append(*diffs, Difference{Path: childPath, Left: l, Right: Missing})
+		default:
+			compare(childPath, l, r, diffs)
+		}
+	}
+}
+
+func compareArrays(path string, left, right []interface{}, diffs *[]Difference) {
+	n := max(len(left), len(right))
+	for i := 0; i < n; i++ {
+		childPath := path + "[" + strconv.Itoa(i) + "]"
+		switch {
+		case i >= len(left):
+			*diffs = append(*diffs, Difference{Path: childPath, Left: Missing, Right: right[i]})
+		case i >= len(right):
+			*diffs = append(*diffs, Difference{Path: childPath, Left: left[i], Right: Missing})
+		default:
+			compare(childPath, left[i], right[i], diffs)
+		}
+	}
+}
+
+func rootPath(path string) string {
+	if path == "" {
+		return "$"
+	}
+	return path
+}
+
+// format renders a value of a difference as compact JSON.
+func format(v interface{}) string {
+	if s, ok := v.(string); ok && s == Missing {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
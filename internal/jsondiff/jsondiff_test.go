@@ -0,0 +1,80 @@
+package jsondiff_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/jsondiff"
+)
+
+func TestCompare(t *testing.T) {
+	cases := []struct {
+		name     string
+		left     string
+		right    string
+		expected []string
+		error    string
+	}{
+		{
+			name:  "identical documents",
+			left:  `{"a": 1, "b": [1, 2, {"c": "d"}]}`,
+			right: `{"b": [1, 2, {"c": "d"}], "a": 1}`,
+		},
+		{
+			name:     "changed scalar",
+			left:     `{"header": {"height": "10", "time": "2024-01-01T00:00:00Z"}}`,
+			right:    `{"header": {"height": "10", "time": "2024-01-01T00:00:01Z"}}`,
+			expected: []string{`header.time: "2024-01-01T00:00:00Z" != "2024-01-01T00:00:01Z"`},
+		},
+		{
+			name:     "missing keys on both sides",
+			left:     `{"a": 1, "b": 2}`,
+			right:    `{"b": 2, "c": 3}`,
+			expected: []string{`a: 1 != <missing>`, `c: <missing> != 3`},
+		},
+		{
+			name:     "array length mismatch",
+			left:     `{"events": [{"type": "a"}]}`,
+			right:    `{"events": [{"type": "b"}, {"type": "c"}]}`,
+			expected: []string{`events[0].type: "a" != "b"`, `events[1]: <missing> != {"type":"c"}`},
+		},
+		{
+			name:     "type mismatch",
+			left:     `{"a": "1"}`,
+			right:    `{"a": 1}`,
+			expected: []string{`a: "1" != 1`},
+		},
+		{
+			name:     "root mismatch",
+			left:     `1`,
+			right:    `2`,
+			expected: []string{`$: 1 != 2`},
+		},
+		{
+			name:  "invalid document",
+			left:  `{`,
+			right: `{}`,
+			error: "failed to decode left document",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			diffs, err := jsondiff.Compare([]byte(tc.left), []byte(tc.right))
+			if tc.error != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.error)
+				return
+			}
+			require.NoError(t, err)
+
+			var actual []string
+			for _, d := range diffs {
+				actual = append(actual, d.String())
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
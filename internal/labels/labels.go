@@ -0,0 +1,118 @@
+// Package labels reads the human-readable labels of known addresses, e.g., exchanges, validators, module accounts and
+// team wallets.
+package labels
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/manifest-network/yaci/internal/addresses"
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+// ModuleCategory is the category of the labels of the module accounts.
+const ModuleCategory = "module"
+
+// modules are the names of the module accounts of the Cosmos SDK, labeled with their name.
+var modules = []string{
+	"bonded_tokens_pool",
+	"not_bonded_tokens_pool",
+	"distribution",
+	"fee_collector",
+	"gov",
+	"mint",
+	"transfer",
+	"protocolpool",
+	"wasm",
+	"tokenfactory",
+}
+
+// ParseCSV parses the labels of a CSV document of address,label[,category] records, labeled with source. A first record
+// whose first field is "address" is a header, and the lines starting with # are comments.
+func ParseCSV(r io.Reader, source string) ([]*models.AddressLabel, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var labels []*models.AddressLabel
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		if first && strings.EqualFold(strings.TrimSpace(record[0]), "address") {
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("invalid record on line %d, expected address,label[,category]", line)
+		}
+		label := &models.AddressLabel{
+			Address: strings.TrimSpace(record[0]),
+			Label:   strings.TrimSpace(record[1]),
+			Source:  source,
+		}
+		if len(record) == 3 {
+			label.Category = strings.TrimSpace(record[2])
+		}
+		if err := Validate(label); err != nil {
+			return nil, fmt.Errorf("invalid record on line %d: %w", line, err)
+		}
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
+
+// Validate returns an error if the label has no label or its address is not a valid bech32 address.
+func Validate(label *models.AddressLabel) error {
+	if !addresses.Valid(label.Address) {
+		return fmt.Errorf("invalid address %q", label.Address)
+	}
+	if label.Label == "" {
+		return fmt.Errorf("missing label of address %s", label.Address)
+	}
+	return nil
+}
+
+// ModuleAccounts returns the labels of the module accounts of the Cosmos SDK with the bech32 prefix of the chain.
+func ModuleAccounts(bech32Prefix string) ([]*models.AddressLabel, error) {
+	labels := make([]*models.AddressLabel, 0, len(modules))
+	for _, module := range modules {
+		address, err := addresses.ModuleAddress(bech32Prefix, module)
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, &models.AddressLabel{
+			Address:  address,
+			Label:    module,
+			Category: ModuleCategory,
+			Source:   ModuleCategory,
+		})
+	}
+	return labels, nil
+}
+
+// Merge returns the labels of the sets, the labels of the later sets replacing those of the same address in the former.
+func Merge(sets ...[]*models.AddressLabel) []*models.AddressLabel {
+	var merged []*models.AddressLabel
+	index := make(map[string]int)
+	for _, set := range sets {
+		for _, label := range set {
+			if i, ok := index[label.Address]; ok {
+				merged[i] = label
+				continue
+			}
+			index[label.Address] = len(merged)
+			merged = append(merged, label)
+		}
+	}
+	return merged
+}
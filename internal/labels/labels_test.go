@@ -0,0 +1,71 @@
+package labels_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/labels"
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+const address = "cosmos1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc5lzv7xu"
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []*models.AddressLabel
+		wantErr  bool
+	}{
+		{
+			name:     "without category",
+			input:    address + ",Team wallet\n",
+			expected: []*models.AddressLabel{{Address: address, Label: "Team wallet", Source: "labels.csv"}},
+		},
+		{
+			name:     "with header and comments",
+			input:    "address,label,category\n# Exchanges\n" + address + ", Exchange A, exchange\n",
+			expected: []*models.AddressLabel{{Address: address, Label: "Exchange A", Category: "exchange", Source: "labels.csv"}},
+		},
+		{name: "empty", input: ""},
+		{name: "missing label", input: address + "\n", wantErr: true},
+		{name: "empty label", input: address + ",\n", wantErr: true},
+		{name: "too many fields", input: address + ",a,b,c\n", wantErr: true},
+		{name: "invalid address", input: "cosmos1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc5lzv7xv,Team wallet\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := labels.ParseCSV(strings.NewReader(tt.input), "labels.csv")
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, parsed)
+		})
+	}
+}
+
+func TestModuleAccounts(t *testing.T) {
+	accounts, err := labels.ModuleAccounts("cosmos")
+	require.NoError(t, err)
+	assert.Contains(t, accounts, &models.AddressLabel{
+		Address:  "cosmos17xpfvakm2amg962yls6f84z3kell8c5lserqta",
+		Label:    "fee_collector",
+		Category: labels.ModuleCategory,
+		Source:   labels.ModuleCategory,
+	})
+}
+
+func TestMerge(t *testing.T) {
+	module := &models.AddressLabel{Address: address, Label: "gov", Source: "module"}
+	other := &models.AddressLabel{Address: "cosmos17xpfvakm2amg962yls6f84z3kell8c5lserqta", Label: "Fees", Source: "config"}
+	override := &models.AddressLabel{Address: address, Label: "Governance", Source: "config"}
+
+	merged := labels.Merge([]*models.AddressLabel{module, other}, []*models.AddressLabel{override})
+	assert.Equal(t, []*models.AddressLabel{override, other}, merged)
+}
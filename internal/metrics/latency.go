@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// BlockIndexingLatency is the delay between the on-chain time of a block and the time it was committed to the output.
+var BlockIndexingLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    prometheus.BuildFQName("yaci", "block", "indexing_latency_seconds"),
+	Help:    "Delay between the block time and the time the block was committed to the output",
+	Buckets: []float64{0.5, 1, 2, 5, 10, 15, 30, 60, 120, 300, 600},
+})
+
+// ObserveBlockIndexingLatency records the indexing latency of a block committed at indexedAt.
+// Blocks without a known time are ignored.
+func ObserveBlockIndexingLatency(blockTime, indexedAt time.Time) {
+	if blockTime.IsZero() {
+		return
+	}
+	BlockIndexingLatency.Observe(indexedAt.Sub(blockTime).Seconds())
+}
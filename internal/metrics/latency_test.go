@@ -0,0 +1,38 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/manifest-network/yaci/internal/metrics"
+)
+
+func TestObserveBlockIndexingLatency(t *testing.T) {
+	indexedAt := time.Now()
+	metrics.ObserveBlockIndexingLatency(time.Time{}, indexedAt)
+	metrics.ObserveBlockIndexingLatency(indexedAt.Add(-3*time.Second), indexedAt)
+
+	expected := `
+# HELP yaci_block_indexing_latency_seconds Delay between the block time and the time the block was committed to the output
+# TYPE yaci_block_indexing_latency_seconds histogram
+yaci_block_indexing_latency_seconds_bucket{le="0.5"} 0
+yaci_block_indexing_latency_seconds_bucket{le="1"} 0
+yaci_block_indexing_latency_seconds_bucket{le="2"} 0
+yaci_block_indexing_latency_seconds_bucket{le="5"} 1
+yaci_block_indexing_latency_seconds_bucket{le="10"} 1
+yaci_block_indexing_latency_seconds_bucket{le="15"} 1
+yaci_block_indexing_latency_seconds_bucket{le="30"} 1
+yaci_block_indexing_latency_seconds_bucket{le="60"} 1
+yaci_block_indexing_latency_seconds_bucket{le="120"} 1
+yaci_block_indexing_latency_seconds_bucket{le="300"} 1
+yaci_block_indexing_latency_seconds_bucket{le="600"} 1
+yaci_block_indexing_latency_seconds_bucket{le="+Inf"} 1
+yaci_block_indexing_latency_seconds_sum 3
+yaci_block_indexing_latency_seconds_count 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(metrics.BlockIndexingLatency, strings.NewReader(expected)))
+}
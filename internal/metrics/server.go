@@ -42,6 +42,7 @@ func CreateMetricsServer(db *sql.DB, bech32Prefix, addr string) (*http.Server, e
 		return nil, err
 	}
 
+	allCollectors = append(allCollectors, BlockIndexingLatency)
 	for _, c := range allCollectors {
 		if err := prometheus.Register(c); err != nil {
 			var are prometheus.AlreadyRegisteredError
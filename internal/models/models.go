@@ -1,21 +1,241 @@
 package models
 
+import "time"
+
 // Block represents a blockchain block.
+// Hash and ParentHash are the base64-encoded hashes of the block and of the previous block, empty if unknown.
+// Time is the on-chain time of the block, zero if unknown.
+// IndexedAt is set by the output handler to the time at which the block was committed.
+// Raw is the protobuf encoding of the GetBlockWithTxs response as sent by the node, nil unless it is stored.
 type Block struct {
-	ID   uint64
-	Data []byte
+	ID         uint64
+	Data       []byte
+	Raw        []byte
+	Hash       string
+	ParentHash string
+	Time       time.Time
+	IndexedAt  time.Time
 }
 
 // Transaction represents a blockchain transaction.
+// Raw is the protobuf encoding of the GetTx response as sent by the node, nil unless it is stored.
 type Transaction struct {
 	Hash string
 	Data []byte
+	Raw  []byte
 }
 
 // BlockResults represents the results of block finalization.
 // Contains finalize_block_events (slashing, jailing, validator updates),
 // transaction results, and validator updates.
+// Raw is the protobuf encoding of the GetBlockResults response as sent by the node, nil unless it is stored.
 type BlockResults struct {
 	Height uint64
 	Data   []byte
+	Raw    []byte
+}
+
+// GovProposal represents the latest known lifecycle state of a governance proposal.
+// Tally and Deposits are nil when they were not queried.
+type GovProposal struct {
+	ID       uint64
+	Status   string
+	Data     []byte
+	Tally    []byte
+	Deposits []byte
+	Height   uint64
+}
+
+// Delegation represents a staking delegation at a given height.
+// Shares and Amount are decimal strings as returned by the staking module.
+type Delegation struct {
+	DelegatorAddress string
+	ValidatorAddress string
+	Shares           string
+	Amount           string
+	Denom            string
+}
+
+// UnbondingDelegationEntry represents a single entry of an unbonding delegation at a given height.
+type UnbondingDelegationEntry struct {
+	DelegatorAddress string
+	ValidatorAddress string
+	CreationHeight   uint64
+	CompletionTime   time.Time
+	InitialBalance   string
+	Balance          string
+}
+
+// DelegationSnapshot represents all staking delegations and unbonding delegations at a given height.
+type DelegationSnapshot struct {
+	Height      uint64
+	Delegations []*Delegation
+	Unbondings  []*UnbondingDelegationEntry
+}
+
+// Balance represents the balance of a single denom held by an address.
+// Amount is a decimal string as returned by the bank module.
+type Balance struct {
+	Address string
+	Denom   string
+	Amount  string
+}
+
+// BalanceSnapshot represents all bank balances at a given height.
+type BalanceSnapshot struct {
+	Height   uint64
+	Balances []*Balance
+}
+
+// Coin represents an amount of a denom. Amount is a decimal string.
+type Coin struct {
+	Denom  string
+	Amount string
+}
+
+// DenomMetadata represents the bank metadata of a denom.
+// Exponent is the exponent of the display unit relative to the base denom.
+type DenomMetadata struct {
+	Base     string
+	Display  string
+	Symbol   string
+	Exponent uint32
+	Data     []byte
+}
+
+// SupplySnapshot represents the total supply of every denom, and the known denom metadata, at a given height.
+type SupplySnapshot struct {
+	Height   uint64
+	Supply   []*Coin
+	Metadata []*DenomMetadata
+}
+
+// IBCClient represents the state of an IBC light client.
+type IBCClient struct {
+	ClientID string
+	Type     string
+	ChainID  string
+	Status   string
+	Data     []byte
+}
+
+// IBCConnection represents the state of an IBC connection and its counterparty.
+type IBCConnection struct {
+	ConnectionID             string
+	ClientID                 string
+	State                    string
+	CounterpartyClientID     string
+	CounterpartyConnectionID string
+	Data                     []byte
+}
+
+// IBCChannel represents the state of an IBC channel and its counterparty.
+type IBCChannel struct {
+	PortID                string
+	ChannelID             string
+	ConnectionID          string
+	State                 string
+	Ordering              string
+	Version               string
+	CounterpartyPortID    string
+	CounterpartyChannelID string
+	Data                  []byte
+}
+
+// IBCState represents the IBC topology of the chain at a given height.
+type IBCState struct {
+	Height      uint64
+	Clients     []*IBCClient
+	Connections []*IBCConnection
+	Channels    []*IBCChannel
+}
+
+// Validator represents a staking validator.
+// ConsensusAddress is the uppercase hexadecimal address of its consensus public key, as used in the block headers, empty
+// if the key type is not supported.
+type Validator struct {
+	OperatorAddress  string
+	ConsensusAddress string
+	Moniker          string
+	Status           string
+	Jailed           bool
+	Data             []byte
+}
+
+// ValidatorSet represents all the validators, whatever their bonding status, at a given height.
+type ValidatorSet struct {
+	Height     uint64
+	Validators []*Validator
+}
+
+// UpgradePlan represents an upgrade scheduled by the x/upgrade module.
+// AppliedHeight is 0 until the upgrade has been applied.
+type UpgradePlan struct {
+	Name            string
+	Height          uint64
+	Info            string
+	Data            []byte
+	FirstSeenHeight uint64
+	AppliedHeight   uint64
+}
+
+// Shard is a range of heights processed by a single instance when several instances share a backfill.
+type Shard struct {
+	Start uint64
+	Stop  uint64
+}
+
+// Reorg represents divergent blocks found in the output and replaced by the blocks of the node, e.g., after a node rollback.
+// OldHashes and NewHashes are the hashes of the replaced heights, from ForkHeight upward.
+type Reorg struct {
+	ForkHeight     uint64
+	DetectedHeight uint64
+	OldHashes      []string
+	NewHashes      []string
+}
+
+// HeightRange is an inclusive range of block heights.
+type HeightRange struct {
+	Start uint64
+	Stop  uint64
+}
+
+// Contains returns true if height is in the range.
+func (r HeightRange) Contains(height uint64) bool {
+	return height >= r.Start && height <= r.Stop
+}
+
+// ExtraQueryResult represents the response of a user-defined gRPC query at a given height.
+type ExtraQueryResult struct {
+	Name   string
+	Height uint64
+	Data   []byte
+}
+
+// StreamedBlock represents the ABCI data of a block streamed by a node through an ADR-038 listener plugin.
+// The FinalizeBlock request and response and the Commit response are kept as raw protobuf.
+type StreamedBlock struct {
+	Height                uint64
+	FinalizeBlockRequest  []byte
+	FinalizeBlockResponse []byte
+	CommitResponse        []byte
+	StateChanges          []*StateChange
+}
+
+// StateChange represents a key written or deleted in a store of the application state.
+type StateChange struct {
+	StoreKey string
+	Key      []byte
+	Value    []byte
+	Delete   bool
+}
+
+// AddressLabel represents a human-readable label of an address, e.g., an exchange or a team wallet.
+// Category groups the labels, e.g., exchange or module, and may be empty. Source records where the label was imported
+// from, e.g., config or the name of a CSV file.
+type AddressLabel struct {
+	Address  string
+	Label    string
+	Category string
+	Source   string
 }
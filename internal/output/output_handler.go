@@ -2,17 +2,27 @@ package output
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/manifest-network/yaci/internal/models"
 )
 
+// OutputHandler writes the extracted blocks and transactions to an output. The other data are written through the
+// optional interfaces below, which an output handler implements if its output supports them. They are looked up with
+// As, through the output handlers wrapping it.
 type OutputHandler interface {
-	// WriteBlockWithTransactions writes a block and its transactions to the output.
-	WriteBlockWithTransactions(ctx context.Context, block *models.Block, transactions []*models.Transaction) error
+	// WriteBlockWithTransactions writes a block, its transactions and, when not nil, its block results
+	// (finalize_block_events) to the output.
+	// Everything belonging to a height must become visible atomically with the block itself, so that
+	// consumers can treat the presence of a block as a watermark for all data derived from that height.
+	// With a context of WithBlockReplacement, the stored block of the height and its transactions are deleted
+	// atomically with the write.
+	WriteBlockWithTransactions(ctx context.Context, block *models.Block, transactions []*models.Transaction, blockResults *models.BlockResults) error
 
-	// WriteBlockResults writes block results (finalize_block_events) to the output.
-	// Block results contain consensus-level events like slashing, jailing, and validator updates.
-	WriteBlockResults(ctx context.Context, blockResults *models.BlockResults) error
+	// WriteTransactions inserts or updates transactions extracted without their block.
+	WriteTransactions(ctx context.Context, transactions []*models.Transaction) error
 
 	// GetLatestBlock returns the latest block from the output.
 	GetLatestBlock(ctx context.Context) (*models.Block, error)
@@ -26,3 +36,148 @@ type OutputHandler interface {
 	// Close closes the output handler.
 	Close() error
 }
+
+// GovWriter is implemented by the output handlers recording the governance proposals.
+type GovWriter interface {
+	// WriteGovProposals inserts or updates the state of governance proposals.
+	WriteGovProposals(ctx context.Context, proposals []*models.GovProposal) error
+}
+
+// UpgradePlanWriter is implemented by the output handlers recording the upgrade plans.
+type UpgradePlanWriter interface {
+	// WriteUpgradePlan inserts or updates a scheduled upgrade plan.
+	WriteUpgradePlan(ctx context.Context, plan *models.UpgradePlan) error
+}
+
+// SnapshotWriter is implemented by the output handlers recording the state queried by the snapshot jobs.
+type SnapshotWriter interface {
+	// WriteDelegationSnapshot replaces the staking delegation snapshot taken at the snapshot height.
+	WriteDelegationSnapshot(ctx context.Context, snapshot *models.DelegationSnapshot) error
+
+	// WriteBalanceSnapshot replaces the bank balance snapshot taken at the snapshot height.
+	WriteBalanceSnapshot(ctx context.Context, snapshot *models.BalanceSnapshot) error
+
+	// WriteSupplySnapshot replaces the total supply recorded at the snapshot height and updates the denom metadata.
+	WriteSupplySnapshot(ctx context.Context, snapshot *models.SupplySnapshot) error
+
+	// WriteExtraQueryResults inserts or updates the responses of user-defined queries.
+	WriteExtraQueryResults(ctx context.Context, results []*models.ExtraQueryResult) error
+
+	// WriteIBCState inserts or updates the IBC clients, connections and channels.
+	WriteIBCState(ctx context.Context, state *models.IBCState) error
+
+	// WriteValidators inserts or updates the validators and the mapping of their consensus addresses to their operator
+	// addresses.
+	WriteValidators(ctx context.Context, set *models.ValidatorSet) error
+}
+
+// StreamWriter is implemented by the output handlers recording the data streamed by a node.
+type StreamWriter interface {
+	// WriteStreamedBlock writes the ABCI data and the state changes of a block streamed by a node, replacing the ones
+	// already written for the height.
+	WriteStreamedBlock(ctx context.Context, block *models.StreamedBlock) error
+}
+
+// ShardCoordinator is implemented by the output handlers sharing the shards of a backfill between instances.
+type ShardCoordinator interface {
+	// RegisterShards records the shards of a backfill shared by several instances. Existing shards are kept, and an
+	// error is returned if one of them stops at another height than the shard of the same start.
+	RegisterShards(ctx context.Context, shards []models.Shard) error
+
+	// ClaimShard leases the first shard of [start, stop] that is neither completed nor leased by another owner,
+	// and returns nil if there is none.
+	ClaimShard(ctx context.Context, owner string, start, stop uint64, lease time.Duration) (*models.Shard, error)
+
+	// RenewShardLease extends the lease of a shard claimed by owner. It fails if the shard was claimed by another owner.
+	RenewShardLease(ctx context.Context, owner string, shard models.Shard, lease time.Duration) error
+
+	// CompleteShard marks a shard claimed by owner as completed.
+	CompleteShard(ctx context.Context, owner string, shard models.Shard) error
+
+	// CountPendingShards returns the number of shards of [start, stop] that are not completed.
+	CountPendingShards(ctx context.Context, start, stop uint64) (int, error)
+}
+
+// LeaderElector is implemented by the output handlers electing a leader among the instances sharing the output.
+type LeaderElector interface {
+	// TryAcquireLeadership makes the instance the leader among the instances sharing the output under name, if no other
+	// instance is. The leadership is released automatically if the instance crashes.
+	TryAcquireLeadership(ctx context.Context, name string) (bool, error)
+
+	// CheckLeadership returns an error if the instance is no longer the leader.
+	CheckLeadership(ctx context.Context) error
+
+	// ReleaseLeadership releases the leadership, if held.
+	ReleaseLeadership(ctx context.Context) error
+}
+
+// ReorgRecorder is implemented by the output handlers verifying that the stored blocks link to their parent and
+// recording the replaced ones.
+type ReorgRecorder interface {
+	// FindChainMismatch returns the first height of [start, stop] whose parent hash differs from the hash of the
+	// stored previous block, and false if all the stored blocks of the range link to their parent.
+	FindChainMismatch(ctx context.Context, start, stop uint64) (uint64, bool, error)
+
+	// GetBlockHash returns the hash of the stored block at height, or an empty string if unknown.
+	GetBlockHash(ctx context.Context, height uint64) (string, error)
+
+	// WriteReorg records divergent blocks that were replaced.
+	WriteReorg(ctx context.Context, reorg *models.Reorg) error
+}
+
+// RangeRecorder is implemented by the output handlers recording the height ranges which are not reported as missing.
+type RangeRecorder interface {
+	// WriteSkippedRanges records height ranges skipped on purpose, which are not reported as missing.
+	WriteSkippedRanges(ctx context.Context, ranges []models.HeightRange) error
+
+	// WritePrunedRange records a height range that the node cannot serve because it was pruned, which is not reported
+	// as missing blocks.
+	WritePrunedRange(ctx context.Context, r models.HeightRange) error
+}
+
+// Wrapper is implemented by the output handlers wrapping another one, e.g., to rewrite the data before writing it.
+type Wrapper interface {
+	// Unwrap returns the wrapped output handler.
+	Unwrap() OutputHandler
+}
+
+// As returns the first output handler implementing T among h and the output handlers it wraps, and false if none does.
+// A wrapper rewriting the data written through T implements T itself, so that it is not bypassed.
+func As[T any](h OutputHandler) (T, bool) {
+	for h != nil {
+		if t, ok := h.(T); ok {
+			return t, true
+		}
+		w, ok := h.(Wrapper)
+		if !ok {
+			break
+		}
+		h = w.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+// Require returns the first output handler implementing T, as As does, or an error wrapping errors.ErrUnsupported
+// if the output does not support feature.
+func Require[T any](h OutputHandler, feature string) (T, error) {
+	t, ok := As[T](h)
+	if !ok {
+		return t, fmt.Errorf("the output does not support %s: %w", feature, errors.ErrUnsupported)
+	}
+	return t, nil
+}
+
+type blockReplacementKey struct{}
+
+// WithBlockReplacement returns a copy of ctx whose blocks written with WriteBlockWithTransactions replace the stored
+// blocks of their height, e.g., the divergent blocks of a reorg, rather than being merged with them.
+func WithBlockReplacement(ctx context.Context) context.Context {
+	return context.WithValue(ctx, blockReplacementKey{}, true)
+}
+
+// ReplacesBlocks returns true if the blocks written with ctx replace the stored blocks of their height.
+func ReplacesBlocks(ctx context.Context) bool {
+	replaces, _ := ctx.Value(blockReplacementKey{}).(bool)
+	return replaces
+}
@@ -0,0 +1,63 @@
+package output_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+)
+
+// govHandler is an output handler recording the governance proposals.
+type govHandler struct {
+	output.OutputHandler
+}
+
+func (h *govHandler) WriteGovProposals(context.Context, []*models.GovProposal) error {
+	return nil
+}
+
+// wrapper is an output handler wrapping another one.
+type wrapper struct {
+	output.OutputHandler
+}
+
+func (w *wrapper) Unwrap() output.OutputHandler {
+	return w.OutputHandler
+}
+
+func TestAs(t *testing.T) {
+	gov := &govHandler{}
+	tests := []struct {
+		name     string
+		handler  output.OutputHandler
+		expected output.GovWriter
+	}{
+		{name: "implemented", handler: gov, expected: gov},
+		{name: "wrapped", handler: &wrapper{&wrapper{gov}}, expected: gov},
+		{name: "not implemented", handler: &wrapper{&wrapper{}}},
+		{name: "nil", handler: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer, ok := output.As[output.GovWriter](tt.handler)
+			assert.Equal(t, tt.expected != nil, ok)
+			assert.Equal(t, tt.expected, writer)
+		})
+	}
+}
+
+func TestRequire(t *testing.T) {
+	writer, err := output.Require[output.GovWriter](&wrapper{&govHandler{}}, "governance proposals")
+	require.NoError(t, err)
+	assert.NotNil(t, writer)
+
+	_, err = output.Require[output.ShardCoordinator](&wrapper{&govHandler{}}, "sharded extractions")
+	assert.ErrorIs(t, err, errors.ErrUnsupported)
+	assert.ErrorContains(t, err, "the output does not support sharded extractions")
+}
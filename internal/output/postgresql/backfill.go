@@ -0,0 +1,230 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// backfillSource is a table whose rows are read by backfills, by ranges of the height expression.
+type backfillSource struct {
+	table  string
+	height string
+}
+
+var (
+	transactionsSource = backfillSource{table: "api.transactions_raw", height: "(data->'txResponse'->>'height')::BIGINT"}
+	blocksSource       = backfillSource{table: "api.blocks_raw", height: "id"}
+	blockResultsSource = backfillSource{table: "api.block_results_raw", height: "height"}
+	txSignersSource    = backfillSource{table: "api.tx_signers", height: "height"}
+)
+
+// extract returns the query calling the extraction function on the rows of the heights $1 to $2 matching the filter,
+// in height order, so that the rows derived from earlier heights are found, e.g., the proposal of a withdrawal.
+func (s backfillSource) extract(call, filter string) string {
+	return fmt.Sprintf(`SELECT %s FROM %s WHERE %s BETWEEN $1 AND $2 AND (%s) ORDER BY %s;`, call, s.table, s.height, filter, s.height)
+}
+
+// backfill fills a derived table from the rows indexed before the migration that added it. The migrations only create
+// the derived tables and their triggers, which fill them from the rows written afterwards, so that upgrading a large
+// index does not rewrite it in a single transaction.
+type backfill struct {
+	version uint // The migration adding the derived table
+	name    string
+	source  backfillSource
+	query   string // Fills the rows of the heights $1 to $2
+	finish  string // Runs once after the batches, if set
+}
+
+// backfills are the backfills of the derived tables, in the order of their migrations, since the later tables are
+// derived from the earlier ones.
+var backfills = []backfill{
+	{version: 9, name: "ibc_packets", source: transactionsSource, query: transactionsSource.extract("api.extract_ibc_packets(id, data)", `
+		data->'txResponse'->'events' @> '[{"type": "send_packet"}]'
+		OR data->'txResponse'->'events' @> '[{"type": "recv_packet"}]'
+		OR data->'txResponse'->'events' @> '[{"type": "acknowledge_packet"}]'
+		OR data->'txResponse'->'events' @> '[{"type": "timeout_packet"}]'
+		OR data->'txResponse'->'events' @> '[{"type": "timeout_on_close_packet"}]'`)},
+	{version: 18, name: "messages", source: transactionsSource, query: transactionsSource.extract("api.extract_messages(id, data)",
+		`data->'tx'->'body'->'messages' IS NOT NULL`)},
+	{version: 19, name: "tx_events", source: transactionsSource, query: transactionsSource.extract("api.extract_tx_events(id, data)",
+		`jsonb_typeof(data->'txResponse'->'events') = 'array'`)},
+	{version: 19, name: "block_events", source: blockResultsSource, query: blockResultsSource.extract("api.extract_block_events(height, data)",
+		`jsonb_typeof(data->'finalizeBlockEvents') = 'array'`)},
+	{version: 23, name: "wasm_contracts", source: transactionsSource, query: transactionsSource.extract("api.extract_wasm(id, data)", `
+		data->'tx'->'body'->'messages' @> '[{"@type": "/cosmwasm.wasm.v1.MsgExecuteContract"}]'
+		OR data->'tx'->'body'->'messages' @> '[{"@type": "/cosmwasm.wasm.v1.MsgInstantiateContract"}]'
+		OR data->'tx'->'body'->'messages' @> '[{"@type": "/cosmwasm.wasm.v1.MsgInstantiateContract2"}]'
+		OR data->'txResponse'->'events' @> '[{"attributes": [{"key": "_contract_address"}]}]'`)},
+	{version: 24, name: "wasm_lifecycle", source: transactionsSource, query: transactionsSource.extract("api.extract_wasm_lifecycle(id, data)", `
+		data->'txResponse'->'events' @> '[{"type": "store_code"}]'
+		OR data->'txResponse'->'events' @> '[{"type": "instantiate"}]'
+		OR data->'txResponse'->'events' @> '[{"type": "migrate"}]'
+		OR data->'txResponse'->'events' @> '[{"type": "update_contract_admin"}]'`)},
+	{version: 25, name: "evm_transactions", source: transactionsSource, query: transactionsSource.extract("api.extract_evm_transactions(id, data)", `
+		data->'tx'->'body'->'messages' @> '[{"@type": "/ethermint.evm.v1.MsgEthereumTx"}]'
+		OR data->'tx'->'body'->'messages' @> '[{"@type": "/cosmos.evm.vm.v1.MsgEthereumTx"}]'`)},
+	{version: 26, name: "authz_messages", source: transactionsSource, query: transactionsSource.extract("api.extract_messages(id, data)",
+		`data->'tx'->'body'->'messages' @> '[{"@type": "/cosmos.authz.v1beta1.MsgExec"}]'`)},
+	{version: 26, name: "feegrant_usage", source: transactionsSource, query: transactionsSource.extract("api.extract_feegrant_usage(id, data)", `
+		COALESCE(data->'tx'->'authInfo'->'fee'->>'granter', '') <> ''
+		OR data->'txResponse'->'events' @> '[{"type": "use_feegrant"}]'`)},
+	{version: 28, name: "ica_messages", source: transactionsSource, query: transactionsSource.extract("api.extract_messages(id, data)",
+		`jsonb_path_exists(data->'tx'->'body'->'messages', 'strict $.** ? (exists(@."icaMessages"))')`)},
+	{version: 29, name: "groups", source: transactionsSource, query: transactionsSource.extract("api.extract_group(id, data)",
+		`jsonb_path_exists(data->'tx'->'body'->'messages', '$[*] ? (@."@type" starts with "/cosmos.group.v1.")')`)},
+	{version: 30, name: "poa_tx_actions", source: transactionsSource, query: transactionsSource.extract("api.extract_poa_tx_actions(id, data)", `
+		jsonb_path_exists(data->'tx'->'body'->'messages', '$[*] ? (@."@type" starts with "/strangelove_ventures.poa.v1.")')
+		OR data->'txResponse'->'events' @> '[{"type": "cosmos.group.v1.EventExec"}]'`)},
+	{version: 30, name: "poa_block_actions", source: blockResultsSource, query: blockResultsSource.extract("api.extract_poa_block_actions(height, data)", `
+		jsonb_array_length(COALESCE(data->'validatorUpdates', '[]'::JSONB)) > 0
+		OR jsonb_typeof(data->'finalizeBlockEvents') = 'array'`)},
+	{version: 31, name: "tokenfactory", source: transactionsSource, query: transactionsSource.extract("api.extract_tokenfactory(id, data)", `
+		jsonb_path_exists(data->'tx'->'body'->'messages', '$[*] ? (@."@type" starts with "/osmosis.tokenfactory.v1beta1.")')
+		OR data->'txResponse'->'events' @> '[{"type": "cosmos.group.v1.EventExec"}]'`)},
+	{version: 33, name: "tx_signers", source: transactionsSource, query: transactionsSource.extract("api.extract_tx_signers(id, data)",
+		`jsonb_typeof(data->'tx'->'authInfo'->'signerInfos') = 'array'`)},
+	{version: 34, name: "multisig_signers", source: transactionsSource, query: transactionsSource.extract("api.extract_multisig_signers(id, data)",
+		`data->'tx'->'authInfo'->'signerInfos' @> '[{"modeInfo": {"multi": {}}}]'`)},
+	{version: 37, name: "block_time", source: blocksSource, query: `
+		UPDATE api.blocks_raw
+		SET block_time = (data->'block'->'header'->>'time')::TIMESTAMPTZ
+		WHERE id BETWEEN $1 AND $2 AND block_time IS NULL AND data->'block'->'header'->>'time' IS NOT NULL;`},
+	{version: 40, name: "transfers", source: transactionsSource, query: transactionsSource.extract("api.extract_transfers(id, data)", `
+		data->'txResponse'->'events' @> '[{"type": "transfer"}]'
+		OR data->'txResponse'->'events' @> '[{"type": "wasm"}]'`)},
+	{version: 41, name: "tx_balance_deltas", source: transactionsSource, query: transactionsSource.extract("api.extract_tx_balance_deltas(id, data)", `
+		data->'txResponse'->'events' @> '[{"type": "coin_spent"}]'
+		OR data->'txResponse'->'events' @> '[{"type": "coin_received"}]'`)},
+	{version: 41, name: "block_balance_deltas", source: blockResultsSource, query: blockResultsSource.extract("api.extract_block_balance_deltas(height, data)", `
+		data->'finalizeBlockEvents' @> '[{"type": "coin_spent"}]'
+		OR data->'finalizeBlockEvents' @> '[{"type": "coin_received"}]'`)},
+	// In height order so that the absent validators resolve from the previous commits, then the windows ending at the
+	// latest height
+	{version: 42, name: "commit_signatures", source: blocksSource, query: blocksSource.extract("api.extract_commit_signatures(id, data)",
+		`jsonb_typeof(data->'block'->'lastCommit'->'signatures') = 'array'`), finish: `
+		SELECT api.refresh_validator_uptime(h.height, w.window_size)
+		FROM api.uptime_windows w
+		CROSS JOIN (SELECT MAX(height) AS height FROM api.commit_signatures) h
+		WHERE h.height IS NOT NULL;`},
+	{version: 43, name: "slashing_tx_events", source: transactionsSource, query: transactionsSource.extract("api.extract_slashing_tx_events(id, data)",
+		`jsonb_path_exists(data->'tx'->'body'->'messages', '$[*] ? (@."@type" == "/cosmos.slashing.v1beta1.MsgUnjail")')`)},
+	{version: 43, name: "slashing_block_events", source: blockResultsSource, query: blockResultsSource.extract("api.extract_slashing_block_events(height, data)",
+		`data->'finalizeBlockEvents' @> '[{"type": "slash"}]'`)},
+	{version: 44, name: "gov_votes", source: transactionsSource, query: transactionsSource.extract("api.extract_gov_votes(id, data)", `
+		jsonb_path_exists(data->'tx'->'body'->'messages', '$[*] ? (@."@type" like_regex "^/cosmos\\.gov\\.v1(beta1)?\\.MsgVote")')
+		OR jsonb_path_exists(data->'tx'->'body'->'messages', '$[*].msgs[*] ? (@."@type" like_regex "^/cosmos\\.gov\\.v1(beta1)?\\.MsgVote")')`)},
+	{version: 45, name: "staking_tx_events", source: transactionsSource, query: transactionsSource.extract("api.extract_staking_tx_events(id, data)", `
+		jsonb_path_exists(data->'txResponse'->'events',
+			'$[*] ? (@.type == "delegate" || @.type == "unbond" || @.type == "redelegate" || @.type == "cancel_unbonding_delegation" || @.type == "withdraw_rewards" || @.type == "withdraw_commission")')`)},
+	{version: 45, name: "staking_block_events", source: blockResultsSource, query: blockResultsSource.extract("api.extract_staking_block_events(height, data)",
+		`jsonb_path_exists(data->'finalizeBlockEvents', '$[*] ? (@.type == "complete_unbonding" || @.type == "complete_redelegation")')`)},
+	{version: 46, name: "authz_grants", source: transactionsSource, query: transactionsSource.extract("api.extract_authz_grants(id, data)", `
+		jsonb_path_exists(data->'txResponse'->'events',
+			'$[*] ? (@.type == "cosmos.authz.v1beta1.EventGrant" || @.type == "cosmos.authz.v1beta1.EventRevoke")')`)},
+	{version: 47, name: "account_sequences", source: txSignersSource, query: `
+		SELECT api.refresh_account_sequence(address)
+		FROM (
+			SELECT DISTINCT address FROM api.tx_signers
+			WHERE height BETWEEN $1 AND $2 AND address IS NOT NULL AND sequence IS NOT NULL
+		) s;`},
+	{version: 48, name: "nfts", source: transactionsSource, query: transactionsSource.extract("api.extract_nft_history(id, data)", `
+		jsonb_path_exists(data->'txResponse'->'events', '$[*] ? (@.type starts with "cosmos.nft.v1beta1.Event")')
+		OR jsonb_path_exists(data->'txResponse'->'events', '$[*] ? (@.type == "wasm").attributes[*] ? (@.key == "token_id")')`)},
+	{version: 49, name: "vote_extensions", source: blocksSource, query: blocksSource.extract("api.extract_vote_extensions(id, data)",
+		`data ? 'extendedCommitInfo'`)},
+	{version: 51, name: "tx_extension_options", source: transactionsSource, query: transactionsSource.extract("api.extract_tx_extension_options(id, data)",
+		`data->'tx'->'body' ?| ARRAY['extensionOptions', 'nonCriticalExtensionOptions']`)},
+}
+
+// backfillNames returns the names of the backfills.
+func backfillNames() []string {
+	names := make([]string, 0, len(backfills))
+	for _, b := range backfills {
+		names = append(names, b.name)
+	}
+	return names
+}
+
+// backfillsBetween returns the names of the backfills of the migrations above from and up to to.
+func backfillsBetween(from, to uint) []string {
+	var names []string
+	for _, b := range backfills {
+		if b.version > from && b.version <= to {
+			names = append(names, b.name)
+		}
+	}
+	return names
+}
+
+// Backfill runs the backfills of the names, or all of them if empty, whose migrations are applied, in batches of
+// batchSize heights, one database transaction per batch. The backfills replace the derived rows of the heights they
+// read, so that an interrupted run is resumed by running it again.
+func (m *Migrator) Backfill(ctx context.Context, names []string, batchSize uint64) error {
+	if batchSize == 0 {
+		return fmt.Errorf("batch size must be positive")
+	}
+	for _, name := range names {
+		if !slices.Contains(backfillNames(), name) {
+			return fmt.Errorf("unknown backfill %q, expected one of %s", name, strings.Join(backfillNames(), ", "))
+		}
+	}
+
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("migration %d of the database schema failed, fix it then run yaci migrate force %d", status.Version, status.Version)
+	}
+
+	for _, b := range backfills {
+		if len(names) > 0 && !slices.Contains(names, b.name) {
+			continue
+		}
+		if b.version > status.Version {
+			slog.Warn("Skipping backfill of a pending migration", "backfill", b.name, "migration", b.version)
+			continue
+		}
+		if err := runBackfill(ctx, m.pool, b, batchSize); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runBackfill runs a backfill over the heights of its source, in batches of batchSize heights from the lowest one, one
+// database transaction per batch.
+func runBackfill(ctx context.Context, pool *pgxpool.Pool, b backfill, batchSize uint64) error {
+	var first, last *int64
+	err := pool.QueryRow(ctx, fmt.Sprintf(`SELECT MIN(%s), MAX(%s) FROM %s;`, b.source.height, b.source.height, b.source.table)).Scan(&first, &last)
+	if err != nil {
+		return fmt.Errorf("failed to get the heights of %s: %w", b.source.table, err)
+	}
+
+	if first != nil {
+		slog.Info("Starting backfill", "backfill", b.name, "start", *first, "stop", *last)
+		for start := *first; start <= *last; start += int64(batchSize) {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			stop := min(start+int64(batchSize)-1, *last)
+			if _, err := pool.Exec(ctx, b.query, start, stop); err != nil {
+				return fmt.Errorf("failed to backfill %s of heights %d to %d: %w", b.name, start, stop, err)
+			}
+			slog.Info("Backfilling", "backfill", b.name, "height", stop, "remaining", *last-stop)
+		}
+	}
+
+	if b.finish != "" {
+		if _, err := pool.Exec(ctx, b.finish); err != nil {
+			return fmt.Errorf("failed to finish backfill %s: %w", b.name, err)
+		}
+	}
+	slog.Info("Backfill done", "backfill", b.name)
+	return nil
+}
@@ -0,0 +1,91 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// compactProcessedRangesQuery merges overlapping and adjacent processed ranges into maximal ones.
+const compactProcessedRangesQuery = `
+	WITH deleted AS (
+		DELETE FROM api.processed_ranges
+		RETURNING start_height, end_height
+	),
+	ordered AS (
+		SELECT start_height, end_height,
+			MAX(end_height) OVER (
+				ORDER BY start_height, end_height
+				ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+			) AS previous_end
+		FROM deleted
+	),
+	islands AS (
+		SELECT start_height, end_height,
+			SUM(CASE WHEN previous_end IS NULL OR start_height > previous_end + 1 THEN 1 ELSE 0 END)
+				OVER (ORDER BY start_height, end_height) AS island
+		FROM ordered
+	)
+	INSERT INTO api.processed_ranges (start_height, end_height)
+	SELECT MIN(start_height), MAX(end_height)
+	FROM islands
+	GROUP BY island
+`
+
+// CompactProcessedRanges merges the processed ranges written by each block into maximal ranges,
+// then vacuums the table and its index so that missing block detection stays fast.
+func (h *PostgresOutputHandler) CompactProcessedRanges(ctx context.Context) error {
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // Ensure rollback if commit is not reached
+
+	// Block concurrent block writes while the ranges are rewritten
+	if _, err := tx.Exec(ctx, `LOCK TABLE api.processed_ranges IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return fmt.Errorf("failed to lock processed ranges: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, compactProcessedRangesQuery)
+	if err != nil {
+		return fmt.Errorf("failed to compact processed ranges: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// VACUUM cannot run inside a transaction block
+	if _, err := h.pool.Exec(ctx, `VACUUM (ANALYZE) api.processed_ranges`); err != nil {
+		return fmt.Errorf("failed to vacuum processed ranges: %w", err)
+	}
+
+	slog.Debug("Processed ranges compacted", "ranges", tag.RowsAffected())
+	return nil
+}
+
+// StartCompaction periodically compacts the processed ranges until the handler is closed.
+func (h *PostgresOutputHandler) StartCompaction(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.stopCompaction = cancel
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := h.CompactProcessedRanges(ctx); err != nil && ctx.Err() == nil {
+					slog.Error("Failed to compact processed ranges", "error", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,60 @@
+package postgresql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// WriteDescriptorSnapshot stores the descriptors as a serialized FileDescriptorSet taken at height, unless they are the
+// descriptors of the latest snapshot.
+func (h *PostgresOutputHandler) WriteDescriptorSnapshot(ctx context.Context, height uint64, files []*descriptorpb.FileDescriptorProto) error {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(&descriptorpb.FileDescriptorSet{File: files})
+	if err != nil {
+		return fmt.Errorf("failed to marshal descriptor set: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	tag, err := h.pool.Exec(ctx, `
+		INSERT INTO api.descriptor_snapshots (height, hash, file_count, descriptor_set)
+		SELECT $1, $2, $3, $4
+		WHERE $2 IS DISTINCT FROM (SELECT hash FROM api.descriptor_snapshots ORDER BY id DESC LIMIT 1);
+	`, height, hash, len(files), data)
+	if err != nil {
+		return fmt.Errorf("failed to write descriptor snapshot: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		slog.Info("Stored descriptor snapshot", "height", height, "files", len(files))
+	}
+	return nil
+}
+
+// GetLatestDescriptorSnapshot returns the descriptors of the latest descriptor snapshot and its height, or nil if no
+// snapshot was stored.
+func (h *PostgresOutputHandler) GetLatestDescriptorSnapshot(ctx context.Context) ([]*descriptorpb.FileDescriptorProto, uint64, error) {
+	var height uint64
+	var data []byte
+	err := h.pool.QueryRow(ctx, `
+		SELECT height, descriptor_set FROM api.descriptor_snapshots ORDER BY id DESC LIMIT 1;
+	`).Scan(&height, &data)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("failed to query descriptor snapshot: %w", err)
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, 0, fmt.Errorf("failed to unmarshal descriptor snapshot of height %d: %w", height, err)
+	}
+	return set.GetFile(), height, nil
+}
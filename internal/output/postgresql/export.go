@@ -0,0 +1,66 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+// GetBlockTransactions returns the stored transactions of the blocks from start to stop, identified by the SHA-256 of
+// their bytes, by height and in the order of the blocks.
+func (h *PostgresOutputHandler) GetBlockTransactions(ctx context.Context, start, stop uint64) (map[uint64][]*models.Transaction, error) {
+	rows, err := h.pool.Query(ctx, `
+		SELECT b.id, t.id, t.data
+		FROM api.blocks_raw b
+		CROSS JOIN LATERAL jsonb_array_elements_text(COALESCE(b.data->'block'->'data'->'txs', '[]'::JSONB)) WITH ORDINALITY AS raw(tx, idx)
+		JOIN api.transactions_raw t ON t.id = encode(sha256(decode(raw.tx, 'base64')), 'hex')
+		WHERE b.id BETWEEN $1 AND $2
+		ORDER BY b.id, raw.idx;
+	`, start, stop)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer rows.Close()
+
+	transactions := make(map[uint64][]*models.Transaction)
+	for rows.Next() {
+		var height uint64
+		t := &models.Transaction{}
+		if err := rows.Scan(&height, &t.Hash, &t.Data); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions[height] = append(transactions[height], t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	return transactions, nil
+}
+
+// GetBlockResults returns the stored results of the blocks from start to stop, ordered by height.
+func (h *PostgresOutputHandler) GetBlockResults(ctx context.Context, start, stop uint64) ([]*models.BlockResults, error) {
+	rows, err := h.pool.Query(ctx, `
+		SELECT height, data
+		FROM api.block_results_raw
+		WHERE height BETWEEN $1 AND $2
+		ORDER BY height;
+	`, start, stop)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block results: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*models.BlockResults
+	for rows.Next() {
+		r := &models.BlockResults{}
+		if err := rows.Scan(&r.Height, &r.Data); err != nil {
+			return nil, fmt.Errorf("failed to scan block results: %w", err)
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get block results: %w", err)
+	}
+	return results, nil
+}
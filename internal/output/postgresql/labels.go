@@ -0,0 +1,55 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+// WriteAddressLabels inserts or updates the labels of the addresses. With replace, the labels previously imported from
+// the same sources are deleted first, so that the labels removed from a source are removed from the database.
+func (h *PostgresOutputHandler) WriteAddressLabels(ctx context.Context, labels []*models.AddressLabel, replace bool) error {
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // Ensure rollback if commit is not reached
+
+	if replace {
+		var sources []string
+		seen := make(map[string]bool)
+		for _, l := range labels {
+			if !seen[l.Source] {
+				seen[l.Source] = true
+				sources = append(sources, l.Source)
+			}
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM api.address_labels WHERE source = ANY($1)`, sources); err != nil {
+			return fmt.Errorf("failed to delete previous address labels: %w", err)
+		}
+	}
+
+	batch := &pgx.Batch{}
+	for _, l := range labels {
+		batch.Queue(`
+			INSERT INTO api.address_labels (address, label, category, source, updated_at)
+			VALUES ($1, $2, NULLIF($3, ''), $4, NOW())
+			ON CONFLICT (address) DO UPDATE SET
+				label = EXCLUDED.label,
+				category = EXCLUDED.category,
+				source = EXCLUDED.source,
+				updated_at = EXCLUDED.updated_at;
+		`, l.Address, l.Label, l.Category, l.Source)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to write address labels: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
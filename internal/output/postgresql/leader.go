@@ -0,0 +1,73 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// leadershipCheckTimeout is the maximum time to wait for the connection holding the leadership to answer.
+const leadershipCheckTimeout = 5 * time.Second
+
+// TryAcquireLeadership takes the session-level advisory lock derived from name on a dedicated connection, which
+// PostgreSQL releases as soon as the connection is closed, e.g., when the instance crashes.
+func (h *PostgresOutputHandler) TryAcquireLeadership(ctx context.Context, name string) (bool, error) {
+	h.leaderMu.Lock()
+	defer h.leaderMu.Unlock()
+	if h.leaderConn != nil {
+		return true, nil
+	}
+
+	conn, err := h.pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext($1));`, name).Scan(&acquired); err != nil {
+		conn.Release()
+		return false, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+	if !acquired {
+		conn.Release()
+		return false, nil
+	}
+
+	// The connection is removed from the pool, so that the lock is held until it is closed
+	h.leaderConn = conn.Hijack()
+	return true, nil
+}
+
+// CheckLeadership checks that the connection holding the advisory lock is still alive.
+func (h *PostgresOutputHandler) CheckLeadership(ctx context.Context) error {
+	h.leaderMu.Lock()
+	defer h.leaderMu.Unlock()
+	if h.leaderConn == nil {
+		return fmt.Errorf("leadership is not held")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, leadershipCheckTimeout)
+	defer cancel()
+	if err := h.leaderConn.Ping(ctx); err != nil {
+		h.leaderConn.Close(context.Background())
+		h.leaderConn = nil
+		return fmt.Errorf("lost the connection holding the leadership: %w", err)
+	}
+	return nil
+}
+
+// ReleaseLeadership closes the connection holding the advisory lock, which releases it.
+func (h *PostgresOutputHandler) ReleaseLeadership(ctx context.Context) error {
+	h.leaderMu.Lock()
+	defer h.leaderMu.Unlock()
+	if h.leaderConn == nil {
+		return nil
+	}
+
+	err := h.leaderConn.Close(ctx)
+	h.leaderConn = nil
+	if err != nil {
+		return fmt.Errorf("failed to release leadership: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratepgx "github.com/golang-migrate/migrate/v4/database/pgx"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Migration is a versioned schema migration embedded in the binary.
+type Migration struct {
+	Version uint   `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
+
+// MigrationStatus is the schema version of a database relative to the embedded migrations.
+type MigrationStatus struct {
+	Version    uint        `json:"version"` // 0 when no migration was applied
+	Dirty      bool        `json:"dirty"`   // The migration of Version failed and must be fixed by hand
+	Latest     uint        `json:"latest"`
+	Migrations []Migration `json:"migrations"`
+}
+
+// Pending returns the number of embedded migrations not applied yet.
+func (s *MigrationStatus) Pending() int {
+	pending := 0
+	for _, m := range s.Migrations {
+		if !m.Applied {
+			pending++
+		}
+	}
+	return pending
+}
+
+// migrationLogger logs the applied migrations.
+type migrationLogger struct{}
+
+func (migrationLogger) Printf(format string, v ...interface{}) {
+	slog.Info("Migration applied", "migration", strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (migrationLogger) Verbose() bool {
+	return false
+}
+
+// Migrator applies and reverts the embedded schema migrations.
+type Migrator struct {
+	pool    *pgxpool.Pool
+	source  source.Driver
+	migrate *migrate.Migrate
+}
+
+// NewMigrator connects to the database of connString to migrate its schema.
+func NewMigrator(connString string) (*Migrator, error) {
+	pool, err := pgxpool.New(context.Background(), connString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+	}
+
+	m, err := newMigrator(pool)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+func newMigrator(pool *pgxpool.Pool) (*Migrator, error) {
+	d, err := iofs.New(migrationsFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration source: %w", err)
+	}
+
+	driver, err := migratepgx.WithInstance(stdlib.OpenDBFromPool(pool), &migratepgx.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", d, "postgres", driver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration instance: %w", err)
+	}
+	m.Log = migrationLogger{}
+
+	return &Migrator{pool: pool, source: d, migrate: m}, nil
+}
+
+// Up applies the next steps migrations, or all the pending migrations if steps is 0. The derived tables added by the
+// applied migrations are only filled from the rows indexed before them by Backfill.
+func (m *Migrator) Up(steps int) error {
+	from, _, err := m.migrate.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	if steps > 0 {
+		err = m.migrate.Steps(steps)
+	} else {
+		err = m.migrate.Up()
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	// A new database has no rows to backfill
+	if from > 0 {
+		to, _, err := m.migrate.Version()
+		if err != nil {
+			return fmt.Errorf("failed to get schema version: %w", err)
+		}
+		if names := backfillsBetween(from, to); len(names) > 0 {
+			slog.Info("The applied migrations added derived tables, fill them from the indexed rows with yaci migrate backfill", "backfills", names)
+		}
+	}
+	return nil
+}
+
+// Down reverts the last steps migrations, or all the migrations if steps is 0.
+func (m *Migrator) Down(steps int) error {
+	var err error
+	if steps > 0 {
+		err = m.migrate.Steps(-steps)
+	} else {
+		err = m.migrate.Down()
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to revert migrations: %w", err)
+	}
+	return nil
+}
+
+// Force sets the schema version without running any migration and clears the dirty flag, once a failed migration was
+// fixed by hand.
+func (m *Migrator) Force(version int) error {
+	if err := m.migrate.Force(version); err != nil {
+		return fmt.Errorf("failed to force schema version %d: %w", version, err)
+	}
+	return nil
+}
+
+// Status returns the schema version of the database and the embedded migrations.
+func (m *Migrator) Status() (*MigrationStatus, error) {
+	var status MigrationStatus
+	version, dirty, err := m.migrate.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return nil, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	status.Version, status.Dirty = version, dirty
+
+	v, err := m.source.First()
+	for err == nil {
+		r, name, readErr := m.source.ReadUp(v)
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read migration %d: %w", v, readErr)
+		}
+		r.Close()
+		// A dirty version was not applied completely
+		applied := v < status.Version || (v == status.Version && !status.Dirty)
+		status.Migrations = append(status.Migrations, Migration{Version: v, Name: name, Applied: applied})
+		status.Latest = v
+		v, err = m.source.Next(v)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	return &status, nil
+}
+
+// Close closes the connection of the migrator.
+func (m *Migrator) Close() {
+	m.migrate.Close()
+	m.pool.Close()
+}
+
+// checkSchemaVersion returns an error if the schema of the database is not up to date with the embedded migrations,
+// since the migrations are applied explicitly.
+func (h *PostgresOutputHandler) checkSchemaVersion() error {
+	m, err := newMigrator(h.pool)
+	if err != nil {
+		return err
+	}
+	// The pool belongs to the handler
+	defer m.migrate.Close()
+
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("migration %d of the database schema failed, fix it then run yaci migrate force %d", status.Version, status.Version)
+	}
+	if status.Version < status.Latest {
+		return fmt.Errorf("database schema is at version %d, expected %d: run yaci migrate up, or extract with --auto-migrate", status.Version, status.Latest)
+	}
+	if status.Version > status.Latest {
+		slog.Warn("Database schema is newer than this version of yaci", "version", status.Version, "expected", status.Latest)
+	}
+	return nil
+}
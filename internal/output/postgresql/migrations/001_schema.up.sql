@@ -1,6 +1,7 @@
 -- Minimal schema for yaci indexer
 -- Only creates raw tables that the indexer writes to directly
--- All parsing logic, triggers, views, and functions are in yaci-explorer-apis
+-- The derived tables, and their triggers, views and functions, are added by the
+-- later migrations, e.g., 018_messages and 019_events
 
 BEGIN;
 
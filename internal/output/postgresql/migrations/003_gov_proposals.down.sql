@@ -0,0 +1,7 @@
+-- Migration 003 down: Remove gov_proposals table
+
+BEGIN;
+
+DROP TABLE IF EXISTS api.gov_proposals;
+
+COMMIT;
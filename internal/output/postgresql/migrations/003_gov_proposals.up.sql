@@ -0,0 +1,24 @@
+-- Migration 003: Add gov_proposals table
+--
+-- Lifecycle state of governance proposals, queried from the gov module.
+-- Rows are kept up to date while the indexer runs; deposits and tallies of
+-- finished proposals keep their last known value.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.gov_proposals (
+    id BIGINT PRIMARY KEY,
+    status TEXT NOT NULL,
+    data JSONB NOT NULL,
+    tally JSONB,
+    deposits JSONB,
+    updated_height BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_gov_proposals_status ON api.gov_proposals(status);
+
+-- Read access for PostgREST
+GRANT SELECT ON api.gov_proposals TO web_anon;
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Migration 004 down: Remove staking delegation snapshot tables
+
+BEGIN;
+
+DROP TABLE IF EXISTS api.unbonding_delegation_snapshots;
+DROP TABLE IF EXISTS api.delegation_snapshots;
+
+COMMIT;
@@ -0,0 +1,38 @@
+-- Migration 004: Add staking delegation snapshot tables
+--
+-- Full copies of the staking delegations and unbonding delegations, taken
+-- every N blocks with queries pinned to the snapshot height.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.delegation_snapshots (
+    height BIGINT NOT NULL,
+    delegator_address TEXT NOT NULL,
+    validator_address TEXT NOT NULL,
+    shares NUMERIC NOT NULL,
+    amount NUMERIC NOT NULL,
+    denom TEXT NOT NULL,
+    PRIMARY KEY (height, delegator_address, validator_address)
+);
+
+CREATE INDEX IF NOT EXISTS idx_delegation_snapshots_validator ON api.delegation_snapshots(validator_address, height);
+CREATE INDEX IF NOT EXISTS idx_delegation_snapshots_delegator ON api.delegation_snapshots(delegator_address, height);
+
+CREATE TABLE IF NOT EXISTS api.unbonding_delegation_snapshots (
+    height BIGINT NOT NULL,
+    delegator_address TEXT NOT NULL,
+    validator_address TEXT NOT NULL,
+    creation_height BIGINT NOT NULL,
+    completion_time TIMESTAMPTZ NOT NULL,
+    initial_balance NUMERIC NOT NULL,
+    balance NUMERIC NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_unbonding_delegation_snapshots_height ON api.unbonding_delegation_snapshots(height);
+CREATE INDEX IF NOT EXISTS idx_unbonding_delegation_snapshots_delegator ON api.unbonding_delegation_snapshots(delegator_address, height);
+
+-- Read access for PostgREST
+GRANT SELECT ON api.delegation_snapshots TO web_anon;
+GRANT SELECT ON api.unbonding_delegation_snapshots TO web_anon;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Migration 005 down: Remove balance_snapshots table
+
+BEGIN;
+
+DROP TABLE IF EXISTS api.balance_snapshots;
+
+COMMIT;
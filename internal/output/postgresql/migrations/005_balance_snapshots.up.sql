@@ -0,0 +1,22 @@
+-- Migration 005: Add balance_snapshots table
+--
+-- Full copies of the bank balances of every holder of every denom, taken
+-- every N blocks with queries pinned to the snapshot height. Used for supply
+-- audits and holder distribution reports.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.balance_snapshots (
+    height BIGINT NOT NULL,
+    address TEXT NOT NULL,
+    denom TEXT NOT NULL,
+    amount NUMERIC NOT NULL,
+    PRIMARY KEY (height, denom, address)
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_snapshots_address ON api.balance_snapshots(address, height);
+
+-- Read access for PostgREST
+GRANT SELECT ON api.balance_snapshots TO web_anon;
+
+COMMIT;
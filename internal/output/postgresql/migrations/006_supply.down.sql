@@ -0,0 +1,8 @@
+-- Migration 006 down: Remove denom_metadata and supply_history tables
+
+BEGIN;
+
+DROP TABLE IF EXISTS api.supply_history;
+DROP TABLE IF EXISTS api.denom_metadata;
+
+COMMIT;
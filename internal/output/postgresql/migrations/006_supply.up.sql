@@ -0,0 +1,31 @@
+-- Migration 006: Add denom_metadata and supply_history tables
+--
+-- Bank denom metadata, and a time series of the total supply of every denom
+-- recorded every N blocks. Used for inflation dashboards.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.denom_metadata (
+    denom TEXT PRIMARY KEY,
+    display TEXT,
+    symbol TEXT,
+    exponent INTEGER NOT NULL DEFAULT 0,
+    data JSONB NOT NULL,
+    updated_height BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS api.supply_history (
+    height BIGINT NOT NULL,
+    denom TEXT NOT NULL,
+    amount NUMERIC NOT NULL,
+    recorded_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (denom, height)
+);
+
+CREATE INDEX IF NOT EXISTS idx_supply_history_height ON api.supply_history(height);
+
+-- Read access for PostgREST
+GRANT SELECT ON api.denom_metadata TO web_anon;
+GRANT SELECT ON api.supply_history TO web_anon;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Migration 007 down: Remove processed_ranges table
+
+BEGIN;
+
+DROP TABLE IF EXISTS api.processed_ranges;
+
+COMMIT;
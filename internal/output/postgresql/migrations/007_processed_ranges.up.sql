@@ -0,0 +1,31 @@
+-- Migration 007: Add processed_ranges table
+--
+-- Tracks the processed block heights as ranges of contiguous heights, so that
+-- missing blocks can be found by looking at the gaps between ranges instead
+-- of scanning every height of api.blocks_raw.
+-- Each block write appends a single-height range; the indexer periodically
+-- compacts overlapping and adjacent ranges into maximal ones.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.processed_ranges (
+    start_height BIGINT NOT NULL,
+    end_height BIGINT NOT NULL,
+    CHECK (start_height <= end_height)
+);
+
+CREATE INDEX IF NOT EXISTS idx_processed_ranges_start ON api.processed_ranges(start_height);
+
+-- Seed the ranges from the blocks already indexed
+INSERT INTO api.processed_ranges (start_height, end_height)
+SELECT MIN(id), MAX(id)
+FROM (
+    SELECT id, id - ROW_NUMBER() OVER (ORDER BY id) AS island
+    FROM api.blocks_raw
+) s
+GROUP BY island;
+
+-- Read access for PostgREST
+GRANT SELECT ON api.processed_ranges TO web_anon;
+
+COMMIT;
@@ -0,0 +1,9 @@
+-- Migration 008 down: Remove IBC client, connection and channel tables
+
+BEGIN;
+
+DROP TABLE IF EXISTS api.ibc_channels;
+DROP TABLE IF EXISTS api.ibc_connections;
+DROP TABLE IF EXISTS api.ibc_clients;
+
+COMMIT;
@@ -0,0 +1,50 @@
+-- Migration 008: Add IBC client, connection and channel tables
+--
+-- Current state of the IBC light clients, connections and channels, and of
+-- their counterparties, kept up to date while the indexer runs.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.ibc_clients (
+    client_id TEXT PRIMARY KEY,
+    client_type TEXT,
+    chain_id TEXT,
+    status TEXT,
+    data JSONB NOT NULL,
+    updated_height BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS api.ibc_connections (
+    connection_id TEXT PRIMARY KEY,
+    client_id TEXT NOT NULL,
+    state TEXT NOT NULL,
+    counterparty_client_id TEXT,
+    counterparty_connection_id TEXT,
+    data JSONB NOT NULL,
+    updated_height BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ibc_connections_client ON api.ibc_connections(client_id);
+
+CREATE TABLE IF NOT EXISTS api.ibc_channels (
+    port_id TEXT NOT NULL,
+    channel_id TEXT NOT NULL,
+    connection_id TEXT,
+    state TEXT NOT NULL,
+    ordering TEXT,
+    version TEXT,
+    counterparty_port_id TEXT,
+    counterparty_channel_id TEXT,
+    data JSONB NOT NULL,
+    updated_height BIGINT NOT NULL,
+    PRIMARY KEY (port_id, channel_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ibc_channels_connection ON api.ibc_channels(connection_id);
+
+-- Read access for PostgREST
+GRANT SELECT ON api.ibc_clients TO web_anon;
+GRANT SELECT ON api.ibc_connections TO web_anon;
+GRANT SELECT ON api.ibc_channels TO web_anon;
+
+COMMIT;
@@ -0,0 +1,10 @@
+-- Migration 009 down: Remove ibc_packets table
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_update_ibc_packets ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_ibc_packets();
+DROP FUNCTION IF EXISTS api.extract_ibc_packets(TEXT, JSONB);
+DROP TABLE IF EXISTS api.ibc_packets;
+
+COMMIT;
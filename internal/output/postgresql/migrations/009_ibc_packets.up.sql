@@ -0,0 +1,130 @@
+-- Migration 009: Add ibc_packets table
+--
+-- Correlates the send_packet, recv_packet, write_acknowledgement,
+-- acknowledge_packet and timeout_packet events of successful transactions into
+-- a single row per packet, identified by its source port, source channel and
+-- sequence. The events of a packet can be indexed in any order, so every
+-- column is only ever filled in, and the status is derived from the columns.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.ibc_packets (
+    source_port TEXT NOT NULL,
+    source_channel TEXT NOT NULL,
+    sequence BIGINT NOT NULL,
+    destination_port TEXT,
+    destination_channel TEXT,
+    data TEXT,
+    timeout_height TEXT,
+    timeout_timestamp TEXT,
+    send_tx_hash TEXT,
+    send_height BIGINT,
+    recv_tx_hash TEXT,
+    recv_height BIGINT,
+    ack TEXT,
+    acknowledge_tx_hash TEXT,
+    acknowledge_height BIGINT,
+    timeout_tx_hash TEXT,
+    timed_out_height BIGINT,
+    status TEXT GENERATED ALWAYS AS (
+        CASE
+            WHEN timeout_tx_hash IS NOT NULL THEN 'timed_out'
+            WHEN acknowledge_tx_hash IS NOT NULL THEN 'acknowledged'
+            WHEN recv_tx_hash IS NOT NULL THEN 'received'
+            ELSE 'sent'
+        END
+    ) STORED,
+    PRIMARY KEY (source_port, source_channel, sequence)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ibc_packets_status ON api.ibc_packets(status);
+CREATE INDEX IF NOT EXISTS idx_ibc_packets_send_tx ON api.ibc_packets(send_tx_hash) WHERE send_tx_hash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_ibc_packets_recv_tx ON api.ibc_packets(recv_tx_hash) WHERE recv_tx_hash IS NOT NULL;
+
+-- Upserts the packets referenced by the events of a transaction
+CREATE OR REPLACE FUNCTION api.extract_ibc_packets(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+DECLARE
+    _event JSONB;
+    _type TEXT;
+    _attrs JSONB;
+    _height BIGINT;
+BEGIN
+    IF COALESCE((_data->'txResponse'->>'code')::INT, 0) <> 0 THEN
+        RETURN;
+    END IF;
+
+    _height := (_data->'txResponse'->>'height')::BIGINT;
+
+    FOR _event IN SELECT * FROM jsonb_array_elements(COALESCE(_data->'txResponse'->'events', '[]'::JSONB)) LOOP
+        _type := _event->>'type';
+        IF _type NOT IN ('send_packet', 'recv_packet', 'write_acknowledgement', 'acknowledge_packet', 'timeout_packet', 'timeout_on_close_packet') THEN
+            CONTINUE;
+        END IF;
+
+        SELECT jsonb_object_agg(a->>'key', a->>'value') INTO _attrs
+        FROM jsonb_array_elements(COALESCE(_event->'attributes', '[]'::JSONB)) a
+        WHERE a->>'key' IS NOT NULL;
+
+        IF _attrs IS NULL OR _attrs->>'packet_sequence' IS NULL THEN
+            CONTINUE;
+        END IF;
+
+        INSERT INTO api.ibc_packets (
+            source_port, source_channel, sequence, destination_port, destination_channel,
+            data, timeout_height, timeout_timestamp,
+            send_tx_hash, send_height, recv_tx_hash, recv_height, ack,
+            acknowledge_tx_hash, acknowledge_height, timeout_tx_hash, timed_out_height
+        ) VALUES (
+            _attrs->>'packet_src_port',
+            _attrs->>'packet_src_channel',
+            (_attrs->>'packet_sequence')::BIGINT,
+            _attrs->>'packet_dst_port',
+            _attrs->>'packet_dst_channel',
+            _attrs->>'packet_data',
+            _attrs->>'packet_timeout_height',
+            _attrs->>'packet_timeout_timestamp',
+            CASE WHEN _type = 'send_packet' THEN _tx_hash END,
+            CASE WHEN _type = 'send_packet' THEN _height END,
+            CASE WHEN _type = 'recv_packet' THEN _tx_hash END,
+            CASE WHEN _type = 'recv_packet' THEN _height END,
+            CASE WHEN _type = 'write_acknowledgement' THEN _attrs->>'packet_ack' END,
+            CASE WHEN _type = 'acknowledge_packet' THEN _tx_hash END,
+            CASE WHEN _type = 'acknowledge_packet' THEN _height END,
+            CASE WHEN _type IN ('timeout_packet', 'timeout_on_close_packet') THEN _tx_hash END,
+            CASE WHEN _type IN ('timeout_packet', 'timeout_on_close_packet') THEN _height END
+        )
+        ON CONFLICT (source_port, source_channel, sequence) DO UPDATE SET
+            destination_port = COALESCE(EXCLUDED.destination_port, api.ibc_packets.destination_port),
+            destination_channel = COALESCE(EXCLUDED.destination_channel, api.ibc_packets.destination_channel),
+            data = COALESCE(EXCLUDED.data, api.ibc_packets.data),
+            timeout_height = COALESCE(EXCLUDED.timeout_height, api.ibc_packets.timeout_height),
+            timeout_timestamp = COALESCE(EXCLUDED.timeout_timestamp, api.ibc_packets.timeout_timestamp),
+            send_tx_hash = COALESCE(EXCLUDED.send_tx_hash, api.ibc_packets.send_tx_hash),
+            send_height = COALESCE(EXCLUDED.send_height, api.ibc_packets.send_height),
+            recv_tx_hash = COALESCE(EXCLUDED.recv_tx_hash, api.ibc_packets.recv_tx_hash),
+            recv_height = COALESCE(EXCLUDED.recv_height, api.ibc_packets.recv_height),
+            ack = COALESCE(EXCLUDED.ack, api.ibc_packets.ack),
+            acknowledge_tx_hash = COALESCE(EXCLUDED.acknowledge_tx_hash, api.ibc_packets.acknowledge_tx_hash),
+            acknowledge_height = COALESCE(EXCLUDED.acknowledge_height, api.ibc_packets.acknowledge_height),
+            timeout_tx_hash = COALESCE(EXCLUDED.timeout_tx_hash, api.ibc_packets.timeout_tx_hash),
+            timed_out_height = COALESCE(EXCLUDED.timed_out_height, api.ibc_packets.timed_out_height);
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_ibc_packets() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_ibc_packets(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_ibc_packets ON api.transactions_raw;
+CREATE TRIGGER trg_update_ibc_packets
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_ibc_packets();
+
+-- Read access for PostgREST
+GRANT SELECT ON api.ibc_packets TO web_anon;
+
+COMMIT;
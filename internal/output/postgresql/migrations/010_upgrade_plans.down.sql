@@ -0,0 +1,7 @@
+-- Migration 010 down: Remove upgrade_plans table
+
+BEGIN;
+
+DROP TABLE IF EXISTS api.upgrade_plans;
+
+COMMIT;
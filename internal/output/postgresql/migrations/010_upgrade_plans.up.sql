@@ -0,0 +1,23 @@
+-- Migration 010: Add upgrade_plans table
+--
+-- Upgrade plans scheduled by the x/upgrade module, as seen by the live
+-- extractor, and the height at which they were applied.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.upgrade_plans (
+    name TEXT PRIMARY KEY,
+    height BIGINT NOT NULL,
+    info TEXT,
+    data JSONB NOT NULL,
+    first_seen_height BIGINT NOT NULL,
+    applied_height BIGINT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_upgrade_plans_height ON api.upgrade_plans(height);
+
+-- Read access for PostgREST
+GRANT SELECT ON api.upgrade_plans TO web_anon;
+
+COMMIT;
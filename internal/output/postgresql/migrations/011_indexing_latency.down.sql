@@ -0,0 +1,10 @@
+-- Migration 011 down: Remove the indexing latency columns
+
+BEGIN;
+
+ALTER TABLE api.blocks_raw
+    DROP COLUMN IF EXISTS indexing_latency,
+    DROP COLUMN IF EXISTS indexed_at,
+    DROP COLUMN IF EXISTS block_time;
+
+COMMIT;
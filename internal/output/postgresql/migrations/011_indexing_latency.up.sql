@@ -0,0 +1,15 @@
+-- Migration 011: Record the indexing latency of blocks
+--
+-- block_time is the on-chain time of the block header and indexed_at the
+-- time at which the indexer committed the block. Their difference is the
+-- end-to-end latency seen by realtime consumers. Blocks indexed before this
+-- migration have no indexing time.
+
+BEGIN;
+
+ALTER TABLE api.blocks_raw
+    ADD COLUMN IF NOT EXISTS block_time TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS indexed_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS indexing_latency INTERVAL GENERATED ALWAYS AS (indexed_at - block_time) STORED;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Migration 012 down: Remove extraction_shards table
+
+BEGIN;
+
+DROP TABLE IF EXISTS api.extraction_shards;
+
+COMMIT;
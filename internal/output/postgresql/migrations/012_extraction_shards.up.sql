@@ -0,0 +1,21 @@
+-- Migration 012: Add extraction_shards table
+--
+-- Coordinates several indexer instances sharing a backfill. The range is
+-- split into shards that each instance claims under a lease, renewed while
+-- the shard is processed, so that the shards of a crashed instance are
+-- claimed by another one once their lease expires.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.extraction_shards (
+    start_height BIGINT PRIMARY KEY,
+    end_height BIGINT NOT NULL,
+    owner TEXT,
+    lease_expires_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    CHECK (start_height <= end_height)
+);
+
+CREATE INDEX IF NOT EXISTS idx_extraction_shards_pending ON api.extraction_shards(start_height) WHERE completed_at IS NULL;
+
+COMMIT;
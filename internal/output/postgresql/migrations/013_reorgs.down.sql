@@ -0,0 +1,13 @@
+-- Migration 013 down: Remove block hashes and reorgs table
+
+BEGIN;
+
+DROP TABLE IF EXISTS api.reorgs;
+
+DROP INDEX IF EXISTS api.idx_transactions_height;
+
+ALTER TABLE api.blocks_raw
+    DROP COLUMN IF EXISTS parent_hash,
+    DROP COLUMN IF EXISTS hash;
+
+COMMIT;
@@ -0,0 +1,31 @@
+-- Migration 013: Add block hashes and reorgs table
+--
+-- The hash of each block and of its parent are stored so that the live
+-- extractor can verify that new blocks link to the stored chain. Blocks
+-- found to diverge from the node, e.g., after a node rollback, are replaced
+-- and the replacement is recorded in api.reorgs, deleting the transactions of
+-- the divergent blocks by their height. Blocks indexed before this migration
+-- keep their hashes in their data only.
+
+BEGIN;
+
+ALTER TABLE api.blocks_raw
+    ADD COLUMN IF NOT EXISTS hash TEXT,
+    ADD COLUMN IF NOT EXISTS parent_hash TEXT;
+
+CREATE TABLE IF NOT EXISTS api.reorgs (
+    id BIGSERIAL PRIMARY KEY,
+    fork_height BIGINT NOT NULL,
+    detected_height BIGINT NOT NULL,
+    depth INTEGER NOT NULL,
+    old_hashes TEXT[] NOT NULL,
+    new_hashes TEXT[] NOT NULL,
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_height ON api.transactions_raw(((data->'txResponse'->>'height')::BIGINT));
+
+-- Read access for PostgREST
+GRANT SELECT ON api.reorgs TO web_anon;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Migration 014 down: Remove skipped_ranges table
+
+BEGIN;
+
+DROP TABLE IF EXISTS api.skipped_ranges;
+
+COMMIT;
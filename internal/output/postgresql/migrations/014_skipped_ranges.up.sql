@@ -0,0 +1,21 @@
+-- Migration 014: Add skipped_ranges table
+--
+-- Records the height ranges skipped on purpose with --skip-ranges, e.g.,
+-- known-bad or already archived ranges. They are considered along with
+-- api.processed_ranges when looking for missing blocks, so that they are not
+-- reported as gaps.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.skipped_ranges (
+    start_height BIGINT NOT NULL,
+    end_height BIGINT NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (start_height, end_height),
+    CHECK (start_height <= end_height)
+);
+
+-- Read access for PostgREST
+GRANT SELECT ON api.skipped_ranges TO web_anon;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Migration 015 down: Remove extra_query_results table
+
+BEGIN;
+
+DROP TABLE IF EXISTS api.extra_query_results;
+
+COMMIT;
@@ -0,0 +1,21 @@
+-- Migration 015: Add extra_query_results table
+--
+-- Stores the responses of the user-defined gRPC queries configured with
+-- --extra-query, keyed by query name and height, so that chain-specific
+-- module state can be captured without code changes.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.extra_query_results (
+    name TEXT NOT NULL,
+    height BIGINT NOT NULL,
+    data JSONB NOT NULL,
+    PRIMARY KEY (name, height)
+);
+
+CREATE INDEX IF NOT EXISTS idx_extra_query_results_height ON api.extra_query_results(height);
+
+-- Read access for PostgREST
+GRANT SELECT ON api.extra_query_results TO web_anon;
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Migration 016 down: Remove streamed_blocks and state_changes tables
+
+BEGIN;
+
+DROP TABLE IF EXISTS api.state_changes;
+DROP TABLE IF EXISTS api.streamed_blocks;
+
+COMMIT;
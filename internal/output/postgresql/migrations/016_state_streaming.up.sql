@@ -0,0 +1,34 @@
+-- Migration 016: Add streamed_blocks and state_changes tables
+--
+-- Filled by `yaci stream`, running as an ADR-038 ABCI listener plugin of a
+-- node. The ABCI FinalizeBlock request and response and the Commit response
+-- of each block are kept as raw protobuf, along with the keys written or
+-- deleted in each store of the application state.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.streamed_blocks (
+    height BIGINT PRIMARY KEY,
+    finalize_block_request BYTEA,
+    finalize_block_response BYTEA,
+    commit_response BYTEA,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS api.state_changes (
+    height BIGINT NOT NULL,
+    idx INTEGER NOT NULL,
+    store_key TEXT NOT NULL,
+    key BYTEA NOT NULL,
+    value BYTEA,
+    deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (height, idx)
+);
+
+CREATE INDEX IF NOT EXISTS idx_state_changes_store_key ON api.state_changes(store_key, key);
+
+-- Read access for PostgREST
+GRANT SELECT ON api.streamed_blocks TO web_anon;
+GRANT SELECT ON api.state_changes TO web_anon;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Migration 017 down: Remove pruned_ranges table
+
+BEGIN;
+
+DROP TABLE IF EXISTS api.pruned_ranges;
+
+COMMIT;
@@ -0,0 +1,22 @@
+-- Migration 017: Add pruned_ranges table
+--
+-- Records the height ranges that the node could not serve because they were
+-- pruned from its block store, i.e., below the lowest height it reported.
+-- They are considered along with api.processed_ranges when looking for
+-- missing blocks, so that gap reports distinguish the truly missing heights
+-- from the unobtainable ones.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.pruned_ranges (
+    start_height BIGINT NOT NULL,
+    end_height BIGINT NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (start_height, end_height),
+    CHECK (start_height <= end_height)
+);
+
+-- Read access for PostgREST
+GRANT SELECT ON api.pruned_ranges TO web_anon;
+
+COMMIT;
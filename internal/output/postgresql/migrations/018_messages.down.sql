@@ -0,0 +1,11 @@
+-- Migration 018 down: Remove messages and message_types tables
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_update_messages ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_messages();
+DROP FUNCTION IF EXISTS api.extract_messages(TEXT, JSONB);
+DROP TABLE IF EXISTS api.message_types;
+DROP TABLE IF EXISTS api.messages;
+
+COMMIT;
@@ -0,0 +1,68 @@
+-- Migration 018: Add messages and message_types tables
+--
+-- Normalizes the messages of the transactions, decoded with the descriptors of
+-- the node, into one row per message, so that transactions can be queried by
+-- message type without scanning the raw JSON. api.message_types lists the
+-- distinct type URLs with the first and last heights they were seen at.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.messages (
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    msg_index INTEGER NOT NULL,
+    type_url TEXT NOT NULL,
+    height BIGINT,
+    data JSONB NOT NULL,
+    PRIMARY KEY (tx_hash, msg_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_messages_type_url ON api.messages(type_url, height);
+CREATE INDEX IF NOT EXISTS idx_messages_height ON api.messages(height);
+
+CREATE TABLE IF NOT EXISTS api.message_types (
+    type_url TEXT PRIMARY KEY,
+    first_height BIGINT,
+    last_height BIGINT
+);
+
+-- Replaces the messages of a transaction
+CREATE OR REPLACE FUNCTION api.extract_messages(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+DECLARE
+    _height BIGINT;
+BEGIN
+    _height := (_data->'txResponse'->>'height')::BIGINT;
+
+    DELETE FROM api.messages WHERE tx_hash = _tx_hash;
+
+    INSERT INTO api.messages (tx_hash, msg_index, type_url, height, data)
+    SELECT _tx_hash, m.ordinality - 1, m.value->>'@type', _height, m.value
+    FROM jsonb_array_elements(COALESCE(_data->'tx'->'body'->'messages', '[]'::JSONB)) WITH ORDINALITY m
+    WHERE m.value->>'@type' IS NOT NULL;
+
+    INSERT INTO api.message_types (type_url, first_height, last_height)
+    SELECT DISTINCT m->>'@type', _height, _height
+    FROM jsonb_array_elements(COALESCE(_data->'tx'->'body'->'messages', '[]'::JSONB)) m
+    WHERE m->>'@type' IS NOT NULL
+    ON CONFLICT (type_url) DO UPDATE SET
+        first_height = LEAST(api.message_types.first_height, EXCLUDED.first_height),
+        last_height = GREATEST(api.message_types.last_height, EXCLUDED.last_height);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_messages() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_messages(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_messages ON api.transactions_raw;
+CREATE TRIGGER trg_update_messages
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_messages();
+
+-- Read access for PostgREST
+GRANT SELECT ON api.messages TO web_anon;
+GRANT SELECT ON api.message_types TO web_anon;
+
+COMMIT;
@@ -0,0 +1,14 @@
+-- Migration 019 down: Remove events table
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_update_block_events ON api.block_results_raw;
+DROP TRIGGER IF EXISTS trg_update_tx_events ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_block_events();
+DROP FUNCTION IF EXISTS api.update_tx_events();
+DROP FUNCTION IF EXISTS api.extract_block_events(BIGINT, JSONB);
+DROP FUNCTION IF EXISTS api.extract_tx_events(TEXT, JSONB);
+DROP FUNCTION IF EXISTS api.insert_events(BIGINT, TEXT, JSONB);
+DROP TABLE IF EXISTS api.events;
+
+COMMIT;
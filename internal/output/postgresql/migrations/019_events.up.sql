@@ -0,0 +1,86 @@
+-- Migration 019: Add events table
+--
+-- Flattens the events of the transactions and the finalize block events of
+-- the block results into one row per event attribute, so that events can be
+-- queried without unnesting the raw JSON. The finalize block events have no
+-- transaction hash. Events without attributes are kept as a single row with a
+-- NULL attribute.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.events (
+    id BIGSERIAL PRIMARY KEY,
+    height BIGINT,
+    tx_hash TEXT REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    event_index INTEGER NOT NULL,
+    event_type TEXT NOT NULL,
+    attribute_index INTEGER,
+    attribute_key TEXT,
+    attribute_value TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_height ON api.events(height);
+CREATE INDEX IF NOT EXISTS idx_events_tx_hash ON api.events(tx_hash) WHERE tx_hash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_events_type_key ON api.events(event_type, attribute_key, height);
+-- Hash index, since the attribute values can exceed the size limit of B-tree entries
+CREATE INDEX IF NOT EXISTS idx_events_attribute_value ON api.events USING hash (attribute_value);
+
+-- Inserts the attributes of the events, or a single row for the events without attributes
+CREATE OR REPLACE FUNCTION api.insert_events(_height BIGINT, _tx_hash TEXT, _events JSONB) RETURNS VOID AS $$
+BEGIN
+    INSERT INTO api.events (height, tx_hash, event_index, event_type, attribute_index, attribute_key, attribute_value)
+    SELECT _height, _tx_hash, e.ordinality - 1, e.value->>'type', a.ordinality - 1, a.value->>'key', a.value->>'value'
+    FROM jsonb_array_elements(COALESCE(_events, '[]'::JSONB)) WITH ORDINALITY e
+    LEFT JOIN LATERAL jsonb_array_elements(COALESCE(e.value->'attributes', '[]'::JSONB)) WITH ORDINALITY a ON TRUE
+    WHERE e.value->>'type' IS NOT NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Replaces the events of a transaction
+CREATE OR REPLACE FUNCTION api.extract_tx_events(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+BEGIN
+    DELETE FROM api.events WHERE tx_hash = _tx_hash;
+    PERFORM api.insert_events((_data->'txResponse'->>'height')::BIGINT, _tx_hash, _data->'txResponse'->'events');
+END;
+$$ LANGUAGE plpgsql;
+
+-- Replaces the finalize block events of a height
+CREATE OR REPLACE FUNCTION api.extract_block_events(_height BIGINT, _data JSONB) RETURNS VOID AS $$
+BEGIN
+    DELETE FROM api.events WHERE height = _height AND tx_hash IS NULL;
+    PERFORM api.insert_events(_height, NULL, _data->'finalizeBlockEvents');
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_tx_events() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_tx_events(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_block_events() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        DELETE FROM api.events WHERE height = OLD.height AND tx_hash IS NULL;
+        RETURN OLD;
+    END IF;
+    PERFORM api.extract_block_events(NEW.height, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_tx_events ON api.transactions_raw;
+CREATE TRIGGER trg_update_tx_events
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_tx_events();
+
+DROP TRIGGER IF EXISTS trg_update_block_events ON api.block_results_raw;
+CREATE TRIGGER trg_update_block_events
+AFTER INSERT OR UPDATE OR DELETE ON api.block_results_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_block_events();
+
+-- Read access for PostgREST
+GRANT SELECT ON api.events TO web_anon;
+
+COMMIT;
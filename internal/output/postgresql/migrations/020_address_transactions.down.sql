@@ -0,0 +1,7 @@
+-- Migration 020 down: Remove address_transactions table
+
+BEGIN;
+
+DROP TABLE IF EXISTS api.address_transactions;
+
+COMMIT;
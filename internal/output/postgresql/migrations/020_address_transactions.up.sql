@@ -0,0 +1,23 @@
+-- Migration 020: Add address_transactions table
+--
+-- Links every bech32 address found in a transaction, e.g., in its messages,
+-- its signer infos or its event attributes, to the transaction hash and its
+-- height, for "all activity of an address" queries without full scans. The
+-- addresses are found by the indexer, which validates their checksum.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.address_transactions (
+    address TEXT NOT NULL,
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    height BIGINT,
+    PRIMARY KEY (address, tx_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_address_transactions_address_height ON api.address_transactions(address, height DESC);
+CREATE INDEX IF NOT EXISTS idx_address_transactions_tx_hash ON api.address_transactions(tx_hash);
+
+-- Read access for PostgREST
+GRANT SELECT ON api.address_transactions TO web_anon;
+
+COMMIT;
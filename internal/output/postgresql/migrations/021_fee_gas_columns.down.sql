@@ -0,0 +1,15 @@
+-- Migration 021 down: Remove fee and gas columns from transactions_raw
+
+BEGIN;
+
+DROP INDEX IF EXISTS api.idx_transactions_fee_denom;
+
+ALTER TABLE api.transactions_raw
+    DROP COLUMN IF EXISTS fee_amount,
+    DROP COLUMN IF EXISTS fee_denom,
+    DROP COLUMN IF EXISTS fee_amounts,
+    DROP COLUMN IF EXISTS gas_limit,
+    DROP COLUMN IF EXISTS gas_wanted,
+    DROP COLUMN IF EXISTS gas_used;
+
+COMMIT;
@@ -0,0 +1,26 @@
+-- Migration 021: Add fee and gas columns to transactions_raw
+--
+-- Exposes the fee and the gas of the transactions as numeric columns, for
+-- fee revenue and gas usage reporting without parsing the raw JSON. The fee
+-- columns hold the first coin of the fee, which is the only one on most
+-- chains; the full fee is kept in fee_amounts.
+
+BEGIN;
+
+ALTER TABLE api.transactions_raw
+    ADD COLUMN IF NOT EXISTS fee_amount NUMERIC
+        GENERATED ALWAYS AS ((data->'tx'->'authInfo'->'fee'->'amount'->0->>'amount')::NUMERIC) STORED,
+    ADD COLUMN IF NOT EXISTS fee_denom TEXT
+        GENERATED ALWAYS AS (data->'tx'->'authInfo'->'fee'->'amount'->0->>'denom') STORED,
+    ADD COLUMN IF NOT EXISTS fee_amounts JSONB
+        GENERATED ALWAYS AS (data->'tx'->'authInfo'->'fee'->'amount') STORED,
+    ADD COLUMN IF NOT EXISTS gas_limit BIGINT
+        GENERATED ALWAYS AS ((data->'tx'->'authInfo'->'fee'->>'gasLimit')::BIGINT) STORED,
+    ADD COLUMN IF NOT EXISTS gas_wanted BIGINT
+        GENERATED ALWAYS AS ((data->'txResponse'->>'gasWanted')::BIGINT) STORED,
+    ADD COLUMN IF NOT EXISTS gas_used BIGINT
+        GENERATED ALWAYS AS ((data->'txResponse'->>'gasUsed')::BIGINT) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_fee_denom ON api.transactions_raw(fee_denom) WHERE fee_denom IS NOT NULL;
+
+COMMIT;
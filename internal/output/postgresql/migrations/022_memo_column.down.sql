@@ -0,0 +1,9 @@
+-- Migration 022 down: Remove memo column from transactions_raw
+
+BEGIN;
+
+DROP INDEX IF EXISTS api.idx_transactions_memo;
+
+ALTER TABLE api.transactions_raw DROP COLUMN IF EXISTS memo;
+
+COMMIT;
@@ -0,0 +1,15 @@
+-- Migration 022: Add memo column to transactions_raw
+--
+-- Exposes the memo of the transactions as a column, for exchange deposit
+-- tracking and memo searches without parsing the raw JSON. Memos matching
+-- the --redact-memo patterns are redacted before the transactions are stored.
+
+BEGIN;
+
+ALTER TABLE api.transactions_raw
+    ADD COLUMN IF NOT EXISTS memo TEXT
+        GENERATED ALWAYS AS (NULLIF(data->'tx'->'body'->>'memo', '')) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_memo ON api.transactions_raw(memo) WHERE memo IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,12 @@
+-- Migration 023 down: Remove contract_executions and contract_events tables
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_update_wasm ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_wasm();
+DROP FUNCTION IF EXISTS api.extract_wasm(TEXT, JSONB);
+DROP FUNCTION IF EXISTS api.decode_wasm_msg(JSONB);
+DROP TABLE IF EXISTS api.contract_events;
+DROP TABLE IF EXISTS api.contract_executions;
+
+COMMIT;
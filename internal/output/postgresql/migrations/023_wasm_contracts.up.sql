@@ -0,0 +1,120 @@
+-- Migration 023: Add contract_executions and contract_events tables
+--
+-- Decodes the CosmWasm execute and instantiate messages, whose inner JSON is
+-- base64-encoded by the node, into api.contract_executions, and indexes the
+-- events emitted by the contracts, i.e., the events with a _contract_address
+-- attribute, per contract address into api.contract_events.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.contract_executions (
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    msg_index INTEGER NOT NULL,
+    height BIGINT,
+    msg_type TEXT NOT NULL,
+    sender TEXT,
+    contract_address TEXT,
+    code_id BIGINT,
+    label TEXT,
+    action TEXT,
+    msg JSONB,
+    funds JSONB,
+    PRIMARY KEY (tx_hash, msg_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_contract_executions_contract ON api.contract_executions(contract_address, height) WHERE contract_address IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_contract_executions_sender ON api.contract_executions(sender, height);
+CREATE INDEX IF NOT EXISTS idx_contract_executions_code_id ON api.contract_executions(code_id) WHERE code_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS api.contract_events (
+    id BIGSERIAL PRIMARY KEY,
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    height BIGINT,
+    event_index INTEGER NOT NULL,
+    contract_address TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    attributes JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_contract_events_contract ON api.contract_events(contract_address, height);
+CREATE INDEX IF NOT EXISTS idx_contract_events_tx_hash ON api.contract_events(tx_hash);
+CREATE INDEX IF NOT EXISTS idx_contract_events_type ON api.contract_events(event_type, height);
+
+-- Decodes the inner JSON of a CosmWasm message, base64-encoded by the node, or NULL if it is not JSON
+CREATE OR REPLACE FUNCTION api.decode_wasm_msg(_msg JSONB) RETURNS JSONB AS $$
+BEGIN
+    IF jsonb_typeof(_msg) = 'object' THEN
+        RETURN _msg;
+    END IF;
+    IF jsonb_typeof(_msg) <> 'string' THEN
+        RETURN NULL;
+    END IF;
+    RETURN convert_from(decode(_msg #>> '{}', 'base64'), 'UTF8')::JSONB;
+EXCEPTION WHEN OTHERS THEN
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Replaces the contract executions and the contract events of a transaction
+CREATE OR REPLACE FUNCTION api.extract_wasm(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+DECLARE
+    _height BIGINT;
+BEGIN
+    _height := (_data->'txResponse'->>'height')::BIGINT;
+
+    DELETE FROM api.contract_executions WHERE tx_hash = _tx_hash;
+    DELETE FROM api.contract_events WHERE tx_hash = _tx_hash;
+
+    INSERT INTO api.contract_executions (tx_hash, msg_index, height, msg_type, sender, contract_address, code_id, label, action, msg, funds)
+    SELECT _tx_hash, m.msg_index, _height,
+        CASE WHEN m.type_url = '/cosmwasm.wasm.v1.MsgExecuteContract' THEN 'execute' ELSE 'instantiate' END,
+        m.value->>'sender',
+        m.value->>'contract',
+        (m.value->>'codeId')::BIGINT,
+        m.value->>'label',
+        CASE WHEN jsonb_typeof(m.decoded) = 'object' THEN (SELECT k FROM jsonb_object_keys(m.decoded) k LIMIT 1) END,
+        m.decoded,
+        m.value->'funds'
+    FROM (
+        SELECT m.ordinality - 1 AS msg_index, m.value, m.value->>'@type' AS type_url, api.decode_wasm_msg(m.value->'msg') AS decoded
+        FROM jsonb_array_elements(COALESCE(_data->'tx'->'body'->'messages', '[]'::JSONB)) WITH ORDINALITY m
+    ) m
+    WHERE m.type_url IN (
+        '/cosmwasm.wasm.v1.MsgExecuteContract',
+        '/cosmwasm.wasm.v1.MsgInstantiateContract',
+        '/cosmwasm.wasm.v1.MsgInstantiateContract2'
+    );
+
+    INSERT INTO api.contract_events (tx_hash, height, event_index, contract_address, event_type, attributes)
+    SELECT _tx_hash, _height, e.ordinality - 1,
+        (SELECT a->>'value' FROM jsonb_array_elements(e.value->'attributes') a WHERE a->>'key' = '_contract_address' LIMIT 1),
+        e.value->>'type',
+        COALESCE((
+            SELECT jsonb_object_agg(a->>'key', a->'value')
+            FROM jsonb_array_elements(e.value->'attributes') a
+            WHERE a->>'key' IS NOT NULL AND a->>'key' <> '_contract_address'
+        ), '{}'::JSONB)
+    FROM jsonb_array_elements(COALESCE(_data->'txResponse'->'events', '[]'::JSONB)) WITH ORDINALITY e
+    WHERE e.value->>'type' IS NOT NULL
+      AND jsonb_typeof(e.value->'attributes') = 'array'
+      AND e.value->'attributes' @> '[{"key": "_contract_address"}]';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_wasm() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_wasm(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_wasm ON api.transactions_raw;
+CREATE TRIGGER trg_update_wasm
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_wasm();
+
+-- Read access for PostgREST
+GRANT SELECT ON api.contract_executions TO web_anon;
+GRANT SELECT ON api.contract_events TO web_anon;
+
+COMMIT;
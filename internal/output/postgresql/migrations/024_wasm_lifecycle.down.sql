@@ -0,0 +1,13 @@
+-- Migration 024 down: Remove wasm_codes and wasm_contracts tables
+
+BEGIN;
+
+DROP VIEW IF EXISTS api.wasm_contracts;
+DROP TRIGGER IF EXISTS trg_update_wasm_lifecycle ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_wasm_lifecycle();
+DROP FUNCTION IF EXISTS api.extract_wasm_lifecycle(TEXT, JSONB);
+DROP FUNCTION IF EXISTS api.wasm_event_message(JSONB, JSONB, TEXT[]);
+DROP TABLE IF EXISTS api.wasm_contract_history;
+DROP TABLE IF EXISTS api.wasm_codes;
+
+COMMIT;
@@ -0,0 +1,189 @@
+-- Migration 024: Add wasm_codes and wasm_contracts tables
+--
+-- Tracks the lifecycle of the CosmWasm codes and contracts from the events of
+-- the successful transactions: code uploads into api.wasm_codes, and contract
+-- instantiations, migrations and admin changes into
+-- api.wasm_contract_history. api.wasm_contracts is the current state of each
+-- contract, computed from its history so that it follows reorgs.
+--
+-- The events are matched to their message with the msg_index attribute of the
+-- Cosmos SDK 0.50 events, or to the first message of the expected type on
+-- earlier versions. The contracts instantiated by other contracts have no
+-- creator, admin nor label, since these are not part of the events.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.wasm_codes (
+    code_id BIGINT PRIMARY KEY,
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    height BIGINT,
+    creator TEXT,
+    checksum TEXT,
+    instantiate_permission JSONB
+);
+
+CREATE INDEX IF NOT EXISTS idx_wasm_codes_tx_hash ON api.wasm_codes(tx_hash);
+CREATE INDEX IF NOT EXISTS idx_wasm_codes_creator ON api.wasm_codes(creator);
+
+CREATE TABLE IF NOT EXISTS api.wasm_contract_history (
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    event_index INTEGER NOT NULL,
+    height BIGINT,
+    contract_address TEXT NOT NULL,
+    operation TEXT NOT NULL,
+    code_id BIGINT,
+    creator TEXT,
+    admin TEXT,
+    label TEXT,
+    msg JSONB,
+    PRIMARY KEY (tx_hash, event_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wasm_contract_history_contract ON api.wasm_contract_history(contract_address, height);
+CREATE INDEX IF NOT EXISTS idx_wasm_contract_history_code_id ON api.wasm_contract_history(code_id) WHERE code_id IS NOT NULL;
+
+-- Returns the message of a transaction an event was emitted by, if it is of one of the given types
+CREATE OR REPLACE FUNCTION api.wasm_event_message(_data JSONB, _event JSONB, _type_urls TEXT[]) RETURNS JSONB AS $$
+DECLARE
+    _msg_index TEXT;
+    _msg JSONB;
+BEGIN
+    SELECT a->>'value' INTO _msg_index
+    FROM jsonb_array_elements(_event->'attributes') a
+    WHERE a->>'key' = 'msg_index'
+    LIMIT 1;
+
+    IF _msg_index IS NOT NULL THEN
+        _msg := _data->'tx'->'body'->'messages'->(_msg_index::INTEGER);
+    ELSE
+        SELECT m INTO _msg
+        FROM jsonb_array_elements(COALESCE(_data->'tx'->'body'->'messages', '[]'::JSONB)) m
+        WHERE m->>'@type' = ANY(_type_urls)
+        LIMIT 1;
+    END IF;
+
+    IF _msg->>'@type' = ANY(_type_urls) THEN
+        RETURN _msg;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Replaces the codes and the contract history of a transaction
+CREATE OR REPLACE FUNCTION api.extract_wasm_lifecycle(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+DECLARE
+    _height BIGINT;
+    _event RECORD;
+    _attrs JSONB;
+    _msg JSONB;
+BEGIN
+    _height := (_data->'txResponse'->>'height')::BIGINT;
+
+    DELETE FROM api.wasm_codes WHERE tx_hash = _tx_hash;
+    DELETE FROM api.wasm_contract_history WHERE tx_hash = _tx_hash;
+
+    IF COALESCE((_data->'txResponse'->>'code')::INTEGER, 0) <> 0 THEN
+        RETURN;
+    END IF;
+
+    FOR _event IN
+        SELECT e.ordinality - 1 AS event_index, e.value
+        FROM jsonb_array_elements(COALESCE(_data->'txResponse'->'events', '[]'::JSONB)) WITH ORDINALITY e
+        WHERE e.value->>'type' IN ('store_code', 'instantiate', 'migrate', 'update_contract_admin')
+    LOOP
+        SELECT jsonb_object_agg(a->>'key', a->>'value') INTO _attrs
+        FROM jsonb_array_elements(COALESCE(_event.value->'attributes', '[]'::JSONB)) a
+        WHERE a->>'key' IS NOT NULL;
+
+        IF _event.value->>'type' = 'store_code' THEN
+            _msg := api.wasm_event_message(_data, _event.value, ARRAY['/cosmwasm.wasm.v1.MsgStoreCode', '/cosmwasm.wasm.v1.MsgStoreAndInstantiateContract']);
+            INSERT INTO api.wasm_codes (code_id, tx_hash, height, creator, checksum, instantiate_permission)
+            VALUES ((_attrs->>'code_id')::BIGINT, _tx_hash, _height, COALESCE(_msg->>'sender', _msg->>'authority'), _attrs->>'code_checksum', _msg->'instantiatePermission')
+            ON CONFLICT (code_id) DO UPDATE SET
+                tx_hash = EXCLUDED.tx_hash,
+                height = EXCLUDED.height,
+                creator = EXCLUDED.creator,
+                checksum = EXCLUDED.checksum,
+                instantiate_permission = EXCLUDED.instantiate_permission;
+            CONTINUE;
+        END IF;
+
+        IF _attrs->>'_contract_address' IS NULL THEN
+            CONTINUE;
+        END IF;
+
+        IF _event.value->>'type' = 'instantiate' THEN
+            _msg := api.wasm_event_message(_data, _event.value, ARRAY['/cosmwasm.wasm.v1.MsgInstantiateContract', '/cosmwasm.wasm.v1.MsgInstantiateContract2', '/cosmwasm.wasm.v1.MsgStoreAndInstantiateContract']);
+            INSERT INTO api.wasm_contract_history (tx_hash, event_index, height, contract_address, operation, code_id, creator, admin, label, msg)
+            VALUES (_tx_hash, _event.event_index, _height, _attrs->>'_contract_address', 'instantiate', (_attrs->>'code_id')::BIGINT,
+                    COALESCE(_msg->>'sender', _msg->>'authority'), NULLIF(_msg->>'admin', ''), _msg->>'label', api.decode_wasm_msg(_msg->'msg'));
+        ELSIF _event.value->>'type' = 'migrate' THEN
+            _msg := api.wasm_event_message(_data, _event.value, ARRAY['/cosmwasm.wasm.v1.MsgMigrateContract']);
+            INSERT INTO api.wasm_contract_history (tx_hash, event_index, height, contract_address, operation, code_id, msg)
+            VALUES (_tx_hash, _event.event_index, _height, _attrs->>'_contract_address', 'migrate', (_attrs->>'code_id')::BIGINT,
+                    api.decode_wasm_msg(_msg->'msg'));
+        ELSE
+            INSERT INTO api.wasm_contract_history (tx_hash, event_index, height, contract_address, operation, admin)
+            VALUES (_tx_hash, _event.event_index, _height, _attrs->>'_contract_address',
+                    CASE WHEN COALESCE(_attrs->>'new_admin_address', '') = '' THEN 'clear_admin' ELSE 'update_admin' END,
+                    NULLIF(_attrs->>'new_admin_address', ''));
+        END IF;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_wasm_lifecycle() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_wasm_lifecycle(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_wasm_lifecycle ON api.transactions_raw;
+CREATE TRIGGER trg_update_wasm_lifecycle
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_wasm_lifecycle();
+
+-- Current state of the contracts: the code of their latest instantiation or migration and their latest admin
+CREATE OR REPLACE VIEW api.wasm_contracts AS
+SELECT
+    i.contract_address,
+    code.code_id,
+    i.creator,
+    admin.admin,
+    i.label,
+    i.height AS instantiated_height,
+    i.tx_hash AS instantiated_tx_hash,
+    latest.height AS updated_height
+FROM (
+    SELECT DISTINCT ON (contract_address) *
+    FROM api.wasm_contract_history
+    WHERE operation = 'instantiate'
+    ORDER BY contract_address, height, event_index
+) i
+CROSS JOIN LATERAL (
+    SELECT h.code_id
+    FROM api.wasm_contract_history h
+    WHERE h.contract_address = i.contract_address AND h.operation IN ('instantiate', 'migrate')
+    ORDER BY h.height DESC, h.event_index DESC
+    LIMIT 1
+) code
+CROSS JOIN LATERAL (
+    SELECT h.admin
+    FROM api.wasm_contract_history h
+    WHERE h.contract_address = i.contract_address AND h.operation IN ('instantiate', 'update_admin', 'clear_admin')
+    ORDER BY h.height DESC, h.event_index DESC
+    LIMIT 1
+) admin
+CROSS JOIN LATERAL (
+    SELECT MAX(h.height) AS height
+    FROM api.wasm_contract_history h
+    WHERE h.contract_address = i.contract_address
+) latest;
+
+-- Read access for PostgREST
+GRANT SELECT ON api.wasm_codes TO web_anon;
+GRANT SELECT ON api.wasm_contract_history TO web_anon;
+GRANT SELECT ON api.wasm_contracts TO web_anon;
+
+COMMIT;
@@ -0,0 +1,11 @@
+-- Migration 025 down: Remove evm_transactions table
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_update_evm_transactions ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_evm_transactions();
+DROP FUNCTION IF EXISTS api.extract_evm_transactions(TEXT, JSONB);
+DROP FUNCTION IF EXISTS api.decode_base64(TEXT);
+DROP TABLE IF EXISTS api.evm_transactions;
+
+COMMIT;
@@ -0,0 +1,141 @@
+-- Migration 025: Add evm_transactions table
+--
+-- Decodes the MsgEthereumTx messages of the EVM-enabled chains (Ethermint and
+-- Cosmos EVM) into one row per Ethereum transaction, with its Ethereum hash,
+-- sender, recipient, value, nonce, gas and the selector of its input, so that
+-- the EVM activity is queryable alongside the Cosmos messages. The gas used
+-- and the failure of the Ethereum transactions are read from their
+-- ethereum_tx events.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.evm_transactions (
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    msg_index INTEGER NOT NULL,
+    height BIGINT,
+    eth_hash TEXT,
+    tx_type SMALLINT,
+    from_address TEXT,
+    to_address TEXT,
+    value NUMERIC,
+    nonce BIGINT,
+    gas_limit BIGINT,
+    gas_price NUMERIC,
+    gas_fee_cap NUMERIC,
+    gas_tip_cap NUMERIC,
+    input_selector TEXT,
+    input_size INTEGER,
+    gas_used BIGINT,
+    failed BOOLEAN,
+    PRIMARY KEY (tx_hash, msg_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_evm_transactions_eth_hash ON api.evm_transactions(eth_hash);
+CREATE INDEX IF NOT EXISTS idx_evm_transactions_from ON api.evm_transactions(from_address, height);
+CREATE INDEX IF NOT EXISTS idx_evm_transactions_to ON api.evm_transactions(to_address, height) WHERE to_address IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_evm_transactions_selector ON api.evm_transactions(input_selector) WHERE input_selector IS NOT NULL;
+
+-- Decodes base64 bytes, or NULL if they are not valid base64
+CREATE OR REPLACE FUNCTION api.decode_base64(_value TEXT) RETURNS BYTEA AS $$
+BEGIN
+    RETURN decode(_value, 'base64');
+EXCEPTION WHEN OTHERS THEN
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Replaces the Ethereum transactions of a transaction
+CREATE OR REPLACE FUNCTION api.extract_evm_transactions(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+BEGIN
+    DELETE FROM api.evm_transactions WHERE tx_hash = _tx_hash;
+
+    INSERT INTO api.evm_transactions (
+        tx_hash, msg_index, height, eth_hash, tx_type, from_address, to_address, value, nonce,
+        gas_limit, gas_price, gas_fee_cap, gas_tip_cap, input_selector, input_size, gas_used, failed
+    )
+    WITH msgs AS (
+        SELECT m.ordinality - 1 AS msg_index, m.value, api.decode_base64(m.value->'data'->>'data') AS input,
+            ROW_NUMBER() OVER (ORDER BY m.ordinality) AS evm_index
+        FROM jsonb_array_elements(COALESCE(_data->'tx'->'body'->'messages', '[]'::JSONB)) WITH ORDINALITY m
+        WHERE m.value->>'@type' IN ('/ethermint.evm.v1.MsgEthereumTx', '/cosmos.evm.vm.v1.MsgEthereumTx')
+    ),
+    events AS (
+        SELECT e.ordinality, e.value,
+            (SELECT LOWER(a->>'value') FROM jsonb_array_elements(e.value->'attributes') a WHERE a->>'key' = 'ethereumTxHash' LIMIT 1) AS eth_hash
+        FROM jsonb_array_elements(COALESCE(_data->'txResponse'->'events', '[]'::JSONB)) WITH ORDINALITY e
+        WHERE e.value->>'type' = 'ethereum_tx' AND jsonb_typeof(e.value->'attributes') = 'array'
+    ),
+    -- The ethereum_tx events of an Ethereum transaction, emitted by the ante handler and by the message, are merged
+    eth_txs AS (
+        SELECT e.eth_hash, MIN(e.ordinality) AS first_ordinality,
+            jsonb_object_agg(a->>'key', a->>'value' ORDER BY e.ordinality) AS attrs
+        FROM events e
+        CROSS JOIN LATERAL jsonb_array_elements(e.value->'attributes') a
+        WHERE e.eth_hash IS NOT NULL AND a->>'key' IS NOT NULL
+        GROUP BY e.eth_hash
+    ),
+    eth_txs_ordered AS (
+        SELECT *, ROW_NUMBER() OVER (ORDER BY first_ordinality) AS evm_index FROM eth_txs
+    ),
+    -- The n-th message event of the evm module holds the sender of the n-th Ethereum transaction
+    senders AS (
+        SELECT ROW_NUMBER() OVER (ORDER BY e.ordinality) AS evm_index,
+            (SELECT a->>'value' FROM jsonb_array_elements(e.value->'attributes') a WHERE a->>'key' = 'sender' LIMIT 1) AS address
+        FROM jsonb_array_elements(COALESCE(_data->'txResponse'->'events', '[]'::JSONB)) WITH ORDINALITY e
+        WHERE e.value->>'type' = 'message' AND e.value->'attributes' @> '[{"key": "module", "value": "evm"}]'
+    )
+    SELECT
+        _tx_hash,
+        m.msg_index,
+        (_data->'txResponse'->>'height')::BIGINT,
+        COALESCE(LOWER(NULLIF(m.value->>'hash', '')), ev.eth_hash),
+        CASE m.value->'data'->>'@type'
+            WHEN '/ethermint.evm.v1.LegacyTx' THEN 0
+            WHEN '/cosmos.evm.vm.v1.LegacyTx' THEN 0
+            WHEN '/ethermint.evm.v1.AccessListTx' THEN 1
+            WHEN '/cosmos.evm.vm.v1.AccessListTx' THEN 1
+            WHEN '/ethermint.evm.v1.DynamicFeeTx' THEN 2
+            WHEN '/cosmos.evm.vm.v1.DynamicFeeTx' THEN 2
+        END,
+        LOWER(COALESCE(
+            -- The sender is a hexadecimal address on Ethermint, and base64 bytes on Cosmos EVM
+            CASE WHEN m.value->>'from' LIKE '0x%' THEN m.value->>'from' END,
+            '0x' || NULLIF(encode(api.decode_base64(m.value->>'from'), 'hex'), ''),
+            s.address
+        )),
+        LOWER(COALESCE(NULLIF(m.value->'data'->>'to', ''), NULLIF(ev.attrs->>'recipient', ''))),
+        (m.value->'data'->>'value')::NUMERIC,
+        (m.value->'data'->>'nonce')::BIGINT,
+        (m.value->'data'->>'gas')::BIGINT,
+        (m.value->'data'->>'gasPrice')::NUMERIC,
+        (m.value->'data'->>'gasFeeCap')::NUMERIC,
+        (m.value->'data'->>'gasTipCap')::NUMERIC,
+        NULLIF('0x' || encode(substring(m.input FROM 1 FOR 4), 'hex'), '0x'),
+        length(m.input),
+        (ev.attrs->>'txGasUsed')::BIGINT,
+        COALESCE((_data->'txResponse'->>'code')::INTEGER, 0) <> 0 OR COALESCE(ev.attrs ? 'ethereumTxFailed', FALSE)
+    FROM msgs m
+    LEFT JOIN eth_txs_ordered ev ON CASE
+        WHEN NULLIF(m.value->>'hash', '') IS NOT NULL THEN ev.eth_hash = LOWER(m.value->>'hash')
+        ELSE ev.evm_index = m.evm_index
+    END
+    LEFT JOIN senders s ON s.evm_index = m.evm_index;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_evm_transactions() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_evm_transactions(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_evm_transactions ON api.transactions_raw;
+CREATE TRIGGER trg_update_evm_transactions
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_evm_transactions();
+
+-- Read access for PostgREST
+GRANT SELECT ON api.evm_transactions TO web_anon;
+
+COMMIT;
@@ -0,0 +1,40 @@
+-- Migration 026 down: Stop unwrapping authz messages and remove feegrant_usage table
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_update_feegrant_usage ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_feegrant_usage();
+DROP FUNCTION IF EXISTS api.extract_feegrant_usage(TEXT, JSONB);
+DROP TABLE IF EXISTS api.feegrant_usage;
+
+DELETE FROM api.messages WHERE depth > 0;
+DROP INDEX IF EXISTS api.idx_messages_parent;
+ALTER TABLE api.messages
+    DROP COLUMN IF EXISTS parent_msg_index,
+    DROP COLUMN IF EXISTS depth;
+
+-- Restore the extraction of the top-level messages only
+CREATE OR REPLACE FUNCTION api.extract_messages(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+DECLARE
+    _height BIGINT;
+BEGIN
+    _height := (_data->'txResponse'->>'height')::BIGINT;
+
+    DELETE FROM api.messages WHERE tx_hash = _tx_hash;
+
+    INSERT INTO api.messages (tx_hash, msg_index, type_url, height, data)
+    SELECT _tx_hash, m.ordinality - 1, m.value->>'@type', _height, m.value
+    FROM jsonb_array_elements(COALESCE(_data->'tx'->'body'->'messages', '[]'::JSONB)) WITH ORDINALITY m
+    WHERE m.value->>'@type' IS NOT NULL;
+
+    INSERT INTO api.message_types (type_url, first_height, last_height)
+    SELECT DISTINCT m->>'@type', _height, _height
+    FROM jsonb_array_elements(COALESCE(_data->'tx'->'body'->'messages', '[]'::JSONB)) m
+    WHERE m->>'@type' IS NOT NULL
+    ON CONFLICT (type_url) DO UPDATE SET
+        first_height = LEAST(api.message_types.first_height, EXCLUDED.first_height),
+        last_height = GREATEST(api.message_types.last_height, EXCLUDED.last_height);
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
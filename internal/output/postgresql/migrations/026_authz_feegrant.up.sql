@@ -0,0 +1,115 @@
+-- Migration 026: Unwrap authz messages and add feegrant_usage table
+--
+-- The messages executed on behalf of a granter with MsgExec are unwrapped,
+-- recursively, into api.messages, so that the granted actions can be queried
+-- by message type like the other messages. The top-level messages keep their
+-- index in the transaction; the nested messages are numbered after them and
+-- point to their wrapper with parent_msg_index.
+--
+-- The fees paid by a feegrant allowance are indexed into api.feegrant_usage.
+
+BEGIN;
+
+ALTER TABLE api.messages
+    ADD COLUMN IF NOT EXISTS parent_msg_index INTEGER,
+    ADD COLUMN IF NOT EXISTS depth INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_messages_parent ON api.messages(tx_hash, parent_msg_index) WHERE parent_msg_index IS NOT NULL;
+
+-- Replaces the messages of a transaction, with the messages nested in MsgExec
+CREATE OR REPLACE FUNCTION api.extract_messages(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+DECLARE
+    _height BIGINT;
+BEGIN
+    _height := (_data->'txResponse'->>'height')::BIGINT;
+
+    DELETE FROM api.messages WHERE tx_hash = _tx_hash;
+
+    INSERT INTO api.messages (tx_hash, msg_index, type_url, height, data, parent_msg_index, depth)
+    WITH RECURSIVE tree (path, parent_path, value, depth) AS (
+        SELECT ARRAY[(m.ordinality - 1)::INTEGER], NULL::INTEGER[], m.value, 0
+        FROM jsonb_array_elements(COALESCE(_data->'tx'->'body'->'messages', '[]'::JSONB)) WITH ORDINALITY m
+        UNION ALL
+        SELECT t.path || (m.ordinality - 1)::INTEGER, t.path, m.value, t.depth + 1
+        FROM tree t
+        CROSS JOIN LATERAL jsonb_array_elements(t.value->'msgs') WITH ORDINALITY m
+        WHERE t.value->>'@type' = '/cosmos.authz.v1beta1.MsgExec' AND jsonb_typeof(t.value->'msgs') = 'array'
+    ),
+    numbered AS (
+        SELECT t.*, (ROW_NUMBER() OVER (ORDER BY t.depth > 0, t.path) - 1)::INTEGER AS msg_index
+        FROM tree t
+    )
+    SELECT _tx_hash, n.msg_index, n.value->>'@type', _height, n.value, p.msg_index, n.depth
+    FROM numbered n
+    LEFT JOIN numbered p ON p.path = n.parent_path
+    WHERE n.value->>'@type' IS NOT NULL;
+
+    INSERT INTO api.message_types (type_url, first_height, last_height)
+    SELECT DISTINCT m.type_url, _height, _height
+    FROM api.messages m
+    WHERE m.tx_hash = _tx_hash
+    ON CONFLICT (type_url) DO UPDATE SET
+        first_height = LEAST(api.message_types.first_height, EXCLUDED.first_height),
+        last_height = GREATEST(api.message_types.last_height, EXCLUDED.last_height);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TABLE IF NOT EXISTS api.feegrant_usage (
+    tx_hash TEXT PRIMARY KEY REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    height BIGINT,
+    granter TEXT NOT NULL,
+    grantee TEXT,
+    fee JSONB
+);
+
+CREATE INDEX IF NOT EXISTS idx_feegrant_usage_granter ON api.feegrant_usage(granter, height);
+CREATE INDEX IF NOT EXISTS idx_feegrant_usage_grantee ON api.feegrant_usage(grantee, height);
+
+-- Replaces the feegrant usage of a transaction, from the granter of its fee or its use_feegrant event
+CREATE OR REPLACE FUNCTION api.extract_feegrant_usage(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+DECLARE
+    _event JSONB;
+    _granter TEXT;
+    _grantee TEXT;
+BEGIN
+    DELETE FROM api.feegrant_usage WHERE tx_hash = _tx_hash;
+
+    SELECT e INTO _event
+    FROM jsonb_array_elements(COALESCE(_data->'txResponse'->'events', '[]'::JSONB)) e
+    WHERE e->>'type' = 'use_feegrant'
+    LIMIT 1;
+
+    _granter := COALESCE(
+        NULLIF(_data->'tx'->'authInfo'->'fee'->>'granter', ''),
+        (SELECT a->>'value' FROM jsonb_array_elements(_event->'attributes') a WHERE a->>'key' = 'granter' LIMIT 1)
+    );
+    IF _granter IS NULL THEN
+        RETURN;
+    END IF;
+
+    _grantee := COALESCE(
+        (SELECT a->>'value' FROM jsonb_array_elements(_event->'attributes') a WHERE a->>'key' = 'grantee' LIMIT 1),
+        NULLIF(_data->'tx'->'authInfo'->'fee'->>'payer', '')
+    );
+
+    INSERT INTO api.feegrant_usage (tx_hash, height, granter, grantee, fee)
+    VALUES (_tx_hash, (_data->'txResponse'->>'height')::BIGINT, _granter, _grantee, _data->'tx'->'authInfo'->'fee'->'amount');
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_feegrant_usage() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_feegrant_usage(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_feegrant_usage ON api.transactions_raw;
+CREATE TRIGGER trg_update_feegrant_usage
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_feegrant_usage();
+
+-- Read access for PostgREST
+GRANT SELECT ON api.feegrant_usage TO web_anon;
+
+COMMIT;
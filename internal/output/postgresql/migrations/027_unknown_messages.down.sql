@@ -0,0 +1,10 @@
+-- Migration 027 down: Remove unknown_messages table
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_update_unknown_messages ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_unknown_messages();
+DROP FUNCTION IF EXISTS api.extract_unknown_messages(TEXT, JSONB);
+DROP TABLE IF EXISTS api.unknown_messages;
+
+COMMIT;
@@ -0,0 +1,50 @@
+-- Migration 027: Add unknown_messages table
+--
+-- The messages and the Any values whose type could not be resolved with the
+-- descriptors of the node are stored with their type URL and their raw value,
+-- in base64, in the unresolvedValue field of the JSON. They are indexed into
+-- api.unknown_messages with their raw bytes, so that they can be found and
+-- decoded later, e.g., with the .proto files of the chain.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.unknown_messages (
+    id BIGSERIAL PRIMARY KEY,
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    height BIGINT,
+    type_url TEXT NOT NULL,
+    value BYTEA
+);
+
+CREATE INDEX IF NOT EXISTS idx_unknown_messages_tx_hash ON api.unknown_messages(tx_hash);
+CREATE INDEX IF NOT EXISTS idx_unknown_messages_type_url ON api.unknown_messages(type_url, height);
+
+-- Replaces the unresolved values of a transaction
+CREATE OR REPLACE FUNCTION api.extract_unknown_messages(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+BEGIN
+    DELETE FROM api.unknown_messages WHERE tx_hash = _tx_hash;
+
+    -- The decoded transaction is also part of the response, only the first copy is indexed
+    INSERT INTO api.unknown_messages (tx_hash, height, type_url, value)
+    SELECT _tx_hash, (_data->'txResponse'->>'height')::BIGINT, v->>'@type', api.decode_base64(v->>'unresolvedValue')
+    FROM jsonb_path_query(COALESCE(_data->'tx', '{}'::JSONB), 'strict $.** ? (exists(@."unresolvedValue"))') v
+    WHERE v->>'@type' IS NOT NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_unknown_messages() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_unknown_messages(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_unknown_messages ON api.transactions_raw;
+CREATE TRIGGER trg_update_unknown_messages
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_unknown_messages();
+
+-- Read access for PostgREST
+GRANT SELECT ON api.unknown_messages TO web_anon;
+
+COMMIT;
@@ -0,0 +1,51 @@
+-- Migration 028 down: Stop unwrapping interchain accounts messages
+
+BEGIN;
+
+DELETE FROM api.messages WHERE ica_port IS NOT NULL OR ica_connection_id IS NOT NULL OR ica_channel_id IS NOT NULL;
+DROP INDEX IF EXISTS api.idx_messages_ica_connection;
+DROP INDEX IF EXISTS api.idx_messages_ica_port;
+ALTER TABLE api.messages
+    DROP COLUMN IF EXISTS ica_port,
+    DROP COLUMN IF EXISTS ica_connection_id,
+    DROP COLUMN IF EXISTS ica_channel_id;
+
+-- Restore the extraction of the messages nested in MsgExec only
+CREATE OR REPLACE FUNCTION api.extract_messages(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+DECLARE
+    _height BIGINT;
+BEGIN
+    _height := (_data->'txResponse'->>'height')::BIGINT;
+
+    DELETE FROM api.messages WHERE tx_hash = _tx_hash;
+
+    INSERT INTO api.messages (tx_hash, msg_index, type_url, height, data, parent_msg_index, depth)
+    WITH RECURSIVE tree (path, parent_path, value, depth) AS (
+        SELECT ARRAY[(m.ordinality - 1)::INTEGER], NULL::INTEGER[], m.value, 0
+        FROM jsonb_array_elements(COALESCE(_data->'tx'->'body'->'messages', '[]'::JSONB)) WITH ORDINALITY m
+        UNION ALL
+        SELECT t.path || (m.ordinality - 1)::INTEGER, t.path, m.value, t.depth + 1
+        FROM tree t
+        CROSS JOIN LATERAL jsonb_array_elements(t.value->'msgs') WITH ORDINALITY m
+        WHERE t.value->>'@type' = '/cosmos.authz.v1beta1.MsgExec' AND jsonb_typeof(t.value->'msgs') = 'array'
+    ),
+    numbered AS (
+        SELECT t.*, (ROW_NUMBER() OVER (ORDER BY t.depth > 0, t.path) - 1)::INTEGER AS msg_index
+        FROM tree t
+    )
+    SELECT _tx_hash, n.msg_index, n.value->>'@type', _height, n.value, p.msg_index, n.depth
+    FROM numbered n
+    LEFT JOIN numbered p ON p.path = n.parent_path
+    WHERE n.value->>'@type' IS NOT NULL;
+
+    INSERT INTO api.message_types (type_url, first_height, last_height)
+    SELECT DISTINCT m.type_url, _height, _height
+    FROM api.messages m
+    WHERE m.tx_hash = _tx_hash
+    ON CONFLICT (type_url) DO UPDATE SET
+        first_height = LEAST(api.message_types.first_height, EXCLUDED.first_height),
+        last_height = GREATEST(api.message_types.last_height, EXCLUDED.last_height);
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
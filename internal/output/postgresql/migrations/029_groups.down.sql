@@ -0,0 +1,17 @@
+-- Migration 029 down: Remove x/group tables
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_update_group ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_group();
+DROP FUNCTION IF EXISTS api.extract_group(TEXT, JSONB);
+DROP FUNCTION IF EXISTS api.message_event(JSONB, TEXT, INTEGER, INTEGER);
+DROP FUNCTION IF EXISTS api.typed_event_attribute(JSONB, TEXT);
+DROP TABLE IF EXISTS api.group_exec_results;
+DROP TABLE IF EXISTS api.group_votes;
+DROP TABLE IF EXISTS api.group_proposals;
+DROP TABLE IF EXISTS api.group_updates;
+DROP TABLE IF EXISTS api.group_policies;
+DROP TABLE IF EXISTS api.groups;
+
+COMMIT;
@@ -0,0 +1,279 @@
+-- Migration 029: Add x/group tables
+--
+-- Decodes the x/group messages and events of the successful transactions:
+--   - api.groups: the groups, with their admin, metadata and initial members
+--   - api.group_policies: the group policies, with their decision policy
+--   - api.group_updates: the later changes to the groups and policies
+--     (members, admins, metadata, decision policies and members leaving)
+--   - api.group_proposals: the proposals, and their withdrawal
+--   - api.group_votes: the votes on the proposals
+--   - api.group_exec_results: the execution results of the proposals
+--
+-- The IDs and addresses assigned by the module are read from its typed events,
+-- matched to their message with the msg_index attribute of the Cosmos SDK 0.50
+-- events, or by order of occurrence on earlier versions.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.groups (
+    group_id BIGINT PRIMARY KEY,
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    height BIGINT,
+    admin TEXT,
+    metadata TEXT,
+    members JSONB
+);
+
+CREATE INDEX IF NOT EXISTS idx_groups_tx_hash ON api.groups(tx_hash);
+CREATE INDEX IF NOT EXISTS idx_groups_admin ON api.groups(admin);
+
+CREATE TABLE IF NOT EXISTS api.group_policies (
+    address TEXT PRIMARY KEY,
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    height BIGINT,
+    group_id BIGINT,
+    admin TEXT,
+    metadata TEXT,
+    decision_policy JSONB
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_policies_tx_hash ON api.group_policies(tx_hash);
+CREATE INDEX IF NOT EXISTS idx_group_policies_group_id ON api.group_policies(group_id);
+
+CREATE TABLE IF NOT EXISTS api.group_updates (
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    msg_index INTEGER NOT NULL,
+    height BIGINT,
+    type_url TEXT NOT NULL,
+    group_id BIGINT,
+    group_policy_address TEXT,
+    data JSONB,
+    PRIMARY KEY (tx_hash, msg_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_updates_group_id ON api.group_updates(group_id, height);
+CREATE INDEX IF NOT EXISTS idx_group_updates_policy ON api.group_updates(group_policy_address, height) WHERE group_policy_address IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS api.group_proposals (
+    proposal_id BIGINT PRIMARY KEY,
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    height BIGINT,
+    group_policy_address TEXT,
+    proposers TEXT[],
+    title TEXT,
+    summary TEXT,
+    metadata TEXT,
+    messages JSONB,
+    withdrawn_tx_hash TEXT REFERENCES api.transactions_raw(id) ON DELETE SET NULL,
+    withdrawn_height BIGINT
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_proposals_tx_hash ON api.group_proposals(tx_hash);
+CREATE INDEX IF NOT EXISTS idx_group_proposals_policy ON api.group_proposals(group_policy_address, height);
+CREATE INDEX IF NOT EXISTS idx_group_proposals_withdrawn ON api.group_proposals(withdrawn_tx_hash) WHERE withdrawn_tx_hash IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS api.group_votes (
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    msg_index INTEGER NOT NULL,
+    height BIGINT,
+    proposal_id BIGINT NOT NULL,
+    voter TEXT NOT NULL,
+    option TEXT,
+    metadata TEXT,
+    PRIMARY KEY (tx_hash, msg_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_votes_proposal ON api.group_votes(proposal_id, height);
+CREATE INDEX IF NOT EXISTS idx_group_votes_voter ON api.group_votes(voter, height);
+
+CREATE TABLE IF NOT EXISTS api.group_exec_results (
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    event_index INTEGER NOT NULL,
+    height BIGINT,
+    proposal_id BIGINT NOT NULL,
+    result TEXT,
+    logs TEXT,
+    PRIMARY KEY (tx_hash, event_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_exec_results_proposal ON api.group_exec_results(proposal_id, height);
+
+-- Returns an attribute of a typed event, whose values are JSON-encoded
+CREATE OR REPLACE FUNCTION api.typed_event_attribute(_event JSONB, _key TEXT) RETURNS TEXT AS $$
+DECLARE
+    _value TEXT;
+BEGIN
+    SELECT a->>'value' INTO _value
+    FROM jsonb_array_elements(COALESCE(_event->'attributes', '[]'::JSONB)) a
+    WHERE a->>'key' = _key
+    LIMIT 1;
+
+    IF left(_value, 1) = '"' THEN
+        RETURN _value::JSONB #>> '{}';
+    END IF;
+    RETURN _value;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Returns the event of the given type emitted by a message, by msg_index attribute or by order of occurrence
+CREATE OR REPLACE FUNCTION api.message_event(_data JSONB, _type TEXT, _msg_index INTEGER, _occurrence INTEGER) RETURNS JSONB AS $$
+DECLARE
+    _event JSONB;
+BEGIN
+    SELECT e INTO _event
+    FROM jsonb_array_elements(COALESCE(_data->'txResponse'->'events', '[]'::JSONB)) e
+    WHERE e->>'type' = _type
+      AND e->'attributes' @> jsonb_build_array(jsonb_build_object('key', 'msg_index', 'value', _msg_index::TEXT))
+    LIMIT 1;
+
+    IF _event IS NULL THEN
+        SELECT e.value INTO _event
+        FROM jsonb_array_elements(COALESCE(_data->'txResponse'->'events', '[]'::JSONB)) WITH ORDINALITY e
+        WHERE e.value->>'type' = _type
+        ORDER BY e.ordinality
+        OFFSET _occurrence - 1
+        LIMIT 1;
+    END IF;
+
+    RETURN _event;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Replaces the groups, policies, proposals, votes and execution results of a transaction
+CREATE OR REPLACE FUNCTION api.extract_group(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+DECLARE
+    _height BIGINT;
+    _msg RECORD;
+    _type TEXT;
+    _groups INTEGER := 0;
+    _policies INTEGER := 0;
+    _proposals INTEGER := 0;
+    _group_id BIGINT;
+    _policy_address TEXT;
+BEGIN
+    _height := (_data->'txResponse'->>'height')::BIGINT;
+
+    DELETE FROM api.groups WHERE tx_hash = _tx_hash;
+    DELETE FROM api.group_policies WHERE tx_hash = _tx_hash;
+    DELETE FROM api.group_updates WHERE tx_hash = _tx_hash;
+    DELETE FROM api.group_proposals WHERE tx_hash = _tx_hash;
+    DELETE FROM api.group_votes WHERE tx_hash = _tx_hash;
+    DELETE FROM api.group_exec_results WHERE tx_hash = _tx_hash;
+    UPDATE api.group_proposals SET withdrawn_tx_hash = NULL, withdrawn_height = NULL WHERE withdrawn_tx_hash = _tx_hash;
+
+    IF COALESCE((_data->'txResponse'->>'code')::INTEGER, 0) <> 0 THEN
+        RETURN;
+    END IF;
+
+    FOR _msg IN
+        SELECT (m.ordinality - 1)::INTEGER AS msg_index, m.value
+        FROM jsonb_array_elements(COALESCE(_data->'tx'->'body'->'messages', '[]'::JSONB)) WITH ORDINALITY m
+        WHERE m.value->>'@type' LIKE '/cosmos.group.v1.%'
+    LOOP
+        _type := _msg.value->>'@type';
+
+        IF _type IN ('/cosmos.group.v1.MsgCreateGroup', '/cosmos.group.v1.MsgCreateGroupWithPolicy') THEN
+            _groups := _groups + 1;
+            _group_id := api.typed_event_attribute(api.message_event(_data, 'cosmos.group.v1.EventCreateGroup', _msg.msg_index, _groups), 'group_id')::BIGINT;
+            IF _group_id IS NOT NULL THEN
+                INSERT INTO api.groups (group_id, tx_hash, height, admin, metadata, members)
+                VALUES (_group_id, _tx_hash, _height, _msg.value->>'admin',
+                        COALESCE(_msg.value->>'metadata', _msg.value->>'groupMetadata'), _msg.value->'members')
+                ON CONFLICT (group_id) DO UPDATE SET
+                    tx_hash = EXCLUDED.tx_hash,
+                    height = EXCLUDED.height,
+                    admin = EXCLUDED.admin,
+                    metadata = EXCLUDED.metadata,
+                    members = EXCLUDED.members;
+            END IF;
+        END IF;
+
+        IF _type IN ('/cosmos.group.v1.MsgCreateGroupPolicy', '/cosmos.group.v1.MsgCreateGroupWithPolicy') THEN
+            _policies := _policies + 1;
+            _policy_address := api.typed_event_attribute(api.message_event(_data, 'cosmos.group.v1.EventCreateGroupPolicy', _msg.msg_index, _policies), 'address');
+            IF _policy_address IS NOT NULL THEN
+                INSERT INTO api.group_policies (address, tx_hash, height, group_id, admin, metadata, decision_policy)
+                VALUES (_policy_address, _tx_hash, _height,
+                        CASE WHEN _type = '/cosmos.group.v1.MsgCreateGroupPolicy' THEN (_msg.value->>'groupId')::BIGINT ELSE _group_id END,
+                        CASE WHEN (_msg.value->>'groupPolicyAsAdmin')::BOOLEAN THEN _policy_address ELSE _msg.value->>'admin' END,
+                        COALESCE(_msg.value->>'groupPolicyMetadata', _msg.value->>'metadata'), _msg.value->'decisionPolicy')
+                ON CONFLICT (address) DO UPDATE SET
+                    tx_hash = EXCLUDED.tx_hash,
+                    height = EXCLUDED.height,
+                    group_id = EXCLUDED.group_id,
+                    admin = EXCLUDED.admin,
+                    metadata = EXCLUDED.metadata,
+                    decision_policy = EXCLUDED.decision_policy;
+            END IF;
+        ELSIF _type IN ('/cosmos.group.v1.MsgUpdateGroupMembers', '/cosmos.group.v1.MsgUpdateGroupAdmin',
+                        '/cosmos.group.v1.MsgUpdateGroupMetadata', '/cosmos.group.v1.MsgLeaveGroup') THEN
+            INSERT INTO api.group_updates (tx_hash, msg_index, height, type_url, group_id, data)
+            VALUES (_tx_hash, _msg.msg_index, _height, _type, (_msg.value->>'groupId')::BIGINT, _msg.value);
+        ELSIF _type IN ('/cosmos.group.v1.MsgUpdateGroupPolicyAdmin', '/cosmos.group.v1.MsgUpdateGroupPolicyDecisionPolicy',
+                        '/cosmos.group.v1.MsgUpdateGroupPolicyMetadata') THEN
+            INSERT INTO api.group_updates (tx_hash, msg_index, height, type_url, group_id, group_policy_address, data)
+            VALUES (_tx_hash, _msg.msg_index, _height, _type,
+                    (SELECT p.group_id FROM api.group_policies p WHERE p.address = _msg.value->>'groupPolicyAddress'),
+                    _msg.value->>'groupPolicyAddress', _msg.value);
+        ELSIF _type = '/cosmos.group.v1.MsgSubmitProposal' THEN
+            _proposals := _proposals + 1;
+            INSERT INTO api.group_proposals (proposal_id, tx_hash, height, group_policy_address, proposers, title, summary, metadata, messages)
+            SELECT
+                api.typed_event_attribute(api.message_event(_data, 'cosmos.group.v1.EventSubmitProposal', _msg.msg_index, _proposals), 'proposal_id')::BIGINT,
+                _tx_hash, _height, _msg.value->>'groupPolicyAddress',
+                ARRAY(SELECT jsonb_array_elements_text(COALESCE(_msg.value->'proposers', '[]'::JSONB))),
+                _msg.value->>'title', _msg.value->>'summary', _msg.value->>'metadata', _msg.value->'messages'
+            WHERE api.typed_event_attribute(api.message_event(_data, 'cosmos.group.v1.EventSubmitProposal', _msg.msg_index, _proposals), 'proposal_id') IS NOT NULL
+            ON CONFLICT (proposal_id) DO UPDATE SET
+                tx_hash = EXCLUDED.tx_hash,
+                height = EXCLUDED.height,
+                group_policy_address = EXCLUDED.group_policy_address,
+                proposers = EXCLUDED.proposers,
+                title = EXCLUDED.title,
+                summary = EXCLUDED.summary,
+                metadata = EXCLUDED.metadata,
+                messages = EXCLUDED.messages;
+        ELSIF _type = '/cosmos.group.v1.MsgWithdrawProposal' THEN
+            UPDATE api.group_proposals
+            SET withdrawn_tx_hash = _tx_hash, withdrawn_height = _height
+            WHERE proposal_id = (_msg.value->>'proposalId')::BIGINT;
+        ELSIF _type = '/cosmos.group.v1.MsgVote' THEN
+            INSERT INTO api.group_votes (tx_hash, msg_index, height, proposal_id, voter, option, metadata)
+            VALUES (_tx_hash, _msg.msg_index, _height, (_msg.value->>'proposalId')::BIGINT, _msg.value->>'voter',
+                    _msg.value->>'option', _msg.value->>'metadata');
+        END IF;
+    END LOOP;
+
+    -- Proposals are executed by MsgExec, or by MsgSubmitProposal and MsgVote with the TRY_EXEC mode
+    INSERT INTO api.group_exec_results (tx_hash, event_index, height, proposal_id, result, logs)
+    SELECT _tx_hash, (e.ordinality - 1)::INTEGER, _height,
+           api.typed_event_attribute(e.value, 'proposal_id')::BIGINT,
+           api.typed_event_attribute(e.value, 'result'),
+           NULLIF(api.typed_event_attribute(e.value, 'logs'), '')
+    FROM jsonb_array_elements(COALESCE(_data->'txResponse'->'events', '[]'::JSONB)) WITH ORDINALITY e
+    WHERE e.value->>'type' = 'cosmos.group.v1.EventExec'
+      AND api.typed_event_attribute(e.value, 'proposal_id') IS NOT NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_group() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_group(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_group ON api.transactions_raw;
+CREATE TRIGGER trg_update_group
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_group();
+
+-- Read access for PostgREST
+GRANT SELECT ON api.groups TO web_anon;
+GRANT SELECT ON api.group_policies TO web_anon;
+GRANT SELECT ON api.group_updates TO web_anon;
+GRANT SELECT ON api.group_proposals TO web_anon;
+GRANT SELECT ON api.group_votes TO web_anon;
+GRANT SELECT ON api.group_exec_results TO web_anon;
+
+COMMIT;
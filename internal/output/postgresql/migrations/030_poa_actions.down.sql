@@ -0,0 +1,15 @@
+-- Migration 030 down: Remove poa_actions table
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_update_poa_block_actions ON api.block_results_raw;
+DROP TRIGGER IF EXISTS trg_update_poa_tx_actions ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_poa_block_actions();
+DROP FUNCTION IF EXISTS api.update_poa_tx_actions();
+DROP FUNCTION IF EXISTS api.extract_poa_block_actions(BIGINT, JSONB);
+DROP FUNCTION IF EXISTS api.extract_poa_tx_actions(TEXT, JSONB);
+DROP FUNCTION IF EXISTS api.insert_poa_messages(BIGINT, TEXT, TEXT, JSONB, TEXT);
+DROP FUNCTION IF EXISTS api.poa_message_action(TEXT);
+DROP TABLE IF EXISTS api.poa_actions;
+
+COMMIT;
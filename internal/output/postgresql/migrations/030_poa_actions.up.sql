@@ -0,0 +1,144 @@
+-- Migration 030: Add poa_actions table
+--
+-- Audit trail of the Proof-of-Authority module actions that change the
+-- consensus membership and its administration:
+--   - message: the POA messages of the successful transactions
+--   - group_proposal: the POA messages of the group proposals executed by the
+--     transactions, from api.group_proposals, since the POA admin of a chain is
+--     usually a group policy
+--   - finalize_block: the finalize block events of the POA module, whose type
+--     starts with poa or whose module attribute is poa
+--   - validator_update: the validator set updates applied by the block, with
+--     the public key of the validator; a power of 0 removes the validator
+--
+-- The rows of the transactions are replaced with them; the rows of the block
+-- results are replaced with their height.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.poa_actions (
+    id BIGSERIAL PRIMARY KEY,
+    height BIGINT NOT NULL,
+    tx_hash TEXT REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    source TEXT NOT NULL,
+    msg_index INTEGER,
+    event_index INTEGER,
+    action TEXT NOT NULL,
+    sender TEXT,
+    validator_address TEXT,
+    power BIGINT,
+    data JSONB
+);
+
+CREATE INDEX IF NOT EXISTS idx_poa_actions_height ON api.poa_actions(height);
+CREATE INDEX IF NOT EXISTS idx_poa_actions_tx_hash ON api.poa_actions(tx_hash) WHERE tx_hash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_poa_actions_validator ON api.poa_actions(validator_address, height) WHERE validator_address IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_poa_actions_action ON api.poa_actions(action, height);
+
+-- Returns the action of a POA message, e.g., set_power for MsgSetPower
+CREATE OR REPLACE FUNCTION api.poa_message_action(_type_url TEXT) RETURNS TEXT AS $$
+BEGIN
+    IF _type_url NOT LIKE '/strangelove_ventures.poa.v1.Msg%' THEN
+        RETURN NULL;
+    END IF;
+    RETURN lower(regexp_replace(substring(_type_url FROM '\.Msg([A-Za-z]+)$'), '([a-z])([A-Z])', '\1_\2', 'g'));
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Inserts the POA actions of messages
+CREATE OR REPLACE FUNCTION api.insert_poa_messages(_height BIGINT, _tx_hash TEXT, _source TEXT, _messages JSONB, _sender TEXT) RETURNS VOID AS $$
+BEGIN
+    INSERT INTO api.poa_actions (height, tx_hash, source, msg_index, action, sender, validator_address, power, data)
+    SELECT _height, _tx_hash, _source, (m.ordinality - 1)::INTEGER, api.poa_message_action(m.value->>'@type'),
+           COALESCE(_sender, m.value->>'sender', m.value->>'delegatorAddress'),
+           m.value->>'validatorAddress', (m.value->>'power')::BIGINT, m.value
+    FROM jsonb_array_elements(COALESCE(_messages, '[]'::JSONB)) WITH ORDINALITY m
+    WHERE api.poa_message_action(m.value->>'@type') IS NOT NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Replaces the POA actions of a transaction
+CREATE OR REPLACE FUNCTION api.extract_poa_tx_actions(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+DECLARE
+    _height BIGINT;
+    _proposal RECORD;
+BEGIN
+    _height := (_data->'txResponse'->>'height')::BIGINT;
+
+    DELETE FROM api.poa_actions WHERE tx_hash = _tx_hash;
+
+    IF COALESCE((_data->'txResponse'->>'code')::INTEGER, 0) <> 0 THEN
+        RETURN;
+    END IF;
+
+    PERFORM api.insert_poa_messages(_height, _tx_hash, 'message', _data->'tx'->'body'->'messages', NULL);
+
+    FOR _proposal IN
+        SELECT p.group_policy_address, p.messages
+        FROM jsonb_array_elements(COALESCE(_data->'txResponse'->'events', '[]'::JSONB)) e
+        JOIN api.group_proposals p ON p.proposal_id = api.typed_event_attribute(e, 'proposal_id')::BIGINT
+        WHERE e->>'type' = 'cosmos.group.v1.EventExec'
+          AND api.typed_event_attribute(e, 'result') = 'PROPOSAL_EXECUTOR_RESULT_SUCCESS'
+    LOOP
+        PERFORM api.insert_poa_messages(_height, _tx_hash, 'group_proposal', _proposal.messages, _proposal.group_policy_address);
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Replaces the POA actions of the block results of a height
+CREATE OR REPLACE FUNCTION api.extract_poa_block_actions(_height BIGINT, _data JSONB) RETURNS VOID AS $$
+BEGIN
+    DELETE FROM api.poa_actions WHERE height = _height AND tx_hash IS NULL;
+
+    INSERT INTO api.poa_actions (height, source, event_index, action, validator_address, power, data)
+    SELECT _height, 'finalize_block', (e.ordinality - 1)::INTEGER, e.value->>'type', attr.attrs->>'validator',
+           CASE WHEN attr.attrs->>'power' ~ '^[0-9]+$' THEN (attr.attrs->>'power')::BIGINT END, attr.attrs
+    FROM jsonb_array_elements(COALESCE(_data->'finalizeBlockEvents', '[]'::JSONB)) WITH ORDINALITY e
+    CROSS JOIN LATERAL (
+        SELECT COALESCE(jsonb_object_agg(a->>'key', a->>'value'), '{}'::JSONB) AS attrs
+        FROM jsonb_array_elements(COALESCE(e.value->'attributes', '[]'::JSONB)) a
+        WHERE a->>'key' IS NOT NULL
+    ) attr
+    WHERE e.value->>'type' LIKE 'poa%' OR attr.attrs->>'module' = 'poa';
+
+    INSERT INTO api.poa_actions (height, source, event_index, action, power, data)
+    SELECT _height, 'validator_update', (u.ordinality - 1)::INTEGER,
+           CASE WHEN COALESCE((u.value->>'power')::BIGINT, 0) = 0 THEN 'remove_validator' ELSE 'set_power' END,
+           COALESCE((u.value->>'power')::BIGINT, 0), u.value->'pubKey'
+    FROM jsonb_array_elements(COALESCE(_data->'validatorUpdates', '[]'::JSONB)) WITH ORDINALITY u;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_poa_tx_actions() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_poa_tx_actions(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_poa_block_actions() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        DELETE FROM api.poa_actions WHERE height = OLD.height AND tx_hash IS NULL;
+        RETURN OLD;
+    END IF;
+    PERFORM api.extract_poa_block_actions(NEW.height, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Fires after trg_update_group, since the triggers fire in name order, so that the group proposals are extracted first
+DROP TRIGGER IF EXISTS trg_update_poa_tx_actions ON api.transactions_raw;
+CREATE TRIGGER trg_update_poa_tx_actions
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_poa_tx_actions();
+
+DROP TRIGGER IF EXISTS trg_update_poa_block_actions ON api.block_results_raw;
+CREATE TRIGGER trg_update_poa_block_actions
+AFTER INSERT OR UPDATE OR DELETE ON api.block_results_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_poa_block_actions();
+
+-- Read access for PostgREST
+GRANT SELECT ON api.poa_actions TO web_anon;
+
+COMMIT;
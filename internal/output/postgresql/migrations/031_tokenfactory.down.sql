@@ -0,0 +1,13 @@
+-- Migration 031 down: Remove tokenfactory tables
+
+BEGIN;
+
+DROP VIEW IF EXISTS api.tokenfactory_tokens;
+DROP TRIGGER IF EXISTS trg_update_tokenfactory ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_tokenfactory();
+DROP FUNCTION IF EXISTS api.extract_tokenfactory(TEXT, JSONB);
+DROP FUNCTION IF EXISTS api.insert_tokenfactory_messages(TEXT, BIGINT, JSONB, BIGINT);
+DROP TABLE IF EXISTS api.tokenfactory_actions;
+DROP TABLE IF EXISTS api.tokenfactory_denoms;
+
+COMMIT;
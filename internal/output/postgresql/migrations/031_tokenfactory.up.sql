@@ -0,0 +1,152 @@
+-- Migration 031: Add tokenfactory tables
+--
+-- Decodes the tokenfactory messages of the successful transactions, and those
+-- of the group proposals they execute, keyed by denom:
+--   - api.tokenfactory_denoms: the denoms created with MsgCreateDenom
+--   - api.tokenfactory_actions: the mints, burns, admin changes, metadata
+--     changes and force transfers
+-- api.tokenfactory_tokens is the current state of each denom: its admin and
+-- the supply minted and burned through the module.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.tokenfactory_denoms (
+    denom TEXT PRIMARY KEY,
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    height BIGINT,
+    creator TEXT NOT NULL,
+    subdenom TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_tokenfactory_denoms_tx_hash ON api.tokenfactory_denoms(tx_hash);
+CREATE INDEX IF NOT EXISTS idx_tokenfactory_denoms_creator ON api.tokenfactory_denoms(creator);
+
+CREATE TABLE IF NOT EXISTS api.tokenfactory_actions (
+    id BIGSERIAL PRIMARY KEY,
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    height BIGINT,
+    msg_index INTEGER NOT NULL,
+    proposal_id BIGINT,
+    denom TEXT NOT NULL,
+    action TEXT NOT NULL,
+    sender TEXT,
+    address TEXT,
+    amount NUMERIC,
+    data JSONB
+);
+
+CREATE INDEX IF NOT EXISTS idx_tokenfactory_actions_tx_hash ON api.tokenfactory_actions(tx_hash);
+CREATE INDEX IF NOT EXISTS idx_tokenfactory_actions_denom ON api.tokenfactory_actions(denom, height);
+CREATE INDEX IF NOT EXISTS idx_tokenfactory_actions_address ON api.tokenfactory_actions(address, height) WHERE address IS NOT NULL;
+
+-- Inserts the tokenfactory denoms and actions of messages
+CREATE OR REPLACE FUNCTION api.insert_tokenfactory_messages(_tx_hash TEXT, _height BIGINT, _messages JSONB, _proposal_id BIGINT) RETURNS VOID AS $$
+BEGIN
+    INSERT INTO api.tokenfactory_denoms (denom, tx_hash, height, creator, subdenom)
+    SELECT 'factory/' || (m->>'sender') || '/' || (m->>'subdenom'), _tx_hash, _height, m->>'sender', m->>'subdenom'
+    FROM jsonb_array_elements(COALESCE(_messages, '[]'::JSONB)) m
+    WHERE m->>'@type' = '/osmosis.tokenfactory.v1beta1.MsgCreateDenom'
+    ON CONFLICT (denom) DO UPDATE SET
+        tx_hash = EXCLUDED.tx_hash,
+        height = EXCLUDED.height;
+
+    INSERT INTO api.tokenfactory_actions (tx_hash, height, msg_index, proposal_id, denom, action, sender, address, amount, data)
+    SELECT _tx_hash, _height, (m.ordinality - 1)::INTEGER, _proposal_id, a.denom, a.action, m.value->>'sender', a.address, a.amount, m.value
+    FROM jsonb_array_elements(COALESCE(_messages, '[]'::JSONB)) WITH ORDINALITY m
+    CROSS JOIN LATERAL (
+        SELECT
+            CASE m.value->>'@type'
+                WHEN '/osmosis.tokenfactory.v1beta1.MsgMint' THEN 'mint'
+                WHEN '/osmosis.tokenfactory.v1beta1.MsgBurn' THEN 'burn'
+                WHEN '/osmosis.tokenfactory.v1beta1.MsgChangeAdmin' THEN 'change_admin'
+                WHEN '/osmosis.tokenfactory.v1beta1.MsgSetDenomMetadata' THEN 'set_denom_metadata'
+                WHEN '/osmosis.tokenfactory.v1beta1.MsgForceTransfer' THEN 'force_transfer'
+            END AS action,
+            COALESCE(m.value->'amount'->>'denom', m.value->>'denom', m.value->'metadata'->>'base') AS denom,
+            CASE m.value->>'@type'
+                WHEN '/osmosis.tokenfactory.v1beta1.MsgMint' THEN NULLIF(COALESCE(m.value->>'mintToAddress', ''), '')
+                WHEN '/osmosis.tokenfactory.v1beta1.MsgBurn' THEN NULLIF(COALESCE(m.value->>'burnFromAddress', ''), '')
+                WHEN '/osmosis.tokenfactory.v1beta1.MsgChangeAdmin' THEN m.value->>'newAdmin'
+                WHEN '/osmosis.tokenfactory.v1beta1.MsgForceTransfer' THEN m.value->>'transferToAddress'
+            END AS address,
+            (m.value->'amount'->>'amount')::NUMERIC AS amount
+    ) a
+    WHERE a.action IS NOT NULL AND a.denom IS NOT NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Replaces the tokenfactory denoms and actions of a transaction
+CREATE OR REPLACE FUNCTION api.extract_tokenfactory(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+DECLARE
+    _height BIGINT;
+    _proposal RECORD;
+BEGIN
+    _height := (_data->'txResponse'->>'height')::BIGINT;
+
+    DELETE FROM api.tokenfactory_denoms WHERE tx_hash = _tx_hash;
+    DELETE FROM api.tokenfactory_actions WHERE tx_hash = _tx_hash;
+
+    IF COALESCE((_data->'txResponse'->>'code')::INTEGER, 0) <> 0 THEN
+        RETURN;
+    END IF;
+
+    PERFORM api.insert_tokenfactory_messages(_tx_hash, _height, _data->'tx'->'body'->'messages', NULL);
+
+    FOR _proposal IN
+        SELECT p.proposal_id, p.messages
+        FROM jsonb_array_elements(COALESCE(_data->'txResponse'->'events', '[]'::JSONB)) e
+        JOIN api.group_proposals p ON p.proposal_id = api.typed_event_attribute(e, 'proposal_id')::BIGINT
+        WHERE e->>'type' = 'cosmos.group.v1.EventExec'
+          AND api.typed_event_attribute(e, 'result') = 'PROPOSAL_EXECUTOR_RESULT_SUCCESS'
+    LOOP
+        PERFORM api.insert_tokenfactory_messages(_tx_hash, _height, _proposal.messages, _proposal.proposal_id);
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_tokenfactory() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_tokenfactory(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Fires after trg_update_group, so that the group proposals are extracted first
+DROP TRIGGER IF EXISTS trg_update_tokenfactory ON api.transactions_raw;
+CREATE TRIGGER trg_update_tokenfactory
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_tokenfactory();
+
+-- Current state of the denoms: their latest admin and the supply minted and burned through the module
+CREATE OR REPLACE VIEW api.tokenfactory_tokens AS
+SELECT
+    d.denom,
+    d.creator,
+    d.subdenom,
+    d.height AS created_height,
+    COALESCE(admin.address, d.creator) AS admin,
+    COALESCE(supply.minted, 0) AS minted,
+    COALESCE(supply.burned, 0) AS burned,
+    COALESCE(supply.minted, 0) - COALESCE(supply.burned, 0) AS supply
+FROM api.tokenfactory_denoms d
+LEFT JOIN LATERAL (
+    SELECT a.address
+    FROM api.tokenfactory_actions a
+    WHERE a.denom = d.denom AND a.action = 'change_admin'
+    ORDER BY a.height DESC, a.id DESC
+    LIMIT 1
+) admin ON TRUE
+LEFT JOIN LATERAL (
+    SELECT
+        SUM(a.amount) FILTER (WHERE a.action = 'mint') AS minted,
+        SUM(a.amount) FILTER (WHERE a.action = 'burn') AS burned
+    FROM api.tokenfactory_actions a
+    WHERE a.denom = d.denom
+) supply ON TRUE;
+
+-- Read access for PostgREST
+GRANT SELECT ON api.tokenfactory_denoms TO web_anon;
+GRANT SELECT ON api.tokenfactory_actions TO web_anon;
+GRANT SELECT ON api.tokenfactory_tokens TO web_anon;
+
+COMMIT;
@@ -0,0 +1,20 @@
+-- Migration 032 down: Remove display amounts
+
+BEGIN;
+
+DROP FUNCTION IF EXISTS api.display_denom(api.tokenfactory_tokens);
+DROP FUNCTION IF EXISTS api.display_supply(api.tokenfactory_tokens);
+DROP FUNCTION IF EXISTS api.display_denom(api.tokenfactory_actions);
+DROP FUNCTION IF EXISTS api.display_amount(api.tokenfactory_actions);
+DROP FUNCTION IF EXISTS api.display_denom(api.delegation_snapshots);
+DROP FUNCTION IF EXISTS api.display_amount(api.delegation_snapshots);
+DROP FUNCTION IF EXISTS api.display_denom(api.balance_snapshots);
+DROP FUNCTION IF EXISTS api.display_amount(api.balance_snapshots);
+DROP FUNCTION IF EXISTS api.display_denom(api.supply_history);
+DROP FUNCTION IF EXISTS api.display_amount(api.supply_history);
+DROP FUNCTION IF EXISTS api.fee_display_denom(api.transactions_raw);
+DROP FUNCTION IF EXISTS api.fee_display_amount(api.transactions_raw);
+DROP FUNCTION IF EXISTS api.to_display_denom(TEXT);
+DROP FUNCTION IF EXISTS api.to_display_amount(NUMERIC, TEXT);
+
+COMMIT;
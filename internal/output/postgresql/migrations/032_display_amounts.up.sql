@@ -0,0 +1,78 @@
+-- Migration 032: Add display amounts
+--
+-- Converts the raw amounts of the base denoms into decimal amounts of their
+-- display denom, e.g., 1500000 umfx into 1.5 MFX, with the exponent of the
+-- bank denom metadata in api.denom_metadata, recorded by the supply snapshots.
+-- The amounts of the denoms without metadata are NULL, rather than silently
+-- assumed to have no exponent.
+--
+-- The display columns of the derived tables are PostgREST computed fields:
+-- functions taking a row of the table, selected like columns with
+-- ?select=*,display_amount, or with t.display_amount in SQL.
+
+BEGIN;
+
+-- Returns the amount of a base denom in its display denom
+CREATE OR REPLACE FUNCTION api.to_display_amount(_amount NUMERIC, _denom TEXT) RETURNS NUMERIC AS $$
+    SELECT trim_scale(_amount * power(10::NUMERIC, -m.exponent))
+    FROM api.denom_metadata m
+    WHERE m.denom = _denom;
+$$ LANGUAGE sql STABLE;
+
+-- Returns the display denom of a base denom: its symbol, its display name or the denom itself
+CREATE OR REPLACE FUNCTION api.to_display_denom(_denom TEXT) RETURNS TEXT AS $$
+    SELECT COALESCE(
+        (SELECT COALESCE(NULLIF(m.symbol, ''), NULLIF(m.display, '')) FROM api.denom_metadata m WHERE m.denom = _denom),
+        _denom
+    );
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION api.fee_display_amount(api.transactions_raw) RETURNS NUMERIC AS $$
+    SELECT api.to_display_amount($1.fee_amount, $1.fee_denom);
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION api.fee_display_denom(api.transactions_raw) RETURNS TEXT AS $$
+    SELECT api.to_display_denom($1.fee_denom);
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION api.display_amount(api.supply_history) RETURNS NUMERIC AS $$
+    SELECT api.to_display_amount($1.amount, $1.denom);
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION api.display_denom(api.supply_history) RETURNS TEXT AS $$
+    SELECT api.to_display_denom($1.denom);
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION api.display_amount(api.balance_snapshots) RETURNS NUMERIC AS $$
+    SELECT api.to_display_amount($1.amount, $1.denom);
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION api.display_denom(api.balance_snapshots) RETURNS TEXT AS $$
+    SELECT api.to_display_denom($1.denom);
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION api.display_amount(api.delegation_snapshots) RETURNS NUMERIC AS $$
+    SELECT api.to_display_amount($1.amount, $1.denom);
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION api.display_denom(api.delegation_snapshots) RETURNS TEXT AS $$
+    SELECT api.to_display_denom($1.denom);
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION api.display_amount(api.tokenfactory_actions) RETURNS NUMERIC AS $$
+    SELECT api.to_display_amount($1.amount, $1.denom);
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION api.display_denom(api.tokenfactory_actions) RETURNS TEXT AS $$
+    SELECT api.to_display_denom($1.denom);
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION api.display_supply(api.tokenfactory_tokens) RETURNS NUMERIC AS $$
+    SELECT api.to_display_amount($1.supply, $1.denom);
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION api.display_denom(api.tokenfactory_tokens) RETURNS TEXT AS $$
+    SELECT api.to_display_denom($1.denom);
+$$ LANGUAGE sql STABLE;
+
+COMMIT;
@@ -0,0 +1,10 @@
+-- Migration 033 down: Remove tx_signers table
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_update_tx_signers ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_tx_signers();
+DROP FUNCTION IF EXISTS api.extract_tx_signers(TEXT, JSONB);
+DROP TABLE IF EXISTS api.tx_signers;
+
+COMMIT;
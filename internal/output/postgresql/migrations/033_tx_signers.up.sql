@@ -0,0 +1,69 @@
+-- Migration 033: Add tx_signers table
+--
+-- Extracts the signers of the transactions from their auth_info, one row per
+-- signer info: the public key and its type, the sign mode and the sequence.
+-- The addresses are read from the acc_seq attributes of the tx events emitted
+-- by the ante handler, in the order of the signer infos, since deriving them
+-- from the public keys requires hashes and encodings PostgreSQL lacks.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.tx_signers (
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    signer_index INTEGER NOT NULL,
+    height BIGINT,
+    address TEXT,
+    pubkey_type TEXT,
+    pubkey TEXT,
+    public_key JSONB,
+    sign_mode TEXT,
+    sequence BIGINT,
+    PRIMARY KEY (tx_hash, signer_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tx_signers_address ON api.tx_signers(address, height) WHERE address IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_tx_signers_pubkey ON api.tx_signers(pubkey) WHERE pubkey IS NOT NULL;
+
+-- Replaces the signers of a transaction
+CREATE OR REPLACE FUNCTION api.extract_tx_signers(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+BEGIN
+    DELETE FROM api.tx_signers WHERE tx_hash = _tx_hash;
+
+    INSERT INTO api.tx_signers (tx_hash, signer_index, height, address, pubkey_type, pubkey, public_key, sign_mode, sequence)
+    SELECT
+        _tx_hash,
+        (s.ordinality - 1)::INTEGER,
+        (_data->'txResponse'->>'height')::BIGINT,
+        acc.address,
+        s.value->'publicKey'->>'@type',
+        s.value->'publicKey'->>'key',
+        s.value->'publicKey',
+        COALESCE(s.value->'modeInfo'->'single'->>'mode', CASE WHEN s.value->'modeInfo' ? 'multi' THEN 'MULTI' END),
+        COALESCE((s.value->>'sequence')::BIGINT, 0)
+    FROM jsonb_array_elements(COALESCE(_data->'tx'->'authInfo'->'signerInfos', '[]'::JSONB)) WITH ORDINALITY s
+    LEFT JOIN (
+        SELECT ROW_NUMBER() OVER (ORDER BY e.ordinality, a.ordinality) AS ordinality,
+               regexp_replace(a.value->>'value', '/[0-9]+$', '') AS address
+        FROM jsonb_array_elements(COALESCE(_data->'txResponse'->'events', '[]'::JSONB)) WITH ORDINALITY e
+        CROSS JOIN LATERAL jsonb_array_elements(COALESCE(e.value->'attributes', '[]'::JSONB)) WITH ORDINALITY a
+        WHERE e.value->>'type' = 'tx' AND a.value->>'key' = 'acc_seq'
+    ) acc ON acc.ordinality = s.ordinality;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_tx_signers() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_tx_signers(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_tx_signers ON api.transactions_raw;
+CREATE TRIGGER trg_update_tx_signers
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_tx_signers();
+
+-- Read access for PostgREST
+GRANT SELECT ON api.tx_signers TO web_anon;
+
+COMMIT;
@@ -0,0 +1,12 @@
+-- Migration 034 down: Remove multisig_signers table
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_update_multisig_signers ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_multisig_signers();
+DROP FUNCTION IF EXISTS api.extract_multisig_signers(TEXT, JSONB);
+DROP FUNCTION IF EXISTS api.insert_multisig_members(TEXT, INTEGER, BIGINT, INTEGER[], JSONB, JSONB, BYTEA);
+DROP FUNCTION IF EXISTS api.decode_multisignature(BYTEA);
+DROP TABLE IF EXISTS api.multisig_signers;
+
+COMMIT;
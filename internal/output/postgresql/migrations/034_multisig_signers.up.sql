@@ -0,0 +1,150 @@
+-- Migration 034: Add multisig_signers table
+--
+-- Breaks down the signatures of the multisig signers of the transactions into
+-- one row per member key of the multisig: whether the member signed, from the
+-- bit array of the mode info, and its sign mode and signature, from the
+-- MultiSignature of the transaction, which holds the signatures of the
+-- members that signed in order. Nested multisig members are broken down with
+-- the path of their parent in member_path.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.multisig_signers (
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    signer_index INTEGER NOT NULL,
+    member_path INTEGER[] NOT NULL,
+    height BIGINT,
+    threshold INTEGER,
+    pubkey_type TEXT,
+    pubkey TEXT,
+    signed BOOLEAN NOT NULL,
+    sign_mode TEXT,
+    signature TEXT,
+    PRIMARY KEY (tx_hash, signer_index, member_path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_multisig_signers_pubkey ON api.multisig_signers(pubkey, height) WHERE pubkey IS NOT NULL;
+
+-- Returns the signatures of a protobuf MultiSignature, a repeated bytes field
+CREATE OR REPLACE FUNCTION api.decode_multisignature(_data BYTEA) RETURNS BYTEA[] AS $$
+DECLARE
+    _signatures BYTEA[] := ARRAY[]::BYTEA[];
+    _pos INTEGER := 0;
+    _length BIGINT;
+    _shift INTEGER;
+    _byte INTEGER;
+BEGIN
+    WHILE _pos < length(_data) LOOP
+        -- Field 1 with the length-delimited wire type
+        IF get_byte(_data, _pos) <> 10 THEN
+            RETURN NULL;
+        END IF;
+        _pos := _pos + 1;
+
+        _length := 0;
+        _shift := 0;
+        LOOP
+            IF _pos >= length(_data) THEN
+                RETURN NULL;
+            END IF;
+            _byte := get_byte(_data, _pos);
+            _pos := _pos + 1;
+            _length := _length | ((_byte & 127)::BIGINT << _shift);
+            EXIT WHEN _byte < 128;
+            _shift := _shift + 7;
+        END LOOP;
+
+        IF _pos + _length > length(_data) THEN
+            RETURN NULL;
+        END IF;
+        _signatures := _signatures || substring(_data FROM _pos + 1 FOR _length::INTEGER);
+        _pos := _pos + _length::INTEGER;
+    END LOOP;
+
+    RETURN _signatures;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Inserts the members of a multisig signer, and recursively those of its nested multisig members
+CREATE OR REPLACE FUNCTION api.insert_multisig_members(
+    _tx_hash TEXT, _signer_index INTEGER, _height BIGINT, _path INTEGER[],
+    _public_key JSONB, _mode_info JSONB, _signature BYTEA
+) RETURNS VOID AS $$
+DECLARE
+    _bits BYTEA;
+    _signatures BYTEA[];
+    _member RECORD;
+    _signed BOOLEAN;
+    _signed_count INTEGER := 0;
+    _member_mode JSONB;
+    _member_signature BYTEA;
+BEGIN
+    _bits := COALESCE(api.decode_base64(_mode_info->'multi'->'bitarray'->>'elems'), ''::BYTEA);
+    _signatures := api.decode_multisignature(COALESCE(_signature, ''::BYTEA));
+
+    FOR _member IN
+        SELECT (k.ordinality - 1)::INTEGER AS member_index, k.value
+        FROM jsonb_array_elements(COALESCE(_public_key->'publicKeys', '[]'::JSONB)) WITH ORDINALITY k
+    LOOP
+        _signed := CASE WHEN _member.member_index / 8 < length(_bits)
+            THEN (get_byte(_bits, _member.member_index / 8) & (128 >> (_member.member_index % 8))) <> 0
+            ELSE FALSE END;
+        _member_mode := NULL;
+        _member_signature := NULL;
+        IF _signed THEN
+            _signed_count := _signed_count + 1;
+            _member_mode := _mode_info->'multi'->'modeInfos'->(_signed_count - 1);
+            _member_signature := _signatures[_signed_count];
+        END IF;
+
+        INSERT INTO api.multisig_signers (tx_hash, signer_index, member_path, height, threshold, pubkey_type, pubkey, signed, sign_mode, signature)
+        VALUES (_tx_hash, _signer_index, _path || _member.member_index, _height, (_public_key->>'threshold')::INTEGER,
+                _member.value->>'@type', _member.value->>'key', _signed,
+                COALESCE(_member_mode->'single'->>'mode', CASE WHEN _member_mode ? 'multi' THEN 'MULTI' END),
+                encode(_member_signature, 'base64'));
+
+        IF _signed AND _member_mode ? 'multi' THEN
+            PERFORM api.insert_multisig_members(_tx_hash, _signer_index, _height, _path || _member.member_index,
+                                                _member.value, _member_mode, _member_signature);
+        END IF;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Replaces the multisig signers of a transaction
+CREATE OR REPLACE FUNCTION api.extract_multisig_signers(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+DECLARE
+    _signer RECORD;
+BEGIN
+    DELETE FROM api.multisig_signers WHERE tx_hash = _tx_hash;
+
+    FOR _signer IN
+        SELECT (s.ordinality - 1)::INTEGER AS signer_index, s.value
+        FROM jsonb_array_elements(COALESCE(_data->'tx'->'authInfo'->'signerInfos', '[]'::JSONB)) WITH ORDINALITY s
+        WHERE s.value->'modeInfo' ? 'multi'
+    LOOP
+        PERFORM api.insert_multisig_members(
+            _tx_hash, _signer.signer_index, (_data->'txResponse'->>'height')::BIGINT, ARRAY[]::INTEGER[],
+            _signer.value->'publicKey', _signer.value->'modeInfo',
+            api.decode_base64(_data->'tx'->'signatures'->>_signer.signer_index)
+        );
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_multisig_signers() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_multisig_signers(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_multisig_signers ON api.transactions_raw;
+CREATE TRIGGER trg_update_multisig_signers
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_multisig_signers();
+
+-- Read access for PostgREST
+GRANT SELECT ON api.multisig_signers TO web_anon;
+
+COMMIT;
@@ -0,0 +1,13 @@
+-- Migration 035 down: Remove execution result columns from transactions_raw
+
+BEGIN;
+
+DROP INDEX IF EXISTS api.idx_transactions_failed;
+
+ALTER TABLE api.transactions_raw
+    DROP COLUMN IF EXISTS success,
+    DROP COLUMN IF EXISTS raw_log,
+    DROP COLUMN IF EXISTS codespace,
+    DROP COLUMN IF EXISTS code;
+
+COMMIT;
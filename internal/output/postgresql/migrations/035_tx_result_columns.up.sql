@@ -0,0 +1,24 @@
+-- Migration 035: Add execution result columns to transactions_raw
+--
+-- Exposes the execution result of the transactions as columns, so that the
+-- failed transactions can be filtered without parsing the raw JSON. The code
+-- is omitted from the JSON of the successful transactions, so it defaults to
+-- 0 when the transaction has a response; the transactions stored with error
+-- metadata, whose details could not be fetched, have NULL results. The gas
+-- used is in the gas_used column of migration 021.
+
+BEGIN;
+
+ALTER TABLE api.transactions_raw
+    ADD COLUMN IF NOT EXISTS code INTEGER
+        GENERATED ALWAYS AS (CASE WHEN data ? 'txResponse' THEN COALESCE((data->'txResponse'->>'code')::INTEGER, 0) END) STORED,
+    ADD COLUMN IF NOT EXISTS codespace TEXT
+        GENERATED ALWAYS AS (NULLIF(data->'txResponse'->>'codespace', '')) STORED,
+    ADD COLUMN IF NOT EXISTS raw_log TEXT
+        GENERATED ALWAYS AS (NULLIF(data->'txResponse'->>'rawLog', '')) STORED,
+    ADD COLUMN IF NOT EXISTS success BOOLEAN
+        GENERATED ALWAYS AS (CASE WHEN data ? 'txResponse' THEN COALESCE((data->'txResponse'->>'code')::INTEGER, 0) = 0 END) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_failed ON api.transactions_raw(codespace, code) WHERE NOT success;
+
+COMMIT;
@@ -0,0 +1,10 @@
+-- Migration 036 down: Remove hash_mismatch column from transactions_raw
+
+BEGIN;
+
+DROP INDEX IF EXISTS api.idx_transactions_hash_mismatch;
+
+ALTER TABLE api.transactions_raw
+    DROP COLUMN IF EXISTS hash_mismatch;
+
+COMMIT;
@@ -0,0 +1,16 @@
+-- Migration 036: Add hash_mismatch column to transactions_raw
+--
+-- The transactions extracted from blocks are identified by the hash computed
+-- by the indexer from their bytes. hash_mismatch flags the transactions whose
+-- hash reported by the node in their response differs; it is NULL for the
+-- transactions without a response.
+
+BEGIN;
+
+ALTER TABLE api.transactions_raw
+    ADD COLUMN IF NOT EXISTS hash_mismatch BOOLEAN
+        GENERATED ALWAYS AS (lower(id) <> lower(data->'txResponse'->>'txhash')) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_hash_mismatch ON api.transactions_raw(id) WHERE hash_mismatch;
+
+COMMIT;
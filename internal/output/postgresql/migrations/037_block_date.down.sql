@@ -0,0 +1,11 @@
+-- Migration 037 down: Remove block_date column
+
+BEGIN;
+
+DROP INDEX IF EXISTS api.idx_blocks_block_date;
+DROP INDEX IF EXISTS api.idx_blocks_block_time;
+
+ALTER TABLE api.blocks_raw
+    DROP COLUMN IF EXISTS block_date;
+
+COMMIT;
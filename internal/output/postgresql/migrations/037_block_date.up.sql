@@ -0,0 +1,17 @@
+-- Migration 037: Normalize the block timestamps
+--
+-- Adds block_date, the UTC date of the block_time column of migration 011,
+-- for time-based queries, daily aggregations and partitioning without JSON
+-- extraction. The block_time of the blocks indexed before migration 011 is
+-- backfilled from the header time of their raw JSON after the migration, in
+-- batches of heights.
+
+BEGIN;
+
+ALTER TABLE api.blocks_raw
+    ADD COLUMN IF NOT EXISTS block_date DATE GENERATED ALWAYS AS ((block_time AT TIME ZONE 'UTC')::DATE) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_blocks_block_time ON api.blocks_raw(block_time);
+CREATE INDEX IF NOT EXISTS idx_blocks_block_date ON api.blocks_raw(block_date);
+
+COMMIT;
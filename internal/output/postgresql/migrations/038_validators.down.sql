@@ -0,0 +1,14 @@
+-- Migration 038 down: Remove validators tables and block proposers
+
+BEGIN;
+
+DROP VIEW IF EXISTS api.block_proposers;
+DROP INDEX IF EXISTS api.idx_blocks_proposer_address;
+
+ALTER TABLE api.blocks_raw
+    DROP COLUMN IF EXISTS proposer_address;
+
+DROP TABLE IF EXISTS api.validator_consensus_addresses;
+DROP TABLE IF EXISTS api.validators;
+
+COMMIT;
@@ -0,0 +1,55 @@
+-- Migration 038: Add validators tables and block proposers
+--
+-- The validators, queried from the staking module, and the mapping of the
+-- consensus addresses of their keys to their operator addresses, which keeps
+-- the keys validators rotated away from to resolve past proposers. The
+-- proposer_address column of api.blocks_raw is the uppercase hexadecimal
+-- consensus address of the proposer of the block, and api.block_proposers
+-- resolves it to the operator address and moniker of the validator.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.validators (
+    operator_address TEXT PRIMARY KEY,
+    consensus_address TEXT,
+    moniker TEXT,
+    status TEXT,
+    jailed BOOLEAN NOT NULL DEFAULT FALSE,
+    data JSONB NOT NULL,
+    updated_height BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_validators_consensus_address ON api.validators(consensus_address);
+
+CREATE TABLE IF NOT EXISTS api.validator_consensus_addresses (
+    consensus_address TEXT PRIMARY KEY,
+    operator_address TEXT NOT NULL,
+    first_height BIGINT NOT NULL,
+    last_height BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_validator_consensus_addresses_operator ON api.validator_consensus_addresses(operator_address);
+
+ALTER TABLE api.blocks_raw
+    ADD COLUMN IF NOT EXISTS proposer_address TEXT
+        GENERATED ALWAYS AS (upper(encode(api.decode_base64(data->'block'->'header'->>'proposerAddress'), 'hex'))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_blocks_proposer_address ON api.blocks_raw(proposer_address, id);
+
+CREATE OR REPLACE VIEW api.block_proposers AS
+SELECT
+    b.id AS height,
+    b.block_time,
+    b.proposer_address,
+    c.operator_address,
+    v.moniker
+FROM api.blocks_raw b
+LEFT JOIN api.validator_consensus_addresses c ON c.consensus_address = b.proposer_address
+LEFT JOIN api.validators v ON v.operator_address = c.operator_address;
+
+-- Read access for PostgREST
+GRANT SELECT ON api.validators TO web_anon;
+GRANT SELECT ON api.validator_consensus_addresses TO web_anon;
+GRANT SELECT ON api.block_proposers TO web_anon;
+
+COMMIT;
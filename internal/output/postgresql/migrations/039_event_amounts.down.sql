@@ -0,0 +1,13 @@
+-- Migration 039 down: Remove the coin amounts of the event attributes
+
+BEGIN;
+
+DROP INDEX IF EXISTS api.idx_events_denom;
+
+ALTER TABLE api.events
+    DROP COLUMN IF EXISTS amount,
+    DROP COLUMN IF EXISTS denom;
+
+DROP FUNCTION IF EXISTS api.parse_coins(TEXT);
+
+COMMIT;
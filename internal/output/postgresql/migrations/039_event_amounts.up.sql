@@ -0,0 +1,35 @@
+-- Migration 039: Parse the coin amounts of the event attributes
+--
+-- Exposes the attribute values formatted as a single coin, e.g., 1500000umfx,
+-- or a decimal coin, e.g., 1.5umfx, as the numeric amount and denom generated
+-- columns of api.events, so that amounts can be summed and grouped by denom
+-- without regular expressions. The values holding several coins, e.g.,
+-- 100umfx,200uatom, are parsed with api.parse_coins.
+
+BEGIN;
+
+-- Returns the coins of a comma-separated list of coins, e.g., 100umfx,200uatom
+CREATE OR REPLACE FUNCTION api.parse_coins(_value TEXT) RETURNS TABLE (coin_index INTEGER, amount NUMERIC, denom TEXT) AS $$
+    SELECT (c.ordinality - 1)::INTEGER, m[1]::NUMERIC, m[2]
+    FROM unnest(string_to_array(_value, ',')) WITH ORDINALITY AS c(coin, ordinality)
+    CROSS JOIN LATERAL regexp_match(trim(c.coin), '^([0-9]+(?:\.[0-9]+)?)([a-zA-Z][a-zA-Z0-9/:._-]*)$') m
+    WHERE m IS NOT NULL;
+$$ LANGUAGE sql IMMUTABLE;
+
+ALTER TABLE api.events
+    ADD COLUMN IF NOT EXISTS amount NUMERIC
+        GENERATED ALWAYS AS (
+            CASE WHEN attribute_value ~ '^[0-9]+(\.[0-9]+)?[a-zA-Z][a-zA-Z0-9/:._-]*$'
+                THEN substring(attribute_value FROM '^[0-9]+(?:\.[0-9]+)?')::NUMERIC
+            END
+        ) STORED,
+    ADD COLUMN IF NOT EXISTS denom TEXT
+        GENERATED ALWAYS AS (
+            CASE WHEN attribute_value ~ '^[0-9]+(\.[0-9]+)?[a-zA-Z][a-zA-Z0-9/:._-]*$'
+                THEN substring(attribute_value FROM '^[0-9]+(?:\.[0-9]+)?([a-zA-Z][a-zA-Z0-9/:._-]*)$')
+            END
+        ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_events_denom ON api.events(denom, event_type, attribute_key) WHERE denom IS NOT NULL;
+
+COMMIT;
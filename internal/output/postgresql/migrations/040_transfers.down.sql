@@ -0,0 +1,10 @@
+-- Migration 040 down: Remove transfers table
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_update_transfers ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_transfers();
+DROP FUNCTION IF EXISTS api.extract_transfers(TEXT, JSONB);
+DROP TABLE IF EXISTS api.transfers;
+
+COMMIT;
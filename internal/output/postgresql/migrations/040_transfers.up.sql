@@ -0,0 +1,124 @@
+-- Migration 040: Add transfers table
+--
+-- Normalizes the token movements of the transactions into one row per coin
+-- transferred, from their events:
+--   - the bank transfer events, emitted for every movement of native and IBC
+--     coins, including fees, IBC escrows and receptions, and contract funds;
+--     they are attributed to their message with the msg_index attribute of
+--     the Cosmos SDK 0.50 events, or to the only message of the transaction
+--     on earlier versions
+--   - the wasm events of the CW20 transfers, whose denom is the address of
+--     the token contract
+--
+-- The source is fee for the fee payment, ibc for the transfers of IBC
+-- messages, wasm for those of CosmWasm messages and CW20 tokens, and bank for
+-- the others.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.transfers (
+    id BIGSERIAL PRIMARY KEY,
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    height BIGINT,
+    event_index INTEGER NOT NULL,
+    coin_index INTEGER NOT NULL,
+    msg_index INTEGER,
+    source TEXT NOT NULL,
+    sender TEXT,
+    recipient TEXT,
+    amount NUMERIC NOT NULL,
+    denom TEXT NOT NULL,
+    UNIQUE (tx_hash, event_index, coin_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfers_sender ON api.transfers(sender, height);
+CREATE INDEX IF NOT EXISTS idx_transfers_recipient ON api.transfers(recipient, height);
+CREATE INDEX IF NOT EXISTS idx_transfers_denom ON api.transfers(denom, height);
+CREATE INDEX IF NOT EXISTS idx_transfers_height ON api.transfers(height);
+
+-- Replaces the transfers of a transaction
+CREATE OR REPLACE FUNCTION api.extract_transfers(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+DECLARE
+    _messages JSONB;
+    _has_msg_index BOOLEAN;
+    _fee TEXT;
+BEGIN
+    DELETE FROM api.transfers WHERE tx_hash = _tx_hash;
+
+    _messages := COALESCE(_data->'tx'->'body'->'messages', '[]'::JSONB);
+    _has_msg_index := COALESCE(jsonb_path_exists(_data->'txResponse'->'events', '$[*].attributes[*] ? (@.key == "msg_index")'), FALSE);
+    SELECT string_agg((c->>'amount') || (c->>'denom'), ',') INTO _fee
+    FROM jsonb_array_elements(COALESCE(_data->'tx'->'authInfo'->'fee'->'amount', '[]'::JSONB)) c;
+
+    WITH events AS (
+        SELECT (e.ordinality - 1)::INTEGER AS event_index, e.value->>'type' AS type, attrs.attrs
+        FROM jsonb_array_elements(COALESCE(_data->'txResponse'->'events', '[]'::JSONB)) WITH ORDINALITY e
+        CROSS JOIN LATERAL (
+            SELECT COALESCE(jsonb_object_agg(a->>'key', a->>'value'), '{}'::JSONB) AS attrs
+            FROM jsonb_array_elements(COALESCE(e.value->'attributes', '[]'::JSONB)) a
+            WHERE a->>'key' IS NOT NULL
+        ) attrs
+        WHERE e.value->>'type' IN ('transfer', 'wasm')
+    ),
+    movements AS (
+        SELECT
+            ev.event_index,
+            CASE
+                WHEN _has_msg_index THEN (ev.attrs->>'msg_index')::INTEGER
+                WHEN jsonb_array_length(_messages) = 1 THEN 0
+            END AS msg_index,
+            -- Before the Cosmos SDK 0.50, the fee payment is the first transfer, of the amount of the fee
+            CASE
+                WHEN ev.type <> 'transfer' THEN FALSE
+                WHEN _has_msg_index THEN ev.attrs->>'msg_index' IS NULL
+                ELSE ev.attrs->>'amount' = _fee
+                     AND ev.event_index = (SELECT MIN(f.event_index) FROM events f WHERE f.type = 'transfer')
+            END AS is_fee,
+            ev.type = 'wasm' AS is_cw20,
+            COALESCE(ev.attrs->>'sender', ev.attrs->>'from', ev.attrs->>'owner') AS sender,
+            COALESCE(ev.attrs->>'recipient', ev.attrs->>'to', ev.attrs->>'contract') AS recipient,
+            CASE WHEN ev.type = 'wasm' THEN (ev.attrs->>'amount') || (ev.attrs->>'_contract_address') ELSE ev.attrs->>'amount' END AS amount
+        FROM events ev
+        WHERE ev.type = 'transfer'
+           OR (ev.attrs->>'action' IN ('transfer', 'transfer_from', 'send', 'send_from')
+               AND ev.attrs->>'amount' ~ '^[0-9]+$' AND ev.attrs ? '_contract_address')
+    )
+    INSERT INTO api.transfers (tx_hash, height, event_index, coin_index, msg_index, source, sender, recipient, amount, denom)
+    SELECT
+        _tx_hash,
+        (_data->'txResponse'->>'height')::BIGINT,
+        m.event_index,
+        c.coin_index,
+        m.msg_index,
+        CASE
+            WHEN m.is_fee THEN 'fee'
+            WHEN m.is_cw20 THEN 'wasm'
+            WHEN _messages->m.msg_index->>'@type' LIKE '/ibc.%' THEN 'ibc'
+            WHEN _messages->m.msg_index->>'@type' LIKE '/cosmwasm.%' THEN 'wasm'
+            ELSE 'bank'
+        END,
+        m.sender,
+        m.recipient,
+        c.amount,
+        c.denom
+    FROM movements m
+    CROSS JOIN LATERAL api.parse_coins(m.amount) c;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_transfers() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_transfers(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_transfers ON api.transactions_raw;
+CREATE TRIGGER trg_update_transfers
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_transfers();
+
+-- Read access for PostgREST
+GRANT SELECT ON api.transfers TO web_anon;
+
+COMMIT;
@@ -0,0 +1,20 @@
+-- Migration 041 down: Remove balance_deltas and balances tables
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_update_block_balance_deltas ON api.block_results_raw;
+DROP TRIGGER IF EXISTS trg_update_tx_balance_deltas ON api.transactions_raw;
+DROP TRIGGER IF EXISTS trg_apply_balance_delta ON api.balance_deltas;
+DROP FUNCTION IF EXISTS api.update_block_balance_deltas();
+DROP FUNCTION IF EXISTS api.update_tx_balance_deltas();
+DROP FUNCTION IF EXISTS api.balance_at(TEXT, TEXT, BIGINT);
+DROP FUNCTION IF EXISTS api.reconcile_balances(BIGINT);
+DROP FUNCTION IF EXISTS api.apply_balance_delta();
+DROP FUNCTION IF EXISTS api.extract_block_balance_deltas(BIGINT, JSONB);
+DROP FUNCTION IF EXISTS api.extract_tx_balance_deltas(TEXT, JSONB);
+DROP FUNCTION IF EXISTS api.insert_balance_deltas(BIGINT, TEXT, TEXT, JSONB);
+DROP TABLE IF EXISTS api.balance_reconciliations;
+DROP TABLE IF EXISTS api.balances;
+DROP TABLE IF EXISTS api.balance_deltas;
+
+COMMIT;
@@ -0,0 +1,180 @@
+-- Migration 041: Add balance_deltas and balances tables
+--
+-- Accumulates the balance changes of the coin_spent and coin_received events
+-- of the transactions and of the finalize block events into
+-- api.balance_deltas, one row per address and coin, and keeps the running
+-- balance of every address in api.balances up to date from them.
+--
+-- The balances are reconciled with the bank balance snapshots: when a
+-- snapshot is written, the difference between the balances it holds and the
+-- balances computed from the deltas at its height, e.g., the genesis balances
+-- or the changes of blocks whose results were not fetched, is recorded in
+-- api.balance_reconciliations and applied as a reconciliation delta.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.balance_deltas (
+    id BIGSERIAL PRIMARY KEY,
+    height BIGINT NOT NULL,
+    tx_hash TEXT REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    source TEXT NOT NULL,
+    event_index INTEGER,
+    coin_index INTEGER,
+    address TEXT NOT NULL,
+    denom TEXT NOT NULL,
+    delta NUMERIC NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_deltas_address ON api.balance_deltas(address, denom, height);
+CREATE INDEX IF NOT EXISTS idx_balance_deltas_tx_hash ON api.balance_deltas(tx_hash) WHERE tx_hash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_balance_deltas_height ON api.balance_deltas(height, source);
+
+CREATE TABLE IF NOT EXISTS api.balances (
+    address TEXT NOT NULL,
+    denom TEXT NOT NULL,
+    balance NUMERIC NOT NULL,
+    updated_height BIGINT NOT NULL,
+    PRIMARY KEY (address, denom)
+);
+
+CREATE INDEX IF NOT EXISTS idx_balances_denom ON api.balances(denom, balance);
+
+CREATE TABLE IF NOT EXISTS api.balance_reconciliations (
+    height BIGINT NOT NULL,
+    address TEXT NOT NULL,
+    denom TEXT NOT NULL,
+    computed NUMERIC NOT NULL,
+    expected NUMERIC NOT NULL,
+    difference NUMERIC NOT NULL,
+    PRIMARY KEY (height, address, denom)
+);
+
+-- Inserts the balance deltas of the coin_spent and coin_received events
+CREATE OR REPLACE FUNCTION api.insert_balance_deltas(_height BIGINT, _tx_hash TEXT, _source TEXT, _events JSONB) RETURNS VOID AS $$
+BEGIN
+    INSERT INTO api.balance_deltas (height, tx_hash, source, event_index, coin_index, address, denom, delta)
+    SELECT _height, _tx_hash, _source, (e.ordinality - 1)::INTEGER, c.coin_index, ev.address, c.denom,
+           CASE WHEN e.value->>'type' = 'coin_spent' THEN -c.amount ELSE c.amount END
+    FROM jsonb_array_elements(COALESCE(_events, '[]'::JSONB)) WITH ORDINALITY e
+    CROSS JOIN LATERAL (
+        SELECT
+            (SELECT a->>'value' FROM jsonb_array_elements(e.value->'attributes') a WHERE a->>'key' IN ('spender', 'receiver') LIMIT 1) AS address,
+            (SELECT a->>'value' FROM jsonb_array_elements(e.value->'attributes') a WHERE a->>'key' = 'amount' LIMIT 1) AS amount
+    ) ev
+    CROSS JOIN LATERAL api.parse_coins(ev.amount) c
+    WHERE e.value->>'type' IN ('coin_spent', 'coin_received') AND ev.address IS NOT NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Replaces the balance deltas of a transaction
+CREATE OR REPLACE FUNCTION api.extract_tx_balance_deltas(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+BEGIN
+    DELETE FROM api.balance_deltas WHERE tx_hash = _tx_hash;
+    PERFORM api.insert_balance_deltas((_data->'txResponse'->>'height')::BIGINT, _tx_hash, 'tx', _data->'txResponse'->'events');
+END;
+$$ LANGUAGE plpgsql;
+
+-- Replaces the balance deltas of the finalize block events of a height
+CREATE OR REPLACE FUNCTION api.extract_block_balance_deltas(_height BIGINT, _data JSONB) RETURNS VOID AS $$
+BEGIN
+    DELETE FROM api.balance_deltas WHERE height = _height AND source = 'block';
+    PERFORM api.insert_balance_deltas(_height, NULL, 'block', _data->'finalizeBlockEvents');
+END;
+$$ LANGUAGE plpgsql;
+
+-- Applies the inserted and deleted deltas to the running balances
+CREATE OR REPLACE FUNCTION api.apply_balance_delta() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        UPDATE api.balances SET balance = balance - OLD.delta
+        WHERE address = OLD.address AND denom = OLD.denom;
+        RETURN OLD;
+    END IF;
+
+    INSERT INTO api.balances (address, denom, balance, updated_height)
+    VALUES (NEW.address, NEW.denom, NEW.delta, NEW.height)
+    ON CONFLICT (address, denom) DO UPDATE SET
+        balance = api.balances.balance + EXCLUDED.balance,
+        updated_height = GREATEST(api.balances.updated_height, EXCLUDED.updated_height);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Reconciles the balances computed from the deltas with the balance snapshot of a height
+CREATE OR REPLACE FUNCTION api.reconcile_balances(_height BIGINT) RETURNS INTEGER AS $$
+DECLARE
+    _count INTEGER;
+BEGIN
+    DELETE FROM api.balance_deltas WHERE height = _height AND source = 'reconciliation';
+    DELETE FROM api.balance_reconciliations WHERE height = _height;
+
+    INSERT INTO api.balance_reconciliations (height, address, denom, computed, expected, difference)
+    SELECT _height, COALESCE(s.address, d.address), COALESCE(s.denom, d.denom),
+           COALESCE(d.balance, 0), COALESCE(s.amount, 0), COALESCE(s.amount, 0) - COALESCE(d.balance, 0)
+    FROM (
+        SELECT address, denom, amount FROM api.balance_snapshots WHERE height = _height
+    ) s
+    FULL OUTER JOIN (
+        SELECT address, denom, SUM(delta) AS balance
+        FROM api.balance_deltas
+        WHERE height <= _height
+        GROUP BY address, denom
+    ) d ON d.address = s.address AND d.denom = s.denom
+    WHERE COALESCE(s.amount, 0) <> COALESCE(d.balance, 0);
+
+    INSERT INTO api.balance_deltas (height, source, address, denom, delta)
+    SELECT height, 'reconciliation', address, denom, difference
+    FROM api.balance_reconciliations
+    WHERE height = _height;
+
+    GET DIAGNOSTICS _count = ROW_COUNT;
+    RETURN _count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Returns the balance of an address in a denom at a height, from the deltas
+CREATE OR REPLACE FUNCTION api.balance_at(_address TEXT, _denom TEXT, _height BIGINT) RETURNS NUMERIC AS $$
+    SELECT COALESCE(SUM(delta), 0)
+    FROM api.balance_deltas
+    WHERE address = _address AND denom = _denom AND height <= _height;
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION api.update_tx_balance_deltas() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_tx_balance_deltas(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_block_balance_deltas() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        DELETE FROM api.balance_deltas WHERE height = OLD.height AND source = 'block';
+        RETURN OLD;
+    END IF;
+    PERFORM api.extract_block_balance_deltas(NEW.height, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_apply_balance_delta ON api.balance_deltas;
+CREATE TRIGGER trg_apply_balance_delta
+AFTER INSERT OR DELETE ON api.balance_deltas
+FOR EACH ROW EXECUTE FUNCTION api.apply_balance_delta();
+
+DROP TRIGGER IF EXISTS trg_update_tx_balance_deltas ON api.transactions_raw;
+CREATE TRIGGER trg_update_tx_balance_deltas
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_tx_balance_deltas();
+
+DROP TRIGGER IF EXISTS trg_update_block_balance_deltas ON api.block_results_raw;
+CREATE TRIGGER trg_update_block_balance_deltas
+AFTER INSERT OR UPDATE OR DELETE ON api.block_results_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_block_balance_deltas();
+
+-- Read access for PostgREST
+GRANT SELECT ON api.balance_deltas TO web_anon;
+GRANT SELECT ON api.balances TO web_anon;
+GRANT SELECT ON api.balance_reconciliations TO web_anon;
+
+COMMIT;
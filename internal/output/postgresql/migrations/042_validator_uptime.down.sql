@@ -0,0 +1,15 @@
+-- Migration 042 down: Remove commit signatures and validator uptime tables
+
+BEGIN;
+
+DROP VIEW IF EXISTS api.validator_uptime_report;
+DROP TRIGGER IF EXISTS trg_update_commit_signatures ON api.blocks_raw;
+DROP FUNCTION IF EXISTS api.update_commit_signatures();
+DROP FUNCTION IF EXISTS api.refresh_validator_uptime(BIGINT, INTEGER);
+DROP FUNCTION IF EXISTS api.extract_commit_signatures(BIGINT, JSONB);
+DROP FUNCTION IF EXISTS api.resolve_absent_validator(BIGINT, INTEGER, INTEGER);
+DROP TABLE IF EXISTS api.validator_uptime;
+DROP TABLE IF EXISTS api.uptime_windows;
+DROP TABLE IF EXISTS api.commit_signatures;
+
+COMMIT;
@@ -0,0 +1,160 @@
+-- Migration 042: Add commit signatures and validator uptime tables
+--
+-- The signatures of the last commit of each block, i.e., of the previous
+-- height, keyed by the uppercase hexadecimal consensus address of the
+-- validator. CometBFT leaves the address of the absent validators empty, so
+-- it is resolved from the signature at the same index of the nearest commit
+-- of the same size, the commit signatures being ordered by validator set.
+-- The signed and missed blocks of each validator are counted over the sliding
+-- windows of api.uptime_windows and persisted in api.validator_uptime, which
+-- is refreshed every refresh_interval heights.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.commit_signatures (
+    block_height BIGINT NOT NULL REFERENCES api.blocks_raw(id) ON DELETE CASCADE,
+    signature_index INTEGER NOT NULL,
+    height BIGINT NOT NULL,
+    signature_count INTEGER NOT NULL,
+    validator_address TEXT,
+    block_id_flag TEXT NOT NULL,
+    signed BOOLEAN NOT NULL,
+    PRIMARY KEY (block_height, signature_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_commit_signatures_height ON api.commit_signatures(height);
+CREATE INDEX IF NOT EXISTS idx_commit_signatures_validator ON api.commit_signatures(validator_address, height);
+CREATE INDEX IF NOT EXISTS idx_commit_signatures_slot ON api.commit_signatures(signature_count, signature_index, block_height)
+    WHERE validator_address IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS api.uptime_windows (
+    window_size INTEGER PRIMARY KEY CHECK (window_size > 0),
+    refresh_interval INTEGER NOT NULL CHECK (refresh_interval > 0)
+);
+
+INSERT INTO api.uptime_windows (window_size, refresh_interval)
+VALUES (100, 10), (1000, 100), (10000, 1000)
+ON CONFLICT (window_size) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS api.validator_uptime (
+    validator_address TEXT NOT NULL,
+    window_size INTEGER NOT NULL,
+    start_height BIGINT NOT NULL,
+    end_height BIGINT NOT NULL,
+    signed_blocks INTEGER NOT NULL,
+    missed_blocks INTEGER NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (validator_address, window_size)
+);
+
+CREATE INDEX IF NOT EXISTS idx_validator_uptime_window ON api.validator_uptime(window_size, missed_blocks DESC);
+
+-- Resolves the address of the validator of an absent signature, from the same slot of the nearest commit of the
+-- same size, preferring the previous commits
+CREATE OR REPLACE FUNCTION api.resolve_absent_validator(_block_height BIGINT, _signature_count INTEGER, _signature_index INTEGER)
+RETURNS TEXT AS $$
+    SELECT COALESCE(
+        (SELECT validator_address FROM api.commit_signatures
+         WHERE signature_count = _signature_count AND signature_index = _signature_index
+           AND validator_address IS NOT NULL AND block_height < _block_height
+         ORDER BY block_height DESC LIMIT 1),
+        (SELECT validator_address FROM api.commit_signatures
+         WHERE signature_count = _signature_count AND signature_index = _signature_index
+           AND validator_address IS NOT NULL AND block_height > _block_height
+         ORDER BY block_height LIMIT 1)
+    );
+$$ LANGUAGE sql STABLE;
+
+-- Replaces the commit signatures of a block
+CREATE OR REPLACE FUNCTION api.extract_commit_signatures(_block_height BIGINT, _data JSONB) RETURNS VOID AS $$
+DECLARE
+    _commit JSONB := _data->'block'->'lastCommit';
+BEGIN
+    DELETE FROM api.commit_signatures WHERE block_height = _block_height;
+
+    IF jsonb_typeof(_commit->'signatures') IS DISTINCT FROM 'array' THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO api.commit_signatures (block_height, signature_index, height, signature_count, validator_address, block_id_flag, signed)
+    SELECT _block_height,
+           (s.ordinality - 1)::INTEGER,
+           COALESCE((_commit->>'height')::BIGINT, _block_height - 1),
+           jsonb_array_length(_commit->'signatures'),
+           NULLIF(upper(encode(api.decode_base64(s.value->>'validatorAddress'), 'hex')), ''),
+           COALESCE(s.value->>'blockIdFlag', 'BLOCK_ID_FLAG_UNKNOWN'),
+           COALESCE(s.value->>'blockIdFlag', 'BLOCK_ID_FLAG_UNKNOWN') NOT IN ('BLOCK_ID_FLAG_ABSENT', 'BLOCK_ID_FLAG_UNKNOWN')
+    FROM jsonb_array_elements(_commit->'signatures') WITH ORDINALITY s;
+
+    UPDATE api.commit_signatures
+    SET validator_address = api.resolve_absent_validator(block_height, signature_count, signature_index)
+    WHERE block_height = _block_height AND validator_address IS NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Recounts the signed and missed blocks of every validator over the window ending at a height. The window is not
+-- moved back when refreshed at a lower height than its current end, e.g., when backfilling past blocks.
+CREATE OR REPLACE FUNCTION api.refresh_validator_uptime(_height BIGINT, _window_size INTEGER) RETURNS VOID AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM api.validator_uptime WHERE window_size = _window_size AND end_height > _height) THEN
+        RETURN;
+    END IF;
+
+    DELETE FROM api.validator_uptime WHERE window_size = _window_size;
+
+    INSERT INTO api.validator_uptime (validator_address, window_size, start_height, end_height, signed_blocks, missed_blocks)
+    SELECT validator_address, _window_size, GREATEST(_height - _window_size + 1, 1), _height,
+           COUNT(*) FILTER (WHERE signed), COUNT(*) FILTER (WHERE NOT signed)
+    FROM api.commit_signatures
+    WHERE height BETWEEN _height - _window_size + 1 AND _height
+      AND validator_address IS NOT NULL
+    GROUP BY validator_address;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_commit_signatures() RETURNS TRIGGER AS $$
+DECLARE
+    _height BIGINT;
+    _window RECORD;
+BEGIN
+    PERFORM api.extract_commit_signatures(NEW.id, NEW.data);
+
+    _height := (SELECT MAX(height) FROM api.commit_signatures WHERE block_height = NEW.id);
+    IF _height IS NOT NULL THEN
+        FOR _window IN SELECT window_size FROM api.uptime_windows WHERE _height % refresh_interval = 0 LOOP
+            PERFORM api.refresh_validator_uptime(_height, _window.window_size);
+        END LOOP;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_commit_signatures ON api.blocks_raw;
+CREATE TRIGGER trg_update_commit_signatures
+AFTER INSERT OR UPDATE OF data ON api.blocks_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_commit_signatures();
+
+CREATE OR REPLACE VIEW api.validator_uptime_report AS
+SELECT
+    u.validator_address,
+    c.operator_address,
+    v.moniker,
+    u.window_size,
+    u.start_height,
+    u.end_height,
+    u.signed_blocks,
+    u.missed_blocks,
+    ROUND(u.signed_blocks::NUMERIC / NULLIF(u.signed_blocks + u.missed_blocks, 0), 6) AS uptime,
+    u.updated_at
+FROM api.validator_uptime u
+LEFT JOIN api.validator_consensus_addresses c ON c.consensus_address = u.validator_address
+LEFT JOIN api.validators v ON v.operator_address = c.operator_address;
+
+-- Read access for PostgREST
+GRANT SELECT ON api.commit_signatures TO web_anon;
+GRANT SELECT ON api.uptime_windows TO web_anon;
+GRANT SELECT ON api.validator_uptime TO web_anon;
+GRANT SELECT ON api.validator_uptime_report TO web_anon;
+
+COMMIT;
@@ -0,0 +1,15 @@
+-- Migration 043 down: Remove slashing events table
+
+BEGIN;
+
+DROP VIEW IF EXISTS api.validator_slashing_events;
+DROP TRIGGER IF EXISTS trg_update_slashing_block_events ON api.block_results_raw;
+DROP TRIGGER IF EXISTS trg_update_slashing_tx_events ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_slashing_block_events();
+DROP FUNCTION IF EXISTS api.update_slashing_tx_events();
+DROP FUNCTION IF EXISTS api.extract_slashing_tx_events(TEXT, JSONB);
+DROP FUNCTION IF EXISTS api.extract_slashing_block_events(BIGINT, JSONB);
+DROP TABLE IF EXISTS api.slashing_events;
+DROP FUNCTION IF EXISTS api.bech32_to_hex(TEXT);
+
+COMMIT;
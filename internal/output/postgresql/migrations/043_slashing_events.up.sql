@@ -0,0 +1,155 @@
+-- Migration 043: Add slashing events table
+--
+-- The slashing, jailing and tombstoning of validators, parsed from the slash
+-- events of the finalize block events, and their unjailing by the successful
+-- MsgUnjail messages. A slash event with a reason slashes the validator, one
+-- with a jailed attribute jails it, and a double sign slash tombstones it.
+-- The block events identify the validators by their consensus address,
+-- stored in uppercase hexadecimal like api.blocks_raw.proposer_address, and
+-- the unjail messages by their operator address.
+
+BEGIN;
+
+-- Returns the data of a bech32 string as uppercase hexadecimal, without verifying its checksum
+CREATE OR REPLACE FUNCTION api.bech32_to_hex(_address TEXT) RETURNS TEXT AS $$
+DECLARE
+    _charset CONSTANT TEXT := 'qpzry9x8gf2tvdw0s3jn54khce6mua7l';
+    _data TEXT := substring(lower(_address) FROM '^.+1([qpzry9x8gf2tvdw0s3jn54khce6mua7l]{7,})$');
+    _acc INTEGER := 0;
+    _bits INTEGER := 0;
+    _result BYTEA := ''::BYTEA;
+BEGIN
+    IF _data IS NULL THEN
+        RETURN NULL;
+    END IF;
+
+    -- Drop the checksum, then regroup the 5-bit values into bytes
+    _data := left(_data, length(_data) - 6);
+    FOR i IN 1..length(_data) LOOP
+        _acc := ((_acc << 5) | (strpos(_charset, substr(_data, i, 1)) - 1)) & 4095;
+        _bits := _bits + 5;
+        IF _bits >= 8 THEN
+            _bits := _bits - 8;
+            _result := _result || set_byte('\x00'::BYTEA, 0, (_acc >> _bits) & 255);
+        END IF;
+    END LOOP;
+
+    RETURN NULLIF(upper(encode(_result, 'hex')), '');
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+CREATE TABLE IF NOT EXISTS api.slashing_events (
+    id BIGSERIAL PRIMARY KEY,
+    height BIGINT NOT NULL,
+    tx_hash TEXT REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    event_index INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    consensus_address TEXT,
+    operator_address TEXT,
+    power BIGINT,
+    reason TEXT,
+    burned_coins TEXT,
+    data JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_slashing_events_height ON api.slashing_events(height);
+CREATE INDEX IF NOT EXISTS idx_slashing_events_tx_hash ON api.slashing_events(tx_hash) WHERE tx_hash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_slashing_events_consensus_address ON api.slashing_events(consensus_address, height);
+CREATE INDEX IF NOT EXISTS idx_slashing_events_operator_address ON api.slashing_events(operator_address, height);
+CREATE INDEX IF NOT EXISTS idx_slashing_events_action ON api.slashing_events(action, height);
+
+-- Replaces the slashing, jailing and tombstoning events of the block results of a height
+CREATE OR REPLACE FUNCTION api.extract_slashing_block_events(_height BIGINT, _data JSONB) RETURNS VOID AS $$
+BEGIN
+    DELETE FROM api.slashing_events WHERE height = _height AND tx_hash IS NULL;
+
+    INSERT INTO api.slashing_events (height, event_index, action, consensus_address, power, reason, burned_coins, data)
+    SELECT _height, (e.ordinality - 1)::INTEGER, act.action,
+           api.bech32_to_hex(COALESCE(attr.attrs->>'address', attr.attrs->>'jailed')),
+           CASE WHEN attr.attrs->>'power' ~ '^[0-9]+$' THEN (attr.attrs->>'power')::BIGINT END,
+           attr.attrs->>'reason', attr.attrs->>'burned_coins', attr.attrs
+    FROM jsonb_array_elements(COALESCE(_data->'finalizeBlockEvents', '[]'::JSONB)) WITH ORDINALITY e
+    CROSS JOIN LATERAL (
+        SELECT COALESCE(jsonb_object_agg(a->>'key', a->>'value'), '{}'::JSONB) AS attrs
+        FROM jsonb_array_elements(COALESCE(e.value->'attributes', '[]'::JSONB)) a
+        WHERE a->>'key' IS NOT NULL
+    ) attr
+    CROSS JOIN LATERAL (
+        SELECT 'slash' AS action WHERE COALESCE(attr.attrs->>'reason', '') <> ''
+        UNION ALL
+        SELECT 'jail' WHERE COALESCE(attr.attrs->>'jailed', '') <> ''
+        UNION ALL
+        SELECT 'tombstone' WHERE attr.attrs->>'reason' = 'double_sign'
+    ) act
+    WHERE e.value->>'type' = 'slash';
+END;
+$$ LANGUAGE plpgsql;
+
+-- Replaces the unjailing of the validators by a transaction
+CREATE OR REPLACE FUNCTION api.extract_slashing_tx_events(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+BEGIN
+    DELETE FROM api.slashing_events WHERE tx_hash = _tx_hash;
+
+    IF COALESCE((_data->'txResponse'->>'code')::INTEGER, 0) <> 0 THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO api.slashing_events (height, tx_hash, event_index, action, operator_address, data)
+    SELECT (_data->'txResponse'->>'height')::BIGINT, _tx_hash, (m.ordinality - 1)::INTEGER, 'unjail',
+           m.value->>'validatorAddr', m.value
+    FROM jsonb_array_elements(COALESCE(_data->'tx'->'body'->'messages', '[]'::JSONB)) WITH ORDINALITY m
+    WHERE m.value->>'@type' = '/cosmos.slashing.v1beta1.MsgUnjail';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_slashing_tx_events() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_slashing_tx_events(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_slashing_block_events() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        DELETE FROM api.slashing_events WHERE height = OLD.height AND tx_hash IS NULL;
+        RETURN OLD;
+    END IF;
+    PERFORM api.extract_slashing_block_events(NEW.height, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_slashing_tx_events ON api.transactions_raw;
+CREATE TRIGGER trg_update_slashing_tx_events
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_slashing_tx_events();
+
+DROP TRIGGER IF EXISTS trg_update_slashing_block_events ON api.block_results_raw;
+CREATE TRIGGER trg_update_slashing_block_events
+AFTER INSERT OR UPDATE OR DELETE ON api.block_results_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_slashing_block_events();
+
+-- Resolves the validators of the slashing events to their operator and consensus addresses and monikers
+CREATE OR REPLACE VIEW api.validator_slashing_events AS
+SELECT
+    s.id,
+    s.height,
+    s.tx_hash,
+    s.action,
+    COALESCE(s.consensus_address, v.consensus_address) AS consensus_address,
+    COALESCE(s.operator_address, c.operator_address) AS operator_address,
+    COALESCE(v.moniker, cv.moniker) AS moniker,
+    s.power,
+    s.reason,
+    s.burned_coins
+FROM api.slashing_events s
+LEFT JOIN api.validator_consensus_addresses c ON c.consensus_address = s.consensus_address
+LEFT JOIN api.validators cv ON cv.operator_address = c.operator_address
+LEFT JOIN api.validators v ON v.operator_address = s.operator_address;
+
+-- Read access for PostgREST
+GRANT SELECT ON api.slashing_events TO web_anon;
+GRANT SELECT ON api.validator_slashing_events TO web_anon;
+
+COMMIT;
@@ -0,0 +1,13 @@
+-- Migration 044 down: Remove governance votes table and proposal tallies
+
+BEGIN;
+
+DROP VIEW IF EXISTS api.proposal_tallies;
+DROP VIEW IF EXISTS api.proposal_votes;
+DROP TRIGGER IF EXISTS trg_update_gov_votes ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_gov_votes();
+DROP FUNCTION IF EXISTS api.extract_gov_votes(TEXT, JSONB);
+DROP FUNCTION IF EXISTS api.vote_weight(TEXT);
+DROP TABLE IF EXISTS api.gov_votes;
+
+COMMIT;
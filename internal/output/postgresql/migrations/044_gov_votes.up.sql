@@ -0,0 +1,119 @@
+-- Migration 044: Add governance votes table and proposal tallies
+--
+-- The votes cast by the MsgVote and MsgVoteWeighted messages of the gov
+-- module, v1 and v1beta1, of the successful transactions, including the ones
+-- executed through authz MsgExec. Weighted votes have one row per option.
+-- The api.proposal_votes view combines them with the votes on the group
+-- proposals, and api.proposal_tallies counts, for each proposal, the latest
+-- vote of each voter, since a voter can change their vote. The tallies are
+-- weighted by the vote options, not by the voting power of the voters.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.gov_votes (
+    id BIGSERIAL PRIMARY KEY,
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    msg_index INTEGER NOT NULL,
+    exec_msg_index INTEGER,
+    option_index INTEGER NOT NULL,
+    height BIGINT,
+    proposal_id BIGINT NOT NULL,
+    voter TEXT NOT NULL,
+    option TEXT NOT NULL,
+    weight NUMERIC NOT NULL,
+    metadata TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_gov_votes_tx_hash ON api.gov_votes(tx_hash);
+CREATE INDEX IF NOT EXISTS idx_gov_votes_proposal ON api.gov_votes(proposal_id, voter, height);
+CREATE INDEX IF NOT EXISTS idx_gov_votes_voter ON api.gov_votes(voter, height);
+
+-- Returns the weight of a vote option. The v1beta1 weights are encoded as integers scaled by 10^18.
+CREATE OR REPLACE FUNCTION api.vote_weight(_weight TEXT) RETURNS NUMERIC AS $$
+    SELECT CASE
+        WHEN _weight ~ '^[0-9]*\.[0-9]+$' THEN trim_scale(_weight::NUMERIC)
+        WHEN _weight ~ '^[0-9]+$' THEN trim_scale(_weight::NUMERIC / 1000000000000000000)
+    END;
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Replaces the governance votes of a transaction
+CREATE OR REPLACE FUNCTION api.extract_gov_votes(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+BEGIN
+    DELETE FROM api.gov_votes WHERE tx_hash = _tx_hash;
+
+    IF COALESCE((_data->'txResponse'->>'code')::INTEGER, 0) <> 0 THEN
+        RETURN;
+    END IF;
+
+    WITH msgs AS (
+        SELECT (m.ordinality - 1)::INTEGER AS msg_index, NULL::INTEGER AS exec_msg_index, m.value
+        FROM jsonb_array_elements(COALESCE(_data->'tx'->'body'->'messages', '[]'::JSONB)) WITH ORDINALITY m
+        UNION ALL
+        SELECT (m.ordinality - 1)::INTEGER, (x.ordinality - 1)::INTEGER, x.value
+        FROM jsonb_array_elements(COALESCE(_data->'tx'->'body'->'messages', '[]'::JSONB)) WITH ORDINALITY m
+        CROSS JOIN LATERAL jsonb_array_elements(COALESCE(m.value->'msgs', '[]'::JSONB)) WITH ORDINALITY x
+        WHERE m.value->>'@type' = '/cosmos.authz.v1beta1.MsgExec'
+    )
+    INSERT INTO api.gov_votes (tx_hash, msg_index, exec_msg_index, option_index, height, proposal_id, voter, option, weight, metadata)
+    SELECT _tx_hash, msgs.msg_index, msgs.exec_msg_index, o.option_index,
+           (_data->'txResponse'->>'height')::BIGINT, (msgs.value->>'proposalId')::BIGINT, msgs.value->>'voter',
+           o.option, o.weight, NULLIF(msgs.value->>'metadata', '')
+    FROM msgs
+    CROSS JOIN LATERAL (
+        SELECT 0 AS option_index, msgs.value->>'option' AS option, 1::NUMERIC AS weight
+        WHERE msgs.value->>'@type' IN ('/cosmos.gov.v1.MsgVote', '/cosmos.gov.v1beta1.MsgVote')
+        UNION ALL
+        SELECT (w.ordinality - 1)::INTEGER, w.value->>'option', COALESCE(api.vote_weight(w.value->>'weight'), 0)
+        FROM jsonb_array_elements(COALESCE(msgs.value->'options', '[]'::JSONB)) WITH ORDINALITY w
+        WHERE msgs.value->>'@type' IN ('/cosmos.gov.v1.MsgVoteWeighted', '/cosmos.gov.v1beta1.MsgVoteWeighted')
+    ) o
+    WHERE msgs.value->>'proposalId' IS NOT NULL AND msgs.value->>'voter' IS NOT NULL AND o.option IS NOT NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_gov_votes() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_gov_votes(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_gov_votes ON api.transactions_raw;
+CREATE TRIGGER trg_update_gov_votes
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_gov_votes();
+
+-- The votes on the gov and group proposals, one row per vote option
+CREATE OR REPLACE VIEW api.proposal_votes AS
+SELECT 'gov' AS module, proposal_id, voter, option, weight, metadata, height, tx_hash, msg_index
+FROM api.gov_votes
+UNION ALL
+SELECT 'group', proposal_id, voter, option, 1::NUMERIC, metadata, height, tx_hash, msg_index
+FROM api.group_votes;
+
+-- The tallies of the latest vote of each voter of each proposal
+CREATE OR REPLACE VIEW api.proposal_tallies AS
+WITH latest AS (
+    SELECT DISTINCT ON (module, proposal_id, voter) module, proposal_id, voter, tx_hash, msg_index
+    FROM api.proposal_votes
+    ORDER BY module, proposal_id, voter, height DESC, tx_hash DESC, msg_index DESC
+)
+SELECT
+    v.module,
+    v.proposal_id,
+    COUNT(DISTINCT v.voter) AS voters,
+    SUM(v.weight) FILTER (WHERE v.option = 'VOTE_OPTION_YES') AS yes,
+    SUM(v.weight) FILTER (WHERE v.option = 'VOTE_OPTION_NO') AS no,
+    SUM(v.weight) FILTER (WHERE v.option = 'VOTE_OPTION_NO_WITH_VETO') AS no_with_veto,
+    SUM(v.weight) FILTER (WHERE v.option = 'VOTE_OPTION_ABSTAIN') AS abstain,
+    MAX(v.height) AS last_vote_height
+FROM api.proposal_votes v
+JOIN latest l USING (module, proposal_id, voter, tx_hash, msg_index)
+GROUP BY v.module, v.proposal_id;
+
+-- Read access for PostgREST
+GRANT SELECT ON api.gov_votes TO web_anon;
+GRANT SELECT ON api.proposal_votes TO web_anon;
+GRANT SELECT ON api.proposal_tallies TO web_anon;
+
+COMMIT;
@@ -0,0 +1,16 @@
+-- Migration 045 down: Remove delegation events and reward withdrawals tables
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_update_staking_block_events ON api.block_results_raw;
+DROP TRIGGER IF EXISTS trg_update_staking_tx_events ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_staking_block_events();
+DROP FUNCTION IF EXISTS api.update_staking_tx_events();
+DROP FUNCTION IF EXISTS api.extract_staking_block_events(BIGINT, JSONB);
+DROP FUNCTION IF EXISTS api.extract_staking_tx_events(TEXT, JSONB);
+DROP FUNCTION IF EXISTS api.insert_delegation_events(BIGINT, TEXT, JSONB, JSONB);
+DROP FUNCTION IF EXISTS api.delegation_action(TEXT);
+DROP TABLE IF EXISTS api.reward_withdrawals;
+DROP TABLE IF EXISTS api.delegation_events;
+
+COMMIT;
@@ -0,0 +1,180 @@
+-- Migration 045: Add delegation events and reward withdrawals tables
+--
+-- Normalizes the staking events into one row per event, keyed by delegator
+-- and validator:
+--   - api.delegation_events: the delegate, unbond, redelegate and
+--     cancel_unbonding_delegation events of the successful transactions, and
+--     the complete_unbonding and complete_redelegation events of the finalize
+--     block events, when the unbonding and redelegations mature
+--   - api.reward_withdrawals: the withdraw_rewards and withdraw_commission
+--     events, one row per coin, including the rewards withdrawn
+--     automatically when a delegation changes
+--
+-- Before the Cosmos SDK 0.47, the events have no delegator attribute and the
+-- delegation amounts have no denom; the delegator is then taken from the
+-- message of the event, and the denom is left NULL.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.delegation_events (
+    id BIGSERIAL PRIMARY KEY,
+    height BIGINT NOT NULL,
+    tx_hash TEXT REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    event_index INTEGER NOT NULL,
+    msg_index INTEGER,
+    action TEXT NOT NULL,
+    delegator TEXT,
+    validator TEXT,
+    source_validator TEXT,
+    amount NUMERIC,
+    denom TEXT,
+    completion_time TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_delegation_events_height ON api.delegation_events(height);
+CREATE INDEX IF NOT EXISTS idx_delegation_events_tx_hash ON api.delegation_events(tx_hash) WHERE tx_hash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_delegation_events_delegator ON api.delegation_events(delegator, height);
+CREATE INDEX IF NOT EXISTS idx_delegation_events_validator ON api.delegation_events(validator, height);
+CREATE INDEX IF NOT EXISTS idx_delegation_events_source_validator ON api.delegation_events(source_validator, height) WHERE source_validator IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_delegation_events_action ON api.delegation_events(action, height);
+
+CREATE TABLE IF NOT EXISTS api.reward_withdrawals (
+    id BIGSERIAL PRIMARY KEY,
+    height BIGINT NOT NULL,
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    event_index INTEGER NOT NULL,
+    coin_index INTEGER NOT NULL,
+    msg_index INTEGER,
+    kind TEXT NOT NULL,
+    delegator TEXT,
+    validator TEXT,
+    amount NUMERIC NOT NULL,
+    denom TEXT NOT NULL,
+    UNIQUE (tx_hash, event_index, coin_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reward_withdrawals_delegator ON api.reward_withdrawals(delegator, height);
+CREATE INDEX IF NOT EXISTS idx_reward_withdrawals_validator ON api.reward_withdrawals(validator, height);
+CREATE INDEX IF NOT EXISTS idx_reward_withdrawals_height ON api.reward_withdrawals(height);
+
+-- Returns the staking action of an event type
+CREATE OR REPLACE FUNCTION api.delegation_action(_type TEXT) RETURNS TEXT AS $$
+    SELECT CASE _type
+        WHEN 'delegate' THEN 'delegate'
+        WHEN 'unbond' THEN 'undelegate'
+        WHEN 'redelegate' THEN 'redelegate'
+        WHEN 'cancel_unbonding_delegation' THEN 'cancel_unbonding'
+        WHEN 'complete_unbonding' THEN 'complete_unbonding'
+        WHEN 'complete_redelegation' THEN 'complete_redelegation'
+    END;
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Inserts the delegation events of a list of events, attributing them to the messages of their transaction, if any
+CREATE OR REPLACE FUNCTION api.insert_delegation_events(_height BIGINT, _tx_hash TEXT, _events JSONB, _messages JSONB) RETURNS VOID AS $$
+BEGIN
+    INSERT INTO api.delegation_events (height, tx_hash, event_index, msg_index, action, delegator, validator, source_validator, amount, denom, completion_time)
+    SELECT _height, _tx_hash, ev.event_index, ev.msg_index, api.delegation_action(ev.type),
+           COALESCE(ev.attrs->>'delegator', _messages->ev.msg_index->>'delegatorAddress'),
+           COALESCE(ev.attrs->>'validator', ev.attrs->>'destination_validator'),
+           ev.attrs->>'source_validator',
+           CASE WHEN ev.attrs->>'amount' ~ '^[0-9]+$' THEN (ev.attrs->>'amount')::NUMERIC ELSE coin.amount END,
+           coin.denom,
+           CASE WHEN ev.attrs->>'completion_time' ~ '^[0-9]{4}-[0-9]{2}-[0-9]{2}T' THEN (ev.attrs->>'completion_time')::TIMESTAMPTZ END
+    FROM (
+        SELECT (e.ordinality - 1)::INTEGER AS event_index, e.value->>'type' AS type, attrs.attrs,
+               CASE
+                   WHEN attrs.attrs->>'msg_index' ~ '^[0-9]+$' THEN (attrs.attrs->>'msg_index')::INTEGER
+                   WHEN jsonb_array_length(_messages) = 1 THEN 0
+               END AS msg_index
+        FROM jsonb_array_elements(COALESCE(_events, '[]'::JSONB)) WITH ORDINALITY e
+        CROSS JOIN LATERAL (
+            SELECT COALESCE(jsonb_object_agg(a->>'key', a->>'value'), '{}'::JSONB) AS attrs
+            FROM jsonb_array_elements(COALESCE(e.value->'attributes', '[]'::JSONB)) a
+            WHERE a->>'key' IS NOT NULL
+        ) attrs
+        WHERE api.delegation_action(e.value->>'type') IS NOT NULL
+    ) ev
+    LEFT JOIN LATERAL (SELECT p.amount, p.denom FROM api.parse_coins(ev.attrs->>'amount') p ORDER BY p.coin_index LIMIT 1) coin ON TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Replaces the delegation events and reward withdrawals of a transaction
+CREATE OR REPLACE FUNCTION api.extract_staking_tx_events(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+DECLARE
+    _height BIGINT := (_data->'txResponse'->>'height')::BIGINT;
+    _messages JSONB := COALESCE(_data->'tx'->'body'->'messages', '[]'::JSONB);
+BEGIN
+    DELETE FROM api.delegation_events WHERE tx_hash = _tx_hash;
+    DELETE FROM api.reward_withdrawals WHERE tx_hash = _tx_hash;
+
+    IF COALESCE((_data->'txResponse'->>'code')::INTEGER, 0) <> 0 THEN
+        RETURN;
+    END IF;
+
+    PERFORM api.insert_delegation_events(_height, _tx_hash, _data->'txResponse'->'events', _messages);
+
+    INSERT INTO api.reward_withdrawals (height, tx_hash, event_index, coin_index, msg_index, kind, delegator, validator, amount, denom)
+    SELECT _height, _tx_hash, ev.event_index, coin.coin_index, ev.msg_index,
+           CASE ev.type WHEN 'withdraw_rewards' THEN 'rewards' ELSE 'commission' END,
+           CASE WHEN ev.type = 'withdraw_rewards' THEN COALESCE(ev.attrs->>'delegator', _messages->ev.msg_index->>'delegatorAddress') END,
+           COALESCE(ev.attrs->>'validator', _messages->ev.msg_index->>'validatorAddress'),
+           coin.amount, coin.denom
+    FROM (
+        SELECT (e.ordinality - 1)::INTEGER AS event_index, e.value->>'type' AS type, attrs.attrs,
+               CASE
+                   WHEN attrs.attrs->>'msg_index' ~ '^[0-9]+$' THEN (attrs.attrs->>'msg_index')::INTEGER
+                   WHEN jsonb_array_length(_messages) = 1 THEN 0
+               END AS msg_index
+        FROM jsonb_array_elements(COALESCE(_data->'txResponse'->'events', '[]'::JSONB)) WITH ORDINALITY e
+        CROSS JOIN LATERAL (
+            SELECT COALESCE(jsonb_object_agg(a->>'key', a->>'value'), '{}'::JSONB) AS attrs
+            FROM jsonb_array_elements(COALESCE(e.value->'attributes', '[]'::JSONB)) a
+            WHERE a->>'key' IS NOT NULL
+        ) attrs
+        WHERE e.value->>'type' IN ('withdraw_rewards', 'withdraw_commission')
+    ) ev
+    CROSS JOIN LATERAL api.parse_coins(ev.attrs->>'amount') coin;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Replaces the matured unbonding and redelegations of the block results of a height
+CREATE OR REPLACE FUNCTION api.extract_staking_block_events(_height BIGINT, _data JSONB) RETURNS VOID AS $$
+BEGIN
+    DELETE FROM api.delegation_events WHERE height = _height AND tx_hash IS NULL;
+    PERFORM api.insert_delegation_events(_height, NULL, _data->'finalizeBlockEvents', '[]'::JSONB);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_staking_tx_events() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_staking_tx_events(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_staking_block_events() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        DELETE FROM api.delegation_events WHERE height = OLD.height AND tx_hash IS NULL;
+        RETURN OLD;
+    END IF;
+    PERFORM api.extract_staking_block_events(NEW.height, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_staking_tx_events ON api.transactions_raw;
+CREATE TRIGGER trg_update_staking_tx_events
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_staking_tx_events();
+
+DROP TRIGGER IF EXISTS trg_update_staking_block_events ON api.block_results_raw;
+CREATE TRIGGER trg_update_staking_block_events
+AFTER INSERT OR UPDATE OR DELETE ON api.block_results_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_staking_block_events();
+
+-- Read access for PostgREST
+GRANT SELECT ON api.delegation_events TO web_anon;
+GRANT SELECT ON api.reward_withdrawals TO web_anon;
+
+COMMIT;
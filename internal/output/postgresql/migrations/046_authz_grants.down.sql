@@ -0,0 +1,15 @@
+-- Migration 046 down: Remove authz grants tables
+
+BEGIN;
+
+DROP VIEW IF EXISTS api.active_authz_grants;
+DROP TRIGGER IF EXISTS trg_update_authz_grants ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_authz_grants();
+DROP TRIGGER IF EXISTS trg_apply_authz_grant_history ON api.authz_grant_history;
+DROP FUNCTION IF EXISTS api.apply_authz_grant_history();
+DROP FUNCTION IF EXISTS api.refresh_authz_grant(TEXT, TEXT, TEXT);
+DROP FUNCTION IF EXISTS api.extract_authz_grants(TEXT, JSONB);
+DROP TABLE IF EXISTS api.authz_grants;
+DROP TABLE IF EXISTS api.authz_grant_history;
+
+COMMIT;
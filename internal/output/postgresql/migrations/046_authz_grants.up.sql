@@ -0,0 +1,172 @@
+-- Migration 046: Add authz grants tables
+--
+-- The history of the authz grants of the successful transactions, from their
+-- EventGrant and EventRevoke events, and the current grants derived from it,
+-- by granter, grantee and message type URL. The revocations include the
+-- grants deleted when they are used up, e.g., the send authorizations whose
+-- spend limit is exhausted, which emit an EventRevoke. The authorization and
+-- expiration of a grant are taken from its MsgGrant. Expired grants are kept
+-- in api.authz_grants until revoked, since the chain prunes them without an
+-- event; api.active_authz_grants excludes the grants expired at the time of
+-- the latest indexed block.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.authz_grant_history (
+    id BIGSERIAL PRIMARY KEY,
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    height BIGINT,
+    event_index INTEGER NOT NULL,
+    msg_index INTEGER,
+    action TEXT NOT NULL,
+    granter TEXT NOT NULL,
+    grantee TEXT NOT NULL,
+    msg_type_url TEXT NOT NULL,
+    authorization_data JSONB,
+    expiration TIMESTAMPTZ,
+    UNIQUE (tx_hash, event_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_authz_grant_history_key ON api.authz_grant_history(granter, grantee, msg_type_url, height);
+CREATE INDEX IF NOT EXISTS idx_authz_grant_history_grantee ON api.authz_grant_history(grantee, height);
+
+CREATE TABLE IF NOT EXISTS api.authz_grants (
+    granter TEXT NOT NULL,
+    grantee TEXT NOT NULL,
+    msg_type_url TEXT NOT NULL,
+    authorization_data JSONB,
+    expiration TIMESTAMPTZ,
+    height BIGINT,
+    tx_hash TEXT,
+    PRIMARY KEY (granter, grantee, msg_type_url)
+);
+
+CREATE INDEX IF NOT EXISTS idx_authz_grants_grantee ON api.authz_grants(grantee);
+CREATE INDEX IF NOT EXISTS idx_authz_grants_expiration ON api.authz_grants(expiration) WHERE expiration IS NOT NULL;
+
+-- Replaces the grant history of a transaction
+CREATE OR REPLACE FUNCTION api.extract_authz_grants(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+DECLARE
+    _messages JSONB := COALESCE(_data->'tx'->'body'->'messages', '[]'::JSONB);
+    _event RECORD;
+    _msg_index INTEGER;
+    _msg JSONB;
+    _grants INTEGER := 0;
+BEGIN
+    DELETE FROM api.authz_grant_history WHERE tx_hash = _tx_hash;
+
+    IF COALESCE((_data->'txResponse'->>'code')::INTEGER, 0) <> 0 THEN
+        RETURN;
+    END IF;
+
+    FOR _event IN
+        SELECT (e.ordinality - 1)::INTEGER AS event_index, e.value
+        FROM jsonb_array_elements(COALESCE(_data->'txResponse'->'events', '[]'::JSONB)) WITH ORDINALITY e
+        WHERE e.value->>'type' IN ('cosmos.authz.v1beta1.EventGrant', 'cosmos.authz.v1beta1.EventRevoke')
+    LOOP
+        _msg_index := NULL;
+        IF api.typed_event_attribute(_event.value, 'msg_index') ~ '^[0-9]+$' THEN
+            _msg_index := api.typed_event_attribute(_event.value, 'msg_index')::INTEGER;
+        END IF;
+
+        _msg := NULL;
+        IF _event.value->>'type' = 'cosmos.authz.v1beta1.EventGrant' THEN
+            _grants := _grants + 1;
+            IF _msg_index IS NOT NULL THEN
+                _msg := _messages->_msg_index;
+            ELSE
+                -- Before the Cosmos SDK 0.50, the grant events are in the order of the MsgGrant messages
+                SELECT m INTO _msg
+                FROM jsonb_array_elements(_messages) WITH ORDINALITY AS t(m, ordinality)
+                WHERE m->>'@type' = '/cosmos.authz.v1beta1.MsgGrant'
+                ORDER BY t.ordinality
+                OFFSET _grants - 1
+                LIMIT 1;
+            END IF;
+            IF _msg->>'@type' IS DISTINCT FROM '/cosmos.authz.v1beta1.MsgGrant' THEN
+                _msg := NULL;
+            END IF;
+        END IF;
+
+        INSERT INTO api.authz_grant_history (tx_hash, height, event_index, msg_index, action, granter, grantee, msg_type_url, authorization_data, expiration)
+        SELECT _tx_hash, (_data->'txResponse'->>'height')::BIGINT, _event.event_index, _msg_index,
+               CASE WHEN _event.value->>'type' = 'cosmos.authz.v1beta1.EventGrant' THEN 'grant' ELSE 'revoke' END,
+               api.typed_event_attribute(_event.value, 'granter'),
+               api.typed_event_attribute(_event.value, 'grantee'),
+               COALESCE(api.typed_event_attribute(_event.value, 'msg_type_url'), _msg->'grant'->'authorization'->>'msg'),
+               _msg->'grant'->'authorization',
+               CASE WHEN _msg->'grant'->>'expiration' ~ '^[0-9]{4}-[0-9]{2}-[0-9]{2}T' THEN (_msg->'grant'->>'expiration')::TIMESTAMPTZ END
+        WHERE api.typed_event_attribute(_event.value, 'granter') IS NOT NULL
+          AND api.typed_event_attribute(_event.value, 'grantee') IS NOT NULL
+          AND COALESCE(api.typed_event_attribute(_event.value, 'msg_type_url'), _msg->'grant'->'authorization'->>'msg') IS NOT NULL;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Sets the current grant of a granter, grantee and message type URL from its latest history entry
+CREATE OR REPLACE FUNCTION api.refresh_authz_grant(_granter TEXT, _grantee TEXT, _msg_type_url TEXT) RETURNS VOID AS $$
+DECLARE
+    _latest api.authz_grant_history;
+BEGIN
+    SELECT * INTO _latest
+    FROM api.authz_grant_history
+    WHERE granter = _granter AND grantee = _grantee AND msg_type_url = _msg_type_url
+    ORDER BY height DESC, tx_hash DESC, event_index DESC
+    LIMIT 1;
+
+    IF _latest.id IS NULL OR _latest.action = 'revoke' THEN
+        DELETE FROM api.authz_grants WHERE granter = _granter AND grantee = _grantee AND msg_type_url = _msg_type_url;
+        RETURN;
+    END IF;
+
+    INSERT INTO api.authz_grants (granter, grantee, msg_type_url, authorization_data, expiration, height, tx_hash)
+    VALUES (_granter, _grantee, _msg_type_url, _latest.authorization_data, _latest.expiration, _latest.height, _latest.tx_hash)
+    ON CONFLICT (granter, grantee, msg_type_url) DO UPDATE SET
+        authorization_data = EXCLUDED.authorization_data,
+        expiration = EXCLUDED.expiration,
+        height = EXCLUDED.height,
+        tx_hash = EXCLUDED.tx_hash;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.apply_authz_grant_history() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM api.refresh_authz_grant(OLD.granter, OLD.grantee, OLD.msg_type_url);
+        RETURN OLD;
+    END IF;
+    PERFORM api.refresh_authz_grant(NEW.granter, NEW.grantee, NEW.msg_type_url);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_apply_authz_grant_history ON api.authz_grant_history;
+CREATE TRIGGER trg_apply_authz_grant_history
+AFTER INSERT OR DELETE ON api.authz_grant_history
+FOR EACH ROW EXECUTE FUNCTION api.apply_authz_grant_history();
+
+CREATE OR REPLACE FUNCTION api.update_authz_grants() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_authz_grants(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_authz_grants ON api.transactions_raw;
+CREATE TRIGGER trg_update_authz_grants
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_authz_grants();
+
+-- The grants that are not expired at the time of the latest indexed block
+CREATE OR REPLACE VIEW api.active_authz_grants AS
+SELECT g.*
+FROM api.authz_grants g
+WHERE g.expiration IS NULL
+   OR g.expiration > COALESCE((SELECT MAX(block_time) FROM api.blocks_raw), NOW());
+
+-- Read access for PostgREST
+GRANT SELECT ON api.authz_grant_history TO web_anon;
+GRANT SELECT ON api.authz_grants TO web_anon;
+GRANT SELECT ON api.active_authz_grants TO web_anon;
+
+COMMIT;
@@ -0,0 +1,12 @@
+-- Migration 047 down: Remove account_sequences table
+
+BEGIN;
+
+DROP VIEW IF EXISTS api.duplicate_sequences;
+DROP TRIGGER IF EXISTS trg_apply_tx_signer_sequence ON api.tx_signers;
+DROP FUNCTION IF EXISTS api.apply_tx_signer_sequence();
+DROP FUNCTION IF EXISTS api.refresh_account_sequence(TEXT);
+DROP TABLE IF EXISTS api.account_sequences;
+DROP INDEX IF EXISTS api.idx_tx_signers_address_sequence;
+
+COMMIT;
@@ -0,0 +1,79 @@
+-- Migration 047: Add account_sequences table
+--
+-- The latest sequence observed for each account in the signer infos of its
+-- transactions, maintained from api.tx_signers, along with the transaction
+-- that used it. The sequences used by several transactions of the same
+-- signer, e.g., replayed or double-submitted transactions, are listed by the
+-- api.duplicate_sequences view.
+
+BEGIN;
+
+CREATE INDEX IF NOT EXISTS idx_tx_signers_address_sequence ON api.tx_signers(address, sequence) WHERE address IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS api.account_sequences (
+    address TEXT PRIMARY KEY,
+    sequence BIGINT NOT NULL,
+    height BIGINT,
+    tx_hash TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_sequences_height ON api.account_sequences(height);
+
+-- Sets the latest sequence of an account from its signer infos
+CREATE OR REPLACE FUNCTION api.refresh_account_sequence(_address TEXT) RETURNS VOID AS $$
+DECLARE
+    _latest RECORD;
+BEGIN
+    SELECT sequence, height, tx_hash INTO _latest
+    FROM api.tx_signers
+    WHERE address = _address AND sequence IS NOT NULL
+    ORDER BY sequence DESC, height DESC
+    LIMIT 1;
+
+    IF NOT FOUND THEN
+        DELETE FROM api.account_sequences WHERE address = _address;
+        RETURN;
+    END IF;
+
+    INSERT INTO api.account_sequences (address, sequence, height, tx_hash)
+    VALUES (_address, _latest.sequence, _latest.height, _latest.tx_hash)
+    ON CONFLICT (address) DO UPDATE SET
+        sequence = EXCLUDED.sequence,
+        height = EXCLUDED.height,
+        tx_hash = EXCLUDED.tx_hash;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.apply_tx_signer_sequence() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        IF OLD.address IS NOT NULL THEN
+            PERFORM api.refresh_account_sequence(OLD.address);
+        END IF;
+        RETURN OLD;
+    END IF;
+    IF NEW.address IS NOT NULL THEN
+        PERFORM api.refresh_account_sequence(NEW.address);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_apply_tx_signer_sequence ON api.tx_signers;
+CREATE TRIGGER trg_apply_tx_signer_sequence
+AFTER INSERT OR DELETE ON api.tx_signers
+FOR EACH ROW EXECUTE FUNCTION api.apply_tx_signer_sequence();
+
+-- The sequences used by more than one transaction of the same signer
+CREATE OR REPLACE VIEW api.duplicate_sequences AS
+SELECT address, sequence, COUNT(*) AS transactions, array_agg(tx_hash ORDER BY height, tx_hash) AS tx_hashes
+FROM api.tx_signers
+WHERE address IS NOT NULL AND sequence IS NOT NULL
+GROUP BY address, sequence
+HAVING COUNT(*) > 1;
+
+-- Read access for PostgREST
+GRANT SELECT ON api.account_sequences TO web_anon;
+GRANT SELECT ON api.duplicate_sequences TO web_anon;
+
+COMMIT;
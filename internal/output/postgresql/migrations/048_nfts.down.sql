@@ -0,0 +1,14 @@
+-- Migration 048 down: Remove NFT tables
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_update_nft_history ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_nft_history();
+DROP TRIGGER IF EXISTS trg_apply_nft_history ON api.nft_history;
+DROP FUNCTION IF EXISTS api.apply_nft_history();
+DROP FUNCTION IF EXISTS api.refresh_nft_owner(TEXT, TEXT);
+DROP FUNCTION IF EXISTS api.extract_nft_history(TEXT, JSONB);
+DROP TABLE IF EXISTS api.nft_owners;
+DROP TABLE IF EXISTS api.nft_history;
+
+COMMIT;
@@ -0,0 +1,167 @@
+-- Migration 048: Add NFT tables
+--
+-- The provenance history of the non-fungible tokens of the successful
+-- transactions, one row per mint, transfer or burn, from:
+--   - the EventMint, EventSend and EventBurn typed events of the x/nft
+--     module, keyed by class ID and NFT ID
+--   - the wasm events of the CW721 contracts, i.e., those with a token_id
+--     attribute and a mint, transfer_nft, send_nft or burn action, whose
+--     class ID is the address of the contract
+--
+-- The current owner of each token is maintained in api.nft_owners from the
+-- latest entry of its history; burned tokens have no owner.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.nft_history (
+    id BIGSERIAL PRIMARY KEY,
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    height BIGINT,
+    event_index INTEGER NOT NULL,
+    msg_index INTEGER,
+    standard TEXT NOT NULL,
+    class_id TEXT NOT NULL,
+    token_id TEXT NOT NULL,
+    action TEXT NOT NULL,
+    sender TEXT,
+    recipient TEXT,
+    UNIQUE (tx_hash, event_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_nft_history_token ON api.nft_history(class_id, token_id, height);
+CREATE INDEX IF NOT EXISTS idx_nft_history_sender ON api.nft_history(sender, height) WHERE sender IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_nft_history_recipient ON api.nft_history(recipient, height) WHERE recipient IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS api.nft_owners (
+    class_id TEXT NOT NULL,
+    token_id TEXT NOT NULL,
+    standard TEXT NOT NULL,
+    owner TEXT,
+    burned BOOLEAN NOT NULL DEFAULT FALSE,
+    height BIGINT,
+    tx_hash TEXT,
+    PRIMARY KEY (class_id, token_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_nft_owners_owner ON api.nft_owners(owner) WHERE owner IS NOT NULL;
+
+-- Replaces the NFT history of a transaction
+CREATE OR REPLACE FUNCTION api.extract_nft_history(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+BEGIN
+    DELETE FROM api.nft_history WHERE tx_hash = _tx_hash;
+
+    IF COALESCE((_data->'txResponse'->>'code')::INTEGER, 0) <> 0 THEN
+        RETURN;
+    END IF;
+
+    WITH events AS (
+        SELECT (e.ordinality - 1)::INTEGER AS event_index, e.value->>'type' AS type, attrs.attrs
+        FROM jsonb_array_elements(COALESCE(_data->'txResponse'->'events', '[]'::JSONB)) WITH ORDINALITY e
+        CROSS JOIN LATERAL (
+            SELECT COALESCE(jsonb_object_agg(a->>'key', api.typed_event_attribute(e.value, a->>'key')), '{}'::JSONB) AS attrs
+            FROM jsonb_array_elements(COALESCE(e.value->'attributes', '[]'::JSONB)) a
+            WHERE a->>'key' IS NOT NULL
+        ) attrs
+        WHERE e.value->>'type' IN ('cosmos.nft.v1beta1.EventMint', 'cosmos.nft.v1beta1.EventSend', 'cosmos.nft.v1beta1.EventBurn', 'wasm')
+    )
+    INSERT INTO api.nft_history (tx_hash, height, event_index, msg_index, standard, class_id, token_id, action, sender, recipient)
+    SELECT _tx_hash, (_data->'txResponse'->>'height')::BIGINT, ev.event_index,
+           CASE WHEN ev.attrs->>'msg_index' ~ '^[0-9]+$' THEN (ev.attrs->>'msg_index')::INTEGER END,
+           n.standard, n.class_id, n.token_id, n.action, n.sender, n.recipient
+    FROM events ev
+    CROSS JOIN LATERAL (
+        SELECT 'nft' AS standard, ev.attrs->>'class_id' AS class_id, ev.attrs->>'id' AS token_id,
+               CASE ev.type
+                   WHEN 'cosmos.nft.v1beta1.EventMint' THEN 'mint'
+                   WHEN 'cosmos.nft.v1beta1.EventSend' THEN 'transfer'
+                   ELSE 'burn'
+               END AS action,
+               CASE ev.type
+                   WHEN 'cosmos.nft.v1beta1.EventSend' THEN ev.attrs->>'sender'
+                   WHEN 'cosmos.nft.v1beta1.EventBurn' THEN ev.attrs->>'owner'
+               END AS sender,
+               CASE ev.type
+                   WHEN 'cosmos.nft.v1beta1.EventSend' THEN ev.attrs->>'receiver'
+                   WHEN 'cosmos.nft.v1beta1.EventMint' THEN ev.attrs->>'owner'
+               END AS recipient
+        WHERE ev.type <> 'wasm'
+        UNION ALL
+        SELECT 'cw721', ev.attrs->>'_contract_address', ev.attrs->>'token_id',
+               CASE ev.attrs->>'action'
+                   WHEN 'mint' THEN 'mint'
+                   WHEN 'transfer_nft' THEN 'transfer'
+                   WHEN 'send_nft' THEN 'send'
+                   ELSE 'burn'
+               END,
+               CASE WHEN ev.attrs->>'action' <> 'mint' THEN ev.attrs->>'sender' END,
+               CASE WHEN ev.attrs->>'action' = 'mint' THEN ev.attrs->>'owner' WHEN ev.attrs->>'action' <> 'burn' THEN ev.attrs->>'recipient' END
+        WHERE ev.type = 'wasm'
+          AND ev.attrs ? 'token_id'
+          AND ev.attrs->>'action' IN ('mint', 'transfer_nft', 'send_nft', 'burn')
+    ) n
+    WHERE n.class_id IS NOT NULL AND n.token_id IS NOT NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Sets the current owner of a token from the latest entry of its history
+CREATE OR REPLACE FUNCTION api.refresh_nft_owner(_class_id TEXT, _token_id TEXT) RETURNS VOID AS $$
+DECLARE
+    _latest api.nft_history;
+BEGIN
+    SELECT * INTO _latest
+    FROM api.nft_history
+    WHERE class_id = _class_id AND token_id = _token_id
+    ORDER BY height DESC, tx_hash DESC, event_index DESC
+    LIMIT 1;
+
+    IF _latest.id IS NULL THEN
+        DELETE FROM api.nft_owners WHERE class_id = _class_id AND token_id = _token_id;
+        RETURN;
+    END IF;
+
+    INSERT INTO api.nft_owners (class_id, token_id, standard, owner, burned, height, tx_hash)
+    VALUES (_class_id, _token_id, _latest.standard,
+            CASE WHEN _latest.action = 'burn' THEN NULL ELSE _latest.recipient END,
+            _latest.action = 'burn', _latest.height, _latest.tx_hash)
+    ON CONFLICT (class_id, token_id) DO UPDATE SET
+        standard = EXCLUDED.standard,
+        owner = EXCLUDED.owner,
+        burned = EXCLUDED.burned,
+        height = EXCLUDED.height,
+        tx_hash = EXCLUDED.tx_hash;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.apply_nft_history() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM api.refresh_nft_owner(OLD.class_id, OLD.token_id);
+        RETURN OLD;
+    END IF;
+    PERFORM api.refresh_nft_owner(NEW.class_id, NEW.token_id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_apply_nft_history ON api.nft_history;
+CREATE TRIGGER trg_apply_nft_history
+AFTER INSERT OR DELETE ON api.nft_history
+FOR EACH ROW EXECUTE FUNCTION api.apply_nft_history();
+
+CREATE OR REPLACE FUNCTION api.update_nft_history() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_nft_history(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_nft_history ON api.transactions_raw;
+CREATE TRIGGER trg_update_nft_history
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_nft_history();
+
+-- Read access for PostgREST
+GRANT SELECT ON api.nft_history TO web_anon;
+GRANT SELECT ON api.nft_owners TO web_anon;
+
+COMMIT;
@@ -0,0 +1,10 @@
+-- Migration 049 down: Remove vote_extensions table
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_update_vote_extensions ON api.blocks_raw;
+DROP FUNCTION IF EXISTS api.update_vote_extensions();
+DROP FUNCTION IF EXISTS api.extract_vote_extensions(BIGINT, JSONB);
+DROP TABLE IF EXISTS api.vote_extensions;
+
+COMMIT;
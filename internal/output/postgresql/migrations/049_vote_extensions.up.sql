@@ -0,0 +1,63 @@
+-- Migration 049: Add vote_extensions table
+--
+-- Flattens the extended commit info decoded by the indexer with
+-- --vote-extension-decoder into one row per vote. The extended commit info
+-- of a block holds the votes, and their extensions, of the previous height.
+-- The validator address is the uppercase hexadecimal consensus address, like
+-- api.blocks_raw.proposer_address. The decoded extension is NULL when the
+-- vote has no extension, or when it failed to decode, with the error in
+-- decode_error.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.vote_extensions (
+    block_height BIGINT NOT NULL REFERENCES api.blocks_raw(id) ON DELETE CASCADE,
+    vote_index INTEGER NOT NULL,
+    height BIGINT NOT NULL,
+    round INTEGER,
+    validator_address TEXT,
+    power BIGINT,
+    block_id_flag TEXT,
+    vote_extension TEXT,
+    decoded JSONB,
+    decode_error TEXT,
+    PRIMARY KEY (block_height, vote_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_vote_extensions_height ON api.vote_extensions(height);
+CREATE INDEX IF NOT EXISTS idx_vote_extensions_validator ON api.vote_extensions(validator_address, height);
+
+-- Replaces the vote extensions of a block
+CREATE OR REPLACE FUNCTION api.extract_vote_extensions(_block_height BIGINT, _data JSONB) RETURNS VOID AS $$
+BEGIN
+    DELETE FROM api.vote_extensions WHERE block_height = _block_height;
+
+    INSERT INTO api.vote_extensions (block_height, vote_index, height, round, validator_address, power, block_id_flag, vote_extension, decoded, decode_error)
+    SELECT _block_height, (v.ordinality - 1)::INTEGER, _block_height - 1,
+           (_data->'extendedCommitInfo'->>'round')::INTEGER,
+           upper(encode(api.decode_base64(v.value->'validator'->>'address'), 'hex')),
+           (v.value->'validator'->>'power')::BIGINT,
+           v.value->>'blockIdFlag',
+           v.value->>'voteExtension',
+           v.value->'decoded',
+           v.value->>'decodeError'
+    FROM jsonb_array_elements(COALESCE(_data->'extendedCommitInfo'->'votes', '[]'::JSONB)) WITH ORDINALITY v;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_vote_extensions() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_vote_extensions(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_vote_extensions ON api.blocks_raw;
+CREATE TRIGGER trg_update_vote_extensions
+AFTER INSERT OR UPDATE OF data ON api.blocks_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_vote_extensions();
+
+-- Read access for PostgREST
+GRANT SELECT ON api.vote_extensions TO web_anon;
+
+COMMIT;
@@ -0,0 +1,9 @@
+-- Migration 050 down: Remove the raw protobuf responses from the raw tables
+
+BEGIN;
+
+ALTER TABLE api.block_results_raw DROP COLUMN IF EXISTS raw;
+ALTER TABLE api.transactions_raw DROP COLUMN IF EXISTS raw;
+ALTER TABLE api.blocks_raw DROP COLUMN IF EXISTS raw;
+
+COMMIT;
@@ -0,0 +1,16 @@
+-- Migration 050: Add the raw protobuf responses to the raw tables
+--
+-- With --store-raw-protobuf, the block, transaction and block results
+-- responses are stored as the protobuf bytes sent by the node, along with
+-- their JSON rendering, for the consumers needing lossless data, e.g., to
+-- verify the signatures of the transactions. The column is NULL when the
+-- option is disabled, for the block results fetched from the CometBFT RPC, and
+-- for the transactions fetched with a transaction events query.
+
+BEGIN;
+
+ALTER TABLE api.blocks_raw ADD COLUMN IF NOT EXISTS raw BYTEA;
+ALTER TABLE api.transactions_raw ADD COLUMN IF NOT EXISTS raw BYTEA;
+ALTER TABLE api.block_results_raw ADD COLUMN IF NOT EXISTS raw BYTEA;
+
+COMMIT;
@@ -0,0 +1,17 @@
+-- Migration 051 down: Remove the decoded transaction extension options
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_update_tx_extension_options ON api.transactions_raw;
+DROP FUNCTION IF EXISTS api.update_tx_extension_options();
+DROP FUNCTION IF EXISTS api.extract_tx_extension_options(TEXT, JSONB);
+DROP TABLE IF EXISTS api.tx_extension_options;
+
+ALTER TABLE api.transactions_raw
+    DROP COLUMN IF EXISTS unordered,
+    DROP COLUMN IF EXISTS timeout_timestamp,
+    DROP COLUMN IF EXISTS timeout_height;
+
+DROP FUNCTION IF EXISTS api.parse_timestamp(TEXT);
+
+COMMIT;
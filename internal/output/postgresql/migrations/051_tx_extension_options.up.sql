@@ -0,0 +1,99 @@
+-- Migration 051: Decode the transaction extension options
+--
+-- Exposes the timeout of the transactions as columns of transactions_raw:
+-- their timeout height, and the timeout timestamp and unordered flag of the
+-- unordered transactions of the Cosmos SDK 0.53.
+--
+-- The extension options and non-critical extension options of the
+-- transaction bodies are normalized into api.tx_extension_options, one row
+-- per option, with the fields of the known options decoded into columns:
+--   - the maximum priority price of the dynamic fee options of Ethermint and
+--     Cosmos EVM
+--   - the chain ID and the fee payer of the Web3 options of Ethermint and
+--     Injective, used by the transactions signed with EIP-712
+-- The other options are kept with their JSON value only.
+
+BEGIN;
+
+-- Parses an RFC 3339 timestamp, or returns NULL if it is not valid. The
+-- timestamps of the transactions always have an offset, which makes their
+-- parsing independent of the time zone of the session.
+CREATE OR REPLACE FUNCTION api.parse_timestamp(_value TEXT) RETURNS TIMESTAMPTZ AS $$
+BEGIN
+    RETURN _value::TIMESTAMPTZ;
+EXCEPTION WHEN OTHERS THEN
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+ALTER TABLE api.transactions_raw
+    ADD COLUMN IF NOT EXISTS timeout_height BIGINT
+        GENERATED ALWAYS AS (NULLIF((data->'tx'->'body'->>'timeoutHeight')::BIGINT, 0)) STORED,
+    ADD COLUMN IF NOT EXISTS timeout_timestamp TIMESTAMPTZ
+        GENERATED ALWAYS AS (api.parse_timestamp(data->'tx'->'body'->>'timeoutTimestamp')) STORED,
+    ADD COLUMN IF NOT EXISTS unordered BOOLEAN
+        GENERATED ALWAYS AS (COALESCE((data->'tx'->'body'->>'unordered')::BOOLEAN, FALSE)) STORED;
+
+CREATE TABLE IF NOT EXISTS api.tx_extension_options (
+    tx_hash TEXT NOT NULL REFERENCES api.transactions_raw(id) ON DELETE CASCADE,
+    option_index INTEGER NOT NULL,
+    critical BOOLEAN NOT NULL,
+    height BIGINT,
+    type_url TEXT,
+    max_priority_price NUMERIC,
+    typed_data_chain_id NUMERIC,
+    fee_payer TEXT,
+    value JSONB NOT NULL,
+    PRIMARY KEY (tx_hash, critical, option_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tx_extension_options_type ON api.tx_extension_options(type_url, height);
+CREATE INDEX IF NOT EXISTS idx_tx_extension_options_fee_payer ON api.tx_extension_options(fee_payer) WHERE fee_payer IS NOT NULL;
+
+-- Replaces the extension options of a transaction
+CREATE OR REPLACE FUNCTION api.extract_tx_extension_options(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+BEGIN
+    DELETE FROM api.tx_extension_options WHERE tx_hash = _tx_hash;
+
+    INSERT INTO api.tx_extension_options (
+        tx_hash, option_index, critical, height, type_url, max_priority_price, typed_data_chain_id, fee_payer, value
+    )
+    SELECT
+        _tx_hash,
+        (o.ordinality - 1)::INTEGER,
+        opts.critical,
+        (_data->'txResponse'->>'height')::BIGINT,
+        o.value->>'@type',
+        CASE WHEN o.value->>'@type' IN ('/ethermint.types.v1.ExtensionOptionDynamicFeeTx', '/cosmos.evm.types.v1.ExtensionOptionDynamicFeeTx')
+             AND o.value->>'maxPriorityPrice' ~ '^[0-9]+$'
+            THEN (o.value->>'maxPriorityPrice')::NUMERIC
+        END,
+        CASE WHEN o.value->>'@type' IN ('/ethermint.types.v1.ExtensionOptionsWeb3Tx', '/injective.types.v1beta1.ExtensionOptionsWeb3Tx')
+             AND o.value->>'typedDataChainId' ~ '^[0-9]+$'
+            THEN (o.value->>'typedDataChainId')::NUMERIC
+        END,
+        CASE WHEN o.value->>'@type' IN ('/ethermint.types.v1.ExtensionOptionsWeb3Tx', '/injective.types.v1beta1.ExtensionOptionsWeb3Tx')
+            THEN NULLIF(o.value->>'feePayer', '')
+        END,
+        o.value - '@type'
+    FROM (VALUES (TRUE, 'extensionOptions'), (FALSE, 'nonCriticalExtensionOptions')) opts(critical, field)
+    CROSS JOIN LATERAL jsonb_array_elements(COALESCE(_data->'tx'->'body'->opts.field, '[]'::JSONB)) WITH ORDINALITY o;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION api.update_tx_extension_options() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM api.extract_tx_extension_options(NEW.id, NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_update_tx_extension_options ON api.transactions_raw;
+CREATE TRIGGER trg_update_tx_extension_options
+AFTER INSERT OR UPDATE ON api.transactions_raw
+FOR EACH ROW EXECUTE FUNCTION api.update_tx_extension_options();
+
+-- Read access for PostgREST
+GRANT SELECT ON api.tx_extension_options TO web_anon;
+
+COMMIT;
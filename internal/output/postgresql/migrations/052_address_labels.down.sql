@@ -0,0 +1,13 @@
+-- Migration 052 down: Remove address_labels table and labeled views
+
+BEGIN;
+
+DROP VIEW IF EXISTS api.labeled_proposal_votes;
+DROP VIEW IF EXISTS api.labeled_address_transactions;
+DROP VIEW IF EXISTS api.labeled_balances;
+DROP VIEW IF EXISTS api.labeled_transfers;
+DROP FUNCTION IF EXISTS api.address_label(TEXT);
+DROP VIEW IF EXISTS api.known_addresses;
+DROP TABLE IF EXISTS api.address_labels;
+
+COMMIT;
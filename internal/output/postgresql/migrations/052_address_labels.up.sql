@@ -0,0 +1,76 @@
+-- Migration 052: Add address_labels table and labeled views
+--
+-- The human-readable labels of known addresses, e.g., exchanges, module
+-- accounts and team wallets, imported by the labels command from CSV files
+-- and from the configuration file. api.known_addresses adds the monikers of
+-- the validators to the imported labels, which take precedence, and the
+-- labeled views join them to the addresses of the derived tables.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.address_labels (
+    address TEXT PRIMARY KEY,
+    label TEXT NOT NULL,
+    category TEXT,
+    source TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_address_labels_category ON api.address_labels(category);
+CREATE INDEX IF NOT EXISTS idx_address_labels_source ON api.address_labels(source);
+
+CREATE OR REPLACE VIEW api.known_addresses AS
+SELECT address, label, category, source
+FROM api.address_labels
+UNION ALL
+SELECT v.operator_address, v.moniker, 'validator', 'validators'
+FROM api.validators v
+WHERE COALESCE(v.moniker, '') <> ''
+  AND NOT EXISTS (SELECT 1 FROM api.address_labels l WHERE l.address = v.operator_address);
+
+-- Returns the label of an address, or NULL when it is unknown
+CREATE OR REPLACE FUNCTION api.address_label(_address TEXT) RETURNS TEXT AS $$
+    SELECT label FROM api.known_addresses WHERE address = _address LIMIT 1;
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE VIEW api.labeled_transfers AS
+SELECT
+    t.*,
+    s.label AS sender_label,
+    r.label AS recipient_label
+FROM api.transfers t
+LEFT JOIN api.known_addresses s ON s.address = t.sender
+LEFT JOIN api.known_addresses r ON r.address = t.recipient;
+
+CREATE OR REPLACE VIEW api.labeled_balances AS
+SELECT
+    b.*,
+    k.label,
+    k.category
+FROM api.balances b
+LEFT JOIN api.known_addresses k ON k.address = b.address;
+
+CREATE OR REPLACE VIEW api.labeled_address_transactions AS
+SELECT
+    a.*,
+    k.label,
+    k.category
+FROM api.address_transactions a
+LEFT JOIN api.known_addresses k ON k.address = a.address;
+
+CREATE OR REPLACE VIEW api.labeled_proposal_votes AS
+SELECT
+    v.*,
+    k.label AS voter_label
+FROM api.proposal_votes v
+LEFT JOIN api.known_addresses k ON k.address = v.voter;
+
+-- Read access for PostgREST
+GRANT SELECT ON api.address_labels TO web_anon;
+GRANT SELECT ON api.known_addresses TO web_anon;
+GRANT SELECT ON api.labeled_transfers TO web_anon;
+GRANT SELECT ON api.labeled_balances TO web_anon;
+GRANT SELECT ON api.labeled_address_transactions TO web_anon;
+GRANT SELECT ON api.labeled_proposal_votes TO web_anon;
+
+COMMIT;
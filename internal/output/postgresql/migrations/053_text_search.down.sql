@@ -0,0 +1,14 @@
+-- Migration 053 down: Remove the full-text search on memos and events
+
+BEGIN;
+
+DROP FUNCTION IF EXISTS api.search_text(TEXT, INTEGER);
+DROP FUNCTION IF EXISTS api.text_search_enabled();
+
+ALTER TABLE api.events DROP COLUMN IF EXISTS attribute_value_tsv;
+ALTER TABLE api.transactions_raw DROP COLUMN IF EXISTS memo_tsv;
+
+DROP FUNCTION IF EXISTS api.disable_text_search();
+DROP FUNCTION IF EXISTS api.enable_text_search();
+
+COMMIT;
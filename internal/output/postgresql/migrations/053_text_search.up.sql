@@ -0,0 +1,91 @@
+-- Migration 053: Add optional full-text search on memos and events
+--
+-- api.enable_text_search() adds tsvector generated columns over the memos of
+-- api.transactions_raw and the attribute values of api.events, with their GIN
+-- indexes, for "find this string anywhere" searches. They are not added by
+-- default, since adding them rewrites both tables and the indexes take
+-- storage; the postgres subcommand enables them with --enable-text-search.
+--
+-- The simple configuration is used, which neither stems nor drops stop
+-- words, since the memos and the attribute values are mostly identifiers,
+-- addresses and amounts rather than natural language.
+
+BEGIN;
+
+-- Adds the tsvector columns and their indexes, if not already added
+CREATE OR REPLACE FUNCTION api.enable_text_search() RETURNS VOID AS $$
+BEGIN
+    ALTER TABLE api.transactions_raw
+        ADD COLUMN IF NOT EXISTS memo_tsv TSVECTOR
+            GENERATED ALWAYS AS (to_tsvector('simple', COALESCE(data->'tx'->'body'->>'memo', ''))) STORED;
+    CREATE INDEX IF NOT EXISTS idx_transactions_memo_tsv ON api.transactions_raw USING gin (memo_tsv);
+
+    ALTER TABLE api.events
+        ADD COLUMN IF NOT EXISTS attribute_value_tsv TSVECTOR
+            GENERATED ALWAYS AS (to_tsvector('simple', COALESCE(attribute_value, ''))) STORED;
+    CREATE INDEX IF NOT EXISTS idx_events_attribute_value_tsv ON api.events USING gin (attribute_value_tsv);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Drops the tsvector columns and their indexes
+CREATE OR REPLACE FUNCTION api.disable_text_search() RETURNS VOID AS $$
+BEGIN
+    ALTER TABLE api.transactions_raw DROP COLUMN IF EXISTS memo_tsv;
+    ALTER TABLE api.events DROP COLUMN IF EXISTS attribute_value_tsv;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Returns true if the tsvector columns were added
+CREATE OR REPLACE FUNCTION api.text_search_enabled() RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = 'api' AND table_name = 'transactions_raw' AND column_name = 'memo_tsv'
+    ) AND EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = 'api' AND table_name = 'events' AND column_name = 'attribute_value_tsv'
+    );
+$$ LANGUAGE sql STABLE;
+
+-- Returns the memos and the event attributes matching a web search query,
+-- e.g., '"exchange deposit" -refund', most relevant and most recent first
+CREATE OR REPLACE FUNCTION api.search_text(_query TEXT, _limit INTEGER DEFAULT 100)
+RETURNS TABLE (
+    source TEXT,
+    height BIGINT,
+    tx_hash TEXT,
+    event_type TEXT,
+    attribute_key TEXT,
+    text TEXT,
+    rank REAL
+) AS $$
+DECLARE
+    _tsquery TSQUERY;
+BEGIN
+    IF NOT api.text_search_enabled() THEN
+        RAISE EXCEPTION 'text search is not enabled, see api.enable_text_search()';
+    END IF;
+
+    _tsquery := websearch_to_tsquery('simple', _query);
+
+    -- The columns are referenced dynamically, since they only exist once text search is enabled
+    RETURN QUERY EXECUTE $query$
+        SELECT * FROM (
+            SELECT 'memo'::TEXT, (t.data->'txResponse'->>'height')::BIGINT, t.id, NULL::TEXT, NULL::TEXT, t.memo, ts_rank(t.memo_tsv, $1)
+            FROM api.transactions_raw t
+            WHERE t.memo_tsv @@ $1
+            UNION ALL
+            SELECT 'event'::TEXT, e.height, e.tx_hash, e.event_type, e.attribute_key, e.attribute_value, ts_rank(e.attribute_value_tsv, $1)
+            FROM api.events e
+            WHERE e.attribute_value_tsv @@ $1
+        ) matches
+        ORDER BY 7 DESC, 2 DESC NULLS LAST
+        LIMIT $2
+    $query$ USING _tsquery, _limit;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- Read access for PostgREST
+GRANT EXECUTE ON FUNCTION api.search_text(TEXT, INTEGER) TO web_anon;
+GRANT EXECUTE ON FUNCTION api.text_search_enabled() TO web_anon;
+
+COMMIT;
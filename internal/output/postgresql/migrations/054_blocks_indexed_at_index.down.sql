@@ -0,0 +1,7 @@
+-- Migration 054 down: Remove the index of the indexing time of the blocks
+
+BEGIN;
+
+DROP INDEX IF EXISTS api.idx_blocks_indexed_at;
+
+COMMIT;
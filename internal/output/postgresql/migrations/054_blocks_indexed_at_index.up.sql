@@ -0,0 +1,11 @@
+-- Migration 054: Index the indexing time of the blocks
+--
+-- The status command measures the indexing rate from the number of blocks
+-- committed during a recent window, which would otherwise scan the whole
+-- blocks table.
+
+BEGIN;
+
+CREATE INDEX IF NOT EXISTS idx_blocks_indexed_at ON api.blocks_raw(indexed_at) WHERE indexed_at IS NOT NULL;
+
+COMMIT;
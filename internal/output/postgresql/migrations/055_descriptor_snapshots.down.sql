@@ -0,0 +1,7 @@
+-- Migration 055 down: Remove descriptor_snapshots table
+
+BEGIN;
+
+DROP TABLE IF EXISTS api.descriptor_snapshots;
+
+COMMIT;
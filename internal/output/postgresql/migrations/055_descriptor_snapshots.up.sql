@@ -0,0 +1,25 @@
+-- Migration 055: Add descriptor_snapshots table
+--
+-- The protocol buffer descriptors the chain data was decoded with, stored by
+-- the extraction as serialized FileDescriptorSet along with the latest height
+-- of the node, whenever they differ from the latest snapshot, e.g., after an
+-- upgrade of the chain. The serve command answers the gRPC server reflection
+-- requests with the latest snapshot, even when the node is unreachable.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api.descriptor_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    height BIGINT NOT NULL,
+    hash TEXT NOT NULL,
+    file_count INTEGER NOT NULL,
+    descriptor_set BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_descriptor_snapshots_height ON api.descriptor_snapshots(height);
+
+-- Read access for PostgREST
+GRANT SELECT ON api.descriptor_snapshots TO web_anon;
+
+COMMIT;
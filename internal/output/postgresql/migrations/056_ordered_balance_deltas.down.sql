@@ -0,0 +1,33 @@
+-- Migration 056 down: Apply the balance deltas row by row again
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_apply_deleted_balance_deltas ON api.balance_deltas;
+DROP TRIGGER IF EXISTS trg_apply_inserted_balance_deltas ON api.balance_deltas;
+DROP FUNCTION IF EXISTS api.apply_deleted_balance_deltas();
+DROP FUNCTION IF EXISTS api.apply_inserted_balance_deltas();
+
+-- Applies the inserted and deleted deltas to the running balances
+CREATE OR REPLACE FUNCTION api.apply_balance_delta() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        UPDATE api.balances SET balance = balance - OLD.delta
+        WHERE address = OLD.address AND denom = OLD.denom;
+        RETURN OLD;
+    END IF;
+
+    INSERT INTO api.balances (address, denom, balance, updated_height)
+    VALUES (NEW.address, NEW.denom, NEW.delta, NEW.height)
+    ON CONFLICT (address, denom) DO UPDATE SET
+        balance = api.balances.balance + EXCLUDED.balance,
+        updated_height = GREATEST(api.balances.updated_height, EXCLUDED.updated_height);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_apply_balance_delta ON api.balance_deltas;
+CREATE TRIGGER trg_apply_balance_delta
+AFTER INSERT OR DELETE ON api.balance_deltas
+FOR EACH ROW EXECUTE FUNCTION api.apply_balance_delta();
+
+COMMIT;
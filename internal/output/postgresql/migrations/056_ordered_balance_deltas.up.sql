@@ -0,0 +1,62 @@
+-- Migration 056: Apply the balance deltas by statement, in the order of the balances
+--
+-- The balance deltas were applied to api.balances row by row, in the order of
+-- the events, so that the workers writing blocks concurrently could lock the
+-- same balances in opposite orders and deadlock. The deltas inserted or
+-- deleted by a statement, i.e., those of a transaction, of the finalize block
+-- events of a block or of a reconciliation, are now summed by address and
+-- denom and applied in the order of the address and the denom.
+
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_apply_balance_delta ON api.balance_deltas;
+DROP FUNCTION IF EXISTS api.apply_balance_delta();
+
+-- Applies the deltas inserted by a statement to the running balances
+CREATE OR REPLACE FUNCTION api.apply_inserted_balance_deltas() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO api.balances (address, denom, balance, updated_height)
+    SELECT address, denom, SUM(delta), MAX(height)
+    FROM inserted_deltas
+    GROUP BY address, denom
+    ORDER BY address, denom
+    ON CONFLICT (address, denom) DO UPDATE SET
+        balance = api.balances.balance + EXCLUDED.balance,
+        updated_height = GREATEST(api.balances.updated_height, EXCLUDED.updated_height);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Reverts the deltas deleted by a statement from the running balances, locking them in order first
+CREATE OR REPLACE FUNCTION api.apply_deleted_balance_deltas() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM 1
+    FROM api.balances b
+    WHERE (b.address, b.denom) IN (SELECT address, denom FROM deleted_deltas)
+    ORDER BY b.address, b.denom
+    FOR UPDATE;
+
+    UPDATE api.balances b SET balance = b.balance - d.delta
+    FROM (
+        SELECT address, denom, SUM(delta) AS delta
+        FROM deleted_deltas
+        GROUP BY address, denom
+    ) d
+    WHERE b.address = d.address AND b.denom = d.denom;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_apply_inserted_balance_deltas ON api.balance_deltas;
+CREATE TRIGGER trg_apply_inserted_balance_deltas
+AFTER INSERT ON api.balance_deltas
+REFERENCING NEW TABLE AS inserted_deltas
+FOR EACH STATEMENT EXECUTE FUNCTION api.apply_inserted_balance_deltas();
+
+DROP TRIGGER IF EXISTS trg_apply_deleted_balance_deltas ON api.balance_deltas;
+CREATE TRIGGER trg_apply_deleted_balance_deltas
+AFTER DELETE ON api.balance_deltas
+REFERENCING OLD TABLE AS deleted_deltas
+FOR EACH STATEMENT EXECUTE FUNCTION api.apply_deleted_balance_deltas();
+
+COMMIT;
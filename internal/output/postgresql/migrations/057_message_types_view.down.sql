@@ -0,0 +1,85 @@
+-- Migration 057 down: Maintain the message types in a table again
+
+BEGIN;
+
+DROP VIEW IF EXISTS api.message_types;
+
+CREATE TABLE IF NOT EXISTS api.message_types (
+    type_url TEXT PRIMARY KEY,
+    first_height BIGINT,
+    last_height BIGINT
+);
+
+INSERT INTO api.message_types (type_url, first_height, last_height)
+SELECT type_url, MIN(height), MAX(height)
+FROM api.messages
+GROUP BY type_url;
+
+-- Replaces the messages of a transaction, with the messages nested in MsgExec and in interchain accounts packets
+CREATE OR REPLACE FUNCTION api.extract_messages(_tx_hash TEXT, _data JSONB) RETURNS VOID AS $$
+DECLARE
+    _height BIGINT;
+BEGIN
+    _height := (_data->'txResponse'->>'height')::BIGINT;
+
+    DELETE FROM api.messages WHERE tx_hash = _tx_hash;
+
+    INSERT INTO api.messages (tx_hash, msg_index, type_url, height, data, parent_msg_index, depth, ica_port, ica_connection_id, ica_channel_id)
+    WITH RECURSIVE tree (path, parent_path, value, depth, ica_port, ica_connection_id, ica_channel_id) AS (
+        SELECT ARRAY[(m.ordinality - 1)::INTEGER], NULL::INTEGER[], m.value, 0, NULL::TEXT, NULL::TEXT, NULL::TEXT
+        FROM jsonb_array_elements(COALESCE(_data->'tx'->'body'->'messages', '[]'::JSONB)) WITH ORDINALITY m
+        UNION ALL
+        SELECT t.path || (m.ordinality - 1)::INTEGER, t.path, m.value, t.depth + 1, p.ica_port, p.ica_connection_id, p.ica_channel_id
+        FROM tree t
+        CROSS JOIN LATERAL (
+            SELECT
+                CASE t.value->>'@type'
+                    WHEN '/cosmos.authz.v1beta1.MsgExec' THEN t.value->'msgs'
+                    WHEN '/ibc.applications.interchain_accounts.controller.v1.MsgSendTx' THEN t.value->'icaMessages'
+                    WHEN '/ibc.core.channel.v1.MsgRecvPacket' THEN t.value->'icaMessages'
+                END AS msgs,
+                CASE t.value->>'@type'
+                    WHEN '/ibc.applications.interchain_accounts.controller.v1.MsgSendTx' THEN 'icacontroller-' || (t.value->>'owner')
+                    WHEN '/ibc.core.channel.v1.MsgRecvPacket' THEN t.value->'packet'->>'sourcePort'
+                    ELSE t.ica_port
+                END AS ica_port,
+                CASE t.value->>'@type'
+                    WHEN '/ibc.applications.interchain_accounts.controller.v1.MsgSendTx' THEN t.value->>'connectionId'
+                    WHEN '/ibc.core.channel.v1.MsgRecvPacket' THEN (
+                        SELECT c.connection_id FROM api.ibc_channels c
+                        WHERE c.port_id = t.value->'packet'->>'destinationPort'
+                          AND c.channel_id = t.value->'packet'->>'destinationChannel'
+                    )
+                    ELSE t.ica_connection_id
+                END AS ica_connection_id,
+                CASE t.value->>'@type'
+                    WHEN '/ibc.applications.interchain_accounts.controller.v1.MsgSendTx' THEN NULL
+                    WHEN '/ibc.core.channel.v1.MsgRecvPacket' THEN t.value->'packet'->>'destinationChannel'
+                    ELSE t.ica_channel_id
+                END AS ica_channel_id
+        ) p
+        CROSS JOIN LATERAL jsonb_array_elements(CASE WHEN jsonb_typeof(p.msgs) = 'array' THEN p.msgs ELSE '[]'::JSONB END) WITH ORDINALITY m
+    ),
+    numbered AS (
+        SELECT t.*, (ROW_NUMBER() OVER (ORDER BY t.depth > 0, t.path) - 1)::INTEGER AS msg_index
+        FROM tree t
+    )
+    SELECT _tx_hash, n.msg_index, n.value->>'@type', _height, n.value, p.msg_index, n.depth, n.ica_port, n.ica_connection_id, n.ica_channel_id
+    FROM numbered n
+    LEFT JOIN numbered p ON p.path = n.parent_path
+    WHERE n.value->>'@type' IS NOT NULL;
+
+    INSERT INTO api.message_types (type_url, first_height, last_height)
+    SELECT DISTINCT m.type_url, _height, _height
+    FROM api.messages m
+    WHERE m.tx_hash = _tx_hash
+    ON CONFLICT (type_url) DO UPDATE SET
+        first_height = LEAST(api.message_types.first_height, EXCLUDED.first_height),
+        last_height = GREATEST(api.message_types.last_height, EXCLUDED.last_height);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Read access for PostgREST
+GRANT SELECT ON api.message_types TO web_anon;
+
+COMMIT;
@@ -9,24 +9,43 @@ import (
 	"fmt"
 	"log/slog"
 	"regexp"
+	"sync"
+	"time"
 
-	"github.com/golang-migrate/migrate/v4"
-	migratepgx "github.com/golang-migrate/migrate/v4/database/pgx"
-	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/jackc/pgx/v5/stdlib"
 
+	"github.com/manifest-network/yaci/internal/addresses"
 	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
 )
 
 //go:embed migrations/*
 var migrationsFS embed.FS
 
 type PostgresOutputHandler struct {
-	pool *pgxpool.Pool
+	pool           *pgxpool.Pool
+	stopCompaction context.CancelFunc
+
+	// leaderConn is the dedicated connection holding the leadership advisory lock, nil if not leader
+	leaderConn *pgx.Conn
+	leaderMu   sync.Mutex
 }
 
+// The PostgreSQL output supports all the optional interfaces of the output handlers
+var (
+	_ output.OutputHandler     = (*PostgresOutputHandler)(nil)
+	_ output.GovWriter         = (*PostgresOutputHandler)(nil)
+	_ output.UpgradePlanWriter = (*PostgresOutputHandler)(nil)
+	_ output.SnapshotWriter    = (*PostgresOutputHandler)(nil)
+	_ output.StreamWriter      = (*PostgresOutputHandler)(nil)
+	_ output.ShardCoordinator  = (*PostgresOutputHandler)(nil)
+	_ output.LeaderElector     = (*PostgresOutputHandler)(nil)
+	_ output.ReorgRecorder     = (*PostgresOutputHandler)(nil)
+	_ output.RangeRecorder     = (*PostgresOutputHandler)(nil)
+)
+
 func (h *PostgresOutputHandler) GetPool() *pgxpool.Pool {
 	return h.pool
 }
@@ -46,9 +65,10 @@ func NewPostgresOutputHandler(connString string) (*PostgresOutputHandler, error)
 		pool: pool,
 	}
 
-	// Run migrations. This is idempotent.
-	if err = handler.runMigrations(); err != nil {
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	// The migrations are applied explicitly, by the migrate command or with auto-migrate
+	if err = handler.checkSchemaVersion(); err != nil {
+		pool.Close()
+		return nil, err
 	}
 
 	return handler, nil
@@ -88,15 +108,40 @@ func (h *PostgresOutputHandler) GetEarliestBlock(ctx context.Context) (*models.B
 	return &block, nil
 }
 
+// missingRangesQuery defines the gaps between processed, skipped and pruned ranges, as the inclusive ranges of the
+// gaps CTE. The ranges do not need to be compacted: each range is compared with the highest height processed before
+// it. The gaps are bounded by the extracted heights, the processed and pruned ranges, so that skipped ranges outside
+// of them do not make the heights never meant to be extracted look missing.
+const missingRangesQuery = `
+	WITH extracted AS (
+		SELECT start_height, end_height FROM api.processed_ranges
+		UNION ALL
+		SELECT start_height, end_height FROM api.pruned_ranges
+	), gaps AS (
+		SELECT previous_end + 1 AS start_height, start_height - 1 AS end_height
+		FROM (
+			SELECT start_height,
+				MAX(end_height) OVER (
+					ORDER BY start_height, end_height
+					ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+				) AS previous_end
+			FROM (
+				SELECT start_height, end_height FROM extracted
+				UNION ALL
+				SELECT start_height, end_height FROM api.skipped_ranges
+			) ranges
+		) r
+		WHERE start_height > previous_end + 1
+			AND previous_end >= (SELECT MIN(start_height) FROM extracted)
+			AND start_height <= (SELECT MAX(end_height) FROM extracted)
+	)
+`
+
+// GetMissingBlockIds returns the heights found in the gaps between processed, skipped and pruned ranges, within the
+// extracted heights.
 func (h *PostgresOutputHandler) GetMissingBlockIds(ctx context.Context) ([]uint64, error) {
-	rows, err := h.pool.Query(ctx, `
-		SELECT s.id
-		FROM generate_series(
-				 (SELECT MIN(id) FROM api.blocks_raw),
-				 (SELECT MAX(id) FROM api.blocks_raw)
-			 ) AS s(id)
-		LEFT JOIN api.blocks_raw t ON t.id = s.id
-		WHERE t.id IS NULL;
+	rows, err := h.pool.Query(ctx, missingRangesQuery+`
+		SELECT generate_series(start_height, end_height) FROM gaps;
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get missing block IDs: %w", err)
@@ -115,31 +160,113 @@ func (h *PostgresOutputHandler) GetMissingBlockIds(ctx context.Context) ([]uint6
 	return missing, nil
 }
 
-func (h *PostgresOutputHandler) WriteBlockWithTransactions(ctx context.Context, block *models.Block, transactions []*models.Transaction) error {
+// addressTransactionsQuery replaces the addresses linked to the transaction $1 with the addresses $2, at the height of
+// the transaction data $3.
+const addressTransactionsQuery = `
+	WITH removed AS (
+		DELETE FROM api.address_transactions WHERE tx_hash = $1 AND NOT (address = ANY(COALESCE($2::TEXT[], '{}')))
+	)
+	INSERT INTO api.address_transactions (address, tx_hash, height)
+	SELECT a, $1, ($3::JSONB->'txResponse'->>'height')::BIGINT
+	FROM unnest($2::TEXT[]) a
+	ON CONFLICT (address, tx_hash) DO UPDATE SET height = EXCLUDED.height;
+`
+
+// maxBlockWriteAttempts is the number of attempts of a block write failing on a serialization failure or a deadlock,
+// e.g., with the balances updated by the writes of concurrent workers.
+const maxBlockWriteAttempts = 5
+
+// WriteBlockWithTransactions writes a block, its transactions and its block results in a single database transaction.
+// Rows of the raw tables, and of any table derived from them by triggers, only become visible once the block row
+// commits, which makes api.blocks_raw usable as a per-height watermark by downstream consumers. The stored raw responses
+// are kept when the rows are written again without them, e.g., by a replay, unless the block replaces the stored one.
+// The database transaction is retried when it is rolled back on a serialization failure or a deadlock.
+func (h *PostgresOutputHandler) WriteBlockWithTransactions(ctx context.Context, block *models.Block, transactions []*models.Transaction, blockResults *models.BlockResults) error {
+	var err error
+	for attempt := 1; attempt <= maxBlockWriteAttempts; attempt++ {
+		err = h.writeBlockWithTransactions(ctx, block, transactions, blockResults)
+		if err == nil || !isRetryableTxError(err) {
+			return err
+		}
+		slog.Warn("Retrying block write", "height", block.ID, "attempt", attempt, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * 100 * time.Millisecond):
+		}
+	}
+	return err
+}
+
+// isRetryableTxError reports whether the database transaction failed on a serialization failure or a deadlock, after
+// which it can be retried.
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == "40001" || pgErr.Code == "40P01")
+}
+
+func (h *PostgresOutputHandler) writeBlockWithTransactions(ctx context.Context, block *models.Block, transactions []*models.Transaction, blockResults *models.BlockResults) error {
 	tx, err := h.pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx) // Ensure rollback if commit is not reached
 
-	// Write block
+	if output.ReplacesBlocks(ctx) {
+		if err = deleteBlock(ctx, tx, block.ID); err != nil {
+			return err
+		}
+	}
+
+	// Track the processed height
 	_, err = tx.Exec(ctx, `
-		INSERT INTO api.blocks_raw (id, data) VALUES ($1, $2)
-		ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data;
-	`, block.ID, block.Data)
+		INSERT INTO api.processed_ranges (start_height, end_height) VALUES ($1, $1);
+	`, block.ID)
 	if err != nil {
-		return fmt.Errorf("failed to write blockchain block: %w", err)
+		return fmt.Errorf("failed to track processed height: %w", err)
 	}
 
 	// Write transactions
 	for _, txData := range transactions {
 		_, err = tx.Exec(ctx, `
-			INSERT INTO api.transactions_raw (id, data) VALUES ($1, $2)
-			ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data;
-		`, txData.Hash, txData.Data)
+			INSERT INTO api.transactions_raw (id, data, raw) VALUES ($1, $2, $3)
+			ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data, raw = COALESCE(EXCLUDED.raw, api.transactions_raw.raw);
+		`, txData.Hash, txData.Data, txData.Raw)
 		if err != nil {
 			return fmt.Errorf("failed to write blockchain transaction: %w", err)
 		}
+		if _, err = tx.Exec(ctx, addressTransactionsQuery, txData.Hash, addresses.Find(txData.Data), txData.Data); err != nil {
+			return fmt.Errorf("failed to write transaction addresses: %w", err)
+		}
+	}
+
+	// Write block results
+	if blockResults != nil {
+		if err = writeBlockResults(ctx, tx, blockResults); err != nil {
+			return err
+		}
+	}
+
+	var blockTime *time.Time
+	if !block.Time.IsZero() {
+		blockTime = &block.Time
+	}
+
+	// Write block last, so that its indexing time is as close as possible to the commit
+	err = tx.QueryRow(ctx, `
+		INSERT INTO api.blocks_raw (id, data, raw, hash, parent_hash, block_time, indexed_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, clock_timestamp())
+		ON CONFLICT (id) DO UPDATE SET
+			data = EXCLUDED.data,
+			raw = COALESCE(EXCLUDED.raw, api.blocks_raw.raw),
+			hash = EXCLUDED.hash,
+			parent_hash = EXCLUDED.parent_hash,
+			block_time = EXCLUDED.block_time,
+			indexed_at = EXCLUDED.indexed_at
+		RETURNING indexed_at;
+	`, block.ID, block.Data, block.Raw, block.Hash, block.ParentHash, blockTime).Scan(&block.IndexedAt)
+	if err != nil {
+		return fmt.Errorf("failed to write blockchain block: %w", err)
 	}
 
 	// Commit transaction
@@ -163,52 +290,132 @@ func sanitizeJSONForPostgres(data []byte) []byte {
 	return data
 }
 
-func (h *PostgresOutputHandler) WriteBlockResults(ctx context.Context, blockResults *models.BlockResults) error {
+// writeBlockResults writes block results within the given database transaction.
+func writeBlockResults(ctx context.Context, tx pgx.Tx, blockResults *models.BlockResults) error {
 	// Sanitize JSON data to remove null bytes and invalid Unicode sequences
 	// that PostgreSQL JSONB doesn't accept
 	sanitizedData := sanitizeJSONForPostgres(blockResults.Data)
 
-	_, err := h.pool.Exec(ctx, `
-		INSERT INTO api.block_results_raw (height, data) VALUES ($1, $2)
-		ON CONFLICT (height) DO UPDATE SET data = EXCLUDED.data;
-	`, blockResults.Height, sanitizedData)
+	_, err := tx.Exec(ctx, `
+		INSERT INTO api.block_results_raw (height, data, raw) VALUES ($1, $2, $3)
+		ON CONFLICT (height) DO UPDATE SET data = EXCLUDED.data, raw = COALESCE(EXCLUDED.raw, api.block_results_raw.raw);
+	`, blockResults.Height, sanitizedData, blockResults.Raw)
 	if err != nil {
 		return fmt.Errorf("failed to write block results: %w", err)
 	}
 	return nil
 }
 
-func (h *PostgresOutputHandler) runMigrations() error {
-	// Create tables if they don't exist
-	slog.Info("Running PostgreSQL migrations...")
+func (h *PostgresOutputHandler) WriteGovProposals(ctx context.Context, proposals []*models.GovProposal) error {
+	batch := &pgx.Batch{}
+	for _, p := range proposals {
+		batch.Queue(`
+			INSERT INTO api.gov_proposals (id, status, data, tally, deposits, updated_height, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, NOW())
+			ON CONFLICT (id) DO UPDATE SET
+				status = EXCLUDED.status,
+				data = EXCLUDED.data,
+				tally = COALESCE(EXCLUDED.tally, api.gov_proposals.tally),
+				deposits = COALESCE(EXCLUDED.deposits, api.gov_proposals.deposits),
+				updated_height = EXCLUDED.updated_height,
+				updated_at = EXCLUDED.updated_at;
+		`, p.ID, p.Status, sanitizeJSONForPostgres(p.Data), nullableJSON(p.Tally), nullableJSON(p.Deposits), p.Height)
+	}
 
-	d, err := iofs.New(migrationsFS, "migrations")
-	if err != nil {
-		return fmt.Errorf("failed to create migration source: %w", err)
+	if err := h.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to write governance proposals: %w", err)
 	}
+	return nil
+}
 
-	driver, err := migratepgx.WithInstance(stdlib.OpenDBFromPool(h.pool), &migratepgx.Config{})
-	if err != nil {
-		return fmt.Errorf("failed to create migration driver: %w", err)
+// WriteUpgradePlan inserts or updates a scheduled upgrade plan, keeping the height at which it was first seen.
+func (h *PostgresOutputHandler) WriteUpgradePlan(ctx context.Context, plan *models.UpgradePlan) error {
+	var appliedHeight *uint64
+	if plan.AppliedHeight != 0 {
+		appliedHeight = &plan.AppliedHeight
 	}
 
-	m, err := migrate.NewWithInstance("iofs", d, "postgres", driver)
+	_, err := h.pool.Exec(ctx, `
+		INSERT INTO api.upgrade_plans (name, height, info, data, first_seen_height, applied_height, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (name) DO UPDATE SET
+			height = EXCLUDED.height,
+			info = EXCLUDED.info,
+			data = EXCLUDED.data,
+			first_seen_height = LEAST(api.upgrade_plans.first_seen_height, EXCLUDED.first_seen_height),
+			applied_height = COALESCE(EXCLUDED.applied_height, api.upgrade_plans.applied_height),
+			updated_at = EXCLUDED.updated_at;
+	`, plan.Name, plan.Height, plan.Info, sanitizeJSONForPostgres(plan.Data), plan.FirstSeenHeight, appliedHeight)
 	if err != nil {
-		return fmt.Errorf("failed to create migration instance: %w", err)
+		return fmt.Errorf("failed to write upgrade plan: %w", err)
 	}
-	defer m.Close()
+	return nil
+}
 
-	// Run migrations
-	if err = m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return fmt.Errorf("failed to run migrations: %w", err)
+// nullableJSON returns nil for empty JSON so that it is stored as SQL NULL.
+func nullableJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
 	}
-
-	return nil
+	return sanitizeJSONForPostgres(data)
 }
 
 func (h *PostgresOutputHandler) Close() error {
+	if h.stopCompaction != nil {
+		h.stopCompaction()
+	}
+	if err := h.ReleaseLeadership(context.Background()); err != nil {
+		slog.Warn("Failed to release leadership", "error", err)
+	}
+
 	slog.Info("Closing PostgreSQL connection pool")
 	h.pool.Close()
 	slog.Info("PostgreSQL connection pool closed")
 	return nil
 }
+
+// WriteSkippedRanges records the skipped ranges, ignoring the ones already recorded.
+func (h *PostgresOutputHandler) WriteSkippedRanges(ctx context.Context, ranges []models.HeightRange) error {
+	batch := &pgx.Batch{}
+	for _, r := range ranges {
+		batch.Queue(`
+			INSERT INTO api.skipped_ranges (start_height, end_height) VALUES ($1, $2)
+			ON CONFLICT (start_height, end_height) DO NOTHING;
+		`, r.Start, r.Stop)
+	}
+
+	if err := h.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to write skipped ranges: %w", err)
+	}
+	return nil
+}
+
+// WritePrunedRange records a range pruned from the node, ignoring it if already recorded.
+func (h *PostgresOutputHandler) WritePrunedRange(ctx context.Context, r models.HeightRange) error {
+	_, err := h.pool.Exec(ctx, `
+		INSERT INTO api.pruned_ranges (start_height, end_height) VALUES ($1, $2)
+		ON CONFLICT (start_height, end_height) DO NOTHING;
+	`, r.Start, r.Stop)
+	if err != nil {
+		return fmt.Errorf("failed to write pruned range: %w", err)
+	}
+	return nil
+}
+
+// WriteTransactions writes transactions extracted without their block, e.g., by event.
+func (h *PostgresOutputHandler) WriteTransactions(ctx context.Context, transactions []*models.Transaction) error {
+	batch := &pgx.Batch{}
+	for _, t := range transactions {
+		// The raw response of a transaction extracted with its block is kept when it is written again without it
+		batch.Queue(`
+			INSERT INTO api.transactions_raw (id, data, raw) VALUES ($1, $2, $3)
+			ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data, raw = COALESCE(EXCLUDED.raw, api.transactions_raw.raw);
+		`, t.Hash, t.Data, t.Raw)
+		batch.Queue(addressTransactionsQuery, t.Hash, addresses.Find(t.Data), t.Data)
+	}
+
+	if err := h.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to write transactions: %w", err)
+	}
+	return nil
+}
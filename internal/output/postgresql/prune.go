@@ -0,0 +1,89 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// blockTxHashesQuery selects the hashes of the transactions of the blocks up to the height $1, identified by the
+// SHA-256 of their bytes.
+const blockTxHashesQuery = `
+	SELECT encode(sha256(decode(t, 'base64')), 'hex')
+	FROM api.blocks_raw b, jsonb_array_elements_text(b.data->'block'->'data'->'txs') t
+	WHERE b.id <= $1
+`
+
+// GetFirstBlockSince returns the height of the first stored block whose time is at or after since, and false if none.
+func (h *PostgresOutputHandler) GetFirstBlockSince(ctx context.Context, since time.Time) (uint64, bool, error) {
+	var height uint64
+	err := h.pool.QueryRow(ctx, `
+		SELECT id FROM api.blocks_raw WHERE block_time >= $1 ORDER BY id LIMIT 1;
+	`, since).Scan(&height)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("failed to get the first block since %s: %w", since, err)
+	}
+	return height, true, nil
+}
+
+// CountBlocksBefore returns the number of stored blocks below height and the number of transactions they contain.
+func (h *PostgresOutputHandler) CountBlocksBefore(ctx context.Context, height uint64) (uint64, uint64, error) {
+	var blocks, transactions uint64
+	err := h.pool.QueryRow(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(jsonb_array_length(COALESCE(data->'block'->'data'->'txs', '[]'::JSONB))), 0)
+		FROM api.blocks_raw
+		WHERE id < $1;
+	`, height).Scan(&blocks, &transactions)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count blocks: %w", err)
+	}
+	return blocks, transactions, nil
+}
+
+// PruneBlocks deletes, in a single database transaction, the limit lowest stored blocks below height, along with
+// their transactions, whose derived rows are deleted in cascade, their block results and their finalize block events.
+// It returns the number of deleted blocks and transactions, and the highest deleted height.
+func (h *PostgresOutputHandler) PruneBlocks(ctx context.Context, height uint64, limit int) (uint64, uint64, uint64, error) {
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // Ensure rollback if commit is not reached
+
+	var last *uint64
+	err = tx.QueryRow(ctx, `
+		SELECT MAX(id) FROM (SELECT id FROM api.blocks_raw WHERE id < $1 ORDER BY id LIMIT $2) b;
+	`, height, limit).Scan(&last)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to select blocks to prune: %w", err)
+	}
+	if last == nil {
+		return 0, 0, 0, nil
+	}
+
+	txTag, err := tx.Exec(ctx, `DELETE FROM api.transactions_raw WHERE id IN (`+blockTxHashesQuery+`);`, *last)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to delete transactions: %w", err)
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM api.events WHERE tx_hash IS NULL AND height <= $1;`, *last); err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to delete finalize block events: %w", err)
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM api.block_results_raw WHERE height <= $1;`, *last); err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to delete block results: %w", err)
+	}
+	blockTag, err := tx.Exec(ctx, `DELETE FROM api.blocks_raw WHERE id <= $1;`, *last)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to delete blocks: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return uint64(blockTag.RowsAffected()), uint64(txTag.RowsAffected()), *last, nil
+}
@@ -0,0 +1,383 @@
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/manifest-network/yaci/internal/query"
+)
+
+// collectResult reads the rows of a canned query, with the columns in the order of the query.
+func collectResult(rows pgx.Rows) (*query.Result, error) {
+	defer rows.Close()
+
+	result := &query.Result{Rows: [][]any{}}
+	for _, field := range rows.FieldDescriptions() {
+		result.Columns = append(result.Columns, field.Name)
+	}
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row: %w", err)
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+	return result, nil
+}
+
+// QueryTransaction returns the stored JSON of the transaction with the given hash, or nil if not found.
+func (h *PostgresOutputHandler) QueryTransaction(ctx context.Context, hash string) (json.RawMessage, error) {
+	var data json.RawMessage
+	err := h.pool.QueryRow(ctx, `SELECT data FROM api.transactions_raw WHERE id = $1;`, strings.ToLower(hash)).Scan(&data)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query transaction: %w", err)
+	}
+	return data, nil
+}
+
+// txSummaryColumns are the columns of the transaction summaries of the canned queries, of the transaction t.
+const txSummaryColumns = `
+	(t.data->'txResponse'->>'timestamp')::TIMESTAMPTZ AS time,
+	COALESCE((t.data->'txResponse'->>'code')::INTEGER, 0) AS code,
+	t.data->'tx'->'body'->'messages'->0->>'@type' AS first_message,
+	NULLIF(t.data->'tx'->'body'->>'memo', '') AS memo
+`
+
+// cursorArgs returns the height and key of the cursor as query arguments, NULL without cursor.
+func cursorArgs(cursor *query.Cursor) (any, any) {
+	if cursor == nil {
+		return nil, nil
+	}
+	return cursor.Height, cursor.Key
+}
+
+// QueryAddressTransactions returns the limit transactions involving the address after the cursor, if any, from the
+// highest height.
+func (h *PostgresOutputHandler) QueryAddressTransactions(ctx context.Context, address string, cursor *query.Cursor, limit int) (*query.Result, error) {
+	height, key := cursorArgs(cursor)
+	rows, err := h.pool.Query(ctx, `
+		SELECT a.height, a.tx_hash AS hash, `+txSummaryColumns+`
+		FROM api.address_transactions a
+		JOIN api.transactions_raw t ON t.id = a.tx_hash
+		WHERE a.address = $1
+			AND ($2::BIGINT IS NULL OR (a.height, a.tx_hash) < ($2::BIGINT, $3::TEXT))
+		ORDER BY a.height DESC, a.tx_hash DESC
+		LIMIT $4;
+	`, address, height, key, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query address transactions: %w", err)
+	}
+	return collectResult(rows)
+}
+
+// QueryMessageTransactions returns the limit transactions containing a message of the type URL after the cursor, if
+// any, from the highest height.
+func (h *PostgresOutputHandler) QueryMessageTransactions(ctx context.Context, typeURL string, cursor *query.Cursor, limit int) (*query.Result, error) {
+	height, key := cursorArgs(cursor)
+	rows, err := h.pool.Query(ctx, `
+		SELECT m.height, m.tx_hash AS hash, `+txSummaryColumns+`
+		FROM (
+			SELECT DISTINCT height, tx_hash
+			FROM api.messages
+			WHERE type_url = $1
+				AND ($2::BIGINT IS NULL OR (height, tx_hash) < ($2::BIGINT, $3::TEXT))
+			ORDER BY height DESC, tx_hash DESC
+			LIMIT $4
+		) m
+		JOIN api.transactions_raw t ON t.id = m.tx_hash
+		ORDER BY m.height DESC, m.tx_hash DESC;
+	`, typeURL, height, key, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query message transactions: %w", err)
+	}
+	return collectResult(rows)
+}
+
+// GetBlockData returns the stored JSON of the block at height, or nil if not found.
+func (h *PostgresOutputHandler) GetBlockData(ctx context.Context, height uint64) (json.RawMessage, error) {
+	var data json.RawMessage
+	err := h.pool.QueryRow(ctx, `SELECT data FROM api.blocks_raw WHERE id = $1;`, height).Scan(&data)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query block: %w", err)
+	}
+	return data, nil
+}
+
+// blockSummaryColumns are the columns of the block summaries of the canned queries, of the block b.
+var blockSummaryColumns = `
+	b.id AS height,
+	` + fmt.Sprintf(blockHashExpr, "b") + ` AS hash,
+	b.block_time AS time,
+	jsonb_array_length(COALESCE(b.data->'block'->'data'->'txs', '[]'::JSONB)) AS txs,
+	b.data->'block'->'header'->>'proposerAddress' AS proposer
+`
+
+// QueryBlocks returns the limit blocks below the cursor, if any, from the highest height.
+func (h *PostgresOutputHandler) QueryBlocks(ctx context.Context, cursor *query.Cursor, limit int) (*query.Result, error) {
+	height, _ := cursorArgs(cursor)
+	rows, err := h.pool.Query(ctx, `
+		SELECT `+blockSummaryColumns+`
+		FROM api.blocks_raw b
+		WHERE $1::BIGINT IS NULL OR b.id < $1::BIGINT
+		ORDER BY b.id DESC
+		LIMIT $2;
+	`, height, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blocks: %w", err)
+	}
+	return collectResult(rows)
+}
+
+// QueryBlocksByDate returns the first limit blocks of the given UTC date.
+func (h *PostgresOutputHandler) QueryBlocksByDate(ctx context.Context, date time.Time, limit int) (*query.Result, error) {
+	rows, err := h.pool.Query(ctx, `
+		SELECT `+blockSummaryColumns+`
+		FROM api.blocks_raw b
+		WHERE b.block_date = $1
+		ORDER BY b.id
+		LIMIT $2;
+	`, date.Format(time.DateOnly), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blocks: %w", err)
+	}
+	return collectResult(rows)
+}
+
+// QueryEvents returns the limit event attributes of the given type after the cursor, if any, from the highest height,
+// optionally restricted to an attribute key and value. The key of the cursor is the id of the attribute row.
+func (h *PostgresOutputHandler) QueryEvents(ctx context.Context, eventType, key, value string, cursor *query.Cursor, limit int) (*query.Result, error) {
+	var height, id any
+	if cursor != nil {
+		rowID, err := strconv.ParseInt(cursor.Key, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid event key %q", query.ErrInvalidCursor, cursor.Key)
+		}
+		height, id = cursor.Height, rowID
+	}
+	rows, err := h.pool.Query(ctx, `
+		SELECT id, height, tx_hash, event_type, attribute_key, attribute_value
+		FROM api.events
+		WHERE event_type = $1
+			AND ($2 = '' OR attribute_key = $2)
+			AND ($3 = '' OR attribute_value = $3)
+			AND ($4::BIGINT IS NULL OR (height, id) < ($4::BIGINT, $5::BIGINT))
+		ORDER BY height DESC, id DESC
+		LIMIT $6;
+	`, eventType, key, value, height, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	return collectResult(rows)
+}
+
+// QueryBlock returns the summary of the block at height, without row if not found.
+func (h *PostgresOutputHandler) QueryBlock(ctx context.Context, height uint64) (*query.Result, error) {
+	rows, err := h.pool.Query(ctx, `SELECT `+blockSummaryColumns+` FROM api.blocks_raw b WHERE b.id = $1;`, height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query block: %w", err)
+	}
+	return collectResult(rows)
+}
+
+// QueryTransactionSummary returns the summary of the transaction with the given hash, without row if not found.
+func (h *PostgresOutputHandler) QueryTransactionSummary(ctx context.Context, hash string) (*query.Result, error) {
+	rows, err := h.pool.Query(ctx, `
+		SELECT (t.data->'txResponse'->>'height')::BIGINT AS height, t.id AS hash, `+txSummaryColumns+`
+		FROM api.transactions_raw t
+		WHERE t.id = $1;
+	`, strings.ToLower(hash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transaction: %w", err)
+	}
+	return collectResult(rows)
+}
+
+// QueryBlockTransactions returns the summaries of the stored transactions of the block at height, in block order.
+func (h *PostgresOutputHandler) QueryBlockTransactions(ctx context.Context, height uint64) (*query.Result, error) {
+	rows, err := h.pool.Query(ctx, `
+		SELECT b.id AS height, t.id AS hash, `+txSummaryColumns+`
+		FROM api.blocks_raw b
+		CROSS JOIN LATERAL jsonb_array_elements_text(b.data->'block'->'data'->'txs') WITH ORDINALITY tx(bytes, index)
+		JOIN api.transactions_raw t ON t.id = encode(sha256(decode(tx.bytes, 'base64')), 'hex')
+		WHERE b.id = $1
+		ORDER BY tx.index;
+	`, height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query block transactions: %w", err)
+	}
+	return collectResult(rows)
+}
+
+// QueryTransactionMessages returns the messages of the transaction with the given hash, in transaction order.
+func (h *PostgresOutputHandler) QueryTransactionMessages(ctx context.Context, hash string) (*query.Result, error) {
+	rows, err := h.pool.Query(ctx, `
+		SELECT height, tx_hash, msg_index, type_url, data
+		FROM api.messages
+		WHERE tx_hash = $1
+		ORDER BY msg_index;
+	`, strings.ToLower(hash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transaction messages: %w", err)
+	}
+	return collectResult(rows)
+}
+
+// QueryTransactionEvents returns the event attributes of the transaction with the given hash, in transaction order.
+func (h *PostgresOutputHandler) QueryTransactionEvents(ctx context.Context, hash string) (*query.Result, error) {
+	rows, err := h.pool.Query(ctx, `
+		SELECT id, height, tx_hash, event_type, attribute_key, attribute_value
+		FROM api.events
+		WHERE tx_hash = $1
+		ORDER BY event_index, attribute_index;
+	`, strings.ToLower(hash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transaction events: %w", err)
+	}
+	return collectResult(rows)
+}
+
+// QueryMessages returns the limit messages of the type URL after the cursor, if any, from the highest height. The key
+// of the cursor is the transaction hash and the index of the message, as HASH:INDEX.
+func (h *PostgresOutputHandler) QueryMessages(ctx context.Context, typeURL string, cursor *query.Cursor, limit int) (*query.Result, error) {
+	var height, hash, index any
+	if cursor != nil {
+		txHash, indexStr, _ := strings.Cut(cursor.Key, ":")
+		msgIndex, err := strconv.Atoi(indexStr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid message key %q", query.ErrInvalidCursor, cursor.Key)
+		}
+		height, hash, index = cursor.Height, txHash, msgIndex
+	}
+	rows, err := h.pool.Query(ctx, `
+		SELECT height, tx_hash, msg_index, type_url, data
+		FROM api.messages
+		WHERE type_url = $1
+			AND ($2::BIGINT IS NULL OR (height, tx_hash, msg_index) < ($2::BIGINT, $3::TEXT, $4::INTEGER))
+		ORDER BY height DESC, tx_hash DESC, msg_index DESC
+		LIMIT $5;
+	`, typeURL, height, hash, index, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query messages: %w", err)
+	}
+	return collectResult(rows)
+}
+
+// SearchText returns the limit memos and event attributes matching the web search query text, e.g.,
+// '"exchange deposit" -refund', the most relevant and the most recent first. It fails with
+// query.ErrTextSearchDisabled if the text search columns were not added.
+func (h *PostgresOutputHandler) SearchText(ctx context.Context, text string, limit int) (*query.Result, error) {
+	var enabled bool
+	if err := h.pool.QueryRow(ctx, `SELECT api.text_search_enabled();`).Scan(&enabled); err != nil {
+		return nil, fmt.Errorf("failed to check text search: %w", err)
+	}
+	if !enabled {
+		return nil, fmt.Errorf("%w, see --enable-text-search", query.ErrTextSearchDisabled)
+	}
+
+	rows, err := h.pool.Query(ctx, `
+		SELECT source, height, tx_hash, event_type, attribute_key, text, rank
+		FROM api.search_text($1, $2);
+	`, text, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search text: %w", err)
+	}
+	return collectResult(rows)
+}
+
+// heightArg returns the height as a query argument, NULL for the latest height.
+func heightArg(height uint64) any {
+	if height == 0 {
+		return nil
+	}
+	return height
+}
+
+// QueryBalances returns the non-zero balances of the address as of atHeight, or the running balances if atHeight is 0.
+// A past balance is the balance of the latest balance snapshot at or below the height, plus the balance deltas of the
+// heights after the snapshot, or the sum of the balance deltas up to the height without snapshot.
+func (h *PostgresOutputHandler) QueryBalances(ctx context.Context, address string, atHeight uint64) (*query.Result, error) {
+	var rows pgx.Rows
+	var err error
+	if atHeight == 0 {
+		rows, err = h.pool.Query(ctx, `
+			SELECT address, denom, balance::TEXT AS balance, updated_height AS height
+			FROM api.balances
+			WHERE address = $1 AND balance <> 0
+			ORDER BY denom;
+		`, address)
+	} else {
+		rows, err = h.pool.Query(ctx, `
+			WITH snapshot AS (
+				SELECT COALESCE(MAX(height), 0) AS height FROM api.balance_snapshots WHERE height <= $2
+			)
+			SELECT $1::TEXT AS address, b.denom, SUM(b.amount)::TEXT AS balance, $2::BIGINT AS height
+			FROM (
+				SELECT s.denom, s.amount
+				FROM api.balance_snapshots s, snapshot
+				WHERE s.height = snapshot.height AND s.address = $1
+				UNION ALL
+				SELECT d.denom, d.delta
+				FROM api.balance_deltas d, snapshot
+				WHERE d.address = $1 AND d.height > snapshot.height AND d.height <= $2
+			) b
+			GROUP BY b.denom
+			HAVING SUM(b.amount) <> 0
+			ORDER BY b.denom;
+		`, address, atHeight)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query balances: %w", err)
+	}
+	return collectResult(rows)
+}
+
+// QueryDelegations returns the delegations of the delegator, or to the validator if delegator is empty, of the latest
+// delegation snapshot at or below atHeight, or of the latest snapshot if atHeight is 0.
+func (h *PostgresOutputHandler) QueryDelegations(ctx context.Context, delegator, validator string, atHeight uint64) (*query.Result, error) {
+	rows, err := h.pool.Query(ctx, `
+		SELECT height, delegator_address, validator_address, shares::TEXT AS shares, amount::TEXT AS amount, denom
+		FROM api.delegation_snapshots
+		WHERE height = (SELECT MAX(height) FROM api.delegation_snapshots WHERE $3::BIGINT IS NULL OR height <= $3::BIGINT)
+			AND ($1 = '' OR delegator_address = $1)
+			AND ($2 = '' OR validator_address = $2)
+		ORDER BY delegator_address, validator_address;
+	`, delegator, validator, heightArg(atHeight))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query delegations: %w", err)
+	}
+	return collectResult(rows)
+}
+
+// QueryValidators returns the validator set at atHeight, or at the latest committed height if atHeight is 0, from the
+// signatures of its commit in validator set order, with the operator address and the current moniker of the
+// validators.
+func (h *PostgresOutputHandler) QueryValidators(ctx context.Context, atHeight uint64) (*query.Result, error) {
+	rows, err := h.pool.Query(ctx, `
+		SELECT s.height, s.validator_address AS consensus_address, c.operator_address, v.moniker, s.signed
+		FROM api.commit_signatures s
+		LEFT JOIN api.validator_consensus_addresses c ON c.consensus_address = s.validator_address
+		LEFT JOIN api.validators v ON v.operator_address = c.operator_address
+		WHERE s.height = COALESCE($1::BIGINT, (SELECT MAX(height) FROM api.commit_signatures))
+		ORDER BY s.signature_index;
+	`, heightArg(atHeight))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query validators: %w", err)
+	}
+	return collectResult(rows)
+}
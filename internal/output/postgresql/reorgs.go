@@ -0,0 +1,87 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+// Blocks indexed before the hash columns were added only have their hashes in their data
+const (
+	blockHashExpr  = `COALESCE(%[1]s.hash, %[1]s.data->'blockId'->>'hash')`
+	parentHashExpr = `COALESCE(%[1]s.parent_hash, %[1]s.data->'block'->'header'->'lastBlockId'->>'hash')`
+)
+
+func (h *PostgresOutputHandler) FindChainMismatch(ctx context.Context, start, stop uint64) (uint64, bool, error) {
+	var height uint64
+	err := h.pool.QueryRow(ctx, fmt.Sprintf(`
+		SELECT b.id
+		FROM api.blocks_raw b
+		JOIN api.blocks_raw p ON p.id = b.id - 1
+		WHERE b.id BETWEEN $1 AND $2
+			AND %s IS DISTINCT FROM %s
+		ORDER BY b.id
+		LIMIT 1;
+	`, fmt.Sprintf(parentHashExpr, "b"), fmt.Sprintf(blockHashExpr, "p")), start, stop).Scan(&height)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("failed to verify block hashes: %w", err)
+	}
+	return height, true, nil
+}
+
+func (h *PostgresOutputHandler) GetBlockHash(ctx context.Context, height uint64) (string, error) {
+	var hash *string
+	err := h.pool.QueryRow(ctx, fmt.Sprintf(`
+		SELECT %s FROM api.blocks_raw b WHERE b.id = $1;
+	`, fmt.Sprintf(blockHashExpr, "b")), height).Scan(&hash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get block hash: %w", err)
+	}
+	if hash == nil {
+		return "", nil
+	}
+	return *hash, nil
+}
+
+// deleteBlock deletes the block at height, its block results and its transactions, identified by their height rather
+// than by the transactions of the block JSON, which --prune-json or the header-only mode may have removed. The height
+// stays processed, since the block is written again in the same transaction.
+func deleteBlock(ctx context.Context, tx pgx.Tx, height uint64) error {
+	_, err := tx.Exec(ctx, `
+		DELETE FROM api.transactions_raw
+		WHERE (data->'txResponse'->>'height')::BIGINT = $1;
+	`, height)
+	if err != nil {
+		return fmt.Errorf("failed to delete block transactions: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, `DELETE FROM api.block_results_raw WHERE height = $1;`, height); err != nil {
+		return fmt.Errorf("failed to delete block results: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, `DELETE FROM api.blocks_raw WHERE id = $1;`, height); err != nil {
+		return fmt.Errorf("failed to delete block: %w", err)
+	}
+	return nil
+}
+
+func (h *PostgresOutputHandler) WriteReorg(ctx context.Context, reorg *models.Reorg) error {
+	_, err := h.pool.Exec(ctx, `
+		INSERT INTO api.reorgs (fork_height, detected_height, depth, old_hashes, new_hashes)
+		VALUES ($1, $2, $3, $4, $5);
+	`, reorg.ForkHeight, reorg.DetectedHeight, len(reorg.OldHashes), reorg.OldHashes, reorg.NewHashes)
+	if err != nil {
+		return fmt.Errorf("failed to write reorg: %w", err)
+	}
+	return nil
+}
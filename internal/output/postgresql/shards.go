@@ -0,0 +1,118 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+func (h *PostgresOutputHandler) RegisterShards(ctx context.Context, shards []models.Shard) error {
+	starts := make([]int64, 0, len(shards))
+	stops := make([]int64, 0, len(shards))
+	registered := make(map[uint64]uint64, len(shards))
+	for _, s := range shards {
+		starts = append(starts, int64(s.Start))
+		stops = append(stops, int64(s.Stop))
+		registered[s.Start] = s.Stop
+	}
+
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // Ensure rollback if commit is not reached
+
+	// The existing shards are kept, and returned if they stop at another height, e.g., registered by an instance with
+	// another range, whose claim would leave the heights in between unprocessed
+	var conflict models.Shard
+	err = tx.QueryRow(ctx, `
+		INSERT INTO api.extraction_shards (start_height, end_height)
+		SELECT * FROM unnest($1::BIGINT[], $2::BIGINT[])
+		ON CONFLICT (start_height) DO UPDATE SET end_height = api.extraction_shards.end_height
+		WHERE api.extraction_shards.end_height <> EXCLUDED.end_height
+		RETURNING start_height, end_height;
+	`, starts, stops).Scan(&conflict.Start, &conflict.Stop)
+	if err == nil {
+		return fmt.Errorf("shard [%d, %d] conflicts with the registered shard [%d, %d]: the instances sharing a backfill must use the same --start, --stop and --shard-size",
+			conflict.Start, registered[conflict.Start], conflict.Start, conflict.Stop)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to register shards: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (h *PostgresOutputHandler) ClaimShard(ctx context.Context, owner string, start, stop uint64, lease time.Duration) (*models.Shard, error) {
+	var shard models.Shard
+	err := h.pool.QueryRow(ctx, `
+		UPDATE api.extraction_shards
+		SET owner = $1, lease_expires_at = NOW() + make_interval(secs => $2)
+		WHERE start_height = (
+			SELECT start_height
+			FROM api.extraction_shards
+			WHERE start_height >= $3 AND end_height <= $4
+				AND completed_at IS NULL
+				AND (owner IS NULL OR owner = $1 OR lease_expires_at < NOW())
+			ORDER BY start_height
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING start_height, end_height;
+	`, owner, lease.Seconds(), start, stop).Scan(&shard.Start, &shard.Stop)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim shard: %w", err)
+	}
+	return &shard, nil
+}
+
+func (h *PostgresOutputHandler) RenewShardLease(ctx context.Context, owner string, shard models.Shard, lease time.Duration) error {
+	tag, err := h.pool.Exec(ctx, `
+		UPDATE api.extraction_shards
+		SET lease_expires_at = NOW() + make_interval(secs => $3)
+		WHERE start_height = $2 AND owner = $1 AND completed_at IS NULL;
+	`, owner, shard.Start, lease.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to renew shard lease: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("shard [%d, %d] is no longer leased by %s", shard.Start, shard.Stop, owner)
+	}
+	return nil
+}
+
+func (h *PostgresOutputHandler) CompleteShard(ctx context.Context, owner string, shard models.Shard) error {
+	_, err := h.pool.Exec(ctx, `
+		UPDATE api.extraction_shards
+		SET completed_at = NOW(), lease_expires_at = NULL
+		WHERE start_height = $2 AND owner = $1;
+	`, owner, shard.Start)
+	if err != nil {
+		return fmt.Errorf("failed to complete shard: %w", err)
+	}
+	return nil
+}
+
+func (h *PostgresOutputHandler) CountPendingShards(ctx context.Context, start, stop uint64) (int, error) {
+	var count int
+	err := h.pool.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM api.extraction_shards
+		WHERE start_height >= $1 AND end_height <= $2 AND completed_at IS NULL;
+	`, start, stop).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pending shards: %w", err)
+	}
+	return count, nil
+}
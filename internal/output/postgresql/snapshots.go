@@ -0,0 +1,287 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+// WriteDelegationSnapshot replaces the delegation snapshot stored at the snapshot height.
+func (h *PostgresOutputHandler) WriteDelegationSnapshot(ctx context.Context, snapshot *models.DelegationSnapshot) error {
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // Ensure rollback if commit is not reached
+
+	if _, err := tx.Exec(ctx, `DELETE FROM api.delegation_snapshots WHERE height = $1`, snapshot.Height); err != nil {
+		return fmt.Errorf("failed to delete previous delegation snapshot: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM api.unbonding_delegation_snapshots WHERE height = $1`, snapshot.Height); err != nil {
+		return fmt.Errorf("failed to delete previous unbonding delegation snapshot: %w", err)
+	}
+
+	delegationRows := make([][]interface{}, 0, len(snapshot.Delegations))
+	for _, d := range snapshot.Delegations {
+		shares, err := parseNumeric(d.Shares)
+		if err != nil {
+			return err
+		}
+		amount, err := parseNumeric(d.Amount)
+		if err != nil {
+			return err
+		}
+		delegationRows = append(delegationRows, []interface{}{snapshot.Height, d.DelegatorAddress, d.ValidatorAddress, shares, amount, d.Denom})
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"api", "delegation_snapshots"},
+		[]string{"height", "delegator_address", "validator_address", "shares", "amount", "denom"},
+		pgx.CopyFromRows(delegationRows),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write delegation snapshot: %w", err)
+	}
+
+	unbondingRows := make([][]interface{}, 0, len(snapshot.Unbondings))
+	for _, u := range snapshot.Unbondings {
+		initialBalance, err := parseNumeric(u.InitialBalance)
+		if err != nil {
+			return err
+		}
+		balance, err := parseNumeric(u.Balance)
+		if err != nil {
+			return err
+		}
+		unbondingRows = append(unbondingRows, []interface{}{snapshot.Height, u.DelegatorAddress, u.ValidatorAddress, u.CreationHeight, u.CompletionTime, initialBalance, balance})
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"api", "unbonding_delegation_snapshots"},
+		[]string{"height", "delegator_address", "validator_address", "creation_height", "completion_time", "initial_balance", "balance"},
+		pgx.CopyFromRows(unbondingRows),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write unbonding delegation snapshot: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// parseNumeric parses a decimal string into a PostgreSQL numeric value.
+func parseNumeric(s string) (pgtype.Numeric, error) {
+	var n pgtype.Numeric
+	if err := n.Scan(s); err != nil {
+		return n, fmt.Errorf("invalid numeric value %q: %w", s, err)
+	}
+	return n, nil
+}
+
+// WriteBalanceSnapshot replaces the balance snapshot stored at the snapshot height, and reconciles the balances computed
+// from the balance deltas with it.
+func (h *PostgresOutputHandler) WriteBalanceSnapshot(ctx context.Context, snapshot *models.BalanceSnapshot) error {
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // Ensure rollback if commit is not reached
+
+	if _, err := tx.Exec(ctx, `DELETE FROM api.balance_snapshots WHERE height = $1`, snapshot.Height); err != nil {
+		return fmt.Errorf("failed to delete previous balance snapshot: %w", err)
+	}
+
+	rows := make([][]interface{}, 0, len(snapshot.Balances))
+	for _, b := range snapshot.Balances {
+		amount, err := parseNumeric(b.Amount)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, []interface{}{snapshot.Height, b.Address, b.Denom, amount})
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"api", "balance_snapshots"},
+		[]string{"height", "address", "denom", "amount"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write balance snapshot: %w", err)
+	}
+
+	// Reconcile the running balances computed from the balance deltas with the snapshot
+	var reconciled int
+	if err := tx.QueryRow(ctx, `SELECT api.reconcile_balances($1)`, snapshot.Height).Scan(&reconciled); err != nil {
+		return fmt.Errorf("failed to reconcile balances: %w", err)
+	}
+	if reconciled > 0 {
+		slog.Info("Reconciled balances with the balance snapshot", "height", snapshot.Height, "balances", reconciled)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// WriteSupplySnapshot replaces the total supply recorded at the snapshot height and updates the denom metadata.
+func (h *PostgresOutputHandler) WriteSupplySnapshot(ctx context.Context, snapshot *models.SupplySnapshot) error {
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // Ensure rollback if commit is not reached
+
+	for _, m := range snapshot.Metadata {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO api.denom_metadata (denom, display, symbol, exponent, data, updated_height)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (denom) DO UPDATE SET
+				display = EXCLUDED.display,
+				symbol = EXCLUDED.symbol,
+				exponent = EXCLUDED.exponent,
+				data = EXCLUDED.data,
+				updated_height = EXCLUDED.updated_height;
+		`, m.Base, m.Display, m.Symbol, m.Exponent, sanitizeJSONForPostgres(m.Data), snapshot.Height)
+		if err != nil {
+			return fmt.Errorf("failed to write denom metadata: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM api.supply_history WHERE height = $1`, snapshot.Height); err != nil {
+		return fmt.Errorf("failed to delete previous supply: %w", err)
+	}
+
+	rows := make([][]interface{}, 0, len(snapshot.Supply))
+	for _, c := range snapshot.Supply {
+		amount, err := parseNumeric(c.Amount)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, []interface{}{snapshot.Height, c.Denom, amount})
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"api", "supply_history"},
+		[]string{"height", "denom", "amount"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write supply: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// WriteIBCState inserts or updates the IBC clients, connections and channels.
+func (h *PostgresOutputHandler) WriteIBCState(ctx context.Context, state *models.IBCState) error {
+	batch := &pgx.Batch{}
+	for _, c := range state.Clients {
+		batch.Queue(`
+			INSERT INTO api.ibc_clients (client_id, client_type, chain_id, status, data, updated_height)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (client_id) DO UPDATE SET
+				client_type = EXCLUDED.client_type,
+				chain_id = EXCLUDED.chain_id,
+				status = EXCLUDED.status,
+				data = EXCLUDED.data,
+				updated_height = EXCLUDED.updated_height;
+		`, c.ClientID, c.Type, c.ChainID, c.Status, sanitizeJSONForPostgres(c.Data), state.Height)
+	}
+	for _, c := range state.Connections {
+		batch.Queue(`
+			INSERT INTO api.ibc_connections (connection_id, client_id, state, counterparty_client_id, counterparty_connection_id, data, updated_height)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (connection_id) DO UPDATE SET
+				client_id = EXCLUDED.client_id,
+				state = EXCLUDED.state,
+				counterparty_client_id = EXCLUDED.counterparty_client_id,
+				counterparty_connection_id = EXCLUDED.counterparty_connection_id,
+				data = EXCLUDED.data,
+				updated_height = EXCLUDED.updated_height;
+		`, c.ConnectionID, c.ClientID, c.State, c.CounterpartyClientID, c.CounterpartyConnectionID, sanitizeJSONForPostgres(c.Data), state.Height)
+	}
+	for _, c := range state.Channels {
+		batch.Queue(`
+			INSERT INTO api.ibc_channels (port_id, channel_id, connection_id, state, ordering, version, counterparty_port_id, counterparty_channel_id, data, updated_height)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (port_id, channel_id) DO UPDATE SET
+				connection_id = EXCLUDED.connection_id,
+				state = EXCLUDED.state,
+				ordering = EXCLUDED.ordering,
+				version = EXCLUDED.version,
+				counterparty_port_id = EXCLUDED.counterparty_port_id,
+				counterparty_channel_id = EXCLUDED.counterparty_channel_id,
+				data = EXCLUDED.data,
+				updated_height = EXCLUDED.updated_height;
+		`, c.PortID, c.ChannelID, c.ConnectionID, c.State, c.Ordering, c.Version, c.CounterpartyPortID, c.CounterpartyChannelID, sanitizeJSONForPostgres(c.Data), state.Height)
+	}
+
+	if err := h.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to write IBC state: %w", err)
+	}
+	return nil
+}
+
+// WriteValidators inserts or updates the validators and the mapping of their consensus addresses to their operator
+// addresses. The consensus addresses of the keys a validator rotated away from are kept, to resolve past proposers.
+func (h *PostgresOutputHandler) WriteValidators(ctx context.Context, set *models.ValidatorSet) error {
+	batch := &pgx.Batch{}
+	for _, v := range set.Validators {
+		batch.Queue(`
+			INSERT INTO api.validators (operator_address, consensus_address, moniker, status, jailed, data, updated_height)
+			VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7)
+			ON CONFLICT (operator_address) DO UPDATE SET
+				consensus_address = EXCLUDED.consensus_address,
+				moniker = EXCLUDED.moniker,
+				status = EXCLUDED.status,
+				jailed = EXCLUDED.jailed,
+				data = EXCLUDED.data,
+				updated_height = EXCLUDED.updated_height;
+		`, v.OperatorAddress, v.ConsensusAddress, v.Moniker, v.Status, v.Jailed, sanitizeJSONForPostgres(v.Data), set.Height)
+		if v.ConsensusAddress == "" {
+			continue
+		}
+		batch.Queue(`
+			INSERT INTO api.validator_consensus_addresses (consensus_address, operator_address, first_height, last_height)
+			VALUES ($1, $2, $3, $3)
+			ON CONFLICT (consensus_address) DO UPDATE SET
+				operator_address = EXCLUDED.operator_address,
+				first_height = LEAST(api.validator_consensus_addresses.first_height, EXCLUDED.first_height),
+				last_height = GREATEST(api.validator_consensus_addresses.last_height, EXCLUDED.last_height);
+		`, v.ConsensusAddress, v.OperatorAddress, set.Height)
+	}
+
+	if err := h.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to write validators: %w", err)
+	}
+	return nil
+}
+
+func (h *PostgresOutputHandler) WriteExtraQueryResults(ctx context.Context, results []*models.ExtraQueryResult) error {
+	batch := &pgx.Batch{}
+	for _, r := range results {
+		batch.Queue(`
+			INSERT INTO api.extra_query_results (name, height, data) VALUES ($1, $2, $3)
+			ON CONFLICT (name, height) DO UPDATE SET data = EXCLUDED.data;
+		`, r.Name, r.Height, sanitizeJSONForPostgres(r.Data))
+	}
+
+	if err := h.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to write extra query results: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+// CountMissingBlocks returns the number of heights found in the gaps between processed, skipped and pruned ranges,
+// within the extracted heights, like GetMissingBlockIds without listing them.
+func (h *PostgresOutputHandler) CountMissingBlocks(ctx context.Context) (uint64, error) {
+	var count uint64
+	err := h.pool.QueryRow(ctx, missingRangesQuery+`
+		SELECT COALESCE(SUM(end_height - start_height + 1), 0) FROM gaps;
+	`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count missing blocks: %w", err)
+	}
+	return count, nil
+}
+
+// GetMissingRanges returns the gaps between processed, skipped and pruned ranges, within the extracted heights, as
+// inclusive height ranges ordered by height, like GetMissingBlockIds without listing every height.
+func (h *PostgresOutputHandler) GetMissingRanges(ctx context.Context) ([]models.HeightRange, error) {
+	rows, err := h.pool.Query(ctx, missingRangesQuery+`
+		SELECT start_height, end_height FROM gaps ORDER BY start_height;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get missing ranges: %w", err)
+	}
+	defer rows.Close()
+
+	var missing []models.HeightRange
+	for rows.Next() {
+		var r models.HeightRange
+		if err := rows.Scan(&r.Start, &r.Stop); err != nil {
+			return nil, fmt.Errorf("failed to scan missing range: %w", err)
+		}
+		missing = append(missing, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get missing ranges: %w", err)
+	}
+	return missing, nil
+}
+
+// CountBlocksIndexedSince returns the number of blocks committed since the given time.
+func (h *PostgresOutputHandler) CountBlocksIndexedSince(ctx context.Context, since time.Time) (uint64, error) {
+	var count uint64
+	err := h.pool.QueryRow(ctx, `SELECT COUNT(*) FROM api.blocks_raw WHERE indexed_at >= $1;`, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count indexed blocks: %w", err)
+	}
+	return count, nil
+}
+
+// GetBlockTime returns the on-chain time of the stored block at height, or the zero time if unknown.
+func (h *PostgresOutputHandler) GetBlockTime(ctx context.Context, height uint64) (time.Time, error) {
+	var blockTime *time.Time
+	err := h.pool.QueryRow(ctx, `SELECT block_time FROM api.blocks_raw WHERE id = $1;`, height).Scan(&blockTime)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("failed to get block time: %w", err)
+	}
+	if blockTime == nil {
+		return time.Time{}, nil
+	}
+	return *blockTime, nil
+}
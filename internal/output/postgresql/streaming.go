@@ -0,0 +1,54 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+func (h *PostgresOutputHandler) WriteStreamedBlock(ctx context.Context, block *models.StreamedBlock) error {
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // Ensure rollback if commit is not reached
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO api.streamed_blocks (height, finalize_block_request, finalize_block_response, commit_response, received_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (height) DO UPDATE SET
+			finalize_block_request = EXCLUDED.finalize_block_request,
+			finalize_block_response = EXCLUDED.finalize_block_response,
+			commit_response = EXCLUDED.commit_response,
+			received_at = EXCLUDED.received_at;
+	`, block.Height, block.FinalizeBlockRequest, block.FinalizeBlockResponse, block.CommitResponse)
+	if err != nil {
+		return fmt.Errorf("failed to write streamed block: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM api.state_changes WHERE height = $1`, block.Height); err != nil {
+		return fmt.Errorf("failed to delete previous state changes: %w", err)
+	}
+
+	rows := make([][]interface{}, 0, len(block.StateChanges))
+	for i, c := range block.StateChanges {
+		rows = append(rows, []interface{}{block.Height, i, c.StoreKey, c.Key, c.Value, c.Delete})
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"api", "state_changes"},
+		[]string{"height", "idx", "store_key", "key", "value", "deleted"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write state changes: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// EnableTextSearch adds the full-text search columns over the memos and the event attribute values, if not already
+// added. Adding them rewrites the transactions and the events tables, which can take a while on a large index.
+func (h *PostgresOutputHandler) EnableTextSearch(ctx context.Context) error {
+	var enabled bool
+	if err := h.pool.QueryRow(ctx, `SELECT api.text_search_enabled()`).Scan(&enabled); err != nil {
+		return fmt.Errorf("failed to check text search: %w", err)
+	}
+	if enabled {
+		return nil
+	}
+
+	slog.Info("Enabling text search, the transactions and events tables are rewritten...")
+	if _, err := h.pool.Exec(ctx, `SELECT api.enable_text_search()`); err != nil {
+		return fmt.Errorf("failed to enable text search: %w", err)
+	}
+	slog.Info("Text search enabled")
+	return nil
+}
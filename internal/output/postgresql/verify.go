@@ -0,0 +1,101 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+// GetBlocks returns up to limit blocks above height after and up to stop, 0 for the latest, ordered by height.
+// Hash and ParentHash are the values of the hash columns, empty for the blocks indexed before they were added, and Time
+// the value of the block time column, zero if unknown.
+func (h *PostgresOutputHandler) GetBlocks(ctx context.Context, after, stop uint64, limit int) ([]*models.Block, error) {
+	if stop == 0 {
+		stop = math.MaxInt64
+	}
+	rows, err := h.pool.Query(ctx, `
+		SELECT id, data, COALESCE(hash, ''), COALESCE(parent_hash, ''), block_time
+		FROM api.blocks_raw
+		WHERE id > $1 AND id <= $2
+		ORDER BY id
+		LIMIT $3;
+	`, after, stop, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocks: %w", err)
+	}
+	defer rows.Close()
+
+	var blocks []*models.Block
+	for rows.Next() {
+		b := &models.Block{}
+		var blockTime *time.Time
+		if err := rows.Scan(&b.ID, &b.Data, &b.Hash, &b.ParentHash, &blockTime); err != nil {
+			return nil, fmt.Errorf("failed to scan block: %w", err)
+		}
+		if blockTime != nil {
+			b.Time = *blockTime
+		}
+		blocks = append(blocks, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get blocks: %w", err)
+	}
+	return blocks, nil
+}
+
+// GetTransactionHashMismatches returns the stored transactions among hashes, and whether the hash reported by their
+// response differs.
+func (h *PostgresOutputHandler) GetTransactionHashMismatches(ctx context.Context, hashes []string) (map[string]bool, error) {
+	rows, err := h.pool.Query(ctx, `
+		SELECT id, COALESCE(hash_mismatch, FALSE)
+		FROM api.transactions_raw
+		WHERE id = ANY($1);
+	`, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer rows.Close()
+
+	stored := make(map[string]bool, len(hashes))
+	for rows.Next() {
+		var hash string
+		var mismatch bool
+		if err := rows.Scan(&hash, &mismatch); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		stored[hash] = mismatch
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	return stored, nil
+}
+
+// GetExcludedRanges returns the skipped ranges and the ranges pruned from the node, which are missing on purpose.
+func (h *PostgresOutputHandler) GetExcludedRanges(ctx context.Context) ([]models.HeightRange, error) {
+	rows, err := h.pool.Query(ctx, `
+		SELECT start_height, end_height FROM api.skipped_ranges
+		UNION ALL
+		SELECT start_height, end_height FROM api.pruned_ranges;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get excluded ranges: %w", err)
+	}
+	defer rows.Close()
+
+	var ranges []models.HeightRange
+	for rows.Next() {
+		var r models.HeightRange
+		if err := rows.Scan(&r.Start, &r.Stop); err != nil {
+			return nil, fmt.Errorf("failed to scan excluded range: %w", err)
+		}
+		ranges = append(ranges, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get excluded ranges: %w", err)
+	}
+	return ranges, nil
+}
@@ -0,0 +1,104 @@
+// Package preset provides extraction settings bundled for common kinds of chains.
+// A preset is either selected by name or detected from the gRPC services exposed by the node.
+package preset
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/spf13/viper"
+)
+
+// Auto is the preset name selecting the preset matching the services exposed by the node.
+const Auto = "auto"
+
+//go:embed presets/*.yaml
+var presetsFS embed.FS
+
+// Preset is a set of extraction settings for a kind of chain.
+type Preset struct {
+	Name        string         `mapstructure:"name"`
+	Description string         `mapstructure:"description"`
+	Priority    int            `mapstructure:"priority"`
+	Detect      []string       `mapstructure:"detect"`   // The preset matches a node exposing any of these gRPC services
+	Settings    map[string]any `mapstructure:"settings"` // Configuration keys, named after the command-line flags
+}
+
+// List returns the embedded presets, from the highest to the lowest priority.
+func List() ([]*Preset, error) {
+	entries, err := presetsFS.ReadDir("presets")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read presets: %w", err)
+	}
+
+	var presets []*Preset
+	for _, entry := range entries {
+		data, err := presetsFS.ReadFile(path.Join("presets", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read preset %s: %w", entry.Name(), err)
+		}
+
+		v := viper.New()
+		v.SetConfigType("yaml")
+		if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("failed to parse preset %s: %w", entry.Name(), err)
+		}
+
+		var p Preset
+		if err := v.Unmarshal(&p); err != nil {
+			return nil, fmt.Errorf("failed to decode preset %s: %w", entry.Name(), err)
+		}
+		presets = append(presets, &p)
+	}
+
+	sort.SliceStable(presets, func(i, j int) bool {
+		if presets[i].Priority != presets[j].Priority {
+			return presets[i].Priority > presets[j].Priority
+		}
+		return presets[i].Name < presets[j].Name
+	})
+	return presets, nil
+}
+
+// Get returns the preset with the given name.
+func Get(name string) (*Preset, error) {
+	presets, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range presets {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown preset: %s", name)
+}
+
+// Detect returns the preset with the highest priority matching the services exposed by the node.
+func Detect(hasService func(serviceName string) bool) (*Preset, error) {
+	presets, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range presets {
+		for _, service := range p.Detect {
+			if hasService(service) {
+				return p, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no preset matches the services exposed by the node")
+}
+
+// Apply sets the settings of the preset as defaults of v.
+// Values set explicitly, from flags, environment variables or configuration files, take precedence.
+func (p *Preset) Apply(v *viper.Viper) {
+	for key, value := range p.Settings {
+		v.SetDefault(key, value)
+	}
+}
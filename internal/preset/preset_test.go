@@ -0,0 +1,116 @@
+package preset_test
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/preset"
+)
+
+func TestList(t *testing.T) {
+	presets, err := preset.List()
+	require.NoError(t, err)
+
+	var names []string
+	for _, p := range presets {
+		names = append(names, p.Name)
+		assert.NotEmpty(t, p.Description, p.Name)
+		assert.NotEmpty(t, p.Detect, p.Name)
+	}
+	assert.Equal(t, []string{"ics-consumer", "evm", "wasm", "sdk"}, names)
+}
+
+func TestDetect(t *testing.T) {
+	cases := []struct {
+		name     string
+		services []string
+		expected string
+		error    string
+	}{
+		{
+			name:     "pure sdk",
+			services: []string{"cosmos.bank.v1beta1.Query", "cosmos.staking.v1beta1.Query"},
+			expected: "sdk",
+		},
+		{
+			name:     "wasm",
+			services: []string{"cosmos.bank.v1beta1.Query", "cosmwasm.wasm.v1.Query"},
+			expected: "wasm",
+		},
+		{
+			name:     "ethermint",
+			services: []string{"cosmos.bank.v1beta1.Query", "ethermint.evm.v1.Query"},
+			expected: "evm",
+		},
+		{
+			name:     "consumer chain with wasm",
+			services: []string{"cosmos.bank.v1beta1.Query", "cosmwasm.wasm.v1.Query", "interchain_security.ccv.consumer.v1.Query"},
+			expected: "ics-consumer",
+		},
+		{
+			name:  "no match",
+			error: "no preset matches",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := preset.Detect(func(serviceName string) bool {
+				for _, s := range tc.services {
+					if s == serviceName {
+						return true
+					}
+				}
+				return false
+			})
+			if tc.error != "" {
+				require.ErrorContains(t, err, tc.error)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, p.Name)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	p, err := preset.Get("ics-consumer")
+	require.NoError(t, err)
+
+	v := viper.New()
+	v.Set("supply-interval", 5)
+	p.Apply(v)
+
+	assert.Equal(t, uint64(5), v.GetUint64("supply-interval"), "explicit values take precedence")
+	assert.Equal(t, uint64(100), v.GetUint64("gov-proposals-interval"))
+	assert.Equal(t, uint64(0), v.GetUint64("delegation-snapshot-interval"))
+
+	_, err = preset.Get("unknown")
+	require.ErrorContains(t, err, "unknown preset")
+}
+
+func TestPresetsDiffer(t *testing.T) {
+	presets, err := preset.List()
+	require.NoError(t, err)
+
+	for i, p := range presets {
+		for _, other := range presets[i+1:] {
+			assert.NotEqual(t, p.Settings, other.Settings, "presets %s and %s have the same settings", p.Name, other.Name)
+		}
+	}
+
+	wasm, err := preset.Get("wasm")
+	require.NoError(t, err)
+	v := viper.New()
+	wasm.Apply(v)
+	assert.Contains(t, v.GetStringSlice("prune-json"), "tx.body.messages.*.wasmByteCode")
+
+	evm, err := preset.Get("evm")
+	require.NoError(t, err)
+	v = viper.New()
+	evm.Apply(v)
+	assert.Equal(t, uint(25), v.GetUint("max-concurrency"))
+}
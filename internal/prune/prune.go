@@ -0,0 +1,144 @@
+// Package prune drops or truncates the fields of the JSON documents that are not worth storing, e.g., the byte code of
+// the CosmWasm contracts or the headers of the IBC client updates.
+package prune
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// wildcard matches any field of an object, or any element of an array.
+const wildcard = "*"
+
+// Rule drops the values at a path, or truncates their strings to MaxLength characters if MaxLength is not 0.
+// The path is a list of field names, array indexes or wildcards, from the root of the document.
+type Rule struct {
+	Path      []string
+	MaxLength int
+}
+
+// ParseRule parses a rule of the form PATH to drop the values, or PATH=N to truncate their strings to N characters,
+// where PATH is a dot-separated list of JSON field names, array indexes or * wildcards, e.g., tx.body.messages.*.wasmByteCode.
+func ParseRule(s string) (Rule, error) {
+	path, maxLength, truncate := strings.Cut(strings.TrimSpace(s), "=")
+
+	r := Rule{Path: strings.Split(strings.TrimSpace(path), ".")}
+	for _, segment := range r.Path {
+		if segment == "" {
+			return Rule{}, fmt.Errorf("invalid prune rule %q, expected PATH or PATH=N", s)
+		}
+	}
+	if truncate {
+		n, err := strconv.Atoi(strings.TrimSpace(maxLength))
+		if err != nil || n <= 0 {
+			return Rule{}, fmt.Errorf("invalid prune rule %q, expected a positive length", s)
+		}
+		r.MaxLength = n
+	}
+	return r, nil
+}
+
+// Pruner applies its rules to JSON documents.
+type Pruner struct {
+	rules []Rule
+}
+
+// NewPruner parses the rules of a pruner.
+func NewPruner(rules []string) (*Pruner, error) {
+	p := &Pruner{}
+	for _, s := range rules {
+		r, err := ParseRule(s)
+		if err != nil {
+			return nil, err
+		}
+		p.rules = append(p.rules, r)
+	}
+	return p, nil
+}
+
+// Prune returns the document without the values matching the rules. The data is returned as is if no value matches.
+func (p *Pruner) Prune(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal document: %w", err)
+	}
+
+	changed := false
+	for _, r := range p.rules {
+		if prune(doc, r.Path, r.MaxLength) {
+			changed = true
+		}
+	}
+	if !changed {
+		return data, nil
+	}
+	return json.Marshal(doc)
+}
+
+// prune applies a rule to the values of node at path, and returns true if any value was pruned.
+func prune(node interface{}, path []string, maxLength int) bool {
+	segment, rest := path[0], path[1:]
+	changed := false
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for key, child := range n {
+			if segment != wildcard && segment != key {
+				continue
+			}
+			if len(rest) > 0 {
+				changed = prune(child, rest, maxLength) || changed
+				continue
+			}
+			if maxLength == 0 {
+				delete(n, key)
+				changed = true
+			} else if s, ok := truncate(child, maxLength); ok {
+				n[key] = s
+				changed = true
+			}
+		}
+	case []interface{}:
+		for i, child := range n {
+			if segment != wildcard && segment != strconv.Itoa(i) {
+				continue
+			}
+			if len(rest) > 0 {
+				changed = prune(child, rest, maxLength) || changed
+				continue
+			}
+			// The elements of the arrays are not removed, to keep the indexes of the others, e.g., the message indexes
+			if maxLength == 0 {
+				n[i] = nil
+				changed = true
+			} else if s, ok := truncate(child, maxLength); ok {
+				n[i] = s
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// truncate returns the string value truncated to maxLength characters followed by "...", and false if the value is not a
+// string or is not longer than maxLength.
+func truncate(value interface{}, maxLength int) (string, bool) {
+	s, ok := value.(string)
+	if !ok {
+		return "", false
+	}
+	runes := []rune(s)
+	if len(runes) <= maxLength {
+		return "", false
+	}
+	return string(runes[:maxLength]) + "...", true
+}
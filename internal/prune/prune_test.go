@@ -0,0 +1,102 @@
+package prune_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/prune"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected prune.Rule
+		wantErr  bool
+	}{
+		{
+			name:     "drop",
+			input:    "tx.body.messages.*.wasmByteCode",
+			expected: prune.Rule{Path: []string{"tx", "body", "messages", "*", "wasmByteCode"}},
+		},
+		{
+			name:     "truncate",
+			input:    "tx.body.messages.0.clientMessage=64",
+			expected: prune.Rule{Path: []string{"tx", "body", "messages", "0", "clientMessage"}, MaxLength: 64},
+		},
+		{name: "empty", input: "", wantErr: true},
+		{name: "empty segment", input: "tx..body", wantErr: true},
+		{name: "invalid length", input: "tx.body=abc", wantErr: true},
+		{name: "zero length", input: "tx.body=0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := prune.ParseRule(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, r)
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	const tx = `{"tx":{"body":{"memo":"deposit","messages":[` +
+		`{"@type":"/cosmwasm.wasm.v1.MsgStoreCode","wasmByteCode":"AGFzbQEAAAAB"},` +
+		`{"@type":"/ibc.core.client.v1.MsgUpdateClient","clientMessage":{"header":"..."}}]}},"txResponse":{"height":"42"}}`
+
+	tests := []struct {
+		name     string
+		rules    []string
+		expected string
+	}{
+		{
+			name:     "drop wildcard",
+			rules:    []string{"tx.body.messages.*.wasmByteCode"},
+			expected: `{"tx":{"body":{"memo":"deposit","messages":[{"@type":"/cosmwasm.wasm.v1.MsgStoreCode"},{"@type":"/ibc.core.client.v1.MsgUpdateClient","clientMessage":{"header":"..."}}]}},"txResponse":{"height":"42"}}`,
+		},
+		{
+			name:     "drop object at index",
+			rules:    []string{"tx.body.messages.1.clientMessage"},
+			expected: `{"tx":{"body":{"memo":"deposit","messages":[{"@type":"/cosmwasm.wasm.v1.MsgStoreCode","wasmByteCode":"AGFzbQEAAAAB"},{"@type":"/ibc.core.client.v1.MsgUpdateClient"}]}},"txResponse":{"height":"42"}}`,
+		},
+		{
+			name:     "truncate",
+			rules:    []string{"tx.body.messages.*.wasmByteCode=4", "tx.body.memo=10"},
+			expected: `{"tx":{"body":{"memo":"deposit","messages":[{"@type":"/cosmwasm.wasm.v1.MsgStoreCode","wasmByteCode":"AGFz..."},{"@type":"/ibc.core.client.v1.MsgUpdateClient","clientMessage":{"header":"..."}}]}},"txResponse":{"height":"42"}}`,
+		},
+		{
+			name:     "array elements are nulled",
+			rules:    []string{"tx.body.messages.0"},
+			expected: `{"tx":{"body":{"memo":"deposit","messages":[null,{"@type":"/ibc.core.client.v1.MsgUpdateClient","clientMessage":{"header":"..."}}]}},"txResponse":{"height":"42"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := prune.NewPruner(tt.rules)
+			require.NoError(t, err)
+			pruned, err := p.Prune([]byte(tx))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(pruned))
+		})
+	}
+}
+
+func TestPruneUnchanged(t *testing.T) {
+	p, err := prune.NewPruner([]string{"tx.body.messages.*.wasmByteCode"})
+	require.NoError(t, err)
+
+	data := []byte(`{"tx": {"body": {"memo": "1.50"}}}`)
+	pruned, err := p.Prune(data)
+	require.NoError(t, err)
+	assert.Equal(t, data, pruned)
+
+	_, err = p.Prune([]byte(`{`))
+	require.Error(t, err)
+}
@@ -0,0 +1,68 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCursor is the error of the cursors that are malformed, or whose key is not a key of the queried rows.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of the last row of a page, whose next page starts after it. Key breaks the ties between the
+// rows of the same height, e.g., a transaction hash; it is empty when the rows are identified by their height.
+type Cursor struct {
+	Height uint64
+	Key    string
+}
+
+// String encodes the cursor as HEIGHT or HEIGHT:KEY.
+func (c Cursor) String() string {
+	if c.Key == "" {
+		return strconv.FormatUint(c.Height, 10)
+	}
+	return strconv.FormatUint(c.Height, 10) + ":" + c.Key
+}
+
+// ParseCursor parses a cursor encoded by String.
+func ParseCursor(s string) (Cursor, error) {
+	heightStr, key, _ := strings.Cut(s, ":")
+	height, err := strconv.ParseUint(heightStr, 10, 64)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w %q", ErrInvalidCursor, s)
+	}
+	return Cursor{Height: height, Key: key}, nil
+}
+
+// LastCursor returns the cursor of the last row of the result, read from its height column and its key columns, whose
+// values are joined with colons.
+func (r *Result) LastCursor(heightColumn string, keyColumns ...string) (Cursor, error) {
+	var cursor Cursor
+	if len(r.Rows) == 0 {
+		return cursor, fmt.Errorf("no row")
+	}
+	last := r.Rows[len(r.Rows)-1]
+
+	i := slices.Index(r.Columns, heightColumn)
+	if i < 0 {
+		return cursor, fmt.Errorf("missing cursor column %s", heightColumn)
+	}
+	height, err := strconv.ParseUint(fmt.Sprint(last[i]), 10, 64)
+	if err != nil {
+		return cursor, fmt.Errorf("invalid cursor height %v", last[i])
+	}
+	cursor.Height = height
+
+	keys := make([]string, len(keyColumns))
+	for j, column := range keyColumns {
+		i := slices.Index(r.Columns, column)
+		if i < 0 {
+			return cursor, fmt.Errorf("missing cursor column %s", column)
+		}
+		keys[j] = fmt.Sprint(last[i])
+	}
+	cursor.Key = strings.Join(keys, ":")
+	return cursor, nil
+}
@@ -0,0 +1,85 @@
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ErrTextSearchDisabled is the error of the text searches of a database whose text search columns were not added.
+var ErrTextSearchDisabled = errors.New("text search is not enabled")
+
+// Result is the result of a canned query, whose columns are kept in the order of the query.
+type Result struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// Print writes the result as a table, with a header line, or "No results" when it has no row.
+func (r *Result) Print(w io.Writer) error {
+	if len(r.Rows) == 0 {
+		_, err := fmt.Fprintln(w, "No results")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(r.Columns, "\t")))
+	for _, row := range r.Rows {
+		values := make([]string, len(row))
+		for i, v := range row {
+			values[i] = formatValue(v)
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	return tw.Flush()
+}
+
+// MarshalJSON encodes the result as an array of objects whose keys are in the order of the columns.
+func (r *Result) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, row := range r.Rows {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		for j, v := range row {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(r.Columns[j])
+			if err != nil {
+				return nil, err
+			}
+			value, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode column %s: %w", r.Columns[j], err)
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+// formatValue returns the value of a table cell, on a single line.
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case string:
+		return strings.Join(strings.Fields(v), " ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,95 @@
+package query_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/query"
+)
+
+func newResult() *query.Result {
+	return &query.Result{
+		Columns: []string{"height", "hash", "time", "memo"},
+		Rows: [][]any{
+			{int64(12), "AA", time.Date(2026, 1, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600)), "multi\nline  memo"},
+			{int64(9), "BB", nil, nil},
+		},
+	}
+}
+
+func TestResultPrint(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, newResult().Print(&buf))
+	assert.Equal(t, ""+
+		"HEIGHT  HASH  TIME                  MEMO\n"+
+		"12      AA    2026-01-01T11:00:00Z  multi line memo\n"+
+		"9       BB                          \n", buf.String())
+}
+
+func TestResultPrintEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, (&query.Result{Columns: []string{"height"}}).Print(&buf))
+	assert.Equal(t, "No results\n", buf.String())
+}
+
+func TestResultMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(newResult())
+	require.NoError(t, err)
+	assert.Equal(t, `[{"height":12,"hash":"AA","time":"2026-01-01T12:00:00+01:00","memo":"multi\nline  memo"},{"height":9,"hash":"BB","time":null,"memo":null}]`, string(data))
+
+	data, err = json.Marshal(&query.Result{Columns: []string{"height"}})
+	require.NoError(t, err)
+	assert.Equal(t, `[]`, string(data))
+}
+
+func TestParseCursor(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected query.Cursor
+		wantErr  bool
+	}{
+		{name: "height", input: "1200", expected: query.Cursor{Height: 1200}},
+		{name: "height and key", input: "1200:abcd", expected: query.Cursor{Height: 1200, Key: "abcd"}},
+		{name: "invalid height", input: "abc:1", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := query.ParseCursor(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cursor)
+			assert.Equal(t, tt.input, cursor.String())
+		})
+	}
+}
+
+func TestResultLastCursor(t *testing.T) {
+	result := &query.Result{
+		Columns: []string{"height", "tx_hash", "msg_index"},
+		Rows:    [][]any{{int64(12), "aa", int32(0)}, {int64(9), "bb", int32(1)}},
+	}
+
+	cursor, err := result.LastCursor("height")
+	require.NoError(t, err)
+	assert.Equal(t, query.Cursor{Height: 9}, cursor)
+
+	cursor, err = result.LastCursor("height", "tx_hash", "msg_index")
+	require.NoError(t, err)
+	assert.Equal(t, query.Cursor{Height: 9, Key: "bb:1"}, cursor)
+
+	_, err = result.LastCursor("height", "id")
+	require.Error(t, err)
+	_, err = (&query.Result{Columns: result.Columns}).LastCursor("height")
+	require.Error(t, err)
+}
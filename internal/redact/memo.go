@@ -0,0 +1,150 @@
+// Package redact redacts the privacy-sensitive fields of the transactions before they are stored.
+package redact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// Memo redaction modes.
+const (
+	ModeHash     = "hash"
+	ModeTruncate = "truncate"
+)
+
+// truncatedMemoLength is the number of characters kept by the truncate mode.
+const truncatedMemoLength = 16
+
+// MemoRedactor replaces the memos matching any of its patterns with their SHA-256 hash, or truncates them.
+type MemoRedactor struct {
+	patterns []*regexp.Regexp
+	mode     string
+}
+
+// NewMemoRedactor compiles the patterns of a redactor with the given mode.
+func NewMemoRedactor(patterns []string, mode string) (*MemoRedactor, error) {
+	if mode != ModeHash && mode != ModeTruncate {
+		return nil, fmt.Errorf("invalid memo redaction mode %q, expected %s or %s", mode, ModeHash, ModeTruncate)
+	}
+
+	r := &MemoRedactor{mode: mode}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid memo pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns the redacted memo, and false if the memo matches none of the patterns.
+func (r *MemoRedactor) Redact(memo string) (string, bool) {
+	for _, re := range r.patterns {
+		if !re.MatchString(memo) {
+			continue
+		}
+		if r.mode == ModeTruncate {
+			runes := []rune(memo)
+			if len(runes) <= truncatedMemoLength {
+				return memo, false
+			}
+			return string(runes[:truncatedMemoLength]) + "...", true
+		}
+		hash := sha256.Sum256([]byte(memo))
+		return "sha256:" + hex.EncodeToString(hash[:]), true
+	}
+	return memo, false
+}
+
+// Transaction redacts the memo of the JSON of a GetTx response, in the decoded transaction and in the transaction of
+// the response. The data is returned as is if the memo matches none of the patterns.
+func (r *MemoRedactor) Transaction(data []byte) ([]byte, error) {
+	var memos struct {
+		Tx struct {
+			Body struct {
+				Memo string `json:"memo"`
+			} `json:"body"`
+		} `json:"tx"`
+	}
+	if err := json.Unmarshal(data, &memos); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transaction: %w", err)
+	}
+	redacted, ok := r.Redact(memos.Tx.Body.Memo)
+	if !ok {
+		return data, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var tx map[string]interface{}
+	if err := decoder.Decode(&tx); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transaction: %w", err)
+	}
+
+	setMemo(tx["tx"], redacted)
+	if txResponse, ok := tx["txResponse"].(map[string]interface{}); ok {
+		setMemo(txResponse["tx"], redacted)
+	}
+	return json.Marshal(tx)
+}
+
+// Block redacts the memos of the transactions of the JSON of a GetBlockWithTxs response, in its decoded transactions.
+// The raw transactions of the block, whose bytes hold the memos as is, are dropped from the block if any memo is
+// redacted. The data is returned as is if no memo matches the patterns.
+func (r *MemoRedactor) Block(data []byte) ([]byte, error) {
+	var memos struct {
+		Txs []struct {
+			Body struct {
+				Memo string `json:"memo"`
+			} `json:"body"`
+		} `json:"txs"`
+	}
+	if err := json.Unmarshal(data, &memos); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal block: %w", err)
+	}
+	redacted := make(map[int]string) // By index of the transaction
+	for i, tx := range memos.Txs {
+		if memo, ok := r.Redact(tx.Body.Memo); ok {
+			redacted[i] = memo
+		}
+	}
+	if len(redacted) == 0 {
+		return data, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var block map[string]interface{}
+	if err := decoder.Decode(&block); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal block: %w", err)
+	}
+
+	txs, _ := block["txs"].([]interface{})
+	for i, memo := range redacted {
+		if i < len(txs) {
+			setMemo(txs[i], memo)
+		}
+	}
+	blockData, _ := block["block"].(map[string]interface{})
+	if dataField, ok := blockData["data"].(map[string]interface{}); ok {
+		delete(dataField, "txs")
+	}
+	return json.Marshal(block)
+}
+
+// setMemo sets the memo of the body of a decoded transaction, if any.
+func setMemo(tx interface{}, memo string) {
+	txMap, _ := tx.(map[string]interface{})
+	body, ok := txMap["body"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if _, ok := body["memo"]; ok {
+		body["memo"] = memo
+	}
+}
@@ -0,0 +1,71 @@
+package redact_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/redact"
+)
+
+func TestMemoRedactor(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		memo     string
+		expected string
+		redacted bool
+	}{
+		{name: "hash", mode: redact.ModeHash, memo: "user@example.com", expected: "sha256:b4c9a289323b21a01c3e940f150eb9b8c542587f1abfd8f0e1cc1ffc5e475514", redacted: true},
+		{name: "truncate", mode: redact.ModeTruncate, memo: "contact me at user@example.com", expected: "contact me at us...", redacted: true},
+		{name: "short truncate", mode: redact.ModeTruncate, memo: "a@b.co", expected: "a@b.co"},
+		{name: "no match", mode: redact.ModeHash, memo: "gm", expected: "gm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := redact.NewMemoRedactor([]string{`@`}, tt.mode)
+			require.NoError(t, err)
+
+			memo, redacted := r.Redact(tt.memo)
+			assert.Equal(t, tt.expected, memo)
+			assert.Equal(t, tt.redacted, redacted)
+		})
+	}
+}
+
+func TestMemoRedactorTransaction(t *testing.T) {
+	r, err := redact.NewMemoRedactor([]string{`^secret`}, redact.ModeTruncate)
+	require.NoError(t, err)
+
+	data := []byte(`{"tx":{"body":{"memo":"secret memo of a user","timeoutHeight":"0"}},"txResponse":{"height":"10","tx":{"@type":"/cosmos.tx.v1beta1.Tx","body":{"memo":"secret memo of a user"}}}}`)
+	redacted, err := r.Transaction(data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"tx":{"body":{"memo":"secret memo of a...","timeoutHeight":"0"}},"txResponse":{"height":"10","tx":{"@type":"/cosmos.tx.v1beta1.Tx","body":{"memo":"secret memo of a..."}}}}`, string(redacted))
+
+	unchanged := []byte(`{"tx":{"body":{"memo":"public"}}}`)
+	redacted, err = r.Transaction(unchanged)
+	require.NoError(t, err)
+	assert.Equal(t, unchanged, redacted)
+
+	_, err = redact.NewMemoRedactor([]string{`(`}, redact.ModeHash)
+	assert.Error(t, err)
+	_, err = redact.NewMemoRedactor(nil, "drop")
+	assert.Error(t, err)
+}
+
+func TestMemoRedactorBlock(t *testing.T) {
+	r, err := redact.NewMemoRedactor([]string{`^secret`}, redact.ModeTruncate)
+	require.NoError(t, err)
+
+	data := []byte(`{"txs":[{"body":{"memo":"gm"}},{"body":{"memo":"secret memo of a user"}}],"block":{"header":{"height":"10"},"data":{"txs":["Z20=","c2VjcmV0"]}}}`)
+	redacted, err := r.Block(data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"txs":[{"body":{"memo":"gm"}},{"body":{"memo":"secret memo of a..."}}],"block":{"header":{"height":"10"},"data":{}}}`, string(redacted))
+
+	unchanged := []byte(`{"txs":[{"body":{"memo":"public"}}],"block":{"data":{"txs":["cHVibGlj"]}}}`)
+	redacted, err = r.Block(unchanged)
+	require.NoError(t, err)
+	assert.Equal(t, unchanged, redacted)
+}
@@ -0,0 +1,73 @@
+package reflection
+
+import (
+	"fmt"
+	"os"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// LoadFileDescriptorSet reads the file descriptors of a serialized FileDescriptorSet, e.g., built with
+// `buf build -o chain.pb` or `protoc --include_imports --descriptor_set_out=chain.pb`.
+// The well-known dependencies missing from the set, such as google/protobuf/any.proto, are added when known.
+func LoadFileDescriptorSet(path string) ([]*descriptorpb.FileDescriptorProto, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set: %w", err)
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal descriptor set %s: %w", path, err)
+	}
+	if len(set.File) == 0 {
+		return nil, fmt.Errorf("descriptor set %s is empty", path)
+	}
+
+	return AddKnownDependencies(set.File), nil
+}
+
+// AddKnownDependencies adds the dependencies missing from the descriptors that are linked in the binary, such as the
+// well-known types, so that a partial set of descriptors can still be built.
+func AddKnownDependencies(descriptors []*descriptorpb.FileDescriptorProto) []*descriptorpb.FileDescriptorProto {
+	names := make(map[string]bool, len(descriptors))
+	for _, fd := range descriptors {
+		names[fd.GetName()] = true
+	}
+
+	result := descriptors
+	for i := 0; i < len(result); i++ {
+		for _, dep := range result[i].Dependency {
+			if names[dep] {
+				continue
+			}
+			fd, err := protoregistry.GlobalFiles.FindFileByPath(dep)
+			if err != nil {
+				continue
+			}
+			names[dep] = true
+			result = append(result, protodesc.ToFileDescriptorProto(fd))
+		}
+	}
+	return result
+}
+
+// MergeDescriptors merges two lists of file descriptors. The preferred descriptors replace the other descriptors of
+// the same file.
+func MergeDescriptors(preferred, others []*descriptorpb.FileDescriptorProto) []*descriptorpb.FileDescriptorProto {
+	names := make(map[string]bool, len(preferred))
+	result := make([]*descriptorpb.FileDescriptorProto, 0, len(preferred)+len(others))
+	for _, fd := range preferred {
+		names[fd.GetName()] = true
+		result = append(result, fd)
+	}
+	for _, fd := range others {
+		if !names[fd.GetName()] {
+			result = append(result, fd)
+		}
+	}
+	return result
+}
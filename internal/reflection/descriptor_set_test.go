@@ -0,0 +1,73 @@
+package reflection_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/manifest-network/yaci/internal/reflection"
+)
+
+func TestLoadFileDescriptorSet(t *testing.T) {
+	set := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			{
+				Name:       proto.String("chain/module/v1/tx.proto"),
+				Package:    proto.String("chain.module.v1"),
+				Dependency: []string{"google/protobuf/any.proto"},
+				MessageType: []*descriptorpb.DescriptorProto{{
+					Name: proto.String("MsgDo"),
+					Field: []*descriptorpb.FieldDescriptorProto{{
+						Name:     proto.String("payload"),
+						Number:   proto.Int32(1),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".google.protobuf.Any"),
+					}},
+				}},
+				Syntax: proto.String("proto3"),
+			},
+		},
+	}
+	data, err := proto.Marshal(set)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "chain.pb")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	descriptors, err := reflection.LoadFileDescriptorSet(path)
+	require.NoError(t, err)
+	require.Len(t, descriptors, 2)
+	assert.Equal(t, "google/protobuf/any.proto", descriptors[1].GetName())
+
+	files, err := reflection.BuildFileDescriptorSet(descriptors)
+	require.NoError(t, err)
+	_, err = files.FindDescriptorByName("chain.module.v1.MsgDo")
+	assert.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("not a descriptor set"), 0o600))
+	_, err = reflection.LoadFileDescriptorSet(path)
+	assert.Error(t, err)
+
+	_, err = reflection.LoadFileDescriptorSet(filepath.Join(t.TempDir(), "missing.pb"))
+	assert.Error(t, err)
+}
+
+func TestMergeDescriptors(t *testing.T) {
+	preferred := []*descriptorpb.FileDescriptorProto{
+		{Name: proto.String("a.proto"), Package: proto.String("offline")},
+	}
+	others := []*descriptorpb.FileDescriptorProto{
+		{Name: proto.String("a.proto"), Package: proto.String("reflection")},
+		{Name: proto.String("b.proto"), Package: proto.String("reflection")},
+	}
+
+	merged := reflection.MergeDescriptors(preferred, others)
+	require.Len(t, merged, 2)
+	assert.Equal(t, "offline", merged[0].GetPackage())
+	assert.Equal(t, "b.proto", merged[1].GetName())
+}
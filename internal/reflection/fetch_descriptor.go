@@ -12,7 +12,7 @@ import (
 )
 
 // FetchAllDescriptors retrieves all file descriptors supported by the server.
-func FetchAllDescriptors(ctx context.Context, grpcClient *grpc.ClientConn, maxRetries uint) ([]*descriptorpb.FileDescriptorProto, error) {
+func FetchAllDescriptors(ctx context.Context, grpcClient grpc.ClientConnInterface, maxRetries uint) ([]*descriptorpb.FileDescriptorProto, error) {
 	seenFiles := make(map[string]*descriptorpb.FileDescriptorProto)
 
 	// List all services
@@ -39,7 +39,7 @@ func FetchAllDescriptors(ctx context.Context, grpcClient *grpc.ClientConn, maxRe
 }
 
 // listServices lists all services provided by the server via reflection.
-func listServices(ctx context.Context, grpcClient *grpc.ClientConn, maxRetries uint) ([]string, error) {
+func listServices(ctx context.Context, grpcClient grpc.ClientConnInterface, maxRetries uint) ([]string, error) {
 	req := &reflection.ServerReflectionRequest{
 		MessageRequest: &reflection.ServerReflectionRequest_ListServices{
 			ListServices: "*",
@@ -65,7 +65,7 @@ func listServices(ctx context.Context, grpcClient *grpc.ClientConn, maxRetries u
 }
 
 // fetchFileDescriptors fetches the file descriptors containing the given symbol and their dependencies.
-func fetchFileDescriptors(ctx context.Context, grpcClient *grpc.ClientConn, symbol string, seen map[string]*descriptorpb.FileDescriptorProto, maxRetries uint) error {
+func fetchFileDescriptors(ctx context.Context, grpcClient grpc.ClientConnInterface, symbol string, seen map[string]*descriptorpb.FileDescriptorProto, maxRetries uint) error {
 	if _, exists := seen[symbol]; exists {
 		return nil
 	}
@@ -85,7 +85,7 @@ func fetchFileDescriptors(ctx context.Context, grpcClient *grpc.ClientConn, symb
 }
 
 // fetchFileByName fetches the file descriptor by filename and its dependencies.
-func fetchFileByName(ctx context.Context, grpcClient *grpc.ClientConn, name string, seen map[string]*descriptorpb.FileDescriptorProto, maxRetries uint) error {
+func fetchFileByName(ctx context.Context, grpcClient grpc.ClientConnInterface, name string, seen map[string]*descriptorpb.FileDescriptorProto, maxRetries uint) error {
 	if _, exists := seen[name]; exists {
 		return nil
 	}
@@ -105,7 +105,7 @@ func fetchFileByName(ctx context.Context, grpcClient *grpc.ClientConn, name stri
 }
 
 // fetchFileDescriptorsFromRequest sends a reflection request and returns the file descriptors.
-func fetchFileDescriptorsFromRequest(ctx context.Context, grpcClient *grpc.ClientConn, req *reflection.ServerReflectionRequest, maxRetries uint) ([]*descriptorpb.FileDescriptorProto, error) {
+func fetchFileDescriptorsFromRequest(ctx context.Context, grpcClient grpc.ClientConnInterface, req *reflection.ServerReflectionRequest, maxRetries uint) ([]*descriptorpb.FileDescriptorProto, error) {
 	resp, err := sendReflectionRequestWithRetry(ctx, grpcClient, req, maxRetries)
 	if err != nil {
 		return nil, err
@@ -129,7 +129,7 @@ func fetchFileDescriptorsFromRequest(ctx context.Context, grpcClient *grpc.Clien
 }
 
 // processFileDescriptors processes the fetched file descriptors and recursively fetches their dependencies.
-func processFileDescriptors(ctx context.Context, grpcClient *grpc.ClientConn, fdProtos []*descriptorpb.FileDescriptorProto, seen map[string]*descriptorpb.FileDescriptorProto, maxRetries uint) error {
+func processFileDescriptors(ctx context.Context, grpcClient grpc.ClientConnInterface, fdProtos []*descriptorpb.FileDescriptorProto, seen map[string]*descriptorpb.FileDescriptorProto, maxRetries uint) error {
 	for _, fdProto := range fdProtos {
 		name := fdProto.GetName()
 		if _, exists := seen[name]; exists {
@@ -150,7 +150,7 @@ func processFileDescriptors(ctx context.Context, grpcClient *grpc.ClientConn, fd
 	return nil
 }
 
-func sendReflectionRequestWithRetry(ctx context.Context, grpcClient *grpc.ClientConn, req *reflection.ServerReflectionRequest, maxRetries uint) (*reflection.ServerReflectionResponse, error) {
+func sendReflectionRequestWithRetry(ctx context.Context, grpcClient grpc.ClientConnInterface, req *reflection.ServerReflectionRequest, maxRetries uint) (*reflection.ServerReflectionResponse, error) {
 	var resp *reflection.ServerReflectionResponse
 	var err error
 	for attempt := uint(1); attempt <= maxRetries; attempt++ {
@@ -164,7 +164,7 @@ func sendReflectionRequestWithRetry(ctx context.Context, grpcClient *grpc.Client
 }
 
 // sendReflectionRequest sends a reflection request and returns the response.
-func sendReflectionRequest(ctx context.Context, grpcClient *grpc.ClientConn, req *reflection.ServerReflectionRequest) (*reflection.ServerReflectionResponse, error) {
+func sendReflectionRequest(ctx context.Context, grpcClient grpc.ClientConnInterface, req *reflection.ServerReflectionRequest) (*reflection.ServerReflectionResponse, error) {
 	refClient := reflection.NewServerReflectionClient(grpcClient)
 	stream, err := refClient.ServerReflectionInfo(ctx)
 	if err != nil {
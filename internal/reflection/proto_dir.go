@@ -0,0 +1,71 @@
+package reflection
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// CompileProtoDirs compiles the .proto files found in the directories and returns their file descriptors, with the
+// descriptors of their imports. The directories are also the import paths, so that the files import each other by
+// their path relative to their directory, e.g., "mychain/module/v1/tx.proto"; the well-known imports such as
+// "google/protobuf/any.proto" are provided.
+func CompileProtoDirs(ctx context.Context, dirs []string) ([]*descriptorpb.FileDescriptorProto, error) {
+	var names []string
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !strings.HasSuffix(path, ".proto") {
+				return nil
+			}
+			name, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			names = append(names, filepath.ToSlash(name))
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the .proto files of %s: %w", dir, err)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no .proto file found in %s", strings.Join(dirs, ", "))
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: dirs}),
+	}
+	files, err := compiler.Compile(ctx, names...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile .proto files: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var descriptors []*descriptorpb.FileDescriptorProto
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+		descriptors = append(descriptors, protodesc.ToFileDescriptorProto(fd))
+	}
+	for _, fd := range files {
+		add(fd)
+	}
+	return descriptors, nil
+}
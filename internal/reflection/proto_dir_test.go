@@ -0,0 +1,55 @@
+package reflection_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/manifest-network/yaci/internal/reflection"
+)
+
+func TestCompileProtoDirs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "mychain", "module", "v1"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mychain", "module", "v1", "types.proto"), []byte(`syntax = "proto3";
+package mychain.module.v1;
+
+message Item {
+  string name = 1;
+}
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mychain", "module", "v1", "tx.proto"), []byte(`syntax = "proto3";
+package mychain.module.v1;
+
+import "google/protobuf/any.proto";
+import "mychain/module/v1/types.proto";
+
+message MsgCreate {
+  string creator = 1;
+  Item item = 2;
+  google.protobuf.Any extra = 3;
+}
+`), 0o600))
+
+	descriptors, err := reflection.CompileProtoDirs(context.Background(), []string{dir})
+	require.NoError(t, err)
+
+	files, err := reflection.BuildFileDescriptorSet(descriptors)
+	require.NoError(t, err)
+	for _, name := range []string{"mychain.module.v1.MsgCreate", "mychain.module.v1.Item", "google.protobuf.Any"} {
+		_, err := files.FindDescriptorByName(protoreflect.FullName(name))
+		assert.NoError(t, err, name)
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "invalid.proto"), []byte(`syntax = "proto3"; message {`), 0o600))
+	_, err = reflection.CompileProtoDirs(context.Background(), []string{dir})
+	assert.Error(t, err)
+
+	_, err = reflection.CompileProtoDirs(context.Background(), []string{t.TempDir()})
+	assert.ErrorContains(t, err, "no .proto file found")
+}
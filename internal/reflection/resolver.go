@@ -3,6 +3,7 @@ package reflection
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 
 	"google.golang.org/grpc"
@@ -19,7 +20,7 @@ import (
 // It also correctly resolves message types by fetching dependencies recursively.
 type CustomResolver struct {
 	files       *protoregistry.Files
-	grpcConn    *grpc.ClientConn
+	grpcConn    grpc.ClientConnInterface
 	ctx         context.Context
 	seenSymbols map[string]bool
 	maxRetries  uint
@@ -27,7 +28,8 @@ type CustomResolver struct {
 }
 
 // NewCustomResolver creates a new instance of CustomResolver.
-func NewCustomResolver(ctx context.Context, files *protoregistry.Files, grpcConn *grpc.ClientConn, maxRetries uint) *CustomResolver {
+// Without a gRPC connection, the resolver only resolves the descriptors of files.
+func NewCustomResolver(ctx context.Context, files *protoregistry.Files, grpcConn grpc.ClientConnInterface, maxRetries uint) *CustomResolver {
 	return &CustomResolver{
 		files:       files, // Note: The protoregistry.Files type is safe for concurrent use by multiple goroutines, but it is not safe to concurrently mutate the registry while also being used.
 		grpcConn:    grpcConn,
@@ -68,6 +70,34 @@ func (r *CustomResolver) FindMethodDescriptor(serviceName, methodName string) (p
 	return methodDesc, nil
 }
 
+// HasService returns true if the service is described by the descriptors fetched from the server.
+func (r *CustomResolver) HasService(serviceName string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	desc, err := r.files.FindDescriptorByName(protoreflect.FullName(serviceName))
+	if err != nil {
+		return false
+	}
+	_, ok := desc.(protoreflect.ServiceDescriptor)
+	return ok
+}
+
+// Descriptors returns the descriptors of the files of the resolver, including the ones fetched since it was created,
+// sorted by path.
+func (r *CustomResolver) Descriptors() []*descriptorpb.FileDescriptorProto {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	descriptors := make([]*descriptorpb.FileDescriptorProto, 0, r.files.NumFiles())
+	r.files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		descriptors = append(descriptors, protodesc.ToFileDescriptorProto(fd))
+		return true
+	})
+	sort.Slice(descriptors, func(i, j int) bool { return descriptors[i].GetName() < descriptors[j].GetName() })
+	return descriptors
+}
+
 // FindMessageByName finds a message descriptor by its name.
 func (r *CustomResolver) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
 	// First, try to find the message in the existing registry
@@ -137,6 +167,11 @@ func (r *CustomResolver) fetchDescriptorBySymbol(symbol string) error {
 	r.seenSymbols[symbol] = true
 	r.mu.Unlock()
 
+	// Without a connection, only the supplied descriptors are used
+	if r.grpcConn == nil {
+		return fmt.Errorf("symbol %s is not in the supplied descriptors", symbol)
+	}
+
 	// Create the request to fetch file descriptors containing the symbol
 	req := &reflection.ServerReflectionRequest{
 		MessageRequest: &reflection.ServerReflectionRequest_FileContainingSymbol{
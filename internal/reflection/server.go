@@ -0,0 +1,75 @@
+package reflection
+
+import (
+	"fmt"
+	"log/slog"
+
+	"google.golang.org/grpc"
+	grpcreflection "google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// services advertises the services of the descriptors, which the server does not implement.
+type services map[string]grpc.ServiceInfo
+
+func (s services) GetServiceInfo() map[string]grpc.ServiceInfo {
+	return s
+}
+
+// NewServer returns a gRPC server answering the server reflection requests, v1 and v1alpha, from the descriptors, e.g.,
+// of a stored descriptor snapshot, rather than from the services it implements. The services of the descriptors are
+// advertised, but not served.
+func NewServer(descriptors []*descriptorpb.FileDescriptorProto, opts ...grpc.ServerOption) (*grpc.Server, error) {
+	files, err := BuildFileDescriptorSet(descriptors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build descriptor set: %w", err)
+	}
+
+	advertised := services{}
+	types := &protoregistry.Types{}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		for i := 0; i < fd.Services().Len(); i++ {
+			svc := fd.Services().Get(i)
+			info := grpc.ServiceInfo{Metadata: fd.Path()}
+			for j := 0; j < svc.Methods().Len(); j++ {
+				m := svc.Methods().Get(j)
+				info.Methods = append(info.Methods, grpc.MethodInfo{Name: string(m.Name()), IsClientStream: m.IsStreamingClient(), IsServerStream: m.IsStreamingServer()})
+			}
+			advertised[string(svc.FullName())] = info
+		}
+		registerExtensions(types, fd.Extensions())
+		registerMessageExtensions(types, fd.Messages())
+		return true
+	})
+
+	server := grpc.NewServer(opts...)
+	options := grpcreflection.ServerOptions{Services: advertised, DescriptorResolver: files, ExtensionResolver: types}
+	reflectionv1.RegisterServerReflectionServer(server, grpcreflection.NewServerV1(options))
+	reflectionv1alpha.RegisterServerReflectionServer(server, grpcreflection.NewServer(options))
+	return server, nil
+}
+
+// registerExtensions registers the extensions to resolve them by number. The extensions conflicting with an already
+// registered one are skipped.
+func registerExtensions(types *protoregistry.Types, extensions protoreflect.ExtensionDescriptors) {
+	for i := 0; i < extensions.Len(); i++ {
+		xd := extensions.Get(i)
+		if err := types.RegisterExtension(dynamicpb.NewExtensionType(xd)); err != nil {
+			slog.Debug("Skipped conflicting extension", "extension", xd.FullName(), "error", err)
+		}
+	}
+}
+
+// registerMessageExtensions registers the extensions declared in the messages, recursively.
+func registerMessageExtensions(types *protoregistry.Types, messages protoreflect.MessageDescriptors) {
+	for i := 0; i < messages.Len(); i++ {
+		md := messages.Get(i)
+		registerExtensions(types, md.Extensions())
+		registerMessageExtensions(types, md.Messages())
+	}
+}
@@ -0,0 +1,87 @@
+package reflection_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/manifest-network/yaci/internal/reflection"
+)
+
+func TestNewServer(t *testing.T) {
+	types := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String("chain/types.proto"),
+		Package:     proto.String("chain"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Coin")}},
+	}
+	query := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("chain/query.proto"),
+		Package:    proto.String("chain"),
+		Dependency: []string{"chain/types.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("BalanceRequest")},
+			{Name: proto.String("BalanceResponse"), Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("balance"),
+				Number:   proto.Int32(1),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				TypeName: proto.String(".chain.Coin"),
+			}}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Query"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Balance"),
+				InputType:  proto.String(".chain.BalanceRequest"),
+				OutputType: proto.String(".chain.BalanceResponse"),
+			}},
+		}},
+	}
+
+	server, err := reflection.NewServer([]*descriptorpb.FileDescriptorProto{query, types})
+	require.NoError(t, err)
+	listener := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	ctx := context.Background()
+	dialer := func(context.Context, string) (net.Conn, error) { return listener.Dial() }
+	conn, err := grpc.DialContext(ctx, "", grpc.WithContextDialer(dialer), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// The descriptors served are fetched as from a node
+	descriptors, err := reflection.FetchAllDescriptors(ctx, conn, 1)
+	require.NoError(t, err)
+	names := make([]string, 0, len(descriptors))
+	for _, d := range descriptors {
+		names = append(names, d.GetName())
+	}
+	assert.ElementsMatch(t, []string{"chain/query.proto", "chain/types.proto"}, names)
+
+	files, err := reflection.BuildFileDescriptorSet(descriptors)
+	require.NoError(t, err)
+	resolver := reflection.NewCustomResolver(ctx, files, nil, 1)
+	assert.True(t, resolver.HasService("chain.Query"))
+	snapshot := resolver.Descriptors()
+	require.Len(t, snapshot, 2)
+	assert.True(t, proto.Equal(query, snapshot[0]))
+	assert.True(t, proto.Equal(types, snapshot[1]))
+}
+
+func TestNewServerInvalidDescriptors(t *testing.T) {
+	_, err := reflection.NewServer([]*descriptorpb.FileDescriptorProto{{
+		Name:       proto.String("chain/query.proto"),
+		Dependency: []string{"chain/missing.proto"},
+	}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,194 @@
+package reflection
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const anyFullName = "google.protobuf.Any"
+
+// UnresolvedValueField is the JSON field holding the raw value, in base64, of the Any values whose type could not be
+// resolved.
+const UnresolvedValueField = "unresolvedValue"
+
+// JSONResolver is the resolver of the types of the Any values and of the extensions used by protojson.
+type JSONResolver interface {
+	protoregistry.MessageTypeResolver
+	protoregistry.ExtensionTypeResolver
+}
+
+// unresolvedTypes counts the Any values whose type could not be resolved, by type URL, since the start of the process.
+var unresolvedTypes = struct {
+	sync.Mutex
+	counts map[string]uint64
+}{counts: make(map[string]uint64)}
+
+// UnresolvedTypeURLs returns the number of Any values whose type could not be resolved, by type URL.
+func UnresolvedTypeURLs() map[string]uint64 {
+	unresolvedTypes.Lock()
+	defer unresolvedTypes.Unlock()
+
+	counts := make(map[string]uint64, len(unresolvedTypes.counts))
+	for typeURL, count := range unresolvedTypes.counts {
+		counts[typeURL] = count
+	}
+	return counts
+}
+
+func recordUnresolved(typeURL string) {
+	unresolvedTypes.Lock()
+	defer unresolvedTypes.Unlock()
+
+	if unresolvedTypes.counts[typeURL] == 0 {
+		slog.Warn("Unable to resolve message type, storing its raw value", "type_url", typeURL)
+	}
+	unresolvedTypes.counts[typeURL]++
+}
+
+// MarshalJSON marshals the message to JSON with the resolver. Instead of failing, the Any values whose type cannot be
+// resolved are marshalled with their type URL and their raw value, in base64, in the UnresolvedValueField field, which
+// modifies these values in the message.
+func MarshalJSON(msg proto.Message, resolver JSONResolver) ([]byte, error) {
+	mo := protojson.MarshalOptions{Resolver: resolver}
+	data, err := mo.Marshal(msg)
+	if err == nil {
+		return data, nil
+	}
+
+	fallback := &unresolvedResolver{JSONResolver: resolver, types: make(map[string]protoreflect.MessageType)}
+	if !fallback.replace(msg.ProtoReflect()) {
+		return nil, err
+	}
+	for typeURL := range fallback.types {
+		recordUnresolved(typeURL)
+	}
+
+	mo.Resolver = fallback
+	return mo.Marshal(msg)
+}
+
+// unresolvedResolver resolves the unresolved types to messages holding their raw value.
+type unresolvedResolver struct {
+	JSONResolver
+	types map[string]protoreflect.MessageType // By type URL
+}
+
+func (r *unresolvedResolver) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	if mt, ok := r.types[url]; ok {
+		return mt, nil
+	}
+	return r.JSONResolver.FindMessageByURL(url)
+}
+
+// replace replaces the value of the unresolved Any values found in the message by the encoding of a message holding
+// their raw value, and returns true if any was replaced.
+func (r *unresolvedResolver) replace(m protoreflect.Message) bool {
+	if m.Descriptor().FullName() == anyFullName {
+		return r.replaceAny(m)
+	}
+
+	replaced := false
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsList() && fd.Kind() == protoreflect.MessageKind:
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				replaced = r.replace(list.Get(i).Message()) || replaced
+			}
+		case fd.IsMap() && fd.MapValue().Kind() == protoreflect.MessageKind:
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				replaced = r.replace(mv.Message()) || replaced
+				return true
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Kind() == protoreflect.MessageKind:
+			replaced = r.replace(v.Message()) || replaced
+		}
+		return true
+	})
+	return replaced
+}
+
+func (r *unresolvedResolver) replaceAny(m protoreflect.Message) bool {
+	fields := m.Descriptor().Fields()
+	typeURLField, valueField := fields.ByName("type_url"), fields.ByName("value")
+	if typeURLField == nil || valueField == nil {
+		return false
+	}
+	typeURL := m.Get(typeURLField).String()
+	value := m.Get(valueField).Bytes()
+
+	mt, err := r.JSONResolver.FindMessageByURL(typeURL)
+	if err == nil {
+		// The resolved value may itself hold unresolved Any values
+		inner := mt.New()
+		if err := proto.Unmarshal(value, inner.Interface()); err != nil || !r.replace(inner) {
+			return false
+		}
+		if value, err = proto.Marshal(inner.Interface()); err != nil {
+			return false
+		}
+		m.Set(valueField, protoreflect.ValueOfBytes(value))
+		return true
+	}
+
+	unresolved, ok := r.types[typeURL]
+	if !ok {
+		if unresolved, err = newUnresolvedType(typeURL); err != nil {
+			slog.Debug("Failed to build unresolved message type", "type_url", typeURL, "error", err)
+			return false
+		}
+		r.types[typeURL] = unresolved
+	}
+
+	holder := unresolved.New()
+	holder.Set(holder.Descriptor().Fields().ByNumber(1), protoreflect.ValueOfBytes(value))
+	if value, err = proto.Marshal(holder.Interface()); err != nil {
+		return false
+	}
+	m.Set(valueField, protoreflect.ValueOfBytes(value))
+	return true
+}
+
+// newUnresolvedType builds a message type named after the type URL, with a single bytes field holding a raw value.
+func newUnresolvedType(typeURL string) (protoreflect.MessageType, error) {
+	fullName := typeURL[strings.LastIndex(typeURL, "/")+1:]
+	if !protoreflect.FullName(fullName).IsValid() {
+		return nil, fmt.Errorf("invalid type URL %q", typeURL)
+	}
+
+	pkg, name := "", fullName
+	if i := strings.LastIndex(fullName, "."); i >= 0 {
+		pkg, name = fullName[:i], fullName[i+1:]
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("yaci/unresolved/" + fullName + ".proto"),
+		Package: proto.String(pkg),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String(name),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("unresolved_value"),
+				JsonName: proto.String(UnresolvedValueField),
+				Number:   proto.Int32(1),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_BYTES.Enum(),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			}},
+		}},
+	}
+	fd, err := protodesc.NewFile(file, new(protoregistry.Files))
+	if err != nil {
+		return nil, err
+	}
+	return dynamicpb.NewMessageType(fd.Messages().Get(0)), nil
+}
@@ -0,0 +1,51 @@
+package reflection_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/typepb"
+
+	"github.com/manifest-network/yaci/internal/reflection"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	unresolved := &anypb.Any{TypeUrl: "/unknown.module.v1.MsgDo", Value: []byte{0x0a, 0x03, 'b', 'a', 'r'}}
+	nested, err := anypb.New(&typepb.Option{Name: "option", Value: unresolved})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		msg      *typepb.Option
+		expected string
+	}{
+		{
+			name:     "resolved",
+			msg:      &typepb.Option{Name: "resolved", Value: &anypb.Any{TypeUrl: "type.googleapis.com/google.protobuf.Option"}},
+			expected: `{"name":"resolved","value":{"@type":"type.googleapis.com/google.protobuf.Option"}}`,
+		},
+		{
+			name:     "unresolved",
+			msg:      &typepb.Option{Name: "unresolved", Value: unresolved},
+			expected: `{"name":"unresolved","value":{"@type":"/unknown.module.v1.MsgDo","unresolvedValue":"CgNiYXI="}}`,
+		},
+		{
+			name:     "unresolved in resolved",
+			msg:      &typepb.Option{Name: "nested", Value: nested},
+			expected: `{"name":"nested","value":{"@type":"type.googleapis.com/google.protobuf.Option","name":"option","value":{"@type":"/unknown.module.v1.MsgDo","unresolvedValue":"CgNiYXI="}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := reflection.MarshalJSON(tt.msg, protoregistry.GlobalTypes)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(data))
+		})
+	}
+
+	assert.Equal(t, uint64(2), reflection.UnresolvedTypeURLs()["/unknown.module.v1.MsgDo"])
+}
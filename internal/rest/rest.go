@@ -0,0 +1,256 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/manifest-network/yaci/internal/query"
+)
+
+const (
+	// DefaultLimit is the number of rows of a page when the request sets no limit.
+	DefaultLimit = 50
+	// MaxLimit is the highest limit of a page.
+	MaxLimit = 500
+)
+
+// Store is the extracted data served by the API. The list methods return the rows after the cursor, nil for the first
+// page, ordered by decreasing height.
+type Store interface {
+	QueryBlocks(ctx context.Context, cursor *query.Cursor, limit int) (*query.Result, error)
+	GetBlockData(ctx context.Context, height uint64) (json.RawMessage, error)
+	QueryTransaction(ctx context.Context, hash string) (json.RawMessage, error)
+	QueryAddressTransactions(ctx context.Context, address string, cursor *query.Cursor, limit int) (*query.Result, error)
+	QueryMessageTransactions(ctx context.Context, typeURL string, cursor *query.Cursor, limit int) (*query.Result, error)
+	QueryEvents(ctx context.Context, eventType, key, value string, cursor *query.Cursor, limit int) (*query.Result, error)
+	SearchText(ctx context.Context, text string, limit int) (*query.Result, error)
+	QueryBalances(ctx context.Context, address string, atHeight uint64) (*query.Result, error)
+	QueryDelegations(ctx context.Context, delegator, validator string, atHeight uint64) (*query.Result, error)
+	QueryValidators(ctx context.Context, atHeight uint64) (*query.Result, error)
+}
+
+// Page is a page of rows, along with the cursor of the next page, empty on the last page.
+type Page struct {
+	Data *query.Result `json:"data"`
+	Next string        `json:"next,omitempty"`
+}
+
+// errBadRequest is the error of the invalid requests.
+var errBadRequest = errors.New("bad request")
+
+// NewHandler returns the handler of the API routes over the store.
+func NewHandler(store Store) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/blocks", func(w http.ResponseWriter, r *http.Request) {
+		servePage(w, r, func(cursor *query.Cursor, limit int) (*query.Result, error) {
+			return store.QueryBlocks(r.Context(), cursor, limit)
+		}, "height")
+	})
+	mux.HandleFunc("GET /v1/blocks/{height}", func(w http.ResponseWriter, r *http.Request) {
+		height, err := strconv.ParseUint(r.PathValue("height"), 10, 64)
+		if err != nil {
+			writeError(w, fmt.Errorf("%w: invalid height %q", errBadRequest, r.PathValue("height")))
+			return
+		}
+		data, err := store.GetBlockData(r.Context(), height)
+		serveDocument(w, data, err, "block")
+	})
+	mux.HandleFunc("GET /v1/txs/{hash}", func(w http.ResponseWriter, r *http.Request) {
+		data, err := store.QueryTransaction(r.Context(), r.PathValue("hash"))
+		serveDocument(w, data, err, "transaction")
+	})
+	mux.HandleFunc("GET /v1/txs", func(w http.ResponseWriter, r *http.Request) {
+		address, msgType := r.URL.Query().Get("address"), r.URL.Query().Get("type")
+		if (address == "") == (msgType == "") {
+			writeError(w, fmt.Errorf("%w: exactly one of address and type must be set", errBadRequest))
+			return
+		}
+		servePage(w, r, func(cursor *query.Cursor, limit int) (*query.Result, error) {
+			if address != "" {
+				return store.QueryAddressTransactions(r.Context(), address, cursor, limit)
+			}
+			return store.QueryMessageTransactions(r.Context(), msgType, cursor, limit)
+		}, "height", "hash")
+	})
+	mux.HandleFunc("GET /v1/events", func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		if params.Get("type") == "" {
+			writeError(w, fmt.Errorf("%w: missing type", errBadRequest))
+			return
+		}
+		servePage(w, r, func(cursor *query.Cursor, limit int) (*query.Result, error) {
+			return store.QueryEvents(r.Context(), params.Get("type"), params.Get("key"), params.Get("value"), cursor, limit)
+		}, "height", "id")
+	})
+	mux.HandleFunc("GET /v1/search", func(w http.ResponseWriter, r *http.Request) {
+		text := r.URL.Query().Get("q")
+		if text == "" {
+			writeError(w, fmt.Errorf("%w: missing q", errBadRequest))
+			return
+		}
+		limit, err := limitParam(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		result, err := store.SearchText(r.Context(), text, limit)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, Page{Data: result})
+	})
+	mux.HandleFunc("GET /v1/balances/{address}", func(w http.ResponseWriter, r *http.Request) {
+		serveAtHeight(w, r, func(height uint64) (*query.Result, error) {
+			return store.QueryBalances(r.Context(), r.PathValue("address"), height)
+		})
+	})
+	mux.HandleFunc("GET /v1/delegations", func(w http.ResponseWriter, r *http.Request) {
+		delegator, validator := r.URL.Query().Get("delegator"), r.URL.Query().Get("validator")
+		if (delegator == "") == (validator == "") {
+			writeError(w, fmt.Errorf("%w: exactly one of delegator and validator must be set", errBadRequest))
+			return
+		}
+		serveAtHeight(w, r, func(height uint64) (*query.Result, error) {
+			return store.QueryDelegations(r.Context(), delegator, validator, height)
+		})
+	})
+	mux.HandleFunc("GET /v1/validators", func(w http.ResponseWriter, r *http.Request) {
+		serveAtHeight(w, r, func(height uint64) (*query.Result, error) {
+			return store.QueryValidators(r.Context(), height)
+		})
+	})
+	return mux
+}
+
+// WithCORS allows the requests from origin to the handler, e.g., from the browser of a frontend, and answers their
+// preflight requests. The handler is returned as is if origin is empty.
+func WithCORS(handler http.Handler, origin string) http.Handler {
+	if origin == "" {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// NewServer returns the API server listening on addr.
+func NewServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+}
+
+// servePage writes the page of rows of the limit and cursor parameters. The cursor of the next page is read from the
+// heightColumn and the keyColumns of the last row of a full page.
+func servePage(w http.ResponseWriter, r *http.Request, list func(*query.Cursor, int) (*query.Result, error), heightColumn string, keyColumns ...string) {
+	limit, err := limitParam(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var cursor *query.Cursor
+	if s := r.URL.Query().Get("cursor"); s != "" {
+		c, err := query.ParseCursor(s)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		cursor = &c
+	}
+
+	result, err := list(cursor, limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	page := Page{Data: result}
+	if len(result.Rows) == limit {
+		next, err := result.LastCursor(heightColumn, keyColumns...)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		page.Next = next.String()
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// limitParam returns the limit parameter of the request, DefaultLimit if not set.
+func limitParam(r *http.Request) (int, error) {
+	s := r.URL.Query().Get("limit")
+	if s == "" {
+		return DefaultLimit, nil
+	}
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit <= 0 || limit > MaxLimit {
+		return 0, fmt.Errorf("%w: limit must be between 1 and %d", errBadRequest, MaxLimit)
+	}
+	return limit, nil
+}
+
+// serveAtHeight writes the rows of the state as of the at_height parameter, the latest state if not set.
+func serveAtHeight(w http.ResponseWriter, r *http.Request, state func(uint64) (*query.Result, error)) {
+	var height uint64
+	if s := r.URL.Query().Get("at_height"); s != "" {
+		var err error
+		height, err = strconv.ParseUint(s, 10, 64)
+		if err != nil || height == 0 {
+			writeError(w, fmt.Errorf("%w: invalid at_height %q", errBadRequest, s))
+			return
+		}
+	}
+	result, err := state(height)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, Page{Data: result})
+}
+
+// serveDocument writes a stored JSON document, or a not found error if nil.
+func serveDocument(w http.ResponseWriter, data json.RawMessage, err error, kind string) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if data == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": kind + " not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, data)
+}
+
+// writeError writes the error of a bad request, including an invalid cursor rejected by the store, or of a search while
+// text search is not enabled, or logs an internal error and hides its details.
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errBadRequest) || errors.Is(err, query.ErrInvalidCursor) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, query.ErrTextSearchDisabled) {
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"error": err.Error()})
+		return
+	}
+	slog.Error("Failed to serve API request", "error", err)
+	writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to write API response", "error", err)
+	}
+}
@@ -0,0 +1,269 @@
+package rest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/query"
+	"github.com/manifest-network/yaci/internal/rest"
+)
+
+// fakeStore serves blocks 1 to 5 and a single transaction.
+type fakeStore struct {
+	lastAddress string
+	lastType    string
+	lastCursor  *query.Cursor
+	lastHeight  uint64
+}
+
+func (s *fakeStore) QueryBlocks(_ context.Context, cursor *query.Cursor, limit int) (*query.Result, error) {
+	s.lastCursor = cursor
+	result := &query.Result{Columns: []string{"height", "hash"}, Rows: [][]any{}}
+	height := int64(5)
+	if cursor != nil {
+		height = int64(cursor.Height) - 1
+	}
+	for ; height > 0 && len(result.Rows) < limit; height-- {
+		result.Rows = append(result.Rows, []any{height, "HASH"})
+	}
+	return result, nil
+}
+
+func (s *fakeStore) GetBlockData(_ context.Context, height uint64) (json.RawMessage, error) {
+	if height > 5 {
+		return nil, nil
+	}
+	return json.RawMessage(`{"block":{}}`), nil
+}
+
+func (s *fakeStore) QueryTransaction(_ context.Context, hash string) (json.RawMessage, error) {
+	if hash == "fail" {
+		return nil, errors.New("connection refused")
+	}
+	if hash != "aa" {
+		return nil, nil
+	}
+	return json.RawMessage(`{"tx":{}}`), nil
+}
+
+func (s *fakeStore) QueryAddressTransactions(_ context.Context, address string, cursor *query.Cursor, _ int) (*query.Result, error) {
+	s.lastAddress, s.lastCursor = address, cursor
+	return &query.Result{Columns: []string{"height", "hash"}, Rows: [][]any{{int64(3), "aa"}}}, nil
+}
+
+func (s *fakeStore) QueryMessageTransactions(_ context.Context, typeURL string, cursor *query.Cursor, _ int) (*query.Result, error) {
+	s.lastType, s.lastCursor = typeURL, cursor
+	return &query.Result{Columns: []string{"height", "hash"}, Rows: [][]any{{int64(3), "aa"}}}, nil
+}
+
+func (s *fakeStore) QueryEvents(_ context.Context, _, _, _ string, cursor *query.Cursor, _ int) (*query.Result, error) {
+	s.lastCursor = cursor
+	if cursor != nil {
+		if _, err := strconv.ParseInt(cursor.Key, 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: invalid event key %q", query.ErrInvalidCursor, cursor.Key)
+		}
+	}
+	return &query.Result{Columns: []string{"id", "height"}, Rows: [][]any{{int64(42), int64(3)}}}, nil
+}
+
+func (s *fakeStore) SearchText(_ context.Context, text string, _ int) (*query.Result, error) {
+	if text == "disabled" {
+		return nil, query.ErrTextSearchDisabled
+	}
+	return &query.Result{Columns: []string{"source", "height", "tx_hash", "text"}, Rows: [][]any{{"memo", int64(3), "aa", text}}}, nil
+}
+
+// stateHeight returns the height of the state as of atHeight, 9 for the latest state.
+func stateHeight(atHeight uint64) int64 {
+	if atHeight == 0 {
+		return 9
+	}
+	return int64(atHeight)
+}
+
+func (s *fakeStore) QueryBalances(_ context.Context, address string, atHeight uint64) (*query.Result, error) {
+	s.lastAddress, s.lastHeight = address, atHeight
+	return &query.Result{Columns: []string{"address", "denom", "balance", "height"}, Rows: [][]any{{address, "umfx", "100", stateHeight(atHeight)}}}, nil
+}
+
+func (s *fakeStore) QueryDelegations(_ context.Context, delegator, validator string, atHeight uint64) (*query.Result, error) {
+	s.lastAddress, s.lastHeight = delegator+validator, atHeight
+	return &query.Result{Columns: []string{"height", "delegator_address", "validator_address"}, Rows: [][]any{{stateHeight(atHeight), "manifest1abc", "manifestvaloper1abc"}}}, nil
+}
+
+func (s *fakeStore) QueryValidators(_ context.Context, atHeight uint64) (*query.Result, error) {
+	s.lastHeight = atHeight
+	return &query.Result{Columns: []string{"height", "consensus_address", "signed"}, Rows: [][]any{{stateHeight(atHeight), "AB12", true}}}, nil
+}
+
+func get(t *testing.T, handler http.Handler, target string) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	return recorder, body
+}
+
+func TestBlocksPagination(t *testing.T) {
+	handler := rest.NewHandler(&fakeStore{})
+
+	recorder, body := get(t, handler, "/v1/blocks?limit=2")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, []any{map[string]any{"height": 5.0, "hash": "HASH"}, map[string]any{"height": 4.0, "hash": "HASH"}}, body["data"])
+	assert.Equal(t, "4", body["next"])
+
+	_, body = get(t, handler, "/v1/blocks?limit=3&cursor=4")
+	assert.Len(t, body["data"], 3)
+	assert.Equal(t, "1", body["next"])
+
+	_, body = get(t, handler, "/v1/blocks?limit=3&cursor=1")
+	assert.Equal(t, []any{}, body["data"])
+	assert.NotContains(t, body, "next")
+
+	_, body = get(t, handler, "/v1/blocks?limit=10")
+	assert.Len(t, body["data"], 5)
+	assert.NotContains(t, body, "next")
+}
+
+func TestTransactionsCursor(t *testing.T) {
+	store := &fakeStore{}
+	handler := rest.NewHandler(store)
+
+	_, body := get(t, handler, "/v1/txs?address=manifest1abc&limit=1&cursor=10:bb")
+	assert.Equal(t, "manifest1abc", store.lastAddress)
+	assert.Equal(t, &query.Cursor{Height: 10, Key: "bb"}, store.lastCursor)
+	assert.Equal(t, "3:aa", body["next"])
+
+	get(t, handler, "/v1/txs?type=/cosmos.bank.v1beta1.MsgSend")
+	assert.Equal(t, "/cosmos.bank.v1beta1.MsgSend", store.lastType)
+	assert.Nil(t, store.lastCursor)
+
+	_, body = get(t, handler, "/v1/events?type=transfer&limit=1")
+	assert.Equal(t, "3:42", body["next"])
+}
+
+func TestSearch(t *testing.T) {
+	recorder, body := get(t, rest.NewHandler(&fakeStore{}), "/v1/search?q=deposit&limit=10")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, []any{map[string]any{"source": "memo", "height": 3.0, "tx_hash": "aa", "text": "deposit"}}, body["data"])
+	assert.NotContains(t, body, "next")
+}
+
+func TestAtHeight(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		address string
+		height  uint64
+		want    map[string]any
+	}{
+		{
+			name:    "latest balances",
+			target:  "/v1/balances/manifest1abc",
+			address: "manifest1abc",
+			want:    map[string]any{"address": "manifest1abc", "denom": "umfx", "balance": "100", "height": 9.0},
+		},
+		{
+			name:    "past balances",
+			target:  "/v1/balances/manifest1abc?at_height=4",
+			address: "manifest1abc",
+			height:  4,
+			want:    map[string]any{"address": "manifest1abc", "denom": "umfx", "balance": "100", "height": 4.0},
+		},
+		{
+			name:    "delegations of a delegator",
+			target:  "/v1/delegations?delegator=manifest1abc&at_height=4",
+			address: "manifest1abc",
+			height:  4,
+			want:    map[string]any{"height": 4.0, "delegator_address": "manifest1abc", "validator_address": "manifestvaloper1abc"},
+		},
+		{
+			name:    "delegations to a validator",
+			target:  "/v1/delegations?validator=manifestvaloper1abc",
+			address: "manifestvaloper1abc",
+			want:    map[string]any{"height": 9.0, "delegator_address": "manifest1abc", "validator_address": "manifestvaloper1abc"},
+		},
+		{
+			name:   "past validators",
+			target: "/v1/validators?at_height=4",
+			height: 4,
+			want:   map[string]any{"height": 4.0, "consensus_address": "AB12", "signed": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{}
+			recorder, body := get(t, rest.NewHandler(store), tt.target)
+			require.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, tt.address, store.lastAddress)
+			assert.Equal(t, tt.height, store.lastHeight)
+			assert.Equal(t, []any{tt.want}, body["data"])
+			assert.NotContains(t, body, "next")
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	handler := rest.NewHandler(&fakeStore{})
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+	}{
+		{name: "block", target: "/v1/blocks/3", status: http.StatusOK},
+		{name: "block not found", target: "/v1/blocks/6", status: http.StatusNotFound},
+		{name: "invalid height", target: "/v1/blocks/abc", status: http.StatusBadRequest},
+		{name: "transaction", target: "/v1/txs/aa", status: http.StatusOK},
+		{name: "transaction not found", target: "/v1/txs/bb", status: http.StatusNotFound},
+		{name: "store failure", target: "/v1/txs/fail", status: http.StatusInternalServerError},
+		{name: "no filter", target: "/v1/txs", status: http.StatusBadRequest},
+		{name: "both filters", target: "/v1/txs?address=a&type=b", status: http.StatusBadRequest},
+		{name: "missing event type", target: "/v1/events", status: http.StatusBadRequest},
+		{name: "limit too high", target: "/v1/blocks?limit=501", status: http.StatusBadRequest},
+		{name: "invalid cursor", target: "/v1/blocks?cursor=abc", status: http.StatusBadRequest},
+		{name: "invalid cursor key", target: "/v1/events?type=transfer&cursor=3:abc", status: http.StatusBadRequest},
+		{name: "missing search text", target: "/v1/search", status: http.StatusBadRequest},
+		{name: "text search disabled", target: "/v1/search?q=disabled", status: http.StatusNotImplemented},
+		{name: "invalid at height", target: "/v1/validators?at_height=abc", status: http.StatusBadRequest},
+		{name: "zero at height", target: "/v1/balances/manifest1abc?at_height=0", status: http.StatusBadRequest},
+		{name: "no delegation filter", target: "/v1/delegations", status: http.StatusBadRequest},
+		{name: "both delegation filters", target: "/v1/delegations?delegator=a&validator=b", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder, body := get(t, handler, tt.target)
+			assert.Equal(t, tt.status, recorder.Code)
+			assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+			if tt.status != http.StatusOK {
+				assert.NotEmpty(t, body["error"])
+				assert.NotContains(t, body["error"], "connection refused")
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	recorder, _ := get(t, rest.WithCORS(rest.NewHandler(&fakeStore{}), "https://explorer.example"), "/v1/blocks/1")
+	assert.Equal(t, "https://explorer.example", recorder.Header().Get("Access-Control-Allow-Origin"))
+
+	recorder = httptest.NewRecorder()
+	rest.WithCORS(rest.NewHandler(&fakeStore{}), "*").ServeHTTP(recorder, httptest.NewRequest(http.MethodOptions, "/graphql", nil))
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "Content-Type", recorder.Header().Get("Access-Control-Allow-Headers"))
+
+	recorder, _ = get(t, rest.WithCORS(rest.NewHandler(&fakeStore{}), ""), "/v1/blocks/1")
+	assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,119 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/utils"
+)
+
+const (
+	bankTotalSupplyMethodFullName = "cosmos.bank.v1beta1.Query.TotalSupply"
+	bankDenomOwnersMethodFullName = "cosmos.bank.v1beta1.Query.DenomOwners"
+)
+
+// BalanceSnapshotJob snapshots the bank balances of every holder of every denom at a given height.
+// Denoms are discovered from the total supply, and their holders from the DenomOwners query.
+// Queries are pinned to the snapshot height, so the node must not have pruned the state of that height.
+type BalanceSnapshotJob struct {
+	maxRetries uint
+}
+
+func NewBalanceSnapshotJob(maxRetries uint) *BalanceSnapshotJob {
+	return &BalanceSnapshotJob{maxRetries: maxRetries}
+}
+
+func (j *BalanceSnapshotJob) Name() string {
+	return "balance_snapshot"
+}
+
+func (j *BalanceSnapshotJob) Run(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, height uint64) error {
+	writer, err := output.Require[output.SnapshotWriter](outputHandler, "balance snapshots")
+	if err != nil {
+		return err
+	}
+
+	clientAtHeight := gRPCClient.AtHeight(height)
+
+	supply, err := queryTotalSupply(clientAtHeight, j.maxRetries)
+	if err != nil {
+		return err
+	}
+
+	snapshot := &models.BalanceSnapshot{Height: height}
+	for _, coin := range supply {
+		balances, err := j.listDenomOwners(clientAtHeight, coin.Denom)
+		if err != nil {
+			return err
+		}
+		snapshot.Balances = append(snapshot.Balances, balances...)
+	}
+
+	slog.Debug("Writing balance snapshot", "height", height, "denoms", len(supply), "balances", len(snapshot.Balances))
+	if err := writer.WriteBalanceSnapshot(gRPCClient.Ctx, snapshot); err != nil {
+		return fmt.Errorf("failed to write balance snapshot: %w", err)
+	}
+
+	return nil
+}
+
+func (j *BalanceSnapshotJob) listDenomOwners(gRPCClient *client.GRPCClient, denom string) ([]*models.Balance, error) {
+	params := map[string]interface{}{"denom": denom}
+	pages, err := utils.GetPaginatedGRPCResponse(gRPCClient, bankDenomOwnersMethodFullName, j.maxRetries, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query owners of denom %s: %w", denom, err)
+	}
+
+	var balances []*models.Balance
+	for _, page := range pages {
+		var resp struct {
+			DenomOwners []struct {
+				Address string `json:"address"`
+				Balance coin   `json:"balance"`
+			} `json:"denomOwners"`
+		}
+		if err := json.Unmarshal(page, &resp); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal owners of denom %s: %w", denom, err)
+		}
+		for _, owner := range resp.DenomOwners {
+			balances = append(balances, &models.Balance{
+				Address: owner.Address,
+				Denom:   owner.Balance.Denom,
+				Amount:  owner.Balance.Amount,
+			})
+		}
+	}
+
+	return balances, nil
+}
+
+// coin is the JSON representation of a cosmos.base.v1beta1.Coin.
+type coin struct {
+	Denom  string `json:"denom"`
+	Amount string `json:"amount"`
+}
+
+// queryTotalSupply returns the total supply of every denom.
+func queryTotalSupply(gRPCClient *client.GRPCClient, maxRetries uint) ([]coin, error) {
+	pages, err := utils.GetPaginatedGRPCResponse(gRPCClient, bankTotalSupplyMethodFullName, maxRetries, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query total supply: %w", err)
+	}
+
+	var supply []coin
+	for _, page := range pages {
+		var resp struct {
+			Supply []coin `json:"supply"`
+		}
+		if err := json.Unmarshal(page, &resp); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal total supply: %w", err)
+		}
+		supply = append(supply, resp.Supply...)
+	}
+
+	return supply, nil
+}
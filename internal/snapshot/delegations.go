@@ -0,0 +1,179 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/utils"
+)
+
+const (
+	stakingValidatorsMethodFullName           = "cosmos.staking.v1beta1.Query.Validators"
+	stakingValidatorDelegationsMethodFullName = "cosmos.staking.v1beta1.Query.ValidatorDelegations"
+	stakingValidatorUnbondingsMethodFullName  = "cosmos.staking.v1beta1.Query.ValidatorUnbondingDelegations"
+)
+
+// DelegationSnapshotJob snapshots all staking delegations and unbonding delegations at a given height.
+// Queries are pinned to the snapshot height, so the node must not have pruned the state of that height.
+type DelegationSnapshotJob struct {
+	maxRetries uint
+}
+
+func NewDelegationSnapshotJob(maxRetries uint) *DelegationSnapshotJob {
+	return &DelegationSnapshotJob{maxRetries: maxRetries}
+}
+
+func (j *DelegationSnapshotJob) Name() string {
+	return "delegation_snapshot"
+}
+
+func (j *DelegationSnapshotJob) Run(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, height uint64) error {
+	writer, err := output.Require[output.SnapshotWriter](outputHandler, "delegation snapshots")
+	if err != nil {
+		return err
+	}
+
+	clientAtHeight := gRPCClient.AtHeight(height)
+
+	validators, err := j.listValidators(clientAtHeight)
+	if err != nil {
+		return err
+	}
+
+	snapshot := &models.DelegationSnapshot{Height: height}
+	for _, validator := range validators {
+		delegations, err := j.listDelegations(clientAtHeight, validator)
+		if err != nil {
+			return err
+		}
+		snapshot.Delegations = append(snapshot.Delegations, delegations...)
+
+		unbondings, err := j.listUnbondings(clientAtHeight, validator)
+		if err != nil {
+			return err
+		}
+		snapshot.Unbondings = append(snapshot.Unbondings, unbondings...)
+	}
+
+	slog.Debug("Writing delegation snapshot", "height", height, "validators", len(validators), "delegations", len(snapshot.Delegations), "unbondings", len(snapshot.Unbondings))
+	if err := writer.WriteDelegationSnapshot(gRPCClient.Ctx, snapshot); err != nil {
+		return fmt.Errorf("failed to write delegation snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// listValidators returns the operator addresses of all validators, whatever their bonding status.
+func (j *DelegationSnapshotJob) listValidators(gRPCClient *client.GRPCClient) ([]string, error) {
+	pages, err := utils.GetPaginatedGRPCResponse(gRPCClient, stakingValidatorsMethodFullName, j.maxRetries, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query validators: %w", err)
+	}
+
+	var validators []string
+	for _, page := range pages {
+		var resp struct {
+			Validators []struct {
+				OperatorAddress string `json:"operatorAddress"`
+			} `json:"validators"`
+		}
+		if err := json.Unmarshal(page, &resp); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal validators: %w", err)
+		}
+		for _, v := range resp.Validators {
+			validators = append(validators, v.OperatorAddress)
+		}
+	}
+
+	return validators, nil
+}
+
+func (j *DelegationSnapshotJob) listDelegations(gRPCClient *client.GRPCClient, validator string) ([]*models.Delegation, error) {
+	params := map[string]interface{}{"validator_addr": validator}
+	pages, err := utils.GetPaginatedGRPCResponse(gRPCClient, stakingValidatorDelegationsMethodFullName, j.maxRetries, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query delegations of validator %s: %w", validator, err)
+	}
+
+	var delegations []*models.Delegation
+	for _, page := range pages {
+		var resp struct {
+			DelegationResponses []struct {
+				Delegation struct {
+					DelegatorAddress string `json:"delegatorAddress"`
+					ValidatorAddress string `json:"validatorAddress"`
+					Shares           string `json:"shares"`
+				} `json:"delegation"`
+				Balance struct {
+					Denom  string `json:"denom"`
+					Amount string `json:"amount"`
+				} `json:"balance"`
+			} `json:"delegationResponses"`
+		}
+		if err := json.Unmarshal(page, &resp); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal delegations of validator %s: %w", validator, err)
+		}
+		for _, d := range resp.DelegationResponses {
+			delegations = append(delegations, &models.Delegation{
+				DelegatorAddress: d.Delegation.DelegatorAddress,
+				ValidatorAddress: d.Delegation.ValidatorAddress,
+				Shares:           d.Delegation.Shares,
+				Amount:           d.Balance.Amount,
+				Denom:            d.Balance.Denom,
+			})
+		}
+	}
+
+	return delegations, nil
+}
+
+func (j *DelegationSnapshotJob) listUnbondings(gRPCClient *client.GRPCClient, validator string) ([]*models.UnbondingDelegationEntry, error) {
+	params := map[string]interface{}{"validator_addr": validator}
+	pages, err := utils.GetPaginatedGRPCResponse(gRPCClient, stakingValidatorUnbondingsMethodFullName, j.maxRetries, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unbonding delegations of validator %s: %w", validator, err)
+	}
+
+	var unbondings []*models.UnbondingDelegationEntry
+	for _, page := range pages {
+		var resp struct {
+			UnbondingResponses []struct {
+				DelegatorAddress string `json:"delegatorAddress"`
+				ValidatorAddress string `json:"validatorAddress"`
+				Entries          []struct {
+					CreationHeight string    `json:"creationHeight"`
+					CompletionTime time.Time `json:"completionTime"`
+					InitialBalance string    `json:"initialBalance"`
+					Balance        string    `json:"balance"`
+				} `json:"entries"`
+			} `json:"unbondingResponses"`
+		}
+		if err := json.Unmarshal(page, &resp); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal unbonding delegations of validator %s: %w", validator, err)
+		}
+		for _, u := range resp.UnbondingResponses {
+			for _, e := range u.Entries {
+				creationHeight, err := strconv.ParseUint(e.CreationHeight, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid unbonding creation height %q: %w", e.CreationHeight, err)
+				}
+				unbondings = append(unbondings, &models.UnbondingDelegationEntry{
+					DelegatorAddress: u.DelegatorAddress,
+					ValidatorAddress: u.ValidatorAddress,
+					CreationHeight:   creationHeight,
+					CompletionTime:   e.CompletionTime,
+					InitialBalance:   e.InitialBalance,
+					Balance:          e.Balance,
+				})
+			}
+		}
+	}
+
+	return unbondings, nil
+}
@@ -0,0 +1,156 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/utils"
+)
+
+const (
+	govProposalsMethodFullName = "cosmos.gov.v1.Query.Proposals"
+	govTallyMethodFullName     = "cosmos.gov.v1.Query.TallyResult"
+	govDepositsMethodFullName  = "cosmos.gov.v1.Query.Deposits"
+)
+
+// activeProposalStatuses are the statuses for which deposits and tallies can still change.
+var activeProposalStatuses = map[string]bool{
+	"PROPOSAL_STATUS_DEPOSIT_PERIOD": true,
+	"PROPOSAL_STATUS_VOTING_PERIOD":  true,
+}
+
+// GovProposalsJob keeps the lifecycle state of governance proposals up to date.
+// Deposits and live tallies are only queried for proposals that are still in their deposit or voting period;
+// finished proposals keep their last known deposits and use the final tally result reported by the gov module.
+// Queries are pinned to the height the proposals are recorded at.
+type GovProposalsJob struct {
+	maxRetries uint
+}
+
+func NewGovProposalsJob(maxRetries uint) *GovProposalsJob {
+	return &GovProposalsJob{maxRetries: maxRetries}
+}
+
+func (j *GovProposalsJob) Name() string {
+	return "gov_proposals"
+}
+
+func (j *GovProposalsJob) Run(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, height uint64) error {
+	writer, err := output.Require[output.GovWriter](outputHandler, "governance proposals")
+	if err != nil {
+		return err
+	}
+
+	clientAtHeight := gRPCClient.AtHeight(height)
+
+	pages, err := utils.GetPaginatedGRPCResponse(clientAtHeight, govProposalsMethodFullName, j.maxRetries, nil)
+	if err != nil {
+		return fmt.Errorf("failed to query proposals: %w", err)
+	}
+
+	var proposals []*models.GovProposal
+	for _, page := range pages {
+		var resp struct {
+			Proposals []json.RawMessage `json:"proposals"`
+		}
+		if err := json.Unmarshal(page, &resp); err != nil {
+			return fmt.Errorf("failed to unmarshal proposals: %w", err)
+		}
+
+		for _, raw := range resp.Proposals {
+			proposal, err := j.buildProposal(clientAtHeight, raw, height)
+			if err != nil {
+				return err
+			}
+			proposals = append(proposals, proposal)
+		}
+	}
+
+	if len(proposals) == 0 {
+		return nil
+	}
+
+	slog.Debug("Writing governance proposals", "count", len(proposals), "height", height)
+	if err := writer.WriteGovProposals(gRPCClient.Ctx, proposals); err != nil {
+		return fmt.Errorf("failed to write proposals: %w", err)
+	}
+
+	return nil
+}
+
+// buildProposal builds a proposal model, querying deposits and the current tally for active proposals.
+func (j *GovProposalsJob) buildProposal(gRPCClient *client.GRPCClient, raw json.RawMessage, height uint64) (*models.GovProposal, error) {
+	var header struct {
+		ID               string          `json:"id"`
+		Status           string          `json:"status"`
+		FinalTallyResult json.RawMessage `json:"finalTallyResult"`
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal proposal: %w", err)
+	}
+
+	id, err := strconv.ParseUint(header.ID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proposal id %q: %w", header.ID, err)
+	}
+
+	proposal := &models.GovProposal{
+		ID:     id,
+		Status: header.Status,
+		Data:   raw,
+		Tally:  header.FinalTallyResult,
+		Height: height,
+	}
+
+	if !activeProposalStatuses[header.Status] {
+		return proposal, nil
+	}
+
+	params := map[string]interface{}{"proposal_id": header.ID}
+	paramsBytes, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal proposal parameters: %w", err)
+	}
+
+	tallyBytes, err := utils.GetGRPCResponse(gRPCClient, govTallyMethodFullName, j.maxRetries, paramsBytes)
+	if err != nil {
+		slog.Warn("Failed to query proposal tally", "proposal_id", id, "error", err)
+	} else {
+		var tally struct {
+			Tally json.RawMessage `json:"tally"`
+		}
+		if err := json.Unmarshal(tallyBytes, &tally); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal tally of proposal %d: %w", id, err)
+		}
+		proposal.Tally = tally.Tally
+	}
+
+	depositPages, err := utils.GetPaginatedGRPCResponse(gRPCClient, govDepositsMethodFullName, j.maxRetries, params)
+	if err != nil {
+		slog.Warn("Failed to query proposal deposits", "proposal_id", id, "error", err)
+		return proposal, nil
+	}
+
+	deposits := make([]json.RawMessage, 0)
+	for _, page := range depositPages {
+		var resp struct {
+			Deposits []json.RawMessage `json:"deposits"`
+		}
+		if err := json.Unmarshal(page, &resp); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal deposits of proposal %d: %w", id, err)
+		}
+		deposits = append(deposits, resp.Deposits...)
+	}
+
+	proposal.Deposits, err = json.Marshal(deposits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal deposits of proposal %d: %w", id, err)
+	}
+
+	return proposal, nil
+}
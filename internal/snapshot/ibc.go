@@ -0,0 +1,228 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/utils"
+)
+
+const (
+	ibcClientStatesMethodFullName = "ibc.core.client.v1.Query.ClientStates"
+	ibcClientStatusMethodFullName = "ibc.core.client.v1.Query.ClientStatus"
+	ibcConnectionsMethodFullName  = "ibc.core.connection.v1.Query.Connections"
+	ibcChannelsMethodFullName     = "ibc.core.channel.v1.Query.Channels"
+)
+
+// IBCStateJob keeps the IBC clients, connections and channels, along with their states and counterparties, up to date.
+type IBCStateJob struct {
+	maxRetries uint
+}
+
+func NewIBCStateJob(maxRetries uint) *IBCStateJob {
+	return &IBCStateJob{maxRetries: maxRetries}
+}
+
+func (j *IBCStateJob) Name() string {
+	return "ibc_state"
+}
+
+func (j *IBCStateJob) Run(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, height uint64) error {
+	writer, err := output.Require[output.SnapshotWriter](outputHandler, "IBC state snapshots")
+	if err != nil {
+		return err
+	}
+
+	clientAtHeight := gRPCClient.AtHeight(height)
+
+	clients, err := j.listClients(clientAtHeight)
+	if err != nil {
+		return err
+	}
+
+	connections, err := j.listConnections(clientAtHeight)
+	if err != nil {
+		return err
+	}
+
+	channels, err := j.listChannels(clientAtHeight)
+	if err != nil {
+		return err
+	}
+
+	state := &models.IBCState{
+		Height:      height,
+		Clients:     clients,
+		Connections: connections,
+		Channels:    channels,
+	}
+
+	slog.Debug("Writing IBC state", "height", height, "clients", len(clients), "connections", len(connections), "channels", len(channels))
+	if err := writer.WriteIBCState(gRPCClient.Ctx, state); err != nil {
+		return fmt.Errorf("failed to write IBC state: %w", err)
+	}
+
+	return nil
+}
+
+func (j *IBCStateJob) listClients(gRPCClient *client.GRPCClient) ([]*models.IBCClient, error) {
+	pages, err := utils.GetPaginatedGRPCResponse(gRPCClient, ibcClientStatesMethodFullName, j.maxRetries, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query IBC client states: %w", err)
+	}
+
+	var clients []*models.IBCClient
+	for _, page := range pages {
+		var resp struct {
+			ClientStates []json.RawMessage `json:"clientStates"`
+		}
+		if err := json.Unmarshal(page, &resp); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal IBC client states: %w", err)
+		}
+
+		for _, raw := range resp.ClientStates {
+			var cs struct {
+				ClientID    string `json:"clientId"`
+				ClientState struct {
+					Type    string `json:"@type"`
+					ChainID string `json:"chainId"`
+				} `json:"clientState"`
+			}
+			if err := json.Unmarshal(raw, &cs); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal IBC client state: %w", err)
+			}
+
+			clients = append(clients, &models.IBCClient{
+				ClientID: cs.ClientID,
+				Type:     cs.ClientState.Type,
+				ChainID:  cs.ClientState.ChainID,
+				Status:   j.queryClientStatus(gRPCClient, cs.ClientID),
+				Data:     raw,
+			})
+		}
+	}
+
+	return clients, nil
+}
+
+// queryClientStatus returns the status of a light client (Active, Frozen, Expired...), or an empty string if unknown.
+func (j *IBCStateJob) queryClientStatus(gRPCClient *client.GRPCClient, clientID string) string {
+	params, err := json.Marshal(map[string]string{"client_id": clientID})
+	if err != nil {
+		return ""
+	}
+
+	resp, err := utils.GetGRPCResponse(gRPCClient, ibcClientStatusMethodFullName, j.maxRetries, params)
+	if err != nil {
+		slog.Warn("Failed to query IBC client status", "client_id", clientID, "error", err)
+		return ""
+	}
+
+	var status struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(resp, &status); err != nil {
+		slog.Warn("Failed to unmarshal IBC client status", "client_id", clientID, "error", err)
+		return ""
+	}
+	return status.Status
+}
+
+func (j *IBCStateJob) listConnections(gRPCClient *client.GRPCClient) ([]*models.IBCConnection, error) {
+	pages, err := utils.GetPaginatedGRPCResponse(gRPCClient, ibcConnectionsMethodFullName, j.maxRetries, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query IBC connections: %w", err)
+	}
+
+	var connections []*models.IBCConnection
+	for _, page := range pages {
+		var resp struct {
+			Connections []json.RawMessage `json:"connections"`
+		}
+		if err := json.Unmarshal(page, &resp); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal IBC connections: %w", err)
+		}
+
+		for _, raw := range resp.Connections {
+			var c struct {
+				ID           string `json:"id"`
+				ClientID     string `json:"clientId"`
+				State        string `json:"state"`
+				Counterparty struct {
+					ClientID     string `json:"clientId"`
+					ConnectionID string `json:"connectionId"`
+				} `json:"counterparty"`
+			}
+			if err := json.Unmarshal(raw, &c); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal IBC connection: %w", err)
+			}
+
+			connections = append(connections, &models.IBCConnection{
+				ConnectionID:             c.ID,
+				ClientID:                 c.ClientID,
+				State:                    c.State,
+				CounterpartyClientID:     c.Counterparty.ClientID,
+				CounterpartyConnectionID: c.Counterparty.ConnectionID,
+				Data:                     raw,
+			})
+		}
+	}
+
+	return connections, nil
+}
+
+func (j *IBCStateJob) listChannels(gRPCClient *client.GRPCClient) ([]*models.IBCChannel, error) {
+	pages, err := utils.GetPaginatedGRPCResponse(gRPCClient, ibcChannelsMethodFullName, j.maxRetries, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query IBC channels: %w", err)
+	}
+
+	var channels []*models.IBCChannel
+	for _, page := range pages {
+		var resp struct {
+			Channels []json.RawMessage `json:"channels"`
+		}
+		if err := json.Unmarshal(page, &resp); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal IBC channels: %w", err)
+		}
+
+		for _, raw := range resp.Channels {
+			var c struct {
+				PortID         string   `json:"portId"`
+				ChannelID      string   `json:"channelId"`
+				State          string   `json:"state"`
+				Ordering       string   `json:"ordering"`
+				Version        string   `json:"version"`
+				ConnectionHops []string `json:"connectionHops"`
+				Counterparty   struct {
+					PortID    string `json:"portId"`
+					ChannelID string `json:"channelId"`
+				} `json:"counterparty"`
+			}
+			if err := json.Unmarshal(raw, &c); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal IBC channel: %w", err)
+			}
+
+			channel := &models.IBCChannel{
+				PortID:                c.PortID,
+				ChannelID:             c.ChannelID,
+				State:                 c.State,
+				Ordering:              c.Ordering,
+				Version:               c.Version,
+				CounterpartyPortID:    c.Counterparty.PortID,
+				CounterpartyChannelID: c.Counterparty.ChannelID,
+				Data:                  raw,
+			}
+			if len(c.ConnectionHops) > 0 {
+				channel.ConnectionID = c.ConnectionHops[0]
+			}
+			channels = append(channels, channel)
+		}
+	}
+
+	return channels, nil
+}
@@ -0,0 +1,58 @@
+package snapshot
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/config"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/utils"
+)
+
+// ExtraQueriesJob runs user-defined gRPC queries and stores their responses, so that chain-specific module state can
+// be captured without code changes. Queries are pinned to the height they are run at.
+type ExtraQueriesJob struct {
+	queries    []config.ExtraQuery
+	maxRetries uint
+}
+
+func NewExtraQueriesJob(queries []config.ExtraQuery, maxRetries uint) *ExtraQueriesJob {
+	return &ExtraQueriesJob{queries: queries, maxRetries: maxRetries}
+}
+
+func (j *ExtraQueriesJob) Name() string {
+	return "extra_queries"
+}
+
+// Run runs every query at height. A failing query is logged and skipped, so that it does not prevent the other
+// queries from being recorded.
+func (j *ExtraQueriesJob) Run(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, height uint64) error {
+	writer, err := output.Require[output.SnapshotWriter](outputHandler, "extra queries")
+	if err != nil {
+		return err
+	}
+
+	clientAtHeight := gRPCClient.AtHeight(height)
+
+	var results []*models.ExtraQueryResult
+	for _, q := range j.queries {
+		resp, err := utils.GetGRPCResponse(clientAtHeight, q.Method, j.maxRetries, q.ParamsAt(height))
+		if err != nil {
+			slog.Warn("Extra query failed", "query", q.Name, "method", q.Method, "height", height, "error", err)
+			continue
+		}
+		results = append(results, &models.ExtraQueryResult{Name: q.Name, Height: height, Data: resp})
+	}
+
+	if len(results) == 0 {
+		return fmt.Errorf("all extra queries failed")
+	}
+
+	if err := writer.WriteExtraQueryResults(gRPCClient.Ctx, results); err != nil {
+		return fmt.Errorf("failed to write extra query results: %w", err)
+	}
+
+	return nil
+}
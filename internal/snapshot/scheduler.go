@@ -0,0 +1,91 @@
+// Package snapshot periodically queries module state from the gRPC server and writes it to the output.
+package snapshot
+
+import (
+	"log/slog"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/output"
+)
+
+// Job queries a piece of module state and writes it to the output.
+type Job interface {
+	// Name returns the name of the job, used for logging.
+	Name() string
+
+	// Run queries the module state and writes it to the output.
+	// The height is the block height the state is associated with.
+	Run(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, height uint64) error
+}
+
+type entry struct {
+	job        Job
+	interval   uint64
+	historical bool
+	lastHeight uint64
+}
+
+// Scheduler runs jobs as the extraction progresses through the chain.
+type Scheduler struct {
+	entries []*entry
+}
+
+// NewScheduler creates an empty scheduler.
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add registers a job to be run every interval blocks.
+// Historical jobs are run at every multiple of the interval within the extracted range.
+// Other jobs only track the latest state and are run at most once per extracted range.
+func (s *Scheduler) Add(job Job, interval uint64, historical bool) {
+	if interval == 0 {
+		return
+	}
+	s.entries = append(s.entries, &entry{job: job, interval: interval, historical: historical})
+}
+
+// Empty returns true if no job is registered.
+func (s *Scheduler) Empty() bool {
+	return len(s.entries) == 0
+}
+
+// RunRange runs the jobs that are due within the given range of extracted heights.
+// Job failures are logged and the job is retried at its next due height.
+func (s *Scheduler) RunRange(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, start, stop uint64) {
+	for _, e := range s.entries {
+		for _, height := range e.dueHeights(start, stop) {
+			slog.Debug("Running snapshot job", "job", e.job.Name(), "height", height)
+			if err := e.job.Run(gRPCClient, outputHandler, height); err != nil {
+				if client.IsPrunedStateError(err) {
+					slog.Error("Snapshot job failed, the node pruned the state of this height, use an archive node to query past heights", "job", e.job.Name(), "height", height, "error", err)
+					continue
+				}
+				slog.Error("Snapshot job failed", "job", e.job.Name(), "height", height, "error", err)
+				continue
+			}
+			e.lastHeight = height
+		}
+	}
+}
+
+// dueHeights returns the heights at which the job must run within the given range.
+func (e *entry) dueHeights(start, stop uint64) []uint64 {
+	if start > stop {
+		return nil
+	}
+
+	if !e.historical {
+		if e.lastHeight == 0 || stop-e.lastHeight >= e.interval {
+			return []uint64{stop}
+		}
+		return nil
+	}
+
+	var heights []uint64
+	first := (start + e.interval - 1) / e.interval * e.interval
+	for height := first; height <= stop && height >= first; height += e.interval {
+		heights = append(heights, height)
+	}
+	return heights
+}
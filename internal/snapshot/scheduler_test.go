@@ -0,0 +1,90 @@
+package snapshot_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/snapshot"
+)
+
+type recordingJob struct {
+	heights []uint64
+	err     error
+}
+
+func (j *recordingJob) Name() string { return "recording" }
+
+func (j *recordingJob) Run(_ *client.GRPCClient, _ output.OutputHandler, height uint64) error {
+	j.heights = append(j.heights, height)
+	return j.err
+}
+
+func TestSchedulerRunRange(t *testing.T) {
+	cases := []struct {
+		name       string
+		interval   uint64
+		historical bool
+		ranges     [][2]uint64
+		expected   []uint64
+	}{
+		{
+			name:     "latest state job runs once per range",
+			interval: 10,
+			ranges:   [][2]uint64{{1, 100}},
+			expected: []uint64{100},
+		},
+		{
+			name:     "latest state job waits for the interval",
+			interval: 10,
+			ranges:   [][2]uint64{{1, 100}, {101, 105}, {106, 110}},
+			expected: []uint64{100, 110},
+		},
+		{
+			name:       "historical job runs at every interval height",
+			interval:   10,
+			historical: true,
+			ranges:     [][2]uint64{{5, 35}},
+			expected:   []uint64{10, 20, 30},
+		},
+		{
+			name:       "historical job includes range boundaries",
+			interval:   10,
+			historical: true,
+			ranges:     [][2]uint64{{10, 19}, {20, 20}},
+			expected:   []uint64{10, 20},
+		},
+		{
+			name:     "disabled job never runs",
+			interval: 0,
+			ranges:   [][2]uint64{{1, 100}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			job := &recordingJob{}
+			scheduler := snapshot.NewScheduler()
+			scheduler.Add(job, tc.interval, tc.historical)
+			assert.Equal(t, tc.interval == 0, scheduler.Empty())
+
+			for _, r := range tc.ranges {
+				scheduler.RunRange(nil, nil, r[0], r[1])
+			}
+			assert.Equal(t, tc.expected, job.heights)
+		})
+	}
+}
+
+func TestSchedulerRetriesFailedJob(t *testing.T) {
+	job := &recordingJob{err: errors.New("boom")}
+	scheduler := snapshot.NewScheduler()
+	scheduler.Add(job, 10, false)
+
+	scheduler.RunRange(nil, nil, 1, 100)
+	scheduler.RunRange(nil, nil, 101, 101)
+	assert.Equal(t, []uint64{100, 101}, job.heights)
+}
@@ -0,0 +1,116 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/utils"
+)
+
+const bankDenomsMetadataMethodFullName = "cosmos.bank.v1beta1.Query.DenomsMetadata"
+
+// SupplyJob records the total supply of every denom, along with the bank denom metadata.
+// It only tracks the latest state, but queries are pinned to the height the supply is recorded at.
+type SupplyJob struct {
+	maxRetries uint
+}
+
+func NewSupplyJob(maxRetries uint) *SupplyJob {
+	return &SupplyJob{maxRetries: maxRetries}
+}
+
+func (j *SupplyJob) Name() string {
+	return "supply"
+}
+
+func (j *SupplyJob) Run(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, height uint64) error {
+	writer, err := output.Require[output.SnapshotWriter](outputHandler, "supply snapshots")
+	if err != nil {
+		return err
+	}
+
+	clientAtHeight := gRPCClient.AtHeight(height)
+
+	supply, err := queryTotalSupply(clientAtHeight, j.maxRetries)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := queryDenomsMetadata(clientAtHeight, j.maxRetries)
+	if err != nil {
+		return err
+	}
+
+	snapshot := &models.SupplySnapshot{Height: height, Metadata: metadata}
+	for _, c := range supply {
+		snapshot.Supply = append(snapshot.Supply, &models.Coin{Denom: c.Denom, Amount: c.Amount})
+	}
+
+	slog.Debug("Writing supply snapshot", "height", height, "denoms", len(snapshot.Supply), "metadata", len(metadata))
+	if err := writer.WriteSupplySnapshot(gRPCClient.Ctx, snapshot); err != nil {
+		return fmt.Errorf("failed to write supply snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// queryDenomsMetadata returns the bank metadata of every denom that has one.
+func queryDenomsMetadata(gRPCClient *client.GRPCClient, maxRetries uint) ([]*models.DenomMetadata, error) {
+	pages, err := utils.GetPaginatedGRPCResponse(gRPCClient, bankDenomsMetadataMethodFullName, maxRetries, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query denoms metadata: %w", err)
+	}
+
+	var metadata []*models.DenomMetadata
+	for _, page := range pages {
+		var resp struct {
+			Metadatas []json.RawMessage `json:"metadatas"`
+		}
+		if err := json.Unmarshal(page, &resp); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal denoms metadata: %w", err)
+		}
+
+		for _, raw := range resp.Metadatas {
+			m, err := parseDenomMetadata(raw)
+			if err != nil {
+				return nil, err
+			}
+			metadata = append(metadata, m)
+		}
+	}
+
+	return metadata, nil
+}
+
+func parseDenomMetadata(raw json.RawMessage) (*models.DenomMetadata, error) {
+	var m struct {
+		Base       string `json:"base"`
+		Display    string `json:"display"`
+		Symbol     string `json:"symbol"`
+		DenomUnits []struct {
+			Denom    string `json:"denom"`
+			Exponent uint32 `json:"exponent"`
+		} `json:"denomUnits"`
+	}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal denom metadata: %w", err)
+	}
+
+	metadata := &models.DenomMetadata{
+		Base:    m.Base,
+		Display: m.Display,
+		Symbol:  m.Symbol,
+		Data:    raw,
+	}
+	for _, unit := range m.DenomUnits {
+		if unit.Denom == m.Display {
+			metadata.Exponent = unit.Exponent
+		}
+	}
+
+	return metadata, nil
+}
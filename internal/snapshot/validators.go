@@ -0,0 +1,119 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"golang.org/x/crypto/ripemd160" //nolint:staticcheck // Used by the Cosmos SDK to derive secp256k1 addresses
+
+	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+	"github.com/manifest-network/yaci/internal/utils"
+)
+
+const (
+	ed25519PubKeyType   = "/cosmos.crypto.ed25519.PubKey"
+	secp256k1PubKeyType = "/cosmos.crypto.secp256k1.PubKey"
+)
+
+// ValidatorsJob keeps the validators, along with the consensus addresses identifying them in the block headers, up to
+// date.
+type ValidatorsJob struct {
+	maxRetries uint
+}
+
+func NewValidatorsJob(maxRetries uint) *ValidatorsJob {
+	return &ValidatorsJob{maxRetries: maxRetries}
+}
+
+func (j *ValidatorsJob) Name() string {
+	return "validators"
+}
+
+func (j *ValidatorsJob) Run(gRPCClient *client.GRPCClient, outputHandler output.OutputHandler, height uint64) error {
+	writer, err := output.Require[output.SnapshotWriter](outputHandler, "validator snapshots")
+	if err != nil {
+		return err
+	}
+
+	pages, err := utils.GetPaginatedGRPCResponse(gRPCClient.AtHeight(height), stakingValidatorsMethodFullName, j.maxRetries, nil)
+	if err != nil {
+		return fmt.Errorf("failed to query validators: %w", err)
+	}
+
+	set := &models.ValidatorSet{Height: height}
+	for _, page := range pages {
+		var resp struct {
+			Validators []json.RawMessage `json:"validators"`
+		}
+		if err := json.Unmarshal(page, &resp); err != nil {
+			return fmt.Errorf("failed to unmarshal validators: %w", err)
+		}
+		for _, raw := range resp.Validators {
+			validator, err := parseValidator(raw)
+			if err != nil {
+				return err
+			}
+			set.Validators = append(set.Validators, validator)
+		}
+	}
+
+	slog.Debug("Writing validators", "height", height, "validators", len(set.Validators))
+	if err := writer.WriteValidators(gRPCClient.Ctx, set); err != nil {
+		return fmt.Errorf("failed to write validators: %w", err)
+	}
+
+	return nil
+}
+
+func parseValidator(raw json.RawMessage) (*models.Validator, error) {
+	var v struct {
+		OperatorAddress string `json:"operatorAddress"`
+		ConsensusPubkey struct {
+			Type string `json:"@type"`
+			Key  []byte `json:"key"`
+		} `json:"consensusPubkey"`
+		Jailed      bool   `json:"jailed"`
+		Status      string `json:"status"`
+		Description struct {
+			Moniker string `json:"moniker"`
+		} `json:"description"`
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal validator: %w", err)
+	}
+
+	return &models.Validator{
+		OperatorAddress:  v.OperatorAddress,
+		ConsensusAddress: ConsensusAddress(v.ConsensusPubkey.Type, v.ConsensusPubkey.Key),
+		Moniker:          v.Description.Moniker,
+		Status:           v.Status,
+		Jailed:           v.Jailed,
+		Data:             raw,
+	}, nil
+}
+
+// ConsensusAddress returns the uppercase hexadecimal address of a consensus public key, as used in the block headers,
+// or an empty string if the key type is not supported.
+func ConsensusAddress(keyType string, key []byte) string {
+	if len(key) == 0 {
+		return ""
+	}
+
+	hash := sha256.Sum256(key)
+	switch keyType {
+	case ed25519PubKeyType:
+		return strings.ToUpper(hex.EncodeToString(hash[:20]))
+	case secp256k1PubKeyType:
+		hasher := ripemd160.New()
+		hasher.Write(hash[:])
+		return strings.ToUpper(hex.EncodeToString(hasher.Sum(nil)))
+	default:
+		return ""
+	}
+}
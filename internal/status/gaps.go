@@ -0,0 +1,49 @@
+package status
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+// Gap is an inclusive range of missing heights.
+type Gap struct {
+	Start  uint64 `json:"start"`
+	Stop   uint64 `json:"stop"`
+	Blocks uint64 `json:"blocks"`
+}
+
+// Gaps is the report of the heights missing from the output, ordered by height.
+type Gaps struct {
+	MissingBlocks uint64 `json:"missing_blocks"`
+	Gaps          []Gap  `json:"gaps"`
+}
+
+// NewGaps returns the report of the missing height ranges.
+func NewGaps(ranges []models.HeightRange) Gaps {
+	report := Gaps{Gaps: make([]Gap, 0, len(ranges))}
+	for _, r := range ranges {
+		gap := Gap{Start: r.Start, Stop: r.Stop, Blocks: r.Stop - r.Start + 1}
+		report.Gaps = append(report.Gaps, gap)
+		report.MissingBlocks += gap.Blocks
+	}
+	return report
+}
+
+// Print writes one gap per line, as a height or a height range, followed by the total of missing blocks.
+func (g Gaps) Print(w io.Writer) error {
+	for _, gap := range g.Gaps {
+		var err error
+		if gap.Start == gap.Stop {
+			_, err = fmt.Fprintf(w, "%d\n", gap.Start)
+		} else {
+			_, err = fmt.Fprintf(w, "%d-%d\t(%d blocks)\n", gap.Start, gap.Stop, gap.Blocks)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "Missing blocks: %d in %d gaps\n", g.MissingBlocks, len(g.Gaps))
+	return err
+}
@@ -0,0 +1,44 @@
+package status_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/status"
+)
+
+func TestGaps(t *testing.T) {
+	g := status.NewGaps([]models.HeightRange{{Start: 100, Stop: 150}, {Start: 2000, Stop: 2000}})
+	assert.Equal(t, uint64(52), g.MissingBlocks)
+
+	data, err := json.Marshal(g)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"missing_blocks": 52,
+		"gaps": [
+			{"start": 100, "stop": 150, "blocks": 51},
+			{"start": 2000, "stop": 2000, "blocks": 1}
+		]
+	}`, string(data))
+
+	var out bytes.Buffer
+	require.NoError(t, g.Print(&out))
+	assert.Equal(t, "100-150\t(51 blocks)\n2000\nMissing blocks: 52 in 2 gaps\n", out.String())
+}
+
+func TestGapsEmpty(t *testing.T) {
+	g := status.NewGaps(nil)
+
+	data, err := json.Marshal(g)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"missing_blocks": 0, "gaps": []}`, string(data))
+
+	var out bytes.Buffer
+	require.NoError(t, g.Print(&out))
+	assert.Equal(t, "Missing blocks: 0 in 0 gaps\n", out.String())
+}
@@ -0,0 +1,140 @@
+// Package status reports the progress of the output relative to the node: the stored heights, the missing blocks, the
+// lag behind the tip of the chain and the estimated time to catch up with it.
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Output is the state of the output.
+// IndexedRecently is the number of blocks committed during RateWindow, to measure the indexing rate.
+type Output struct {
+	Earliest        uint64
+	Latest          uint64
+	LatestTime      time.Time
+	Missing         uint64
+	IndexedRecently uint64
+	RateWindow      time.Duration
+}
+
+// Node is the tip of the chain reported by the node.
+type Node struct {
+	Latest     uint64
+	LatestTime time.Time
+}
+
+// Report is the status of the output relative to the node.
+// IndexingRate and ChainRate are in blocks per second. CatchUp is nil when the output does not catch up with the chain at
+// the current rates, e.g., when it is not being indexed. The durations are encoded in seconds in JSON.
+type Report struct {
+	EarliestHeight     uint64         `json:"earliest_height"`
+	LatestHeight       uint64         `json:"latest_height"`
+	LatestTime         *time.Time     `json:"latest_time,omitempty"`
+	MissingBlocks      uint64         `json:"missing_blocks"`
+	NodeHeight         uint64         `json:"node_height"`
+	NodeTime           *time.Time     `json:"node_time,omitempty"`
+	LagBlocks          uint64         `json:"lag_blocks"`
+	LagTime            *time.Duration `json:"-"`
+	IndexingRate       float64        `json:"indexing_rate"`
+	ChainRate          float64        `json:"chain_rate"`
+	CatchUp            *time.Duration `json:"-"`
+	IndexingRateWindow time.Duration  `json:"-"`
+}
+
+// MarshalJSON encodes the durations of the report in seconds.
+func (r Report) MarshalJSON() ([]byte, error) {
+	type report Report
+	seconds := func(d *time.Duration) *float64 {
+		if d == nil {
+			return nil
+		}
+		s := d.Seconds()
+		return &s
+	}
+	return json.Marshal(struct {
+		report
+		LagSeconds                *float64 `json:"lag_seconds,omitempty"`
+		CatchUpSeconds            *float64 `json:"catch_up_seconds,omitempty"`
+		IndexingRateWindowSeconds float64  `json:"indexing_rate_window_seconds"`
+	}{
+		report:                    report(r),
+		LagSeconds:                seconds(r.LagTime),
+		CatchUpSeconds:            seconds(r.CatchUp),
+		IndexingRateWindowSeconds: r.IndexingRateWindow.Seconds(),
+	})
+}
+
+// NewReport compares the output with the node.
+func NewReport(output Output, node Node) Report {
+	r := Report{
+		EarliestHeight:     output.Earliest,
+		LatestHeight:       output.Latest,
+		MissingBlocks:      output.Missing,
+		NodeHeight:         node.Latest,
+		IndexingRateWindow: output.RateWindow,
+	}
+	if !output.LatestTime.IsZero() {
+		r.LatestTime = &output.LatestTime
+	}
+	if !node.LatestTime.IsZero() {
+		r.NodeTime = &node.LatestTime
+	}
+
+	if node.Latest > output.Latest {
+		r.LagBlocks = node.Latest - output.Latest
+	}
+	if r.LatestTime != nil && r.NodeTime != nil {
+		lag := max(node.LatestTime.Sub(output.LatestTime), 0)
+		r.LagTime = &lag
+		// The rate at which the chain produced the blocks the output is missing
+		if r.LagBlocks > 0 && lag > 0 {
+			r.ChainRate = float64(r.LagBlocks) / lag.Seconds()
+		}
+	}
+	if output.RateWindow > 0 {
+		r.IndexingRate = float64(output.IndexedRecently) / output.RateWindow.Seconds()
+	}
+
+	switch {
+	case r.LagBlocks == 0:
+		catchUp := time.Duration(0)
+		r.CatchUp = &catchUp
+	case r.IndexingRate > r.ChainRate:
+		catchUp := time.Duration(float64(r.LagBlocks) / (r.IndexingRate - r.ChainRate) * float64(time.Second)).Round(time.Second)
+		r.CatchUp = &catchUp
+	}
+	return r
+}
+
+// Print writes the report in human-readable form.
+func (r Report) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Earliest stored height:\t%d\n", r.EarliestHeight)
+	fmt.Fprintf(tw, "Latest stored height:\t%d%s\n", r.LatestHeight, formatTime(r.LatestTime))
+	fmt.Fprintf(tw, "Missing blocks:\t%d\n", r.MissingBlocks)
+	fmt.Fprintf(tw, "Node height:\t%d%s\n", r.NodeHeight, formatTime(r.NodeTime))
+	if r.LagTime != nil {
+		fmt.Fprintf(tw, "Lag:\t%d blocks, %s\n", r.LagBlocks, r.LagTime.Round(time.Second))
+	} else {
+		fmt.Fprintf(tw, "Lag:\t%d blocks\n", r.LagBlocks)
+	}
+	fmt.Fprintf(tw, "Indexing rate:\t%.2f blocks/s over the last %s\n", r.IndexingRate, r.IndexingRateWindow)
+	fmt.Fprintf(tw, "Chain rate:\t%.2f blocks/s\n", r.ChainRate)
+	if r.CatchUp != nil {
+		fmt.Fprintf(tw, "Estimated catch-up:\t%s\n", *r.CatchUp)
+	} else {
+		fmt.Fprintf(tw, "Estimated catch-up:\tunknown, the output is not catching up at the current indexing rate\n")
+	}
+	return tw.Flush()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", t.UTC().Format(time.RFC3339))
+}
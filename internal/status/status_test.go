@@ -0,0 +1,85 @@
+package status_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/status"
+)
+
+var now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestNewReport(t *testing.T) {
+	tests := []struct {
+		name            string
+		output          status.Output
+		node            status.Node
+		expectedLag     uint64
+		expectedCatchUp *time.Duration
+	}{
+		{
+			name:            "catching up",
+			output:          status.Output{Earliest: 1, Latest: 900, LatestTime: now.Add(-100 * time.Second), IndexedRecently: 600, RateWindow: time.Minute},
+			node:            status.Node{Latest: 1000, LatestTime: now},
+			expectedLag:     100,
+			expectedCatchUp: durationPtr(11 * time.Second), // 100 blocks at 10 - 1 blocks/s
+		},
+		{
+			name:            "up to date",
+			output:          status.Output{Earliest: 1, Latest: 1000, LatestTime: now, RateWindow: time.Minute},
+			node:            status.Node{Latest: 1000, LatestTime: now},
+			expectedCatchUp: durationPtr(0),
+		},
+		{
+			name:        "not indexing",
+			output:      status.Output{Earliest: 1, Latest: 900, LatestTime: now.Add(-100 * time.Second), RateWindow: time.Minute},
+			node:        status.Node{Latest: 1000, LatestTime: now},
+			expectedLag: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := status.NewReport(tt.output, tt.node)
+			assert.Equal(t, tt.expectedLag, r.LagBlocks)
+			assert.Equal(t, tt.expectedCatchUp, r.CatchUp)
+		})
+	}
+}
+
+func TestReportJSON(t *testing.T) {
+	r := status.NewReport(
+		status.Output{Earliest: 1, Latest: 900, LatestTime: now.Add(-100 * time.Second), Missing: 2, IndexedRecently: 600, RateWindow: time.Minute},
+		status.Node{Latest: 1000, LatestTime: now},
+	)
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"earliest_height": 1,
+		"latest_height": 900,
+		"latest_time": "2026-01-01T11:58:20Z",
+		"missing_blocks": 2,
+		"node_height": 1000,
+		"node_time": "2026-01-01T12:00:00Z",
+		"lag_blocks": 100,
+		"lag_seconds": 100,
+		"indexing_rate": 10,
+		"chain_rate": 1,
+		"catch_up_seconds": 11,
+		"indexing_rate_window_seconds": 60
+	}`, string(data))
+
+	var out bytes.Buffer
+	require.NoError(t, r.Print(&out))
+	assert.Contains(t, out.String(), "100 blocks, 1m40s")
+	assert.Regexp(t, `Estimated catch-up:\s+11s`, out.String())
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
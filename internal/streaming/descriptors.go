@@ -0,0 +1,99 @@
+package streaming
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// The ABCI listener messages are declared without depending on the Cosmos SDK and CometBFT protos: the ABCI requests
+// and responses embedded in them are declared as bytes, which is wire compatible with embedded messages, so that they
+// are kept as raw protobuf.
+const streamingPackage = "cosmos.store.streaming.abci"
+
+// messages holds the descriptors of the ABCI listener messages.
+type messages struct {
+	listenFinalizeBlockRequest  protoreflect.MessageDescriptor
+	listenFinalizeBlockResponse protoreflect.MessageDescriptor
+	listenCommitRequest         protoreflect.MessageDescriptor
+	listenCommitResponse        protoreflect.MessageDescriptor
+}
+
+func field(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(name),
+		Number:   proto.Int32(number),
+		Type:     typ.Enum(),
+		Label:    label.Enum(),
+	}
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+	return f
+}
+
+func optional(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+	return field(name, number, typ, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, "")
+}
+
+// buildMessages builds the descriptors of the ABCI listener messages.
+func buildMessages() (*messages, error) {
+	const (
+		typeBytes   = descriptorpb.FieldDescriptorProto_TYPE_BYTES
+		typeInt64   = descriptorpb.FieldDescriptorProto_TYPE_INT64
+		typeString  = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		typeBool    = descriptorpb.FieldDescriptorProto_TYPE_BOOL
+		typeMessage = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("yaci/streaming/abci.proto"),
+		Package: proto.String(streamingPackage),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("ListenFinalizeBlockRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					optional("req", 1, typeBytes),
+					optional("res", 2, typeBytes),
+				},
+			},
+			{Name: proto.String("ListenFinalizeBlockResponse")},
+			{
+				Name: proto.String("ListenCommitRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					optional("block_height", 1, typeInt64),
+					optional("res", 2, typeBytes),
+					field("change_set", 3, typeMessage, descriptorpb.FieldDescriptorProto_LABEL_REPEATED, "."+streamingPackage+".StoreKVPair"),
+				},
+			},
+			{Name: proto.String("ListenCommitResponse")},
+			{
+				Name: proto.String("StoreKVPair"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					optional("store_key", 1, typeString),
+					optional("delete", 2, typeBool),
+					optional("key", 3, typeBytes),
+					optional("value", 4, typeBytes),
+				},
+			},
+		},
+	}
+
+	fd, err := protodesc.NewFile(file, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build streaming descriptors: %w", err)
+	}
+
+	msgs := fd.Messages()
+	return &messages{
+		listenFinalizeBlockRequest:  msgs.ByName("ListenFinalizeBlockRequest"),
+		listenFinalizeBlockResponse: msgs.ByName("ListenFinalizeBlockResponse"),
+		listenCommitRequest:         msgs.ByName("ListenCommitRequest"),
+		listenCommitResponse:        msgs.ByName("ListenCommitResponse"),
+	}, nil
+}
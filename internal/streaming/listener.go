@@ -0,0 +1,171 @@
+// Package streaming receives the ABCI events and state changes streamed by a node through an ADR-038 ABCI listener
+// plugin, and writes them to the output.
+package streaming
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/output"
+)
+
+// finalizeBlockHeightField is the number of the height field of the ABCI FinalizeBlock request.
+const finalizeBlockHeightField = 5
+
+// Listener implements the ABCI listener service called by the node. The FinalizeBlock data of a height is kept until
+// the node commits the height, and is then written along with the state changes of the height.
+type Listener struct {
+	msgs   *messages
+	writer output.StreamWriter
+
+	mu      sync.Mutex
+	pending map[uint64]*models.StreamedBlock
+}
+
+func NewListener(writer output.StreamWriter) (*Listener, error) {
+	msgs, err := buildMessages()
+	if err != nil {
+		return nil, err
+	}
+	return &Listener{msgs: msgs, writer: writer, pending: make(map[uint64]*models.StreamedBlock)}, nil
+}
+
+// ListenFinalizeBlock records the FinalizeBlock request and response of a height until it is committed.
+func (l *Listener) ListenFinalizeBlock(_ context.Context, req *dynamicpb.Message) error {
+	fields := req.Descriptor().Fields()
+	finalizeReq := req.Get(fields.ByName("req")).Bytes()
+	finalizeRes := req.Get(fields.ByName("res")).Bytes()
+
+	height, err := finalizeBlockHeight(finalizeReq)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending[height] = &models.StreamedBlock{
+		Height:                height,
+		FinalizeBlockRequest:  finalizeReq,
+		FinalizeBlockResponse: finalizeRes,
+	}
+	return nil
+}
+
+// ListenCommit writes the committed height, with its FinalizeBlock data and its state changes.
+// An error is returned to the node, which halts if it is configured to stop on listener errors, so that no height
+// is silently missed.
+func (l *Listener) ListenCommit(ctx context.Context, req *dynamicpb.Message) error {
+	fields := req.Descriptor().Fields()
+	height := uint64(req.Get(fields.ByName("block_height")).Int())
+
+	l.mu.Lock()
+	block, ok := l.pending[height]
+	for h := range l.pending {
+		if h <= height {
+			delete(l.pending, h)
+		}
+	}
+	l.mu.Unlock()
+
+	if !ok {
+		// The listener was started between the FinalizeBlock and the Commit of the height
+		slog.Warn("Committed height without FinalizeBlock data", "height", height)
+		block = &models.StreamedBlock{Height: height}
+	}
+	block.CommitResponse = req.Get(fields.ByName("res")).Bytes()
+
+	changeSet := req.Get(fields.ByName("change_set")).List()
+	for i := 0; i < changeSet.Len(); i++ {
+		pair := changeSet.Get(i).Message()
+		pairFields := pair.Descriptor().Fields()
+		block.StateChanges = append(block.StateChanges, &models.StateChange{
+			StoreKey: pair.Get(pairFields.ByName("store_key")).String(),
+			Delete:   pair.Get(pairFields.ByName("delete")).Bool(),
+			Key:      pair.Get(pairFields.ByName("key")).Bytes(),
+			Value:    pair.Get(pairFields.ByName("value")).Bytes(),
+		})
+	}
+
+	if err := l.writer.WriteStreamedBlock(ctx, block); err != nil {
+		slog.Error("Failed to write streamed block", "height", height, "error", err)
+		return err
+	}
+	slog.Debug("Wrote streamed block", "height", height, "state_changes", len(block.StateChanges))
+	return nil
+}
+
+// serviceDesc returns the description of the ABCI listener service served by the listener.
+func (l *Listener) serviceDesc() *grpc.ServiceDesc {
+	serviceName := streamingPackage + ".ABCIListenerService"
+	return &grpc.ServiceDesc{
+		ServiceName: serviceName,
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{
+			{
+				MethodName: "ListenFinalizeBlock",
+				Handler:    unaryHandler("/"+serviceName+"/ListenFinalizeBlock", l.msgs.listenFinalizeBlockRequest, l.msgs.listenFinalizeBlockResponse, l.ListenFinalizeBlock),
+			},
+			{
+				MethodName: "ListenCommit",
+				Handler:    unaryHandler("/"+serviceName+"/ListenCommit", l.msgs.listenCommitRequest, l.msgs.listenCommitResponse, l.ListenCommit),
+			},
+		},
+		Metadata: "cosmos/store/streaming/abci/grpc.proto",
+	}
+}
+
+// unaryHandler returns a gRPC method handler decoding its request as a dynamic message of the input descriptor and
+// answering with an empty message of the output descriptor.
+func unaryHandler(method string, input, output protoreflect.MessageDescriptor, handle func(context.Context, *dynamicpb.Message) error) grpc.MethodHandler {
+	return func(_ any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		req := dynamicpb.NewMessage(input)
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+
+		call := func(ctx context.Context, req any) (any, error) {
+			if err := handle(ctx, req.(*dynamicpb.Message)); err != nil {
+				return nil, err
+			}
+			return dynamicpb.NewMessage(output), nil
+		}
+		if interceptor == nil {
+			return call(ctx, req)
+		}
+		return interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, call)
+	}
+}
+
+// finalizeBlockHeight returns the height of a raw ABCI FinalizeBlock request.
+func finalizeBlockHeight(req []byte) (uint64, error) {
+	for len(req) > 0 {
+		num, typ, n := protowire.ConsumeTag(req)
+		if n < 0 {
+			return 0, fmt.Errorf("invalid FinalizeBlock request: %w", protowire.ParseError(n))
+		}
+		req = req[n:]
+
+		if num == finalizeBlockHeightField && typ == protowire.VarintType {
+			height, n := protowire.ConsumeVarint(req)
+			if n < 0 {
+				return 0, fmt.Errorf("invalid FinalizeBlock height: %w", protowire.ParseError(n))
+			}
+			return height, nil
+		}
+
+		n = protowire.ConsumeFieldValue(num, typ, req)
+		if n < 0 {
+			return 0, fmt.Errorf("invalid FinalizeBlock request: %w", protowire.ParseError(n))
+		}
+		req = req[n:]
+	}
+	return 0, fmt.Errorf("FinalizeBlock request has no height")
+}
@@ -0,0 +1,68 @@
+package streaming
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Handshake of the Cosmos SDK ABCI listener plugins, following the HashiCorp go-plugin protocol used by the node to
+// launch and connect to its streaming plugins.
+const (
+	MagicCookieKey      = "ABCI_LISTENER_PLUGIN"
+	MagicCookieValue    = "ef78114d-7bdf-411c-868f-347c99a78345"
+	coreProtocolVersion = 1
+	appProtocolVersion  = 1
+
+	// pluginHealthService is the health service name checked by the node to ping the plugin
+	pluginHealthService = "plugin"
+)
+
+// Serve serves the listener as a plugin launched by the node, until the context is cancelled or the node closes the
+// connection. The handshake line announcing the address of the plugin is written to stdout, which must not be used
+// for anything else.
+func Serve(ctx context.Context, listener *Listener, stdout io.Writer) error {
+	if os.Getenv(MagicCookieKey) != MagicCookieValue {
+		return fmt.Errorf("the stream command must be launched by a node as an ABCI listener plugin, see the streaming section of the README")
+	}
+	if os.Getenv("PLUGIN_CLIENT_CERT") != "" {
+		return fmt.Errorf("automatic mutual TLS between the node and its plugins is not supported")
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(pluginHealthService, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	server.RegisterService(listener.serviceDesc(), listener)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(lis)
+	}()
+
+	if _, err := fmt.Fprintf(stdout, "%d|%d|tcp|%s|grpc\n", coreProtocolVersion, appProtocolVersion, lis.Addr()); err != nil {
+		server.Stop()
+		return fmt.Errorf("failed to write plugin handshake: %w", err)
+	}
+	slog.Info("Serving ABCI listener plugin", "address", lis.Addr().String())
+
+	select {
+	case <-ctx.Done():
+		server.GracefulStop()
+		return nil
+	case err := <-errCh:
+		return err
+	}
+}
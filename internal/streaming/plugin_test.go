@@ -0,0 +1,62 @@
+package streaming_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/manifest-network/yaci/internal/streaming"
+)
+
+func TestServeRequiresMagicCookie(t *testing.T) {
+	t.Setenv(streaming.MagicCookieKey, "")
+
+	listener, err := streaming.NewListener(nil)
+	require.NoError(t, err)
+
+	err = streaming.Serve(context.Background(), listener, io.Discard)
+	assert.ErrorContains(t, err, "launched by a node")
+}
+
+func TestServeHandshake(t *testing.T) {
+	t.Setenv(streaming.MagicCookieKey, streaming.MagicCookieValue)
+
+	listener, err := streaming.NewListener(nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pr, pw := io.Pipe()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- streaming.Serve(ctx, listener, pw)
+	}()
+
+	line, err := bufio.NewReader(pr).ReadString('\n')
+	require.NoError(t, err)
+	parts := strings.Split(strings.TrimSpace(line), "|")
+	require.Len(t, parts, 5)
+	assert.Equal(t, []string{"1", "1", "tcp"}, parts[:3])
+	assert.Equal(t, "grpc", parts[4])
+
+	conn, err := grpc.NewClient(parts[3], grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	checkCtx, checkCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer checkCancel()
+	resp, err := healthpb.NewHealthClient(conn).Check(checkCtx, &healthpb.HealthCheckRequest{Service: "plugin"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	cancel()
+	assert.NoError(t, <-errCh)
+}
@@ -7,12 +7,10 @@ import (
 	"github.com/pkg/errors"
 )
 
-const statusMethod = "cosmos.base.node.v1beta1.Service.Status"
-const getLatestBlockMethod = "cosmos.base.tendermint.v1beta1.Service.GetLatestBlock"
-
 // GetLatestBlockHeightWithRetry retrieves the latest block height from the gRPC server with retry logic.
 // It tries the Status method (cosmos.base.node.v1beta1.Service.Status) first, and falls back to
-// GetLatestBlock (cosmos.base.tendermint.v1beta1.Service.GetLatestBlock) if Status is unavailable.
+// GetLatestBlock (cosmos.base.tendermint.v1beta1.Service.GetLatestBlock, or its variant exposed by the node) if Status
+// is unavailable.
 //
 // This fallback improves indexer robustness when:
 // - The node's Status endpoint is disabled or unavailable
@@ -25,7 +23,7 @@ func GetLatestBlockHeightWithRetry(gRPCClient *client.GRPCClient, maxRetries uin
 	// Try the standard Status method first
 	height, err := ExtractGRPCField(
 		gRPCClient,
-		statusMethod,
+		gRPCClient.Methods.Status,
 		maxRetries,
 		"height",
 		func(s string) (uint64, error) {
@@ -41,9 +39,9 @@ func GetLatestBlockHeightWithRetry(gRPCClient *client.GRPCClient, maxRetries uin
 	if err != nil {
 		height, err = ExtractGRPCField(
 			gRPCClient,
-			getLatestBlockMethod,
+			gRPCClient.Methods.LatestBlock,
 			maxRetries,
-			"sdk_block.header.height",
+			gRPCClient.Methods.BlockField+".header.height",
 			func(s string) (uint64, error) {
 				height, err := strconv.ParseUint(s, 10, 64)
 				if err != nil {
@@ -56,3 +54,26 @@ func GetLatestBlockHeightWithRetry(gRPCClient *client.GRPCClient, maxRetries uin
 
 	return height, err
 }
+
+// GetEarliestBlockHeightWithRetry retrieves the earliest height whose state is still stored by the node, from the
+// Status method (cosmos.base.node.v1beta1.Service.Status). It returns 1 if the node does not report it.
+func GetEarliestBlockHeightWithRetry(gRPCClient *client.GRPCClient, maxRetries uint) (uint64, error) {
+	height, err := ExtractGRPCField(
+		gRPCClient,
+		gRPCClient.Methods.Status,
+		maxRetries,
+		"earliest_store_height",
+		func(s string) (uint64, error) {
+			height, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				return 0, errors.WithMessage(err, "error parsing earliest store height")
+			}
+			return height, nil
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return max(height, 1), nil
+}
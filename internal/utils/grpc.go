@@ -1,14 +1,18 @@
 package utils
 
 import (
+	"bytes"
 	"fmt"
 	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/manifest-network/yaci/internal/client"
+	"github.com/manifest-network/yaci/internal/reflection"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/dynamicpb"
 )
@@ -49,6 +53,7 @@ func invokeGRPC(
 	fullMethodName string,
 	methodDescriptor protoreflect.MethodDescriptor,
 	inputParams []byte,
+	opts ...grpc.CallOption,
 ) (*dynamicpb.Message, error) {
 	// Create request and response messages
 	inputMsg := dynamicpb.NewMessage(methodDescriptor.Input())
@@ -63,7 +68,7 @@ func invokeGRPC(
 	}
 
 	// Make the gRPC call
-	err := gRPCClient.Conn.Invoke(gRPCClient.Ctx, fullMethodName, inputMsg, outputMsg)
+	err := gRPCClient.Invoke(fullMethodName, inputMsg, outputMsg, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -173,24 +178,73 @@ func GetGRPCResponse(
 	maxRetries uint,
 	inputParams []byte,
 ) ([]byte, error) {
-	return RetryGRPCCall(
+	response, _, err := GetGRPCResponseWithRaw(gRPCClient, methodFullName, maxRetries, inputParams, false)
+	return response, err
+}
+
+// GetGRPCResponseWithRaw calls a gRPC method and returns the response as JSON and, when keepRaw is set, the protobuf
+// bytes of the response as sent by the node, which are lost when re-encoding the decoded response.
+func GetGRPCResponseWithRaw(
+	gRPCClient *client.GRPCClient,
+	methodFullName string,
+	maxRetries uint,
+	inputParams []byte,
+	keepRaw bool,
+) ([]byte, []byte, error) {
+	type response struct {
+		json []byte
+		raw  []byte
+	}
+	resp, err := RetryGRPCCall(
 		gRPCClient,
 		methodFullName,
 		maxRetries,
-		func(fullMethodName string, methodDescriptor protoreflect.MethodDescriptor) ([]byte, error) {
-			outputMsg, err := invokeGRPC(gRPCClient, fullMethodName, methodDescriptor, inputParams)
+		func(fullMethodName string, methodDescriptor protoreflect.MethodDescriptor) (response, error) {
+			var opts []grpc.CallOption
+			codec := &rawCodec{}
+			if keepRaw {
+				opts = append(opts, grpc.ForceCodec(codec))
+			}
+			outputMsg, err := invokeGRPC(gRPCClient, fullMethodName, methodDescriptor, inputParams, opts...)
 			if err != nil {
-				return nil, fmt.Errorf("error invoking method: %w", err)
+				return response{}, fmt.Errorf("error invoking method: %w", err)
 			}
 
-			// Marshal the response to JSON
-			mo := protojson.MarshalOptions{Resolver: gRPCClient.Resolver}
-			responseBytes, err := mo.Marshal(outputMsg)
+			// Marshal the response to JSON, keeping the raw value of the types that cannot be resolved
+			responseBytes, err := reflection.MarshalJSON(outputMsg, gRPCClient.Resolver)
 			if err != nil {
-				return nil, fmt.Errorf("failed to marshal response: %w", err)
+				return response{}, fmt.Errorf("failed to marshal response: %w", err)
 			}
 
-			return responseBytes, nil
+			return response{json: responseBytes, raw: codec.raw}, nil
 		},
 	)
+	return resp.json, resp.raw, err
+}
+
+// rawCodec is the protobuf codec of a single call, keeping a copy of the bytes of the response it decodes.
+type rawCodec struct {
+	raw []byte
+}
+
+func (c *rawCodec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("failed to marshal, message is %T, want proto.Message", v)
+	}
+	return proto.Marshal(msg)
+}
+
+func (c *rawCodec) Unmarshal(data []byte, v any) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal, message is %T, want proto.Message", v)
+	}
+	// The buffer of the response is reused by gRPC once decoded
+	c.raw = bytes.Clone(data)
+	return proto.Unmarshal(data, msg)
+}
+
+func (c *rawCodec) Name() string {
+	return "proto"
 }
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/manifest-network/yaci/internal/client"
+)
+
+// maxPages guards against servers that keep returning the same pagination key.
+const maxPages = 100000
+
+// GetPaginatedGRPCResponse calls a paginated gRPC method until the server stops returning a next key.
+// The pagination request field is managed by this function and must not be part of inputParams.
+// Each page is returned as a separate JSON document.
+func GetPaginatedGRPCResponse(
+	gRPCClient *client.GRPCClient,
+	methodFullName string,
+	maxRetries uint,
+	inputParams map[string]interface{},
+) ([][]byte, error) {
+	params := make(map[string]interface{}, len(inputParams)+1)
+	for k, v := range inputParams {
+		params[k] = v
+	}
+
+	var pages [][]byte
+	var nextKey string
+	for page := 0; page < maxPages; page++ {
+		if nextKey != "" {
+			params["pagination"] = map[string]interface{}{"key": nextKey}
+		}
+
+		paramsBytes, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal input parameters: %w", err)
+		}
+
+		resp, err := GetGRPCResponse(gRPCClient, methodFullName, maxRetries, paramsBytes)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, resp)
+
+		var pagination struct {
+			Pagination struct {
+				NextKey string `json:"nextKey"`
+			} `json:"pagination"`
+		}
+		if err := json.Unmarshal(resp, &pagination); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal pagination: %w", err)
+		}
+
+		if pagination.Pagination.NextKey == "" || pagination.Pagination.NextKey == nextKey {
+			return pages, nil
+		}
+		nextKey = pagination.Pagination.NextKey
+	}
+
+	return nil, fmt.Errorf("too many pages returned by %s", methodFullName)
+}
@@ -0,0 +1,226 @@
+// Package verify audits the blocks and transactions stored by the indexer: height continuity, block hash linkage and
+// transaction hashes recomputed from the bytes of the blocks.
+package verify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/manifest-network/yaci/internal/models"
+)
+
+// Kinds of issues.
+const (
+	KindGap            = "gap"              // Heights missing between two stored blocks
+	KindInvalidData    = "invalid_data"     // Block data that cannot be decoded
+	KindHeight         = "height"           // Header height different from the stored height
+	KindHash           = "hash"             // Hash columns different from the hashes in the block data
+	KindLinkage        = "linkage"          // Previous block hash different from the hash of the stored previous block
+	KindMissingTx      = "missing_tx"       // Transaction of the block not stored
+	KindTxHashMismatch = "tx_hash_mismatch" // Transaction whose response reports another hash
+)
+
+// defaultBatchSize is the number of blocks read at once by default.
+const defaultBatchSize = 1000
+
+// Issue is a corruption found at a height.
+type Issue struct {
+	Height uint64
+	Kind   string
+	Detail string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("height %d: %s: %s", i.Height, i.Kind, i.Detail)
+}
+
+// Summary counts what was verified.
+type Summary struct {
+	Blocks       uint64
+	Transactions uint64
+	Issues       uint64
+}
+
+// Store reads the stored blocks and transactions.
+type Store interface {
+	// GetBlocks returns up to limit blocks above height after and up to stop, ordered by height.
+	// Hash and ParentHash are the values of the hash columns, empty if not set.
+	GetBlocks(ctx context.Context, after, stop uint64, limit int) ([]*models.Block, error)
+	// GetTransactionHashMismatches returns the stored transactions among hashes, and whether the hash reported by their
+	// response differs.
+	GetTransactionHashMismatches(ctx context.Context, hashes []string) (map[string]bool, error)
+	// GetExcludedRanges returns the ranges that are missing on purpose, e.g., skipped or pruned from the node.
+	GetExcludedRanges(ctx context.Context) ([]models.HeightRange, error)
+}
+
+// blockData holds the fields of the block data that are verified.
+type blockData struct {
+	BlockID struct {
+		Hash string `json:"hash"`
+	} `json:"blockId"`
+	Block struct {
+		Header struct {
+			Height      string `json:"height"`
+			LastBlockID struct {
+				Hash string `json:"hash"`
+			} `json:"lastBlockId"`
+		} `json:"header"`
+		Data struct {
+			Txs []string `json:"txs"`
+		} `json:"data"`
+	} `json:"block"`
+}
+
+// verifiedBlock is the part of a block kept to verify the next one and its transactions.
+type verifiedBlock struct {
+	height   uint64
+	hash     string
+	txHashes []string
+}
+
+// Verify verifies the blocks from start to stop, 0 for the latest, in batches of batchSize blocks, 0 for the default,
+// and reports the issues found in order of height.
+func Verify(ctx context.Context, store Store, start, stop uint64, batchSize int, report func(Issue)) (Summary, error) {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	excluded, err := store.GetExcludedRanges(ctx)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	var summary Summary
+	emit := func(i Issue) {
+		summary.Issues++
+		report(i)
+	}
+
+	var prev *verifiedBlock
+	after := start
+	if after > 0 {
+		after--
+	}
+	for {
+		blocks, err := store.GetBlocks(ctx, after, stop, batchSize)
+		if err != nil {
+			return summary, err
+		}
+		if len(blocks) == 0 {
+			return summary, nil
+		}
+
+		verified := make([]*verifiedBlock, 0, len(blocks))
+		for _, block := range blocks {
+			summary.Blocks++
+			v := verifyBlock(block, prev, excluded, emit)
+			verified = append(verified, v)
+			prev = v
+		}
+		n, err := verifyTransactions(ctx, store, verified, emit)
+		if err != nil {
+			return summary, err
+		}
+		summary.Transactions += n
+
+		after = blocks[len(blocks)-1].ID
+		if stop > 0 && after >= stop {
+			return summary, nil
+		}
+	}
+}
+
+// verifyBlock verifies a block against the previous stored block, nil for the first one.
+func verifyBlock(block *models.Block, prev *verifiedBlock, excluded []models.HeightRange, emit func(Issue)) *verifiedBlock {
+	if prev != nil && block.ID > prev.height+1 {
+		for _, gap := range subtract(models.HeightRange{Start: prev.height + 1, Stop: block.ID - 1}, excluded) {
+			emit(Issue{Height: gap.Start, Kind: KindGap, Detail: fmt.Sprintf("heights %d to %d are missing", gap.Start, gap.Stop)})
+		}
+	}
+
+	v := &verifiedBlock{height: block.ID}
+	var data blockData
+	if err := json.Unmarshal(block.Data, &data); err != nil {
+		emit(Issue{Height: block.ID, Kind: KindInvalidData, Detail: err.Error()})
+		return v
+	}
+	v.hash = data.BlockID.Hash
+
+	if height, err := strconv.ParseUint(data.Block.Header.Height, 10, 64); err != nil || height != block.ID {
+		emit(Issue{Height: block.ID, Kind: KindHeight, Detail: fmt.Sprintf("header height is %q", data.Block.Header.Height)})
+	}
+	if block.Hash != "" && block.Hash != data.BlockID.Hash {
+		emit(Issue{Height: block.ID, Kind: KindHash, Detail: fmt.Sprintf("hash column %s differs from block hash %s", block.Hash, data.BlockID.Hash)})
+	}
+	parentHash := data.Block.Header.LastBlockID.Hash
+	if block.ParentHash != "" && block.ParentHash != parentHash {
+		emit(Issue{Height: block.ID, Kind: KindHash, Detail: fmt.Sprintf("parent hash column %s differs from previous block hash %s", block.ParentHash, parentHash)})
+	}
+	if prev != nil && prev.height == block.ID-1 && prev.hash != "" && parentHash != prev.hash {
+		emit(Issue{Height: block.ID, Kind: KindLinkage, Detail: fmt.Sprintf("previous block hash %s differs from hash %s of block %d", parentHash, prev.hash, prev.height)})
+	}
+
+	for i, tx := range data.Block.Data.Txs {
+		bytes, err := base64.StdEncoding.DecodeString(tx)
+		if err != nil {
+			emit(Issue{Height: block.ID, Kind: KindInvalidData, Detail: fmt.Sprintf("transaction %d: %v", i, err)})
+			continue
+		}
+		hash := sha256.Sum256(bytes)
+		v.txHashes = append(v.txHashes, hex.EncodeToString(hash[:]))
+	}
+	return v
+}
+
+// verifyTransactions verifies that the transactions of the blocks are stored, and returns their number.
+func verifyTransactions(ctx context.Context, store Store, blocks []*verifiedBlock, emit func(Issue)) (uint64, error) {
+	var hashes []string
+	for _, b := range blocks {
+		hashes = append(hashes, b.txHashes...)
+	}
+	if len(hashes) == 0 {
+		return 0, nil
+	}
+
+	stored, err := store.GetTransactionHashMismatches(ctx, hashes)
+	if err != nil {
+		return 0, err
+	}
+	for _, b := range blocks {
+		for _, hash := range b.txHashes {
+			mismatch, ok := stored[hash]
+			if !ok {
+				emit(Issue{Height: b.height, Kind: KindMissingTx, Detail: fmt.Sprintf("transaction %s is not stored", hash)})
+			} else if mismatch {
+				emit(Issue{Height: b.height, Kind: KindTxHashMismatch, Detail: fmt.Sprintf("transaction %s has a response for another hash", hash)})
+			}
+		}
+	}
+	return uint64(len(hashes)), nil
+}
+
+// subtract returns the parts of r that are not in any of the excluded ranges.
+func subtract(r models.HeightRange, excluded []models.HeightRange) []models.HeightRange {
+	remaining := []models.HeightRange{r}
+	for _, e := range excluded {
+		var next []models.HeightRange
+		for _, part := range remaining {
+			if e.Stop < part.Start || e.Start > part.Stop {
+				next = append(next, part)
+				continue
+			}
+			if e.Start > part.Start {
+				next = append(next, models.HeightRange{Start: part.Start, Stop: e.Start - 1})
+			}
+			if e.Stop < part.Stop {
+				next = append(next, models.HeightRange{Start: e.Stop + 1, Stop: part.Stop})
+			}
+		}
+		remaining = next
+	}
+	return remaining
+}
@@ -0,0 +1,145 @@
+package verify_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manifest-network/yaci/internal/models"
+	"github.com/manifest-network/yaci/internal/verify"
+)
+
+// fakeStore is an in-memory store of blocks and transactions.
+type fakeStore struct {
+	blocks       []*models.Block
+	transactions map[string]bool
+	excluded     []models.HeightRange
+}
+
+func (s *fakeStore) GetBlocks(_ context.Context, after, stop uint64, limit int) ([]*models.Block, error) {
+	var blocks []*models.Block
+	for _, b := range s.blocks {
+		if b.ID > after && (stop == 0 || b.ID <= stop) && len(blocks) < limit {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks, nil
+}
+
+func (s *fakeStore) GetTransactionHashMismatches(_ context.Context, hashes []string) (map[string]bool, error) {
+	stored := make(map[string]bool)
+	for _, h := range hashes {
+		if mismatch, ok := s.transactions[h]; ok {
+			stored[h] = mismatch
+		}
+	}
+	return stored, nil
+}
+
+func (s *fakeStore) GetExcludedRanges(context.Context) ([]models.HeightRange, error) {
+	return s.excluded, nil
+}
+
+// block returns a block whose hash is HASH<height> and whose previous block hash is parentHash.
+func block(height uint64, parentHash string, txs ...string) *models.Block {
+	encoded := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		encoded = append(encoded, fmt.Sprintf("%q", base64.StdEncoding.EncodeToString([]byte(tx))))
+	}
+	hash := fmt.Sprintf("HASH%d", height)
+	data := fmt.Sprintf(`{"blockId": {"hash": %q}, "block": {"header": {"height": "%d", "lastBlockId": {"hash": %q}}, "data": {"txs": [%s]}}}`,
+		hash, height, parentHash, strings.Join(encoded, ","))
+	return &models.Block{ID: height, Data: []byte(data), Hash: hash, ParentHash: parentHash}
+}
+
+func txHash(tx string) string {
+	hash := sha256.Sum256([]byte(tx))
+	return hex.EncodeToString(hash[:])
+}
+
+func run(t *testing.T, store *fakeStore, start, stop uint64) (verify.Summary, []verify.Issue) {
+	var issues []verify.Issue
+	summary, err := verify.Verify(context.Background(), store, start, stop, 2, func(i verify.Issue) {
+		issues = append(issues, i)
+	})
+	require.NoError(t, err)
+	return summary, issues
+}
+
+func TestVerifyValid(t *testing.T) {
+	store := &fakeStore{
+		blocks:       []*models.Block{block(1, ""), block(2, "HASH1", "tx1", "tx2"), block(3, "HASH2", "tx3")},
+		transactions: map[string]bool{txHash("tx1"): false, txHash("tx2"): false, txHash("tx3"): false},
+	}
+
+	summary, issues := run(t, store, 0, 0)
+	assert.Empty(t, issues)
+	assert.Equal(t, verify.Summary{Blocks: 3, Transactions: 3}, summary)
+}
+
+func TestVerifyIssues(t *testing.T) {
+	corrupted := block(6, "HASH5")
+	corrupted.Data = []byte(`{"block": `)
+	wrongHeight := block(8, "HASH7")
+	wrongHeight.Data = []byte(`{"blockId": {"hash": "HASH8"}, "block": {"header": {"height": "80", "lastBlockId": {"hash": "HASH7"}}}}`)
+	wrongHashColumn := block(9, "HASH8")
+	wrongHashColumn.Hash = "OTHER"
+
+	store := &fakeStore{
+		blocks: []*models.Block{
+			block(1, ""),
+			block(2, "HASH1", "tx1", "tx2"),
+			block(5, "HASH4"),
+			corrupted,
+			block(7, "FORK"),
+			wrongHeight,
+			wrongHashColumn,
+			block(14, "HASH13"),
+		},
+		transactions: map[string]bool{txHash("tx1"): true},
+		excluded:     []models.HeightRange{{Start: 11, Stop: 12}},
+	}
+
+	summary, issues := run(t, store, 0, 0)
+	assert.Equal(t, uint64(8), summary.Blocks)
+	assert.Equal(t, uint64(2), summary.Transactions)
+	assert.Equal(t, uint64(len(issues)), summary.Issues)
+
+	var kinds []string
+	for _, i := range issues {
+		kinds = append(kinds, fmt.Sprintf("%d:%s", i.Height, i.Kind))
+	}
+	assert.Equal(t, []string{
+		"2:tx_hash_mismatch",
+		"2:missing_tx",
+		"3:gap",
+		"6:invalid_data",
+		"8:height",
+		"9:hash",
+		"10:gap",
+		"13:gap",
+	}, kinds)
+}
+
+func TestVerifyLinkage(t *testing.T) {
+	store := &fakeStore{blocks: []*models.Block{block(1, ""), block(2, "FORK"), block(3, "HASH2")}}
+
+	_, issues := run(t, store, 0, 0)
+	require.Len(t, issues, 1)
+	assert.Equal(t, verify.Issue{Height: 2, Kind: verify.KindLinkage, Detail: "previous block hash FORK differs from hash HASH1 of block 1"}, issues[0])
+}
+
+func TestVerifyRange(t *testing.T) {
+	store := &fakeStore{blocks: []*models.Block{block(1, ""), block(2, "FORK"), block(3, "HASH2"), block(4, "HASH3"), block(6, "HASH5")}}
+
+	summary, issues := run(t, store, 3, 4)
+	assert.Empty(t, issues)
+	assert.Equal(t, uint64(2), summary.Blocks)
+}
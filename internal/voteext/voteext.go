@@ -0,0 +1,298 @@
+// Package voteext decodes the vote extensions (ABCI 2.0) injected by the block proposers into their blocks.
+package voteext
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/manifest-network/yaci/internal/reflection"
+)
+
+// Field is the JSON field of the block the decoded extended commit info is added to
+const Field = "extendedCommitInfo"
+
+// Fields of tendermint.abci.ExtendedCommitInfo, ExtendedVoteInfo and Validator
+const (
+	commitRoundField       = 1
+	commitVotesField       = 2
+	voteValidatorField     = 1
+	voteExtensionField     = 3
+	voteSignatureField     = 4
+	voteBlockIDFlagField   = 5
+	validatorAddressField  = 1
+	validatorPowerField    = 3
+	validatorAddressLength = 20
+)
+
+var blockIDFlags = map[uint64]string{
+	0: "BLOCK_ID_FLAG_UNKNOWN",
+	1: "BLOCK_ID_FLAG_ABSENT",
+	2: "BLOCK_ID_FLAG_COMMIT",
+	3: "BLOCK_ID_FLAG_NIL",
+}
+
+// Decoder decodes the payload of the vote extensions of a chain into JSON.
+type Decoder interface {
+	Decode(extension []byte, resolver reflection.JSONResolver) (json.RawMessage, error)
+}
+
+// DecoderFunc is a function implementing Decoder.
+type DecoderFunc func(extension []byte, resolver reflection.JSONResolver) (json.RawMessage, error)
+
+func (f DecoderFunc) Decode(extension []byte, resolver reflection.JSONResolver) (json.RawMessage, error) {
+	return f(extension, resolver)
+}
+
+// Factory creates a decoder from the argument of its specification, which is empty if none is given.
+type Factory func(arg string) (Decoder, error)
+
+var registry = struct {
+	sync.RWMutex
+	factories map[string]Factory
+}{factories: map[string]Factory{
+	"json":  newJSONDecoder,
+	"proto": newProtoDecoder,
+}}
+
+// Register registers the factory of the decoders of a name, for the chains encoding their vote extensions in their own
+// format. It replaces the factory previously registered with the same name.
+func Register(name string, factory Factory) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.factories[name] = factory
+}
+
+// Names returns the sorted names of the registered decoders.
+func Names() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	names := make([]string, 0, len(registry.factories))
+	for name := range registry.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewDecoder returns the decoder of a specification, NAME or NAME:ARG, e.g., json or
+// proto:slinky.abci.v2.OracleVoteExtension. It returns nil if the specification is empty.
+func NewDecoder(spec string) (Decoder, error) {
+	if spec == "" {
+		return nil, nil
+	}
+
+	name, arg, _ := strings.Cut(spec, ":")
+	registry.RLock()
+	factory, ok := registry.factories[name]
+	registry.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown vote extension decoder %q, expected one of %s", name, strings.Join(Names(), ", "))
+	}
+	return factory(arg)
+}
+
+// newJSONDecoder creates a decoder of the vote extensions encoded in JSON.
+func newJSONDecoder(string) (Decoder, error) {
+	return DecoderFunc(func(extension []byte, _ reflection.JSONResolver) (json.RawMessage, error) {
+		if !json.Valid(extension) {
+			return nil, fmt.Errorf("vote extension is not valid JSON")
+		}
+		return extension, nil
+	}), nil
+}
+
+// newProtoDecoder creates a decoder of the vote extensions encoded as the protobuf message of the given full name,
+// resolved with the descriptors of the node.
+func newProtoDecoder(fullName string) (Decoder, error) {
+	if fullName == "" {
+		return nil, fmt.Errorf("the proto vote extension decoder requires a message name, e.g., proto:slinky.abci.v2.OracleVoteExtension")
+	}
+	return DecoderFunc(func(extension []byte, resolver reflection.JSONResolver) (json.RawMessage, error) {
+		mt, err := resolver.FindMessageByName(protoreflect.FullName(fullName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", fullName, err)
+		}
+		msg := mt.New()
+		if err := proto.Unmarshal(extension, msg.Interface()); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", fullName, err)
+		}
+		return reflection.MarshalJSON(msg.Interface(), resolver)
+	}), nil
+}
+
+// vote is the JSON of a vote of the extended commit info, in the format of protojson, along with its decoded
+// extension or the error decoding it.
+type vote struct {
+	Validator struct {
+		Address []byte `json:"address"`
+		Power   int64  `json:"power,string"`
+	} `json:"validator"`
+	VoteExtension      []byte          `json:"voteExtension,omitempty"`
+	ExtensionSignature []byte          `json:"extensionSignature,omitempty"`
+	BlockIDFlag        string          `json:"blockIdFlag"`
+	Decoded            json.RawMessage `json:"decoded,omitempty"`
+	DecodeError        string          `json:"decodeError,omitempty"`
+}
+
+type commitInfo struct {
+	Round int32   `json:"round"`
+	Votes []*vote `json:"votes"`
+}
+
+// DecodeBlock decodes the extended commit info the proposer injected as the first transaction of the block of a
+// GetBlockByHeight response, and adds it to the response in the Field field, with the vote extensions decoded by the
+// decoder. A vote extension that fails to decode is kept with the error. The data is returned as is if the first
+// transaction is not an extended commit info.
+func DecodeBlock(data []byte, decoder Decoder, resolver reflection.JSONResolver) ([]byte, error) {
+	if decoder == nil {
+		return data, nil
+	}
+
+	jsonDecoder := json.NewDecoder(bytes.NewReader(data))
+	jsonDecoder.UseNumber()
+	var block map[string]interface{}
+	if err := jsonDecoder.Decode(&block); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal block: %w", err)
+	}
+
+	blockData, _ := block["block"].(map[string]interface{})
+	dataField, _ := blockData["data"].(map[string]interface{})
+	txs, _ := dataField["txs"].([]interface{})
+	if len(txs) == 0 {
+		return data, nil
+	}
+	encoded, _ := txs[0].(string)
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return data, nil
+	}
+
+	info, err := parseCommitInfo(raw)
+	if err != nil {
+		return data, nil
+	}
+	for _, v := range info.Votes {
+		if len(v.VoteExtension) == 0 {
+			continue
+		}
+		decoded, err := decoder.Decode(v.VoteExtension, resolver)
+		if err != nil {
+			v.DecodeError = err.Error()
+			continue
+		}
+		v.Decoded = decoded
+	}
+
+	block[Field] = info
+	return json.Marshal(block)
+}
+
+// parseCommitInfo parses a tendermint.abci.ExtendedCommitInfo. It rejects the bytes that have other fields or no
+// vote, which tells it apart from the transactions.
+func parseCommitInfo(b []byte) (*commitInfo, error) {
+	info := &commitInfo{}
+	err := parseFields(b, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		switch {
+		case num == commitRoundField && typ == protowire.VarintType:
+			info.Round = int32(varint)
+		case num == commitVotesField && typ == protowire.BytesType:
+			v, err := parseVote(value)
+			if err != nil {
+				return err
+			}
+			info.Votes = append(info.Votes, v)
+		default:
+			return fmt.Errorf("unexpected field %d of type %d", num, typ)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(info.Votes) == 0 {
+		return nil, fmt.Errorf("no vote")
+	}
+	return info, nil
+}
+
+// parseVote parses a tendermint.abci.ExtendedVoteInfo.
+func parseVote(b []byte) (*vote, error) {
+	v := &vote{BlockIDFlag: blockIDFlags[0]}
+	err := parseFields(b, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		switch {
+		case num == voteValidatorField && typ == protowire.BytesType:
+			return parseFields(value, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+				switch {
+				case num == validatorAddressField && typ == protowire.BytesType:
+					v.Validator.Address = value
+				case num == validatorPowerField && typ == protowire.VarintType:
+					v.Validator.Power = int64(varint)
+				default:
+					return fmt.Errorf("unexpected validator field %d of type %d", num, typ)
+				}
+				return nil
+			})
+		case num == voteExtensionField && typ == protowire.BytesType:
+			v.VoteExtension = value
+		case num == voteSignatureField && typ == protowire.BytesType:
+			v.ExtensionSignature = value
+		case num == voteBlockIDFlagField && typ == protowire.VarintType:
+			flag, ok := blockIDFlags[varint]
+			if !ok {
+				return fmt.Errorf("unknown block ID flag %d", varint)
+			}
+			v.BlockIDFlag = flag
+		default:
+			return fmt.Errorf("unexpected vote field %d of type %d", num, typ)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(v.Validator.Address) != validatorAddressLength {
+		return nil, fmt.Errorf("invalid validator address length %d", len(v.Validator.Address))
+	}
+	return v, nil
+}
+
+// parseFields calls fn with each field of a protobuf message: the bytes of the length-delimited fields, or the value of
+// the varint fields. Fields of other wire types are rejected.
+func parseFields(b []byte, fn func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var value []byte
+		var varint uint64
+		switch typ {
+		case protowire.VarintType:
+			varint, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(b)
+		default:
+			return fmt.Errorf("unexpected wire type %d of field %d", typ, num)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if err := fn(num, typ, value, varint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package voteext_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/typepb"
+
+	"github.com/manifest-network/yaci/internal/reflection"
+	"github.com/manifest-network/yaci/internal/voteext"
+)
+
+// extendedVote encodes a tendermint.abci.ExtendedVoteInfo.
+func extendedVote(address []byte, power uint64, extension []byte, flag uint64) []byte {
+	var validator []byte
+	validator = protowire.AppendTag(validator, 1, protowire.BytesType)
+	validator = protowire.AppendBytes(validator, address)
+	validator = protowire.AppendTag(validator, 3, protowire.VarintType)
+	validator = protowire.AppendVarint(validator, power)
+
+	var vote []byte
+	vote = protowire.AppendTag(vote, 1, protowire.BytesType)
+	vote = protowire.AppendBytes(vote, validator)
+	if extension != nil {
+		vote = protowire.AppendTag(vote, 3, protowire.BytesType)
+		vote = protowire.AppendBytes(vote, extension)
+		vote = protowire.AppendTag(vote, 4, protowire.BytesType)
+		vote = protowire.AppendBytes(vote, []byte("signature"))
+	}
+	vote = protowire.AppendTag(vote, 5, protowire.VarintType)
+	return protowire.AppendVarint(vote, flag)
+}
+
+// extendedCommitInfo encodes a tendermint.abci.ExtendedCommitInfo.
+func extendedCommitInfo(round uint64, votes ...[]byte) []byte {
+	var info []byte
+	info = protowire.AppendTag(info, 1, protowire.VarintType)
+	info = protowire.AppendVarint(info, round)
+	for _, vote := range votes {
+		info = protowire.AppendTag(info, 2, protowire.BytesType)
+		info = protowire.AppendBytes(info, vote)
+	}
+	return info
+}
+
+// block returns the JSON of a GetBlockByHeight response with the given transactions.
+func block(txs ...[]byte) []byte {
+	encoded := make([]string, len(txs))
+	for i, tx := range txs {
+		encoded[i] = fmt.Sprintf("%q", base64.StdEncoding.EncodeToString(tx))
+	}
+	return []byte(fmt.Sprintf(`{"blockId":{"hash":"AA=="},"block":{"header":{"height":"10"},"data":{"txs":[%s]}}}`, strings.Join(encoded, ",")))
+}
+
+type decodedBlock struct {
+	ExtendedCommitInfo *struct {
+		Round int32 `json:"round"`
+		Votes []struct {
+			Validator struct {
+				Address []byte `json:"address"`
+				Power   string `json:"power"`
+			} `json:"validator"`
+			VoteExtension []byte          `json:"voteExtension"`
+			BlockIDFlag   string          `json:"blockIdFlag"`
+			Decoded       json.RawMessage `json:"decoded"`
+			DecodeError   string          `json:"decodeError"`
+		} `json:"votes"`
+	} `json:"extendedCommitInfo"`
+}
+
+func TestDecodeBlockJSON(t *testing.T) {
+	address := bytes.Repeat([]byte{0xab}, 20)
+	info := extendedCommitInfo(1,
+		extendedVote(address, 100, []byte(`{"prices":{"1":"42"}}`), 2),
+		extendedVote(address, 50, []byte(`not json`), 2),
+		extendedVote(address, 10, nil, 1),
+	)
+
+	decoder, err := voteext.NewDecoder("json")
+	require.NoError(t, err)
+	data, err := voteext.DecodeBlock(block(info, []byte("tx")), decoder, new(protoregistry.Types))
+	require.NoError(t, err)
+
+	var decoded decodedBlock
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.NotNil(t, decoded.ExtendedCommitInfo)
+	assert.Equal(t, int32(1), decoded.ExtendedCommitInfo.Round)
+	votes := decoded.ExtendedCommitInfo.Votes
+	require.Len(t, votes, 3)
+
+	assert.Equal(t, address, votes[0].Validator.Address)
+	assert.Equal(t, "100", votes[0].Validator.Power)
+	assert.Equal(t, "BLOCK_ID_FLAG_COMMIT", votes[0].BlockIDFlag)
+	assert.JSONEq(t, `{"prices":{"1":"42"}}`, string(votes[0].Decoded))
+
+	assert.Empty(t, votes[1].Decoded)
+	assert.Contains(t, votes[1].DecodeError, "not valid JSON")
+	assert.Equal(t, []byte("not json"), votes[1].VoteExtension)
+
+	assert.Equal(t, "BLOCK_ID_FLAG_ABSENT", votes[2].BlockIDFlag)
+	assert.Empty(t, votes[2].VoteExtension)
+	assert.Empty(t, votes[2].DecodeError)
+}
+
+func TestDecodeBlockProto(t *testing.T) {
+	types := new(protoregistry.Types)
+	require.NoError(t, types.RegisterMessage((&typepb.Option{}).ProtoReflect().Type()))
+	extension, err := proto.Marshal(&typepb.Option{Name: "price"})
+	require.NoError(t, err)
+	info := extendedCommitInfo(0, extendedVote(bytes.Repeat([]byte{1}, 20), 1, extension, 2))
+
+	decoder, err := voteext.NewDecoder("proto:google.protobuf.Option")
+	require.NoError(t, err)
+	data, err := voteext.DecodeBlock(block(info), decoder, types)
+	require.NoError(t, err)
+
+	var decoded decodedBlock
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.NotNil(t, decoded.ExtendedCommitInfo)
+	require.Len(t, decoded.ExtendedCommitInfo.Votes, 1)
+	assert.JSONEq(t, `{"name":"price"}`, string(decoded.ExtendedCommitInfo.Votes[0].Decoded))
+
+	// The extensions are kept with the error when their type cannot be resolved
+	data, err = voteext.DecodeBlock(block(info), decoder, new(protoregistry.Types))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Contains(t, decoded.ExtendedCommitInfo.Votes[0].DecodeError, "failed to resolve google.protobuf.Option")
+}
+
+func TestDecodeBlockUnchanged(t *testing.T) {
+	decoder, err := voteext.NewDecoder("json")
+	require.NoError(t, err)
+
+	// A transaction, whose first field is the body
+	var tx []byte
+	tx = protowire.AppendTag(tx, 1, protowire.BytesType)
+	tx = protowire.AppendBytes(tx, []byte("body"))
+
+	tests := []struct {
+		name    string
+		data    []byte
+		decoder voteext.Decoder
+	}{
+		{name: "no decoder", data: block(extendedCommitInfo(0, extendedVote(bytes.Repeat([]byte{1}, 20), 1, []byte("{}"), 2))), decoder: nil},
+		{name: "no transactions", data: block(), decoder: decoder},
+		{name: "transaction", data: block(tx), decoder: decoder},
+		{name: "no votes", data: block(extendedCommitInfo(0)), decoder: decoder},
+		{name: "invalid validator address", data: block(extendedCommitInfo(0, extendedVote([]byte{1}, 1, nil, 2))), decoder: decoder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := voteext.DecodeBlock(tt.data, tt.decoder, new(protoregistry.Types))
+			require.NoError(t, err)
+			assert.Equal(t, tt.data, data)
+		})
+	}
+}
+
+func TestNewDecoder(t *testing.T) {
+	decoder, err := voteext.NewDecoder("")
+	require.NoError(t, err)
+	assert.Nil(t, decoder)
+
+	_, err = voteext.NewDecoder("proto")
+	assert.ErrorContains(t, err, "requires a message name")
+
+	_, err = voteext.NewDecoder("unknown")
+	assert.ErrorContains(t, err, `unknown vote extension decoder "unknown"`)
+
+	voteext.Register("upper", func(arg string) (voteext.Decoder, error) {
+		return voteext.DecoderFunc(func(extension []byte, _ reflection.JSONResolver) (json.RawMessage, error) {
+			return json.Marshal(arg + string(bytes.ToUpper(extension)))
+		}), nil
+	})
+	assert.Contains(t, voteext.Names(), "upper")
+
+	decoder, err = voteext.NewDecoder("upper:price=")
+	require.NoError(t, err)
+	decoded, err := decoder.Decode([]byte("abc"), nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `"price=ABC"`, string(decoded))
+}